@@ -0,0 +1,169 @@
+// Package aclcache reads and writes a signed snapshot of the knot's keys and
+// push permissions. The knot refreshes it periodically, and keyfetch and guard
+// fall back to it when the internal API cannot be reached.
+package aclcache
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+var (
+	ErrBadSignature = errors.New("acl snapshot signature mismatch")
+	ErrStale        = errors.New("acl snapshot is too old")
+)
+
+type Key struct {
+	Did   string   `json:"did"`
+	Key   string   `json:"key"`
+	Repos []string `json:"repos"`
+}
+
+type Snapshot struct {
+	Generated time.Time `json:"generated"`
+	Keys      []Key     `json:"keys"`
+}
+
+// CanPush reports whether any key of the user was allowed to push to repo
+// when the snapshot was taken.
+func (s *Snapshot) CanPush(did, repo string) bool {
+	for _, k := range s.Keys {
+		if k.Did == did && slices.Contains(k.Repos, repo) {
+			return true
+		}
+	}
+	return false
+}
+
+type signedSnapshot struct {
+	Snapshot  json.RawMessage `json:"snapshot"`
+	Signature string          `json:"signature"`
+}
+
+// the snapshot and its public key are readable by everyone, since sshd runs
+// keyfetch as an unprivileged user; only the knot can sign it.
+const (
+	privateKeyMode = 0600
+	publicMode     = 0644
+)
+
+func privateKeyPath(path string) string {
+	return path + ".key"
+}
+
+func publicKeyPath(path string) string {
+	return path + ".pub"
+}
+
+// LoadOrCreateKey reads the signing key for the snapshot at path, creating
+// one if this is the first run.
+func LoadOrCreateKey(path string) (ed25519.PrivateKey, error) {
+	seed, err := os.ReadFile(privateKeyPath(path))
+	if err == nil {
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid signing key at %s", privateKeyPath(path))
+		}
+		priv := ed25519.NewKeyFromSeed(seed)
+		// rewrite the public half in case it went missing
+		return priv, os.WriteFile(publicKeyPath(path), priv.Public().(ed25519.PublicKey), publicMode)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(privateKeyPath(path), priv.Seed(), privateKeyMode); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(publicKeyPath(path), pub, publicMode); err != nil {
+		return nil, err
+	}
+
+	return priv, nil
+}
+
+// Write signs and atomically replaces the snapshot at path.
+func Write(path string, key ed25519.PrivateKey, s Snapshot) error {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	signed, err := json.Marshal(signedSnapshot{
+		Snapshot:  raw,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, raw)),
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(publicMode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(signed); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Read loads the snapshot at path, rejecting it if the signature does not
+// match or if it was generated more than maxAge ago.
+func Read(path string, maxAge time.Duration) (*Snapshot, error) {
+	pub, err := os.ReadFile(publicKeyPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key at %s", publicKeyPath(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var signed signedSnapshot
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(pub), signed.Snapshot, sig) {
+		return nil, ErrBadSignature
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(signed.Snapshot, &s); err != nil {
+		return nil, err
+	}
+
+	if time.Since(s.Generated) > maxAge {
+		return nil, fmt.Errorf("%w: generated %s", ErrStale, s.Generated.Format(time.RFC3339))
+	}
+
+	return &s, nil
+}
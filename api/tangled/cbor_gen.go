@@ -504,83 +504,215 @@ func (t *ActorProfile) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *FeedReaction) MarshalCBOR(w io.Writer) error {
+func (t *FeedEvent) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
+	fieldCount := 8
 
-	if _, err := cw.Write([]byte{164}); err != nil {
+	if t.Ref == nil {
+		fieldCount--
+	}
+
+	if t.Subject == nil {
+		fieldCount--
+	}
+
+	if t.Title == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
 		return err
 	}
 
-	// t.LexiconTypeID (string) (string)
-	if len("$type") > 1000000 {
-		return xerrors.Errorf("Value in field \"$type\" was too long")
+	// t.Ref (string) (string)
+	if t.Ref != nil {
+
+		if len("ref") > 1000000 {
+			return xerrors.Errorf("Value in field \"ref\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("ref"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("ref")); err != nil {
+			return err
+		}
+
+		if t.Ref == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Ref) > 1000000 {
+				return xerrors.Errorf("Value in field t.Ref was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Ref))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Ref)); err != nil {
+				return err
+			}
+		}
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
+	// t.Kind (string) (string)
+	if len("kind") > 1000000 {
+		return xerrors.Errorf("Value in field \"kind\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("kind"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("$type")); err != nil {
+	if _, err := cw.WriteString(string("kind")); err != nil {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.feed.reaction"))); err != nil {
+	if len(t.Kind) > 1000000 {
+		return xerrors.Errorf("Value in field t.Kind was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Kind))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.feed.reaction")); err != nil {
+	if _, err := cw.WriteString(string(t.Kind)); err != nil {
 		return err
 	}
 
-	// t.Subject (string) (string)
-	if len("subject") > 1000000 {
-		return xerrors.Errorf("Value in field \"subject\" was too long")
+	// t.Repo (string) (string)
+	if len("repo") > 1000000 {
+		return xerrors.Errorf("Value in field \"repo\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("subject")); err != nil {
+	if _, err := cw.WriteString(string("repo")); err != nil {
 		return err
 	}
 
-	if len(t.Subject) > 1000000 {
-		return xerrors.Errorf("Value in field t.Subject was too long")
+	if len(t.Repo) > 1000000 {
+		return xerrors.Errorf("Value in field t.Repo was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Subject)); err != nil {
+	if _, err := cw.WriteString(string(t.Repo)); err != nil {
 		return err
 	}
 
-	// t.Reaction (string) (string)
-	if len("reaction") > 1000000 {
-		return xerrors.Errorf("Value in field \"reaction\" was too long")
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("reaction"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("reaction")); err != nil {
+	if _, err := cw.WriteString(string("$type")); err != nil {
 		return err
 	}
 
-	if len(t.Reaction) > 1000000 {
-		return xerrors.Errorf("Value in field t.Reaction was too long")
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.feed.event"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.feed.event")); err != nil {
+		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Reaction))); err != nil {
+	// t.Actor (string) (string)
+	if len("actor") > 1000000 {
+		return xerrors.Errorf("Value in field \"actor\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("actor"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Reaction)); err != nil {
+	if _, err := cw.WriteString(string("actor")); err != nil {
+		return err
+	}
+
+	if len(t.Actor) > 1000000 {
+		return xerrors.Errorf("Value in field t.Actor was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Actor))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Actor)); err != nil {
 		return err
 	}
 
+	// t.Title (string) (string)
+	if t.Title != nil {
+
+		if len("title") > 1000000 {
+			return xerrors.Errorf("Value in field \"title\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("title"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("title")); err != nil {
+			return err
+		}
+
+		if t.Title == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Title) > 1000000 {
+				return xerrors.Errorf("Value in field t.Title was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Title))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Title)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// t.Subject (string) (string)
+	if t.Subject != nil {
+
+		if len("subject") > 1000000 {
+			return xerrors.Errorf("Value in field \"subject\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("subject")); err != nil {
+			return err
+		}
+
+		if t.Subject == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Subject) > 1000000 {
+				return xerrors.Errorf("Value in field t.Subject was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Subject))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Subject)); err != nil {
+				return err
+			}
+		}
+	}
+
 	// t.CreatedAt (string) (string)
 	if len("createdAt") > 1000000 {
 		return xerrors.Errorf("Value in field \"createdAt\" was too long")
@@ -606,8 +738,8 @@ func (t *FeedReaction) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
-func (t *FeedReaction) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = FeedReaction{}
+func (t *FeedEvent) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = FeedEvent{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -626,7 +758,7 @@ func (t *FeedReaction) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("FeedReaction: map struct too large (%d)", extra)
+		return fmt.Errorf("FeedEvent: map struct too large (%d)", extra)
 	}
 
 	n := extra
@@ -647,7 +779,50 @@ func (t *FeedReaction) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.LexiconTypeID (string) (string)
+		// t.Ref (string) (string)
+		case "ref":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Ref = (*string)(&sval)
+				}
+			}
+			// t.Kind (string) (string)
+		case "kind":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Kind = string(sval)
+			}
+			// t.Repo (string) (string)
+		case "repo":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Repo = string(sval)
+			}
+			// t.LexiconTypeID (string) (string)
 		case "$type":
 
 			{
@@ -658,8 +833,8 @@ func (t *FeedReaction) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.Subject (string) (string)
-		case "subject":
+			// t.Actor (string) (string)
+		case "actor":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -667,18 +842,49 @@ func (t *FeedReaction) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Subject = string(sval)
+				t.Actor = string(sval)
 			}
-			// t.Reaction (string) (string)
-		case "reaction":
+			// t.Title (string) (string)
+		case "title":
 
 			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				b, err := cr.ReadByte()
 				if err != nil {
 					return err
 				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
 
-				t.Reaction = string(sval)
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Title = (*string)(&sval)
+				}
+			}
+			// t.Subject (string) (string)
+		case "subject":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Subject = (*string)(&sval)
+				}
 			}
 			// t.CreatedAt (string) (string)
 		case "createdAt":
@@ -702,7 +908,7 @@ func (t *FeedReaction) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *FeedStar) MarshalCBOR(w io.Writer) error {
+func (t *FeedReaction) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -710,7 +916,7 @@ func (t *FeedStar) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{163}); err != nil {
+	if _, err := cw.Write([]byte{164}); err != nil {
 		return err
 	}
 
@@ -726,10 +932,10 @@ func (t *FeedStar) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.feed.star"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.feed.reaction"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.feed.star")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.feed.reaction")); err != nil {
 		return err
 	}
 
@@ -756,33 +962,56 @@ func (t *FeedStar) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.CreatedAt (string) (string)
-	if len("createdAt") > 1000000 {
-		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	// t.Reaction (string) (string)
+	if len("reaction") > 1000000 {
+		return xerrors.Errorf("Value in field \"reaction\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("reaction"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("createdAt")); err != nil {
+	if _, err := cw.WriteString(string("reaction")); err != nil {
 		return err
 	}
 
-	if len(t.CreatedAt) > 1000000 {
-		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	if len(t.Reaction) > 1000000 {
+		return xerrors.Errorf("Value in field t.Reaction was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Reaction))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+	if _, err := cw.WriteString(string(t.Reaction)); err != nil {
 		return err
 	}
-	return nil
-}
 
-func (t *FeedStar) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = FeedStar{}
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
+		return err
+	}
+
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *FeedReaction) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = FeedReaction{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -801,7 +1030,7 @@ func (t *FeedStar) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("FeedStar: map struct too large (%d)", extra)
+		return fmt.Errorf("FeedReaction: map struct too large (%d)", extra)
 	}
 
 	n := extra
@@ -844,6 +1073,17 @@ func (t *FeedStar) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.Subject = string(sval)
 			}
+			// t.Reaction (string) (string)
+		case "reaction":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Reaction = string(sval)
+			}
 			// t.CreatedAt (string) (string)
 		case "createdAt":
 
@@ -866,7 +1106,7 @@ func (t *FeedStar) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *GitRefUpdate) MarshalCBOR(w io.Writer) error {
+func (t *FeedStar) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -874,46 +1114,7 @@ func (t *GitRefUpdate) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{168}); err != nil {
-		return err
-	}
-
-	// t.Ref (string) (string)
-	if len("ref") > 1000000 {
-		return xerrors.Errorf("Value in field \"ref\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("ref"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("ref")); err != nil {
-		return err
-	}
-
-	if len(t.Ref) > 1000000 {
-		return xerrors.Errorf("Value in field t.Ref was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Ref))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Ref)); err != nil {
-		return err
-	}
-
-	// t.Meta (tangled.GitRefUpdate_Meta) (struct)
-	if len("meta") > 1000000 {
-		return xerrors.Errorf("Value in field \"meta\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("meta"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("meta")); err != nil {
-		return err
-	}
-
-	if err := t.Meta.MarshalCBOR(cw); err != nil {
+	if _, err := cw.Write([]byte{163}); err != nil {
 		return err
 	}
 
@@ -929,132 +1130,63 @@ func (t *GitRefUpdate) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.git.refUpdate"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("sh.tangled.git.refUpdate")); err != nil {
-		return err
-	}
-
-	// t.NewSha (string) (string)
-	if len("newSha") > 1000000 {
-		return xerrors.Errorf("Value in field \"newSha\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("newSha"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("newSha")); err != nil {
-		return err
-	}
-
-	if len(t.NewSha) > 1000000 {
-		return xerrors.Errorf("Value in field t.NewSha was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.NewSha))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.NewSha)); err != nil {
-		return err
-	}
-
-	// t.OldSha (string) (string)
-	if len("oldSha") > 1000000 {
-		return xerrors.Errorf("Value in field \"oldSha\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("oldSha"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("oldSha")); err != nil {
-		return err
-	}
-
-	if len(t.OldSha) > 1000000 {
-		return xerrors.Errorf("Value in field t.OldSha was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.OldSha))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.OldSha)); err != nil {
-		return err
-	}
-
-	// t.RepoDid (string) (string)
-	if len("repoDid") > 1000000 {
-		return xerrors.Errorf("Value in field \"repoDid\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repoDid"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("repoDid")); err != nil {
-		return err
-	}
-
-	if len(t.RepoDid) > 1000000 {
-		return xerrors.Errorf("Value in field t.RepoDid was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.RepoDid))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.feed.star"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.RepoDid)); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.feed.star")); err != nil {
 		return err
 	}
 
-	// t.RepoName (string) (string)
-	if len("repoName") > 1000000 {
-		return xerrors.Errorf("Value in field \"repoName\" was too long")
+	// t.Subject (string) (string)
+	if len("subject") > 1000000 {
+		return xerrors.Errorf("Value in field \"subject\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repoName"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("repoName")); err != nil {
+	if _, err := cw.WriteString(string("subject")); err != nil {
 		return err
 	}
 
-	if len(t.RepoName) > 1000000 {
-		return xerrors.Errorf("Value in field t.RepoName was too long")
+	if len(t.Subject) > 1000000 {
+		return xerrors.Errorf("Value in field t.Subject was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.RepoName))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.RepoName)); err != nil {
+	if _, err := cw.WriteString(string(t.Subject)); err != nil {
 		return err
 	}
 
-	// t.CommitterDid (string) (string)
-	if len("committerDid") > 1000000 {
-		return xerrors.Errorf("Value in field \"committerDid\" was too long")
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("committerDid"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("committerDid")); err != nil {
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
 		return err
 	}
 
-	if len(t.CommitterDid) > 1000000 {
-		return xerrors.Errorf("Value in field t.CommitterDid was too long")
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CommitterDid))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.CommitterDid)); err != nil {
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *GitRefUpdate) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = GitRefUpdate{}
+func (t *FeedStar) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = FeedStar{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -1073,12 +1205,12 @@ func (t *GitRefUpdate) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("GitRefUpdate: map struct too large (%d)", extra)
+		return fmt.Errorf("FeedStar: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 12)
+	nameBuf := make([]byte, 9)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -1094,38 +1226,7 @@ func (t *GitRefUpdate) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Ref (string) (string)
-		case "ref":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Ref = string(sval)
-			}
-			// t.Meta (tangled.GitRefUpdate_Meta) (struct)
-		case "meta":
-
-			{
-
-				b, err := cr.ReadByte()
-				if err != nil {
-					return err
-				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.Meta = new(GitRefUpdate_Meta)
-					if err := t.Meta.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.Meta pointer: %w", err)
-					}
-				}
-
-			}
-			// t.LexiconTypeID (string) (string)
+		// t.LexiconTypeID (string) (string)
 		case "$type":
 
 			{
@@ -1136,8 +1237,8 @@ func (t *GitRefUpdate) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.NewSha (string) (string)
-		case "newSha":
+			// t.Subject (string) (string)
+		case "subject":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -1145,10 +1246,10 @@ func (t *GitRefUpdate) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.NewSha = string(sval)
+				t.Subject = string(sval)
 			}
-			// t.OldSha (string) (string)
-		case "oldSha":
+			// t.CreatedAt (string) (string)
+		case "createdAt":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -1156,40 +1257,7 @@ func (t *GitRefUpdate) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.OldSha = string(sval)
-			}
-			// t.RepoDid (string) (string)
-		case "repoDid":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.RepoDid = string(sval)
-			}
-			// t.RepoName (string) (string)
-		case "repoName":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.RepoName = string(sval)
-			}
-			// t.CommitterDid (string) (string)
-		case "committerDid":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.CommitterDid = string(sval)
+				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -1202,16 +1270,20 @@ func (t *GitRefUpdate) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *GitRefUpdate_CommitCountBreakdown) MarshalCBOR(w io.Writer) error {
+func (t *Gist) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 1
+	fieldCount := 5
 
-	if t.ByEmail == nil {
+	if t.Description == nil {
+		fieldCount--
+	}
+
+	if t.ForkedFrom == nil {
 		fieldCount--
 	}
 
@@ -1219,39 +1291,142 @@ func (t *GitRefUpdate_CommitCountBreakdown) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.ByEmail ([]*tangled.GitRefUpdate_IndividualEmailCommitCount) (slice)
-	if t.ByEmail != nil {
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
+	}
 
-		if len("byEmail") > 1000000 {
-			return xerrors.Errorf("Value in field \"byEmail\" was too long")
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("$type")); err != nil {
+		return err
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.gist"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.gist")); err != nil {
+		return err
+	}
+
+	// t.Files ([]*tangled.Gist_File) (slice)
+	if len("files") > 1000000 {
+		return xerrors.Errorf("Value in field \"files\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("files"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("files")); err != nil {
+		return err
+	}
+
+	if len(t.Files) > 8192 {
+		return xerrors.Errorf("Slice value in field t.Files was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Files))); err != nil {
+		return err
+	}
+	for _, v := range t.Files {
+		if err := v.MarshalCBOR(cw); err != nil {
+			return err
 		}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("byEmail"))); err != nil {
+	}
+
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
+		return err
+	}
+
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+		return err
+	}
+
+	// t.ForkedFrom (string) (string)
+	if t.ForkedFrom != nil {
+
+		if len("forkedFrom") > 1000000 {
+			return xerrors.Errorf("Value in field \"forkedFrom\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("forkedFrom"))); err != nil {
 			return err
 		}
-		if _, err := cw.WriteString(string("byEmail")); err != nil {
+		if _, err := cw.WriteString(string("forkedFrom")); err != nil {
 			return err
 		}
 
-		if len(t.ByEmail) > 8192 {
-			return xerrors.Errorf("Slice value in field t.ByEmail was too long")
+		if t.ForkedFrom == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.ForkedFrom) > 1000000 {
+				return xerrors.Errorf("Value in field t.ForkedFrom was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.ForkedFrom))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.ForkedFrom)); err != nil {
+				return err
+			}
 		}
+	}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.ByEmail))); err != nil {
+	// t.Description (string) (string)
+	if t.Description != nil {
+
+		if len("description") > 1000000 {
+			return xerrors.Errorf("Value in field \"description\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("description"))); err != nil {
 			return err
 		}
-		for _, v := range t.ByEmail {
-			if err := v.MarshalCBOR(cw); err != nil {
+		if _, err := cw.WriteString(string("description")); err != nil {
+			return err
+		}
+
+		if t.Description == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
 				return err
 			}
+		} else {
+			if len(*t.Description) > 1000000 {
+				return xerrors.Errorf("Value in field t.Description was too long")
+			}
 
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Description))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Description)); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
-func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = GitRefUpdate_CommitCountBreakdown{}
+func (t *Gist) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Gist{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -1270,12 +1445,12 @@ func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err erro
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("GitRefUpdate_CommitCountBreakdown: map struct too large (%d)", extra)
+		return fmt.Errorf("Gist: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 7)
+	nameBuf := make([]byte, 11)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -1291,8 +1466,19 @@ func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err erro
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.ByEmail ([]*tangled.GitRefUpdate_IndividualEmailCommitCount) (slice)
-		case "byEmail":
+		// t.LexiconTypeID (string) (string)
+		case "$type":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.LexiconTypeID = string(sval)
+			}
+			// t.Files ([]*tangled.Gist_File) (slice)
+		case "files":
 
 			maj, extra, err = cr.ReadHeader()
 			if err != nil {
@@ -1300,7 +1486,7 @@ func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err erro
 			}
 
 			if extra > 8192 {
-				return fmt.Errorf("t.ByEmail: array too large (%d)", extra)
+				return fmt.Errorf("t.Files: array too large (%d)", extra)
 			}
 
 			if maj != cbg.MajArray {
@@ -1308,7 +1494,7 @@ func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err erro
 			}
 
 			if extra > 0 {
-				t.ByEmail = make([]*GitRefUpdate_IndividualEmailCommitCount, extra)
+				t.Files = make([]*Gist_File, extra)
 			}
 
 			for i := 0; i < int(extra); i++ {
@@ -1330,9 +1516,9 @@ func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err erro
 							if err := cr.UnreadByte(); err != nil {
 								return err
 							}
-							t.ByEmail[i] = new(GitRefUpdate_IndividualEmailCommitCount)
-							if err := t.ByEmail[i].UnmarshalCBOR(cr); err != nil {
-								return xerrors.Errorf("unmarshaling t.ByEmail[i] pointer: %w", err)
+							t.Files[i] = new(Gist_File)
+							if err := t.Files[i].UnmarshalCBOR(cr); err != nil {
+								return xerrors.Errorf("unmarshaling t.Files[i] pointer: %w", err)
 							}
 						}
 
@@ -1340,6 +1526,59 @@ func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err erro
 
 				}
 			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.CreatedAt = string(sval)
+			}
+			// t.ForkedFrom (string) (string)
+		case "forkedFrom":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.ForkedFrom = (*string)(&sval)
+				}
+			}
+			// t.Description (string) (string)
+		case "description":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Description = (*string)(&sval)
+				}
+			}
 
 		default:
 			// Field doesn't exist on this type, so ignore it
@@ -1351,7 +1590,7 @@ func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err erro
 
 	return nil
 }
-func (t *GitRefUpdate_IndividualEmailCommitCount) MarshalCBOR(w io.Writer) error {
+func (t *Gist_File) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -1363,55 +1602,56 @@ func (t *GitRefUpdate_IndividualEmailCommitCount) MarshalCBOR(w io.Writer) error
 		return err
 	}
 
-	// t.Count (int64) (int64)
-	if len("count") > 1000000 {
-		return xerrors.Errorf("Value in field \"count\" was too long")
+	// t.Contents (string) (string)
+	if len("contents") > 1000000 {
+		return xerrors.Errorf("Value in field \"contents\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("count"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("contents"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("count")); err != nil {
+	if _, err := cw.WriteString(string("contents")); err != nil {
 		return err
 	}
 
-	if t.Count >= 0 {
-		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.Count)); err != nil {
-			return err
-		}
-	} else {
-		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.Count-1)); err != nil {
-			return err
-		}
+	if len(t.Contents) > 1000000 {
+		return xerrors.Errorf("Value in field t.Contents was too long")
 	}
 
-	// t.Email (string) (string)
-	if len("email") > 1000000 {
-		return xerrors.Errorf("Value in field \"email\" was too long")
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Contents))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Contents)); err != nil {
+		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("email"))); err != nil {
+	// t.Filename (string) (string)
+	if len("filename") > 1000000 {
+		return xerrors.Errorf("Value in field \"filename\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("filename"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("email")); err != nil {
+	if _, err := cw.WriteString(string("filename")); err != nil {
 		return err
 	}
 
-	if len(t.Email) > 1000000 {
-		return xerrors.Errorf("Value in field t.Email was too long")
+	if len(t.Filename) > 1000000 {
+		return xerrors.Errorf("Value in field t.Filename was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Email))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Filename))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Email)); err != nil {
+	if _, err := cw.WriteString(string(t.Filename)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *GitRefUpdate_IndividualEmailCommitCount) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = GitRefUpdate_IndividualEmailCommitCount{}
+func (t *Gist_File) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Gist_File{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -1430,12 +1670,12 @@ func (t *GitRefUpdate_IndividualEmailCommitCount) UnmarshalCBOR(r io.Reader) (er
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("GitRefUpdate_IndividualEmailCommitCount: map struct too large (%d)", extra)
+		return fmt.Errorf("Gist_File: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 5)
+	nameBuf := make([]byte, 8)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -1451,34 +1691,19 @@ func (t *GitRefUpdate_IndividualEmailCommitCount) UnmarshalCBOR(r io.Reader) (er
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Count (int64) (int64)
-		case "count":
+		// t.Contents (string) (string)
+		case "contents":
+
 			{
-				maj, extra, err := cr.ReadHeader()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				var extraI int64
-				switch maj {
-				case cbg.MajUnsignedInt:
-					extraI = int64(extra)
-					if extraI < 0 {
-						return fmt.Errorf("int64 positive overflow")
-					}
-				case cbg.MajNegativeInt:
-					extraI = int64(extra)
-					if extraI < 0 {
-						return fmt.Errorf("int64 negative overflow")
-					}
-					extraI = -1 - extraI
-				default:
-					return fmt.Errorf("wrong type for int64 field: %d", maj)
-				}
 
-				t.Count = int64(extraI)
+				t.Contents = string(sval)
 			}
-			// t.Email (string) (string)
-		case "email":
+			// t.Filename (string) (string)
+		case "filename":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -1486,7 +1711,7 @@ func (t *GitRefUpdate_IndividualEmailCommitCount) UnmarshalCBOR(r io.Reader) (er
 					return err
 				}
 
-				t.Email = string(sval)
+				t.Filename = string(sval)
 			}
 
 		default:
@@ -1499,56 +1724,195 @@ func (t *GitRefUpdate_IndividualEmailCommitCount) UnmarshalCBOR(r io.Reader) (er
 
 	return nil
 }
-func (t *GitRefUpdate_LangBreakdown) MarshalCBOR(w io.Writer) error {
+func (t *GitRefUpdate) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 1
 
-	if t.Inputs == nil {
-		fieldCount--
+	if _, err := cw.Write([]byte{168}); err != nil {
+		return err
 	}
 
-	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+	// t.Ref (string) (string)
+	if len("ref") > 1000000 {
+		return xerrors.Errorf("Value in field \"ref\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("ref"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("ref")); err != nil {
 		return err
 	}
 
-	// t.Inputs ([]*tangled.GitRefUpdate_IndividualLanguageSize) (slice)
-	if t.Inputs != nil {
+	if len(t.Ref) > 1000000 {
+		return xerrors.Errorf("Value in field t.Ref was too long")
+	}
 
-		if len("inputs") > 1000000 {
-			return xerrors.Errorf("Value in field \"inputs\" was too long")
-		}
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Ref))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Ref)); err != nil {
+		return err
+	}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("inputs"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("inputs")); err != nil {
-			return err
-		}
+	// t.Meta (tangled.GitRefUpdate_Meta) (struct)
+	if len("meta") > 1000000 {
+		return xerrors.Errorf("Value in field \"meta\" was too long")
+	}
 
-		if len(t.Inputs) > 8192 {
-			return xerrors.Errorf("Slice value in field t.Inputs was too long")
-		}
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("meta"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("meta")); err != nil {
+		return err
+	}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Inputs))); err != nil {
-			return err
-		}
-		for _, v := range t.Inputs {
-			if err := v.MarshalCBOR(cw); err != nil {
-				return err
-			}
+	if err := t.Meta.MarshalCBOR(cw); err != nil {
+		return err
+	}
 
-		}
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("$type")); err != nil {
+		return err
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.git.refUpdate"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.git.refUpdate")); err != nil {
+		return err
+	}
+
+	// t.NewSha (string) (string)
+	if len("newSha") > 1000000 {
+		return xerrors.Errorf("Value in field \"newSha\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("newSha"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("newSha")); err != nil {
+		return err
+	}
+
+	if len(t.NewSha) > 1000000 {
+		return xerrors.Errorf("Value in field t.NewSha was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.NewSha))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.NewSha)); err != nil {
+		return err
+	}
+
+	// t.OldSha (string) (string)
+	if len("oldSha") > 1000000 {
+		return xerrors.Errorf("Value in field \"oldSha\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("oldSha"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("oldSha")); err != nil {
+		return err
+	}
+
+	if len(t.OldSha) > 1000000 {
+		return xerrors.Errorf("Value in field t.OldSha was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.OldSha))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.OldSha)); err != nil {
+		return err
+	}
+
+	// t.RepoDid (string) (string)
+	if len("repoDid") > 1000000 {
+		return xerrors.Errorf("Value in field \"repoDid\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repoDid"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("repoDid")); err != nil {
+		return err
+	}
+
+	if len(t.RepoDid) > 1000000 {
+		return xerrors.Errorf("Value in field t.RepoDid was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.RepoDid))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.RepoDid)); err != nil {
+		return err
+	}
+
+	// t.RepoName (string) (string)
+	if len("repoName") > 1000000 {
+		return xerrors.Errorf("Value in field \"repoName\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repoName"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("repoName")); err != nil {
+		return err
+	}
+
+	if len(t.RepoName) > 1000000 {
+		return xerrors.Errorf("Value in field t.RepoName was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.RepoName))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.RepoName)); err != nil {
+		return err
+	}
+
+	// t.CommitterDid (string) (string)
+	if len("committerDid") > 1000000 {
+		return xerrors.Errorf("Value in field \"committerDid\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("committerDid"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("committerDid")); err != nil {
+		return err
+	}
+
+	if len(t.CommitterDid) > 1000000 {
+		return xerrors.Errorf("Value in field t.CommitterDid was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CommitterDid))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.CommitterDid)); err != nil {
+		return err
 	}
 	return nil
 }
 
-func (t *GitRefUpdate_LangBreakdown) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = GitRefUpdate_LangBreakdown{}
+func (t *GitRefUpdate) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = GitRefUpdate{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -1567,12 +1931,12 @@ func (t *GitRefUpdate_LangBreakdown) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("GitRefUpdate_LangBreakdown: map struct too large (%d)", extra)
+		return fmt.Errorf("GitRefUpdate: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 6)
+	nameBuf := make([]byte, 12)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -1588,54 +1952,102 @@ func (t *GitRefUpdate_LangBreakdown) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Inputs ([]*tangled.GitRefUpdate_IndividualLanguageSize) (slice)
-		case "inputs":
+		// t.Ref (string) (string)
+		case "ref":
 
-			maj, extra, err = cr.ReadHeader()
-			if err != nil {
-				return err
-			}
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
 
-			if extra > 8192 {
-				return fmt.Errorf("t.Inputs: array too large (%d)", extra)
+				t.Ref = string(sval)
 			}
+			// t.Meta (tangled.GitRefUpdate_Meta) (struct)
+		case "meta":
 
-			if maj != cbg.MajArray {
-				return fmt.Errorf("expected cbor array")
-			}
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Meta = new(GitRefUpdate_Meta)
+					if err := t.Meta.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Meta pointer: %w", err)
+					}
+				}
 
-			if extra > 0 {
-				t.Inputs = make([]*GitRefUpdate_IndividualLanguageSize, extra)
 			}
+			// t.LexiconTypeID (string) (string)
+		case "$type":
 
-			for i := 0; i < int(extra); i++ {
-				{
-					var maj byte
-					var extra uint64
-					var err error
-					_ = maj
-					_ = extra
-					_ = err
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
 
-					{
+				t.LexiconTypeID = string(sval)
+			}
+			// t.NewSha (string) (string)
+		case "newSha":
 
-						b, err := cr.ReadByte()
-						if err != nil {
-							return err
-						}
-						if b != cbg.CborNull[0] {
-							if err := cr.UnreadByte(); err != nil {
-								return err
-							}
-							t.Inputs[i] = new(GitRefUpdate_IndividualLanguageSize)
-							if err := t.Inputs[i].UnmarshalCBOR(cr); err != nil {
-								return xerrors.Errorf("unmarshaling t.Inputs[i] pointer: %w", err)
-							}
-						}
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
 
-					}
+				t.NewSha = string(sval)
+			}
+			// t.OldSha (string) (string)
+		case "oldSha":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.OldSha = string(sval)
+			}
+			// t.RepoDid (string) (string)
+		case "repoDid":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.RepoDid = string(sval)
+			}
+			// t.RepoName (string) (string)
+		case "repoName":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.RepoName = string(sval)
+			}
+			// t.CommitterDid (string) (string)
+		case "committerDid":
 
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
 				}
+
+				t.CommitterDid = string(sval)
 			}
 
 		default:
@@ -1648,68 +2060,56 @@ func (t *GitRefUpdate_LangBreakdown) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *GitRefUpdate_IndividualLanguageSize) MarshalCBOR(w io.Writer) error {
+func (t *GitRefUpdate_CommitCountBreakdown) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
+	fieldCount := 1
 
-	if _, err := cw.Write([]byte{162}); err != nil {
-		return err
-	}
-
-	// t.Lang (string) (string)
-	if len("lang") > 1000000 {
-		return xerrors.Errorf("Value in field \"lang\" was too long")
+	if t.ByEmail == nil {
+		fieldCount--
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("lang"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("lang")); err != nil {
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
 		return err
 	}
 
-	if len(t.Lang) > 1000000 {
-		return xerrors.Errorf("Value in field t.Lang was too long")
-	}
+	// t.ByEmail ([]*tangled.GitRefUpdate_IndividualEmailCommitCount) (slice)
+	if t.ByEmail != nil {
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Lang))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Lang)); err != nil {
-		return err
-	}
+		if len("byEmail") > 1000000 {
+			return xerrors.Errorf("Value in field \"byEmail\" was too long")
+		}
 
-	// t.Size (int64) (int64)
-	if len("size") > 1000000 {
-		return xerrors.Errorf("Value in field \"size\" was too long")
-	}
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("byEmail"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("byEmail")); err != nil {
+			return err
+		}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("size"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("size")); err != nil {
-		return err
-	}
+		if len(t.ByEmail) > 8192 {
+			return xerrors.Errorf("Slice value in field t.ByEmail was too long")
+		}
 
-	if t.Size >= 0 {
-		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.Size)); err != nil {
+		if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.ByEmail))); err != nil {
 			return err
 		}
-	} else {
-		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.Size-1)); err != nil {
-			return err
+		for _, v := range t.ByEmail {
+			if err := v.MarshalCBOR(cw); err != nil {
+				return err
+			}
+
 		}
 	}
-
 	return nil
 }
 
-func (t *GitRefUpdate_IndividualLanguageSize) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = GitRefUpdate_IndividualLanguageSize{}
+func (t *GitRefUpdate_CommitCountBreakdown) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = GitRefUpdate_CommitCountBreakdown{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -1728,12 +2128,12 @@ func (t *GitRefUpdate_IndividualLanguageSize) UnmarshalCBOR(r io.Reader) (err er
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("GitRefUpdate_IndividualLanguageSize: map struct too large (%d)", extra)
+		return fmt.Errorf("GitRefUpdate_CommitCountBreakdown: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 4)
+	nameBuf := make([]byte, 7)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -1749,42 +2149,54 @@ func (t *GitRefUpdate_IndividualLanguageSize) UnmarshalCBOR(r io.Reader) (err er
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Lang (string) (string)
-		case "lang":
+		// t.ByEmail ([]*tangled.GitRefUpdate_IndividualEmailCommitCount) (slice)
+		case "byEmail":
 
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
 
-				t.Lang = string(sval)
+			if extra > 8192 {
+				return fmt.Errorf("t.ByEmail: array too large (%d)", extra)
 			}
-			// t.Size (int64) (int64)
-		case "size":
-			{
-				maj, extra, err := cr.ReadHeader()
-				if err != nil {
-					return err
-				}
-				var extraI int64
-				switch maj {
-				case cbg.MajUnsignedInt:
-					extraI = int64(extra)
-					if extraI < 0 {
-						return fmt.Errorf("int64 positive overflow")
-					}
-				case cbg.MajNegativeInt:
-					extraI = int64(extra)
-					if extraI < 0 {
-						return fmt.Errorf("int64 negative overflow")
+
+			if maj != cbg.MajArray {
+				return fmt.Errorf("expected cbor array")
+			}
+
+			if extra > 0 {
+				t.ByEmail = make([]*GitRefUpdate_IndividualEmailCommitCount, extra)
+			}
+
+			for i := 0; i < int(extra); i++ {
+				{
+					var maj byte
+					var extra uint64
+					var err error
+					_ = maj
+					_ = extra
+					_ = err
+
+					{
+
+						b, err := cr.ReadByte()
+						if err != nil {
+							return err
+						}
+						if b != cbg.CborNull[0] {
+							if err := cr.UnreadByte(); err != nil {
+								return err
+							}
+							t.ByEmail[i] = new(GitRefUpdate_IndividualEmailCommitCount)
+							if err := t.ByEmail[i].UnmarshalCBOR(cr); err != nil {
+								return xerrors.Errorf("unmarshaling t.ByEmail[i] pointer: %w", err)
+							}
+						}
+
 					}
-					extraI = -1 - extraI
-				default:
-					return fmt.Errorf("wrong type for int64 field: %d", maj)
-				}
 
-				t.Size = int64(extraI)
+				}
 			}
 
 		default:
@@ -1797,78 +2209,67 @@ func (t *GitRefUpdate_IndividualLanguageSize) UnmarshalCBOR(r io.Reader) (err er
 
 	return nil
 }
-func (t *GitRefUpdate_Meta) MarshalCBOR(w io.Writer) error {
+func (t *GitRefUpdate_IndividualEmailCommitCount) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 3
-
-	if t.LangBreakdown == nil {
-		fieldCount--
-	}
 
-	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+	if _, err := cw.Write([]byte{162}); err != nil {
 		return err
 	}
 
-	// t.CommitCount (tangled.GitRefUpdate_CommitCountBreakdown) (struct)
-	if len("commitCount") > 1000000 {
-		return xerrors.Errorf("Value in field \"commitCount\" was too long")
+	// t.Count (int64) (int64)
+	if len("count") > 1000000 {
+		return xerrors.Errorf("Value in field \"count\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("commitCount"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("count"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("commitCount")); err != nil {
+	if _, err := cw.WriteString(string("count")); err != nil {
 		return err
 	}
 
-	if err := t.CommitCount.MarshalCBOR(cw); err != nil {
-		return err
+	if t.Count >= 0 {
+		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.Count)); err != nil {
+			return err
+		}
+	} else {
+		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.Count-1)); err != nil {
+			return err
+		}
 	}
 
-	// t.IsDefaultRef (bool) (bool)
-	if len("isDefaultRef") > 1000000 {
-		return xerrors.Errorf("Value in field \"isDefaultRef\" was too long")
+	// t.Email (string) (string)
+	if len("email") > 1000000 {
+		return xerrors.Errorf("Value in field \"email\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("isDefaultRef"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("email"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("isDefaultRef")); err != nil {
+	if _, err := cw.WriteString(string("email")); err != nil {
 		return err
 	}
 
-	if err := cbg.WriteBool(w, t.IsDefaultRef); err != nil {
-		return err
+	if len(t.Email) > 1000000 {
+		return xerrors.Errorf("Value in field t.Email was too long")
 	}
 
-	// t.LangBreakdown (tangled.GitRefUpdate_LangBreakdown) (struct)
-	if t.LangBreakdown != nil {
-
-		if len("langBreakdown") > 1000000 {
-			return xerrors.Errorf("Value in field \"langBreakdown\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("langBreakdown"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("langBreakdown")); err != nil {
-			return err
-		}
-
-		if err := t.LangBreakdown.MarshalCBOR(cw); err != nil {
-			return err
-		}
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Email))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Email)); err != nil {
+		return err
 	}
 	return nil
 }
 
-func (t *GitRefUpdate_Meta) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = GitRefUpdate_Meta{}
+func (t *GitRefUpdate_IndividualEmailCommitCount) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = GitRefUpdate_IndividualEmailCommitCount{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -1887,12 +2288,12 @@ func (t *GitRefUpdate_Meta) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("GitRefUpdate_Meta: map struct too large (%d)", extra)
+		return fmt.Errorf("GitRefUpdate_IndividualEmailCommitCount: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 13)
+	nameBuf := make([]byte, 5)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -1908,63 +2309,42 @@ func (t *GitRefUpdate_Meta) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.CommitCount (tangled.GitRefUpdate_CommitCountBreakdown) (struct)
-		case "commitCount":
-
+		// t.Count (int64) (int64)
+		case "count":
 			{
-
-				b, err := cr.ReadByte()
+				maj, extra, err := cr.ReadHeader()
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
+				var extraI int64
+				switch maj {
+				case cbg.MajUnsignedInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 positive overflow")
 					}
-					t.CommitCount = new(GitRefUpdate_CommitCountBreakdown)
-					if err := t.CommitCount.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.CommitCount pointer: %w", err)
+				case cbg.MajNegativeInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 negative overflow")
 					}
+					extraI = -1 - extraI
+				default:
+					return fmt.Errorf("wrong type for int64 field: %d", maj)
 				}
 
+				t.Count = int64(extraI)
 			}
-			// t.IsDefaultRef (bool) (bool)
-		case "isDefaultRef":
-
-			maj, extra, err = cr.ReadHeader()
-			if err != nil {
-				return err
-			}
-			if maj != cbg.MajOther {
-				return fmt.Errorf("booleans must be major type 7")
-			}
-			switch extra {
-			case 20:
-				t.IsDefaultRef = false
-			case 21:
-				t.IsDefaultRef = true
-			default:
-				return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
-			}
-			// t.LangBreakdown (tangled.GitRefUpdate_LangBreakdown) (struct)
-		case "langBreakdown":
+			// t.Email (string) (string)
+		case "email":
 
 			{
-
-				b, err := cr.ReadByte()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.LangBreakdown = new(GitRefUpdate_LangBreakdown)
-					if err := t.LangBreakdown.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.LangBreakdown pointer: %w", err)
-					}
-				}
 
+				t.Email = string(sval)
 			}
 
 		default:
@@ -1977,87 +2357,56 @@ func (t *GitRefUpdate_Meta) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *GraphFollow) MarshalCBOR(w io.Writer) error {
+func (t *GitRefUpdate_LangBreakdown) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
+	fieldCount := 1
 
-	if _, err := cw.Write([]byte{163}); err != nil {
-		return err
-	}
-
-	// t.LexiconTypeID (string) (string)
-	if len("$type") > 1000000 {
-		return xerrors.Errorf("Value in field \"$type\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("$type")); err != nil {
-		return err
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.graph.follow"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("sh.tangled.graph.follow")); err != nil {
-		return err
-	}
-
-	// t.Subject (string) (string)
-	if len("subject") > 1000000 {
-		return xerrors.Errorf("Value in field \"subject\" was too long")
+	if t.Inputs == nil {
+		fieldCount--
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("subject")); err != nil {
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
 		return err
 	}
 
-	if len(t.Subject) > 1000000 {
-		return xerrors.Errorf("Value in field t.Subject was too long")
-	}
+	// t.Inputs ([]*tangled.GitRefUpdate_IndividualLanguageSize) (slice)
+	if t.Inputs != nil {
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Subject)); err != nil {
-		return err
-	}
+		if len("inputs") > 1000000 {
+			return xerrors.Errorf("Value in field \"inputs\" was too long")
+		}
 
-	// t.CreatedAt (string) (string)
-	if len("createdAt") > 1000000 {
-		return xerrors.Errorf("Value in field \"createdAt\" was too long")
-	}
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("inputs"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("inputs")); err != nil {
+			return err
+		}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("createdAt")); err != nil {
-		return err
-	}
+		if len(t.Inputs) > 8192 {
+			return xerrors.Errorf("Slice value in field t.Inputs was too long")
+		}
 
-	if len(t.CreatedAt) > 1000000 {
-		return xerrors.Errorf("Value in field t.CreatedAt was too long")
-	}
+		if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Inputs))); err != nil {
+			return err
+		}
+		for _, v := range t.Inputs {
+			if err := v.MarshalCBOR(cw); err != nil {
+				return err
+			}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
-		return err
+		}
 	}
 	return nil
 }
 
-func (t *GraphFollow) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = GraphFollow{}
+func (t *GitRefUpdate_LangBreakdown) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = GitRefUpdate_LangBreakdown{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -2076,12 +2425,12 @@ func (t *GraphFollow) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("GraphFollow: map struct too large (%d)", extra)
+		return fmt.Errorf("GitRefUpdate_LangBreakdown: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 9)
+	nameBuf := make([]byte, 6)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -2097,51 +2446,67 @@ func (t *GraphFollow) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.LexiconTypeID (string) (string)
-		case "$type":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
+		// t.Inputs ([]*tangled.GitRefUpdate_IndividualLanguageSize) (slice)
+		case "inputs":
 
-				t.LexiconTypeID = string(sval)
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
 			}
-			// t.Subject (string) (string)
-		case "subject":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
 
-				t.Subject = string(sval)
+			if extra > 8192 {
+				return fmt.Errorf("t.Inputs: array too large (%d)", extra)
 			}
-			// t.CreatedAt (string) (string)
-		case "createdAt":
 
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.CreatedAt = string(sval)
+			if maj != cbg.MajArray {
+				return fmt.Errorf("expected cbor array")
 			}
 
-		default:
-			// Field doesn't exist on this type, so ignore it
-			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
-				return err
+			if extra > 0 {
+				t.Inputs = make([]*GitRefUpdate_IndividualLanguageSize, extra)
 			}
-		}
+
+			for i := 0; i < int(extra); i++ {
+				{
+					var maj byte
+					var extra uint64
+					var err error
+					_ = maj
+					_ = extra
+					_ = err
+
+					{
+
+						b, err := cr.ReadByte()
+						if err != nil {
+							return err
+						}
+						if b != cbg.CborNull[0] {
+							if err := cr.UnreadByte(); err != nil {
+								return err
+							}
+							t.Inputs[i] = new(GitRefUpdate_IndividualLanguageSize)
+							if err := t.Inputs[i].UnmarshalCBOR(cr); err != nil {
+								return xerrors.Errorf("unmarshaling t.Inputs[i] pointer: %w", err)
+							}
+						}
+
+					}
+
+				}
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
-func (t *Knot) MarshalCBOR(w io.Writer) error {
+func (t *GitRefUpdate_IndividualLanguageSize) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -2153,52 +2518,56 @@ func (t *Knot) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.LexiconTypeID (string) (string)
-	if len("$type") > 1000000 {
-		return xerrors.Errorf("Value in field \"$type\" was too long")
+	// t.Lang (string) (string)
+	if len("lang") > 1000000 {
+		return xerrors.Errorf("Value in field \"lang\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("lang"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("$type")); err != nil {
+	if _, err := cw.WriteString(string("lang")); err != nil {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.knot"))); err != nil {
+	if len(t.Lang) > 1000000 {
+		return xerrors.Errorf("Value in field t.Lang was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Lang))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.knot")); err != nil {
+	if _, err := cw.WriteString(string(t.Lang)); err != nil {
 		return err
 	}
 
-	// t.CreatedAt (string) (string)
-	if len("createdAt") > 1000000 {
-		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	// t.Size (int64) (int64)
+	if len("size") > 1000000 {
+		return xerrors.Errorf("Value in field \"size\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("size"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("createdAt")); err != nil {
+	if _, err := cw.WriteString(string("size")); err != nil {
 		return err
 	}
 
-	if len(t.CreatedAt) > 1000000 {
-		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	if t.Size >= 0 {
+		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.Size)); err != nil {
+			return err
+		}
+	} else {
+		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.Size-1)); err != nil {
+			return err
+		}
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
-		return err
-	}
 	return nil
 }
 
-func (t *Knot) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Knot{}
+func (t *GitRefUpdate_IndividualLanguageSize) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = GitRefUpdate_IndividualLanguageSize{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -2217,12 +2586,12 @@ func (t *Knot) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Knot: map struct too large (%d)", extra)
+		return fmt.Errorf("GitRefUpdate_IndividualLanguageSize: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 9)
+	nameBuf := make([]byte, 4)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -2238,8 +2607,8 @@ func (t *Knot) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.LexiconTypeID (string) (string)
-		case "$type":
+		// t.Lang (string) (string)
+		case "lang":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -2247,18 +2616,33 @@ func (t *Knot) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.LexiconTypeID = string(sval)
+				t.Lang = string(sval)
 			}
-			// t.CreatedAt (string) (string)
-		case "createdAt":
-
+			// t.Size (int64) (int64)
+		case "size":
 			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				maj, extra, err := cr.ReadHeader()
 				if err != nil {
 					return err
 				}
+				var extraI int64
+				switch maj {
+				case cbg.MajUnsignedInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 positive overflow")
+					}
+				case cbg.MajNegativeInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 negative overflow")
+					}
+					extraI = -1 - extraI
+				default:
+					return fmt.Errorf("wrong type for int64 field: %d", maj)
+				}
 
-				t.CreatedAt = string(sval)
+				t.Size = int64(extraI)
 			}
 
 		default:
@@ -2271,110 +2655,78 @@ func (t *Knot) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *KnotMember) MarshalCBOR(w io.Writer) error {
+func (t *GitRefUpdate_Meta) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
+	fieldCount := 3
 
-	if _, err := cw.Write([]byte{164}); err != nil {
-		return err
-	}
-
-	// t.LexiconTypeID (string) (string)
-	if len("$type") > 1000000 {
-		return xerrors.Errorf("Value in field \"$type\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("$type")); err != nil {
-		return err
+	if t.LangBreakdown == nil {
+		fieldCount--
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.knot.member"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("sh.tangled.knot.member")); err != nil {
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
 		return err
 	}
 
-	// t.Domain (string) (string)
-	if len("domain") > 1000000 {
-		return xerrors.Errorf("Value in field \"domain\" was too long")
+	// t.CommitCount (tangled.GitRefUpdate_CommitCountBreakdown) (struct)
+	if len("commitCount") > 1000000 {
+		return xerrors.Errorf("Value in field \"commitCount\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("domain"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("commitCount"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("domain")); err != nil {
+	if _, err := cw.WriteString(string("commitCount")); err != nil {
 		return err
 	}
 
-	if len(t.Domain) > 1000000 {
-		return xerrors.Errorf("Value in field t.Domain was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Domain))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Domain)); err != nil {
+	if err := t.CommitCount.MarshalCBOR(cw); err != nil {
 		return err
 	}
 
-	// t.Subject (string) (string)
-	if len("subject") > 1000000 {
-		return xerrors.Errorf("Value in field \"subject\" was too long")
+	// t.IsDefaultRef (bool) (bool)
+	if len("isDefaultRef") > 1000000 {
+		return xerrors.Errorf("Value in field \"isDefaultRef\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("isDefaultRef"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("subject")); err != nil {
+	if _, err := cw.WriteString(string("isDefaultRef")); err != nil {
 		return err
 	}
 
-	if len(t.Subject) > 1000000 {
-		return xerrors.Errorf("Value in field t.Subject was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Subject)); err != nil {
+	if err := cbg.WriteBool(w, t.IsDefaultRef); err != nil {
 		return err
 	}
 
-	// t.CreatedAt (string) (string)
-	if len("createdAt") > 1000000 {
-		return xerrors.Errorf("Value in field \"createdAt\" was too long")
-	}
+	// t.LangBreakdown (tangled.GitRefUpdate_LangBreakdown) (struct)
+	if t.LangBreakdown != nil {
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("createdAt")); err != nil {
-		return err
-	}
+		if len("langBreakdown") > 1000000 {
+			return xerrors.Errorf("Value in field \"langBreakdown\" was too long")
+		}
 
-	if len(t.CreatedAt) > 1000000 {
-		return xerrors.Errorf("Value in field t.CreatedAt was too long")
-	}
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("langBreakdown"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("langBreakdown")); err != nil {
+			return err
+		}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
-		return err
+		if err := t.LangBreakdown.MarshalCBOR(cw); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (t *KnotMember) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = KnotMember{}
+func (t *GitRefUpdate_Meta) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = GitRefUpdate_Meta{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -2393,12 +2745,12 @@ func (t *KnotMember) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("KnotMember: map struct too large (%d)", extra)
+		return fmt.Errorf("GitRefUpdate_Meta: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 9)
+	nameBuf := make([]byte, 13)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -2414,49 +2766,63 @@ func (t *KnotMember) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.LexiconTypeID (string) (string)
-		case "$type":
+		// t.CommitCount (tangled.GitRefUpdate_CommitCountBreakdown) (struct)
+		case "commitCount":
 
 			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
 
-				t.LexiconTypeID = string(sval)
-			}
-			// t.Domain (string) (string)
-		case "domain":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				b, err := cr.ReadByte()
 				if err != nil {
 					return err
 				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.CommitCount = new(GitRefUpdate_CommitCountBreakdown)
+					if err := t.CommitCount.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.CommitCount pointer: %w", err)
+					}
+				}
 
-				t.Domain = string(sval)
 			}
-			// t.Subject (string) (string)
-		case "subject":
+			// t.IsDefaultRef (bool) (bool)
+		case "isDefaultRef":
 
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Subject = string(sval)
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
 			}
-			// t.CreatedAt (string) (string)
-		case "createdAt":
+			if maj != cbg.MajOther {
+				return fmt.Errorf("booleans must be major type 7")
+			}
+			switch extra {
+			case 20:
+				t.IsDefaultRef = false
+			case 21:
+				t.IsDefaultRef = true
+			default:
+				return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
+			}
+			// t.LangBreakdown (tangled.GitRefUpdate_LangBreakdown) (struct)
+		case "langBreakdown":
 
 			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+
+				b, err := cr.ReadByte()
 				if err != nil {
 					return err
 				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.LangBreakdown = new(GitRefUpdate_LangBreakdown)
+					if err := t.LangBreakdown.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.LangBreakdown pointer: %w", err)
+					}
+				}
 
-				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -2469,7 +2835,7 @@ func (t *KnotMember) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *Pipeline) MarshalCBOR(w io.Writer) error {
+func (t *GraphFollow) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -2493,59 +2859,63 @@ func (t *Pipeline) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.pipeline"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.graph.follow"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.pipeline")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.graph.follow")); err != nil {
 		return err
 	}
 
-	// t.Workflows ([]*tangled.Pipeline_Workflow) (slice)
-	if len("workflows") > 1000000 {
-		return xerrors.Errorf("Value in field \"workflows\" was too long")
+	// t.Subject (string) (string)
+	if len("subject") > 1000000 {
+		return xerrors.Errorf("Value in field \"subject\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("workflows"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("workflows")); err != nil {
+	if _, err := cw.WriteString(string("subject")); err != nil {
 		return err
 	}
 
-	if len(t.Workflows) > 8192 {
-		return xerrors.Errorf("Slice value in field t.Workflows was too long")
+	if len(t.Subject) > 1000000 {
+		return xerrors.Errorf("Value in field t.Subject was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Workflows))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
 		return err
 	}
-	for _, v := range t.Workflows {
-		if err := v.MarshalCBOR(cw); err != nil {
-			return err
-		}
-
+	if _, err := cw.WriteString(string(t.Subject)); err != nil {
+		return err
 	}
 
-	// t.TriggerMetadata (tangled.Pipeline_TriggerMetadata) (struct)
-	if len("triggerMetadata") > 1000000 {
-		return xerrors.Errorf("Value in field \"triggerMetadata\" was too long")
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("triggerMetadata"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("triggerMetadata")); err != nil {
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
 		return err
 	}
 
-	if err := t.TriggerMetadata.MarshalCBOR(cw); err != nil {
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *Pipeline) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline{}
+func (t *GraphFollow) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = GraphFollow{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -2564,12 +2934,12 @@ func (t *Pipeline) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline: map struct too large (%d)", extra)
+		return fmt.Errorf("GraphFollow: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 15)
+	nameBuf := make([]byte, 9)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -2596,74 +2966,27 @@ func (t *Pipeline) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.Workflows ([]*tangled.Pipeline_Workflow) (slice)
-		case "workflows":
-
-			maj, extra, err = cr.ReadHeader()
-			if err != nil {
-				return err
-			}
-
-			if extra > 8192 {
-				return fmt.Errorf("t.Workflows: array too large (%d)", extra)
-			}
-
-			if maj != cbg.MajArray {
-				return fmt.Errorf("expected cbor array")
-			}
-
-			if extra > 0 {
-				t.Workflows = make([]*Pipeline_Workflow, extra)
-			}
-
-			for i := 0; i < int(extra); i++ {
-				{
-					var maj byte
-					var extra uint64
-					var err error
-					_ = maj
-					_ = extra
-					_ = err
-
-					{
-
-						b, err := cr.ReadByte()
-						if err != nil {
-							return err
-						}
-						if b != cbg.CborNull[0] {
-							if err := cr.UnreadByte(); err != nil {
-								return err
-							}
-							t.Workflows[i] = new(Pipeline_Workflow)
-							if err := t.Workflows[i].UnmarshalCBOR(cr); err != nil {
-								return xerrors.Errorf("unmarshaling t.Workflows[i] pointer: %w", err)
-							}
-						}
-
-					}
+			// t.Subject (string) (string)
+		case "subject":
 
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
 				}
+
+				t.Subject = string(sval)
 			}
-			// t.TriggerMetadata (tangled.Pipeline_TriggerMetadata) (struct)
-		case "triggerMetadata":
+			// t.CreatedAt (string) (string)
+		case "createdAt":
 
 			{
-
-				b, err := cr.ReadByte()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.TriggerMetadata = new(Pipeline_TriggerMetadata)
-					if err := t.TriggerMetadata.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.TriggerMetadata pointer: %w", err)
-					}
-				}
 
+				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -2676,238 +2999,137 @@ func (t *Pipeline) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *Pipeline_CloneOpts) MarshalCBOR(w io.Writer) error {
+func (t *Knot) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
+	fieldCount := 4
 
-	if _, err := cw.Write([]byte{163}); err != nil {
-		return err
+	if t.DefaultBranch == nil {
+		fieldCount--
 	}
 
-	// t.Skip (bool) (bool)
-	if len("skip") > 1000000 {
-		return xerrors.Errorf("Value in field \"skip\" was too long")
+	if t.RepoCreate == nil {
+		fieldCount--
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("skip"))); err != nil {
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("skip")); err != nil {
-		return err
+
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
 	}
 
-	if err := cbg.WriteBool(w, t.Skip); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
 		return err
 	}
-
-	// t.Depth (int64) (int64)
-	if len("depth") > 1000000 {
-		return xerrors.Errorf("Value in field \"depth\" was too long")
+	if _, err := cw.WriteString(string("$type")); err != nil {
+		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("depth"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.knot"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("depth")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.knot")); err != nil {
 		return err
 	}
 
-	if t.Depth >= 0 {
-		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.Depth)); err != nil {
-			return err
-		}
-	} else {
-		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.Depth-1)); err != nil {
-			return err
-		}
-	}
-
-	// t.Submodules (bool) (bool)
-	if len("submodules") > 1000000 {
-		return xerrors.Errorf("Value in field \"submodules\" was too long")
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("submodules"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("submodules")); err != nil {
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
 		return err
 	}
 
-	if err := cbg.WriteBool(w, t.Submodules); err != nil {
-		return err
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
 	}
-	return nil
-}
-
-func (t *Pipeline_CloneOpts) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline_CloneOpts{}
-
-	cr := cbg.NewCborReader(r)
 
-	maj, extra, err := cr.ReadHeader()
-	if err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
 		return err
 	}
-	defer func() {
-		if err == io.EOF {
-			err = io.ErrUnexpectedEOF
-		}
-	}()
-
-	if maj != cbg.MajMap {
-		return fmt.Errorf("cbor input should be of type map")
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+		return err
 	}
 
-	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline_CloneOpts: map struct too large (%d)", extra)
-	}
+	// t.RepoCreate (string) (string)
+	if t.RepoCreate != nil {
 
-	n := extra
+		if len("repoCreate") > 1000000 {
+			return xerrors.Errorf("Value in field \"repoCreate\" was too long")
+		}
 
-	nameBuf := make([]byte, 10)
-	for i := uint64(0); i < n; i++ {
-		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
-		if err != nil {
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repoCreate"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("repoCreate")); err != nil {
 			return err
 		}
 
-		if !ok {
-			// Field doesn't exist on this type, so ignore it
-			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
+		if t.RepoCreate == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
 				return err
 			}
-			continue
-		}
+		} else {
+			if len(*t.RepoCreate) > 1000000 {
+				return xerrors.Errorf("Value in field t.RepoCreate was too long")
+			}
 
-		switch string(nameBuf[:nameLen]) {
-		// t.Skip (bool) (bool)
-		case "skip":
-
-			maj, extra, err = cr.ReadHeader()
-			if err != nil {
-				return err
-			}
-			if maj != cbg.MajOther {
-				return fmt.Errorf("booleans must be major type 7")
-			}
-			switch extra {
-			case 20:
-				t.Skip = false
-			case 21:
-				t.Skip = true
-			default:
-				return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
-			}
-			// t.Depth (int64) (int64)
-		case "depth":
-			{
-				maj, extra, err := cr.ReadHeader()
-				if err != nil {
-					return err
-				}
-				var extraI int64
-				switch maj {
-				case cbg.MajUnsignedInt:
-					extraI = int64(extra)
-					if extraI < 0 {
-						return fmt.Errorf("int64 positive overflow")
-					}
-				case cbg.MajNegativeInt:
-					extraI = int64(extra)
-					if extraI < 0 {
-						return fmt.Errorf("int64 negative overflow")
-					}
-					extraI = -1 - extraI
-				default:
-					return fmt.Errorf("wrong type for int64 field: %d", maj)
-				}
-
-				t.Depth = int64(extraI)
-			}
-			// t.Submodules (bool) (bool)
-		case "submodules":
-
-			maj, extra, err = cr.ReadHeader()
-			if err != nil {
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.RepoCreate))); err != nil {
 				return err
 			}
-			if maj != cbg.MajOther {
-				return fmt.Errorf("booleans must be major type 7")
-			}
-			switch extra {
-			case 20:
-				t.Submodules = false
-			case 21:
-				t.Submodules = true
-			default:
-				return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
-			}
-
-		default:
-			// Field doesn't exist on this type, so ignore it
-			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+			if _, err := cw.WriteString(string(*t.RepoCreate)); err != nil {
 				return err
 			}
 		}
 	}
 
-	return nil
-}
-func (t *Pipeline_ManualTriggerData) MarshalCBOR(w io.Writer) error {
-	if t == nil {
-		_, err := w.Write(cbg.CborNull)
-		return err
-	}
-
-	cw := cbg.NewCborWriter(w)
-	fieldCount := 1
-
-	if t.Inputs == nil {
-		fieldCount--
-	}
-
-	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
-		return err
-	}
-
-	// t.Inputs ([]*tangled.Pipeline_Pair) (slice)
-	if t.Inputs != nil {
+	// t.DefaultBranch (string) (string)
+	if t.DefaultBranch != nil {
 
-		if len("inputs") > 1000000 {
-			return xerrors.Errorf("Value in field \"inputs\" was too long")
+		if len("defaultBranch") > 1000000 {
+			return xerrors.Errorf("Value in field \"defaultBranch\" was too long")
 		}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("inputs"))); err != nil {
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("defaultBranch"))); err != nil {
 			return err
 		}
-		if _, err := cw.WriteString(string("inputs")); err != nil {
+		if _, err := cw.WriteString(string("defaultBranch")); err != nil {
 			return err
 		}
 
-		if len(t.Inputs) > 8192 {
-			return xerrors.Errorf("Slice value in field t.Inputs was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Inputs))); err != nil {
-			return err
-		}
-		for _, v := range t.Inputs {
-			if err := v.MarshalCBOR(cw); err != nil {
+		if t.DefaultBranch == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
 				return err
 			}
+		} else {
+			if len(*t.DefaultBranch) > 1000000 {
+				return xerrors.Errorf("Value in field t.DefaultBranch was too long")
+			}
 
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.DefaultBranch))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.DefaultBranch)); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
-func (t *Pipeline_ManualTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline_ManualTriggerData{}
+func (t *Knot) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Knot{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -2926,12 +3148,12 @@ func (t *Pipeline_ManualTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline_ManualTriggerData: map struct too large (%d)", extra)
+		return fmt.Errorf("Knot: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 6)
+	nameBuf := make([]byte, 13)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -2947,53 +3169,68 @@ func (t *Pipeline_ManualTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Inputs ([]*tangled.Pipeline_Pair) (slice)
-		case "inputs":
+		// t.LexiconTypeID (string) (string)
+		case "$type":
 
-			maj, extra, err = cr.ReadHeader()
-			if err != nil {
-				return err
-			}
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
 
-			if extra > 8192 {
-				return fmt.Errorf("t.Inputs: array too large (%d)", extra)
+				t.LexiconTypeID = string(sval)
 			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
 
-			if maj != cbg.MajArray {
-				return fmt.Errorf("expected cbor array")
-			}
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
 
-			if extra > 0 {
-				t.Inputs = make([]*Pipeline_Pair, extra)
+				t.CreatedAt = string(sval)
 			}
+			// t.RepoCreate (string) (string)
+		case "repoCreate":
 
-			for i := 0; i < int(extra); i++ {
-				{
-					var maj byte
-					var extra uint64
-					var err error
-					_ = maj
-					_ = extra
-					_ = err
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
 
-					{
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
 
-						b, err := cr.ReadByte()
-						if err != nil {
-							return err
-						}
-						if b != cbg.CborNull[0] {
-							if err := cr.UnreadByte(); err != nil {
-								return err
-							}
-							t.Inputs[i] = new(Pipeline_Pair)
-							if err := t.Inputs[i].UnmarshalCBOR(cr); err != nil {
-								return xerrors.Errorf("unmarshaling t.Inputs[i] pointer: %w", err)
-							}
-						}
+					t.RepoCreate = (*string)(&sval)
+				}
+			}
+			// t.DefaultBranch (string) (string)
+		case "defaultBranch":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
 
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
 					}
 
+					t.DefaultBranch = (*string)(&sval)
 				}
 			}
 
@@ -3007,7 +3244,7 @@ func (t *Pipeline_ManualTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *Pipeline_Pair) MarshalCBOR(w io.Writer) error {
+func (t *KnotMember) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -3015,68 +3252,110 @@ func (t *Pipeline_Pair) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{162}); err != nil {
+	if _, err := cw.Write([]byte{164}); err != nil {
 		return err
 	}
 
-	// t.Key (string) (string)
-	if len("key") > 1000000 {
-		return xerrors.Errorf("Value in field \"key\" was too long")
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("key"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("key")); err != nil {
+	if _, err := cw.WriteString(string("$type")); err != nil {
 		return err
 	}
 
-	if len(t.Key) > 1000000 {
-		return xerrors.Errorf("Value in field t.Key was too long")
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.knot.member"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.knot.member")); err != nil {
+		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Key))); err != nil {
+	// t.Domain (string) (string)
+	if len("domain") > 1000000 {
+		return xerrors.Errorf("Value in field \"domain\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("domain"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Key)); err != nil {
+	if _, err := cw.WriteString(string("domain")); err != nil {
 		return err
 	}
 
-	// t.Value (string) (string)
-	if len("value") > 1000000 {
-		return xerrors.Errorf("Value in field \"value\" was too long")
+	if len(t.Domain) > 1000000 {
+		return xerrors.Errorf("Value in field t.Domain was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("value"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Domain))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("value")); err != nil {
+	if _, err := cw.WriteString(string(t.Domain)); err != nil {
 		return err
 	}
 
-	if len(t.Value) > 1000000 {
-		return xerrors.Errorf("Value in field t.Value was too long")
+	// t.Subject (string) (string)
+	if len("subject") > 1000000 {
+		return xerrors.Errorf("Value in field \"subject\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Value))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Value)); err != nil {
+	if _, err := cw.WriteString(string("subject")); err != nil {
 		return err
 	}
-	return nil
-}
-
-func (t *Pipeline_Pair) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline_Pair{}
 
-	cr := cbg.NewCborReader(r)
+	if len(t.Subject) > 1000000 {
+		return xerrors.Errorf("Value in field t.Subject was too long")
+	}
 
-	maj, extra, err := cr.ReadHeader()
-	if err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
 		return err
 	}
-	defer func() {
+	if _, err := cw.WriteString(string(t.Subject)); err != nil {
+		return err
+	}
+
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
+		return err
+	}
+
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *KnotMember) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = KnotMember{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
 		if err == io.EOF {
 			err = io.ErrUnexpectedEOF
 		}
@@ -3087,12 +3366,12 @@ func (t *Pipeline_Pair) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline_Pair: map struct too large (%d)", extra)
+		return fmt.Errorf("KnotMember: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 5)
+	nameBuf := make([]byte, 9)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -3108,8 +3387,8 @@ func (t *Pipeline_Pair) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Key (string) (string)
-		case "key":
+		// t.LexiconTypeID (string) (string)
+		case "$type":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -3117,10 +3396,10 @@ func (t *Pipeline_Pair) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Key = string(sval)
+				t.LexiconTypeID = string(sval)
 			}
-			// t.Value (string) (string)
-		case "value":
+			// t.Domain (string) (string)
+		case "domain":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -3128,7 +3407,29 @@ func (t *Pipeline_Pair) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Value = string(sval)
+				t.Domain = string(sval)
+			}
+			// t.Subject (string) (string)
+		case "subject":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Subject = string(sval)
+			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -3141,7 +3442,7 @@ func (t *Pipeline_Pair) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *Pipeline_PullRequestTriggerData) MarshalCBOR(w io.Writer) error {
+func (t *Pipeline) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -3149,106 +3450,75 @@ func (t *Pipeline_PullRequestTriggerData) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{164}); err != nil {
-		return err
-	}
-
-	// t.Action (string) (string)
-	if len("action") > 1000000 {
-		return xerrors.Errorf("Value in field \"action\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("action"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("action")); err != nil {
+	if _, err := cw.Write([]byte{163}); err != nil {
 		return err
 	}
 
-	if len(t.Action) > 1000000 {
-		return xerrors.Errorf("Value in field t.Action was too long")
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Action))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Action)); err != nil {
+	if _, err := cw.WriteString(string("$type")); err != nil {
 		return err
 	}
 
-	// t.SourceSha (string) (string)
-	if len("sourceSha") > 1000000 {
-		return xerrors.Errorf("Value in field \"sourceSha\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sourceSha"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.pipeline"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sourceSha")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.pipeline")); err != nil {
 		return err
 	}
 
-	if len(t.SourceSha) > 1000000 {
-		return xerrors.Errorf("Value in field t.SourceSha was too long")
+	// t.Workflows ([]*tangled.Pipeline_Workflow) (slice)
+	if len("workflows") > 1000000 {
+		return xerrors.Errorf("Value in field \"workflows\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.SourceSha))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("workflows"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.SourceSha)); err != nil {
+	if _, err := cw.WriteString(string("workflows")); err != nil {
 		return err
 	}
 
-	// t.SourceBranch (string) (string)
-	if len("sourceBranch") > 1000000 {
-		return xerrors.Errorf("Value in field \"sourceBranch\" was too long")
+	if len(t.Workflows) > 8192 {
+		return xerrors.Errorf("Slice value in field t.Workflows was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sourceBranch"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("sourceBranch")); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Workflows))); err != nil {
 		return err
 	}
+	for _, v := range t.Workflows {
+		if err := v.MarshalCBOR(cw); err != nil {
+			return err
+		}
 
-	if len(t.SourceBranch) > 1000000 {
-		return xerrors.Errorf("Value in field t.SourceBranch was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.SourceBranch))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.SourceBranch)); err != nil {
-		return err
 	}
 
-	// t.TargetBranch (string) (string)
-	if len("targetBranch") > 1000000 {
-		return xerrors.Errorf("Value in field \"targetBranch\" was too long")
+	// t.TriggerMetadata (tangled.Pipeline_TriggerMetadata) (struct)
+	if len("triggerMetadata") > 1000000 {
+		return xerrors.Errorf("Value in field \"triggerMetadata\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("targetBranch"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("triggerMetadata"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("targetBranch")); err != nil {
+	if _, err := cw.WriteString(string("triggerMetadata")); err != nil {
 		return err
 	}
 
-	if len(t.TargetBranch) > 1000000 {
-		return xerrors.Errorf("Value in field t.TargetBranch was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.TargetBranch))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.TargetBranch)); err != nil {
+	if err := t.TriggerMetadata.MarshalCBOR(cw); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *Pipeline_PullRequestTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline_PullRequestTriggerData{}
+func (t *Pipeline) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -3267,12 +3537,12 @@ func (t *Pipeline_PullRequestTriggerData) UnmarshalCBOR(r io.Reader) (err error)
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline_PullRequestTriggerData: map struct too large (%d)", extra)
+		return fmt.Errorf("Pipeline: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 12)
+	nameBuf := make([]byte, 15)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -3288,8 +3558,8 @@ func (t *Pipeline_PullRequestTriggerData) UnmarshalCBOR(r io.Reader) (err error)
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Action (string) (string)
-		case "action":
+		// t.LexiconTypeID (string) (string)
+		case "$type":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -3297,40 +3567,76 @@ func (t *Pipeline_PullRequestTriggerData) UnmarshalCBOR(r io.Reader) (err error)
 					return err
 				}
 
-				t.Action = string(sval)
+				t.LexiconTypeID = string(sval)
 			}
-			// t.SourceSha (string) (string)
-		case "sourceSha":
+			// t.Workflows ([]*tangled.Pipeline_Workflow) (slice)
+		case "workflows":
 
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
 
-				t.SourceSha = string(sval)
+			if extra > 8192 {
+				return fmt.Errorf("t.Workflows: array too large (%d)", extra)
 			}
-			// t.SourceBranch (string) (string)
-		case "sourceBranch":
 
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
+			if maj != cbg.MajArray {
+				return fmt.Errorf("expected cbor array")
+			}
 
-				t.SourceBranch = string(sval)
+			if extra > 0 {
+				t.Workflows = make([]*Pipeline_Workflow, extra)
 			}
-			// t.TargetBranch (string) (string)
-		case "targetBranch":
+
+			for i := 0; i < int(extra); i++ {
+				{
+					var maj byte
+					var extra uint64
+					var err error
+					_ = maj
+					_ = extra
+					_ = err
+
+					{
+
+						b, err := cr.ReadByte()
+						if err != nil {
+							return err
+						}
+						if b != cbg.CborNull[0] {
+							if err := cr.UnreadByte(); err != nil {
+								return err
+							}
+							t.Workflows[i] = new(Pipeline_Workflow)
+							if err := t.Workflows[i].UnmarshalCBOR(cr); err != nil {
+								return xerrors.Errorf("unmarshaling t.Workflows[i] pointer: %w", err)
+							}
+						}
+
+					}
+
+				}
+			}
+			// t.TriggerMetadata (tangled.Pipeline_TriggerMetadata) (struct)
+		case "triggerMetadata":
 
 			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+
+				b, err := cr.ReadByte()
 				if err != nil {
 					return err
 				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.TriggerMetadata = new(Pipeline_TriggerMetadata)
+					if err := t.TriggerMetadata.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.TriggerMetadata pointer: %w", err)
+					}
+				}
 
-				t.TargetBranch = string(sval)
 			}
 
 		default:
@@ -3343,7 +3649,7 @@ func (t *Pipeline_PullRequestTriggerData) UnmarshalCBOR(r io.Reader) (err error)
 
 	return nil
 }
-func (t *Pipeline_PushTriggerData) MarshalCBOR(w io.Writer) error {
+func (t *Pipeline_CloneOpts) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -3355,79 +3661,64 @@ func (t *Pipeline_PushTriggerData) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.Ref (string) (string)
-	if len("ref") > 1000000 {
-		return xerrors.Errorf("Value in field \"ref\" was too long")
+	// t.Skip (bool) (bool)
+	if len("skip") > 1000000 {
+		return xerrors.Errorf("Value in field \"skip\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("ref"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("skip"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("ref")); err != nil {
+	if _, err := cw.WriteString(string("skip")); err != nil {
 		return err
 	}
 
-	if len(t.Ref) > 1000000 {
-		return xerrors.Errorf("Value in field t.Ref was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Ref))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Ref)); err != nil {
+	if err := cbg.WriteBool(w, t.Skip); err != nil {
 		return err
 	}
 
-	// t.NewSha (string) (string)
-	if len("newSha") > 1000000 {
-		return xerrors.Errorf("Value in field \"newSha\" was too long")
+	// t.Depth (int64) (int64)
+	if len("depth") > 1000000 {
+		return xerrors.Errorf("Value in field \"depth\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("newSha"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("depth"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("newSha")); err != nil {
+	if _, err := cw.WriteString(string("depth")); err != nil {
 		return err
 	}
 
-	if len(t.NewSha) > 1000000 {
-		return xerrors.Errorf("Value in field t.NewSha was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.NewSha))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.NewSha)); err != nil {
-		return err
+	if t.Depth >= 0 {
+		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.Depth)); err != nil {
+			return err
+		}
+	} else {
+		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.Depth-1)); err != nil {
+			return err
+		}
 	}
 
-	// t.OldSha (string) (string)
-	if len("oldSha") > 1000000 {
-		return xerrors.Errorf("Value in field \"oldSha\" was too long")
+	// t.Submodules (bool) (bool)
+	if len("submodules") > 1000000 {
+		return xerrors.Errorf("Value in field \"submodules\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("oldSha"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("submodules"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("oldSha")); err != nil {
+	if _, err := cw.WriteString(string("submodules")); err != nil {
 		return err
 	}
 
-	if len(t.OldSha) > 1000000 {
-		return xerrors.Errorf("Value in field t.OldSha was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.OldSha))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.OldSha)); err != nil {
+	if err := cbg.WriteBool(w, t.Submodules); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *Pipeline_PushTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline_PushTriggerData{}
+func (t *Pipeline_CloneOpts) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline_CloneOpts{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -3446,12 +3737,12 @@ func (t *Pipeline_PushTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline_PushTriggerData: map struct too large (%d)", extra)
+		return fmt.Errorf("Pipeline_CloneOpts: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 6)
+	nameBuf := make([]byte, 10)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -3467,38 +3758,67 @@ func (t *Pipeline_PushTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Ref (string) (string)
-		case "ref":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
+		// t.Skip (bool) (bool)
+		case "skip":
 
-				t.Ref = string(sval)
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
 			}
-			// t.NewSha (string) (string)
-		case "newSha":
-
+			if maj != cbg.MajOther {
+				return fmt.Errorf("booleans must be major type 7")
+			}
+			switch extra {
+			case 20:
+				t.Skip = false
+			case 21:
+				t.Skip = true
+			default:
+				return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
+			}
+			// t.Depth (int64) (int64)
+		case "depth":
 			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				maj, extra, err := cr.ReadHeader()
 				if err != nil {
 					return err
 				}
+				var extraI int64
+				switch maj {
+				case cbg.MajUnsignedInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 positive overflow")
+					}
+				case cbg.MajNegativeInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 negative overflow")
+					}
+					extraI = -1 - extraI
+				default:
+					return fmt.Errorf("wrong type for int64 field: %d", maj)
+				}
 
-				t.NewSha = string(sval)
+				t.Depth = int64(extraI)
 			}
-			// t.OldSha (string) (string)
-		case "oldSha":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
+			// t.Submodules (bool) (bool)
+		case "submodules":
 
-				t.OldSha = string(sval)
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
+			if maj != cbg.MajOther {
+				return fmt.Errorf("booleans must be major type 7")
+			}
+			switch extra {
+			case 20:
+				t.Submodules = false
+			case 21:
+				t.Submodules = true
+			default:
+				return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
 			}
 
 		default:
@@ -3511,20 +3831,16 @@ func (t *Pipeline_PushTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *PipelineStatus) MarshalCBOR(w io.Writer) error {
+func (t *Pipeline_ManualTriggerData) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 7
-
-	if t.Error == nil {
-		fieldCount--
-	}
+	fieldCount := 1
 
-	if t.ExitCode == nil {
+	if t.Inputs == nil {
 		fieldCount--
 	}
 
@@ -3532,185 +3848,200 @@ func (t *PipelineStatus) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.LexiconTypeID (string) (string)
-	if len("$type") > 1000000 {
-		return xerrors.Errorf("Value in field \"$type\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("$type")); err != nil {
-		return err
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.pipeline.status"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("sh.tangled.pipeline.status")); err != nil {
-		return err
-	}
-
-	// t.Error (string) (string)
-	if t.Error != nil {
+	// t.Inputs ([]*tangled.Pipeline_Pair) (slice)
+	if t.Inputs != nil {
 
-		if len("error") > 1000000 {
-			return xerrors.Errorf("Value in field \"error\" was too long")
+		if len("inputs") > 1000000 {
+			return xerrors.Errorf("Value in field \"inputs\" was too long")
 		}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("error"))); err != nil {
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("inputs"))); err != nil {
 			return err
 		}
-		if _, err := cw.WriteString(string("error")); err != nil {
+		if _, err := cw.WriteString(string("inputs")); err != nil {
 			return err
 		}
 
-		if t.Error == nil {
-			if _, err := cw.Write(cbg.CborNull); err != nil {
-				return err
-			}
-		} else {
-			if len(*t.Error) > 1000000 {
-				return xerrors.Errorf("Value in field t.Error was too long")
-			}
+		if len(t.Inputs) > 8192 {
+			return xerrors.Errorf("Slice value in field t.Inputs was too long")
+		}
 
-			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Error))); err != nil {
-				return err
-			}
-			if _, err := cw.WriteString(string(*t.Error)); err != nil {
+		if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Inputs))); err != nil {
+			return err
+		}
+		for _, v := range t.Inputs {
+			if err := v.MarshalCBOR(cw); err != nil {
 				return err
 			}
+
 		}
 	}
+	return nil
+}
 
-	// t.Status (string) (string)
-	if len("status") > 1000000 {
-		return xerrors.Errorf("Value in field \"status\" was too long")
-	}
+func (t *Pipeline_ManualTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline_ManualTriggerData{}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("status"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("status")); err != nil {
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
 		return err
 	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
 
-	if len(t.Status) > 1000000 {
-		return xerrors.Errorf("Value in field t.Status was too long")
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Status))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Status)); err != nil {
-		return err
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("Pipeline_ManualTriggerData: map struct too large (%d)", extra)
 	}
 
-	// t.ExitCode (int64) (int64)
-	if t.ExitCode != nil {
-
-		if len("exitCode") > 1000000 {
-			return xerrors.Errorf("Value in field \"exitCode\" was too long")
-		}
+	n := extra
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("exitCode"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("exitCode")); err != nil {
+	nameBuf := make([]byte, 6)
+	for i := uint64(0); i < n; i++ {
+		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
+		if err != nil {
 			return err
 		}
 
-		if t.ExitCode == nil {
-			if _, err := cw.Write(cbg.CborNull); err != nil {
+		if !ok {
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
 				return err
 			}
-		} else {
-			if *t.ExitCode >= 0 {
-				if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(*t.ExitCode)); err != nil {
-					return err
-				}
-			} else {
-				if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-*t.ExitCode-1)); err != nil {
-					return err
-				}
-			}
+			continue
 		}
 
-	}
+		switch string(nameBuf[:nameLen]) {
+		// t.Inputs ([]*tangled.Pipeline_Pair) (slice)
+		case "inputs":
 
-	// t.Pipeline (string) (string)
-	if len("pipeline") > 1000000 {
-		return xerrors.Errorf("Value in field \"pipeline\" was too long")
-	}
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("pipeline"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("pipeline")); err != nil {
-		return err
-	}
+			if extra > 8192 {
+				return fmt.Errorf("t.Inputs: array too large (%d)", extra)
+			}
 
-	if len(t.Pipeline) > 1000000 {
-		return xerrors.Errorf("Value in field t.Pipeline was too long")
+			if maj != cbg.MajArray {
+				return fmt.Errorf("expected cbor array")
+			}
+
+			if extra > 0 {
+				t.Inputs = make([]*Pipeline_Pair, extra)
+			}
+
+			for i := 0; i < int(extra); i++ {
+				{
+					var maj byte
+					var extra uint64
+					var err error
+					_ = maj
+					_ = extra
+					_ = err
+
+					{
+
+						b, err := cr.ReadByte()
+						if err != nil {
+							return err
+						}
+						if b != cbg.CborNull[0] {
+							if err := cr.UnreadByte(); err != nil {
+								return err
+							}
+							t.Inputs[i] = new(Pipeline_Pair)
+							if err := t.Inputs[i].UnmarshalCBOR(cr); err != nil {
+								return xerrors.Errorf("unmarshaling t.Inputs[i] pointer: %w", err)
+							}
+						}
+
+					}
+
+				}
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Pipeline))); err != nil {
+	return nil
+}
+func (t *Pipeline_Pair) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Pipeline)); err != nil {
+
+	cw := cbg.NewCborWriter(w)
+
+	if _, err := cw.Write([]byte{162}); err != nil {
 		return err
 	}
 
-	// t.Workflow (string) (string)
-	if len("workflow") > 1000000 {
-		return xerrors.Errorf("Value in field \"workflow\" was too long")
+	// t.Key (string) (string)
+	if len("key") > 1000000 {
+		return xerrors.Errorf("Value in field \"key\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("workflow"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("key"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("workflow")); err != nil {
+	if _, err := cw.WriteString(string("key")); err != nil {
 		return err
 	}
 
-	if len(t.Workflow) > 1000000 {
-		return xerrors.Errorf("Value in field t.Workflow was too long")
+	if len(t.Key) > 1000000 {
+		return xerrors.Errorf("Value in field t.Key was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Workflow))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Key))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Workflow)); err != nil {
+	if _, err := cw.WriteString(string(t.Key)); err != nil {
 		return err
 	}
 
-	// t.CreatedAt (string) (string)
-	if len("createdAt") > 1000000 {
-		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	// t.Value (string) (string)
+	if len("value") > 1000000 {
+		return xerrors.Errorf("Value in field \"value\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("value"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("createdAt")); err != nil {
+	if _, err := cw.WriteString(string("value")); err != nil {
 		return err
 	}
 
-	if len(t.CreatedAt) > 1000000 {
-		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	if len(t.Value) > 1000000 {
+		return xerrors.Errorf("Value in field t.Value was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Value))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+	if _, err := cw.WriteString(string(t.Value)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *PipelineStatus) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = PipelineStatus{}
+func (t *Pipeline_Pair) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline_Pair{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -3729,12 +4060,12 @@ func (t *PipelineStatus) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("PipelineStatus: map struct too large (%d)", extra)
+		return fmt.Errorf("Pipeline_Pair: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 9)
+	nameBuf := make([]byte, 5)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -3750,98 +4081,8 @@ func (t *PipelineStatus) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.LexiconTypeID (string) (string)
-		case "$type":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.LexiconTypeID = string(sval)
-			}
-			// t.Error (string) (string)
-		case "error":
-
-			{
-				b, err := cr.ReadByte()
-				if err != nil {
-					return err
-				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-
-					sval, err := cbg.ReadStringWithMax(cr, 1000000)
-					if err != nil {
-						return err
-					}
-
-					t.Error = (*string)(&sval)
-				}
-			}
-			// t.Status (string) (string)
-		case "status":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Status = string(sval)
-			}
-			// t.ExitCode (int64) (int64)
-		case "exitCode":
-			{
-
-				b, err := cr.ReadByte()
-				if err != nil {
-					return err
-				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					maj, extra, err := cr.ReadHeader()
-					if err != nil {
-						return err
-					}
-					var extraI int64
-					switch maj {
-					case cbg.MajUnsignedInt:
-						extraI = int64(extra)
-						if extraI < 0 {
-							return fmt.Errorf("int64 positive overflow")
-						}
-					case cbg.MajNegativeInt:
-						extraI = int64(extra)
-						if extraI < 0 {
-							return fmt.Errorf("int64 negative overflow")
-						}
-						extraI = -1 - extraI
-					default:
-						return fmt.Errorf("wrong type for int64 field: %d", maj)
-					}
-
-					t.ExitCode = (*int64)(&extraI)
-				}
-			}
-			// t.Pipeline (string) (string)
-		case "pipeline":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Pipeline = string(sval)
-			}
-			// t.Workflow (string) (string)
-		case "workflow":
+		// t.Key (string) (string)
+		case "key":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -3849,10 +4090,10 @@ func (t *PipelineStatus) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Workflow = string(sval)
+				t.Key = string(sval)
 			}
-			// t.CreatedAt (string) (string)
-		case "createdAt":
+			// t.Value (string) (string)
+		case "value":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -3860,7 +4101,7 @@ func (t *PipelineStatus) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.CreatedAt = string(sval)
+				t.Value = string(sval)
 			}
 
 		default:
@@ -3873,131 +4114,114 @@ func (t *PipelineStatus) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *Pipeline_TriggerMetadata) MarshalCBOR(w io.Writer) error {
+func (t *Pipeline_PullRequestTriggerData) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 5
 
-	if t.Manual == nil {
-		fieldCount--
+	if _, err := cw.Write([]byte{164}); err != nil {
+		return err
 	}
 
-	if t.PullRequest == nil {
-		fieldCount--
+	// t.Action (string) (string)
+	if len("action") > 1000000 {
+		return xerrors.Errorf("Value in field \"action\" was too long")
 	}
 
-	if t.Push == nil {
-		fieldCount--
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("action"))); err != nil {
+		return err
 	}
-
-	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+	if _, err := cw.WriteString(string("action")); err != nil {
 		return err
 	}
 
-	// t.Kind (string) (string)
-	if len("kind") > 1000000 {
-		return xerrors.Errorf("Value in field \"kind\" was too long")
+	if len(t.Action) > 1000000 {
+		return xerrors.Errorf("Value in field t.Action was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("kind"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Action))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("kind")); err != nil {
+	if _, err := cw.WriteString(string(t.Action)); err != nil {
 		return err
 	}
 
-	if len(t.Kind) > 1000000 {
-		return xerrors.Errorf("Value in field t.Kind was too long")
+	// t.SourceSha (string) (string)
+	if len("sourceSha") > 1000000 {
+		return xerrors.Errorf("Value in field \"sourceSha\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Kind))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sourceSha"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Kind)); err != nil {
+	if _, err := cw.WriteString(string("sourceSha")); err != nil {
 		return err
 	}
 
-	// t.Push (tangled.Pipeline_PushTriggerData) (struct)
-	if t.Push != nil {
-
-		if len("push") > 1000000 {
-			return xerrors.Errorf("Value in field \"push\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("push"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("push")); err != nil {
-			return err
-		}
+	if len(t.SourceSha) > 1000000 {
+		return xerrors.Errorf("Value in field t.SourceSha was too long")
+	}
 
-		if err := t.Push.MarshalCBOR(cw); err != nil {
-			return err
-		}
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.SourceSha))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.SourceSha)); err != nil {
+		return err
 	}
 
-	// t.Repo (tangled.Pipeline_TriggerRepo) (struct)
-	if len("repo") > 1000000 {
-		return xerrors.Errorf("Value in field \"repo\" was too long")
+	// t.SourceBranch (string) (string)
+	if len("sourceBranch") > 1000000 {
+		return xerrors.Errorf("Value in field \"sourceBranch\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sourceBranch"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("repo")); err != nil {
+	if _, err := cw.WriteString(string("sourceBranch")); err != nil {
 		return err
 	}
 
-	if err := t.Repo.MarshalCBOR(cw); err != nil {
-		return err
+	if len(t.SourceBranch) > 1000000 {
+		return xerrors.Errorf("Value in field t.SourceBranch was too long")
 	}
 
-	// t.Manual (tangled.Pipeline_ManualTriggerData) (struct)
-	if t.Manual != nil {
-
-		if len("manual") > 1000000 {
-			return xerrors.Errorf("Value in field \"manual\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("manual"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("manual")); err != nil {
-			return err
-		}
-
-		if err := t.Manual.MarshalCBOR(cw); err != nil {
-			return err
-		}
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.SourceBranch))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.SourceBranch)); err != nil {
+		return err
 	}
 
-	// t.PullRequest (tangled.Pipeline_PullRequestTriggerData) (struct)
-	if t.PullRequest != nil {
+	// t.TargetBranch (string) (string)
+	if len("targetBranch") > 1000000 {
+		return xerrors.Errorf("Value in field \"targetBranch\" was too long")
+	}
 
-		if len("pullRequest") > 1000000 {
-			return xerrors.Errorf("Value in field \"pullRequest\" was too long")
-		}
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("targetBranch"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("targetBranch")); err != nil {
+		return err
+	}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("pullRequest"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("pullRequest")); err != nil {
-			return err
-		}
+	if len(t.TargetBranch) > 1000000 {
+		return xerrors.Errorf("Value in field t.TargetBranch was too long")
+	}
 
-		if err := t.PullRequest.MarshalCBOR(cw); err != nil {
-			return err
-		}
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.TargetBranch))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.TargetBranch)); err != nil {
+		return err
 	}
 	return nil
 }
 
-func (t *Pipeline_TriggerMetadata) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline_TriggerMetadata{}
+func (t *Pipeline_PullRequestTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline_PullRequestTriggerData{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -4016,12 +4240,12 @@ func (t *Pipeline_TriggerMetadata) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline_TriggerMetadata: map struct too large (%d)", extra)
+		return fmt.Errorf("Pipeline_PullRequestTriggerData: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 11)
+	nameBuf := make([]byte, 12)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -4037,8 +4261,8 @@ func (t *Pipeline_TriggerMetadata) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Kind (string) (string)
-		case "kind":
+		// t.Action (string) (string)
+		case "action":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4046,87 +4270,40 @@ func (t *Pipeline_TriggerMetadata) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Kind = string(sval)
-			}
-			// t.Push (tangled.Pipeline_PushTriggerData) (struct)
-		case "push":
-
-			{
-
-				b, err := cr.ReadByte()
-				if err != nil {
-					return err
-				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.Push = new(Pipeline_PushTriggerData)
-					if err := t.Push.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.Push pointer: %w", err)
-					}
-				}
-
+				t.Action = string(sval)
 			}
-			// t.Repo (tangled.Pipeline_TriggerRepo) (struct)
-		case "repo":
+			// t.SourceSha (string) (string)
+		case "sourceSha":
 
 			{
-
-				b, err := cr.ReadByte()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.Repo = new(Pipeline_TriggerRepo)
-					if err := t.Repo.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.Repo pointer: %w", err)
-					}
-				}
 
+				t.SourceSha = string(sval)
 			}
-			// t.Manual (tangled.Pipeline_ManualTriggerData) (struct)
-		case "manual":
+			// t.SourceBranch (string) (string)
+		case "sourceBranch":
 
 			{
-
-				b, err := cr.ReadByte()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.Manual = new(Pipeline_ManualTriggerData)
-					if err := t.Manual.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.Manual pointer: %w", err)
-					}
-				}
 
+				t.SourceBranch = string(sval)
 			}
-			// t.PullRequest (tangled.Pipeline_PullRequestTriggerData) (struct)
-		case "pullRequest":
+			// t.TargetBranch (string) (string)
+		case "targetBranch":
 
 			{
-
-				b, err := cr.ReadByte()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.PullRequest = new(Pipeline_PullRequestTriggerData)
-					if err := t.PullRequest.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.PullRequest pointer: %w", err)
-					}
-				}
 
+				t.TargetBranch = string(sval)
 			}
 
 		default:
@@ -4139,7 +4316,7 @@ func (t *Pipeline_TriggerMetadata) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *Pipeline_TriggerRepo) MarshalCBOR(w io.Writer) error {
+func (t *Pipeline_PushTriggerData) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -4147,106 +4324,1710 @@ func (t *Pipeline_TriggerRepo) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{164}); err != nil {
+	if _, err := cw.Write([]byte{163}); err != nil {
 		return err
 	}
 
-	// t.Did (string) (string)
-	if len("did") > 1000000 {
-		return xerrors.Errorf("Value in field \"did\" was too long")
+	// t.Ref (string) (string)
+	if len("ref") > 1000000 {
+		return xerrors.Errorf("Value in field \"ref\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("did"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("ref"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("did")); err != nil {
+	if _, err := cw.WriteString(string("ref")); err != nil {
 		return err
 	}
 
-	if len(t.Did) > 1000000 {
-		return xerrors.Errorf("Value in field t.Did was too long")
+	if len(t.Ref) > 1000000 {
+		return xerrors.Errorf("Value in field t.Ref was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Did))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Ref))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Did)); err != nil {
+	if _, err := cw.WriteString(string(t.Ref)); err != nil {
 		return err
 	}
 
-	// t.Knot (string) (string)
-	if len("knot") > 1000000 {
-		return xerrors.Errorf("Value in field \"knot\" was too long")
+	// t.NewSha (string) (string)
+	if len("newSha") > 1000000 {
+		return xerrors.Errorf("Value in field \"newSha\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("knot"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("newSha"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("knot")); err != nil {
+	if _, err := cw.WriteString(string("newSha")); err != nil {
 		return err
 	}
 
-	if len(t.Knot) > 1000000 {
-		return xerrors.Errorf("Value in field t.Knot was too long")
+	if len(t.NewSha) > 1000000 {
+		return xerrors.Errorf("Value in field t.NewSha was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Knot))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.NewSha))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Knot)); err != nil {
+	if _, err := cw.WriteString(string(t.NewSha)); err != nil {
 		return err
 	}
 
-	// t.Repo (string) (string)
-	if len("repo") > 1000000 {
-		return xerrors.Errorf("Value in field \"repo\" was too long")
+	// t.OldSha (string) (string)
+	if len("oldSha") > 1000000 {
+		return xerrors.Errorf("Value in field \"oldSha\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("oldSha"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("repo")); err != nil {
+	if _, err := cw.WriteString(string("oldSha")); err != nil {
 		return err
 	}
 
-	if len(t.Repo) > 1000000 {
-		return xerrors.Errorf("Value in field t.Repo was too long")
+	if len(t.OldSha) > 1000000 {
+		return xerrors.Errorf("Value in field t.OldSha was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.OldSha))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Repo)); err != nil {
+	if _, err := cw.WriteString(string(t.OldSha)); err != nil {
 		return err
 	}
-
+	return nil
+}
+
+func (t *Pipeline_PushTriggerData) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline_PushTriggerData{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("Pipeline_PushTriggerData: map struct too large (%d)", extra)
+	}
+
+	n := extra
+
+	nameBuf := make([]byte, 6)
+	for i := uint64(0); i < n; i++ {
+		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch string(nameBuf[:nameLen]) {
+		// t.Ref (string) (string)
+		case "ref":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Ref = string(sval)
+			}
+			// t.NewSha (string) (string)
+		case "newSha":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.NewSha = string(sval)
+			}
+			// t.OldSha (string) (string)
+		case "oldSha":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.OldSha = string(sval)
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+func (t *PipelineStatus) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+	fieldCount := 7
+
+	if t.Error == nil {
+		fieldCount--
+	}
+
+	if t.ExitCode == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+		return err
+	}
+
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("$type")); err != nil {
+		return err
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.pipeline.status"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.pipeline.status")); err != nil {
+		return err
+	}
+
+	// t.Error (string) (string)
+	if t.Error != nil {
+
+		if len("error") > 1000000 {
+			return xerrors.Errorf("Value in field \"error\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("error"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("error")); err != nil {
+			return err
+		}
+
+		if t.Error == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Error) > 1000000 {
+				return xerrors.Errorf("Value in field t.Error was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Error))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Error)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// t.Status (string) (string)
+	if len("status") > 1000000 {
+		return xerrors.Errorf("Value in field \"status\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("status"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("status")); err != nil {
+		return err
+	}
+
+	if len(t.Status) > 1000000 {
+		return xerrors.Errorf("Value in field t.Status was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Status))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Status)); err != nil {
+		return err
+	}
+
+	// t.ExitCode (int64) (int64)
+	if t.ExitCode != nil {
+
+		if len("exitCode") > 1000000 {
+			return xerrors.Errorf("Value in field \"exitCode\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("exitCode"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("exitCode")); err != nil {
+			return err
+		}
+
+		if t.ExitCode == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if *t.ExitCode >= 0 {
+				if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(*t.ExitCode)); err != nil {
+					return err
+				}
+			} else {
+				if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-*t.ExitCode-1)); err != nil {
+					return err
+				}
+			}
+		}
+
+	}
+
+	// t.Pipeline (string) (string)
+	if len("pipeline") > 1000000 {
+		return xerrors.Errorf("Value in field \"pipeline\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("pipeline"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("pipeline")); err != nil {
+		return err
+	}
+
+	if len(t.Pipeline) > 1000000 {
+		return xerrors.Errorf("Value in field t.Pipeline was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Pipeline))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Pipeline)); err != nil {
+		return err
+	}
+
+	// t.Workflow (string) (string)
+	if len("workflow") > 1000000 {
+		return xerrors.Errorf("Value in field \"workflow\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("workflow"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("workflow")); err != nil {
+		return err
+	}
+
+	if len(t.Workflow) > 1000000 {
+		return xerrors.Errorf("Value in field t.Workflow was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Workflow))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Workflow)); err != nil {
+		return err
+	}
+
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
+		return err
+	}
+
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *PipelineStatus) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = PipelineStatus{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("PipelineStatus: map struct too large (%d)", extra)
+	}
+
+	n := extra
+
+	nameBuf := make([]byte, 9)
+	for i := uint64(0); i < n; i++ {
+		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch string(nameBuf[:nameLen]) {
+		// t.LexiconTypeID (string) (string)
+		case "$type":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.LexiconTypeID = string(sval)
+			}
+			// t.Error (string) (string)
+		case "error":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Error = (*string)(&sval)
+				}
+			}
+			// t.Status (string) (string)
+		case "status":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Status = string(sval)
+			}
+			// t.ExitCode (int64) (int64)
+		case "exitCode":
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					maj, extra, err := cr.ReadHeader()
+					if err != nil {
+						return err
+					}
+					var extraI int64
+					switch maj {
+					case cbg.MajUnsignedInt:
+						extraI = int64(extra)
+						if extraI < 0 {
+							return fmt.Errorf("int64 positive overflow")
+						}
+					case cbg.MajNegativeInt:
+						extraI = int64(extra)
+						if extraI < 0 {
+							return fmt.Errorf("int64 negative overflow")
+						}
+						extraI = -1 - extraI
+					default:
+						return fmt.Errorf("wrong type for int64 field: %d", maj)
+					}
+
+					t.ExitCode = (*int64)(&extraI)
+				}
+			}
+			// t.Pipeline (string) (string)
+		case "pipeline":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Pipeline = string(sval)
+			}
+			// t.Workflow (string) (string)
+		case "workflow":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Workflow = string(sval)
+			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.CreatedAt = string(sval)
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+func (t *Pipeline_TriggerMetadata) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+	fieldCount := 5
+
+	if t.Manual == nil {
+		fieldCount--
+	}
+
+	if t.PullRequest == nil {
+		fieldCount--
+	}
+
+	if t.Push == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+		return err
+	}
+
+	// t.Kind (string) (string)
+	if len("kind") > 1000000 {
+		return xerrors.Errorf("Value in field \"kind\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("kind"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("kind")); err != nil {
+		return err
+	}
+
+	if len(t.Kind) > 1000000 {
+		return xerrors.Errorf("Value in field t.Kind was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Kind))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Kind)); err != nil {
+		return err
+	}
+
+	// t.Push (tangled.Pipeline_PushTriggerData) (struct)
+	if t.Push != nil {
+
+		if len("push") > 1000000 {
+			return xerrors.Errorf("Value in field \"push\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("push"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("push")); err != nil {
+			return err
+		}
+
+		if err := t.Push.MarshalCBOR(cw); err != nil {
+			return err
+		}
+	}
+
+	// t.Repo (tangled.Pipeline_TriggerRepo) (struct)
+	if len("repo") > 1000000 {
+		return xerrors.Errorf("Value in field \"repo\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("repo")); err != nil {
+		return err
+	}
+
+	if err := t.Repo.MarshalCBOR(cw); err != nil {
+		return err
+	}
+
+	// t.Manual (tangled.Pipeline_ManualTriggerData) (struct)
+	if t.Manual != nil {
+
+		if len("manual") > 1000000 {
+			return xerrors.Errorf("Value in field \"manual\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("manual"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("manual")); err != nil {
+			return err
+		}
+
+		if err := t.Manual.MarshalCBOR(cw); err != nil {
+			return err
+		}
+	}
+
+	// t.PullRequest (tangled.Pipeline_PullRequestTriggerData) (struct)
+	if t.PullRequest != nil {
+
+		if len("pullRequest") > 1000000 {
+			return xerrors.Errorf("Value in field \"pullRequest\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("pullRequest"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("pullRequest")); err != nil {
+			return err
+		}
+
+		if err := t.PullRequest.MarshalCBOR(cw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Pipeline_TriggerMetadata) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline_TriggerMetadata{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("Pipeline_TriggerMetadata: map struct too large (%d)", extra)
+	}
+
+	n := extra
+
+	nameBuf := make([]byte, 11)
+	for i := uint64(0); i < n; i++ {
+		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch string(nameBuf[:nameLen]) {
+		// t.Kind (string) (string)
+		case "kind":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Kind = string(sval)
+			}
+			// t.Push (tangled.Pipeline_PushTriggerData) (struct)
+		case "push":
+
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Push = new(Pipeline_PushTriggerData)
+					if err := t.Push.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Push pointer: %w", err)
+					}
+				}
+
+			}
+			// t.Repo (tangled.Pipeline_TriggerRepo) (struct)
+		case "repo":
+
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Repo = new(Pipeline_TriggerRepo)
+					if err := t.Repo.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Repo pointer: %w", err)
+					}
+				}
+
+			}
+			// t.Manual (tangled.Pipeline_ManualTriggerData) (struct)
+		case "manual":
+
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Manual = new(Pipeline_ManualTriggerData)
+					if err := t.Manual.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Manual pointer: %w", err)
+					}
+				}
+
+			}
+			// t.PullRequest (tangled.Pipeline_PullRequestTriggerData) (struct)
+		case "pullRequest":
+
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.PullRequest = new(Pipeline_PullRequestTriggerData)
+					if err := t.PullRequest.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.PullRequest pointer: %w", err)
+					}
+				}
+
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+func (t *Pipeline_TriggerRepo) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+
+	if _, err := cw.Write([]byte{164}); err != nil {
+		return err
+	}
+
+	// t.Did (string) (string)
+	if len("did") > 1000000 {
+		return xerrors.Errorf("Value in field \"did\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("did"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("did")); err != nil {
+		return err
+	}
+
+	if len(t.Did) > 1000000 {
+		return xerrors.Errorf("Value in field t.Did was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Did))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Did)); err != nil {
+		return err
+	}
+
+	// t.Knot (string) (string)
+	if len("knot") > 1000000 {
+		return xerrors.Errorf("Value in field \"knot\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("knot"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("knot")); err != nil {
+		return err
+	}
+
+	if len(t.Knot) > 1000000 {
+		return xerrors.Errorf("Value in field t.Knot was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Knot))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Knot)); err != nil {
+		return err
+	}
+
+	// t.Repo (string) (string)
+	if len("repo") > 1000000 {
+		return xerrors.Errorf("Value in field \"repo\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("repo")); err != nil {
+		return err
+	}
+
+	if len(t.Repo) > 1000000 {
+		return xerrors.Errorf("Value in field t.Repo was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Repo)); err != nil {
+		return err
+	}
+
 	// t.DefaultBranch (string) (string)
 	if len("defaultBranch") > 1000000 {
 		return xerrors.Errorf("Value in field \"defaultBranch\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("defaultBranch"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("defaultBranch"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("defaultBranch")); err != nil {
+		return err
+	}
+
+	if len(t.DefaultBranch) > 1000000 {
+		return xerrors.Errorf("Value in field t.DefaultBranch was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.DefaultBranch))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.DefaultBranch)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline_TriggerRepo{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("Pipeline_TriggerRepo: map struct too large (%d)", extra)
+	}
+
+	n := extra
+
+	nameBuf := make([]byte, 13)
+	for i := uint64(0); i < n; i++ {
+		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch string(nameBuf[:nameLen]) {
+		// t.Did (string) (string)
+		case "did":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Did = string(sval)
+			}
+			// t.Knot (string) (string)
+		case "knot":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Knot = string(sval)
+			}
+			// t.Repo (string) (string)
+		case "repo":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Repo = string(sval)
+			}
+			// t.DefaultBranch (string) (string)
+		case "defaultBranch":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.DefaultBranch = string(sval)
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+func (t *Pipeline_Workflow) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+
+	if _, err := cw.Write([]byte{164}); err != nil {
+		return err
+	}
+
+	// t.Raw (string) (string)
+	if len("raw") > 1000000 {
+		return xerrors.Errorf("Value in field \"raw\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("raw"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("raw")); err != nil {
+		return err
+	}
+
+	if len(t.Raw) > 1000000 {
+		return xerrors.Errorf("Value in field t.Raw was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Raw))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Raw)); err != nil {
+		return err
+	}
+
+	// t.Name (string) (string)
+	if len("name") > 1000000 {
+		return xerrors.Errorf("Value in field \"name\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("name")); err != nil {
+		return err
+	}
+
+	if len(t.Name) > 1000000 {
+		return xerrors.Errorf("Value in field t.Name was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Name)); err != nil {
+		return err
+	}
+
+	// t.Clone (tangled.Pipeline_CloneOpts) (struct)
+	if len("clone") > 1000000 {
+		return xerrors.Errorf("Value in field \"clone\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("clone"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("clone")); err != nil {
+		return err
+	}
+
+	if err := t.Clone.MarshalCBOR(cw); err != nil {
+		return err
+	}
+
+	// t.Engine (string) (string)
+	if len("engine") > 1000000 {
+		return xerrors.Errorf("Value in field \"engine\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("engine"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("engine")); err != nil {
+		return err
+	}
+
+	if len(t.Engine) > 1000000 {
+		return xerrors.Errorf("Value in field t.Engine was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Engine))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Engine)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Pipeline_Workflow{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("Pipeline_Workflow: map struct too large (%d)", extra)
+	}
+
+	n := extra
+
+	nameBuf := make([]byte, 6)
+	for i := uint64(0); i < n; i++ {
+		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch string(nameBuf[:nameLen]) {
+		// t.Raw (string) (string)
+		case "raw":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Raw = string(sval)
+			}
+			// t.Name (string) (string)
+		case "name":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Name = string(sval)
+			}
+			// t.Clone (tangled.Pipeline_CloneOpts) (struct)
+		case "clone":
+
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Clone = new(Pipeline_CloneOpts)
+					if err := t.Clone.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Clone pointer: %w", err)
+					}
+				}
+
+			}
+			// t.Engine (string) (string)
+		case "engine":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Engine = string(sval)
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+func (t *PublicKey) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+
+	if _, err := cw.Write([]byte{164}); err != nil {
+		return err
+	}
+
+	// t.Key (string) (string)
+	if len("key") > 1000000 {
+		return xerrors.Errorf("Value in field \"key\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("key"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("key")); err != nil {
+		return err
+	}
+
+	if len(t.Key) > 1000000 {
+		return xerrors.Errorf("Value in field t.Key was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Key))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Key)); err != nil {
+		return err
+	}
+
+	// t.Name (string) (string)
+	if len("name") > 1000000 {
+		return xerrors.Errorf("Value in field \"name\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("name")); err != nil {
+		return err
+	}
+
+	if len(t.Name) > 1000000 {
+		return xerrors.Errorf("Value in field t.Name was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Name)); err != nil {
+		return err
+	}
+
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("$type")); err != nil {
+		return err
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.publicKey"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.publicKey")); err != nil {
+		return err
+	}
+
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
+		return err
+	}
+
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *PublicKey) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = PublicKey{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("PublicKey: map struct too large (%d)", extra)
+	}
+
+	n := extra
+
+	nameBuf := make([]byte, 9)
+	for i := uint64(0); i < n; i++ {
+		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch string(nameBuf[:nameLen]) {
+		// t.Key (string) (string)
+		case "key":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Key = string(sval)
+			}
+			// t.Name (string) (string)
+		case "name":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Name = string(sval)
+			}
+			// t.LexiconTypeID (string) (string)
+		case "$type":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.LexiconTypeID = string(sval)
+			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.CreatedAt = string(sval)
+			}
+
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+func (t *Repo) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+	fieldCount := 10
+
+	if t.Description == nil {
+		fieldCount--
+	}
+
+	if t.Source == nil {
+		fieldCount--
+	}
+
+	if t.Spindle == nil {
+		fieldCount--
+	}
+
+	if t.Topics == nil {
+		fieldCount--
+	}
+
+	if t.Website == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+		return err
+	}
+
+	// t.Knot (string) (string)
+	if len("knot") > 1000000 {
+		return xerrors.Errorf("Value in field \"knot\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("knot"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("knot")); err != nil {
+		return err
+	}
+
+	if len(t.Knot) > 1000000 {
+		return xerrors.Errorf("Value in field t.Knot was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Knot))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Knot)); err != nil {
+		return err
+	}
+
+	// t.Name (string) (string)
+	if len("name") > 1000000 {
+		return xerrors.Errorf("Value in field \"name\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("name")); err != nil {
+		return err
+	}
+
+	if len(t.Name) > 1000000 {
+		return xerrors.Errorf("Value in field t.Name was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Name)); err != nil {
+		return err
+	}
+
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("$type")); err != nil {
+		return err
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.repo")); err != nil {
+		return err
+	}
+
+	// t.Owner (string) (string)
+	if len("owner") > 1000000 {
+		return xerrors.Errorf("Value in field \"owner\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("owner"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("owner")); err != nil {
+		return err
+	}
+
+	if len(t.Owner) > 1000000 {
+		return xerrors.Errorf("Value in field t.Owner was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Owner))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Owner)); err != nil {
+		return err
+	}
+
+	// t.Source (string) (string)
+	if t.Source != nil {
+
+		if len("source") > 1000000 {
+			return xerrors.Errorf("Value in field \"source\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("source"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("source")); err != nil {
+			return err
+		}
+
+		if t.Source == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Source) > 1000000 {
+				return xerrors.Errorf("Value in field t.Source was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Source))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Source)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// t.Topics ([]string) (slice)
+	if t.Topics != nil {
+
+		if len("topics") > 1000000 {
+			return xerrors.Errorf("Value in field \"topics\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("topics"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("topics")); err != nil {
+			return err
+		}
+
+		if len(t.Topics) > 8192 {
+			return xerrors.Errorf("Slice value in field t.Topics was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(len(t.Topics))); err != nil {
+			return err
+		}
+		for _, v := range t.Topics {
+			if len(v) > 1000000 {
+				return xerrors.Errorf("Value in field v was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(v))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(v)); err != nil {
+				return err
+			}
+
+		}
+	}
+
+	// t.Spindle (string) (string)
+	if t.Spindle != nil {
+
+		if len("spindle") > 1000000 {
+			return xerrors.Errorf("Value in field \"spindle\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("spindle"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("spindle")); err != nil {
+			return err
+		}
+
+		if t.Spindle == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Spindle) > 1000000 {
+				return xerrors.Errorf("Value in field t.Spindle was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Spindle))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Spindle)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// t.Website (string) (string)
+	if t.Website != nil {
+
+		if len("website") > 1000000 {
+			return xerrors.Errorf("Value in field \"website\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("website"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("website")); err != nil {
+			return err
+		}
+
+		if t.Website == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Website) > 1000000 {
+				return xerrors.Errorf("Value in field t.Website was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Website))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Website)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("defaultBranch")); err != nil {
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
 		return err
 	}
 
-	if len(t.DefaultBranch) > 1000000 {
-		return xerrors.Errorf("Value in field t.DefaultBranch was too long")
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.DefaultBranch))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.DefaultBranch)); err != nil {
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
+
+	// t.Description (string) (string)
+	if t.Description != nil {
+
+		if len("description") > 1000000 {
+			return xerrors.Errorf("Value in field \"description\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("description"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("description")); err != nil {
+			return err
+		}
+
+		if t.Description == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Description) > 1000000 {
+				return xerrors.Errorf("Value in field t.Description was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Description))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Description)); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline_TriggerRepo{}
+func (t *Repo) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = Repo{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -4265,12 +6046,12 @@ func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline_TriggerRepo: map struct too large (%d)", extra)
+		return fmt.Errorf("Repo: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 13)
+	nameBuf := make([]byte, 11)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -4286,8 +6067,8 @@ func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Did (string) (string)
-		case "did":
+		// t.Knot (string) (string)
+		case "knot":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4295,10 +6076,10 @@ func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Did = string(sval)
+				t.Knot = string(sval)
 			}
-			// t.Knot (string) (string)
-		case "knot":
+			// t.Name (string) (string)
+		case "name":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4306,10 +6087,10 @@ func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Knot = string(sval)
+				t.Name = string(sval)
 			}
-			// t.Repo (string) (string)
-		case "repo":
+			// t.LexiconTypeID (string) (string)
+		case "$type":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4317,10 +6098,10 @@ func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Repo = string(sval)
+				t.LexiconTypeID = string(sval)
 			}
-			// t.DefaultBranch (string) (string)
-		case "defaultBranch":
+			// t.Owner (string) (string)
+		case "owner":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4328,7 +6109,142 @@ func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.DefaultBranch = string(sval)
+				t.Owner = string(sval)
+			}
+			// t.Source (string) (string)
+		case "source":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Source = (*string)(&sval)
+				}
+			}
+			// t.Topics ([]string) (slice)
+		case "topics":
+
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
+
+			if extra > 8192 {
+				return fmt.Errorf("t.Topics: array too large (%d)", extra)
+			}
+
+			if maj != cbg.MajArray {
+				return fmt.Errorf("expected cbor array")
+			}
+
+			if extra > 0 {
+				t.Topics = make([]string, extra)
+			}
+
+			for i := 0; i < int(extra); i++ {
+				{
+					var maj byte
+					var extra uint64
+					var err error
+					_ = maj
+					_ = extra
+					_ = err
+
+					{
+						sval, err := cbg.ReadStringWithMax(cr, 1000000)
+						if err != nil {
+							return err
+						}
+
+						t.Topics[i] = string(sval)
+					}
+
+				}
+			}
+			// t.Spindle (string) (string)
+		case "spindle":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Spindle = (*string)(&sval)
+				}
+			}
+			// t.Website (string) (string)
+		case "website":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Website = (*string)(&sval)
+				}
+			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.CreatedAt = string(sval)
+			}
+			// t.Description (string) (string)
+		case "description":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Description = (*string)(&sval)
+				}
 			}
 
 		default:
@@ -4341,107 +6257,159 @@ func (t *Pipeline_TriggerRepo) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *Pipeline_Workflow) MarshalCBOR(w io.Writer) error {
+func (t *RepoArtifact) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-
-	cw := cbg.NewCborWriter(w)
-
-	if _, err := cw.Write([]byte{164}); err != nil {
+
+	cw := cbg.NewCborWriter(w)
+	fieldCount := 6
+
+	if t.Tag == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+		return err
+	}
+
+	// t.Tag (util.LexBytes) (slice)
+	if t.Tag != nil {
+
+		if len("tag") > 1000000 {
+			return xerrors.Errorf("Value in field \"tag\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("tag"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("tag")); err != nil {
+			return err
+		}
+
+		if len(t.Tag) > 2097152 {
+			return xerrors.Errorf("Byte array in field t.Tag was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajByteString, uint64(len(t.Tag))); err != nil {
+			return err
+		}
+
+		if _, err := cw.Write(t.Tag); err != nil {
+			return err
+		}
+
+	}
+
+	// t.Name (string) (string)
+	if len("name") > 1000000 {
+		return xerrors.Errorf("Value in field \"name\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("name")); err != nil {
 		return err
 	}
 
-	// t.Raw (string) (string)
-	if len("raw") > 1000000 {
-		return xerrors.Errorf("Value in field \"raw\" was too long")
+	if len(t.Name) > 1000000 {
+		return xerrors.Errorf("Value in field t.Name was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("raw"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("raw")); err != nil {
+	if _, err := cw.WriteString(string(t.Name)); err != nil {
 		return err
 	}
 
-	if len(t.Raw) > 1000000 {
-		return xerrors.Errorf("Value in field t.Raw was too long")
+	// t.Repo (string) (string)
+	if len("repo") > 1000000 {
+		return xerrors.Errorf("Value in field \"repo\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Raw))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Raw)); err != nil {
+	if _, err := cw.WriteString(string("repo")); err != nil {
 		return err
 	}
 
-	// t.Name (string) (string)
-	if len("name") > 1000000 {
-		return xerrors.Errorf("Value in field \"name\" was too long")
+	if len(t.Repo) > 1000000 {
+		return xerrors.Errorf("Value in field t.Repo was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("name")); err != nil {
+	if _, err := cw.WriteString(string(t.Repo)); err != nil {
 		return err
 	}
 
-	if len(t.Name) > 1000000 {
-		return xerrors.Errorf("Value in field t.Name was too long")
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Name)); err != nil {
+	if _, err := cw.WriteString(string("$type")); err != nil {
 		return err
 	}
 
-	// t.Clone (tangled.Pipeline_CloneOpts) (struct)
-	if len("clone") > 1000000 {
-		return xerrors.Errorf("Value in field \"clone\" was too long")
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.artifact"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.repo.artifact")); err != nil {
+		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("clone"))); err != nil {
+	// t.Artifact (util.LexBlob) (struct)
+	if len("artifact") > 1000000 {
+		return xerrors.Errorf("Value in field \"artifact\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("artifact"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("clone")); err != nil {
+	if _, err := cw.WriteString(string("artifact")); err != nil {
 		return err
 	}
 
-	if err := t.Clone.MarshalCBOR(cw); err != nil {
+	if err := t.Artifact.MarshalCBOR(cw); err != nil {
 		return err
 	}
 
-	// t.Engine (string) (string)
-	if len("engine") > 1000000 {
-		return xerrors.Errorf("Value in field \"engine\" was too long")
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("engine"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("engine")); err != nil {
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
 		return err
 	}
 
-	if len(t.Engine) > 1000000 {
-		return xerrors.Errorf("Value in field t.Engine was too long")
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Engine))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Engine)); err != nil {
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Pipeline_Workflow{}
+func (t *RepoArtifact) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoArtifact{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -4460,12 +6428,12 @@ func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Pipeline_Workflow: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoArtifact: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 6)
+	nameBuf := make([]byte, 9)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -4481,8 +6449,31 @@ func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Raw (string) (string)
-		case "raw":
+		// t.Tag (util.LexBytes) (slice)
+		case "tag":
+
+			maj, extra, err = cr.ReadHeader()
+			if err != nil {
+				return err
+			}
+
+			if extra > 2097152 {
+				return fmt.Errorf("t.Tag: byte array too large (%d)", extra)
+			}
+			if maj != cbg.MajByteString {
+				return fmt.Errorf("expected byte array")
+			}
+
+			if extra > 0 {
+				t.Tag = make([]uint8, extra)
+			}
+
+			if _, err := io.ReadFull(cr, t.Tag); err != nil {
+				return err
+			}
+
+			// t.Name (string) (string)
+		case "name":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4490,10 +6481,10 @@ func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Raw = string(sval)
+				t.Name = string(sval)
 			}
-			// t.Name (string) (string)
-		case "name":
+			// t.Repo (string) (string)
+		case "repo":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4501,10 +6492,21 @@ func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Name = string(sval)
+				t.Repo = string(sval)
 			}
-			// t.Clone (tangled.Pipeline_CloneOpts) (struct)
-		case "clone":
+			// t.LexiconTypeID (string) (string)
+		case "$type":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.LexiconTypeID = string(sval)
+			}
+			// t.Artifact (util.LexBlob) (struct)
+		case "artifact":
 
 			{
 
@@ -4516,15 +6518,15 @@ func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
 					if err := cr.UnreadByte(); err != nil {
 						return err
 					}
-					t.Clone = new(Pipeline_CloneOpts)
-					if err := t.Clone.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.Clone pointer: %w", err)
+					t.Artifact = new(util.LexBlob)
+					if err := t.Artifact.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Artifact pointer: %w", err)
 					}
 				}
 
 			}
-			// t.Engine (string) (string)
-		case "engine":
+			// t.CreatedAt (string) (string)
+		case "createdAt":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4532,7 +6534,7 @@ func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Engine = string(sval)
+				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -4545,7 +6547,7 @@ func (t *Pipeline_Workflow) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *PublicKey) MarshalCBOR(w io.Writer) error {
+func (t *RepoAttachment) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -4553,53 +6555,53 @@ func (t *PublicKey) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{164}); err != nil {
+	if _, err := cw.Write([]byte{165}); err != nil {
 		return err
 	}
 
-	// t.Key (string) (string)
-	if len("key") > 1000000 {
-		return xerrors.Errorf("Value in field \"key\" was too long")
+	// t.Name (string) (string)
+	if len("name") > 1000000 {
+		return xerrors.Errorf("Value in field \"name\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("key"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("key")); err != nil {
+	if _, err := cw.WriteString(string("name")); err != nil {
 		return err
 	}
 
-	if len(t.Key) > 1000000 {
-		return xerrors.Errorf("Value in field t.Key was too long")
+	if len(t.Name) > 1000000 {
+		return xerrors.Errorf("Value in field t.Name was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Key))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Key)); err != nil {
+	if _, err := cw.WriteString(string(t.Name)); err != nil {
 		return err
 	}
 
-	// t.Name (string) (string)
-	if len("name") > 1000000 {
-		return xerrors.Errorf("Value in field \"name\" was too long")
+	// t.Repo (string) (string)
+	if len("repo") > 1000000 {
+		return xerrors.Errorf("Value in field \"repo\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("name")); err != nil {
+	if _, err := cw.WriteString(string("repo")); err != nil {
 		return err
 	}
 
-	if len(t.Name) > 1000000 {
-		return xerrors.Errorf("Value in field t.Name was too long")
+	if len(t.Repo) > 1000000 {
+		return xerrors.Errorf("Value in field t.Repo was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Name)); err != nil {
+	if _, err := cw.WriteString(string(t.Repo)); err != nil {
 		return err
 	}
 
@@ -4615,10 +6617,10 @@ func (t *PublicKey) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.publicKey"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.attachment"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.publicKey")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.repo.attachment")); err != nil {
 		return err
 	}
 
@@ -4644,11 +6646,27 @@ func (t *PublicKey) MarshalCBOR(w io.Writer) error {
 	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
+
+	// t.Attachment (util.LexBlob) (struct)
+	if len("attachment") > 1000000 {
+		return xerrors.Errorf("Value in field \"attachment\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("attachment"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("attachment")); err != nil {
+		return err
+	}
+
+	if err := t.Attachment.MarshalCBOR(cw); err != nil {
+		return err
+	}
 	return nil
 }
 
-func (t *PublicKey) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = PublicKey{}
+func (t *RepoAttachment) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoAttachment{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -4667,12 +6685,12 @@ func (t *PublicKey) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("PublicKey: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoAttachment: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 9)
+	nameBuf := make([]byte, 10)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -4688,8 +6706,8 @@ func (t *PublicKey) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Key (string) (string)
-		case "key":
+		// t.Name (string) (string)
+		case "name":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4697,10 +6715,10 @@ func (t *PublicKey) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Key = string(sval)
+				t.Name = string(sval)
 			}
-			// t.Name (string) (string)
-		case "name":
+			// t.Repo (string) (string)
+		case "repo":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -4708,7 +6726,7 @@ func (t *PublicKey) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Name = string(sval)
+				t.Repo = string(sval)
 			}
 			// t.LexiconTypeID (string) (string)
 		case "$type":
@@ -4732,85 +6750,69 @@ func (t *PublicKey) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.CreatedAt = string(sval)
 			}
+			// t.Attachment (util.LexBlob) (struct)
+		case "attachment":
 
-		default:
-			// Field doesn't exist on this type, so ignore it
-			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-func (t *Repo) MarshalCBOR(w io.Writer) error {
-	if t == nil {
-		_, err := w.Write(cbg.CborNull)
-		return err
-	}
-
-	cw := cbg.NewCborWriter(w)
-	fieldCount := 8
-
-	if t.Description == nil {
-		fieldCount--
-	}
-
-	if t.Source == nil {
-		fieldCount--
-	}
+			{
 
-	if t.Spindle == nil {
-		fieldCount--
-	}
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Attachment = new(util.LexBlob)
+					if err := t.Attachment.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Attachment pointer: %w", err)
+					}
+				}
 
-	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
-		return err
-	}
+			}
 
-	// t.Knot (string) (string)
-	if len("knot") > 1000000 {
-		return xerrors.Errorf("Value in field \"knot\" was too long")
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("knot"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("knot")); err != nil {
+	return nil
+}
+func (t *RepoCollaborator) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
-	if len(t.Knot) > 1000000 {
-		return xerrors.Errorf("Value in field t.Knot was too long")
-	}
+	cw := cbg.NewCborWriter(w)
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Knot))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Knot)); err != nil {
+	if _, err := cw.Write([]byte{164}); err != nil {
 		return err
 	}
 
-	// t.Name (string) (string)
-	if len("name") > 1000000 {
-		return xerrors.Errorf("Value in field \"name\" was too long")
+	// t.Repo (string) (string)
+	if len("repo") > 1000000 {
+		return xerrors.Errorf("Value in field \"repo\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("name")); err != nil {
+	if _, err := cw.WriteString(string("repo")); err != nil {
 		return err
 	}
 
-	if len(t.Name) > 1000000 {
-		return xerrors.Errorf("Value in field t.Name was too long")
+	if len(t.Repo) > 1000000 {
+		return xerrors.Errorf("Value in field t.Repo was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Name)); err != nil {
+	if _, err := cw.WriteString(string(t.Repo)); err != nil {
 		return err
 	}
 
@@ -4826,100 +6828,36 @@ func (t *Repo) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.collaborator"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.repo")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.repo.collaborator")); err != nil {
 		return err
 	}
 
-	// t.Owner (string) (string)
-	if len("owner") > 1000000 {
-		return xerrors.Errorf("Value in field \"owner\" was too long")
+	// t.Subject (string) (string)
+	if len("subject") > 1000000 {
+		return xerrors.Errorf("Value in field \"subject\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("owner"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("owner")); err != nil {
+	if _, err := cw.WriteString(string("subject")); err != nil {
 		return err
 	}
 
-	if len(t.Owner) > 1000000 {
-		return xerrors.Errorf("Value in field t.Owner was too long")
+	if len(t.Subject) > 1000000 {
+		return xerrors.Errorf("Value in field t.Subject was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Owner))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Owner)); err != nil {
+	if _, err := cw.WriteString(string(t.Subject)); err != nil {
 		return err
 	}
 
-	// t.Source (string) (string)
-	if t.Source != nil {
-
-		if len("source") > 1000000 {
-			return xerrors.Errorf("Value in field \"source\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("source"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("source")); err != nil {
-			return err
-		}
-
-		if t.Source == nil {
-			if _, err := cw.Write(cbg.CborNull); err != nil {
-				return err
-			}
-		} else {
-			if len(*t.Source) > 1000000 {
-				return xerrors.Errorf("Value in field t.Source was too long")
-			}
-
-			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Source))); err != nil {
-				return err
-			}
-			if _, err := cw.WriteString(string(*t.Source)); err != nil {
-				return err
-			}
-		}
-	}
-
-	// t.Spindle (string) (string)
-	if t.Spindle != nil {
-
-		if len("spindle") > 1000000 {
-			return xerrors.Errorf("Value in field \"spindle\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("spindle"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("spindle")); err != nil {
-			return err
-		}
-
-		if t.Spindle == nil {
-			if _, err := cw.Write(cbg.CborNull); err != nil {
-				return err
-			}
-		} else {
-			if len(*t.Spindle) > 1000000 {
-				return xerrors.Errorf("Value in field t.Spindle was too long")
-			}
-
-			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Spindle))); err != nil {
-				return err
-			}
-			if _, err := cw.WriteString(string(*t.Spindle)); err != nil {
-				return err
-			}
-		}
-	}
-
 	// t.CreatedAt (string) (string)
 	if len("createdAt") > 1000000 {
 		return xerrors.Errorf("Value in field \"createdAt\" was too long")
@@ -4942,43 +6880,11 @@ func (t *Repo) MarshalCBOR(w io.Writer) error {
 	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
-
-	// t.Description (string) (string)
-	if t.Description != nil {
-
-		if len("description") > 1000000 {
-			return xerrors.Errorf("Value in field \"description\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("description"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("description")); err != nil {
-			return err
-		}
-
-		if t.Description == nil {
-			if _, err := cw.Write(cbg.CborNull); err != nil {
-				return err
-			}
-		} else {
-			if len(*t.Description) > 1000000 {
-				return xerrors.Errorf("Value in field t.Description was too long")
-			}
-
-			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Description))); err != nil {
-				return err
-			}
-			if _, err := cw.WriteString(string(*t.Description)); err != nil {
-				return err
-			}
-		}
-	}
 	return nil
 }
 
-func (t *Repo) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = Repo{}
+func (t *RepoCollaborator) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoCollaborator{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -4997,12 +6903,12 @@ func (t *Repo) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("Repo: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoCollaborator: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 11)
+	nameBuf := make([]byte, 9)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -5018,19 +6924,8 @@ func (t *Repo) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Knot (string) (string)
-		case "knot":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Knot = string(sval)
-			}
-			// t.Name (string) (string)
-		case "name":
+		// t.Repo (string) (string)
+		case "repo":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -5038,7 +6933,7 @@ func (t *Repo) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Name = string(sval)
+				t.Repo = string(sval)
 			}
 			// t.LexiconTypeID (string) (string)
 		case "$type":
@@ -5049,92 +6944,29 @@ func (t *Repo) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.LexiconTypeID = string(sval)
-			}
-			// t.Owner (string) (string)
-		case "owner":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Owner = string(sval)
-			}
-			// t.Source (string) (string)
-		case "source":
-
-			{
-				b, err := cr.ReadByte()
-				if err != nil {
-					return err
-				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-
-					sval, err := cbg.ReadStringWithMax(cr, 1000000)
-					if err != nil {
-						return err
-					}
-
-					t.Source = (*string)(&sval)
-				}
-			}
-			// t.Spindle (string) (string)
-		case "spindle":
-
-			{
-				b, err := cr.ReadByte()
-				if err != nil {
-					return err
-				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-
-					sval, err := cbg.ReadStringWithMax(cr, 1000000)
-					if err != nil {
-						return err
-					}
-
-					t.Spindle = (*string)(&sval)
-				}
-			}
-			// t.CreatedAt (string) (string)
-		case "createdAt":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.CreatedAt = string(sval)
+				t.LexiconTypeID = string(sval)
 			}
-			// t.Description (string) (string)
-		case "description":
+			// t.Subject (string) (string)
+		case "subject":
 
 			{
-				b, err := cr.ReadByte()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
 
-					sval, err := cbg.ReadStringWithMax(cr, 1000000)
-					if err != nil {
-						return err
-					}
+				t.Subject = string(sval)
+			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
 
-					t.Description = (*string)(&sval)
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
 				}
+
+				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -5147,16 +6979,16 @@ func (t *Repo) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoArtifact) MarshalCBOR(w io.Writer) error {
+func (t *RepoIssue) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 6
+	fieldCount := 5
 
-	if t.Tag == nil {
+	if t.Body == nil {
 		fieldCount--
 	}
 
@@ -5164,55 +6996,36 @@ func (t *RepoArtifact) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.Tag (util.LexBytes) (slice)
-	if t.Tag != nil {
+	// t.Body (string) (string)
+	if t.Body != nil {
 
-		if len("tag") > 1000000 {
-			return xerrors.Errorf("Value in field \"tag\" was too long")
+		if len("body") > 1000000 {
+			return xerrors.Errorf("Value in field \"body\" was too long")
 		}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("tag"))); err != nil {
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
 			return err
 		}
-		if _, err := cw.WriteString(string("tag")); err != nil {
+		if _, err := cw.WriteString(string("body")); err != nil {
 			return err
 		}
 
-		if len(t.Tag) > 2097152 {
-			return xerrors.Errorf("Byte array in field t.Tag was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajByteString, uint64(len(t.Tag))); err != nil {
-			return err
-		}
+		if t.Body == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Body) > 1000000 {
+				return xerrors.Errorf("Value in field t.Body was too long")
+			}
 
-		if _, err := cw.Write(t.Tag); err != nil {
-			return err
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Body))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Body)); err != nil {
+				return err
+			}
 		}
-
-	}
-
-	// t.Name (string) (string)
-	if len("name") > 1000000 {
-		return xerrors.Errorf("Value in field \"name\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("name")); err != nil {
-		return err
-	}
-
-	if len(t.Name) > 1000000 {
-		return xerrors.Errorf("Value in field t.Name was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Name)); err != nil {
-		return err
 	}
 
 	// t.Repo (string) (string)
@@ -5250,26 +7063,33 @@ func (t *RepoArtifact) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.artifact"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.issue"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.repo.artifact")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.repo.issue")); err != nil {
 		return err
 	}
 
-	// t.Artifact (util.LexBlob) (struct)
-	if len("artifact") > 1000000 {
-		return xerrors.Errorf("Value in field \"artifact\" was too long")
+	// t.Title (string) (string)
+	if len("title") > 1000000 {
+		return xerrors.Errorf("Value in field \"title\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("artifact"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("title"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("artifact")); err != nil {
+	if _, err := cw.WriteString(string("title")); err != nil {
 		return err
 	}
 
-	if err := t.Artifact.MarshalCBOR(cw); err != nil {
+	if len(t.Title) > 1000000 {
+		return xerrors.Errorf("Value in field t.Title was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Title))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Title)); err != nil {
 		return err
 	}
 
@@ -5298,8 +7118,8 @@ func (t *RepoArtifact) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
-func (t *RepoArtifact) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoArtifact{}
+func (t *RepoIssue) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoIssue{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -5318,7 +7138,7 @@ func (t *RepoArtifact) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoArtifact: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoIssue: map struct too large (%d)", extra)
 	}
 
 	n := extra
@@ -5339,39 +7159,26 @@ func (t *RepoArtifact) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Tag (util.LexBytes) (slice)
-		case "tag":
-
-			maj, extra, err = cr.ReadHeader()
-			if err != nil {
-				return err
-			}
-
-			if extra > 2097152 {
-				return fmt.Errorf("t.Tag: byte array too large (%d)", extra)
-			}
-			if maj != cbg.MajByteString {
-				return fmt.Errorf("expected byte array")
-			}
-
-			if extra > 0 {
-				t.Tag = make([]uint8, extra)
-			}
-
-			if _, err := io.ReadFull(cr, t.Tag); err != nil {
-				return err
-			}
-
-			// t.Name (string) (string)
-		case "name":
+		// t.Body (string) (string)
+		case "body":
 
 			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				b, err := cr.ReadByte()
 				if err != nil {
 					return err
 				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
 
-				t.Name = string(sval)
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Body = (*string)(&sval)
+				}
 			}
 			// t.Repo (string) (string)
 		case "repo":
@@ -5395,25 +7202,16 @@ func (t *RepoArtifact) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.Artifact (util.LexBlob) (struct)
-		case "artifact":
+			// t.Title (string) (string)
+		case "title":
 
 			{
-
-				b, err := cr.ReadByte()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.Artifact = new(util.LexBlob)
-					if err := t.Artifact.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.Artifact pointer: %w", err)
-					}
-				}
 
+				t.Title = string(sval)
 			}
 			// t.CreatedAt (string) (string)
 		case "createdAt":
@@ -5437,41 +7235,82 @@ func (t *RepoArtifact) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoCollaborator) MarshalCBOR(w io.Writer) error {
+func (t *RepoIssueComment) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
+	fieldCount := 6
 
-	if _, err := cw.Write([]byte{164}); err != nil {
+	if t.Owner == nil {
+		fieldCount--
+	}
+
+	if t.Repo == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
 		return err
 	}
 
-	// t.Repo (string) (string)
-	if len("repo") > 1000000 {
-		return xerrors.Errorf("Value in field \"repo\" was too long")
+	// t.Body (string) (string)
+	if len("body") > 1000000 {
+		return xerrors.Errorf("Value in field \"body\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("repo")); err != nil {
+	if _, err := cw.WriteString(string("body")); err != nil {
 		return err
 	}
 
-	if len(t.Repo) > 1000000 {
-		return xerrors.Errorf("Value in field t.Repo was too long")
+	if len(t.Body) > 1000000 {
+		return xerrors.Errorf("Value in field t.Body was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Body))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Repo)); err != nil {
+	if _, err := cw.WriteString(string(t.Body)); err != nil {
 		return err
 	}
 
+	// t.Repo (string) (string)
+	if t.Repo != nil {
+
+		if len("repo") > 1000000 {
+			return xerrors.Errorf("Value in field \"repo\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("repo")); err != nil {
+			return err
+		}
+
+		if t.Repo == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Repo) > 1000000 {
+				return xerrors.Errorf("Value in field t.Repo was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Repo))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Repo)); err != nil {
+				return err
+			}
+		}
+	}
+
 	// t.LexiconTypeID (string) (string)
 	if len("$type") > 1000000 {
 		return xerrors.Errorf("Value in field \"$type\" was too long")
@@ -5484,34 +7323,66 @@ func (t *RepoCollaborator) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.collaborator"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.issue.comment"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.repo.issue.comment")); err != nil {
+		return err
+	}
+
+	// t.Issue (string) (string)
+	if len("issue") > 1000000 {
+		return xerrors.Errorf("Value in field \"issue\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("issue"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.repo.collaborator")); err != nil {
+	if _, err := cw.WriteString(string("issue")); err != nil {
 		return err
 	}
 
-	// t.Subject (string) (string)
-	if len("subject") > 1000000 {
-		return xerrors.Errorf("Value in field \"subject\" was too long")
+	if len(t.Issue) > 1000000 {
+		return xerrors.Errorf("Value in field t.Issue was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Issue))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("subject")); err != nil {
+	if _, err := cw.WriteString(string(t.Issue)); err != nil {
 		return err
 	}
 
-	if len(t.Subject) > 1000000 {
-		return xerrors.Errorf("Value in field t.Subject was too long")
-	}
+	// t.Owner (string) (string)
+	if t.Owner != nil {
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Subject)); err != nil {
-		return err
+		if len("owner") > 1000000 {
+			return xerrors.Errorf("Value in field \"owner\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("owner"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("owner")); err != nil {
+			return err
+		}
+
+		if t.Owner == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Owner) > 1000000 {
+				return xerrors.Errorf("Value in field t.Owner was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Owner))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Owner)); err != nil {
+				return err
+			}
+		}
 	}
 
 	// t.CreatedAt (string) (string)
@@ -5539,8 +7410,8 @@ func (t *RepoCollaborator) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
-func (t *RepoCollaborator) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoCollaborator{}
+func (t *RepoIssueComment) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoIssueComment{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -5559,7 +7430,7 @@ func (t *RepoCollaborator) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoCollaborator: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoIssueComment: map struct too large (%d)", extra)
 	}
 
 	n := extra
@@ -5580,8 +7451,8 @@ func (t *RepoCollaborator) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Repo (string) (string)
-		case "repo":
+		// t.Body (string) (string)
+		case "body":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -5589,7 +7460,28 @@ func (t *RepoCollaborator) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Repo = string(sval)
+				t.Body = string(sval)
+			}
+			// t.Repo (string) (string)
+		case "repo":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Repo = (*string)(&sval)
+				}
 			}
 			// t.LexiconTypeID (string) (string)
 		case "$type":
@@ -5602,8 +7494,8 @@ func (t *RepoCollaborator) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.Subject (string) (string)
-		case "subject":
+			// t.Issue (string) (string)
+		case "issue":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -5611,7 +7503,28 @@ func (t *RepoCollaborator) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Subject = string(sval)
+				t.Issue = string(sval)
+			}
+			// t.Owner (string) (string)
+		case "owner":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Owner = (*string)(&sval)
+				}
 			}
 			// t.CreatedAt (string) (string)
 		case "createdAt":
@@ -5635,75 +7548,15 @@ func (t *RepoCollaborator) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoIssue) MarshalCBOR(w io.Writer) error {
+func (t *RepoIssueState) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 5
-
-	if t.Body == nil {
-		fieldCount--
-	}
-
-	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
-		return err
-	}
-
-	// t.Body (string) (string)
-	if t.Body != nil {
-
-		if len("body") > 1000000 {
-			return xerrors.Errorf("Value in field \"body\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("body")); err != nil {
-			return err
-		}
-
-		if t.Body == nil {
-			if _, err := cw.Write(cbg.CborNull); err != nil {
-				return err
-			}
-		} else {
-			if len(*t.Body) > 1000000 {
-				return xerrors.Errorf("Value in field t.Body was too long")
-			}
-
-			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Body))); err != nil {
-				return err
-			}
-			if _, err := cw.WriteString(string(*t.Body)); err != nil {
-				return err
-			}
-		}
-	}
-
-	// t.Repo (string) (string)
-	if len("repo") > 1000000 {
-		return xerrors.Errorf("Value in field \"repo\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("repo")); err != nil {
-		return err
-	}
-
-	if len(t.Repo) > 1000000 {
-		return xerrors.Errorf("Value in field t.Repo was too long")
-	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Repo)); err != nil {
+	if _, err := cw.Write([]byte{163}); err != nil {
 		return err
 	}
 
@@ -5719,63 +7572,63 @@ func (t *RepoIssue) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.issue"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.issue.state"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.repo.issue")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.repo.issue.state")); err != nil {
 		return err
 	}
 
-	// t.Title (string) (string)
-	if len("title") > 1000000 {
-		return xerrors.Errorf("Value in field \"title\" was too long")
+	// t.Issue (string) (string)
+	if len("issue") > 1000000 {
+		return xerrors.Errorf("Value in field \"issue\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("title"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("issue"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("title")); err != nil {
+	if _, err := cw.WriteString(string("issue")); err != nil {
 		return err
 	}
 
-	if len(t.Title) > 1000000 {
-		return xerrors.Errorf("Value in field t.Title was too long")
+	if len(t.Issue) > 1000000 {
+		return xerrors.Errorf("Value in field t.Issue was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Title))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Issue))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Title)); err != nil {
+	if _, err := cw.WriteString(string(t.Issue)); err != nil {
 		return err
 	}
 
-	// t.CreatedAt (string) (string)
-	if len("createdAt") > 1000000 {
-		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	// t.State (string) (string)
+	if len("state") > 1000000 {
+		return xerrors.Errorf("Value in field \"state\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("state"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("createdAt")); err != nil {
+	if _, err := cw.WriteString(string("state")); err != nil {
 		return err
 	}
 
-	if len(t.CreatedAt) > 1000000 {
-		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	if len(t.State) > 1000000 {
+		return xerrors.Errorf("Value in field t.State was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.State))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+	if _, err := cw.WriteString(string(t.State)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *RepoIssue) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoIssue{}
+func (t *RepoIssueState) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoIssueState{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -5794,12 +7647,12 @@ func (t *RepoIssue) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoIssue: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoIssueState: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 9)
+	nameBuf := make([]byte, 5)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -5811,43 +7664,11 @@ func (t *RepoIssue) UnmarshalCBOR(r io.Reader) (err error) {
 			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
 				return err
 			}
-			continue
-		}
-
-		switch string(nameBuf[:nameLen]) {
-		// t.Body (string) (string)
-		case "body":
-
-			{
-				b, err := cr.ReadByte()
-				if err != nil {
-					return err
-				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-
-					sval, err := cbg.ReadStringWithMax(cr, 1000000)
-					if err != nil {
-						return err
-					}
-
-					t.Body = (*string)(&sval)
-				}
-			}
-			// t.Repo (string) (string)
-		case "repo":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Repo = string(sval)
-			}
-			// t.LexiconTypeID (string) (string)
+			continue
+		}
+
+		switch string(nameBuf[:nameLen]) {
+		// t.LexiconTypeID (string) (string)
 		case "$type":
 
 			{
@@ -5858,8 +7679,8 @@ func (t *RepoIssue) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.Title (string) (string)
-		case "title":
+			// t.Issue (string) (string)
+		case "issue":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -5867,10 +7688,10 @@ func (t *RepoIssue) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Title = string(sval)
+				t.Issue = string(sval)
 			}
-			// t.CreatedAt (string) (string)
-		case "createdAt":
+			// t.State (string) (string)
+		case "state":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -5878,7 +7699,7 @@ func (t *RepoIssue) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.CreatedAt = string(sval)
+				t.State = string(sval)
 			}
 
 		default:
@@ -5891,18 +7712,14 @@ func (t *RepoIssue) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoIssueComment) MarshalCBOR(w io.Writer) error {
+func (t *RepoLabel) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 6
-
-	if t.Owner == nil {
-		fieldCount--
-	}
+	fieldCount := 5
 
 	if t.Repo == nil {
 		fieldCount--
@@ -5912,26 +7729,26 @@ func (t *RepoIssueComment) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.Body (string) (string)
-	if len("body") > 1000000 {
-		return xerrors.Errorf("Value in field \"body\" was too long")
+	// t.Name (string) (string)
+	if len("name") > 1000000 {
+		return xerrors.Errorf("Value in field \"name\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("body")); err != nil {
+	if _, err := cw.WriteString(string("name")); err != nil {
 		return err
 	}
 
-	if len(t.Body) > 1000000 {
-		return xerrors.Errorf("Value in field t.Body was too long")
+	if len(t.Name) > 1000000 {
+		return xerrors.Errorf("Value in field t.Name was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Body))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Body)); err != nil {
+	if _, err := cw.WriteString(string(t.Name)); err != nil {
 		return err
 	}
 
@@ -5979,68 +7796,36 @@ func (t *RepoIssueComment) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.issue.comment"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.label"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.repo.issue.comment")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.repo.label")); err != nil {
 		return err
 	}
 
-	// t.Issue (string) (string)
-	if len("issue") > 1000000 {
-		return xerrors.Errorf("Value in field \"issue\" was too long")
+	// t.Subject (string) (string)
+	if len("subject") > 1000000 {
+		return xerrors.Errorf("Value in field \"subject\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("issue"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("subject"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("issue")); err != nil {
+	if _, err := cw.WriteString(string("subject")); err != nil {
 		return err
 	}
 
-	if len(t.Issue) > 1000000 {
-		return xerrors.Errorf("Value in field t.Issue was too long")
+	if len(t.Subject) > 1000000 {
+		return xerrors.Errorf("Value in field t.Subject was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Issue))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Subject))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Issue)); err != nil {
+	if _, err := cw.WriteString(string(t.Subject)); err != nil {
 		return err
 	}
 
-	// t.Owner (string) (string)
-	if t.Owner != nil {
-
-		if len("owner") > 1000000 {
-			return xerrors.Errorf("Value in field \"owner\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("owner"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("owner")); err != nil {
-			return err
-		}
-
-		if t.Owner == nil {
-			if _, err := cw.Write(cbg.CborNull); err != nil {
-				return err
-			}
-		} else {
-			if len(*t.Owner) > 1000000 {
-				return xerrors.Errorf("Value in field t.Owner was too long")
-			}
-
-			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Owner))); err != nil {
-				return err
-			}
-			if _, err := cw.WriteString(string(*t.Owner)); err != nil {
-				return err
-			}
-		}
-	}
-
 	// t.CreatedAt (string) (string)
 	if len("createdAt") > 1000000 {
 		return xerrors.Errorf("Value in field \"createdAt\" was too long")
@@ -6066,8 +7851,8 @@ func (t *RepoIssueComment) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
-func (t *RepoIssueComment) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoIssueComment{}
+func (t *RepoLabel) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoLabel{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -6086,7 +7871,7 @@ func (t *RepoIssueComment) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoIssueComment: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoLabel: map struct too large (%d)", extra)
 	}
 
 	n := extra
@@ -6107,8 +7892,8 @@ func (t *RepoIssueComment) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Body (string) (string)
-		case "body":
+		// t.Name (string) (string)
+		case "name":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6116,7 +7901,7 @@ func (t *RepoIssueComment) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Body = string(sval)
+				t.Name = string(sval)
 			}
 			// t.Repo (string) (string)
 		case "repo":
@@ -6150,8 +7935,8 @@ func (t *RepoIssueComment) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.Issue (string) (string)
-		case "issue":
+			// t.Subject (string) (string)
+		case "subject":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6159,28 +7944,7 @@ func (t *RepoIssueComment) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Issue = string(sval)
-			}
-			// t.Owner (string) (string)
-		case "owner":
-
-			{
-				b, err := cr.ReadByte()
-				if err != nil {
-					return err
-				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-
-					sval, err := cbg.ReadStringWithMax(cr, 1000000)
-					if err != nil {
-						return err
-					}
-
-					t.Owner = (*string)(&sval)
-				}
+				t.Subject = string(sval)
 			}
 			// t.CreatedAt (string) (string)
 		case "createdAt":
@@ -6204,18 +7968,59 @@ func (t *RepoIssueComment) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoIssueState) MarshalCBOR(w io.Writer) error {
+func (t *RepoPull) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
+	fieldCount := 7
 
-	if _, err := cw.Write([]byte{163}); err != nil {
+	if t.Body == nil {
+		fieldCount--
+	}
+
+	if t.Source == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
 		return err
 	}
 
+	// t.Body (string) (string)
+	if t.Body != nil {
+
+		if len("body") > 1000000 {
+			return xerrors.Errorf("Value in field \"body\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("body")); err != nil {
+			return err
+		}
+
+		if t.Body == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Body) > 1000000 {
+				return xerrors.Errorf("Value in field t.Body was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Body))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Body)); err != nil {
+				return err
+			}
+		}
+	}
+
 	// t.LexiconTypeID (string) (string)
 	if len("$type") > 1000000 {
 		return xerrors.Errorf("Value in field \"$type\" was too long")
@@ -6228,63 +8033,121 @@ func (t *RepoIssueState) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.issue.state"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.pull"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.repo.issue.state")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.repo.pull")); err != nil {
 		return err
 	}
 
-	// t.Issue (string) (string)
-	if len("issue") > 1000000 {
-		return xerrors.Errorf("Value in field \"issue\" was too long")
+	// t.Patch (string) (string)
+	if len("patch") > 1000000 {
+		return xerrors.Errorf("Value in field \"patch\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("issue"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("patch"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("issue")); err != nil {
+	if _, err := cw.WriteString(string("patch")); err != nil {
 		return err
 	}
 
-	if len(t.Issue) > 1000000 {
-		return xerrors.Errorf("Value in field t.Issue was too long")
+	if len(t.Patch) > 1000000 {
+		return xerrors.Errorf("Value in field t.Patch was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Issue))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Patch))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Issue)); err != nil {
+	if _, err := cw.WriteString(string(t.Patch)); err != nil {
 		return err
 	}
 
-	// t.State (string) (string)
-	if len("state") > 1000000 {
-		return xerrors.Errorf("Value in field \"state\" was too long")
+	// t.Title (string) (string)
+	if len("title") > 1000000 {
+		return xerrors.Errorf("Value in field \"title\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("title"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("title")); err != nil {
+		return err
+	}
+
+	if len(t.Title) > 1000000 {
+		return xerrors.Errorf("Value in field t.Title was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Title))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Title)); err != nil {
+		return err
+	}
+
+	// t.Source (tangled.RepoPull_Source) (struct)
+	if t.Source != nil {
+
+		if len("source") > 1000000 {
+			return xerrors.Errorf("Value in field \"source\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("source"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("source")); err != nil {
+			return err
+		}
+
+		if err := t.Source.MarshalCBOR(cw); err != nil {
+			return err
+		}
+	}
+
+	// t.Target (tangled.RepoPull_Target) (struct)
+	if len("target") > 1000000 {
+		return xerrors.Errorf("Value in field \"target\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("target"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("target")); err != nil {
+		return err
+	}
+
+	if err := t.Target.MarshalCBOR(cw); err != nil {
+		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("state"))); err != nil {
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("state")); err != nil {
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
 		return err
 	}
 
-	if len(t.State) > 1000000 {
-		return xerrors.Errorf("Value in field t.State was too long")
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.State))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.State)); err != nil {
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *RepoIssueState) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoIssueState{}
+func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoPull{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -6303,12 +8166,12 @@ func (t *RepoIssueState) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoIssueState: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoPull: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 5)
+	nameBuf := make([]byte, 9)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -6324,7 +8187,28 @@ func (t *RepoIssueState) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.LexiconTypeID (string) (string)
+		// t.Body (string) (string)
+		case "body":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Body = (*string)(&sval)
+				}
+			}
+			// t.LexiconTypeID (string) (string)
 		case "$type":
 
 			{
@@ -6335,8 +8219,8 @@ func (t *RepoIssueState) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.Issue (string) (string)
-		case "issue":
+			// t.Patch (string) (string)
+		case "patch":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6344,10 +8228,10 @@ func (t *RepoIssueState) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Issue = string(sval)
+				t.Patch = string(sval)
 			}
-			// t.State (string) (string)
-		case "state":
+			// t.Title (string) (string)
+		case "title":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6355,7 +8239,58 @@ func (t *RepoIssueState) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.State = string(sval)
+				t.Title = string(sval)
+			}
+			// t.Source (tangled.RepoPull_Source) (struct)
+		case "source":
+
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Source = new(RepoPull_Source)
+					if err := t.Source.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Source pointer: %w", err)
+					}
+				}
+
+			}
+			// t.Target (tangled.RepoPull_Target) (struct)
+		case "target":
+
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					t.Target = new(RepoPull_Target)
+					if err := t.Target.UnmarshalCBOR(cr); err != nil {
+						return xerrors.Errorf("unmarshaling t.Target pointer: %w", err)
+					}
+				}
+
+			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -6368,20 +8303,20 @@ func (t *RepoIssueState) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoPull) MarshalCBOR(w io.Writer) error {
+func (t *RepoPullComment) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 7
+	fieldCount := 6
 
-	if t.Body == nil {
+	if t.Line == nil {
 		fieldCount--
 	}
 
-	if t.Source == nil {
+	if t.Path == nil {
 		fieldCount--
 	}
 
@@ -6390,134 +8325,131 @@ func (t *RepoPull) MarshalCBOR(w io.Writer) error {
 	}
 
 	// t.Body (string) (string)
-	if t.Body != nil {
+	if len("body") > 1000000 {
+		return xerrors.Errorf("Value in field \"body\" was too long")
+	}
 
-		if len("body") > 1000000 {
-			return xerrors.Errorf("Value in field \"body\" was too long")
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("body")); err != nil {
+		return err
+	}
+
+	if len(t.Body) > 1000000 {
+		return xerrors.Errorf("Value in field t.Body was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Body))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Body)); err != nil {
+		return err
+	}
+
+	// t.Line (int64) (int64)
+	if t.Line != nil {
+
+		if len("line") > 1000000 {
+			return xerrors.Errorf("Value in field \"line\" was too long")
 		}
 
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("line"))); err != nil {
 			return err
 		}
-		if _, err := cw.WriteString(string("body")); err != nil {
+		if _, err := cw.WriteString(string("line")); err != nil {
 			return err
 		}
 
-		if t.Body == nil {
+		if t.Line == nil {
 			if _, err := cw.Write(cbg.CborNull); err != nil {
 				return err
 			}
 		} else {
-			if len(*t.Body) > 1000000 {
-				return xerrors.Errorf("Value in field t.Body was too long")
-			}
-
-			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Body))); err != nil {
-				return err
-			}
-			if _, err := cw.WriteString(string(*t.Body)); err != nil {
-				return err
+			if *t.Line >= 0 {
+				if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(*t.Line)); err != nil {
+					return err
+				}
+			} else {
+				if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-*t.Line-1)); err != nil {
+					return err
+				}
 			}
 		}
-	}
 
-	// t.LexiconTypeID (string) (string)
-	if len("$type") > 1000000 {
-		return xerrors.Errorf("Value in field \"$type\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("$type")); err != nil {
-		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.pull"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("sh.tangled.repo.pull")); err != nil {
-		return err
-	}
+	// t.Path (string) (string)
+	if t.Path != nil {
 
-	// t.Patch (string) (string)
-	if len("patch") > 1000000 {
-		return xerrors.Errorf("Value in field \"patch\" was too long")
-	}
+		if len("path") > 1000000 {
+			return xerrors.Errorf("Value in field \"path\" was too long")
+		}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("patch"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("patch")); err != nil {
-		return err
-	}
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("path"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("path")); err != nil {
+			return err
+		}
 
-	if len(t.Patch) > 1000000 {
-		return xerrors.Errorf("Value in field t.Patch was too long")
-	}
+		if t.Path == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Path) > 1000000 {
+				return xerrors.Errorf("Value in field t.Path was too long")
+			}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Patch))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Patch)); err != nil {
-		return err
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Path))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Path)); err != nil {
+				return err
+			}
+		}
 	}
 
-	// t.Title (string) (string)
-	if len("title") > 1000000 {
-		return xerrors.Errorf("Value in field \"title\" was too long")
+	// t.Pull (string) (string)
+	if len("pull") > 1000000 {
+		return xerrors.Errorf("Value in field \"pull\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("title"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("pull"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("title")); err != nil {
+	if _, err := cw.WriteString(string("pull")); err != nil {
 		return err
 	}
 
-	if len(t.Title) > 1000000 {
-		return xerrors.Errorf("Value in field t.Title was too long")
+	if len(t.Pull) > 1000000 {
+		return xerrors.Errorf("Value in field t.Pull was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Title))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Pull))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Title)); err != nil {
+	if _, err := cw.WriteString(string(t.Pull)); err != nil {
 		return err
 	}
 
-	// t.Source (tangled.RepoPull_Source) (struct)
-	if t.Source != nil {
-
-		if len("source") > 1000000 {
-			return xerrors.Errorf("Value in field \"source\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("source"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("source")); err != nil {
-			return err
-		}
-
-		if err := t.Source.MarshalCBOR(cw); err != nil {
-			return err
-		}
-	}
-
-	// t.Target (tangled.RepoPull_Target) (struct)
-	if len("target") > 1000000 {
-		return xerrors.Errorf("Value in field \"target\" was too long")
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("target"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("target")); err != nil {
+	if _, err := cw.WriteString(string("$type")); err != nil {
 		return err
 	}
 
-	if err := t.Target.MarshalCBOR(cw); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.pull.comment"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.repo.pull.comment")); err != nil {
 		return err
 	}
 
@@ -6546,8 +8478,8 @@ func (t *RepoPull) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
-func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoPull{}
+func (t *RepoPullComment) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoPullComment{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -6566,7 +8498,7 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoPull: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoPullComment: map struct too large (%d)", extra)
 	}
 
 	n := extra
@@ -6590,6 +8522,53 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 		// t.Body (string) (string)
 		case "body":
 
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Body = string(sval)
+			}
+			// t.Line (int64) (int64)
+		case "line":
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					maj, extra, err := cr.ReadHeader()
+					if err != nil {
+						return err
+					}
+					var extraI int64
+					switch maj {
+					case cbg.MajUnsignedInt:
+						extraI = int64(extra)
+						if extraI < 0 {
+							return fmt.Errorf("int64 positive overflow")
+						}
+					case cbg.MajNegativeInt:
+						extraI = int64(extra)
+						if extraI < 0 {
+							return fmt.Errorf("int64 negative overflow")
+						}
+						extraI = -1 - extraI
+					default:
+						return fmt.Errorf("wrong type for int64 field: %d", maj)
+					}
+
+					t.Line = (*int64)(&extraI)
+				}
+			}
+			// t.Path (string) (string)
+		case "path":
+
 			{
 				b, err := cr.ReadByte()
 				if err != nil {
@@ -6605,11 +8584,11 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 						return err
 					}
 
-					t.Body = (*string)(&sval)
+					t.Path = (*string)(&sval)
 				}
 			}
-			// t.LexiconTypeID (string) (string)
-		case "$type":
+			// t.Pull (string) (string)
+		case "pull":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6617,10 +8596,10 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.LexiconTypeID = string(sval)
+				t.Pull = string(sval)
 			}
-			// t.Patch (string) (string)
-		case "patch":
+			// t.LexiconTypeID (string) (string)
+		case "$type":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6628,10 +8607,10 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Patch = string(sval)
+				t.LexiconTypeID = string(sval)
 			}
-			// t.Title (string) (string)
-		case "title":
+			// t.CreatedAt (string) (string)
+		case "createdAt":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6639,33 +8618,172 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Title = string(sval)
+				t.CreatedAt = string(sval)
 			}
-			// t.Source (tangled.RepoPull_Source) (struct)
-		case "source":
 
-			{
+		default:
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(r, func(cid.Cid) {}); err != nil {
+				return err
+			}
+		}
+	}
 
-				b, err := cr.ReadByte()
+	return nil
+}
+func (t *RepoPull_Source) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+
+	cw := cbg.NewCborWriter(w)
+	fieldCount := 3
+
+	if t.Repo == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+		return err
+	}
+
+	// t.Sha (string) (string)
+	if len("sha") > 1000000 {
+		return xerrors.Errorf("Value in field \"sha\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sha"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sha")); err != nil {
+		return err
+	}
+
+	if len(t.Sha) > 1000000 {
+		return xerrors.Errorf("Value in field t.Sha was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Sha))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Sha)); err != nil {
+		return err
+	}
+
+	// t.Repo (string) (string)
+	if t.Repo != nil {
+
+		if len("repo") > 1000000 {
+			return xerrors.Errorf("Value in field \"repo\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("repo")); err != nil {
+			return err
+		}
+
+		if t.Repo == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Repo) > 1000000 {
+				return xerrors.Errorf("Value in field t.Repo was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Repo))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Repo)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// t.Branch (string) (string)
+	if len("branch") > 1000000 {
+		return xerrors.Errorf("Value in field \"branch\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("branch"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("branch")); err != nil {
+		return err
+	}
+
+	if len(t.Branch) > 1000000 {
+		return xerrors.Errorf("Value in field t.Branch was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Branch))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Branch)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *RepoPull_Source) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoPull_Source{}
+
+	cr := cbg.NewCborReader(r)
+
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	if maj != cbg.MajMap {
+		return fmt.Errorf("cbor input should be of type map")
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("RepoPull_Source: map struct too large (%d)", extra)
+	}
+
+	n := extra
+
+	nameBuf := make([]byte, 6)
+	for i := uint64(0); i < n; i++ {
+		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			// Field doesn't exist on this type, so ignore it
+			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch string(nameBuf[:nameLen]) {
+		// t.Sha (string) (string)
+		case "sha":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-					t.Source = new(RepoPull_Source)
-					if err := t.Source.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.Source pointer: %w", err)
-					}
-				}
 
+				t.Sha = string(sval)
 			}
-			// t.Target (tangled.RepoPull_Target) (struct)
-		case "target":
+			// t.Repo (string) (string)
+		case "repo":
 
 			{
-
 				b, err := cr.ReadByte()
 				if err != nil {
 					return err
@@ -6674,15 +8792,17 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 					if err := cr.UnreadByte(); err != nil {
 						return err
 					}
-					t.Target = new(RepoPull_Target)
-					if err := t.Target.UnmarshalCBOR(cr); err != nil {
-						return xerrors.Errorf("unmarshaling t.Target pointer: %w", err)
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
 					}
-				}
 
+					t.Repo = (*string)(&sval)
+				}
 			}
-			// t.CreatedAt (string) (string)
-		case "createdAt":
+			// t.Branch (string) (string)
+		case "branch":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6690,7 +8810,7 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.CreatedAt = string(sval)
+				t.Branch = string(sval)
 			}
 
 		default:
@@ -6703,7 +8823,7 @@ func (t *RepoPull) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoPullComment) MarshalCBOR(w io.Writer) error {
+func (t *RepoPullStatus) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -6711,30 +8831,7 @@ func (t *RepoPullComment) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{164}); err != nil {
-		return err
-	}
-
-	// t.Body (string) (string)
-	if len("body") > 1000000 {
-		return xerrors.Errorf("Value in field \"body\" was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string("body")); err != nil {
-		return err
-	}
-
-	if len(t.Body) > 1000000 {
-		return xerrors.Errorf("Value in field t.Body was too long")
-	}
-
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Body))); err != nil {
-		return err
-	}
-	if _, err := cw.WriteString(string(t.Body)); err != nil {
+	if _, err := cw.Write([]byte{163}); err != nil {
 		return err
 	}
 
@@ -6773,40 +8870,40 @@ func (t *RepoPullComment) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.pull.comment"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.pull.status"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.repo.pull.comment")); err != nil {
+	if _, err := cw.WriteString(string("sh.tangled.repo.pull.status")); err != nil {
 		return err
 	}
 
-	// t.CreatedAt (string) (string)
-	if len("createdAt") > 1000000 {
-		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	// t.Status (string) (string)
+	if len("status") > 1000000 {
+		return xerrors.Errorf("Value in field \"status\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("status"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("createdAt")); err != nil {
+	if _, err := cw.WriteString(string("status")); err != nil {
 		return err
 	}
 
-	if len(t.CreatedAt) > 1000000 {
-		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	if len(t.Status) > 1000000 {
+		return xerrors.Errorf("Value in field t.Status was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Status))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
+	if _, err := cw.WriteString(string(t.Status)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *RepoPullComment) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoPullComment{}
+func (t *RepoPullStatus) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoPullStatus{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -6825,12 +8922,12 @@ func (t *RepoPullComment) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoPullComment: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoPullStatus: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 9)
+	nameBuf := make([]byte, 6)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -6846,18 +8943,7 @@ func (t *RepoPullComment) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Body (string) (string)
-		case "body":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Body = string(sval)
-			}
-			// t.Pull (string) (string)
+		// t.Pull (string) (string)
 		case "pull":
 
 			{
@@ -6879,8 +8965,8 @@ func (t *RepoPullComment) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.CreatedAt (string) (string)
-		case "createdAt":
+			// t.Status (string) (string)
+		case "status":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -6888,7 +8974,7 @@ func (t *RepoPullComment) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.CreatedAt = string(sval)
+				t.Status = string(sval)
 			}
 
 		default:
@@ -6901,78 +8987,41 @@ func (t *RepoPullComment) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoPull_Source) MarshalCBOR(w io.Writer) error {
+func (t *RepoPull_Target) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
-	fieldCount := 3
-
-	if t.Repo == nil {
-		fieldCount--
-	}
 
-	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
+	if _, err := cw.Write([]byte{162}); err != nil {
 		return err
 	}
 
-	// t.Sha (string) (string)
-	if len("sha") > 1000000 {
-		return xerrors.Errorf("Value in field \"sha\" was too long")
+	// t.Repo (string) (string)
+	if len("repo") > 1000000 {
+		return xerrors.Errorf("Value in field \"repo\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sha"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sha")); err != nil {
+	if _, err := cw.WriteString(string("repo")); err != nil {
 		return err
 	}
 
-	if len(t.Sha) > 1000000 {
-		return xerrors.Errorf("Value in field t.Sha was too long")
+	if len(t.Repo) > 1000000 {
+		return xerrors.Errorf("Value in field t.Repo was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Sha))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Sha)); err != nil {
+	if _, err := cw.WriteString(string(t.Repo)); err != nil {
 		return err
 	}
 
-	// t.Repo (string) (string)
-	if t.Repo != nil {
-
-		if len("repo") > 1000000 {
-			return xerrors.Errorf("Value in field \"repo\" was too long")
-		}
-
-		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
-			return err
-		}
-		if _, err := cw.WriteString(string("repo")); err != nil {
-			return err
-		}
-
-		if t.Repo == nil {
-			if _, err := cw.Write(cbg.CborNull); err != nil {
-				return err
-			}
-		} else {
-			if len(*t.Repo) > 1000000 {
-				return xerrors.Errorf("Value in field t.Repo was too long")
-			}
-
-			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Repo))); err != nil {
-				return err
-			}
-			if _, err := cw.WriteString(string(*t.Repo)); err != nil {
-				return err
-			}
-		}
-	}
-
 	// t.Branch (string) (string)
 	if len("branch") > 1000000 {
 		return xerrors.Errorf("Value in field \"branch\" was too long")
@@ -6998,8 +9047,8 @@ func (t *RepoPull_Source) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
-func (t *RepoPull_Source) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoPull_Source{}
+func (t *RepoPull_Target) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoPull_Target{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -7018,7 +9067,7 @@ func (t *RepoPull_Source) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoPull_Source: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoPull_Target: map struct too large (%d)", extra)
 	}
 
 	n := extra
@@ -7039,37 +9088,16 @@ func (t *RepoPull_Source) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Sha (string) (string)
-		case "sha":
-
-			{
-				sval, err := cbg.ReadStringWithMax(cr, 1000000)
-				if err != nil {
-					return err
-				}
-
-				t.Sha = string(sval)
-			}
-			// t.Repo (string) (string)
+		// t.Repo (string) (string)
 		case "repo":
 
 			{
-				b, err := cr.ReadByte()
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
 				if err != nil {
 					return err
 				}
-				if b != cbg.CborNull[0] {
-					if err := cr.UnreadByte(); err != nil {
-						return err
-					}
-
-					sval, err := cbg.ReadStringWithMax(cr, 1000000)
-					if err != nil {
-						return err
-					}
 
-					t.Repo = (*string)(&sval)
-				}
+				t.Repo = string(sval)
 			}
 			// t.Branch (string) (string)
 		case "branch":
@@ -7093,18 +9121,59 @@ func (t *RepoPull_Source) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoPullStatus) MarshalCBOR(w io.Writer) error {
+func (t *RepoPullReview) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
 
 	cw := cbg.NewCborWriter(w)
+	fieldCount := 6
 
-	if _, err := cw.Write([]byte{163}); err != nil {
+	if t.Body == nil {
+		fieldCount--
+	}
+
+	if t.Round == nil {
+		fieldCount--
+	}
+
+	if _, err := cw.Write(cbg.CborEncodeMajorType(cbg.MajMap, uint64(fieldCount))); err != nil {
 		return err
 	}
 
+	// t.Body (string) (string)
+	if t.Body != nil {
+
+		if len("body") > 1000000 {
+			return xerrors.Errorf("Value in field \"body\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("body"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("body")); err != nil {
+			return err
+		}
+
+		if t.Body == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if len(*t.Body) > 1000000 {
+				return xerrors.Errorf("Value in field t.Body was too long")
+			}
+
+			if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(*t.Body))); err != nil {
+				return err
+			}
+			if _, err := cw.WriteString(string(*t.Body)); err != nil {
+				return err
+			}
+		}
+	}
+
 	// t.Pull (string) (string)
 	if len("pull") > 1000000 {
 		return xerrors.Errorf("Value in field \"pull\" was too long")
@@ -7136,44 +9205,99 @@ func (t *RepoPullStatus) MarshalCBOR(w io.Writer) error {
 	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("$type")); err != nil {
-		return err
+	if _, err := cw.WriteString(string("$type")); err != nil {
+		return err
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.pull.review"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.repo.pull.review")); err != nil {
+		return err
+	}
+
+	// t.Round (int64) (int64)
+	if t.Round != nil {
+
+		if len("round") > 1000000 {
+			return xerrors.Errorf("Value in field \"round\" was too long")
+		}
+
+		if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("round"))); err != nil {
+			return err
+		}
+		if _, err := cw.WriteString(string("round")); err != nil {
+			return err
+		}
+
+		if t.Round == nil {
+			if _, err := cw.Write(cbg.CborNull); err != nil {
+				return err
+			}
+		} else {
+			if *t.Round >= 0 {
+				if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(*t.Round)); err != nil {
+					return err
+				}
+			} else {
+				if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-*t.Round-1)); err != nil {
+					return err
+				}
+			}
+		}
+
+	}
+
+	// t.State (string) (string)
+	if len("state") > 1000000 {
+		return xerrors.Errorf("Value in field \"state\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("state"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("state")); err != nil {
+		return err
+	}
+
+	if len(t.State) > 1000000 {
+		return xerrors.Errorf("Value in field t.State was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.repo.pull.status"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.State))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("sh.tangled.repo.pull.status")); err != nil {
+	if _, err := cw.WriteString(string(t.State)); err != nil {
 		return err
 	}
 
-	// t.Status (string) (string)
-	if len("status") > 1000000 {
-		return xerrors.Errorf("Value in field \"status\" was too long")
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("status"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("status")); err != nil {
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
 		return err
 	}
 
-	if len(t.Status) > 1000000 {
-		return xerrors.Errorf("Value in field t.Status was too long")
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Status))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Status)); err != nil {
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *RepoPullStatus) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoPullStatus{}
+func (t *RepoPullReview) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = RepoPullReview{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -7192,12 +9316,12 @@ func (t *RepoPullStatus) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoPullStatus: map struct too large (%d)", extra)
+		return fmt.Errorf("RepoPullReview: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 6)
+	nameBuf := make([]byte, 9)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -7213,7 +9337,28 @@ func (t *RepoPullStatus) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Pull (string) (string)
+		// t.Body (string) (string)
+		case "body":
+
+			{
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+
+					sval, err := cbg.ReadStringWithMax(cr, 1000000)
+					if err != nil {
+						return err
+					}
+
+					t.Body = (*string)(&sval)
+				}
+			}
+			// t.Pull (string) (string)
 		case "pull":
 
 			{
@@ -7235,8 +9380,44 @@ func (t *RepoPullStatus) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.LexiconTypeID = string(sval)
 			}
-			// t.Status (string) (string)
-		case "status":
+			// t.Round (int64) (int64)
+		case "round":
+			{
+
+				b, err := cr.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b != cbg.CborNull[0] {
+					if err := cr.UnreadByte(); err != nil {
+						return err
+					}
+					maj, extra, err := cr.ReadHeader()
+					if err != nil {
+						return err
+					}
+					var extraI int64
+					switch maj {
+					case cbg.MajUnsignedInt:
+						extraI = int64(extra)
+						if extraI < 0 {
+							return fmt.Errorf("int64 positive overflow")
+						}
+					case cbg.MajNegativeInt:
+						extraI = int64(extra)
+						if extraI < 0 {
+							return fmt.Errorf("int64 negative overflow")
+						}
+						extraI = -1 - extraI
+					default:
+						return fmt.Errorf("wrong type for int64 field: %d", maj)
+					}
+
+					t.Round = (*int64)(&extraI)
+				}
+			}
+			// t.State (string) (string)
+		case "state":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -7244,7 +9425,18 @@ func (t *RepoPullStatus) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Status = string(sval)
+				t.State = string(sval)
+			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -7257,7 +9449,7 @@ func (t *RepoPullStatus) UnmarshalCBOR(r io.Reader) (err error) {
 
 	return nil
 }
-func (t *RepoPull_Target) MarshalCBOR(w io.Writer) error {
+func (t *SigningKey) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
@@ -7265,60 +9457,125 @@ func (t *RepoPull_Target) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{162}); err != nil {
+	if _, err := cw.Write([]byte{165}); err != nil {
 		return err
 	}
 
-	// t.Repo (string) (string)
-	if len("repo") > 1000000 {
-		return xerrors.Errorf("Value in field \"repo\" was too long")
+	// t.Key (string) (string)
+	if len("key") > 1000000 {
+		return xerrors.Errorf("Value in field \"key\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("repo"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("key"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("repo")); err != nil {
+	if _, err := cw.WriteString(string("key")); err != nil {
 		return err
 	}
 
-	if len(t.Repo) > 1000000 {
-		return xerrors.Errorf("Value in field t.Repo was too long")
+	if len(t.Key) > 1000000 {
+		return xerrors.Errorf("Value in field t.Key was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Repo))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Key))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Repo)); err != nil {
+	if _, err := cw.WriteString(string(t.Key)); err != nil {
 		return err
 	}
 
-	// t.Branch (string) (string)
-	if len("branch") > 1000000 {
-		return xerrors.Errorf("Value in field \"branch\" was too long")
+	// t.Kind (string) (string)
+	if len("kind") > 1000000 {
+		return xerrors.Errorf("Value in field \"kind\" was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("branch"))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("kind"))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string("branch")); err != nil {
+	if _, err := cw.WriteString(string("kind")); err != nil {
 		return err
 	}
 
-	if len(t.Branch) > 1000000 {
-		return xerrors.Errorf("Value in field t.Branch was too long")
+	if len(t.Kind) > 1000000 {
+		return xerrors.Errorf("Value in field t.Kind was too long")
 	}
 
-	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Branch))); err != nil {
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Kind))); err != nil {
 		return err
 	}
-	if _, err := cw.WriteString(string(t.Branch)); err != nil {
+	if _, err := cw.WriteString(string(t.Kind)); err != nil {
+		return err
+	}
+
+	// t.Name (string) (string)
+	if len("name") > 1000000 {
+		return xerrors.Errorf("Value in field \"name\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("name"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("name")); err != nil {
+		return err
+	}
+
+	if len(t.Name) > 1000000 {
+		return xerrors.Errorf("Value in field t.Name was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.Name))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.Name)); err != nil {
+		return err
+	}
+
+	// t.LexiconTypeID (string) (string)
+	if len("$type") > 1000000 {
+		return xerrors.Errorf("Value in field \"$type\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("$type"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("$type")); err != nil {
+		return err
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("sh.tangled.signingKey"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("sh.tangled.signingKey")); err != nil {
+		return err
+	}
+
+	// t.CreatedAt (string) (string)
+	if len("createdAt") > 1000000 {
+		return xerrors.Errorf("Value in field \"createdAt\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("createdAt"))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string("createdAt")); err != nil {
+		return err
+	}
+
+	if len(t.CreatedAt) > 1000000 {
+		return xerrors.Errorf("Value in field t.CreatedAt was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(t.CreatedAt))); err != nil {
+		return err
+	}
+	if _, err := cw.WriteString(string(t.CreatedAt)); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *RepoPull_Target) UnmarshalCBOR(r io.Reader) (err error) {
-	*t = RepoPull_Target{}
+func (t *SigningKey) UnmarshalCBOR(r io.Reader) (err error) {
+	*t = SigningKey{}
 
 	cr := cbg.NewCborReader(r)
 
@@ -7337,12 +9594,12 @@ func (t *RepoPull_Target) UnmarshalCBOR(r io.Reader) (err error) {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("RepoPull_Target: map struct too large (%d)", extra)
+		return fmt.Errorf("SigningKey: map struct too large (%d)", extra)
 	}
 
 	n := extra
 
-	nameBuf := make([]byte, 6)
+	nameBuf := make([]byte, 9)
 	for i := uint64(0); i < n; i++ {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 1000000)
 		if err != nil {
@@ -7358,8 +9615,8 @@ func (t *RepoPull_Target) UnmarshalCBOR(r io.Reader) (err error) {
 		}
 
 		switch string(nameBuf[:nameLen]) {
-		// t.Repo (string) (string)
-		case "repo":
+		// t.Key (string) (string)
+		case "key":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -7367,10 +9624,10 @@ func (t *RepoPull_Target) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Repo = string(sval)
+				t.Key = string(sval)
 			}
-			// t.Branch (string) (string)
-		case "branch":
+			// t.Kind (string) (string)
+		case "kind":
 
 			{
 				sval, err := cbg.ReadStringWithMax(cr, 1000000)
@@ -7378,7 +9635,40 @@ func (t *RepoPull_Target) UnmarshalCBOR(r io.Reader) (err error) {
 					return err
 				}
 
-				t.Branch = string(sval)
+				t.Kind = string(sval)
+			}
+			// t.Name (string) (string)
+		case "name":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.Name = string(sval)
+			}
+			// t.LexiconTypeID (string) (string)
+		case "$type":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.LexiconTypeID = string(sval)
+			}
+			// t.CreatedAt (string) (string)
+		case "createdAt":
+
+			{
+				sval, err := cbg.ReadStringWithMax(cr, 1000000)
+				if err != nil {
+					return err
+				}
+
+				t.CreatedAt = string(sval)
 			}
 
 		default:
@@ -20,5 +20,9 @@ type RepoPullComment struct {
 	LexiconTypeID string `json:"$type,const=sh.tangled.repo.pull.comment" cborgen:"$type,const=sh.tangled.repo.pull.comment"`
 	Body          string `json:"body" cborgen:"body"`
 	CreatedAt     string `json:"createdAt" cborgen:"createdAt"`
-	Pull          string `json:"pull" cborgen:"pull"`
+	// line: line of the new version of path an inline review comment is on
+	Line *int64 `json:"line,omitempty" cborgen:"line,omitempty"`
+	// path: file of the patch an inline review comment is on
+	Path *string `json:"path,omitempty" cborgen:"path,omitempty"`
+	Pull string  `json:"pull" cborgen:"pull"`
 }
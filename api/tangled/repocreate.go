@@ -18,6 +18,12 @@ const (
 type RepoCreate_Input struct {
 	// defaultBranch: Default branch to push to
 	DefaultBranch *string `json:"defaultBranch,omitempty" cborgen:"defaultBranch,omitempty"`
+	// gitignore: Language of the .gitignore template to add in an initial commit
+	Gitignore *string `json:"gitignore,omitempty" cborgen:"gitignore,omitempty"`
+	// license: SPDX identifier of the license to add in an initial commit
+	License *string `json:"license,omitempty" cborgen:"license,omitempty"`
+	// readme: Whether to add a README stub in an initial commit
+	Readme *bool `json:"readme,omitempty" cborgen:"readme,omitempty"`
 	// rkey: Rkey of the repository record
 	Rkey string `json:"rkey" cborgen:"rkey"`
 	// source: A source URL to clone from, populate this when forking or importing a repository.
@@ -26,11 +26,22 @@ type RepoForkSync_Input struct {
 	Source string `json:"source" cborgen:"source"`
 }
 
+// RepoForkSync_Output is the output of a sh.tangled.repo.forkSync call.
+type RepoForkSync_Output struct {
+	// branch: Branch holding the upstream changes, when they conflict with the fork
+	Branch *string `json:"branch,omitempty" cborgen:"branch,omitempty"`
+	// commit: Head of the branch after syncing
+	Commit string `json:"commit" cborgen:"commit"`
+	// status: What syncing did to the branch
+	Status string `json:"status" cborgen:"status"`
+}
+
 // RepoForkSync calls the XRPC method "sh.tangled.repo.forkSync".
-func RepoForkSync(ctx context.Context, c util.LexClient, input *RepoForkSync_Input) error {
-	if err := c.LexDo(ctx, util.Procedure, "application/json", "sh.tangled.repo.forkSync", nil, input, nil); err != nil {
-		return err
+func RepoForkSync(ctx context.Context, c util.LexClient, input *RepoForkSync_Input) (*RepoForkSync_Output, error) {
+	var out RepoForkSync_Output
+	if err := c.LexDo(ctx, util.Procedure, "application/json", "sh.tangled.repo.forkSync", nil, input, &out); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &out, nil
 }
@@ -32,6 +32,8 @@ type RepoMerge_Input struct {
 	Name string `json:"name" cborgen:"name"`
 	// patch: Patch content to merge
 	Patch string `json:"patch" cborgen:"patch"`
+	// strategy: How the patch lands on the branch: a merge commit, a single squashed commit, or its commits rebased onto the branch. Defaults to rebasing format-patches and committing plain diffs.
+	Strategy *string `json:"strategy,omitempty" cborgen:"strategy,omitempty"`
 }
 
 // RepoMerge calls the XRPC method "sh.tangled.repo.merge".
@@ -19,4 +19,8 @@ func init() {
 type Knot struct {
 	LexiconTypeID string `json:"$type,const=sh.tangled.knot" cborgen:"$type,const=sh.tangled.knot"`
 	CreatedAt     string `json:"createdAt" cborgen:"createdAt"`
+	// defaultBranch: default branch of new repositories on this knot
+	DefaultBranch *string `json:"defaultBranch,omitempty" cborgen:"defaultBranch,omitempty"`
+	// repoCreate: who may create repositories on this knot
+	RepoCreate *string `json:"repoCreate,omitempty" cborgen:"repoCreate,omitempty"`
 }
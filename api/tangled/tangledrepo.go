@@ -29,4 +29,8 @@ type Repo struct {
 	Source *string `json:"source,omitempty" cborgen:"source,omitempty"`
 	// spindle: CI runner to send jobs to and receive results from
 	Spindle *string `json:"spindle,omitempty" cborgen:"spindle,omitempty"`
+	// topics: short lowercase words describing the repo
+	Topics []string `json:"topics,omitempty" cborgen:"topics,omitempty"`
+	// website: homepage of the project
+	Website *string `json:"website,omitempty" cborgen:"website,omitempty"`
 }
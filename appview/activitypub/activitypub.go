@@ -0,0 +1,242 @@
+// Package activitypub exposes users and repos as ActivityPub actors, so that
+// Mastodon and ForgeFed servers can follow pushes, releases and issues.
+// Replies from the fediverse to an issue are brought in as comments.
+package activitypub
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
+	"tangled.sh/tangled.sh/core/appview/publicnet"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+const (
+	ContentType = "application/activity+json"
+	Public      = "https://www.w3.org/ns/activitystreams#Public"
+
+	activityStreams = "https://www.w3.org/ns/activitystreams"
+)
+
+var actorContext = []string{
+	activityStreams,
+	"https://w3id.org/security/v1",
+	"https://forgefed.org/ns",
+}
+
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	Id                string     `json:"id"`
+	Type              any        `json:"type"`
+	PreferredUsername string     `json:"preferredUsername,omitempty"`
+	Name              string     `json:"name,omitempty"`
+	Summary           string     `json:"summary,omitempty"`
+	Url               string     `json:"url,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	AttributedTo      string     `json:"attributedTo,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	PublicKey         *PublicKey `json:"publicKey,omitempty"`
+}
+
+// SharedInbox returns the inbox that deliveries to this actor can be batched
+// into, which is the actor's own inbox if the server has no shared one
+func (a *Actor) SharedInbox() string {
+	if a.Endpoints != nil && a.Endpoints.SharedInbox != "" {
+		return a.Endpoints.SharedInbox
+	}
+	return a.Inbox
+}
+
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+type PublicKey struct {
+	Id           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type Activity struct {
+	Context   any      `json:"@context,omitempty"`
+	Id        string   `json:"id"`
+	Type      string   `json:"type"`
+	Actor     string   `json:"actor"`
+	Object    any      `json:"object,omitempty"`
+	To        []string `json:"to,omitempty"`
+	Cc        []string `json:"cc,omitempty"`
+	Published string   `json:"published,omitempty"`
+}
+
+type Note struct {
+	Context      any      `json:"@context,omitempty"`
+	Id           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo"`
+	InReplyTo    string   `json:"inReplyTo,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Content      string   `json:"content"`
+	Url          string   `json:"url,omitempty"`
+	Published    string   `json:"published,omitempty"`
+	To           []string `json:"to,omitempty"`
+	Cc           []string `json:"cc,omitempty"`
+}
+
+type OrderedCollection struct {
+	Context      any               `json:"@context,omitempty"`
+	Id           string            `json:"id"`
+	Type         string            `json:"type"`
+	TotalItems   int               `json:"totalItems"`
+	OrderedItems []json.RawMessage `json:"orderedItems,omitempty"`
+}
+
+// Federation serves the actors, their inboxes and outboxes, and sends out
+// activities to remote followers as things happen
+type Federation struct {
+	notify.BaseNotifier
+
+	db         *db.DB
+	idResolver *idresolver.Resolver
+	config     *config.Config
+	logger     *slog.Logger
+	http       *http.Client
+}
+
+var _ notify.Notifier = &Federation{}
+
+func New(d *db.DB, idResolver *idresolver.Resolver, config *config.Config, logger *slog.Logger) *Federation {
+	return &Federation{
+		db:         d,
+		idResolver: idResolver,
+		config:     config,
+		logger:     logger,
+		// actor documents and inboxes are at urls given by remote servers
+		http: publicnet.Client(publicnet.Dialer(10*time.Second, config.Core.Dev), 30*time.Second),
+	}
+}
+
+func (f *Federation) Router() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/actor", f.instance)
+	r.Post("/inbox", f.inbox)
+
+	r.Route("/activities/{id}", func(r chi.Router) {
+		r.Get("/", f.activity)
+		r.Get("/object", f.activityObject)
+	})
+
+	r.Route("/users/{did}", func(r chi.Router) {
+		r.Get("/", f.actor)
+		r.Get("/outbox", f.outbox)
+		r.Get("/followers", f.followers)
+		r.Post("/inbox", f.inbox)
+	})
+
+	r.Route("/repos/{did}/{repo}", func(r chi.Router) {
+		r.Get("/", f.actor)
+		r.Get("/outbox", f.outbox)
+		r.Get("/followers", f.followers)
+		r.Post("/inbox", f.inbox)
+		r.Get("/issues/{issue}", f.issueNote)
+		r.Get("/issues/{issue}/comments/{comment}", f.commentNote)
+	})
+
+	return r
+}
+
+// WebFinger maps acct: uris to actors. Users are addressed by handle, as in
+// acct:alice.example.com@tangled.sh, and repos by handle and name joined with
+// an underscore, as in acct:alice.example.com_core@tangled.sh. Handles cannot
+// contain underscores, so the first one always separates the two.
+func (f *Federation) WebFinger(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("resource")
+
+	a, err := f.resolveResource(r, resource)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jrd+json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"subject": resource,
+		"aliases": []string{a.id, a.url},
+		"links": []map[string]string{
+			{
+				"rel":  "self",
+				"type": ContentType,
+				"href": a.id,
+			},
+			{
+				"rel":  "http://webfinger.net/rel/profile-page",
+				"type": "text/html",
+				"href": a.url,
+			},
+		},
+	})
+}
+
+func (f *Federation) resolveResource(r *http.Request, resource string) (*localActor, error) {
+	acct, ok := strings.CutPrefix(resource, "acct:")
+	if !ok {
+		return f.actorFromId(resource)
+	}
+
+	at := strings.LastIndex(acct, "@")
+	if at < 0 || acct[at+1:] != f.host() {
+		return nil, errNotLocal
+	}
+
+	handle, name, isRepo := strings.Cut(acct[:at], "_")
+	ident, err := f.idResolver.ResolveIdent(r.Context(), handle)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isRepo {
+		a := f.userActor(ident.DID.String())
+		return &a, nil
+	}
+
+	repo, err := db.GetRepo(f.db, ident.DID.String(), name)
+	if err != nil {
+		return nil, err
+	}
+	a := f.repoActor(repo)
+	return &a, nil
+}
+
+func (f *Federation) base() string {
+	return strings.TrimSuffix(f.config.Core.AppviewHost, "/")
+}
+
+func (f *Federation) host() string {
+	u, err := url.Parse(f.config.Core.AppviewHost)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// wantsActivityJson reports whether a request asks for the activitypub
+// representation rather than a web page
+func wantsActivityJson(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "activity+json") || strings.Contains(accept, "ld+json")
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", ContentType)
+	json.NewEncoder(w).Encode(v)
+}
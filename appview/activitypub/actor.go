@@ -0,0 +1,432 @@
+package activitypub
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+var errNotLocal = errors.New("not a local actor")
+
+// localActor is a user or repo on this appview
+type localActor struct {
+	// what keys and followers are stored under: the did of a user, or the
+	// at-uri of a repo
+	key string
+	id  string
+	url string
+
+	did  string
+	repo *db.Repo
+}
+
+func (a *localActor) keyId() string {
+	return a.id + "#main-key"
+}
+
+func (a *localActor) followers() string {
+	return a.id + "/followers"
+}
+
+func (f *Federation) userActor(did string) localActor {
+	return localActor{
+		key: did,
+		id:  fmt.Sprintf("%s/ap/users/%s", f.base(), did),
+		url: fmt.Sprintf("%s/%s", f.base(), did),
+		did: did,
+	}
+}
+
+func (f *Federation) repoActor(repo *db.Repo) localActor {
+	return localActor{
+		key:  repo.RepoAt().String(),
+		id:   fmt.Sprintf("%s/ap/repos/%s/%s", f.base(), repo.Did, repo.Name),
+		url:  fmt.Sprintf("%s/%s/%s", f.base(), repo.Did, repo.Name),
+		did:  repo.Did,
+		repo: repo,
+	}
+}
+
+// instanceActor signs requests made on behalf of the appview itself, such
+// as fetching the actor of an incoming activity
+func (f *Federation) instanceActor() localActor {
+	return localActor{
+		key: "instance",
+		id:  fmt.Sprintf("%s/ap/actor", f.base()),
+		url: f.base(),
+	}
+}
+
+func (f *Federation) actorFromId(id string) (*localActor, error) {
+	path, ok := strings.CutPrefix(id, f.base()+"/ap/")
+	if !ok {
+		return nil, errNotLocal
+	}
+
+	parts := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "users":
+		return f.lookupUser(parts[1])
+	case len(parts) == 3 && parts[0] == "repos":
+		return f.lookupRepo(parts[1], parts[2])
+	}
+
+	return nil, errNotLocal
+}
+
+func (f *Federation) actorFromRequest(r *http.Request) (*localActor, error) {
+	did := chi.URLParam(r, "did")
+	if name := chi.URLParam(r, "repo"); name != "" {
+		return f.lookupRepo(did, name)
+	}
+	return f.lookupUser(did)
+}
+
+// lookupUser only knows users with at least one repo here, which keeps
+// arbitrary dids from being turned into actors
+func (f *Federation) lookupUser(did string) (*localActor, error) {
+	if _, err := syntax.ParseDID(did); err != nil {
+		return nil, err
+	}
+
+	count, err := db.CountRepos(f.db, db.FilterEq("did", did))
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errNotLocal
+	}
+
+	a := f.userActor(did)
+	return &a, nil
+}
+
+func (f *Federation) lookupRepo(did, name string) (*localActor, error) {
+	repo, err := db.GetRepo(f.db, did, name)
+	if err != nil {
+		return nil, err
+	}
+
+	a := f.repoActor(repo)
+	return &a, nil
+}
+
+// privateKey returns the signing key of an actor, creating one the first
+// time it is needed
+func (f *Federation) privateKey(a *localActor) (*rsa.PrivateKey, error) {
+	encoded, err := db.GetApKey(f.db, a.key)
+	if errors.Is(err, sql.ErrNoRows) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		err = db.AddApKey(f.db, a.key, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+		if err != nil {
+			return nil, err
+		}
+
+		// read it back, in case another request stored one first
+		encoded, err = db.GetApKey(f.db, a.key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, fmt.Errorf("invalid key stored for %s", a.key)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid key stored for %s", a.key)
+	}
+	return rsaKey, nil
+}
+
+func (f *Federation) document(ctx context.Context, a *localActor) (*Actor, error) {
+	key, err := f.privateKey(a)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Actor{
+		Context:   actorContext,
+		Id:        a.id,
+		Url:       a.url,
+		Inbox:     a.id + "/inbox",
+		Outbox:    a.id + "/outbox",
+		Followers: a.followers(),
+		Endpoints: &Endpoints{
+			SharedInbox: f.base() + "/ap/inbox",
+		},
+		PublicKey: &PublicKey{
+			Id:           a.keyId(),
+			Owner:        a.id,
+			PublicKeyPem: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		},
+	}
+
+	switch {
+	case a.repo != nil:
+		handle := f.handle(ctx, a.did)
+		// ForgeFed calls these Repository, which Mastodon does not know of.
+		// Mastodon is fine with a list of types as long as it knows one.
+		doc.Type = []string{"Repository", "Service"}
+		doc.PreferredUsername = handle + "_" + a.repo.Name
+		doc.Name = handle + "/" + a.repo.Name
+		doc.Summary = html.EscapeString(a.repo.Description)
+		doc.AttributedTo = f.userActor(a.did).id
+
+	case a.did != "":
+		handle := f.handle(ctx, a.did)
+		doc.Type = "Person"
+		doc.PreferredUsername = handle
+		doc.Name = handle
+		if profile, err := db.GetProfile(f.db, a.did); err == nil && profile != nil {
+			doc.Summary = html.EscapeString(profile.Description)
+		}
+
+	default:
+		doc.Type = "Application"
+		doc.PreferredUsername = f.host()
+		doc.Outbox = ""
+		doc.Followers = ""
+	}
+
+	return doc, nil
+}
+
+func (f *Federation) handle(ctx context.Context, did string) string {
+	ident, err := f.idResolver.ResolveIdent(ctx, did)
+	if err != nil || ident.Handle.IsInvalidHandle() {
+		return did
+	}
+	return ident.Handle.String()
+}
+
+func (f *Federation) actor(w http.ResponseWriter, r *http.Request) {
+	l := f.logger.With("handler", "actor")
+
+	a, err := f.actorFromRequest(r)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if !wantsActivityJson(r) {
+		http.Redirect(w, r, a.url, http.StatusFound)
+		return
+	}
+
+	doc, err := f.document(r.Context(), a)
+	if err != nil {
+		l.Error("failed to build actor", "actor", a.id, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, doc)
+}
+
+func (f *Federation) instance(w http.ResponseWriter, r *http.Request) {
+	a := f.instanceActor()
+	doc, err := f.document(r.Context(), &a)
+	if err != nil {
+		f.logger.Error("failed to build instance actor", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, doc)
+}
+
+func (f *Federation) outbox(w http.ResponseWriter, r *http.Request) {
+	l := f.logger.With("handler", "outbox")
+
+	a, err := f.actorFromRequest(r)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	count, err := db.CountApActivities(f.db, a.key)
+	if err != nil {
+		l.Error("failed to count activities", "actor", a.id, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	activities, err := db.GetApActivities(f.db, a.key, 20)
+	if err != nil {
+		l.Error("failed to get activities", "actor", a.id, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]json.RawMessage, len(activities))
+	for i, activity := range activities {
+		items[i] = json.RawMessage(activity)
+	}
+
+	writeJson(w, OrderedCollection{
+		Context:      activityStreams,
+		Id:           a.id + "/outbox",
+		Type:         "OrderedCollection",
+		TotalItems:   count,
+		OrderedItems: items,
+	})
+}
+
+// followers only gives out a count, like most servers do
+func (f *Federation) followers(w http.ResponseWriter, r *http.Request) {
+	a, err := f.actorFromRequest(r)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	count, err := db.CountApFollowers(f.db, a.key)
+	if err != nil {
+		f.logger.Error("failed to count followers", "actor", a.id, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, OrderedCollection{
+		Context:    activityStreams,
+		Id:         a.followers(),
+		Type:       "OrderedCollection",
+		TotalItems: count,
+	})
+}
+
+func (f *Federation) activity(w http.ResponseWriter, r *http.Request) {
+	activity, err := db.GetApActivity(f.db, f.activityId(chi.URLParam(r, "id")))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write([]byte(activity))
+}
+
+func (f *Federation) activityObject(w http.ResponseWriter, r *http.Request) {
+	activity, err := db.GetApActivity(f.db, f.activityId(chi.URLParam(r, "id")))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	var stored struct {
+		Object json.RawMessage `json:"object"`
+	}
+	if err := json.Unmarshal([]byte(activity), &stored); err != nil || len(stored.Object) == 0 || stored.Object[0] != '{' {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(stored.Object)
+}
+
+func (f *Federation) issueNote(w http.ResponseWriter, r *http.Request) {
+	a, issue, ok := f.localIssue(w, r)
+	if !ok {
+		return
+	}
+
+	note := f.issueObject(r.Context(), a, issue)
+	note.Context = activityStreams
+	writeJson(w, note)
+}
+
+func (f *Federation) commentNote(w http.ResponseWriter, r *http.Request) {
+	a, issue, ok := f.localIssue(w, r)
+	if !ok {
+		return
+	}
+
+	commentId, err := strconv.Atoi(chi.URLParam(r, "comment"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	comment, err := db.GetComment(f.db, issue.RepoAt, issue.IssueId, commentId)
+	if err != nil || comment.Deleted != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	// comments that came in from elsewhere are not ours to serve
+	imports, err := db.GetIssueImports(f.db, issue.RepoAt, issue.IssueId)
+	if err == nil && imports[commentId] != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	note := f.commentObject(r.Context(), a, comment)
+	note.Context = activityStreams
+	writeJson(w, note)
+}
+
+func (f *Federation) localIssue(w http.ResponseWriter, r *http.Request) (*localActor, *db.Issue, bool) {
+	a, err := f.actorFromRequest(r)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	issueId, err := strconv.Atoi(chi.URLParam(r, "issue"))
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	// issues hidden as spam are not federated
+	issue, err := db.GetIssue(f.db, a.repo.RepoAt(), issueId)
+	if err != nil || issue.Hidden != "" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	imports, err := db.GetIssueImports(f.db, issue.RepoAt, issue.IssueId)
+	if err == nil && imports[0] != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	return a, issue, true
+}
@@ -0,0 +1,356 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages/markup"
+	"tangled.sh/tangled.sh/core/tid"
+	"tangled.sh/tangled.sh/core/types"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func (f *Federation) activityId(id string) string {
+	return fmt.Sprintf("%s/ap/activities/%s", f.base(), id)
+}
+
+func (f *Federation) issueLink(repo *db.Repo, issueId int) string {
+	return fmt.Sprintf("%s/%s/%s/issues/%d", f.base(), repo.Did, repo.Name, issueId)
+}
+
+func (f *Federation) issueObject(ctx context.Context, a *localActor, issue *db.Issue) Note {
+	author := f.userActor(issue.OwnerDid)
+	link := f.issueLink(a.repo, issue.IssueId)
+
+	return Note{
+		Id:           fmt.Sprintf("%s/issues/%d", a.id, issue.IssueId),
+		Type:         "Note",
+		AttributedTo: author.id,
+		Name:         issue.Title,
+		Content: fmt.Sprintf(
+			`<p>opened <a href="%s">#%d %s</a> in <a href="%s">%s</a></p>%s`,
+			link,
+			issue.IssueId,
+			html.EscapeString(issue.Title),
+			a.url,
+			html.EscapeString(f.handle(ctx, a.did)+"/"+a.repo.Name),
+			renderBody(issue.Body),
+		),
+		Url:       link,
+		Published: issue.Created.UTC().Format(time.RFC3339),
+		To:        []string{Public},
+		Cc:        []string{author.followers(), a.followers()},
+	}
+}
+
+func (f *Federation) commentObject(ctx context.Context, a *localActor, comment *db.Comment) Note {
+	author := f.userActor(comment.OwnerDid)
+
+	published := time.Now()
+	if comment.Created != nil {
+		published = *comment.Created
+	}
+
+	return Note{
+		Id:           fmt.Sprintf("%s/issues/%d/comments/%d", a.id, comment.Issue, comment.CommentId),
+		Type:         "Note",
+		AttributedTo: author.id,
+		InReplyTo:    fmt.Sprintf("%s/issues/%d", a.id, comment.Issue),
+		Content:      renderBody(comment.Body),
+		Url:          fmt.Sprintf("%s#comment-%d", f.issueLink(a.repo, comment.Issue), comment.CommentId),
+		Published:    published.UTC().Format(time.RFC3339),
+		To:           []string{Public},
+		Cc:           []string{author.followers(), a.followers()},
+	}
+}
+
+func renderBody(body string) string {
+	rctx := &markup.RenderContext{
+		RendererType: markup.RendererTypeDefault,
+		Sanitizer:    markup.NewSanitizer(),
+	}
+	return rctx.SanitizeDefault(rctx.RenderMarkdown(body))
+}
+
+func (f *Federation) NewIssue(ctx context.Context, issue *db.Issue) {
+	repo, err := db.GetRepoByAtUri(f.db, issue.RepoAt.String())
+	if err != nil {
+		f.logger.Error("failed to get repo", "repo", issue.RepoAt, "err", err)
+		return
+	}
+	a := f.repoActor(repo)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		f.publishPost(ctx, &a, issue.OwnerDid, f.issueObject(ctx, &a, issue))
+	}()
+}
+
+func (f *Federation) NewIssueComment(ctx context.Context, comment *db.Comment) {
+	repo, err := db.GetRepoByAtUri(f.db, comment.RepoAt.String())
+	if err != nil {
+		f.logger.Error("failed to get repo", "repo", comment.RepoAt, "err", err)
+		return
+	}
+	a := f.repoActor(repo)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		f.publishPost(ctx, &a, comment.OwnerDid, f.commentObject(ctx, &a, comment))
+	}()
+}
+
+func (f *Federation) NewPush(ctx context.Context, update *tangled.GitRefUpdate) {
+	// deleted refs are not worth telling anyone about
+	if update.NewSha == plumbing.ZeroHash.String() {
+		return
+	}
+
+	repo, err := db.GetRepo(f.db, update.RepoDid, update.RepoName)
+	if err != nil {
+		f.logger.Error("failed to get repo", "repo", update.RepoDid+"/"+update.RepoName, "err", err)
+		return
+	}
+	a := f.repoActor(repo)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		pusher := f.userActor(update.CommitterDid)
+		ref := plumbing.ReferenceName(update.Ref)
+
+		var what, link string
+		switch {
+		case ref.IsTag():
+			what = fmt.Sprintf("tag %s", ref.Short())
+			link = fmt.Sprintf("%s/tags#%s", a.url, ref.Short())
+		default:
+			commits := "commits"
+			if n := commitCount(update); n == 1 {
+				commits = "commit"
+			} else if n > 0 {
+				commits = fmt.Sprintf("%d commits", n)
+			}
+			what = fmt.Sprintf("%s to %s", commits, ref.Short())
+			link = fmt.Sprintf("%s/commit/%s", a.url, update.NewSha)
+		}
+
+		f.publishUpdate(ctx, &a, fmt.Sprintf(
+			`<p><a href="%s">%s</a> pushed <a href="%s">%s</a> to <a href="%s">%s</a></p>`,
+			pusher.url,
+			html.EscapeString(f.handle(ctx, update.CommitterDid)),
+			link,
+			html.EscapeString(what),
+			a.url,
+			html.EscapeString(f.handle(ctx, a.did)+"/"+a.repo.Name),
+		), link)
+	}()
+}
+
+func commitCount(update *tangled.GitRefUpdate) int64 {
+	if update.Meta == nil || update.Meta.CommitCount == nil {
+		return 0
+	}
+
+	var n int64
+	for _, c := range update.Meta.CommitCount.ByEmail {
+		if c != nil {
+			n += c.Count
+		}
+	}
+	return n
+}
+
+func (f *Federation) NewRelease(ctx context.Context, repo *db.Repo, tag *types.TagReference) {
+	a := f.repoActor(repo)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		link := fmt.Sprintf("%s/tags#%s", a.url, tag.Name)
+		content := fmt.Sprintf(
+			`<p><a href="%s">%s</a> released <a href="%s">%s</a></p>`,
+			a.url,
+			html.EscapeString(f.handle(ctx, a.did)+"/"+a.repo.Name),
+			link,
+			html.EscapeString(tag.Name),
+		)
+
+		// the first paragraph of an annotated tag is its title
+		if tag.Tag != nil {
+			summary, _, _ := strings.Cut(strings.TrimSpace(tag.Tag.Message), "\n\n")
+			if summary != "" {
+				content += fmt.Sprintf("<p>%s</p>", html.EscapeString(summary))
+			}
+		}
+
+		f.publishUpdate(ctx, &a, content, link)
+	}()
+}
+
+// publishPost sends out an issue or comment. The author creates it, and the
+// repo announces it, so that it reaches the followers of either.
+func (f *Federation) publishPost(ctx context.Context, repo *localActor, authorDid string, note Note) {
+	author := f.userActor(authorDid)
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	f.publish(ctx, &author, Activity{
+		Context:   activityStreams,
+		Id:        f.activityId(tid.TID()),
+		Type:      "Create",
+		Actor:     author.id,
+		Object:    note,
+		To:        note.To,
+		Cc:        note.Cc,
+		Published: now,
+	})
+
+	f.publish(ctx, repo, Activity{
+		Context:   activityStreams,
+		Id:        f.activityId(tid.TID()),
+		Type:      "Announce",
+		Actor:     repo.id,
+		Object:    note.Id,
+		To:        []string{Public},
+		Cc:        []string{repo.followers(), author.id},
+		Published: now,
+	})
+}
+
+// publishUpdate sends out something that happened to a repo, such as a push
+func (f *Federation) publishUpdate(ctx context.Context, a *localActor, content, link string) {
+	id := f.activityId(tid.TID())
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	note := Note{
+		Id:           id + "/object",
+		Type:         "Note",
+		AttributedTo: a.id,
+		Content:      content,
+		Url:          link,
+		Published:    now,
+		To:           []string{Public},
+		Cc:           []string{a.followers()},
+	}
+
+	f.publish(ctx, a, Activity{
+		Context:   activityStreams,
+		Id:        id,
+		Type:      "Create",
+		Actor:     a.id,
+		Object:    note,
+		To:        note.To,
+		Cc:        note.Cc,
+		Published: now,
+	})
+}
+
+// publish records an activity in the outbox of an actor, and delivers it to
+// everyone following the actor
+func (f *Federation) publish(ctx context.Context, a *localActor, activity Activity) {
+	l := f.logger.With("actor", a.id, "activity", activity.Id)
+
+	body, err := json.Marshal(activity)
+	if err != nil {
+		l.Error("failed to encode activity", "err", err)
+		return
+	}
+
+	if err := db.AddApActivity(f.db, a.key, activity.Id, string(body)); err != nil {
+		l.Error("failed to record activity", "err", err)
+		return
+	}
+
+	inboxes, err := db.GetApInboxes(f.db, a.key)
+	if err != nil {
+		l.Error("failed to get inboxes", "err", err)
+		return
+	}
+
+	for _, inbox := range inboxes {
+		if err := f.deliver(ctx, a, inbox, body); err != nil {
+			l.Warn("failed to deliver activity", "inbox", inbox, "err", err)
+		}
+	}
+}
+
+func (f *Federation) deliver(ctx context.Context, a *localActor, inbox string, body []byte) error {
+	key, err := f.privateKey(a)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+
+	if err := signRequest(req, a.keyId(), key, body); err != nil {
+		return err
+	}
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// fetchActor gets a remote actor, signing the request as the appview for
+// servers that refuse unsigned fetches
+func (f *Federation) fetchActor(ctx context.Context, id string) (*Actor, error) {
+	instance := f.instanceActor()
+	key, err := f.privateKey(&instance)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, id, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ContentType)
+
+	if err := signRequest(req, instance.keyId(), key, nil); err != nil {
+		return nil, err
+	}
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", id, resp.Status)
+	}
+
+	var actor Actor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxActivitySize)).Decode(&actor); err != nil {
+		return nil, err
+	}
+	return &actor, nil
+}
@@ -0,0 +1,185 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// requests are signed as described in draft-cavage-http-signatures, which is
+// what Mastodon and most of the fediverse expects
+
+// how far the date of a signed request may be off from ours
+const maxClockSkew = time.Hour
+
+type signature struct {
+	keyId     string
+	algorithm string
+	headers   []string
+	signature []byte
+}
+
+// signRequest signs a request with the key of a local actor. body is nil for
+// requests without one.
+func signRequest(req *http.Request, keyId string, key *rsa.PrivateKey, body []byte) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		sum := sha256.Sum256(body)
+		req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+		headers = append(headers, "digest")
+	}
+
+	s, err := signingString(req, req.URL.Host, headers)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(s))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyId,
+		strings.Join(headers, " "),
+		base64.StdEncoding.EncodeToString(sig),
+	))
+	return nil
+}
+
+func parseSignature(header string) (*signature, error) {
+	if header == "" {
+		return nil, errors.New("request is not signed")
+	}
+
+	sig := &signature{
+		// the default when no headers are listed
+		headers: []string{"date"},
+	}
+
+	for _, param := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed signature parameter: %q", param)
+		}
+		v = strings.Trim(v, `"`)
+
+		switch k {
+		case "keyId":
+			sig.keyId = v
+		case "algorithm":
+			sig.algorithm = v
+		case "headers":
+			sig.headers = strings.Fields(strings.ToLower(v))
+		case "signature":
+			b, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, fmt.Errorf("malformed signature: %w", err)
+			}
+			sig.signature = b
+		}
+	}
+
+	if sig.keyId == "" || sig.signature == nil {
+		return nil, errors.New("signature is missing keyId or signature")
+	}
+
+	return sig, nil
+}
+
+// verifySignature checks a signed incoming request against the public key of
+// the actor that signed it. The signature has to cover the request target,
+// host and date, and the digest of the body if there is one.
+func verifySignature(r *http.Request, body []byte, sig *signature, key *rsa.PublicKey) error {
+	switch sig.algorithm {
+	case "", "rsa-sha256", "hs2019":
+	default:
+		return fmt.Errorf("unsupported signature algorithm: %s", sig.algorithm)
+	}
+
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+	}
+	for _, h := range required {
+		if !slices.Contains(sig.headers, h) {
+			return fmt.Errorf("signature does not cover %s", h)
+		}
+	}
+
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+	if d := time.Since(date); d > maxClockSkew || d < -maxClockSkew {
+		return errors.New("request date is too far off")
+	}
+
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		if r.Header.Get("Digest") != "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]) {
+			return errors.New("digest does not match body")
+		}
+	}
+
+	s, err := signingString(r, r.Host, sig.headers)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256([]byte(s))
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig.signature)
+}
+
+func signingString(r *http.Request, host string, headers []string) (string, error) {
+	lines := make([]string, 0, len(headers))
+	for _, h := range headers {
+		var v string
+		switch h {
+		case "(request-target)":
+			v = strings.ToLower(r.Method) + " " + r.URL.RequestURI()
+		case "host":
+			v = host
+		default:
+			v = r.Header.Get(h)
+			if v == "" {
+				return "", fmt.Errorf("signed header %s is missing", h)
+			}
+		}
+		lines = append(lines, h+": "+v)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func parsePublicKey(s string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("invalid public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		// some servers still publish pkcs1 keys
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an rsa key")
+	}
+	return rsaKey, nil
+}
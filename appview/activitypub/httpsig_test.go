@@ -0,0 +1,68 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`{"type":"Follow"}`)
+
+	signed, err := http.NewRequest(http.MethodPost, "https://tangled.sh/ap/inbox", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signRequest(signed, "https://example.com/users/alice#main-key", key, body); err != nil {
+		t.Fatal(err)
+	}
+
+	// what the receiving end sees
+	received := func(body []byte) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/ap/inbox", bytes.NewReader(body))
+		r.Host = "tangled.sh"
+		r.Header = signed.Header.Clone()
+		return r
+	}
+
+	sig, err := parseSignature(signed.Header.Get("Signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.keyId != "https://example.com/users/alice#main-key" {
+		t.Errorf("keyId = %q", sig.keyId)
+	}
+
+	if err := verifySignature(received(body), body, sig, &key.PublicKey); err != nil {
+		t.Errorf("valid signature was rejected: %v", err)
+	}
+
+	tampered := []byte(`{"type":"Block"}`)
+	if err := verifySignature(received(tampered), tampered, sig, &key.PublicKey); err == nil {
+		t.Error("signature over a different body was accepted")
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySignature(received(body), body, sig, &other.PublicKey); err == nil {
+		t.Error("signature was accepted with the wrong key")
+	}
+}
+
+func TestNoteText(t *testing.T) {
+	got := noteText(`<p><span class="h-card"><a href="https://example.com/@bob">@<span>bob</span></a></span> looks good</p><p>one &amp; two<br>three</p>`)
+	want := "@bob looks good\n\none & two\nthree"
+	if got != want {
+		t.Errorf("noteText = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,292 @@
+package activitypub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// activities and actors larger than this are turned away
+const maxActivitySize = 1 << 20
+
+// incoming is an activity received from a remote server, with the object
+// left undecoded until the type of the activity is known
+type incoming struct {
+	Id     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// objectId returns the id of an object that is either inlined or referred to
+// by id
+func objectId(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+
+	var obj struct {
+		Id string `json:"id"`
+	}
+	json.Unmarshal(raw, &obj)
+	return obj.Id
+}
+
+func (f *Federation) inbox(w http.ResponseWriter, r *http.Request) {
+	l := f.logger.With("handler", "inbox")
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxActivitySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var activity incoming
+	if err := json.Unmarshal(body, &activity); err != nil {
+		http.Error(w, "invalid activity", http.StatusBadRequest)
+		return
+	}
+
+	remote, err := f.verify(r, body)
+	if err != nil {
+		l.Debug("rejected activity", "actor", activity.Actor, "err", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if remote.Id != activity.Actor {
+		http.Error(w, "activity was not signed by its actor", http.StatusUnauthorized)
+		return
+	}
+
+	switch activity.Type {
+	case "Follow":
+		err = f.follow(r.Context(), remote, activity, body)
+	case "Undo":
+		err = f.undo(remote, activity)
+	case "Create":
+		err = f.create(r.Context(), remote, activity)
+	}
+	if err != nil {
+		l.Warn("failed to handle activity", "type", activity.Type, "actor", remote.Id, "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verify checks the signature of an incoming request and returns the actor
+// that made it
+func (f *Federation) verify(r *http.Request, body []byte) (*Actor, error) {
+	sig, err := parseSignature(r.Header.Get("Signature"))
+	if err != nil {
+		return nil, err
+	}
+
+	keyUrl, err := url.Parse(sig.keyId)
+	if err != nil || keyUrl.Scheme != "https" || keyUrl.Host == "" {
+		return nil, fmt.Errorf("invalid key id %q", sig.keyId)
+	}
+
+	actorId, _, _ := strings.Cut(sig.keyId, "#")
+	remote, err := f.fetchActor(r.Context(), actorId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the document is only trusted to speak for the actor it was fetched as,
+	// and the key for actors on its own host
+	if remote.Id != actorId {
+		return nil, fmt.Errorf("fetched %s, got actor %s", actorId, remote.Id)
+	}
+	if actorUrl, err := url.Parse(remote.Id); err != nil || actorUrl.Host != keyUrl.Host {
+		return nil, fmt.Errorf("key %s is not on the host of %s", sig.keyId, remote.Id)
+	}
+
+	if remote.PublicKey == nil || remote.PublicKey.Id != sig.keyId {
+		return nil, fmt.Errorf("%s has no key %s", remote.Id, sig.keyId)
+	}
+
+	key, err := parsePublicKey(remote.PublicKey.PublicKeyPem)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(r, body, sig, key); err != nil {
+		return nil, err
+	}
+
+	return remote, nil
+}
+
+func (f *Federation) follow(ctx context.Context, remote *Actor, activity incoming, body []byte) error {
+	a, err := f.actorFromId(objectId(activity.Object))
+	if err != nil {
+		return err
+	}
+
+	err = db.AddApFollower(f.db, db.ApFollower{
+		Actor:    a.key,
+		Follower: remote.Id,
+		Inbox:    remote.SharedInbox(),
+	})
+	if err != nil {
+		return err
+	}
+
+	accept, err := json.Marshal(Activity{
+		Context: activityStreams,
+		Id:      f.activityId(tid.TID()),
+		Type:    "Accept",
+		Actor:   a.id,
+		Object:  json.RawMessage(body),
+	})
+	if err != nil {
+		return err
+	}
+
+	// the follower is told once the response to the follow is out of the way
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := f.deliver(ctx, a, remote.Inbox, accept); err != nil {
+			f.logger.Warn("failed to accept follow", "actor", a.id, "follower", remote.Id, "err", err)
+		}
+	}()
+
+	return nil
+}
+
+func (f *Federation) undo(remote *Actor, activity incoming) error {
+	var undone incoming
+	if err := json.Unmarshal(activity.Object, &undone); err != nil {
+		// only inlined activities can be undone, there is no looking up
+		// remote activities by id
+		return nil
+	}
+
+	if undone.Type != "Follow" || undone.Actor != remote.Id {
+		return nil
+	}
+
+	a, err := f.actorFromId(objectId(undone.Object))
+	if err != nil {
+		return err
+	}
+
+	return db.DeleteApFollower(f.db, a.key, remote.Id)
+}
+
+// issueNotePattern matches the ids of the notes that issues and their
+// comments are published as
+var issueNotePattern = regexp.MustCompile(`^/ap/repos/([^/]+)/([^/]+)/issues/(\d+)(?:/comments/\d+)?$`)
+
+// create brings replies to issues in as comments. Everything else that gets
+// created is of no interest.
+func (f *Federation) create(ctx context.Context, remote *Actor, activity incoming) error {
+	var note Note
+	if err := json.Unmarshal(activity.Object, &note); err != nil || note.Type != "Note" {
+		return nil
+	}
+
+	if note.AttributedTo != remote.Id {
+		return errors.New("note is not attributed to the actor that created it")
+	}
+
+	path, ok := strings.CutPrefix(note.InReplyTo, f.base())
+	if !ok {
+		return nil
+	}
+	m := issueNotePattern.FindStringSubmatch(path)
+	if m == nil {
+		return nil
+	}
+
+	repo, err := db.GetRepo(f.db, m[1], m[2])
+	if err != nil {
+		return err
+	}
+	issueId, _ := strconv.Atoi(m[3])
+
+	issue, err := db.GetIssue(f.db, repo.RepoAt(), issueId)
+	if err != nil {
+		return err
+	}
+	if issue.Hidden != "" {
+		return errors.New("issue is hidden")
+	}
+
+	seen, err := db.IsIssueImportSource(f.db, repo.RepoAt(), note.Id)
+	if err != nil || seen {
+		return err
+	}
+
+	created := time.Now()
+	if t, err := time.Parse(time.RFC3339, note.Published); err == nil {
+		created = t
+	}
+
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// remote authors have no did, so the comment is held by the repo owner
+	// and attributed to its author
+	err = db.ImportIssueComment(tx, &db.Comment{
+		OwnerDid: repo.Did,
+		RepoAt:   repo.RepoAt(),
+		Issue:    issueId,
+		Body:     noteText(note.Content),
+		Created:  &created,
+	}, db.IssueImport{
+		SourceUrl: note.Id,
+		Author:    remoteHandle(remote),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// remoteHandle formats an actor the way the fediverse addresses them, as in
+// @alice@example.com
+func remoteHandle(a *Actor) string {
+	u, err := url.Parse(a.Id)
+	if err != nil || a.PreferredUsername == "" {
+		return a.Id
+	}
+	return fmt.Sprintf("@%s@%s", a.PreferredUsername, u.Host)
+}
+
+var paragraphBreak = regexp.MustCompile(`(?i)</p>\s*<p[^>]*>|<br\s*/?>`)
+
+// noteText turns the html content of a note into plain text for a comment
+func noteText(content string) string {
+	content = paragraphBreak.ReplaceAllStringFunc(content, func(s string) string {
+		if strings.HasPrefix(strings.ToLower(s), "<br") {
+			return "\n"
+		}
+		return "\n\n"
+	})
+	text := bluemonday.StrictPolicy().Sanitize(content)
+	return strings.TrimSpace(html.UnescapeString(text))
+}
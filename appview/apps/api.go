@@ -0,0 +1,176 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// APIRouter serves the data of users to the apps they authorized, as far as
+// the scopes they consented to allow
+func (a *Apps) APIRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(a.authenticate)
+
+	r.Get("/user", a.user)
+	r.With(requireScope(db.AppScopeRepoRead)).Get("/repos", a.repos)
+	r.With(requireScope(db.AppScopeIssueRead)).Get("/issues", a.issues)
+	r.With(requireScope(db.AppScopeEmailRead)).Get("/emails", a.emails)
+
+	return r
+}
+
+// authenticate finds the token an app presents as a bearer token
+func (a *Apps) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(token, TokenPrefix) {
+			writeError(w, http.StatusUnauthorized, "missing access token")
+			return
+		}
+
+		t, err := db.GetAppTokenByHash(a.db, Hash(token))
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid or revoked access token")
+			return
+		}
+
+		if err := db.SetAppTokenUsed(a.db, t.Id); err != nil {
+			a.logger.Error("failed to record token use", "token", t.Id, "err", err)
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, t)))
+	})
+}
+
+func requireScope(scope db.AppScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !tokenFrom(r.Context()).Can(scope) {
+				writeError(w, http.StatusForbidden, fmt.Sprintf("this token lacks the %s scope", scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (a *Apps) user(w http.ResponseWriter, r *http.Request) {
+	token := tokenFrom(r.Context())
+
+	user := map[string]any{
+		"did":    token.Did,
+		"scopes": token.Scopes,
+	}
+	if id, err := a.idResolver.ResolveIdent(r.Context(), token.Did); err == nil && !id.Handle.IsInvalidHandle() {
+		user["handle"] = id.Handle.String()
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+type repoJSON struct {
+	Name        string    `json:"name"`
+	Uri         string    `json:"uri"`
+	Owner       string    `json:"owner"`
+	Knot        string    `json:"knot"`
+	Description string    `json:"description,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Created     time.Time `json:"created"`
+}
+
+func (a *Apps) repos(w http.ResponseWriter, r *http.Request) {
+	token := tokenFrom(r.Context())
+
+	repos, err := db.GetAllReposByDid(a.db, token.Did)
+	if err != nil {
+		a.logger.Error("failed to get repos", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load repos")
+		return
+	}
+
+	resp := make([]repoJSON, 0, len(repos))
+	for _, repo := range repos {
+		resp = append(resp, repoJSON{
+			Name:        repo.Name,
+			Uri:         repo.RepoAt().String(),
+			Owner:       repo.Did,
+			Knot:        repo.Knot,
+			Description: repo.Description,
+			Source:      repo.Source,
+			Created:     repo.Created,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type issueJSON struct {
+	Id      int       `json:"id"`
+	Uri     string    `json:"uri"`
+	Repo    string    `json:"repo"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+	Open    bool      `json:"open"`
+	Created time.Time `json:"created"`
+}
+
+func (a *Apps) issues(w http.ResponseWriter, r *http.Request) {
+	token := tokenFrom(r.Context())
+
+	issues, err := db.GetIssues(a.db, db.FilterEq("owner_did", token.Did))
+	if err != nil {
+		a.logger.Error("failed to get issues", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load issues")
+		return
+	}
+
+	resp := make([]issueJSON, 0, len(issues))
+	for _, issue := range issues {
+		resp = append(resp, issueJSON{
+			Id:      issue.IssueId,
+			Uri:     issue.AtUri().String(),
+			Repo:    issue.RepoAt.String(),
+			Title:   issue.Title,
+			Body:    issue.Body,
+			Open:    issue.Open,
+			Created: issue.Created,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type emailJSON struct {
+	Address string `json:"address"`
+	Primary bool   `json:"primary"`
+}
+
+func (a *Apps) emails(w http.ResponseWriter, r *http.Request) {
+	token := tokenFrom(r.Context())
+
+	emails, err := db.GetAllEmails(a.db, token.Did)
+	if err != nil {
+		a.logger.Error("failed to get emails", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to load emails")
+		return
+	}
+
+	resp := make([]emailJSON, 0, len(emails))
+	for _, email := range emails {
+		if email.Verified {
+			resp = append(resp, emailJSON{Address: email.Address, Primary: email.Primary})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
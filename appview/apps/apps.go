@@ -0,0 +1,437 @@
+// Package apps lets users give third-party applications access to their data
+// on the appview, with OAuth 2.0 authorization code grants:
+//
+//  1. the app sends the user to /apps/authorize, where they consent to the
+//     scopes it asks for
+//  2. the user is sent back to the redirect uri of the app with a code
+//  3. the app exchanges the code at /apps/token, with its client secret or
+//     a PKCE verifier, for an access token
+//  4. the app calls /api/app with Authorization: Bearer <token>
+//
+// Tokens last until the user revokes the app in their settings, or its
+// owner deletes it.
+package apps
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/middleware"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+const (
+	// ClientIdPrefix starts the client ids of apps
+	ClientIdPrefix = "tgc_"
+	// SecretPrefix and TokenPrefix start client secrets and access tokens,
+	// so that leaked ones are easy to spot
+	SecretPrefix = "tgs_"
+	TokenPrefix  = "tga_"
+
+	// how long an app has to exchange a code for a token
+	codeLifetime = 10 * time.Minute
+)
+
+func random(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewClientId makes the public identifier of an app
+func NewClientId() string {
+	return ClientIdPrefix + random(16)
+}
+
+// NewSecret makes the client secret of an app, along with the hash that is
+// stored in its place
+func NewSecret() (secret, hash string) {
+	secret = SecretPrefix + random(32)
+	return secret, Hash(secret)
+}
+
+// Hash is how secrets, codes and tokens are stored
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+type Apps struct {
+	db         *db.DB
+	oauth      *oauth.OAuth
+	pages      *pages.Pages
+	idResolver *idresolver.Resolver
+	config     *config.Config
+	logger     *slog.Logger
+}
+
+func New(db *db.DB, oauth *oauth.OAuth, pages *pages.Pages, idResolver *idresolver.Resolver, config *config.Config, logger *slog.Logger) *Apps {
+	return &Apps{
+		db:         db,
+		oauth:      oauth,
+		pages:      pages,
+		idResolver: idResolver,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// Router serves the authorization and token endpoints
+func (a *Apps) Router() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/authorize", a.authorize)
+	r.With(middleware.AuthMiddleware(a.oauth), middleware.Sudo(a.oauth, a.db)).Post("/authorize", a.consent)
+	r.Post("/token", a.token)
+
+	return r
+}
+
+// authorizeRequest is what an app asks a user for
+type authorizeRequest struct {
+	app           *db.App
+	redirectUri   string
+	state         string
+	scopes        []db.AppScope
+	codeChallenge string
+}
+
+// oauthError is an error the app is told about, as in RFC 6749
+type oauthError struct {
+	code        string
+	description string
+}
+
+func (e *oauthError) Error() string {
+	return e.code + ": " + e.description
+}
+
+// parseAuthorize reads an authorization request. Until the app and its
+// redirect uri are known to be valid, errors are shown to the user rather
+// than sent to the app, as an *oauthError.
+func (a *Apps) parseAuthorize(r *http.Request) (*authorizeRequest, error) {
+	app, err := db.GetApp(a.db, r.FormValue("client_id"))
+	if err != nil {
+		return nil, errors.New("There is no application with this client id.")
+	}
+
+	redirectUri := r.FormValue("redirect_uri")
+	if redirectUri == "" && len(app.RedirectUris) == 1 {
+		redirectUri = app.RedirectUris[0]
+	}
+	if !app.HasRedirectUri(redirectUri) {
+		return nil, errors.New("The redirect URI is not one registered for this application.")
+	}
+
+	req := &authorizeRequest{
+		app:           app,
+		redirectUri:   redirectUri,
+		state:         r.FormValue("state"),
+		codeChallenge: r.FormValue("code_challenge"),
+	}
+
+	if r.FormValue("response_type") != "code" {
+		return req, &oauthError{"unsupported_response_type", "only the code response type is supported"}
+	}
+
+	for _, s := range strings.Fields(r.FormValue("scope")) {
+		scope := db.AppScope(s)
+		if !scope.IsValid() {
+			return req, &oauthError{"invalid_scope", fmt.Sprintf("unknown scope %s", s)}
+		}
+		if !slices.Contains(req.scopes, scope) {
+			req.scopes = append(req.scopes, scope)
+		}
+	}
+
+	if req.codeChallenge != "" && r.FormValue("code_challenge_method") != "S256" {
+		return req, &oauthError{"invalid_request", "only S256 code challenges are supported"}
+	}
+
+	return req, nil
+}
+
+// authorize shows the consent screen
+func (a *Apps) authorize(w http.ResponseWriter, r *http.Request) {
+	// the screen grants access with a click, it is never to be framed
+	w.Header().Set("Content-Security-Policy", "frame-ancestors 'none'")
+	w.Header().Set("X-Frame-Options", "DENY")
+
+	user := a.oauth.GetUser(r)
+	if user == nil {
+		loginUrl := fmt.Sprintf("/login?return_url=%s", url.QueryEscape(r.URL.RequestURI()))
+		http.Redirect(w, r, loginUrl, http.StatusTemporaryRedirect)
+		return
+	}
+
+	req, err := a.parseAuthorize(r)
+	var oerr *oauthError
+	if errors.As(err, &oerr) {
+		redirect(w, r, req.redirectUri, url.Values{
+			"error":             {oerr.code},
+			"error_description": {oerr.description},
+			"state":             {req.state},
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		a.pages.AuthorizeApp(w, pages.AuthorizeAppParams{
+			LoggedInUser: user,
+			Error:        err.Error(),
+		})
+		return
+	}
+
+	previous, err := db.GetAppTokens(a.db, db.FilterEq("did", user.Did), db.FilterEq("client_id", req.app.ClientId))
+	if err != nil {
+		a.logger.Error("failed to get tokens", "err", err)
+	}
+
+	scopes := make([]string, len(req.scopes))
+	for i, s := range req.scopes {
+		scopes[i] = string(s)
+	}
+
+	nonce := random(32)
+	if err := a.oauth.SetConsent(w, r, nonce); err != nil {
+		a.logger.Error("failed to set consent nonce", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		a.pages.AuthorizeApp(w, pages.AuthorizeAppParams{
+			LoggedInUser: user,
+			Error:        "Something went wrong. Try again later.",
+		})
+		return
+	}
+
+	a.pages.AuthorizeApp(w, pages.AuthorizeAppParams{
+		LoggedInUser:  user,
+		App:           req.app,
+		Scopes:        req.scopes,
+		Scope:         strings.Join(scopes, " "),
+		RedirectUri:   req.redirectUri,
+		State:         req.state,
+		CodeChallenge: req.codeChallenge,
+		Nonce:         nonce,
+		Authorized:    len(previous) > 0,
+	})
+}
+
+// consent sends the user back to the app, with a code if they allowed it.
+// Only the consent screen shown to the user, on this site, can answer it.
+func (a *Apps) consent(w http.ResponseWriter, r *http.Request) {
+	user := a.oauth.GetUser(r)
+
+	nonce := a.oauth.TakeConsent(w, r)
+	if !sameOrigin(r) || nonce == "" ||
+		subtle.ConstantTimeCompare([]byte(nonce), []byte(r.FormValue("nonce"))) != 1 {
+		w.WriteHeader(http.StatusForbidden)
+		a.pages.AuthorizeApp(w, pages.AuthorizeAppParams{
+			LoggedInUser: user,
+			Error:        "This request did not come from the authorization screen. Go back to the application and try again.",
+		})
+		return
+	}
+
+	req, err := a.parseAuthorize(r)
+	var oerr *oauthError
+	if errors.As(err, &oerr) {
+		redirect(w, r, req.redirectUri, url.Values{
+			"error":             {oerr.code},
+			"error_description": {oerr.description},
+			"state":             {req.state},
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		a.pages.AuthorizeApp(w, pages.AuthorizeAppParams{
+			LoggedInUser: user,
+			Error:        err.Error(),
+		})
+		return
+	}
+
+	if r.FormValue("decision") != "allow" {
+		redirect(w, r, req.redirectUri, url.Values{
+			"error":             {"access_denied"},
+			"error_description": {"the user denied access"},
+			"state":             {req.state},
+		})
+		return
+	}
+
+	code := random(32)
+	err = db.AddAppCode(a.db, db.AppCode{
+		ClientId:      req.app.ClientId,
+		Did:           user.Did,
+		Scopes:        req.scopes,
+		RedirectUri:   req.redirectUri,
+		CodeChallenge: req.codeChallenge,
+		Expires:       time.Now().Add(codeLifetime),
+	}, Hash(code))
+	if err != nil {
+		a.logger.Error("failed to add code", "err", err)
+		redirect(w, r, req.redirectUri, url.Values{
+			"error": {"server_error"},
+			"state": {req.state},
+		})
+		return
+	}
+
+	redirect(w, r, req.redirectUri, url.Values{
+		"code":  {code},
+		"state": {req.state},
+	})
+}
+
+// sameOrigin reports whether the request was sent from a page of the appview,
+// browsers that leave out the Origin header are trusted on the nonce alone
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// redirect sends the user to uri, with params added to its query
+func redirect(w http.ResponseWriter, r *http.Request, uri string, params url.Values) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		http.Error(w, "invalid redirect uri", http.StatusBadRequest)
+		return
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		if len(v) > 0 && v[0] != "" {
+			q[k] = v
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusSeeOther)
+}
+
+// token exchanges a code for an access token
+func (a *Apps) token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.FormValue("grant_type") != "authorization_code" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "only the authorization_code grant is supported")
+		return
+	}
+
+	clientId, secret, ok := r.BasicAuth()
+	if !ok {
+		clientId, secret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+
+	code, err := db.TakeAppCode(a.db, Hash(r.FormValue("code")))
+	if errors.Is(err, sql.ErrNoRows) {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "the code is invalid or expired")
+		return
+	}
+	if err != nil {
+		a.logger.Error("failed to take code", "err", err)
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	if code.ClientId != clientId {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "the code was issued to another client")
+		return
+	}
+	if uri := r.FormValue("redirect_uri"); uri != "" && uri != code.RedirectUri {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "the redirect uri does not match")
+		return
+	}
+
+	// confidential clients authenticate with their secret, public ones may
+	// only use codes bound to a PKCE challenge
+	if secret != "" {
+		valid, err := db.CheckAppSecret(a.db, clientId, Hash(secret))
+		if err != nil || !valid {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "invalid client credentials")
+			return
+		}
+	} else if code.CodeChallenge == "" {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "a client secret or a PKCE code verifier is required")
+		return
+	}
+
+	if code.CodeChallenge != "" {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+		if subtle.ConstantTimeCompare([]byte(challenge), []byte(code.CodeChallenge)) != 1 {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "the code verifier does not match")
+			return
+		}
+	}
+
+	token := TokenPrefix + random(32)
+	err = db.AddAppToken(a.db, &db.AppToken{
+		ClientId: code.ClientId,
+		Did:      code.Did,
+		Scopes:   code.Scopes,
+	}, Hash(token))
+	if err != nil {
+		a.logger.Error("failed to add token", "err", err)
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	scopes := make([]string, len(code.Scopes))
+	for i, s := range code.Scopes {
+		scopes[i] = string(s)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"scope":        strings.Join(scopes, " "),
+		"did":          code.Did,
+	})
+}
+
+type tokenKey struct{}
+
+func tokenFrom(ctx context.Context) *db.AppToken {
+	token, _ := ctx.Value(tokenKey{}).(*db.AppToken)
+	return token
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	body := map[string]string{"error": code}
+	if description != "" {
+		body["error_description"] = description
+	}
+	writeJSON(w, status, body)
+}
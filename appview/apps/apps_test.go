@@ -0,0 +1,147 @@
+package apps
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+func TestTokenExchange(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, hash := NewSecret()
+	app := &db.App{
+		ClientId:     NewClientId(),
+		Name:         "ci dashboard",
+		RedirectUris: []string{"https://app.example/callback"},
+		OwnerDid:     "did:plc:alice",
+	}
+	if err := db.AddApp(d, app, hash); err != nil {
+		t.Fatal(err)
+	}
+
+	a := New(d, nil, nil, nil, &config.Config{}, slog.Default())
+	router, api := a.Router(), a.APIRouter()
+
+	verifier := "a-verifier-long-enough-to-be-unguessable"
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	newCode := func(challenge string) string {
+		code := random(32)
+		err := db.AddAppCode(d, db.AppCode{
+			ClientId:      app.ClientId,
+			Did:           "did:plc:bob",
+			Scopes:        []db.AppScope{db.AppScopeRepoRead},
+			RedirectUri:   app.RedirectUris[0],
+			CodeChallenge: challenge,
+			Expires:       time.Now().Add(time.Minute),
+		}, Hash(code))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	exchange := func(form url.Values) (int, map[string]string) {
+		r := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		var body map[string]string
+		json.NewDecoder(w.Body).Decode(&body)
+		return w.Code, body
+	}
+
+	tests := []struct {
+		name      string
+		challenge string
+		form      url.Values
+		status    int
+	}{
+		{"secret", "", url.Values{"client_secret": {secret}}, http.StatusOK},
+		{"wrong secret", "", url.Values{"client_secret": {SecretPrefix + "nope"}}, http.StatusUnauthorized},
+		{"no secret or pkce", "", url.Values{}, http.StatusUnauthorized},
+		{"pkce", challenge, url.Values{"code_verifier": {verifier}}, http.StatusOK},
+		{"wrong verifier", challenge, url.Values{"code_verifier": {"something else"}}, http.StatusBadRequest},
+		{"other client", "", url.Values{"client_id": {NewClientId()}, "client_secret": {secret}}, http.StatusBadRequest},
+		{"other redirect", "", url.Values{"client_secret": {secret}, "redirect_uri": {"https://evil.example"}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := newCode(tt.challenge)
+			form := url.Values{"grant_type": {"authorization_code"}, "code": {code}, "client_id": {app.ClientId}}
+			for k, v := range tt.form {
+				form[k] = v
+			}
+
+			status, body := exchange(form)
+			if status != tt.status {
+				t.Fatalf("got %d %v, want %d", status, body, tt.status)
+			}
+			if status != http.StatusOK {
+				return
+			}
+
+			// codes are single use
+			if status, _ := exchange(form); status != http.StatusBadRequest {
+				t.Errorf("reusing the code got %d", status)
+			}
+
+			call := func(path string) int {
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				r.Header.Set("Authorization", "Bearer "+body["access_token"])
+				w := httptest.NewRecorder()
+				api.ServeHTTP(w, r)
+				return w.Code
+			}
+			if got := call("/repos"); got != http.StatusOK {
+				t.Errorf("repos got %d", got)
+			}
+			if got := call("/emails"); got != http.StatusForbidden {
+				t.Errorf("emails without the scope got %d", got)
+			}
+		})
+	}
+
+	if err := db.RevokeApp(d, "did:plc:bob", app.ClientId); err != nil {
+		t.Fatal(err)
+	}
+	if tokens, _ := db.GetAppTokens(d); len(tokens) != 0 {
+		t.Errorf("%d tokens left after revoking", len(tokens))
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	for origin, want := range map[string]bool{
+		"":                      true,
+		"https://tangled.sh":    true,
+		"https://evil.example":  false,
+		"https://tangled.sh.io": false,
+		"null":                  false,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "https://tangled.sh/apps/authorize", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := sameOrigin(r); got != want {
+			t.Errorf("origin %q: got %v, want %v", origin, got, want)
+		}
+	}
+}
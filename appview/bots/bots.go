@@ -0,0 +1,301 @@
+// Package bots serves the API that the bots of a repo use, with a token
+// instead of a login. Every request is authenticated with
+//
+//	Authorization: Bearer <token>
+//
+// and acts on the repo the bot belongs to, as far as its scopes allow.
+package bots
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// TokenPrefix starts every bot token, so that leaked ones are easy to spot
+const TokenPrefix = "tgb_"
+
+// NewToken makes a token for a bot, along with the hash that is stored in
+// its place
+func NewToken() (token, hash string) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token = TokenPrefix + hex.EncodeToString(b)
+	return token, HashToken(token)
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type Bots struct {
+	db       *db.DB
+	config   *config.Config
+	notifier notify.Notifier
+	logger   *slog.Logger
+}
+
+func New(db *db.DB, config *config.Config, notifier notify.Notifier, logger *slog.Logger) *Bots {
+	return &Bots{
+		db:       db,
+		config:   config,
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+func (b *Bots) Router() http.Handler {
+	r := chi.NewRouter()
+	r.Use(b.authenticate)
+
+	r.Get("/whoami", b.whoami)
+	r.With(requireScope(db.BotScopePullComment)).Post("/pulls/{pull}/comments", b.pullComment)
+	r.With(requireScope(db.BotScopeIssueComment)).Post("/issues/{issue}/comments", b.issueComment)
+	r.With(requireScope(db.BotScopeStatus)).Post("/statuses/{sha}", b.status)
+
+	return r
+}
+
+type botKey struct{}
+
+func botFrom(ctx context.Context) *db.Bot {
+	bot, _ := ctx.Value(botKey{}).(*db.Bot)
+	return bot
+}
+
+// authenticate finds the bot the bearer token belongs to
+func (b *Bots) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(token, TokenPrefix) {
+			writeError(w, http.StatusUnauthorized, "missing bot token")
+			return
+		}
+
+		bot, err := db.GetBotByToken(b.db, HashToken(token))
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid bot token")
+			return
+		}
+
+		if err := db.SetBotUsed(b.db, bot.Id); err != nil {
+			b.logger.Error("failed to record bot use", "bot", bot.Id, "err", err)
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), botKey{}, bot)))
+	})
+}
+
+func requireScope(scope db.BotScope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !botFrom(r.Context()).Can(scope) {
+				writeError(w, http.StatusForbidden, fmt.Sprintf("this bot lacks the %s scope", scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (b *Bots) whoami(w http.ResponseWriter, r *http.Request) {
+	bot := botFrom(r.Context())
+	writeJSON(w, http.StatusOK, map[string]any{
+		"name":   bot.Name,
+		"repo":   bot.RepoAt,
+		"scopes": bot.Scopes,
+	})
+}
+
+type commentInput struct {
+	Body string `json:"body"`
+}
+
+func (b *Bots) pullComment(w http.ResponseWriter, r *http.Request) {
+	bot := botFrom(r.Context())
+	l := b.logger.With("handler", "pullComment", "bot", bot.Id)
+
+	pullId, err := strconv.Atoi(chi.URLParam(r, "pull"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid pull request")
+		return
+	}
+
+	var input commentInput
+	if !b.decode(w, r, &input) {
+		return
+	}
+
+	pull, err := db.GetPull(b.db, bot.RepoAt, pullId)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no such pull request")
+		return
+	}
+
+	comment := &db.PullComment{
+		OwnerDid:     bot.Ident(),
+		RepoAt:       bot.RepoAt.String(),
+		PullId:       pull.PullId,
+		Body:         input.Body,
+		SubmissionId: pull.Submissions[pull.LastRoundNumber()].ID,
+	}
+
+	id, err := db.NewPullComment(b.db, comment)
+	if err != nil {
+		l.Error("failed to create pull comment", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to create comment")
+		return
+	}
+	comment.ID = int(id)
+
+	b.notifier.NewPullComment(r.Context(), comment)
+
+	writeJSON(w, http.StatusCreated, map[string]any{"id": id})
+}
+
+func (b *Bots) issueComment(w http.ResponseWriter, r *http.Request) {
+	bot := botFrom(r.Context())
+	l := b.logger.With("handler", "issueComment", "bot", bot.Id)
+
+	issueId, err := strconv.Atoi(chi.URLParam(r, "issue"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid issue")
+		return
+	}
+
+	var input commentInput
+	if !b.decode(w, r, &input) {
+		return
+	}
+
+	if _, err := db.GetIssue(b.db, bot.RepoAt, issueId); err != nil {
+		writeError(w, http.StatusNotFound, "no such issue")
+		return
+	}
+
+	createdAt := time.Now()
+	comment := &db.Comment{
+		OwnerDid:  bot.Ident(),
+		RepoAt:    bot.RepoAt,
+		Issue:     issueId,
+		CommentId: mathrand.IntN(1000000),
+		Body:      input.Body,
+		Rkey:      tid.TID(),
+		Created:   &createdAt,
+	}
+
+	if err := db.NewIssueComment(b.db, comment); err != nil {
+		l.Error("failed to create issue comment", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to create comment")
+		return
+	}
+
+	b.notifier.NewIssueComment(r.Context(), comment)
+
+	writeJSON(w, http.StatusCreated, map[string]any{"id": comment.CommentId})
+}
+
+type statusInput struct {
+	State       db.CommitState `json:"state"`
+	Context     string         `json:"context"`
+	Description string         `json:"description"`
+	TargetUrl   string         `json:"targetUrl"`
+}
+
+func (b *Bots) status(w http.ResponseWriter, r *http.Request) {
+	bot := botFrom(r.Context())
+	l := b.logger.With("handler", "status", "bot", bot.Id)
+
+	sha := strings.ToLower(chi.URLParam(r, "sha"))
+	if !plumbing.IsHash(sha) {
+		writeError(w, http.StatusBadRequest, "sha must be a full commit hash")
+		return
+	}
+
+	var input statusInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	if !input.State.IsValid() {
+		writeError(w, http.StatusBadRequest, "state must be one of pending, success, failure or error")
+		return
+	}
+	if input.Context == "" {
+		input.Context = bot.Name
+	}
+	if len(input.Context) > 100 || len(input.Description) > 300 {
+		writeError(w, http.StatusBadRequest, "context or description is too long")
+		return
+	}
+	if input.TargetUrl != "" && !strings.HasPrefix(input.TargetUrl, "https://") && !strings.HasPrefix(input.TargetUrl, "http://") {
+		writeError(w, http.StatusBadRequest, "targetUrl must be a http or https url")
+		return
+	}
+
+	err := db.SetCommitStatus(b.db, db.CommitStatus{
+		RepoAt:      bot.RepoAt,
+		Sha:         sha,
+		Context:     input.Context,
+		State:       input.State,
+		Description: input.Description,
+		TargetUrl:   input.TargetUrl,
+		BotId:       bot.Id,
+	})
+	if err != nil {
+		l.Error("failed to set status", "err", err)
+		writeError(w, http.StatusInternalServerError, "failed to set status")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decode reads a comment from the request body and checks it against the
+// limits of the appview
+func (b *Bots) decode(w http.ResponseWriter, r *http.Request, input *commentInput) bool {
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return false
+	}
+
+	input.Body = strings.TrimSpace(input.Body)
+	if input.Body == "" {
+		writeError(w, http.StatusBadRequest, "body is required")
+		return false
+	}
+	if err := b.config.Limits.CheckCommentBody(input.Body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
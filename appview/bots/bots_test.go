@@ -0,0 +1,115 @@
+package bots
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
+)
+
+func TestBots(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &db.Repo{Did: "did:plc:alice", Name: "core", Knot: "knot.example", Rkey: "3abc"}
+	if err := db.AddRepo(d, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.NewIssue(tx, &db.Issue{RepoAt: repo.RepoAt(), OwnerDid: "did:plc:bob", Title: "flaky test", Rkey: "3def"}); err != nil {
+		t.Fatal(err)
+	}
+
+	token, hash := NewToken()
+	bot := &db.Bot{RepoAt: repo.RepoAt(), Name: "ci", CreatedBy: repo.Did, Scopes: []db.BotScope{db.BotScopeStatus}}
+	if err := db.AddBot(d, bot, hash); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Limits: config.LimitsConfig{CommentBody: 100}}
+	router := New(d, cfg, &notify.BaseNotifier{}, slog.Default()).Router()
+
+	do := func(token, path, body string) int {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	sha := strings.Repeat("a", 40)
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		body   string
+		status int
+	}{
+		{"no token", "", "/statuses/" + sha, `{"state": "success"}`, http.StatusUnauthorized},
+		{"unknown token", TokenPrefix + "nope", "/statuses/" + sha, `{"state": "success"}`, http.StatusUnauthorized},
+		{"status", token, "/statuses/" + sha, `{"state": "success", "context": "ci/build"}`, http.StatusNoContent},
+		{"invalid state", token, "/statuses/" + sha, `{"state": "great"}`, http.StatusBadRequest},
+		{"short sha", token, "/statuses/abc", `{"state": "success"}`, http.StatusBadRequest},
+		{"missing scope", token, "/issues/1/comments", `{"body": "hello"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := do(tt.token, tt.path, tt.body); got != tt.status {
+				t.Errorf("got status %d, expected %d", got, tt.status)
+			}
+		})
+	}
+
+	statuses, err := db.GetCommitStatuses(d, repo.RepoAt(), []string{sha})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses[sha]; len(s) != 1 || s[0].Context != "ci/build" || s[0].BotName != "ci" {
+		t.Errorf("got statuses %+v", s)
+	}
+
+	// a bot allowed to comment gets to, under its own name
+	commenter, hash := NewToken()
+	if err := db.AddBot(d, &db.Bot{RepoAt: repo.RepoAt(), Name: "triage", CreatedBy: repo.Did, Scopes: []db.BotScope{db.BotScopeIssueComment}}, hash); err != nil {
+		t.Fatal(err)
+	}
+	if got := do(commenter, "/issues/1/comments", `{"body": "hello"}`); got != http.StatusCreated {
+		t.Fatalf("got status %d commenting", got)
+	}
+	if got := do(commenter, "/issues/2/comments", `{"body": "hello"}`); got != http.StatusNotFound {
+		t.Errorf("got status %d commenting on a missing issue", got)
+	}
+	if got := do(commenter, "/issues/1/comments", `{"body": "`+strings.Repeat("x", 101)+`"}`); got != http.StatusBadRequest {
+		t.Errorf("got status %d for a long comment", got)
+	}
+
+	comments, err := db.GetComments(d, repo.RepoAt(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || comments[0].OwnerDid != "bot:triage" {
+		t.Errorf("got comments %+v", comments)
+	}
+
+	// removing a bot revokes its token
+	if err := db.DeleteBot(d, repo.RepoAt(), bot.Id); err != nil {
+		t.Fatal(err)
+	}
+	if got := do(token, "/statuses/"+sha, `{"state": "success"}`); got != http.StatusUnauthorized {
+		t.Errorf("got status %d with a revoked token", got)
+	}
+}
@@ -0,0 +1,108 @@
+// Package bsky posts announcements about tangled activity to the user's
+// bluesky feed, using the same oauth session that writes tangled records.
+package bsky
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"tangled.sh/tangled.sh/core/appview/ogcard"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// posts are limited to 300 graphemes, runes are a conservative stand-in
+const maxPostLength = 300
+
+type Announcement struct {
+	// body of the post, the url is appended on a line of its own
+	Text string
+	Url  string
+
+	// title and description of the link card
+	Title       string
+	Description string
+
+	// rendered and attached as the thumbnail of the link card, if set
+	Card *ogcard.Card
+}
+
+// Post creates an app.bsky.feed.post on the user's PDS and returns its
+// at-uri
+func Post(ctx context.Context, client *xrpcclient.Client, did string, a Announcement) (string, error) {
+	external := &bsky.EmbedExternal_External{
+		Uri:         a.Url,
+		Title:       a.Title,
+		Description: a.Description,
+	}
+
+	if a.Card != nil {
+		var buf bytes.Buffer
+		if err := a.Card.Render(&buf); err != nil {
+			return "", fmt.Errorf("rendering card: %w", err)
+		}
+
+		resp, err := client.RepoUploadBlob(ctx, &buf)
+		if err != nil {
+			return "", fmt.Errorf("uploading card: %w", err)
+		}
+		external.Thumb = resp.Blob
+	}
+
+	text, facets := compose(a.Text, a.Url)
+
+	resp, err := client.RepoPutRecord(ctx, &comatproto.RepoPutRecord_Input{
+		Collection: "app.bsky.feed.post",
+		Repo:       did,
+		Rkey:       tid.TID(),
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &bsky.FeedPost{
+				Text:      text,
+				Facets:    facets,
+				CreatedAt: time.Now().Format(time.RFC3339),
+				Embed: &bsky.FeedPost_Embed{
+					EmbedExternal: &bsky.EmbedExternal{
+						External: external,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating post: %w", err)
+	}
+
+	return resp.Uri, nil
+}
+
+// compose joins the body and the url, trimming the body so that the url
+// always fits, and marks the url as a link facet
+func compose(body, url string) (string, []*bsky.RichtextFacet) {
+	budget := maxPostLength - len([]rune(url)) - 2
+	if runes := []rune(body); len(runes) > budget {
+		body = string(runes[:max(budget-1, 0)]) + "…"
+	}
+
+	text := url
+	if body != "" {
+		text = body + "\n\n" + url
+	}
+
+	start := len(text) - len(url)
+	return text, []*bsky.RichtextFacet{
+		{
+			Index: &bsky.RichtextFacet_ByteSlice{
+				ByteStart: int64(start),
+				ByteEnd:   int64(len(text)),
+			},
+			Features: []*bsky.RichtextFacet_Features_Elem{
+				{RichtextFacet_Link: &bsky.RichtextFacet_Link{Uri: url}},
+			},
+		},
+	}
+}
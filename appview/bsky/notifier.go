@@ -0,0 +1,277 @@
+package bsky
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/api/chat"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
+	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// the bluesky chat service, which the pds proxies chat.bsky calls to
+const chatProxy = "did:web:api.bsky.chat#bsky_chat"
+
+// Notifier tells users about pulls awaiting their review and about their
+// merged pulls from an account run by the operator, as a direct message or a
+// post mentioning them, whichever they opted in to
+type Notifier struct {
+	notify.BaseNotifier
+
+	db          *db.DB
+	idResolver  *idresolver.Resolver
+	config      config.BskyNotifyConfig
+	appviewHost string
+	logger      *slog.Logger
+
+	mu     sync.Mutex
+	client *xrpc.Client
+	// when each user was last notified, within the rate limit window
+	sent map[string][]time.Time
+}
+
+var _ notify.Notifier = &Notifier{}
+
+func NewNotifier(d *db.DB, idResolver *idresolver.Resolver, c *config.Config, logger *slog.Logger) *Notifier {
+	return &Notifier{
+		db:          d,
+		idResolver:  idResolver,
+		config:      c.BskyNotify,
+		appviewHost: c.Core.AppviewHost,
+		logger:      logger,
+		sent:        make(map[string][]time.Time),
+	}
+}
+
+// NewPull asks the owner of the repo for a review
+func (n *Notifier) NewPull(ctx context.Context, pull *db.Pull) {
+	repo, err := db.GetRepoByAtUri(n.db, pull.RepoAt.String())
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", pull.RepoAt, "err", err)
+		return
+	}
+
+	n.notify(pull.OwnerDid, repo.Did, repo, pull, func(actor, repoName string) string {
+		return fmt.Sprintf("%s requested your review on %s: #%d %s", actor, repoName, pull.PullId, pull.Title)
+	})
+}
+
+func (n *Notifier) NewPullMerged(ctx context.Context, pull *db.Pull, actorDid string) {
+	repo, err := db.GetRepoByAtUri(n.db, pull.RepoAt.String())
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", pull.RepoAt, "err", err)
+		return
+	}
+
+	n.notify(actorDid, pull.OwnerDid, repo, pull, func(actor, repoName string) string {
+		return fmt.Sprintf("%s merged your pull request on %s: #%d %s", actor, repoName, pull.PullId, pull.Title)
+	})
+}
+
+// notify delivers the message to recipient, unless they are the actor, did
+// not opt in, or had too many notifications lately
+func (n *Notifier) notify(actor, recipient string, repo *db.Repo, pull *db.Pull, message func(actor, repoName string) string) {
+	if actor == recipient {
+		return
+	}
+
+	settings, err := db.GetNotificationSettings(n.db, recipient)
+	if err != nil {
+		n.logger.Error("failed to get notification settings", "did", recipient, "err", err)
+		return
+	}
+	if settings.Bluesky == db.BlueskyOff {
+		return
+	}
+
+	if !n.allow(recipient) {
+		n.logger.Info("rate limited", "did", recipient)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		actorName := actor
+		if id, err := n.idResolver.ResolveIdent(ctx, actor); err == nil && !id.Handle.IsInvalidHandle() {
+			actorName = "@" + id.Handle.String()
+		}
+
+		repoName := repo.Did + "/" + repo.Name
+		ownerSlashRepo := repoName
+		if id, err := n.idResolver.ResolveIdent(ctx, repo.Did); err == nil && !id.Handle.IsInvalidHandle() {
+			repoName = id.Handle.String() + "/" + repo.Name
+			ownerSlashRepo = "@" + repoName
+		}
+		link := fmt.Sprintf("%s/%s/pulls/%d", n.appviewHost, ownerSlashRepo, pull.PullId)
+
+		text := message(actorName, repoName)
+
+		var err error
+		switch settings.Bluesky {
+		case db.BlueskyDm:
+			err = n.message(ctx, recipient, text, link)
+		case db.BlueskyMention:
+			err = n.mention(ctx, recipient, text, link)
+		}
+		if err != nil {
+			n.logger.Error("failed to notify", "did", recipient, "via", settings.Bluesky, "err", err)
+		}
+	}()
+}
+
+// allow counts a notification against the rate limit of did, if it is
+// within it
+func (n *Notifier) allow(did string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	var recent []time.Time
+	for _, t := range n.sent[did] {
+		if now.Sub(t) < n.config.Window {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= n.config.Limit {
+		n.sent[did] = recent
+		return false
+	}
+	n.sent[did] = append(recent, now)
+	return true
+}
+
+// message sends a direct message, which only arrives if the recipient
+// accepts messages from the account
+func (n *Notifier) message(ctx context.Context, did, text, link string) error {
+	client, err := n.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	// chat calls go through the pds to the chat service
+	chatClient := *client
+	chatClient.Headers = map[string]string{"atproto-proxy": chatProxy}
+
+	convo, err := chat.ConvoGetConvoForMembers(ctx, &chatClient, []string{did})
+	if err != nil {
+		n.reset()
+		return fmt.Errorf("getting conversation: %w", err)
+	}
+
+	text, facets := compose(text, link)
+	_, err = chat.ConvoSendMessage(ctx, &chatClient, &chat.ConvoSendMessage_Input{
+		ConvoId: convo.Convo.Id,
+		Message: &chat.ConvoDefs_MessageInput{
+			Text:   text,
+			Facets: facets,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	return nil
+}
+
+// mention posts text from the account, starting with a mention of did
+func (n *Notifier) mention(ctx context.Context, did, text, link string) error {
+	id, err := n.idResolver.ResolveIdent(ctx, did)
+	if err != nil || id.Handle.IsInvalidHandle() {
+		return fmt.Errorf("resolving %s: %w", did, err)
+	}
+
+	client, err := n.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	handle := "@" + id.Handle.String()
+	text, facets := compose(handle+" "+text, link)
+	facets = append(facets, &bsky.RichtextFacet{
+		Index: &bsky.RichtextFacet_ByteSlice{
+			ByteStart: 0,
+			ByteEnd:   int64(len(handle)),
+		},
+		Features: []*bsky.RichtextFacet_Features_Elem{
+			{RichtextFacet_Mention: &bsky.RichtextFacet_Mention{Did: did}},
+		},
+	})
+
+	_, err = comatproto.RepoPutRecord(ctx, client, &comatproto.RepoPutRecord_Input{
+		Collection: "app.bsky.feed.post",
+		Repo:       n.config.Did,
+		Rkey:       tid.TID(),
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &bsky.FeedPost{
+				Text:      text,
+				Facets:    facets,
+				CreatedAt: time.Now().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		n.reset()
+		return fmt.Errorf("creating post: %w", err)
+	}
+	return nil
+}
+
+// session logs in to the account with its app password, the session is kept
+// until a call fails
+func (n *Notifier) session(ctx context.Context) (*xrpc.Client, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.client != nil {
+		return n.client, nil
+	}
+
+	host := n.config.Host
+	if host == "" {
+		id, err := n.idResolver.ResolveIdent(ctx, n.config.Did)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", n.config.Did, err)
+		}
+		host = id.PDSEndpoint()
+		if host == "" {
+			return nil, fmt.Errorf("no pds found for %s", n.config.Did)
+		}
+	}
+
+	client := &xrpc.Client{Host: host}
+	out, err := comatproto.ServerCreateSession(ctx, client, &comatproto.ServerCreateSession_Input{
+		Identifier: n.config.Did,
+		Password:   n.config.AppPassword,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+
+	client.Auth = &xrpc.AuthInfo{
+		AccessJwt:  out.AccessJwt,
+		RefreshJwt: out.RefreshJwt,
+		Handle:     out.Handle,
+		Did:        out.Did,
+	}
+	n.client = client
+	return client, nil
+}
+
+// reset drops the session, most likely it expired
+func (n *Notifier) reset() {
+	n.mu.Lock()
+	n.client = nil
+	n.mu.Unlock()
+}
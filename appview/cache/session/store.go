@@ -3,9 +3,12 @@ package session
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"tangled.sh/tangled.sh/core/appview/cache"
 )
 
@@ -32,6 +35,20 @@ type OAuthRequest struct {
 	DpopAuthserverNonce string
 	DpopPrivateJwk      string
 	ReturnUrl           string
+	InviteCode          string
+}
+
+// Login is one sign in of a user, kept so that they can see where they are
+// signed in and revoke the sessions they do not recognise
+type Login struct {
+	Id        string
+	Did       string
+	IP        string
+	UserAgent string
+	Created   time.Time
+	// whether it came from a device or address that did had not signed in
+	// from before
+	New bool
 }
 
 type SessionStore struct {
@@ -42,8 +59,14 @@ const (
 	stateKey   = "oauthstate:%s"
 	requestKey = "oauthrequest:%s"
 	sessionKey = "oauthsession:%s"
+	loginsKey  = "oauthlogins:%s"
+	alertKey   = "oauthloginalert:%s"
 )
 
+// logins are remembered for longer than sessions last, so that signing in
+// again after a session expired is not reported as a new device
+const loginTTL = 30 * 24 * time.Hour
+
 func New(cache *cache.Cache) *SessionStore {
 	return &SessionStore{cache: cache}
 }
@@ -170,3 +193,90 @@ func (s *SessionStore) getRequestKeyFromState(ctx context.Context, state string)
 	didKey := fmt.Sprintf(requestKey, did)
 	return didKey, nil
 }
+
+// AddLogin records a login of login.Did, and marks it as new if its user agent
+// or address was not seen among earlier logins. The very first login is never
+// new, there is nothing to compare it with.
+func (s *SessionStore) AddLogin(ctx context.Context, login *Login) error {
+	logins, err := s.GetLogins(ctx, login.Did)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf(loginsKey, login.Did)
+
+	var seen int
+	var knownIP, knownDevice bool
+	for _, l := range logins {
+		if time.Since(l.Created) > loginTTL {
+			s.cache.HDel(ctx, key, l.Id)
+			continue
+		}
+		seen++
+		knownIP = knownIP || l.IP == login.IP
+		knownDevice = knownDevice || l.UserAgent == login.UserAgent
+	}
+	login.New = seen > 0 && (!knownIP || !knownDevice)
+
+	data, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cache.HSet(ctx, key, login.Id, data).Err(); err != nil {
+		return err
+	}
+	return s.cache.Expire(ctx, key, loginTTL).Err()
+}
+
+// GetLogins lists the logins of did, newest first
+func (s *SessionStore) GetLogins(ctx context.Context, did string) ([]Login, error) {
+	vals, err := s.cache.HGetAll(ctx, fmt.Sprintf(loginsKey, did)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	logins := make([]Login, 0, len(vals))
+	for _, val := range vals {
+		var login Login
+		if err := json.Unmarshal([]byte(val), &login); err != nil {
+			continue
+		}
+		logins = append(logins, login)
+	}
+
+	slices.SortFunc(logins, func(a, b Login) int {
+		return b.Created.Compare(a.Created)
+	})
+	return logins, nil
+}
+
+// HasLogin reports whether the login id of did is still valid, i.e. it has
+// not been revoked
+func (s *SessionStore) HasLogin(ctx context.Context, did, id string) (bool, error) {
+	return s.cache.HExists(ctx, fmt.Sprintf(loginsKey, did), id).Result()
+}
+
+func (s *SessionStore) DeleteLogin(ctx context.Context, did, id string) error {
+	return s.cache.HDel(ctx, fmt.Sprintf(loginsKey, did), id).Err()
+}
+
+// SetLoginAlert flags a new login of did, to be shown on the other devices
+// did is signed in on until they look at their sessions
+func (s *SessionStore) SetLoginAlert(ctx context.Context, did, id string) error {
+	return s.cache.Set(ctx, fmt.Sprintf(alertKey, did), id, loginTTL).Err()
+}
+
+// GetLoginAlert returns the id of the flagged login of did, or an empty
+// string if there is none
+func (s *SessionStore) GetLoginAlert(ctx context.Context, did string) (string, error) {
+	id, err := s.cache.Get(ctx, fmt.Sprintf(alertKey, did)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return id, err
+}
+
+func (s *SessionStore) ClearLoginAlert(ctx context.Context, did string) error {
+	return s.cache.Del(ctx, fmt.Sprintf(alertKey, did)).Err()
+}
@@ -0,0 +1,156 @@
+// Package challenge issues and verifies proof of work challenges, which
+// slow down bots signing up and posting in bulk. A challenge is a signed
+// token, solved by finding a solution such that the sha256 of token and
+// solution starts with the given number of zero bits.
+package challenge
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionSignup  Action = "signup"
+	ActionRepo    Action = "repo"
+	ActionComment Action = "comment"
+)
+
+// Challenge is sent to clients as json
+type Challenge struct {
+	Token      string `json:"token"`
+	Difficulty int    `json:"difficulty"`
+}
+
+var (
+	ErrMissing  = errors.New("challenge not solved")
+	ErrInvalid  = errors.New("invalid challenge")
+	ErrExpired  = errors.New("challenge expired")
+	ErrReused   = errors.New("challenge already used")
+	ErrUnsolved = errors.New("incorrect solution")
+)
+
+type Challenger struct {
+	secret   []byte
+	validity time.Duration
+
+	// solved tokens, until they expire
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func New(secret []byte, validity time.Duration) *Challenger {
+	return &Challenger{
+		secret:   secret,
+		validity: validity,
+		used:     make(map[string]time.Time),
+	}
+}
+
+// Issue creates a challenge for action
+func (c *Challenger) Issue(action Action, difficulty int) Challenge {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	payload := strings.Join([]string{
+		string(action),
+		strconv.Itoa(difficulty),
+		strconv.FormatInt(time.Now().Add(c.validity).Unix(), 10),
+		hex.EncodeToString(nonce),
+	}, ".")
+
+	return Challenge{
+		Token:      payload + "." + c.sign(payload),
+		Difficulty: difficulty,
+	}
+}
+
+// Verify checks that token is a challenge for action that has been solved,
+// a token can only be used once
+func (c *Challenger) Verify(action Action, token, solution string) error {
+	if token == "" || solution == "" {
+		return ErrMissing
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return ErrInvalid
+	}
+
+	payload := strings.Join(parts[:4], ".")
+	if !hmac.Equal([]byte(parts[4]), []byte(c.sign(payload))) {
+		return ErrInvalid
+	}
+
+	if Action(parts[0]) != action {
+		return fmt.Errorf("%w: issued for %s", ErrInvalid, parts[0])
+	}
+
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return ErrInvalid
+	}
+
+	expiry, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	expires := time.Unix(expiry, 0)
+	if time.Now().After(expires) {
+		return ErrExpired
+	}
+
+	if !Solves(token, solution, difficulty) {
+		return ErrUnsolved
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for t, exp := range c.used {
+		if now.After(exp) {
+			delete(c.used, t)
+		}
+	}
+
+	if _, ok := c.used[token]; ok {
+		return ErrReused
+	}
+	c.used[token] = expires
+
+	return nil
+}
+
+func (c *Challenger) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Solves tells whether the sha256 of token and solution starts with
+// difficulty zero bits
+func Solves(token, solution string, difficulty int) bool {
+	sum := sha256.Sum256([]byte(token + solution))
+
+	zeros := 0
+	for _, b := range sum {
+		if b != 0 {
+			zeros += bits.LeadingZeros8(b)
+			break
+		}
+		zeros += 8
+	}
+
+	return zeros >= difficulty
+}
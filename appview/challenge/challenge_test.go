@@ -0,0 +1,48 @@
+package challenge
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func solve(c Challenge) string {
+	for n := 0; ; n++ {
+		if Solves(c.Token, strconv.Itoa(n), c.Difficulty) {
+			return strconv.Itoa(n)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	c := New([]byte("secret"), time.Minute)
+
+	ch := c.Issue(ActionComment, 8)
+	solution := solve(ch)
+
+	if err := c.Verify(ActionRepo, ch.Token, solution); !errors.Is(err, ErrInvalid) {
+		t.Errorf("challenge for another action: got %v", err)
+	}
+	if err := c.Verify(ActionComment, ch.Token+"0", solution); !errors.Is(err, ErrInvalid) {
+		t.Errorf("tampered challenge: got %v", err)
+	}
+	if err := New([]byte("other"), time.Minute).Verify(ActionComment, ch.Token, solution); !errors.Is(err, ErrInvalid) {
+		t.Errorf("challenge signed with another secret: got %v", err)
+	}
+	if err := c.Verify(ActionComment, ch.Token, ""); !errors.Is(err, ErrMissing) {
+		t.Errorf("missing solution: got %v", err)
+	}
+
+	if err := c.Verify(ActionComment, ch.Token, solution); err != nil {
+		t.Fatalf("solved challenge: %v", err)
+	}
+	if err := c.Verify(ActionComment, ch.Token, solution); !errors.Is(err, ErrReused) {
+		t.Errorf("reused challenge: got %v", err)
+	}
+
+	expired := New([]byte("secret"), -time.Minute).Issue(ActionComment, 0)
+	if err := c.Verify(ActionComment, expired.Token, "0"); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired challenge: got %v", err)
+	}
+}
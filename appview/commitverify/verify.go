@@ -12,6 +12,7 @@ import (
 type verifiedCommit struct {
 	fingerprint string
 	hash        string
+	kind        string
 }
 
 type VerifiedCommits map[verifiedCommit]struct{}
@@ -34,6 +35,17 @@ func (vcs VerifiedCommits) Fingerprint(hash string) string {
 	return ""
 }
 
+// Kind is the kind of key that signed the commit, db.SigningKeySSH or
+// db.SigningKeyGPG
+func (vcs VerifiedCommits) Kind(hash string) string {
+	for vc := range vcs {
+		if vc.hash == hash {
+			return vc.kind
+		}
+	}
+	return ""
+}
+
 func GetVerifiedObjectCommits(e db.Execer, emailToDid map[string]string, commits []*object.Commit) (VerifiedCommits, error) {
 	ndCommits := []types.NiceDiff{}
 	for _, commit := range commits {
@@ -42,39 +54,91 @@ func GetVerifiedObjectCommits(e db.Execer, emailToDid map[string]string, commits
 	return GetVerifiedCommits(e, emailToDid, ndCommits)
 }
 
+type didKeys struct {
+	ssh []string
+	gpg []string
+}
+
+// keysForDid collects both the keys a user pushes with and their signing keys
+func keysForDid(e db.Execer, did string) (didKeys, error) {
+	var keys didKeys
+
+	pubKeys, err := db.GetPublicKeysForDid(e, did)
+	if err != nil {
+		return keys, err
+	}
+	for _, pk := range pubKeys {
+		keys.ssh = append(keys.ssh, pk.Key)
+	}
+
+	signingKeys, err := db.GetSigningKeys(e, db.FilterEq("did", did))
+	if err != nil {
+		return keys, err
+	}
+	for _, sk := range signingKeys {
+		switch sk.Kind {
+		case db.SigningKeySSH:
+			keys.ssh = append(keys.ssh, sk.Key)
+		case db.SigningKeyGPG:
+			keys.gpg = append(keys.gpg, sk.Key)
+		}
+	}
+
+	return keys, nil
+}
+
 func GetVerifiedCommits(e db.Execer, emailToDid map[string]string, ndCommits []types.NiceDiff) (VerifiedCommits, error) {
 	vcs := VerifiedCommits{}
 
-	didPubkeyCache := make(map[string][]db.PublicKey)
+	didKeyCache := make(map[string]didKeys)
 
 	for _, commit := range ndCommits {
 		c := commit.Commit
+		if c.PGPSignature == "" {
+			continue
+		}
 
 		committerEmail := c.Committer.Email
 		if did, exists := emailToDid[committerEmail]; exists {
-			// check if we've already fetched public keys for this did
-			pubKeys, ok := didPubkeyCache[did]
+			// check if we've already fetched keys for this did
+			keys, ok := didKeyCache[did]
 			if !ok {
-				// fetch and cache public keys
-				keys, err := db.GetPublicKeysForDid(e, did)
+				// fetch and cache keys
+				k, err := keysForDid(e, did)
 				if err != nil {
-					log.Printf("failed to fetch pubkey for %s: %v", committerEmail, err)
+					log.Printf("failed to fetch keys for %s: %v", committerEmail, err)
 					continue
 				}
-				pubKeys = keys
-				didPubkeyCache[did] = pubKeys
+				keys = k
+				didKeyCache[did] = keys
+			}
+
+			// try to verify with any associated keys
+			if crypto.IsPGPSignature(c.PGPSignature) {
+				for _, key := range keys.gpg {
+					if _, ok := crypto.VerifyCommitPGPSignature(key, commit); ok {
+						fp, err := crypto.PGPFingerprint(key)
+						if err != nil {
+							log.Println("error computing gpg fingerprint:", err)
+						}
+
+						vc := verifiedCommit{fingerprint: fp, hash: c.This, kind: db.SigningKeyGPG}
+						vcs[vc] = struct{}{}
+						break
+					}
+				}
+				continue
 			}
 
-			// try to verify with any associated pubkeys
-			for _, pk := range pubKeys {
-				if _, ok := crypto.VerifyCommitSignature(pk.Key, commit); ok {
+			for _, key := range keys.ssh {
+				if _, ok := crypto.VerifyCommitSignature(key, commit); ok {
 
-					fp, err := crypto.SSHFingerprint(pk.Key)
+					fp, err := crypto.SSHFingerprint(key)
 					if err != nil {
 						log.Println("error computing ssh fingerprint:", err)
 					}
 
-					vc := verifiedCommit{fingerprint: fp, hash: c.This}
+					vc := verifiedCommit{fingerprint: fp, hash: c.This, kind: db.SigningKeySSH}
 					vcs[vc] = struct{}{}
 					break
 				}
@@ -2,11 +2,18 @@ package config
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"fmt"
 	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/sethvargo/go-envconfig"
+	"tangled.sh/tangled.sh/core/objectstore"
 )
 
 type CoreConfig struct {
@@ -17,6 +24,14 @@ type CoreConfig struct {
 	Dev                     bool   `env:"DEV, default=false"`
 	DisallowedNicknamesFile string `env:"DISALLOWED_NICKNAMES_FILE"`
 
+	// queries taking longer than this are logged, and listed for admins
+	// under settings
+	SlowQueryThreshold time.Duration `env:"SLOW_QUERY_THRESHOLD, default=100ms"`
+
+	// directory with templates/ and static/ files replacing the built-in
+	// ones, to customize the look of an instance
+	ThemeDir string `env:"THEME_DIR"`
+
 	// temporarily, to add users to default knot and spindle
 	AppPassword string `env:"APP_PASSWORD"`
 
@@ -24,6 +39,14 @@ type CoreConfig struct {
 	TmpAltAppPassword string `env:"ALT_APP_PASSWORD"`
 }
 
+// Secret derives a key for one purpose from CookieSecret, so that a token
+// signed for one purpose is never accepted for another
+func (cfg CoreConfig) Secret(purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(cfg.CookieSecret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
 type OAuthConfig struct {
 	Jwks string `env:"JWKS"`
 }
@@ -71,6 +94,150 @@ type PdsConfig struct {
 	AdminSecret string `env:"ADMIN_SECRET"`
 }
 
+type SshCaConfig struct {
+	// private key of the certificate authority, certificates are not issued
+	// without one
+	KeyPath  string        `env:"KEY_PATH"`
+	Validity time.Duration `env:"VALIDITY, default=24h"`
+}
+
+type SpamConfig struct {
+	// accounts first seen by the appview within NewAccountAge may only open
+	// NewAccountLimit issues and comments per RateWindow
+	NewAccountAge   time.Duration `env:"NEW_ACCOUNT_AGE, default=72h"`
+	NewAccountLimit int           `env:"NEW_ACCOUNT_LIMIT, default=5"`
+	RateWindow      time.Duration `env:"RATE_WINDOW, default=1h"`
+
+	MaxLinks       int     `env:"MAX_LINKS, default=10"`
+	MaxLinkDensity float64 `env:"MAX_LINK_DENSITY, default=0.3"`
+
+	// one entry per line, dids block their authors and anything else is
+	// matched against the title and body
+	BlocklistFile string `env:"BLOCKLIST_FILE"`
+
+	// optional, content is posted here as json to be classified
+	ClassifierUrl     string        `env:"CLASSIFIER_URL"`
+	ClassifierTimeout time.Duration `env:"CLASSIFIER_TIMEOUT, default=2s"`
+}
+
+type ChallengeConfig struct {
+	// leading zero bits required of the proof of work, challenges are
+	// disabled when 0
+	Difficulty int      `env:"DIFFICULTY, default=0"`
+	Actions    []string `env:"ACTIONS, default=signup,repo,comment"`
+
+	// logged in users are only challenged once they create more than
+	// BurstLimit repos or comments within BurstWindow
+	BurstLimit  int           `env:"BURST_LIMIT, default=5"`
+	BurstWindow time.Duration `env:"BURST_WINDOW, default=10m"`
+
+	Validity time.Duration `env:"VALIDITY, default=5m"`
+}
+
+const (
+	RegistrationOpen   = "open"
+	RegistrationInvite = "invite"
+	RegistrationClosed = "closed"
+)
+
+type RegistrationConfig struct {
+	// one of open, invite or closed, accounts that have used the appview
+	// before are always let in
+	Policy string `env:"POLICY, default=open"`
+
+	// hosts of PDSes whose accounts may sign up, or may not, an empty
+	// allowlist allows any PDS
+	AllowedPds []string `env:"ALLOWED_PDS"`
+	DeniedPds  []string `env:"DENIED_PDS"`
+
+	// dids that may create any number of invites and manage the waitlist
+	Admins []string `env:"ADMINS"`
+
+	// invites every member may create
+	InvitesPerUser int `env:"INVITES_PER_USER, default=0"`
+}
+
+// PdsAllowed tells whether accounts hosted on pdsUrl may sign up
+func (cfg RegistrationConfig) PdsAllowed(pdsUrl string) bool {
+	host := pdsUrl
+	if u, err := url.Parse(pdsUrl); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+
+	if slices.Contains(cfg.DeniedPds, host) {
+		return false
+	}
+	return len(cfg.AllowedPds) == 0 || slices.Contains(cfg.AllowedPds, host)
+}
+
+func (cfg RegistrationConfig) IsAdmin(did string) bool {
+	return slices.Contains(cfg.Admins, did)
+}
+
+// LimitsConfig caps the length of text users write, in characters. A limit
+// of 0 disables it.
+type LimitsConfig struct {
+	IssueBody   int `env:"ISSUE_BODY, default=65536"`
+	CommentBody int `env:"COMMENT_BODY, default=65536"`
+	PullBody    int `env:"PULL_BODY, default=65536"`
+}
+
+func (cfg LimitsConfig) CheckIssueBody(body string) error {
+	return checkLength("issue body", body, cfg.IssueBody)
+}
+
+func (cfg LimitsConfig) CheckCommentBody(body string) error {
+	return checkLength("comment", body, cfg.CommentBody)
+}
+
+func (cfg LimitsConfig) CheckPullBody(body string) error {
+	return checkLength("pull request description", body, cfg.PullBody)
+}
+
+func checkLength(what, text string, limit int) error {
+	if n := utf8.RuneCountInString(text); limit > 0 && n > limit {
+		return fmt.Errorf("The %s is too long: %d characters, at most %d are allowed.", what, n, limit)
+	}
+	return nil
+}
+
+// FeedConfig is the account pull merges, closed issues and releases are
+// published to as sh.tangled.feed.event records, for other apps to build on
+type FeedConfig struct {
+	Enabled     bool   `env:"ENABLED, default=false"`
+	Did         string `env:"DID"`
+	AppPassword string `env:"APP_PASSWORD"`
+
+	// pds of the account, resolved from its did when empty
+	Host string `env:"HOST"`
+
+	// events are written in batches of up to BatchSize, at least every
+	// FlushInterval
+	BatchSize     int           `env:"BATCH_SIZE, default=50"`
+	FlushInterval time.Duration `env:"FLUSH_INTERVAL, default=30s"`
+}
+
+// BskyNotifyConfig is the account that tells users about pulls awaiting
+// their review and their merged pulls on bluesky, as a direct message or a
+// mention, if they opt in. Unset, there are no such notifications.
+type BskyNotifyConfig struct {
+	Did         string `env:"DID"`
+	AppPassword string `env:"APP_PASSWORD"`
+
+	// pds of the account, resolved from its did when empty
+	Host string `env:"HOST"`
+
+	// the most notifications one user gets within Window
+	Limit  int           `env:"LIMIT, default=10"`
+	Window time.Duration `env:"WINDOW, default=1h"`
+}
+
+// GithubConfig is for bridging issues to GitHub. Bridges keep a GitHub
+// access token each, sealed with TokenKey, and do not run without it.
+type GithubConfig struct {
+	TokenKey string `env:"TOKEN_KEY"`
+}
+
 type Cloudflare struct {
 	ApiToken string `env:"API_TOKEN"`
 	ZoneId   string `env:"ZONE_ID"`
@@ -90,19 +257,114 @@ func (cfg RedisConfig) ToURL() string {
 	return u.String()
 }
 
+type TrafficConfig struct {
+	// page views and clones of repos are counted for their owners unless
+	// the instance opts out
+	Enabled       bool          `env:"ENABLED, default=true"`
+	FlushInterval time.Duration `env:"FLUSH_INTERVAL, default=1m"`
+
+	// address to serve the counters on for prometheus, e.g. 127.0.0.1:9100,
+	// they are not served when empty
+	MetricsAddr string `env:"METRICS_ADDR"`
+}
+
+// ScanConfig points the appview at a virus scanner, for attachments and
+// release artifacts to be scanned before they can be downloaded. Uploads are
+// not scanned when neither scanner is set.
+type ScanConfig struct {
+	// clamd, e.g. tcp://127.0.0.1:3310 or unix:///run/clamav/clamd.ctl
+	ClamAVAddr string `env:"CLAMAV_ADDR"`
+
+	// ICAP service taking RESPMOD requests, e.g. icap://127.0.0.1:1344/avscan
+	ICAPUrl string `env:"ICAP_URL"`
+
+	Interval time.Duration `env:"INTERVAL, default=30s"`
+	Timeout  time.Duration `env:"TIMEOUT, default=1m"`
+}
+
+func (cfg ScanConfig) Enabled() bool {
+	return cfg.ClamAVAddr != "" || cfg.ICAPUrl != ""
+}
+
+// InstanceConfig brands a self-hosted appview, so that it does not pass for
+// tangled.sh. Left unset, the appview looks like tangled.sh.
+type InstanceConfig struct {
+	Name    string `env:"NAME, default=tangled"`
+	Tagline string `env:"TAGLINE, default=tightly-knit social coding"`
+
+	// url of an image shown in place of the name, e.g. /static/logo.svg
+	// from the theme directory
+	Logo string `env:"LOGO"`
+
+	// hex color of primary buttons, e.g. #7c3aed
+	AccentColor string `env:"ACCENT_COLOR"`
+
+	// label=url entries, listed in the footer in place of the links to
+	// tangled.sh when set
+	FooterLinks []string `env:"FOOTER_LINKS"`
+
+	// markdown file introducing the instance to logged out visitors on the
+	// front page, in place of the introduction to tangled
+	LandingPage string `env:"LANDING_PAGE"`
+}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Accent is the accent color, if it is set and valid
+func (cfg InstanceConfig) Accent() string {
+	if hexColor.MatchString(cfg.AccentColor) {
+		return cfg.AccentColor
+	}
+	return ""
+}
+
+type FooterLink struct {
+	Label string
+	Url   string
+}
+
+func (cfg InstanceConfig) Links() []FooterLink {
+	var links []FooterLink
+	for _, l := range cfg.FooterLinks {
+		label, u, ok := strings.Cut(l, "=")
+		if !ok || strings.TrimSpace(label) == "" {
+			continue
+		}
+		links = append(links, FooterLink{Label: strings.TrimSpace(label), Url: strings.TrimSpace(u)})
+	}
+	return links
+}
+
+// Custom tells whether the instance was renamed from tangled
+func (cfg InstanceConfig) Custom() bool {
+	return cfg.Name != "tangled"
+}
+
 type Config struct {
-	Core          CoreConfig      `env:",prefix=TANGLED_"`
-	Jetstream     JetstreamConfig `env:",prefix=TANGLED_JETSTREAM_"`
-	Knotstream    ConsumerConfig  `env:",prefix=TANGLED_KNOTSTREAM_"`
-	Spindlestream ConsumerConfig  `env:",prefix=TANGLED_SPINDLESTREAM_"`
-	Resend        ResendConfig    `env:",prefix=TANGLED_RESEND_"`
-	Posthog       PosthogConfig   `env:",prefix=TANGLED_POSTHOG_"`
-	Camo          CamoConfig      `env:",prefix=TANGLED_CAMO_"`
-	Avatar        AvatarConfig    `env:",prefix=TANGLED_AVATAR_"`
-	OAuth         OAuthConfig     `env:",prefix=TANGLED_OAUTH_"`
-	Redis         RedisConfig     `env:",prefix=TANGLED_REDIS_"`
-	Pds           PdsConfig       `env:",prefix=TANGLED_PDS_"`
-	Cloudflare    Cloudflare      `env:",prefix=TANGLED_CLOUDFLARE_"`
+	Core          CoreConfig         `env:",prefix=TANGLED_"`
+	Jetstream     JetstreamConfig    `env:",prefix=TANGLED_JETSTREAM_"`
+	Knotstream    ConsumerConfig     `env:",prefix=TANGLED_KNOTSTREAM_"`
+	Spindlestream ConsumerConfig     `env:",prefix=TANGLED_SPINDLESTREAM_"`
+	Resend        ResendConfig       `env:",prefix=TANGLED_RESEND_"`
+	Posthog       PosthogConfig      `env:",prefix=TANGLED_POSTHOG_"`
+	Camo          CamoConfig         `env:",prefix=TANGLED_CAMO_"`
+	Avatar        AvatarConfig       `env:",prefix=TANGLED_AVATAR_"`
+	OAuth         OAuthConfig        `env:",prefix=TANGLED_OAUTH_"`
+	Redis         RedisConfig        `env:",prefix=TANGLED_REDIS_"`
+	Pds           PdsConfig          `env:",prefix=TANGLED_PDS_"`
+	Cloudflare    Cloudflare         `env:",prefix=TANGLED_CLOUDFLARE_"`
+	Github        GithubConfig       `env:",prefix=TANGLED_GITHUB_"`
+	SshCa         SshCaConfig        `env:",prefix=TANGLED_SSH_CA_"`
+	Spam          SpamConfig         `env:",prefix=TANGLED_SPAM_"`
+	Challenge     ChallengeConfig    `env:",prefix=TANGLED_CHALLENGE_"`
+	Registration  RegistrationConfig `env:",prefix=TANGLED_REGISTRATION_"`
+	Feed          FeedConfig         `env:",prefix=TANGLED_FEED_"`
+	BskyNotify    BskyNotifyConfig   `env:",prefix=TANGLED_BSKY_NOTIFY_"`
+	Limits        LimitsConfig       `env:",prefix=TANGLED_LIMITS_"`
+	Traffic       TrafficConfig      `env:",prefix=TANGLED_TRAFFIC_"`
+	Instance      InstanceConfig     `env:",prefix=TANGLED_INSTANCE_"`
+	Storage       objectstore.Config `env:",prefix=TANGLED_STORAGE_"`
+	Scan          ScanConfig         `env:",prefix=TANGLED_SCAN_"`
 }
 
 func LoadConfig(ctx context.Context) (*Config, error) {
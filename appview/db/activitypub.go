@@ -0,0 +1,160 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// GetApKey returns the pem encoded private key of a local activitypub actor
+func GetApKey(e Execer, actor string) (string, error) {
+	var key string
+	err := e.QueryRow(`select private_key from ap_keys where actor = ?`, actor).Scan(&key)
+	return key, err
+}
+
+// AddApKey stores the key of an actor, unless it already has one
+func AddApKey(e Execer, actor, privateKey string) error {
+	_, err := e.Exec(
+		`insert or ignore into ap_keys (actor, private_key) values (?, ?)`,
+		actor,
+		privateKey,
+	)
+	return err
+}
+
+// ApFollower is a remote actor following a local user or repo
+type ApFollower struct {
+	Actor    string
+	Follower string
+	Inbox    string
+}
+
+func AddApFollower(e Execer, f ApFollower) error {
+	_, err := e.Exec(
+		`insert into ap_followers (actor, follower, inbox)
+		values (?, ?, ?)
+		on conflict(actor, follower) do update set inbox = excluded.inbox`,
+		f.Actor,
+		f.Follower,
+		f.Inbox,
+	)
+	return err
+}
+
+func DeleteApFollower(e Execer, actor, follower string) error {
+	_, err := e.Exec(
+		`delete from ap_followers where actor = ? and follower = ?`,
+		actor,
+		follower,
+	)
+	return err
+}
+
+// GetApInboxes returns the distinct inboxes that followers of the given
+// actors are reached at
+func GetApInboxes(e Execer, actors ...string) ([]string, error) {
+	if len(actors) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, len(actors))
+	for i, a := range actors {
+		args[i] = a
+	}
+
+	inClause := strings.TrimSuffix(strings.Repeat("?, ", len(actors)), ", ")
+	rows, err := e.Query(
+		`select distinct inbox from ap_followers where actor in (`+inClause+`)`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var inboxes []string
+	for rows.Next() {
+		var inbox string
+		if err := rows.Scan(&inbox); err != nil {
+			return nil, err
+		}
+		inboxes = append(inboxes, inbox)
+	}
+
+	return inboxes, rows.Err()
+}
+
+func CountApFollowers(e Execer, actor string) (int, error) {
+	var count int
+	err := e.QueryRow(`select count(1) from ap_followers where actor = ?`, actor).Scan(&count)
+	return count, err
+}
+
+// AddApActivity records an activity in the outbox of an actor
+func AddApActivity(e Execer, actor, activityId, activity string) error {
+	_, err := e.Exec(
+		`insert or ignore into ap_activities (actor, activity_id, activity) values (?, ?, ?)`,
+		actor,
+		activityId,
+		activity,
+	)
+	return err
+}
+
+// GetApActivities returns the latest activities of an actor, newest first
+func GetApActivities(e Execer, actor string, limit int) ([]string, error) {
+	rows, err := e.Query(
+		`select activity from ap_activities where actor = ? order by id desc limit ?`,
+		actor,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []string
+	for rows.Next() {
+		var a string
+		if err := rows.Scan(&a); err != nil {
+			return nil, err
+		}
+		activities = append(activities, a)
+	}
+
+	return activities, rows.Err()
+}
+
+func CountApActivities(e Execer, actor string) (int, error) {
+	var count int
+	err := e.QueryRow(`select count(1) from ap_activities where actor = ?`, actor).Scan(&count)
+	return count, err
+}
+
+// GetApActivity looks up an activity by id, whichever outbox it is in
+func GetApActivity(e Execer, activityId string) (string, error) {
+	var activity string
+	err := e.QueryRow(
+		`select activity from ap_activities where activity_id = ? limit 1`,
+		activityId,
+	).Scan(&activity)
+	return activity, err
+}
+
+// IsIssueImportSource reports whether something at the given url has already
+// been brought into a repo as an issue or comment
+func IsIssueImportSource(e Execer, repoAt syntax.ATURI, sourceUrl string) (bool, error) {
+	var exists bool
+	err := e.QueryRow(
+		`select exists (select 1 from issue_imports where repo_at = ? and source_url = ?)`,
+		repoAt,
+		sourceUrl,
+	).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return exists, err
+}
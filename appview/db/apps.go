@@ -0,0 +1,371 @@
+package db
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AppScope is a kind of access a third-party application asks users for
+type AppScope string
+
+const (
+	AppScopeRepoRead  AppScope = "repo:read"
+	AppScopeIssueRead AppScope = "issues:read"
+	AppScopeEmailRead AppScope = "email:read"
+)
+
+var AppScopes = []AppScope{AppScopeRepoRead, AppScopeIssueRead, AppScopeEmailRead}
+
+func (s AppScope) IsValid() bool {
+	return slices.Contains(AppScopes, s)
+}
+
+// Description is what the consent screen says the scope allows
+func (s AppScope) Description() string {
+	switch s {
+	case AppScopeRepoRead:
+		return "List your repositories"
+	case AppScopeIssueRead:
+		return "Read the issues you opened"
+	case AppScopeEmailRead:
+		return "See your verified email addresses"
+	}
+	return string(s)
+}
+
+func joinScopes(scopes []AppScope) string {
+	s := make([]string, len(scopes))
+	for i, scope := range scopes {
+		s[i] = string(scope)
+	}
+	return strings.Join(s, ",")
+}
+
+func splitScopes(s string) []AppScope {
+	var scopes []AppScope
+	for _, scope := range strings.Split(s, ",") {
+		if scope != "" {
+			scopes = append(scopes, AppScope(scope))
+		}
+	}
+	return scopes
+}
+
+// App is a third-party application registered by a user, which other users
+// may authorize to act on their behalf through OAuth
+type App struct {
+	Id           int64
+	ClientId     string
+	Name         string
+	Homepage     string
+	RedirectUris []string
+	OwnerDid     string
+	Created      time.Time
+}
+
+func (a App) HasRedirectUri(uri string) bool {
+	return slices.Contains(a.RedirectUris, uri)
+}
+
+// AddApp registers an app, only the hash of its client secret is kept
+func AddApp(e Execer, app *App, secretHash string) error {
+	res, err := e.Exec(
+		`insert into oauth_apps (client_id, name, homepage, redirect_uris, owner_did, secret_hash) values (?, ?, ?, ?, ?, ?)`,
+		app.ClientId,
+		app.Name,
+		app.Homepage,
+		strings.Join(app.RedirectUris, "\n"),
+		app.OwnerDid,
+		secretHash,
+	)
+	if err != nil {
+		return err
+	}
+
+	app.Id, err = res.LastInsertId()
+	return err
+}
+
+// DeleteApp removes an app of ownerDid, which revokes every token it was
+// given
+func DeleteApp(e Execer, ownerDid, clientId string) error {
+	_, err := e.Exec(`delete from oauth_apps where owner_did = ? and client_id = ?`, ownerDid, clientId)
+	return err
+}
+
+func GetApps(e Execer, filters ...filter) ([]App, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, client_id, name, homepage, redirect_uris, owner_did, created
+		from oauth_apps`+whereClause+`
+		order by name`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apps []App
+	for rows.Next() {
+		var a App
+		var redirectUris, created string
+		if err := rows.Scan(&a.Id, &a.ClientId, &a.Name, &a.Homepage, &redirectUris, &a.OwnerDid, &created); err != nil {
+			return nil, err
+		}
+
+		a.RedirectUris = strings.Split(redirectUris, "\n")
+
+		a.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			a.Created = time.Now()
+		}
+
+		apps = append(apps, a)
+	}
+
+	return apps, rows.Err()
+}
+
+func GetApp(e Execer, clientId string) (*App, error) {
+	apps, err := GetApps(e, FilterEq("client_id", clientId))
+	if err != nil {
+		return nil, err
+	}
+	if len(apps) != 1 {
+		return nil, sql.ErrNoRows
+	}
+	return &apps[0], nil
+}
+
+// CheckAppSecret tells whether secretHash is that of the client secret of an
+// app
+func CheckAppSecret(e Execer, clientId, secretHash string) (bool, error) {
+	var n int
+	err := e.QueryRow(
+		`select count(1) from oauth_apps where client_id = ? and secret_hash = ?`,
+		clientId,
+		secretHash,
+	).Scan(&n)
+	return n == 1, err
+}
+
+// AppCode is an authorization code, handed to an app through the redirect
+// once a user consents, and exchanged by the app for a token
+type AppCode struct {
+	ClientId    string
+	Did         string
+	Scopes      []AppScope
+	RedirectUri string
+	// the PKCE challenge, S256 of the verifier the app has to present
+	CodeChallenge string
+	Expires       time.Time
+}
+
+func AddAppCode(e Execer, code AppCode, codeHash string) error {
+	_, err := e.Exec(
+		`insert into oauth_app_codes (code_hash, client_id, did, scopes, redirect_uri, code_challenge, expires)
+		values (?, ?, ?, ?, ?, ?, ?)`,
+		codeHash,
+		code.ClientId,
+		code.Did,
+		joinScopes(code.Scopes),
+		code.RedirectUri,
+		code.CodeChallenge,
+		code.Expires.UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+// TakeAppCode fetches an authorization code and deletes it, so that it is
+// only ever exchanged once. Expired codes are not returned.
+func TakeAppCode(e Execer, codeHash string) (*AppCode, error) {
+	var code AppCode
+	var scopes, expires string
+	err := e.QueryRow(
+		`delete from oauth_app_codes where code_hash = ?
+		returning client_id, did, scopes, redirect_uri, code_challenge, expires`,
+		codeHash,
+	).Scan(&code.ClientId, &code.Did, &scopes, &code.RedirectUri, &code.CodeChallenge, &expires)
+	if err != nil {
+		return nil, err
+	}
+
+	code.Scopes = splitScopes(scopes)
+	code.Expires, err = time.Parse(time.RFC3339, expires)
+	if err != nil || time.Now().After(code.Expires) {
+		return nil, sql.ErrNoRows
+	}
+
+	return &code, nil
+}
+
+// AppToken is an access token given to an app by a user
+type AppToken struct {
+	Id       int64
+	ClientId string
+	Did      string
+	Scopes   []AppScope
+	Created  time.Time
+	LastUsed *time.Time
+}
+
+func (t AppToken) Can(scope AppScope) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// AddAppToken records a token handed to an app, only its hash is kept
+func AddAppToken(e Execer, token *AppToken, tokenHash string) error {
+	res, err := e.Exec(
+		`insert into oauth_app_tokens (client_id, did, scopes, token_hash) values (?, ?, ?, ?)`,
+		token.ClientId,
+		token.Did,
+		joinScopes(token.Scopes),
+		tokenHash,
+	)
+	if err != nil {
+		return err
+	}
+
+	token.Id, err = res.LastInsertId()
+	return err
+}
+
+// SetAppTokenUsed records that an app made a request with a token just now
+func SetAppTokenUsed(e Execer, id int64) error {
+	_, err := e.Exec(
+		`update oauth_app_tokens set last_used = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') where id = ?`,
+		id,
+	)
+	return err
+}
+
+func GetAppTokens(e Execer, filters ...filter) ([]AppToken, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, client_id, did, scopes, created, last_used
+		from oauth_app_tokens`+whereClause+`
+		order by created desc`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []AppToken
+	for rows.Next() {
+		var t AppToken
+		var scopes, created string
+		var lastUsed sql.NullString
+		if err := rows.Scan(&t.Id, &t.ClientId, &t.Did, &scopes, &created, &lastUsed); err != nil {
+			return nil, err
+		}
+
+		t.Scopes = splitScopes(scopes)
+
+		t.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			t.Created = time.Now()
+		}
+
+		if lastUsed.Valid {
+			if lu, err := time.Parse(time.RFC3339, lastUsed.String); err == nil {
+				t.LastUsed = &lu
+			}
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+// GetAppTokenByHash finds the token an app presented
+func GetAppTokenByHash(e Execer, tokenHash string) (*AppToken, error) {
+	tokens, err := GetAppTokens(e, FilterEq("token_hash", tokenHash))
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) != 1 {
+		return nil, sql.ErrNoRows
+	}
+	return &tokens[0], nil
+}
+
+// AuthorizedApp is an app a user gave access to, with everything the tokens
+// it holds allow
+type AuthorizedApp struct {
+	App
+	Scopes     []AppScope
+	Authorized time.Time
+	LastUsed   *time.Time
+}
+
+// GetAuthorizedApps lists the apps holding tokens of did
+func GetAuthorizedApps(e Execer, did string) ([]AuthorizedApp, error) {
+	tokens, err := GetAppTokens(e, FilterEq("did", did))
+	if err != nil {
+		return nil, err
+	}
+
+	var authorized []AuthorizedApp
+	byClient := make(map[string]int)
+	for _, t := range tokens {
+		i, ok := byClient[t.ClientId]
+		if !ok {
+			app, err := GetApp(e, t.ClientId)
+			if err != nil {
+				return nil, err
+			}
+			byClient[t.ClientId] = len(authorized)
+			authorized = append(authorized, AuthorizedApp{App: *app, Authorized: t.Created})
+			i = len(authorized) - 1
+		}
+
+		a := &authorized[i]
+		for _, s := range t.Scopes {
+			if !slices.Contains(a.Scopes, s) {
+				a.Scopes = append(a.Scopes, s)
+			}
+		}
+		if t.Created.Before(a.Authorized) {
+			a.Authorized = t.Created
+		}
+		if t.LastUsed != nil && (a.LastUsed == nil || t.LastUsed.After(*a.LastUsed)) {
+			a.LastUsed = t.LastUsed
+		}
+	}
+
+	return authorized, nil
+}
+
+// RevokeApp deletes every token did gave to an app
+func RevokeApp(e Execer, did, clientId string) error {
+	_, err := e.Exec(`delete from oauth_app_tokens where did = ? and client_id = ?`, did, clientId)
+	return err
+}
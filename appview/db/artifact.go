@@ -54,7 +54,11 @@ func AddArtifact(e Execer, artifact Artifact) error {
 		artifact.Size,
 		artifact.MimeType,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return AddBlobScan(e, artifact.Did, artifact.BlobCid)
 }
 
 func GetArtifact(e Execer, filters ...filter) ([]Artifact, error) {
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/ipfs/go-cid"
+)
+
+// Attachment is a file uploaded to the PDS of a user to be linked from the
+// issues and pulls of a repo
+type Attachment struct {
+	Did       string
+	Rkey      string
+	RepoAt    syntax.ATURI
+	BlobCid   cid.Cid
+	Name      string
+	Size      int64
+	MimeType  string
+	CreatedAt time.Time
+}
+
+func AddAttachment(e Execer, attachment Attachment) error {
+	_, err := e.Exec(
+		`insert or ignore into attachments (did, rkey, repo_at, blob_cid, name, size, mimetype, created)
+		values (?, ?, ?, ?, ?, ?, ?, ?)`,
+		attachment.Did,
+		attachment.Rkey,
+		attachment.RepoAt,
+		attachment.BlobCid.String(),
+		attachment.Name,
+		attachment.Size,
+		attachment.MimeType,
+		attachment.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	return AddBlobScan(e, attachment.Did, attachment.BlobCid)
+}
+
+func DeleteAttachmentByRkey(e Execer, did, rkey string) error {
+	_, err := e.Exec(`delete from attachments where did = ? and rkey = ?`, did, rkey)
+	return err
+}
+
+func GetAttachments(e Execer, filters ...filter) ([]Attachment, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select did, rkey, repo_at, blob_cid, name, size, mimetype, created
+		from attachments`+whereClause,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []Attachment
+	for rows.Next() {
+		var a Attachment
+		var blobCid, created string
+		if err := rows.Scan(&a.Did, &a.Rkey, &a.RepoAt, &blobCid, &a.Name, &a.Size, &a.MimeType, &created); err != nil {
+			return nil, err
+		}
+
+		a.BlobCid, err = cid.Parse(blobCid)
+		if err != nil {
+			return nil, err
+		}
+
+		a.CreatedAt, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			a.CreatedAt = time.Now()
+		}
+
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
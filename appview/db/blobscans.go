@@ -0,0 +1,164 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+const (
+	BlobScanPending  = "pending"
+	BlobScanClean    = "clean"
+	BlobScanInfected = "infected"
+
+	// given up on, after the blob could not be fetched or scanned a few times
+	BlobScanFailed = "failed"
+)
+
+// BlobScan is the verdict of the virus scanner on a blob of an attachment or
+// artifact. Blobs are content addressed, so that the same file uploaded
+// twice, by anyone, is scanned once.
+type BlobScan struct {
+	Cid cid.Cid
+	// an account whose PDS has the blob
+	Did       string
+	Status    string
+	Threat    string
+	Attempts  int
+	CreatedAt time.Time
+	ScannedAt *time.Time
+}
+
+// AddBlobScan queues a blob for scanning, unless it is known already
+func AddBlobScan(e Execer, did string, blobCid cid.Cid) error {
+	_, err := e.Exec(
+		`insert or ignore into blob_scans (cid, did) values (?, ?)`,
+		blobCid.String(),
+		did,
+	)
+	return err
+}
+
+// GetBlobScan returns sql.ErrNoRows for blobs that were never queued
+func GetBlobScan(e Execer, blobCid cid.Cid) (*BlobScan, error) {
+	scans, err := GetBlobScans(e, 1, FilterEq("cid", blobCid.String()))
+	if err != nil {
+		return nil, err
+	}
+	if len(scans) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &scans[0], nil
+}
+
+// GetBlobScans returns up to limit scans, the oldest first
+func GetBlobScans(e Execer, limit int, filters ...filter) ([]BlobScan, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+	args = append(args, limit)
+
+	rows, err := e.Query(
+		`select cid, did, status, threat, attempts, created, scanned
+		from blob_scans`+whereClause+`
+		order by created asc
+		limit ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []BlobScan
+	for rows.Next() {
+		var s BlobScan
+		var blobCid, created string
+		var scanned sql.NullString
+		if err := rows.Scan(&blobCid, &s.Did, &s.Status, &s.Threat, &s.Attempts, &created, &scanned); err != nil {
+			return nil, err
+		}
+
+		s.Cid, err = cid.Parse(blobCid)
+		if err != nil {
+			return nil, err
+		}
+
+		s.CreatedAt, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			s.CreatedAt = time.Now()
+		}
+		if scanned.Valid {
+			if t, err := time.Parse(time.RFC3339, scanned.String); err == nil {
+				s.ScannedAt = &t
+			}
+		}
+
+		scans = append(scans, s)
+	}
+
+	return scans, rows.Err()
+}
+
+// SetBlobScanResult records the verdict on a blob
+func SetBlobScanResult(e Execer, blobCid cid.Cid, status, threat string) error {
+	_, err := e.Exec(
+		`update blob_scans
+		set status = ?, threat = ?, attempts = attempts + 1, scanned = ?
+		where cid = ?`,
+		status,
+		threat,
+		time.Now().UTC().Format(time.RFC3339),
+		blobCid.String(),
+	)
+	return err
+}
+
+// AddBlobScanAttempt counts a failed attempt at scanning a blob, which stays
+// pending until it has failed maxAttempts times
+func AddBlobScanAttempt(e Execer, blobCid cid.Cid, maxAttempts int) error {
+	_, err := e.Exec(
+		`update blob_scans
+		set attempts = attempts + 1,
+			status = case when attempts + 1 >= ? then ? else status end
+		where cid = ?`,
+		maxAttempts,
+		BlobScanFailed,
+		blobCid.String(),
+	)
+	return err
+}
+
+// RescanBlob queues a blob to be scanned again
+func RescanBlob(e Execer, blobCid cid.Cid) error {
+	_, err := e.Exec(
+		`update blob_scans set status = ?, threat = '', attempts = 0 where cid = ?`,
+		BlobScanPending,
+		blobCid.String(),
+	)
+	return err
+}
+
+// CountBlobReferences counts the attachments and artifacts of a blob, for
+// copies of it to be removed only once nothing links to it anymore
+func CountBlobReferences(e Execer, blobCid cid.Cid) (int, error) {
+	var count int
+	err := e.QueryRow(
+		`select
+			(select count(*) from attachments where blob_cid = ?) +
+			(select count(*) from artifacts where blob_cid = ?)`,
+		blobCid.String(),
+		blobCid.String(),
+	).Scan(&count)
+	return count, err
+}
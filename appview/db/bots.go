@@ -0,0 +1,157 @@
+package db
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// BotScope is something a bot may do with its token
+type BotScope string
+
+const (
+	BotScopePullComment  BotScope = "pulls:comment"
+	BotScopeIssueComment BotScope = "issues:comment"
+	BotScopeStatus       BotScope = "statuses:write"
+)
+
+var BotScopes = []BotScope{BotScopePullComment, BotScopeIssueComment, BotScopeStatus}
+
+func (s BotScope) IsValid() bool {
+	return slices.Contains(BotScopes, s)
+}
+
+// BotPrefix marks the author of a comment as a bot, it stands in for the did
+// of a user
+const BotPrefix = "bot:"
+
+// Bot is a service account of a repo, for CI and other automation. It has an
+// API token instead of an identity, so it never logs in on the web, and may
+// only do what its scopes allow on its own repo.
+type Bot struct {
+	Id        int64
+	RepoAt    syntax.ATURI
+	Name      string
+	CreatedBy string
+	Scopes    []BotScope
+	Created   time.Time
+	LastUsed  *time.Time
+}
+
+// Ident is what the bot's comments are attributed to
+func (b Bot) Ident() string {
+	return BotPrefix + b.Name
+}
+
+func (b Bot) Can(scope BotScope) bool {
+	return slices.Contains(b.Scopes, scope)
+}
+
+// AddBot adds a bot to its repo, only the hash of its token is kept
+func AddBot(e Execer, bot *Bot, tokenHash string) error {
+	scopes := make([]string, len(bot.Scopes))
+	for i, s := range bot.Scopes {
+		scopes[i] = string(s)
+	}
+
+	res, err := e.Exec(
+		`insert into repo_bots (repo_at, name, created_by, scopes, token_hash) values (?, ?, ?, ?, ?)`,
+		bot.RepoAt,
+		bot.Name,
+		bot.CreatedBy,
+		strings.Join(scopes, ","),
+		tokenHash,
+	)
+	if err != nil {
+		return err
+	}
+
+	bot.Id, err = res.LastInsertId()
+	return err
+}
+
+// DeleteBot removes a bot, which revokes its token
+func DeleteBot(e Execer, repoAt syntax.ATURI, id int64) error {
+	_, err := e.Exec(`delete from repo_bots where repo_at = ? and id = ?`, repoAt, id)
+	return err
+}
+
+// SetBotUsed records that a bot made a request just now
+func SetBotUsed(e Execer, id int64) error {
+	_, err := e.Exec(
+		`update repo_bots set last_used = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') where id = ?`,
+		id,
+	)
+	return err
+}
+
+func GetBots(e Execer, filters ...filter) ([]Bot, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, repo_at, name, created_by, scopes, created, last_used
+		from repo_bots`+whereClause+`
+		order by name`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bots []Bot
+	for rows.Next() {
+		var b Bot
+		var scopes, created string
+		var lastUsed sql.NullString
+		if err := rows.Scan(&b.Id, &b.RepoAt, &b.Name, &b.CreatedBy, &scopes, &created, &lastUsed); err != nil {
+			return nil, err
+		}
+
+		for _, s := range strings.Split(scopes, ",") {
+			if s != "" {
+				b.Scopes = append(b.Scopes, BotScope(s))
+			}
+		}
+
+		b.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			b.Created = time.Now()
+		}
+
+		if lastUsed.Valid {
+			if t, err := time.Parse(time.RFC3339, lastUsed.String); err == nil {
+				b.LastUsed = &t
+			}
+		}
+
+		bots = append(bots, b)
+	}
+
+	return bots, rows.Err()
+}
+
+// GetBotByToken finds the bot a token belongs to
+func GetBotByToken(e Execer, tokenHash string) (*Bot, error) {
+	bots, err := GetBots(e, FilterEq("token_hash", tokenHash))
+	if err != nil {
+		return nil, err
+	}
+	if len(bots) != 1 {
+		return nil, sql.ErrNoRows
+	}
+	return &bots[0], nil
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// GithubBridge keeps the issues of a repo in sync with a repo on GitHub
+type GithubBridge struct {
+	RepoAt     syntax.ATURI
+	GithubRepo string
+	// sealed with github.SealToken
+	Token string
+
+	SyncedAt *time.Time
+	Created  time.Time
+}
+
+const githubBridgeColumns = `repo_at, github_repo, token, synced_at, created`
+
+func scanGithubBridge(row interface{ Scan(...any) error }) (*GithubBridge, error) {
+	var b GithubBridge
+	var syncedAt sql.NullString
+	var created string
+
+	if err := row.Scan(&b.RepoAt, &b.GithubRepo, &b.Token, &syncedAt, &created); err != nil {
+		return nil, err
+	}
+
+	var err error
+	b.Created, err = time.Parse(time.RFC3339, created)
+	if err != nil {
+		b.Created = time.Now()
+	}
+
+	if syncedAt.Valid {
+		if t, err := time.Parse(time.RFC3339, syncedAt.String); err == nil {
+			b.SyncedAt = &t
+		}
+	}
+
+	return &b, nil
+}
+
+func GetGithubBridge(e Execer, repoAt syntax.ATURI) (*GithubBridge, error) {
+	return scanGithubBridge(e.QueryRow(
+		`select `+githubBridgeColumns+` from github_bridges where repo_at = ?`,
+		repoAt,
+	))
+}
+
+func GetGithubBridges(e Execer) ([]GithubBridge, error) {
+	rows, err := e.Query(`select ` + githubBridgeColumns + ` from github_bridges`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bridges []GithubBridge
+	for rows.Next() {
+		b, err := scanGithubBridge(rows)
+		if err != nil {
+			return nil, err
+		}
+		bridges = append(bridges, *b)
+	}
+
+	return bridges, rows.Err()
+}
+
+// SetGithubBridge sets up a bridge, or points it at another repo. An empty
+// token keeps the existing one.
+func SetGithubBridge(e Execer, b GithubBridge) error {
+	_, err := e.Exec(
+		`insert into github_bridges (repo_at, github_repo, token)
+		values (?, ?, ?)
+		on conflict(repo_at) do update set
+			github_repo = excluded.github_repo,
+			token = case when excluded.token = '' then token else excluded.token end,
+			synced_at = case when excluded.github_repo = github_repo then synced_at else null end`,
+		b.RepoAt,
+		b.GithubRepo,
+		b.Token,
+	)
+	return err
+}
+
+// SetGithubBridgeToken replaces the token of a bridge as is
+func SetGithubBridgeToken(e Execer, repoAt syntax.ATURI, token string) error {
+	_, err := e.Exec(`update github_bridges set token = ? where repo_at = ?`, token, repoAt)
+	return err
+}
+
+func DeleteGithubBridge(e Execer, repoAt syntax.ATURI) error {
+	_, err := e.Exec(`delete from github_bridges where repo_at = ?`, repoAt)
+	return err
+}
+
+func MarkGithubBridgeSynced(e Execer, repoAt syntax.ATURI, at time.Time) error {
+	_, err := e.Exec(
+		`update github_bridges set synced_at = ? where repo_at = ?`,
+		at.UTC().Format(time.RFC3339),
+		repoAt,
+	)
+	return err
+}
+
+func AddGithubBridgeItem(e Execer, repoAt syntax.ATURI, issueId, commentId int, githubUrl string) error {
+	_, err := e.Exec(
+		`insert into github_bridge_items (repo_at, issue_id, comment_id, github_url)
+		values (?, ?, ?, ?)`,
+		repoAt,
+		issueId,
+		commentId,
+		githubUrl,
+	)
+	return err
+}
+
+// GetIssueGithubUrl returns the url of the github issue that an issue is
+// linked to, in either direction
+func GetIssueGithubUrl(e Execer, repoAt syntax.ATURI, issueId int) (string, error) {
+	var url string
+	err := e.QueryRow(
+		`select github_url from github_bridge_items
+		where repo_at = ? and issue_id = ? and comment_id = 0
+		union all
+		select source_url from issue_imports
+		where repo_at = ? and issue_id = ? and comment_id = 0
+		limit 1`,
+		repoAt, issueId,
+		repoAt, issueId,
+	).Scan(&url)
+	return url, err
+}
+
+// GetIssueIdByGithubUrl is the reverse of GetIssueGithubUrl
+func GetIssueIdByGithubUrl(e Execer, repoAt syntax.ATURI, githubUrl string) (int, error) {
+	var issueId int
+	err := e.QueryRow(
+		`select issue_id from github_bridge_items
+		where repo_at = ? and github_url = ? and comment_id = 0
+		union all
+		select issue_id from issue_imports
+		where repo_at = ? and source_url = ? and comment_id = 0
+		limit 1`,
+		repoAt, githubUrl,
+		repoAt, githubUrl,
+	).Scan(&issueId)
+	return issueId, err
+}
+
+// IsGithubUrlLinked reports whether an issue or comment on github has
+// already been brought over, or was itself created by the bridge
+func IsGithubUrlLinked(e Execer, repoAt syntax.ATURI, githubUrl string) (bool, error) {
+	var exists bool
+	err := e.QueryRow(
+		`select exists (select 1 from github_bridge_items where repo_at = ? and github_url = ?)
+		or exists (select 1 from issue_imports where repo_at = ? and source_url = ?)`,
+		repoAt, githubUrl,
+		repoAt, githubUrl,
+	).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return exists, err
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+type CommitState string
+
+const (
+	CommitStatePending CommitState = "pending"
+	CommitStateSuccess CommitState = "success"
+	CommitStateFailure CommitState = "failure"
+	CommitStateError   CommitState = "error"
+)
+
+func (s CommitState) IsValid() bool {
+	switch s {
+	case CommitStatePending, CommitStateSuccess, CommitStateFailure, CommitStateError:
+		return true
+	}
+	return false
+}
+
+// CommitStatus is the outcome of an external check on a commit, posted by a
+// bot of the repo. There is one per context, later posts replace it.
+type CommitStatus struct {
+	Id          int64
+	RepoAt      syntax.ATURI
+	Sha         string
+	Context     string
+	State       CommitState
+	Description string
+	TargetUrl   string
+	BotId       int64
+	BotName     string
+	Created     time.Time
+}
+
+func SetCommitStatus(e Execer, s CommitStatus) error {
+	_, err := e.Exec(
+		`insert into commit_statuses (repo_at, sha, context, state, description, target_url, bot_id)
+		values (?, ?, ?, ?, ?, ?, ?)
+		on conflict(repo_at, sha, context) do update set
+			state = excluded.state,
+			description = excluded.description,
+			target_url = excluded.target_url,
+			bot_id = excluded.bot_id,
+			created = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')`,
+		s.RepoAt,
+		s.Sha,
+		s.Context,
+		s.State,
+		s.Description,
+		s.TargetUrl,
+		s.BotId,
+	)
+	return err
+}
+
+// GetCommitStatuses returns the statuses of the commits of a repo, by sha
+func GetCommitStatuses(e Execer, repoAt syntax.ATURI, shas []string) (map[string][]CommitStatus, error) {
+	statuses := make(map[string][]CommitStatus)
+	if len(shas) == 0 {
+		return statuses, nil
+	}
+
+	filters := []filter{FilterEq("s.repo_at", repoAt), FilterIn("s.sha", shas)}
+
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	rows, err := e.Query(
+		`select s.id, s.repo_at, s.sha, s.context, s.state, s.description, s.target_url, s.bot_id, b.name, s.created
+		from commit_statuses s
+		join repo_bots b on b.id = s.bot_id
+		where `+strings.Join(conditions, " and ")+`
+		order by s.context`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s CommitStatus
+		var created string
+		if err := rows.Scan(&s.Id, &s.RepoAt, &s.Sha, &s.Context, &s.State, &s.Description, &s.TargetUrl, &s.BotId, &s.BotName, &created); err != nil {
+			return nil, err
+		}
+
+		s.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			s.Created = time.Now()
+		}
+
+		statuses[s.Sha] = append(statuses[s.Sha], s)
+	}
+
+	return statuses, rows.Err()
+}
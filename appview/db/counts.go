@@ -0,0 +1,103 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// RebuildRepoCounts recounts the stars, issues and pulls of every repo. The
+// triggers keep the counts right, this is for when they drift all the same,
+// say after the tables were edited by hand.
+func RebuildRepoCounts(e Execer) error {
+	_, err := e.Exec(`
+		delete from repo_counts;
+
+		insert into repo_counts (repo) select at_uri from repos;
+
+		update repo_counts set
+			stars = (select count(1) from stars where repo_at = repo),
+			issues_open = (select count(1) from issues where repo_at = repo and open = 1 and hidden is null),
+			issues_closed = (select count(1) from issues where repo_at = repo and open = 0 and hidden is null),
+			pulls_closed = (select count(1) from pulls where repo_at = repo and state = 0),
+			pulls_open = (select count(1) from pulls where repo_at = repo and state = 1),
+			pulls_merged = (select count(1) from pulls where repo_at = repo and state = 2),
+			pulls_deleted = (select count(1) from pulls where repo_at = repo and state = 3);
+	`)
+	return err
+}
+
+// GetRepoStats fills in the star, issue and pull counts of the given repos
+func GetRepoStats(e Execer, repoAts []syntax.ATURI) (map[syntax.ATURI]RepoStats, error) {
+	stats := make(map[syntax.ATURI]RepoStats)
+	if len(repoAts) == 0 {
+		return stats, nil
+	}
+
+	inClause := strings.TrimSuffix(strings.Repeat("?, ", len(repoAts)), ", ")
+	args := make([]any, len(repoAts))
+	for i, r := range repoAts {
+		args[i] = r
+	}
+
+	rows, err := e.Query(
+		fmt.Sprintf(
+			`select repo, stars, issues_open, issues_closed, pulls_open, pulls_merged, pulls_closed, pulls_deleted
+			from repo_counts
+			where repo in (%s)`,
+			inClause,
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var repoAt syntax.ATURI
+		var s RepoStats
+		err := rows.Scan(
+			&repoAt,
+			&s.StarCount,
+			&s.IssueCount.Open,
+			&s.IssueCount.Closed,
+			&s.PullCount.Open,
+			&s.PullCount.Merged,
+			&s.PullCount.Closed,
+			&s.PullCount.Deleted,
+		)
+		if err != nil {
+			return nil, err
+		}
+		stats[repoAt] = s
+	}
+
+	return stats, rows.Err()
+}
+
+func getRepoStats(e Execer, repoAt syntax.ATURI) (RepoStats, error) {
+	var s RepoStats
+	err := e.QueryRow(
+		`select stars, issues_open, issues_closed, pulls_open, pulls_merged, pulls_closed, pulls_deleted
+		from repo_counts
+		where repo = ?`,
+		repoAt,
+	).Scan(
+		&s.StarCount,
+		&s.IssueCount.Open,
+		&s.IssueCount.Closed,
+		&s.PullCount.Open,
+		&s.PullCount.Merged,
+		&s.PullCount.Closed,
+		&s.PullCount.Deleted,
+	)
+	// nothing counted yet
+	if errors.Is(err, sql.ErrNoRows) {
+		return RepoStats{}, nil
+	}
+	return s, err
+}
@@ -8,11 +8,14 @@ import (
 	"reflect"
 	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 type DB struct {
 	*sql.DB
+
+	// Queries times every query run against the database
+	Queries *QueryLog
 }
 
 type Execer interface {
@@ -35,10 +38,12 @@ func Make(dbPath string) (*DB, error) {
 		"_auto_vacuum=incremental",
 	}
 
-	db, err := sql.Open("sqlite3", dbPath+"?"+strings.Join(opts, "&"))
-	if err != nil {
-		return nil, err
-	}
+	queries := NewQueryLog(DefaultSlowQueryThreshold)
+	db := sql.OpenDB(&connector{
+		dsn:    dbPath + "?" + strings.Join(opts, "&"),
+		driver: &sqlite3.SQLiteDriver{},
+		log:    queries,
+	})
 
 	ctx := context.Background()
 
@@ -466,6 +471,394 @@ func Make(dbPath string) (*DB, error) {
 			primary key (did, rkey)
 		);
 
+		create table if not exists domains (
+			-- identifiers
+			id integer primary key autoincrement,
+			did text not null,
+			domain text not null unique,
+
+			-- null when the domain points at the profile of did
+			repo_at text,
+
+			-- ownership proof, published as a TXT record
+			token text not null,
+			verified text,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		create table if not exists repo_sites (
+			repo_at text primary key,
+
+			-- where the site is published from
+			branch text not null,
+			dir text not null default '',
+
+			-- commit that is currently being served, bumped on every push to branch
+			deployed_commit text,
+			deployed_at text,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		create table if not exists repo_integrations (
+			id integer primary key autoincrement,
+			repo_at text not null,
+			kind text not null check (kind in ('discord', 'slack', 'matrix', 'irc', 'xmpp')),
+
+			-- webhook url for discord and slack, homeserver url for matrix,
+			-- server url for irc and the bot's jid for xmpp
+			url text not null,
+			room text not null default '',
+			token text not null default '',
+
+			-- comma separated list of events to post about
+			events text not null default '',
+			-- json object of event name to template overriding the default
+			templates text not null default '{}',
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		create table if not exists repo_transfers (
+			repo_at text primary key,
+			from_did text not null,
+			to_did text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		-- old did/name of transferred and renamed repos, so that links to them
+		-- keep working
+		create table if not exists repo_redirects (
+			did text not null,
+			name text not null,
+			repo_at text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			primary key (did, name),
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		-- handles accounts used to have, for the same reason
+		create table if not exists handle_redirects (
+			handle text primary key,
+			did text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+
+		create table if not exists takeouts (
+			id integer primary key autoincrement,
+			did text not null,
+			-- 'user', or the at-uri of the exported repo
+			subject text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+
+		-- page views, unique visitors and clones of a repo per day, see
+		-- appview/traffic
+		create table if not exists repo_traffic (
+			repo_at text not null,
+			day text not null, -- YYYY-MM-DD, in UTC
+			views integer not null default 0,
+			visitors integer not null default 0,
+			clones integer not null default 0,
+			primary key (repo_at, day)
+		);
+
+		-- branches pushed lately, offered to whoever pushed them for a pull
+		-- request
+		create table if not exists recent_pushes (
+			did text not null,
+			repo_at text not null,
+			branch text not null,
+			pushed text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			primary key (did, repo_at, branch)
+		);
+
+		create table if not exists gists (
+			-- identifiers
+			id integer primary key autoincrement,
+			did text not null,
+			gist_id text not null unique,
+
+			-- meta
+			description text not null default '',
+			visibility text not null default 'public' check (visibility in ('public', 'unlisted', 'secret')),
+			forked_from text,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			edited text,
+
+			foreign key (forked_from) references gists(gist_id) on delete set null
+		);
+
+		create table if not exists gist_files (
+			gist_id text not null,
+			filename text not null,
+			content text not null,
+
+			primary key (gist_id, filename),
+			foreign key (gist_id) references gists(gist_id) on delete cascade
+		);
+
+		create table if not exists share_settings (
+			did text primary key,
+
+			-- whether "announce on bluesky" is checked by default
+			announce_repos integer not null default 0,
+			announce_releases integer not null default 0
+		);
+
+		create table if not exists signing_keys (
+			did text not null,
+			rkey text not null,
+			name text not null,
+			kind text not null check (kind in ('ssh', 'gpg')),
+			key text not null,
+			-- whether a sh.tangled.signingKey record exists for this key
+			published integer not null default 0,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			primary key (did, rkey)
+		);
+
+		create table if not exists notification_settings (
+			did text primary key,
+
+			-- whether notifications are sent to the verified primary email
+			email integer not null default 0
+		);
+
+		create table if not exists issue_imports (
+			repo_at text not null,
+			issue_id integer not null,
+			-- 0 for the issue itself
+			comment_id integer not null default 0,
+
+			-- where the issue or comment was imported from
+			source_url text not null,
+			-- login of the original author on the source forge
+			author text not null,
+			-- whether the author was mapped to a did, the issue or comment is
+			-- then owned by that did instead of the importer
+			mapped integer not null default 0,
+
+			primary key (repo_at, issue_id, comment_id),
+			unique (repo_at, source_url),
+			foreign key (repo_at, issue_id) references issues(repo_at, issue_id) on delete cascade
+		);
+
+		create table if not exists github_bridges (
+			repo_at text primary key,
+			-- owner/name of the repo on github
+			github_repo text not null,
+			token text not null,
+
+			-- issues and comments updated after this were not brought over yet
+			synced_at text,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		-- issues and comments that were sent over to github, the ones that came
+		-- from github are tracked in issue_imports
+		create table if not exists github_bridge_items (
+			repo_at text not null,
+			issue_id integer not null,
+			-- 0 for the issue itself
+			comment_id integer not null default 0,
+			github_url text not null,
+
+			primary key (repo_at, issue_id, comment_id),
+			unique (repo_at, github_url),
+			foreign key (repo_at, issue_id) references issues(repo_at, issue_id) on delete cascade
+		);
+
+		create table if not exists labels (
+			id integer primary key autoincrement,
+			owner_did text not null,
+			rkey text not null,
+			repo_at text not null,
+			-- at-uri of the labelled issue or pull
+			subject_at text not null,
+			name text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			unique (owner_did, rkey),
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		-- files uploaded to a pds and linked from issues and pulls
+		create table if not exists attachments (
+			did text not null,
+			rkey text not null,
+			repo_at text not null,
+			blob_cid text not null,
+			name text not null,
+			size integer not null default 0,
+			mimetype text not null default '*/*',
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			primary key (did, rkey),
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		-- previous versions of edited issues
+		create table if not exists issue_edits (
+			id integer primary key autoincrement,
+			repo_at text not null,
+			issue_id integer not null,
+			editor_did text not null,
+			-- title and body as they were before this edit
+			title text not null,
+			body text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			foreign key (repo_at, issue_id) references issues(repo_at, issue_id) on delete cascade
+		);
+
+		-- state changes of issues and pulls, shown in their threads
+		create table if not exists thread_events (
+			id integer primary key autoincrement,
+			repo_at text not null,
+			-- at-uri of the issue or pull
+			subject_at text not null,
+			actor_did text not null,
+			kind text not null,
+			-- label name, new title etc. depending on kind
+			value text not null default '',
+			-- at-uri of the record that caused this event, if any, so that
+			-- records seen both at write time and on jetstream are counted once
+			source_at text unique,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+
+		create table if not exists pull_reviews (
+			id integer primary key autoincrement,
+			owner_did text not null,
+			rkey text not null,
+			repo_at text not null,
+			pull_id integer not null,
+			-- round of the pull that was reviewed, null if not given
+			round integer,
+			state text not null,
+			body text not null default '',
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			unique (owner_did, rkey),
+			foreign key (repo_at, pull_id) references pulls(repo_at, pull_id) on delete cascade
+		);
+
+		-- signing keys of activitypub actors, keyed by did for users and by
+		-- at-uri for repos
+		create table if not exists ap_keys (
+			actor text primary key,
+			private_key text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+
+		create table if not exists ap_followers (
+			actor text not null,
+			-- id of the remote actor
+			follower text not null,
+			-- shared inbox of the remote server where there is one
+			inbox text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			primary key (actor, follower)
+		);
+
+		-- activities sent out by local actors, served from their outboxes
+		create table if not exists ap_activities (
+			id integer primary key autoincrement,
+			actor text not null,
+			activity_id text not null,
+			activity text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			unique (actor, activity_id)
+		);
+
+		-- project boards of a repo, made of columns of issues and pulls
+		create table if not exists projects (
+			id integer primary key autoincrement,
+			repo_at text not null,
+			name text not null,
+			description text not null default '',
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			unique (repo_at, name),
+			foreign key (repo_at) references repos(at_uri) on delete cascade
+		);
+		create table if not exists project_columns (
+			id integer primary key autoincrement,
+			project_id integer not null,
+			name text not null,
+			position integer not null default 0,
+			-- kind of thread event that moves cards here, if any
+			automation text not null default '',
+
+			foreign key (project_id) references projects(id) on delete cascade
+		);
+		create table if not exists project_cards (
+			id integer primary key autoincrement,
+			project_id integer not null,
+			column_id integer not null,
+			-- at-uri of the issue or pull
+			subject_at text not null,
+			position integer not null default 0,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+			unique (project_id, subject_at),
+			foreign key (project_id) references projects(id) on delete cascade,
+			foreign key (column_id) references project_columns(id) on delete cascade
+		);
+
+		-- accounts allowed to sign in, see RegistrationConfig
+		create table if not exists members (
+			did text primary key,
+			-- invite used to join, if any
+			invite_code text,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+		create table if not exists invites (
+			code text primary key,
+			created_by text not null,
+			used_by text,
+			used_at text,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+		create table if not exists waitlist (
+			id integer primary key autoincrement,
+			did text not null unique,
+			handle text not null,
+			email text not null default '',
+			note text not null default '',
+			-- set once an invite has been sent
+			invite_code text,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+
+		-- full text index over the titles and bodies of issues, kept in sync
+		-- with the issues table by the triggers below
+		create virtual table if not exists issues_fts using fts4(content="issues", title, body);
+		create trigger if not exists issues_fts_before_update before update on issues begin
+			delete from issues_fts where docid = old.id;
+		end;
+		create trigger if not exists issues_fts_before_delete before delete on issues begin
+			delete from issues_fts where docid = old.id;
+		end;
+		create trigger if not exists issues_fts_after_update after update on issues begin
+			insert into issues_fts (docid, title, body) values (new.id, new.title, new.body);
+		end;
+		create trigger if not exists issues_fts_after_insert after insert on issues begin
+			insert into issues_fts (docid, title, body) values (new.id, new.title, new.body);
+		end;
+
 		create table if not exists migrations (
 			id integer primary key autoincrement,
 			name text unique
@@ -474,6 +867,15 @@ func Make(dbPath string) (*DB, error) {
 		-- indexes for better star query performance
 		create index if not exists idx_stars_created on stars(created);
 		create index if not exists idx_stars_repo_at_created on stars(repo_at, created);
+
+		-- the slow query log turned these up: followers of a profile, the
+		-- timeline, issues and comments of a user, and comments of an issue
+		create index if not exists idx_follows_subject_did on follows(subject_did);
+		create index if not exists idx_follows_followed_at on follows(followed_at);
+		create index if not exists idx_stars_starred_by_did_created on stars(starred_by_did, created);
+		create index if not exists idx_issues_owner_did_created on issues(owner_did, created);
+		create index if not exists idx_comments_repo_at_issue_id on comments(repo_at, issue_id);
+		create index if not exists idx_comments_owner_did on comments(owner_did);
 	`)
 	if err != nil {
 		return nil, err
@@ -703,7 +1105,476 @@ func Make(dbPath string) (*DB, error) {
 		return err
 	})
 
-	return &DB{db}, nil
+	// index the issues that predate the full text index
+	runMigration(conn, "backfill-issues-fts", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`insert into issues_fts (issues_fts) values ('rebuild')`)
+		return err
+	})
+
+	runMigration(conn, "add-hidden-to-issues-and-comments", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table issues add column hidden text; -- reason given by the spam filter
+			alter table comments add column hidden text; -- reason given by the spam filter
+		`)
+		return err
+	})
+
+	// everyone who used tangled before registration policies existed is a
+	// member, so that closing registrations does not lock them out
+	runMigration(conn, "backfill-members", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			insert or ignore into members (did)
+			select did from repos
+			union select did from public_keys
+			union select did from emails
+			union select did from profile
+			union select did from registrations
+			union select user_did from follows
+			union select starred_by_did from stars
+			union select owner_did from issues
+			union select owner_did from comments
+			union select owner_did from pulls
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-policy-to-registrations", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table registrations add column repo_create text not null default 'member';
+			alter table registrations add column default_branch text not null default '';
+		`)
+		return err
+	})
+
+	// sqlite cannot alter check constraints, so the table is recreated to
+	// allow the new kinds
+	runMigration(conn, "add-irc-and-xmpp-to-integrations", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table repo_integrations_new (
+				id integer primary key autoincrement,
+				repo_at text not null,
+				kind text not null check (kind in ('discord', 'slack', 'matrix', 'irc', 'xmpp')),
+				url text not null,
+				room text not null default '',
+				token text not null default '',
+				events text not null default '',
+				templates text not null default '{}',
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+				foreign key (repo_at) references repos(at_uri) on delete cascade
+			);
+
+			insert into repo_integrations_new (id, repo_at, kind, url, room, token, events, templates, created)
+			select id, repo_at, kind, url, room, token, events, templates, created from repo_integrations;
+
+			drop table repo_integrations;
+			alter table repo_integrations_new rename to repo_integrations;
+		`)
+		return err
+	})
+
+	// the triggers refer to columns added by earlier migrations, so the
+	// counters come in as one too
+	runMigration(conn, "add-repo-counts", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			-- star, issue and pull counts of every repo, kept up to date by the
+			-- triggers below so that pages listing repos need not count them each
+			-- time. RebuildRepoCounts recounts them from scratch.
+			create table if not exists repo_counts (
+				repo text primary key, -- at-uri of the repo
+				stars integer not null default 0,
+				issues_open integer not null default 0,
+				issues_closed integer not null default 0,
+				pulls_open integer not null default 0,
+				pulls_merged integer not null default 0,
+				pulls_closed integer not null default 0,
+				pulls_deleted integer not null default 0
+			);
+			create trigger if not exists repo_counts_repos_delete after delete on repos begin
+				delete from repo_counts where repo = old.at_uri;
+			end;
+			-- a transferred repo is counted again as its stars, issues and pulls
+			-- move over
+			create trigger if not exists repo_counts_repos_update after update of at_uri on repos begin
+				delete from repo_counts where repo = old.at_uri;
+			end;
+
+			create trigger if not exists repo_counts_stars_insert after insert on stars begin
+				insert or ignore into repo_counts (repo) values (new.repo_at);
+				update repo_counts set stars = stars + 1 where repo = new.repo_at;
+			end;
+			create trigger if not exists repo_counts_stars_delete after delete on stars begin
+				update repo_counts set stars = stars - 1 where repo = old.repo_at;
+			end;
+			create trigger if not exists repo_counts_stars_update after update of repo_at on stars begin
+				update repo_counts set stars = stars - 1 where repo = old.repo_at;
+				insert or ignore into repo_counts (repo) values (new.repo_at);
+				update repo_counts set stars = stars + 1 where repo = new.repo_at;
+			end;
+
+			-- issues hidden by the spam filter are not counted
+			create trigger if not exists repo_counts_issues_insert after insert on issues begin
+				insert or ignore into repo_counts (repo) values (new.repo_at);
+				update repo_counts set
+					issues_open = issues_open + (new.open = 1 and new.hidden is null),
+					issues_closed = issues_closed + (new.open = 0 and new.hidden is null)
+				where repo = new.repo_at;
+			end;
+			create trigger if not exists repo_counts_issues_delete after delete on issues begin
+				update repo_counts set
+					issues_open = issues_open - (old.open = 1 and old.hidden is null),
+					issues_closed = issues_closed - (old.open = 0 and old.hidden is null)
+				where repo = old.repo_at;
+			end;
+			create trigger if not exists repo_counts_issues_update after update of repo_at, open, hidden on issues begin
+				update repo_counts set
+					issues_open = issues_open - (old.open = 1 and old.hidden is null),
+					issues_closed = issues_closed - (old.open = 0 and old.hidden is null)
+				where repo = old.repo_at;
+				insert or ignore into repo_counts (repo) values (new.repo_at);
+				update repo_counts set
+					issues_open = issues_open + (new.open = 1 and new.hidden is null),
+					issues_closed = issues_closed + (new.open = 0 and new.hidden is null)
+				where repo = new.repo_at;
+			end;
+
+			-- pull states are 0 closed, 1 open, 2 merged and 3 deleted
+			create trigger if not exists repo_counts_pulls_insert after insert on pulls begin
+				insert or ignore into repo_counts (repo) values (new.repo_at);
+				update repo_counts set
+					pulls_closed = pulls_closed + (new.state = 0),
+					pulls_open = pulls_open + (new.state = 1),
+					pulls_merged = pulls_merged + (new.state = 2),
+					pulls_deleted = pulls_deleted + (new.state = 3)
+				where repo = new.repo_at;
+			end;
+			create trigger if not exists repo_counts_pulls_delete after delete on pulls begin
+				update repo_counts set
+					pulls_closed = pulls_closed - (old.state = 0),
+					pulls_open = pulls_open - (old.state = 1),
+					pulls_merged = pulls_merged - (old.state = 2),
+					pulls_deleted = pulls_deleted - (old.state = 3)
+				where repo = old.repo_at;
+			end;
+			create trigger if not exists repo_counts_pulls_update after update of repo_at, state on pulls begin
+				update repo_counts set
+					pulls_closed = pulls_closed - (old.state = 0),
+					pulls_open = pulls_open - (old.state = 1),
+					pulls_merged = pulls_merged - (old.state = 2),
+					pulls_deleted = pulls_deleted - (old.state = 3)
+				where repo = old.repo_at;
+				insert or ignore into repo_counts (repo) values (new.repo_at);
+				update repo_counts set
+					pulls_closed = pulls_closed + (new.state = 0),
+					pulls_open = pulls_open + (new.state = 1),
+					pulls_merged = pulls_merged + (new.state = 2),
+					pulls_deleted = pulls_deleted + (new.state = 3)
+				where repo = new.repo_at;
+			end;
+		`)
+		if err != nil {
+			return err
+		}
+
+		// count what was there before the counters were
+		return RebuildRepoCounts(tx)
+	})
+
+	runMigration(conn, "add-topics-to-repos", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table repos add column topics text not null default ''; -- space separated
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-pushed-to-repos", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table repos add column pushed text; -- last push to any ref
+		`)
+		return err
+	})
+
+	// every repo page counts the forks of its repo
+	runMigration(conn, "add-repos-source-index", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create index if not exists idx_repos_source on repos(source);
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-bots-and-commit-statuses", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table if not exists repo_bots (
+				id integer primary key autoincrement,
+				repo_at text not null,
+				name text not null,
+				created_by text not null,
+				scopes text not null default '', -- comma separated
+				token_hash text not null unique, -- sha256 of the api token
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+				last_used text,
+				unique(repo_at, name),
+				foreign key (repo_at) references repos(at_uri) on delete cascade
+			);
+
+			create table if not exists commit_statuses (
+				id integer primary key autoincrement,
+				repo_at text not null,
+				sha text not null,
+				context text not null, -- what reported it, such as ci/build
+				state text not null,
+				description text not null default '',
+				target_url text not null default '',
+				bot_id integer not null,
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+				unique(repo_at, sha, context),
+				foreign key (repo_at) references repos(at_uri) on delete cascade,
+				foreign key (bot_id) references repo_bots(id) on delete cascade
+			);
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-repo-rules", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table if not exists repo_rules (
+				id integer primary key autoincrement,
+				repo_at text not null,
+				-- 'settings' for rules added on the appview, 'file' for the
+				-- ones read from .tangled/rules.yml
+				source text not null default 'settings',
+				event text not null,
+				-- only for labeled events, the label that was applied
+				label text not null default '',
+				-- only for stale events, the days without activity
+				days integer not null default 0,
+				action text not null,
+				-- the label to add, or the body of the comment to post
+				value text not null default '',
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+				foreign key (repo_at) references repos(at_uri) on delete cascade
+			);
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-repo-stale-settings", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table if not exists repo_stale_settings (
+				repo_at text primary key,
+				-- days without activity before an item is marked stale
+				days integer not null,
+				-- days a stale item is left open for
+				grace integer not null,
+				label text not null default 'stale',
+				comment text not null default '',
+				-- comma separated labels that keep items from going stale
+				exempt text not null default '',
+				pulls integer not null default 0,
+
+				foreign key (repo_at) references repos(at_uri) on delete cascade
+			);
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-repo-merge-settings", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table if not exists repo_merge_settings (
+				repo_at text primary key,
+				approvals integer not null default 0,
+				-- comma separated commit status contexts
+				contexts text not null default '',
+				block_changes_requested integer not null default 0,
+				-- comma separated, the first one is the default
+				strategies text not null default '',
+
+				foreign key (repo_at) references repos(at_uri) on delete cascade
+			);
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-pull-review-threads", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table pull_comments add column path text;
+			alter table pull_comments add column line integer;
+			alter table repo_merge_settings add column resolve_threads integer not null default 0;
+
+			-- a thread is all inline comments on one line of a round
+			create table if not exists pull_review_resolutions (
+				submission_id integer not null,
+				path text not null,
+				line integer not null,
+				resolved_by text not null,
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+				primary key (submission_id, path, line),
+				foreign key (submission_id) references pull_submissions(id) on delete cascade
+			);
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-merge-settings-require-signoff", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table repo_merge_settings add column require_signoff integer not null default 0;
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-merge-settings-history-policy", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table repo_merge_settings add column linear_history integer not null default 0;
+			alter table repo_merge_settings add column no_merge_commits integer not null default 0;
+			alter table repo_merge_settings add column require_pull_ref integer not null default 0;
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-notification-settings-bluesky", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table notification_settings add column bluesky text not null default '';
+		`)
+		return err
+	})
+
+	// projects with a due date double as milestones
+	runMigration(conn, "add-projects-due", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table projects add column due text;
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-oauth-apps", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table if not exists oauth_apps (
+				id integer primary key autoincrement,
+				client_id text not null unique,
+				name text not null,
+				homepage text not null default '',
+				redirect_uris text not null, -- newline separated
+				owner_did text not null,
+				secret_hash text not null, -- sha256 of the client secret
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+			);
+
+			-- authorization codes, exchanged once for a token
+			create table if not exists oauth_app_codes (
+				code_hash text primary key,
+				client_id text not null,
+				did text not null,
+				scopes text not null default '', -- comma separated
+				redirect_uri text not null,
+				code_challenge text not null default '',
+				expires text not null,
+				foreign key (client_id) references oauth_apps(client_id) on delete cascade
+			);
+
+			create table if not exists oauth_app_tokens (
+				id integer primary key autoincrement,
+				client_id text not null,
+				did text not null,
+				scopes text not null default '', -- comma separated
+				token_hash text not null unique, -- sha256 of the access token
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+				last_used text,
+				foreign key (client_id) references oauth_apps(client_id) on delete cascade
+			);
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-passkeys", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table if not exists passkeys (
+				id integer primary key autoincrement,
+				did text not null,
+				name text not null,
+				credential_id text not null unique, -- base64url
+				public_key blob not null, -- pkix
+				algorithm integer not null, -- cose identifier
+				sign_count integer not null default 0,
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+				last_used text
+			);
+			create index if not exists idx_passkeys_did on passkeys(did);
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-blob-scans", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table if not exists blob_scans (
+				cid text primary key,
+				did text not null, -- fetched from the PDS of this account
+				status text not null default 'pending', -- pending, clean, infected, failed
+				threat text not null default '',
+				attempts integer not null default 0,
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+				scanned text
+			);
+			create index if not exists idx_blob_scans_status on blob_scans(status);
+
+			insert or ignore into blob_scans (cid, did)
+			select blob_cid, did from attachments;
+			insert or ignore into blob_scans (cid, did)
+			select blob_cid, did from artifacts;
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-website-to-repos", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table repos add column website text not null default '';
+		`)
+		return err
+	})
+
+	// a domain is only held by whoever verified it, so pending claims no
+	// longer keep its owner from adding it. sqlite cannot drop the unique
+	// constraint, so the table is recreated.
+	runMigration(conn, "unique-verified-domains", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			create table domains_new (
+				id integer primary key autoincrement,
+				did text not null,
+				domain text not null,
+				repo_at text,
+				token text not null,
+				verified text,
+				created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+				unique (did, domain),
+				foreign key (repo_at) references repos(at_uri) on delete cascade
+			);
+
+			insert into domains_new (id, did, domain, repo_at, token, verified, created)
+			select id, did, domain, repo_at, token, verified, created from domains;
+
+			drop table domains;
+			alter table domains_new rename to domains;
+
+			create unique index if not exists idx_domains_verified on domains(domain) where verified is not null;
+		`)
+		return err
+	})
+
+	runMigration(conn, "add-for-did-to-invites", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			alter table invites add column for_did text;
+
+			-- unused invites sent to the waitlist go to their account only
+			update invites
+			set for_did = (select did from waitlist where waitlist.invite_code = invites.code)
+			where used_by is null;
+		`)
+		return err
+	})
+
+	return &DB{db, queries}, nil
 }
 
 type migrationFn = func(*sql.Tx) error
@@ -774,6 +1645,7 @@ func FilterLte(key string, arg any) filter   { return newFilter(key, "<=", arg)
 func FilterIs(key string, arg any) filter    { return newFilter(key, "is", arg) }
 func FilterIsNot(key string, arg any) filter { return newFilter(key, "is not", arg) }
 func FilterIn(key string, arg any) filter    { return newFilter(key, "in", arg) }
+func FilterLike(key string, arg any) filter  { return newFilter(key, "like", arg) }
 
 func (f filter) Condition() string {
 	rv := reflect.ValueOf(f.arg)
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// Domain is a custom domain mapped onto a user's profile, or onto one of
+// their repos if RepoAt is set.
+type Domain struct {
+	Id     int64
+	Did    syntax.DID
+	Domain string
+	RepoAt *syntax.ATURI
+
+	// random token that must be published in a TXT record at
+	// ChallengeName() to prove ownership of the domain
+	Token    string
+	Verified *time.Time
+	Created  time.Time
+}
+
+func (d Domain) ChallengeName() string {
+	return "_tangled-challenge." + d.Domain
+}
+
+func (d Domain) ChallengeValue() string {
+	return "tangled-domain-verification=" + d.Token
+}
+
+func GetDomains(e Execer, filters ...filter) ([]Domain, error) {
+	var domains []Domain
+
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	query := fmt.Sprintf(
+		`select id, did, domain, repo_at, token, verified, created
+		from domains
+		%s
+		order by created
+		`,
+		whereClause,
+	)
+
+	rows, err := e.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var domain Domain
+		var repoAt, verified sql.NullString
+		var createdAt string
+
+		if err := rows.Scan(
+			&domain.Id,
+			&domain.Did,
+			&domain.Domain,
+			&repoAt,
+			&domain.Token,
+			&verified,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+
+		domain.Created, err = time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			domain.Created = time.Now()
+		}
+
+		if repoAt.Valid {
+			at := syntax.ATURI(repoAt.String)
+			domain.RepoAt = &at
+		}
+
+		if verified.Valid {
+			t, err := time.Parse(time.RFC3339, verified.String)
+			if err != nil {
+				t = time.Now()
+			}
+			domain.Verified = &t
+		}
+
+		domains = append(domains, domain)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+func GetDomain(e Execer, filters ...filter) (*Domain, error) {
+	domains, err := GetDomains(e, filters...)
+	if err != nil {
+		return nil, err
+	}
+	if len(domains) != 1 {
+		return nil, sql.ErrNoRows
+	}
+	return &domains[0], nil
+}
+
+// GetVerifiedDomain looks up the mapping used to route requests arriving
+// with the given Host header
+func GetVerifiedDomain(e Execer, host string) (*Domain, error) {
+	return GetDomain(e, FilterEq("domain", host), FilterIsNot("verified", nil))
+}
+
+// AddDomain adds a pending claim on a domain. Anyone may claim a domain,
+// until one of them verifies it; a user claiming the same domain twice gets an
+// error.
+func AddDomain(e Execer, domain Domain) error {
+	var repoAt *string
+	if domain.RepoAt != nil {
+		s := domain.RepoAt.String()
+		repoAt = &s
+	}
+
+	_, err := e.Exec(
+		`insert into domains (did, domain, repo_at, token) values (?, ?, ?, ?)`,
+		domain.Did,
+		domain.Domain,
+		repoAt,
+		domain.Token,
+	)
+	return err
+}
+
+func VerifyDomain(e Execer, filters ...filter) (int64, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	query := fmt.Sprintf(`update domains set verified = strftime('%%Y-%%m-%%dT%%H:%%M:%%SZ', 'now') %s`, whereClause)
+
+	res, err := e.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// ClaimDomain verifies the mapping with id, dropping all other claims on its
+// domain: whoever can publish the TXT record owns the domain
+func ClaimDomain(e Execer, id int64, domain string) error {
+	if _, err := e.Exec(`delete from domains where domain = ? and id != ?`, domain, id); err != nil {
+		return err
+	}
+	_, err := VerifyDomain(e, FilterEq("id", id))
+	return err
+}
+
+// UnverifyDomain puts a domain whose TXT record went away back to pending,
+// leaving its owner until it expires to publish the record again
+func UnverifyDomain(e Execer, id int64) error {
+	_, err := e.Exec(
+		`update domains set verified = null, created = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') where id = ?`,
+		id,
+	)
+	return err
+}
+
+// DeleteExpiredDomains drops the claims left unverified since before
+func DeleteExpiredDomains(e Execer, before time.Time) (int64, error) {
+	res, err := e.Exec(
+		`delete from domains where verified is null and created < ?`,
+		before.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func DeleteDomain(e Execer, filters ...filter) error {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	query := fmt.Sprintf(`delete from domains %s`, whereClause)
+
+	_, err := e.Exec(query, args...)
+	return err
+}
@@ -80,6 +80,8 @@ func GetDidForEmail(e Execer, em string) (string, error) {
 	return did, nil
 }
 
+// GetEmailToDid maps each of ems to the did that owns it. Emails are
+// compared case-insensitively, and the result is keyed by ems as given.
 func GetEmailToDid(e Execer, ems []string, isVerifiedFilter bool) (map[string]string, error) {
 	if len(ems) == 0 {
 		return make(map[string]string), nil
@@ -90,14 +92,21 @@ func GetEmailToDid(e Execer, ems []string, isVerifiedFilter bool) (map[string]st
 		verifiedFilter = 1
 	}
 
+	// commits may spell the same address differently
+	byLower := make(map[string][]string)
+	for _, em := range ems {
+		lower := strings.ToLower(em)
+		byLower[lower] = append(byLower[lower], em)
+	}
+
 	// Create placeholders for the IN clause
-	placeholders := make([]string, len(ems))
-	args := make([]any, len(ems)+1)
+	placeholders := make([]string, 0, len(byLower))
+	args := make([]any, 0, len(byLower)+1)
 
-	args[0] = verifiedFilter
-	for i, em := range ems {
-		placeholders[i] = "?"
-		args[i+1] = em
+	args = append(args, verifiedFilter)
+	for lower := range byLower {
+		placeholders = append(placeholders, "?")
+		args = append(args, lower)
 	}
 
 	query := `
@@ -105,7 +114,7 @@ func GetEmailToDid(e Execer, ems []string, isVerifiedFilter bool) (map[string]st
 		from emails
 		where
 			verified = ?
-			and email in (` + strings.Join(placeholders, ",") + `)
+			and lower(email) in (` + strings.Join(placeholders, ",") + `)
 	`
 
 	rows, err := e.Query(query, args...)
@@ -121,7 +130,9 @@ func GetEmailToDid(e Execer, ems []string, isVerifiedFilter bool) (map[string]st
 		if err := rows.Scan(&email, &did); err != nil {
 			return nil, err
 		}
-		assoc[email] = did
+		for _, em := range byLower[strings.ToLower(email)] {
+			assoc[em] = did
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -254,6 +265,25 @@ func MakeEmailPrimary(e Execer, did string, email string) error {
 	return err
 }
 
+// EnsurePrimaryEmail makes email the primary one, unless the user already
+// has a verified primary email
+func EnsurePrimaryEmail(e Execer, did string, email string) error {
+	var count int
+	err := e.QueryRow(
+		`select count(*) from emails where did = ? and is_primary = true and verified = true`,
+		did,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	return MakeEmailPrimary(e, did, email)
+}
+
 func GetAllEmails(e Execer, did string) ([]Email, error) {
 	query := `
 		select did, email, verified, is_primary, verification_code, last_sent, created
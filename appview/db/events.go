@@ -0,0 +1,223 @@
+package db
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+type ThreadEventKind string
+
+const (
+	ThreadEventClosed    ThreadEventKind = "closed"
+	ThreadEventReopened  ThreadEventKind = "reopened"
+	ThreadEventMerged    ThreadEventKind = "merged"
+	ThreadEventLabeled   ThreadEventKind = "labeled"
+	ThreadEventUnlabeled ThreadEventKind = "unlabeled"
+	ThreadEventRenamed   ThreadEventKind = "renamed"
+	// the value of a force-push is the old and the new head, separated by a
+	// space
+	ThreadEventForcePushed ThreadEventKind = "force-pushed"
+	// the value of a backport is the branch it went to and the id of the
+	// pull that brings it there, separated by a space
+	ThreadEventBackported ThreadEventKind = "backported"
+)
+
+// ThreadEvent is a change to an issue or pull, rendered in between the
+// comments of its thread
+type ThreadEvent struct {
+	Id        int64
+	RepoAt    syntax.ATURI
+	SubjectAt syntax.ATURI
+	ActorDid  string
+	Kind      ThreadEventKind
+	Value     string
+	SourceAt  *syntax.ATURI
+	Created   time.Time
+}
+
+// ForcePush is the pair of heads a force-push went from and to
+type ForcePush struct {
+	From string
+	To   string
+}
+
+func (e ThreadEvent) ForcePush() ForcePush {
+	from, to, _ := strings.Cut(e.Value, " ")
+	return ForcePush{From: from, To: to}
+}
+
+// Backport is where a pull was backported to
+type Backport struct {
+	Branch string
+	PullId int
+}
+
+func (e ThreadEvent) Backport() Backport {
+	branch, id, _ := strings.Cut(e.Value, " ")
+	pullId, _ := strconv.Atoi(id)
+	return Backport{Branch: branch, PullId: pullId}
+}
+
+func AddThreadEvent(e Execer, event ThreadEvent) error {
+	var source *string
+	if event.SourceAt != nil {
+		s := event.SourceAt.String()
+		source = &s
+	}
+
+	created := event.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	res, err := e.Exec(
+		`insert or ignore into thread_events (repo_at, subject_at, actor_did, kind, value, source_at, created)
+		values (?, ?, ?, ?, ?, ?, ?)`,
+		event.RepoAt,
+		event.SubjectAt,
+		event.ActorDid,
+		event.Kind,
+		event.Value,
+		source,
+		created.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	// events already seen through another path do not move cards again
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	return applyProjectAutomation(e, event.SubjectAt, event.Kind)
+}
+
+// LastThreadEventId returns the id of the newest thread event, or 0 if there
+// are none
+func LastThreadEventId(e Execer) (int64, error) {
+	var id int64
+	err := e.QueryRow(`select coalesce(max(id), 0) from thread_events`).Scan(&id)
+	return id, err
+}
+
+func GetThreadEvents(e Execer, filters ...filter) ([]ThreadEvent, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, repo_at, subject_at, actor_did, kind, value, source_at, created
+		from thread_events`+whereClause+`
+		order by created, id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []ThreadEvent
+	for rows.Next() {
+		var ev ThreadEvent
+		var source *string
+		var created string
+		if err := rows.Scan(&ev.Id, &ev.RepoAt, &ev.SubjectAt, &ev.ActorDid, &ev.Kind, &ev.Value, &source, &created); err != nil {
+			return nil, err
+		}
+
+		if source != nil {
+			at := syntax.ATURI(*source)
+			ev.SourceAt = &at
+		}
+
+		ev.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			ev.Created = time.Now()
+		}
+
+		events = append(events, ev)
+	}
+
+	return events, rows.Err()
+}
+
+// ThreadItem is one entry of an issue or pull thread, either a comment or an
+// event
+type ThreadItem struct {
+	Comment     *Comment
+	PullComment *PullComment
+	Event       *ThreadEvent
+}
+
+// IssueThread interleaves the comments and events of an issue by time
+func IssueThread(comments []Comment, events []ThreadEvent) []ThreadItem {
+	items := make([]ThreadItem, 0, len(comments)+len(events))
+	for i := range comments {
+		items = append(items, ThreadItem{Comment: &comments[i]})
+	}
+	for i := range events {
+		items = append(items, ThreadItem{Event: &events[i]})
+	}
+
+	sortThread(items)
+	return items
+}
+
+// PullThreads interleaves the comments of each round of a pull with the
+// events that happened during that round, keyed by round number
+func PullThreads(pull *Pull, events []ThreadEvent) map[int][]ThreadItem {
+	threads := make(map[int][]ThreadItem)
+	for _, submission := range pull.Submissions {
+		for i := range submission.Comments {
+			threads[submission.RoundNumber] = append(threads[submission.RoundNumber], ThreadItem{PullComment: &submission.Comments[i]})
+		}
+	}
+
+	for i := range events {
+		round := 0
+		for _, submission := range pull.Submissions {
+			if !submission.Created.After(events[i].Created) {
+				round = submission.RoundNumber
+			}
+		}
+		threads[round] = append(threads[round], ThreadItem{Event: &events[i]})
+	}
+
+	for _, items := range threads {
+		sortThread(items)
+	}
+
+	return threads
+}
+
+func sortThread(items []ThreadItem) {
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].created().Before(items[b].created())
+	})
+}
+
+func (t ThreadItem) created() time.Time {
+	if t.Event != nil {
+		return t.Event.Created
+	}
+	if t.Comment != nil && t.Comment.Created != nil {
+		return *t.Comment.Created
+	}
+	if t.PullComment != nil {
+		return t.PullComment.Created
+	}
+	return time.Time{}
+}
@@ -0,0 +1,419 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/api/tangled"
+)
+
+type GistVisibility string
+
+const (
+	// listed on the timeline and the owner's profile
+	GistPublic GistVisibility = "public"
+	// viewable by anyone with the link, but never listed
+	GistUnlisted GistVisibility = "unlisted"
+	// only viewable by the owner
+	GistSecret GistVisibility = "secret"
+)
+
+func (v GistVisibility) IsValid() bool {
+	switch v {
+	case GistPublic, GistUnlisted, GistSecret:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	MaxGistFiles = 10
+	// MaxGistSize caps the contents of all files of a gist together, keeping
+	// its record well within what a PDS accepts
+	MaxGistSize = 512 << 10
+)
+
+// Gist is a collection of one or more files. Public gists are published to
+// the owner's PDS as sh.tangled.gist records, keyed by their gist id. Unlisted
+// and secret gists stay on the appview, since records can be listed and read
+// by anyone crawling the network.
+type Gist struct {
+	Id     int64
+	Did    syntax.DID
+	GistId string
+
+	Description string
+	Visibility  GistVisibility
+	ForkedFrom  *string
+	Created     time.Time
+	Edited      *time.Time
+
+	Files []GistFile
+}
+
+type GistFile struct {
+	Filename string
+	Contents string
+}
+
+// CanView reports whether the given user may view this gist, did may be
+// empty for logged out users
+func (g Gist) CanView(did string) bool {
+	return g.Visibility != GistSecret || g.Did.String() == did
+}
+
+func (g Gist) Stats() StringStats {
+	var stats StringStats
+	for _, f := range g.Files {
+		s := String{Contents: f.Contents}.Stats()
+		stats.LineCount += s.LineCount
+		stats.ByteCount += s.ByteCount
+	}
+	return stats
+}
+
+func (g Gist) File(filename string) (GistFile, bool) {
+	for _, f := range g.Files {
+		if f.Filename == filename {
+			return f, true
+		}
+	}
+	return GistFile{}, false
+}
+
+func (g Gist) Validate() error {
+	var err error
+
+	if utf8.RuneCountInString(g.Description) > 280 {
+		err = errors.Join(err, fmt.Errorf("description too long"))
+	}
+
+	if !g.Visibility.IsValid() {
+		err = errors.Join(err, fmt.Errorf("invalid visibility: %q", g.Visibility))
+	}
+
+	if len(g.Files) == 0 {
+		err = errors.Join(err, fmt.Errorf("gist has no files"))
+	}
+	if len(g.Files) > MaxGistFiles {
+		err = errors.Join(err, fmt.Errorf("gist has more than %d files", MaxGistFiles))
+	}
+
+	size := 0
+	for _, f := range g.Files {
+		size += len(f.Contents)
+	}
+	if size > MaxGistSize {
+		err = errors.Join(err, fmt.Errorf("gist is larger than %d KiB", MaxGistSize>>10))
+	}
+
+	seen := make(map[string]struct{})
+	for _, f := range g.Files {
+		if f.Filename == "" {
+			err = errors.Join(err, fmt.Errorf("filename is empty"))
+		}
+		if utf8.RuneCountInString(f.Filename) > 140 {
+			err = errors.Join(err, fmt.Errorf("filename too long: %s", f.Filename))
+		}
+		if strings.ContainsAny(f.Filename, "/\\") {
+			err = errors.Join(err, fmt.Errorf("filename cannot contain slashes: %s", f.Filename))
+		}
+		if len(f.Contents) == 0 {
+			err = errors.Join(err, fmt.Errorf("contents of %s is empty", f.Filename))
+		}
+		if _, ok := seen[f.Filename]; ok {
+			err = errors.Join(err, fmt.Errorf("duplicate filename: %s", f.Filename))
+		}
+		seen[f.Filename] = struct{}{}
+	}
+
+	return err
+}
+
+// AtUri is where the record of a public gist lives
+func (g Gist) AtUri() syntax.ATURI {
+	return syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", g.Did, tangled.GistNSID, g.GistId))
+}
+
+// AsRecord leaves out ForkedFrom, which the record holds as the at-uri of
+// the source gist rather than its id
+func (g Gist) AsRecord() tangled.Gist {
+	record := tangled.Gist{
+		CreatedAt: g.Created.Format(time.RFC3339),
+	}
+	if g.Description != "" {
+		record.Description = &g.Description
+	}
+	for _, f := range g.Files {
+		record.Files = append(record.Files, &tangled.Gist_File{
+			Filename: f.Filename,
+			Contents: f.Contents,
+		})
+	}
+	return record
+}
+
+// GistFromRecord reads a public gist off its record, leaving ForkedFrom for
+// callers to resolve
+func GistFromRecord(did, rkey string, record tangled.Gist) Gist {
+	created, err := time.Parse(time.RFC3339, record.CreatedAt)
+	if err != nil {
+		created = time.Now()
+	}
+
+	g := Gist{
+		Did:        syntax.DID(did),
+		GistId:     rkey,
+		Visibility: GistPublic,
+		Created:    created,
+	}
+	if record.Description != nil {
+		g.Description = *record.Description
+	}
+	for _, f := range record.Files {
+		if f == nil {
+			continue
+		}
+		g.Files = append(g.Files, GistFile{
+			Filename: f.Filename,
+			Contents: f.Contents,
+		})
+	}
+	return g
+}
+
+func AddGist(tx *sql.Tx, g Gist) error {
+	_, err := tx.Exec(
+		`insert into gists (did, gist_id, description, visibility, forked_from, created)
+		values (?, ?, ?, ?, ?, ?)`,
+		g.Did,
+		g.GistId,
+		g.Description,
+		g.Visibility,
+		g.ForkedFrom,
+		g.Created.Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	return insertGistFiles(tx, g)
+}
+
+// UpdateGist replaces the description, visibility and files of an existing
+// gist
+func UpdateGist(tx *sql.Tx, g Gist) error {
+	_, err := tx.Exec(
+		`update gists
+		set description = ?, visibility = ?, edited = ?
+		where gist_id = ?`,
+		g.Description,
+		g.Visibility,
+		time.Now().Format(time.RFC3339),
+		g.GistId,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`delete from gist_files where gist_id = ?`, g.GistId)
+	if err != nil {
+		return err
+	}
+
+	return insertGistFiles(tx, g)
+}
+
+func insertGistFiles(tx *sql.Tx, g Gist) error {
+	stmt, err := tx.Prepare(`insert into gist_files (gist_id, filename, content) values (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range g.Files {
+		if _, err := stmt.Exec(g.GistId, f.Filename, f.Contents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GetGists(e Execer, limit int, filters ...filter) ([]Gist, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	limitClause := ""
+	if limit != 0 {
+		limitClause = fmt.Sprintf(" limit %d ", limit)
+	}
+
+	query := fmt.Sprintf(
+		`select id, did, gist_id, description, visibility, forked_from, created, edited
+		from gists
+		%s
+		order by created desc
+		%s`,
+		whereClause,
+		limitClause,
+	)
+
+	rows, err := e.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var gists []Gist
+	gistMap := make(map[string]int)
+	for rows.Next() {
+		var g Gist
+		var forkedFrom, editedAt sql.NullString
+		var createdAt string
+
+		if err := rows.Scan(
+			&g.Id,
+			&g.Did,
+			&g.GistId,
+			&g.Description,
+			&g.Visibility,
+			&forkedFrom,
+			&createdAt,
+			&editedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		g.Created, err = time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			g.Created = time.Now()
+		}
+
+		if forkedFrom.Valid {
+			g.ForkedFrom = &forkedFrom.String
+		}
+
+		if editedAt.Valid {
+			e, err := time.Parse(time.RFC3339, editedAt.String)
+			if err != nil {
+				e = time.Now()
+			}
+			g.Edited = &e
+		}
+
+		gistMap[g.GistId] = len(gists)
+		gists = append(gists, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(gists) == 0 {
+		return gists, nil
+	}
+
+	// collect files for all gists in one go
+	ids := make([]string, 0, len(gists))
+	for _, g := range gists {
+		ids = append(ids, g.GistId)
+	}
+
+	filesFilter := FilterIn("gist_id", ids)
+	fileRows, err := e.Query(
+		fmt.Sprintf(`select gist_id, filename, content from gist_files where %s order by filename`, filesFilter.Condition()),
+		filesFilter.Arg()...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer fileRows.Close()
+
+	for fileRows.Next() {
+		var gistId string
+		var f GistFile
+		if err := fileRows.Scan(&gistId, &f.Filename, &f.Contents); err != nil {
+			return nil, err
+		}
+
+		if idx, ok := gistMap[gistId]; ok {
+			gists[idx].Files = append(gists[idx].Files, f)
+		}
+	}
+
+	if err := fileRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return gists, nil
+}
+
+func GetGist(e Execer, filters ...filter) (*Gist, error) {
+	gists, err := GetGists(e, 0, filters...)
+	if err != nil {
+		return nil, err
+	}
+	if len(gists) != 1 {
+		return nil, sql.ErrNoRows
+	}
+	return &gists[0], nil
+}
+
+func CountGists(e Execer, filters ...filter) (int64, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	query := fmt.Sprintf(`select count(1) from gists %s`, whereClause)
+	var count int64
+	err := e.QueryRow(query, args...).Scan(&count)
+
+	if !errors.Is(err, sql.ErrNoRows) && err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func DeleteGist(e Execer, filters ...filter) error {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	query := fmt.Sprintf(`delete from gists %s`, whereClause)
+
+	_, err := e.Exec(query, args...)
+	return err
+}
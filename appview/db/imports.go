@@ -0,0 +1,178 @@
+package db
+
+import (
+	"database/sql"
+	mathrand "math/rand/v2"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// IssueImport records where an imported issue or comment came from. Imported
+// issues and comments have no record on anyone's PDS.
+type IssueImport struct {
+	RepoAt    syntax.ATURI
+	IssueId   int
+	CommentId int // 0 for the issue itself
+
+	SourceUrl string
+	Author    string
+	Mapped    bool
+}
+
+// ImportIssue inserts an issue with its original timestamp and state. Like
+// NewIssue, it allocates the next issue id of the repo.
+func ImportIssue(tx *sql.Tx, issue *Issue, source IssueImport) error {
+	_, err := tx.Exec(`
+		insert or ignore into repo_issue_seqs (repo_at, next_issue_id)
+		values (?, 1)
+		`, issue.RepoAt)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		update repo_issue_seqs
+		set next_issue_id = next_issue_id + 1
+		where repo_at = ?
+		returning next_issue_id - 1
+		`, issue.RepoAt).Scan(&issue.IssueId)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`
+		insert into issues (repo_at, owner_did, rkey, issue_at, issue_id, title, body, open, created)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, issue.RepoAt, issue.OwnerDid, issue.Rkey, issue.AtUri(), issue.IssueId, issue.Title, issue.Body, issue.Open, issue.Created.Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+
+	issue.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	source.RepoAt = issue.RepoAt
+	source.IssueId = issue.IssueId
+	source.CommentId = 0
+	return addIssueImport(tx, source)
+}
+
+func ImportIssueComment(tx *sql.Tx, comment *Comment, source IssueImport) error {
+	comment.CommentId = mathrand.IntN(1000000)
+
+	created := time.Now()
+	if comment.Created != nil {
+		created = *comment.Created
+	}
+
+	// imported comments have no rkey, which keeps edits off the PDS
+	_, err := tx.Exec(
+		`insert into comments (owner_did, repo_at, issue_id, comment_id, body, created) values (?, ?, ?, ?, ?, ?)`,
+		comment.OwnerDid,
+		comment.RepoAt,
+		comment.Issue,
+		comment.CommentId,
+		comment.Body,
+		created.Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	source.RepoAt = comment.RepoAt
+	source.IssueId = comment.Issue
+	source.CommentId = comment.CommentId
+	return addIssueImport(tx, source)
+}
+
+func addIssueImport(e Execer, source IssueImport) error {
+	_, err := e.Exec(
+		`insert into issue_imports (repo_at, issue_id, comment_id, source_url, author, mapped)
+		values (?, ?, ?, ?, ?, ?)`,
+		source.RepoAt,
+		source.IssueId,
+		source.CommentId,
+		source.SourceUrl,
+		source.Author,
+		source.Mapped,
+	)
+	return err
+}
+
+// GetIssueImports returns the import attribution of an issue and its
+// comments, keyed by comment id (0 for the issue itself)
+func GetIssueImports(e Execer, repoAt syntax.ATURI, issueId int) (map[int]*IssueImport, error) {
+	rows, err := e.Query(
+		`select comment_id, source_url, author, mapped
+		from issue_imports
+		where repo_at = ? and issue_id = ?`,
+		repoAt,
+		issueId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imports := make(map[int]*IssueImport)
+	for rows.Next() {
+		i := IssueImport{RepoAt: repoAt, IssueId: issueId}
+		if err := rows.Scan(&i.CommentId, &i.SourceUrl, &i.Author, &i.Mapped); err != nil {
+			return nil, err
+		}
+		imports[i.CommentId] = &i
+	}
+
+	return imports, rows.Err()
+}
+
+// GetImportedIssueSources returns the source urls of all issues already
+// imported into a repo, so that an import can be re-run safely
+func GetImportedIssueSources(e Execer, repoAt syntax.ATURI) (map[string]struct{}, error) {
+	rows, err := e.Query(
+		`select source_url from issue_imports where repo_at = ? and comment_id = 0`,
+		repoAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := make(map[string]struct{})
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		sources[s] = struct{}{}
+	}
+
+	return sources, rows.Err()
+}
+
+// GetDidsByProfileLink returns users whose profile links match the given
+// sql like pattern, compared case-insensitively
+func GetDidsByProfileLink(e Execer, pattern string) ([]string, error) {
+	rows, err := e.Query(
+		`select distinct did from profile_links where lower(rtrim(link, '/')) like lower(?)`,
+		pattern,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dids []string
+	for rows.Next() {
+		var did string
+		if err := rows.Scan(&did); err != nil {
+			return nil, err
+		}
+		dids = append(dids, did)
+	}
+
+	return dids, rows.Err()
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// DefaultSlowQueryThreshold is how long a query may take before it ends
+	// up in the slow query log
+	DefaultSlowQueryThreshold = 100 * time.Millisecond
+
+	slowQueryLogSize = 100
+
+	// queries are told apart by their text, which is bounded by the code
+	// but not by much
+	maxQueryStats = 1000
+)
+
+// SlowQuery is a query that took longer than the threshold of its QueryLog
+type SlowQuery struct {
+	Query    string
+	Duration time.Duration
+	Rows     int64
+	At       time.Time
+}
+
+// QueryStats adds up every run of one query
+type QueryStats struct {
+	Query    string
+	Count    int64
+	Total    time.Duration
+	Max      time.Duration
+	Rows     int64
+	LastSeen time.Time
+}
+
+func (s QueryStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// QueryLog records how long each query takes and how many rows it reads or
+// writes, and keeps the most recent slow ones around
+type QueryLog struct {
+	Threshold time.Duration
+
+	mu    sync.Mutex
+	slow  []SlowQuery
+	stats map[string]*QueryStats
+}
+
+func NewQueryLog(threshold time.Duration) *QueryLog {
+	return &QueryLog{
+		Threshold: threshold,
+		stats:     make(map[string]*QueryStats),
+	}
+}
+
+var (
+	whitespace   = regexp.MustCompile(`\s+`)
+	placeholders = regexp.MustCompile(`\?(\s*,\s*\?)+`)
+)
+
+// normalizeQuery folds the whitespace of a query and the variable length
+// lists of placeholders of in clauses, so that runs of one query add up
+func normalizeQuery(query string) string {
+	query = whitespace.ReplaceAllString(strings.TrimSpace(query), " ")
+	return placeholders.ReplaceAllString(query, "?, ...")
+}
+
+func (l *QueryLog) record(query string, d time.Duration, rows int64) {
+	query = normalizeQuery(query)
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, ok := l.stats[query]
+	if !ok {
+		if len(l.stats) >= maxQueryStats {
+			return
+		}
+		s = &QueryStats{Query: query}
+		l.stats[query] = s
+	}
+	s.Count++
+	s.Total += d
+	s.Max = max(s.Max, d)
+	s.Rows += rows
+	s.LastSeen = now
+
+	if d >= l.Threshold {
+		log.Printf("slow query (%s, %d rows): %s", d, rows, query)
+
+		l.slow = append(l.slow, SlowQuery{Query: query, Duration: d, Rows: rows, At: now})
+		if len(l.slow) > slowQueryLogSize {
+			l.slow = l.slow[len(l.slow)-slowQueryLogSize:]
+		}
+	}
+}
+
+// Slow lists the most recent slow queries, newest first
+func (l *QueryLog) Slow() []SlowQuery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slow := slices.Clone(l.slow)
+	slices.Reverse(slow)
+	return slow
+}
+
+// Stats lists the queries that took the most time altogether, n at most
+func (l *QueryLog) Stats(n int) []QueryStats {
+	l.mu.Lock()
+	stats := make([]QueryStats, 0, len(l.stats))
+	for _, s := range l.stats {
+		stats = append(stats, *s)
+	}
+	l.mu.Unlock()
+
+	slices.SortFunc(stats, func(a, b QueryStats) int {
+		return int(b.Total - a.Total)
+	})
+	if len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// connector opens sqlite connections that report every query to a QueryLog.
+// Going through the driver catches queries run in transactions too.
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+	log    *QueryLog
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{conn.(*sqlite3.SQLiteConn), c.log}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+type instrumentedConn struct {
+	*sqlite3.SQLiteConn
+	log *QueryLog
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.SQLiteConn.QueryContext(ctx, query, args)
+	if err != nil {
+		c.log.record(query, time.Since(start), 0)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, log: c.log, query: query, start: start}, nil
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := c.SQLiteConn.ExecContext(ctx, query, args)
+
+	var rows int64
+	if err == nil {
+		rows, _ = res.RowsAffected()
+	}
+	c.log.record(query, time.Since(start), rows)
+	return res, err
+}
+
+// instrumentedRows times a query until its rows are closed, as sqlite does
+// most of the work while they are read
+type instrumentedRows struct {
+	driver.Rows
+	log   *QueryLog
+	query string
+	start time.Time
+	rows  int64
+	done  bool
+}
+
+func (r *instrumentedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.rows++
+	} else if errors.Is(err, io.EOF) {
+		r.finish()
+	}
+	return err
+}
+
+func (r *instrumentedRows) Close() error {
+	r.finish()
+	return r.Rows.Close()
+}
+
+func (r *instrumentedRows) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+	r.log.record(r.query, time.Since(r.start), r.rows)
+}
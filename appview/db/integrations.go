@@ -0,0 +1,128 @@
+package db
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+type IntegrationKind string
+
+const (
+	IntegrationDiscord IntegrationKind = "discord"
+	IntegrationSlack   IntegrationKind = "slack"
+	IntegrationMatrix  IntegrationKind = "matrix"
+	IntegrationIRC     IntegrationKind = "irc"
+	IntegrationXMPP    IntegrationKind = "xmpp"
+)
+
+func (k IntegrationKind) IsValid() bool {
+	switch k {
+	case IntegrationDiscord, IntegrationSlack, IntegrationMatrix, IntegrationIRC, IntegrationXMPP:
+		return true
+	}
+	return false
+}
+
+// Integration posts activity on a repo to a chat service
+type Integration struct {
+	Id     int64
+	RepoAt syntax.ATURI
+	Kind   IntegrationKind
+
+	// webhook url for discord and slack, homeserver url for matrix, server
+	// url for irc and the bot's jid for xmpp
+	Url string
+	// Room is the matrix room id, irc channel or xmpp muc
+	Room string
+	// Token is the matrix access token, irc server password or xmpp password
+	Token string
+
+	Events []string
+	// Templates override the default message of an event
+	Templates map[string]string
+	Created   time.Time
+}
+
+func (i Integration) HasEvent(event string) bool {
+	return slices.Contains(i.Events, event)
+}
+
+func AddIntegration(e Execer, i Integration) error {
+	templates, err := json.Marshal(i.Templates)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec(
+		`insert into repo_integrations (repo_at, kind, url, room, token, events, templates)
+		values (?, ?, ?, ?, ?, ?, ?)`,
+		i.RepoAt,
+		i.Kind,
+		i.Url,
+		i.Room,
+		i.Token,
+		strings.Join(i.Events, ","),
+		string(templates),
+	)
+	return err
+}
+
+func DeleteIntegration(e Execer, repoAt syntax.ATURI, id int64) error {
+	_, err := e.Exec(`delete from repo_integrations where repo_at = ? and id = ?`, repoAt, id)
+	return err
+}
+
+func GetIntegrations(e Execer, filters ...filter) ([]Integration, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, repo_at, kind, url, room, token, events, templates, created
+		from repo_integrations`+whereClause+`
+		order by created`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var integrations []Integration
+	for rows.Next() {
+		var i Integration
+		var events, templates, created string
+		if err := rows.Scan(&i.Id, &i.RepoAt, &i.Kind, &i.Url, &i.Room, &i.Token, &events, &templates, &created); err != nil {
+			return nil, err
+		}
+
+		if events != "" {
+			i.Events = strings.Split(events, ",")
+		}
+
+		if err := json.Unmarshal([]byte(templates), &i.Templates); err != nil {
+			return nil, err
+		}
+
+		i.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			i.Created = time.Now()
+		}
+
+		integrations = append(integrations, i)
+	}
+
+	return integrations, rows.Err()
+}
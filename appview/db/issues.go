@@ -2,10 +2,14 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	mathrand "math/rand/v2"
+	"slices"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"tangled.sh/tangled.sh/core/api/tangled"
@@ -23,6 +27,10 @@ type Issue struct {
 	Body     string
 	Open     bool
 
+	// why the spam filter hid this issue, hidden issues are only shown to
+	// their author and to the admins of the repo
+	Hidden string
+
 	// optionally, populate this when querying for reverse mappings
 	// like comment counts, parent repo etc.
 	Metadata *IssueMetadata
@@ -31,7 +39,8 @@ type Issue struct {
 type IssueMetadata struct {
 	CommentCount int
 	Repo         *Repo
-	// labels, assignee etc.
+	Labels       []Label
+	// assignee etc.
 }
 
 type Comment struct {
@@ -44,6 +53,9 @@ type Comment struct {
 	Created   *time.Time
 	Deleted   *time.Time
 	Edited    *time.Time
+
+	// why the spam filter hid this comment, see Issue.Hidden
+	Hidden string
 }
 
 func (i *Issue) AtUri() syntax.ATURI {
@@ -133,6 +145,22 @@ func NewIssue(tx *sql.Tx, issue *Issue) error {
 		return err
 	}
 
+	// the record may have been ingested already, if it was written to the
+	// PDS before it got here
+	if issue.Rkey != "" {
+		err = tx.QueryRow(
+			`select id, issue_id from issues where owner_did = ? and rkey = ?`,
+			issue.OwnerDid,
+			issue.Rkey,
+		).Scan(&issue.ID, &issue.IssueId)
+		switch {
+		case err == nil:
+			return tx.Commit()
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+	}
+
 	var nextId int
 	err = tx.QueryRow(`
 		update repo_issue_seqs
@@ -147,9 +175,9 @@ func NewIssue(tx *sql.Tx, issue *Issue) error {
 	issue.IssueId = nextId
 
 	res, err := tx.Exec(`
-		insert into issues (repo_at, owner_did, rkey, issue_at, issue_id, title, body)
-		values (?, ?, ?, ?, ?, ?, ?)
-	`, issue.RepoAt, issue.OwnerDid, issue.Rkey, issue.AtUri(), issue.IssueId, issue.Title, issue.Body)
+		insert into issues (repo_at, owner_did, rkey, issue_at, issue_id, title, body, hidden)
+		values (?, ?, ?, ?, ?, ?, ?, ?)
+	`, issue.RepoAt, issue.OwnerDid, issue.Rkey, issue.AtUri(), issue.IssueId, issue.Title, issue.Body, nullString(issue.Hidden))
 	if err != nil {
 		return err
 	}
@@ -179,7 +207,9 @@ func GetIssueOwnerDid(e Execer, repoAt syntax.ATURI, issueId int) (string, error
 	return ownerDid, err
 }
 
-func GetIssuesPaginated(e Execer, repoAt syntax.ATURI, isOpen bool, page pagination.Page) ([]Issue, error) {
+// GetIssuesPaginated fetches the issues of a repo, hidden issues are left
+// out unless they were opened by viewerDid
+func GetIssuesPaginated(e Execer, repoAt syntax.ATURI, viewerDid string, isOpen bool, page pagination.Page) ([]Issue, error) {
 	var issues []Issue
 	openValue := 0
 	if isOpen {
@@ -198,14 +228,14 @@ func GetIssuesPaginated(e Execer, repoAt syntax.ATURI, isOpen bool, page paginat
 				i.title,
 				i.body,
 				i.open,
-				count(c.id) as comment_count,
+				count(case when c.hidden is null then c.id end) as comment_count,
 				row_number() over (order by i.created desc) as row_num
 			from
 				issues i
 			left join
 				comments c on i.repo_at = c.repo_at and i.issue_id = c.issue_id
 			where
-				i.repo_at = ? and i.open = ?
+				i.repo_at = ? and i.open = ? and (i.hidden is null or i.owner_did = ?)
 			group by
 				i.id, i.owner_did, i.issue_id, i.created, i.title, i.body, i.open
 		)
@@ -223,7 +253,7 @@ func GetIssuesPaginated(e Execer, repoAt syntax.ATURI, isOpen bool, page paginat
 			numbered_issue
 		where
 			row_num between ? and ?`,
-		repoAt, openValue, page.Offset+1, page.Offset+page.Limit)
+		repoAt, openValue, viewerDid, page.Offset+1, page.Offset+page.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -283,7 +313,9 @@ func GetIssuesWithLimit(e Execer, limit int, filters ...filter) ([]Issue, error)
 			i.created,
 			i.title,
 			i.body,
-			i.open
+			i.open,
+			i.rkey,
+			coalesce(i.hidden, '')
 		from
 		    issues i
 		%s
@@ -310,6 +342,8 @@ func GetIssuesWithLimit(e Execer, limit int, filters ...filter) ([]Issue, error)
 			&issue.Title,
 			&issue.Body,
 			&issue.Open,
+			&issue.Rkey,
+			&issue.Hidden,
 		)
 		if err != nil {
 			return nil, err
@@ -420,13 +454,253 @@ func GetIssuesByOwnerDid(e Execer, ownerDid string, timeframe string) ([]Issue,
 	return issues, nil
 }
 
+// GetTriageIssues fetches issues across many repos, newest first, along with
+// their repo, comment count and labels
+func GetTriageIssues(e Execer, limit int, filters ...filter) ([]Issue, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+	limitClause := ""
+	if limit != 0 {
+		limitClause = fmt.Sprintf(" limit %d ", limit)
+	}
+
+	rows, err := e.Query(
+		`select
+			i.id,
+			i.owner_did,
+			i.rkey,
+			i.repo_at,
+			i.issue_id,
+			i.created,
+			i.title,
+			i.body,
+			i.open,
+			r.did,
+			r.name,
+			r.knot,
+			r.rkey,
+			r.created,
+			(select count(*) from comments c where c.repo_at = i.repo_at and c.issue_id = i.issue_id and c.deleted is null)
+		from
+			issues i
+		join
+			repos r on i.repo_at = r.at_uri`+whereClause+`
+		order by
+			i.created desc`+limitClause,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []Issue
+	var issueAts []string
+	for rows.Next() {
+		var issue Issue
+		var issueCreatedAt, repoCreatedAt string
+		var repo Repo
+		var commentCount int
+		err := rows.Scan(
+			&issue.ID,
+			&issue.OwnerDid,
+			&issue.Rkey,
+			&issue.RepoAt,
+			&issue.IssueId,
+			&issueCreatedAt,
+			&issue.Title,
+			&issue.Body,
+			&issue.Open,
+			&repo.Did,
+			&repo.Name,
+			&repo.Knot,
+			&repo.Rkey,
+			&repoCreatedAt,
+			&commentCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		issue.Created, err = time.Parse(time.RFC3339, issueCreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		repo.Created, err = time.Parse(time.RFC3339, repoCreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		issue.Metadata = &IssueMetadata{
+			CommentCount: commentCount,
+			Repo:         &repo,
+		}
+
+		issues = append(issues, issue)
+		issueAts = append(issueAts, issue.AtUri().String())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	labels, err := GetLabels(e, FilterIn("subject_at", issueAts))
+	if err != nil {
+		return nil, err
+	}
+	for i := range issues {
+		for _, l := range labels {
+			if l.SubjectAt == issues[i].AtUri() {
+				issues[i].Metadata.Labels = append(issues[i].Metadata.Labels, l)
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// GetOpenIssueCounts counts the open issues of each of the given repos
+func GetOpenIssueCounts(e Execer, repoAts []syntax.ATURI) (map[syntax.ATURI]int, error) {
+	filter := FilterIn("repo_at", repoAts)
+
+	rows, err := e.Query(
+		`select repo_at, count(*) from issues
+		where open = 1 and `+filter.Condition()+`
+		group by repo_at`,
+		filter.Arg()...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[syntax.ATURI]int)
+	for rows.Next() {
+		var repoAt syntax.ATURI
+		var count int
+		if err := rows.Scan(&repoAt, &count); err != nil {
+			return nil, err
+		}
+		counts[repoAt] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// SearchSimilarIssues finds the issues of a repo whose title or body share
+// words with title, best matches first
+func SearchSimilarIssues(e Execer, repoAt syntax.ATURI, title string, limit int) ([]Issue, error) {
+	words := searchWords(title)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	// every word is quoted, so that nothing in the title is taken as query
+	// syntax
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `"`
+	}
+
+	rows, err := e.Query(
+		`select i.id, i.owner_did, i.rkey, i.issue_id, i.created, i.title, i.open
+		from issues_fts f
+		join issues i on i.id = f.docid
+		where issues_fts match ? and i.repo_at = ? and i.hidden is null
+		limit 50`,
+		strings.Join(terms, " OR "),
+		repoAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type scored struct {
+		issue Issue
+		score int
+	}
+	var matches []scored
+	for rows.Next() {
+		issue := Issue{RepoAt: repoAt}
+		var createdAt string
+		err := rows.Scan(&issue.ID, &issue.OwnerDid, &issue.Rkey, &issue.IssueId, &createdAt, &issue.Title, &issue.Open)
+		if err != nil {
+			return nil, err
+		}
+
+		issue.Created, err = time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			issue.Created = time.Now()
+		}
+
+		// rank by the words shared with the title, fts4 does not rank
+		// results by itself
+		score := 0
+		titleWords := searchWords(issue.Title)
+		for _, w := range words {
+			if slices.Contains(titleWords, w) {
+				score += 1
+			}
+		}
+
+		matches = append(matches, scored{issue, score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].issue.IssueId > matches[j].issue.IssueId
+	})
+
+	var issues []Issue
+	for i := 0; i < len(matches) && i < limit; i++ {
+		issues = append(issues, matches[i].issue)
+	}
+
+	return issues, nil
+}
+
+// searchWords splits text into the lowercase words worth searching for
+func searchWords(text string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len(w) < 3 || slices.Contains(stopWords, w) || slices.Contains(words, w) {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+var stopWords = []string{
+	"the", "and", "for", "with", "when", "not", "does", "doesn", "can", "cannot",
+	"are", "was", "but", "from", "that", "this", "into", "should", "after",
+	"before", "while", "have", "has", "using", "use", "how", "why", "what",
+}
+
 func GetIssue(e Execer, repoAt syntax.ATURI, issueId int) (*Issue, error) {
-	query := `select id, owner_did, rkey, created, title, body, open from issues where repo_at = ? and issue_id = ?`
+	query := `select id, owner_did, rkey, created, title, body, open, coalesce(hidden, '') from issues where repo_at = ? and issue_id = ?`
 	row := e.QueryRow(query, repoAt, issueId)
 
-	var issue Issue
+	issue := Issue{RepoAt: repoAt, IssueId: issueId}
 	var createdAt string
-	err := row.Scan(&issue.ID, &issue.OwnerDid, &issue.Rkey, &createdAt, &issue.Title, &issue.Body, &issue.Open)
+	err := row.Scan(&issue.ID, &issue.OwnerDid, &issue.Rkey, &createdAt, &issue.Title, &issue.Body, &issue.Open, &issue.Hidden)
 	if err != nil {
 		return nil, err
 	}
@@ -441,12 +715,12 @@ func GetIssue(e Execer, repoAt syntax.ATURI, issueId int) (*Issue, error) {
 }
 
 func GetIssueWithComments(e Execer, repoAt syntax.ATURI, issueId int) (*Issue, []Comment, error) {
-	query := `select id, owner_did, rkey, issue_id, created, title, body, open from issues where repo_at = ? and issue_id = ?`
+	query := `select id, owner_did, rkey, issue_id, created, title, body, open, coalesce(hidden, '') from issues where repo_at = ? and issue_id = ?`
 	row := e.QueryRow(query, repoAt, issueId)
 
 	var issue Issue
 	var createdAt string
-	err := row.Scan(&issue.ID, &issue.OwnerDid, &issue.Rkey, &issue.IssueId, &createdAt, &issue.Title, &issue.Body, &issue.Open)
+	err := row.Scan(&issue.ID, &issue.OwnerDid, &issue.Rkey, &issue.IssueId, &createdAt, &issue.Title, &issue.Body, &issue.Open, &issue.Hidden)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -465,18 +739,43 @@ func GetIssueWithComments(e Execer, repoAt syntax.ATURI, issueId int) (*Issue, [
 	return &issue, comments, nil
 }
 
+// NewIssueComment adds a comment, unless a comment with the same record has
+// already been added, in which case comment is updated with its id
 func NewIssueComment(e Execer, comment *Comment) error {
-	query := `insert into comments (owner_did, repo_at, rkey, issue_id, comment_id, body) values (?, ?, ?, ?, ?, ?)`
-	_, err := e.Exec(
-		query,
+	created := time.Now()
+	if comment.Created != nil {
+		created = *comment.Created
+	}
+
+	res, err := e.Exec(
+		`insert into comments (owner_did, repo_at, rkey, issue_id, comment_id, body, created, hidden)
+		select ?, ?, ?, ?, ?, ?, ?, ?
+		where ? = '' or not exists (select 1 from comments where owner_did = ? and rkey = ?)`,
 		comment.OwnerDid,
 		comment.RepoAt,
 		comment.Rkey,
 		comment.Issue,
 		comment.CommentId,
 		comment.Body,
+		created.UTC().Format(time.RFC3339),
+		nullString(comment.Hidden),
+		comment.Rkey,
+		comment.OwnerDid,
+		comment.Rkey,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	return e.QueryRow(
+		`select comment_id from comments where owner_did = ? and rkey = ?`,
+		comment.OwnerDid,
+		comment.Rkey,
+	).Scan(&comment.CommentId)
 }
 
 func GetComments(e Execer, repoAt syntax.ATURI, issueId int) ([]Comment, error) {
@@ -491,7 +790,8 @@ func GetComments(e Execer, repoAt syntax.ATURI, issueId int) ([]Comment, error)
 			body,
 			created,
 			edited,
-			deleted
+			deleted,
+			coalesce(hidden, '')
 		from
 			comments
 		where
@@ -513,7 +813,7 @@ func GetComments(e Execer, repoAt syntax.ATURI, issueId int) ([]Comment, error)
 		var comment Comment
 		var createdAt string
 		var deletedAt, editedAt, rkey sql.NullString
-		err := rows.Scan(&comment.OwnerDid, &comment.Issue, &comment.CommentId, &rkey, &comment.Body, &createdAt, &editedAt, &deletedAt)
+		err := rows.Scan(&comment.OwnerDid, &comment.Issue, &comment.CommentId, &rkey, &comment.Body, &createdAt, &editedAt, &deletedAt, &comment.Hidden)
 		if err != nil {
 			return nil, err
 		}
@@ -557,7 +857,7 @@ func GetComments(e Execer, repoAt syntax.ATURI, issueId int) ([]Comment, error)
 func GetComment(e Execer, repoAt syntax.ATURI, issueId, commentId int) (*Comment, error) {
 	query := `
 		select
-			owner_did, body, rkey, created, deleted, edited
+			owner_did, body, rkey, created, deleted, edited, coalesce(hidden, '')
 		from
 			comments where repo_at = ? and issue_id = ? and comment_id = ?
 	`
@@ -566,7 +866,7 @@ func GetComment(e Execer, repoAt syntax.ATURI, issueId, commentId int) (*Comment
 	var comment Comment
 	var createdAt string
 	var deletedAt, editedAt, rkey sql.NullString
-	err := row.Scan(&comment.OwnerDid, &comment.Body, &rkey, &createdAt, &deletedAt, &editedAt)
+	err := row.Scan(&comment.OwnerDid, &comment.Body, &rkey, &createdAt, &deletedAt, &editedAt, &comment.Hidden)
 	if err != nil {
 		return nil, err
 	}
@@ -648,16 +948,73 @@ func DeleteCommentByRkey(e Execer, ownerDid, rkey string) error {
 	return err
 }
 
-func UpdateIssueByRkey(e Execer, ownerDid, rkey, title, body string) error {
-	_, err := e.Exec(`update issues set title = ?, body = ? where owner_did = ? and rkey = ?`, title, body, ownerDid, rkey)
+func DeleteIssueByRkey(e Execer, ownerDid, rkey string) error {
+	_, err := e.Exec(`delete from issues where owner_did = ? and rkey = ?`, ownerDid, rkey)
 	return err
 }
 
-func DeleteIssueByRkey(e Execer, ownerDid, rkey string) error {
-	_, err := e.Exec(`delete from issues where owner_did = ? and rkey = ?`, ownerDid, rkey)
+// IssueEdit is the title and body of an issue as they were before an edit
+type IssueEdit struct {
+	Id        int64
+	RepoAt    syntax.ATURI
+	IssueId   int
+	EditorDid string
+	Title     string
+	Body      string
+	Created   time.Time
+}
+
+// EditIssue replaces the title and body of an issue, keeping the previous
+// version in its edit history
+func EditIssue(e Execer, repoAt syntax.ATURI, issueId int, editorDid, title, body string) error {
+	_, err := e.Exec(
+		`insert into issue_edits (repo_at, issue_id, editor_did, title, body)
+		select repo_at, issue_id, ?, title, body
+		from issues
+		where repo_at = ? and issue_id = ? and (title != ? or body != ?)`,
+		editorDid, repoAt, issueId, title, body,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec(`update issues set title = ?, body = ? where repo_at = ? and issue_id = ?`, title, body, repoAt, issueId)
 	return err
 }
 
+// GetIssueEdits returns the edit history of an issue, latest first
+func GetIssueEdits(e Execer, repoAt syntax.ATURI, issueId int) ([]IssueEdit, error) {
+	rows, err := e.Query(
+		`select id, repo_at, issue_id, editor_did, title, body, created
+		from issue_edits
+		where repo_at = ? and issue_id = ?
+		order by created desc, id desc`,
+		repoAt, issueId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []IssueEdit
+	for rows.Next() {
+		var edit IssueEdit
+		var created string
+		if err := rows.Scan(&edit.Id, &edit.RepoAt, &edit.IssueId, &edit.EditorDid, &edit.Title, &edit.Body, &created); err != nil {
+			return nil, err
+		}
+
+		edit.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			edit.Created = time.Now()
+		}
+
+		edits = append(edits, edit)
+	}
+
+	return edits, rows.Err()
+}
+
 func CloseIssue(e Execer, repoAt syntax.ATURI, issueId int) error {
 	_, err := e.Exec(`update issues set open = 0 where repo_at = ? and issue_id = ?`, repoAt, issueId)
 	return err
@@ -674,19 +1031,9 @@ type IssueCount struct {
 }
 
 func GetIssueCount(e Execer, repoAt syntax.ATURI) (IssueCount, error) {
-	row := e.QueryRow(`
-		select
-			count(case when open = 1 then 1 end) as open_count,
-			count(case when open = 0 then 1 end) as closed_count
-		from issues
-		where repo_at = ?`,
-		repoAt,
-	)
-
-	var count IssueCount
-	if err := row.Scan(&count.Open, &count.Closed); err != nil {
+	stats, err := getRepoStats(e, repoAt)
+	if err != nil {
 		return IssueCount{0, 0}, err
 	}
-
-	return count, nil
+	return stats.IssueCount, nil
 }
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/api/tangled"
+)
+
+// Label is a label applied to an issue or pull by a collaborator of the repo
+type Label struct {
+	OwnerDid  string
+	Rkey      string
+	RepoAt    syntax.ATURI
+	SubjectAt syntax.ATURI
+	Name      string
+	Created   time.Time
+}
+
+func (l *Label) LabelAt() syntax.ATURI {
+	return syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", l.OwnerDid, tangled.RepoLabelNSID, l.Rkey))
+}
+
+func AddLabel(e Execer, label Label) error {
+	_, err := e.Exec(
+		`insert or ignore into labels (owner_did, rkey, repo_at, subject_at, name, created)
+		values (?, ?, ?, ?, ?, ?)`,
+		label.OwnerDid,
+		label.Rkey,
+		label.RepoAt,
+		label.SubjectAt,
+		label.Name,
+		label.Created.UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+func DeleteLabelByRkey(e Execer, ownerDid, rkey string) error {
+	_, err := e.Exec(`delete from labels where owner_did = ? and rkey = ?`, ownerDid, rkey)
+	return err
+}
+
+func GetLabels(e Execer, filters ...filter) ([]Label, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select owner_did, rkey, repo_at, subject_at, name, created
+		from labels`+whereClause+`
+		order by name`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []Label
+	for rows.Next() {
+		var l Label
+		var created string
+		if err := rows.Scan(&l.OwnerDid, &l.Rkey, &l.RepoAt, &l.SubjectAt, &l.Name, &created); err != nil {
+			return nil, err
+		}
+
+		l.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			l.Created = time.Now()
+		}
+
+		labels = append(labels, l)
+	}
+
+	return labels, rows.Err()
+}
@@ -0,0 +1,209 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+func IsMember(e Execer, did string) (bool, error) {
+	var exists bool
+	err := e.QueryRow(`select exists (select 1 from members where did = ?)`, did).Scan(&exists)
+	return exists, err
+}
+
+func AddMember(e Execer, did, inviteCode string) error {
+	_, err := e.Exec(
+		`insert or ignore into members (did, invite_code) values (?, ?)`,
+		did,
+		nullString(inviteCode),
+	)
+	return err
+}
+
+type Invite struct {
+	Code      string
+	CreatedBy string
+	// ForDid is the only account that may use the invite, if set
+	ForDid  string
+	UsedBy  string
+	UsedAt  *time.Time
+	Created time.Time
+}
+
+func AddInvite(e Execer, code, createdBy string) error {
+	_, err := e.Exec(`insert into invites (code, created_by) values (?, ?)`, code, createdBy)
+	return err
+}
+
+// AddInviteFor adds an invite that only did can use, as those sent to the
+// waitlist are
+func AddInviteFor(e Execer, code, createdBy, did string) error {
+	_, err := e.Exec(`insert into invites (code, created_by, for_did) values (?, ?, ?)`, code, createdBy, did)
+	return err
+}
+
+func DeleteInvite(e Execer, code, createdBy string) error {
+	_, err := e.Exec(`delete from invites where code = ? and created_by = ? and used_by is null`, code, createdBy)
+	return err
+}
+
+// IsInviteAvailable tells whether code exists and can still be used by did
+func IsInviteAvailable(e Execer, code, did string) (bool, error) {
+	var exists bool
+	err := e.QueryRow(
+		`select exists (select 1 from invites where code = ? and used_by is null and (for_did is null or for_did = ?))`,
+		code,
+		did,
+	).Scan(&exists)
+	return exists, err
+}
+
+// UseInvite claims an invite for did, sql.ErrNoRows is returned if the
+// invite does not exist, has been used already or is meant for someone else
+func UseInvite(e Execer, code, did string) error {
+	res, err := e.Exec(
+		`update invites
+		set used_by = ?, used_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		where code = ? and used_by is null and (for_did is null or for_did = ?)`,
+		did,
+		code,
+		did,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func GetInvites(e Execer, filters ...filter) ([]Invite, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select code, created_by, coalesce(for_did, ''), coalesce(used_by, ''), used_at, created
+		from invites`+whereClause+`
+		order by created desc`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []Invite
+	for rows.Next() {
+		var invite Invite
+		var usedAt sql.NullString
+		var created string
+		if err := rows.Scan(&invite.Code, &invite.CreatedBy, &invite.ForDid, &invite.UsedBy, &usedAt, &created); err != nil {
+			return nil, err
+		}
+
+		invite.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			invite.Created = time.Now()
+		}
+
+		if usedAt.Valid {
+			t, err := time.Parse(time.RFC3339, usedAt.String)
+			if err == nil {
+				invite.UsedAt = &t
+			}
+		}
+
+		invites = append(invites, invite)
+	}
+
+	return invites, rows.Err()
+}
+
+// WaitlistEntry is an account waiting for an invite
+type WaitlistEntry struct {
+	Id         int64
+	Did        string
+	Handle     string
+	Email      string
+	Note       string
+	InviteCode string
+	Created    time.Time
+}
+
+// AddToWaitlist adds an account to the waitlist, or updates its entry if it
+// is on it already
+func AddToWaitlist(e Execer, entry WaitlistEntry) error {
+	_, err := e.Exec(
+		`insert into waitlist (did, handle, email, note) values (?, ?, ?, ?)
+		on conflict(did) do update set handle = excluded.handle, email = excluded.email, note = excluded.note`,
+		entry.Did,
+		entry.Handle,
+		entry.Email,
+		entry.Note,
+	)
+	return err
+}
+
+func SetWaitlistInvite(e Execer, id int64, code string) error {
+	_, err := e.Exec(`update waitlist set invite_code = ? where id = ?`, code, id)
+	return err
+}
+
+func GetWaitlist(e Execer, filters ...filter) ([]WaitlistEntry, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, did, handle, email, note, coalesce(invite_code, ''), created
+		from waitlist`+whereClause+`
+		order by created`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []WaitlistEntry
+	for rows.Next() {
+		var entry WaitlistEntry
+		var created string
+		if err := rows.Scan(&entry.Id, &entry.Did, &entry.Handle, &entry.Email, &entry.Note, &entry.InviteCode, &created); err != nil {
+			return nil, err
+		}
+
+		entry.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			entry.Created = time.Now()
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
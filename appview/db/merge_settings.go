@@ -0,0 +1,257 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/patchutil"
+)
+
+// MergeStrategy is how a pull lands on its target branch
+type MergeStrategy string
+
+const (
+	MergeStrategyMerge  MergeStrategy = "merge"
+	MergeStrategySquash MergeStrategy = "squash"
+	MergeStrategyRebase MergeStrategy = "rebase"
+)
+
+// MergeStrategies are all strategies, rebasing first as that is how pulls
+// were always merged
+var MergeStrategies = []MergeStrategy{
+	MergeStrategyRebase,
+	MergeStrategySquash,
+	MergeStrategyMerge,
+}
+
+func (s MergeStrategy) IsValid() bool {
+	return slices.Contains(MergeStrategies, s)
+}
+
+// MergeSettings are what a repo requires of a pull before it can be merged
+type MergeSettings struct {
+	RepoAt syntax.ATURI
+	// approvals of the latest round, from people who can push other than
+	// the author
+	Approvals int
+	// commit status contexts that have to have succeeded on the latest
+	// commit of a pull
+	Contexts []string
+	// whether a reviewer asking for changes blocks merging, until they
+	// approve
+	BlockChangesRequested bool
+	// whether every inline review thread has to be resolved
+	ResolveThreads bool
+	// whether every commit has to be signed off by its author, see
+	// patchutil.UnsignedCommits
+	RequireSignoff bool
+	// whether pulls may only be rebased or squashed, never merged with a
+	// merge commit
+	LinearHistory bool
+	// whether the knot refuses merge commits on the default branch, pushed
+	// or merged
+	NoMergeCommits bool
+	// whether the knot refuses commits on the default branch that do not
+	// reference a pull, merges reference theirs
+	RequirePullRef bool
+	// the strategies that may be used, the first one is the default
+	Strategies []MergeStrategy
+}
+
+// DefaultMergeSettings require nothing and allow every strategy
+func DefaultMergeSettings(repoAt syntax.ATURI) MergeSettings {
+	return MergeSettings{RepoAt: repoAt, Strategies: MergeStrategies}
+}
+
+func (m MergeSettings) Allows(s MergeStrategy) bool {
+	if m.LinearHistory && s == MergeStrategyMerge {
+		return false
+	}
+	return slices.Contains(m.Strategies, s)
+}
+
+// MergeRequirement is one of the conditions of MergeSettings, and whether a
+// pull meets it
+type MergeRequirement struct {
+	Description string
+	Met         bool
+}
+
+// Requirements checks pull against the settings. reviews and threads are
+// those of the pull, statuses those of its latest commit, and canPush tells
+// whose reviews count.
+func (m MergeSettings) Requirements(pull *Pull, reviews []PullReview, threads []ReviewThread, statuses []CommitStatus, canPush func(did string) bool) []MergeRequirement {
+	var requirements []MergeRequirement
+
+	// reviews come oldest first, the latest one of a reviewer is their say
+	latest := make(map[string]PullReview)
+	var reviewers []string
+	for _, r := range reviews {
+		if r.OwnerDid == pull.OwnerDid || !canPush(r.OwnerDid) {
+			continue
+		}
+		if r.State == ReviewCommented {
+			continue
+		}
+		if _, ok := latest[r.OwnerDid]; !ok {
+			reviewers = append(reviewers, r.OwnerDid)
+		}
+		latest[r.OwnerDid] = r
+	}
+
+	if m.Approvals > 0 {
+		var approvals int
+		for _, did := range reviewers {
+			r := latest[did]
+			if r.State == ReviewApproved && (r.Round == nil || *r.Round == pull.LastRoundNumber()) {
+				approvals += 1
+			}
+		}
+		requirements = append(requirements, MergeRequirement{
+			Description: fmt.Sprintf("%d of %d required approvals", min(approvals, m.Approvals), m.Approvals),
+			Met:         approvals >= m.Approvals,
+		})
+	}
+
+	if m.BlockChangesRequested {
+		var requested int
+		for _, did := range reviewers {
+			if latest[did].State == ReviewChangesRequested {
+				requested += 1
+			}
+		}
+		req := MergeRequirement{Description: "no changes requested", Met: requested == 0}
+		if requested > 0 {
+			req.Description = fmt.Sprintf("changes requested by %d reviewer(s)", requested)
+		}
+		requirements = append(requirements, req)
+	}
+
+	if m.ResolveThreads {
+		unresolved := UnresolvedThreads(threads)
+		req := MergeRequirement{Description: "all review threads resolved", Met: unresolved == 0}
+		if unresolved > 0 {
+			req.Description = fmt.Sprintf("%d unresolved review thread(s)", unresolved)
+		}
+		requirements = append(requirements, req)
+	}
+
+	if m.RequireSignoff {
+		check := CheckSignoff(pull.LatestPatch())
+		req := MergeRequirement{Description: "all commits signed off (DCO)", Met: check.Ok()}
+		switch {
+		case check.NoCommits():
+			req.Description = "commits signed off (DCO), a plain diff has none"
+		case check.Err != nil:
+			req.Description = "commits signed off (DCO), failed to check"
+		case len(check.Unsigned) > 0:
+			req.Description = fmt.Sprintf("%d commit(s) not signed off (DCO)", len(check.Unsigned))
+		}
+		requirements = append(requirements, req)
+	}
+
+	for _, context := range m.Contexts {
+		state := "missing"
+		for _, s := range statuses {
+			if s.Context == context {
+				state = string(s.State)
+			}
+		}
+		requirements = append(requirements, MergeRequirement{
+			Description: fmt.Sprintf("%s: %s", context, state),
+			Met:         state == string(CommitStateSuccess),
+		})
+	}
+
+	return requirements
+}
+
+// SignoffCheck is whether the commits of a round are signed off by their
+// authors
+type SignoffCheck struct {
+	Unsigned []patchutil.UnsignedCommit
+	Err      error
+}
+
+func CheckSignoff(patch string) SignoffCheck {
+	unsigned, err := patchutil.UnsignedCommits(patch)
+	return SignoffCheck{Unsigned: unsigned, Err: err}
+}
+
+func (c SignoffCheck) Ok() bool {
+	return c.Err == nil && len(c.Unsigned) == 0
+}
+
+func (c SignoffCheck) NoCommits() bool {
+	return errors.Is(c.Err, patchutil.ErrNoCommits)
+}
+
+func SetMergeSettings(e Execer, m MergeSettings) error {
+	strategies := make([]string, len(m.Strategies))
+	for i, s := range m.Strategies {
+		strategies[i] = string(s)
+	}
+
+	_, err := e.Exec(
+		`insert into repo_merge_settings (repo_at, approvals, contexts, block_changes_requested, resolve_threads, require_signoff, linear_history, no_merge_commits, require_pull_ref, strategies)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		on conflict(repo_at) do update set
+			approvals = excluded.approvals,
+			contexts = excluded.contexts,
+			block_changes_requested = excluded.block_changes_requested,
+			resolve_threads = excluded.resolve_threads,
+			require_signoff = excluded.require_signoff,
+			linear_history = excluded.linear_history,
+			no_merge_commits = excluded.no_merge_commits,
+			require_pull_ref = excluded.require_pull_ref,
+			strategies = excluded.strategies`,
+		m.RepoAt,
+		m.Approvals,
+		strings.Join(m.Contexts, ","),
+		m.BlockChangesRequested,
+		m.ResolveThreads,
+		m.RequireSignoff,
+		m.LinearHistory,
+		m.NoMergeCommits,
+		m.RequirePullRef,
+		strings.Join(strategies, ","),
+	)
+	return err
+}
+
+// GetMergeSettings returns the merge settings of a repo, or the default ones
+// if it has none
+func GetMergeSettings(e Execer, repoAt syntax.ATURI) (MergeSettings, error) {
+	m := MergeSettings{RepoAt: repoAt}
+	var contexts, strategies string
+	err := e.QueryRow(
+		`select approvals, contexts, block_changes_requested, resolve_threads, require_signoff, linear_history, no_merge_commits, require_pull_ref, strategies
+		from repo_merge_settings
+		where repo_at = ?`,
+		repoAt,
+	).Scan(&m.Approvals, &contexts, &m.BlockChangesRequested, &m.ResolveThreads, &m.RequireSignoff, &m.LinearHistory, &m.NoMergeCommits, &m.RequirePullRef, &strategies)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultMergeSettings(repoAt), nil
+	}
+	if err != nil {
+		return m, err
+	}
+
+	if contexts != "" {
+		m.Contexts = strings.Split(contexts, ",")
+	}
+	for _, s := range strings.Split(strategies, ",") {
+		if strategy := MergeStrategy(s); strategy.IsValid() {
+			m.Strategies = append(m.Strategies, strategy)
+		}
+	}
+	if len(m.Strategies) == 0 {
+		m.Strategies = MergeStrategies
+	}
+
+	return m, nil
+}
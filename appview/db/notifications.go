@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// NotificationSettings decide how a user hears about activity on their repos,
+// issues and pulls
+type NotificationSettings struct {
+	Did   string
+	Email bool
+	// how pulls awaiting review and merged pulls reach the user on bluesky
+	Bluesky string
+}
+
+const (
+	BlueskyOff     = ""
+	BlueskyDm      = "dm"
+	BlueskyMention = "mention"
+)
+
+// GetNotificationSettings returns the user's settings, or the defaults
+// (everything off) if they never changed them
+func GetNotificationSettings(e Execer, did string) (NotificationSettings, error) {
+	settings := NotificationSettings{Did: did}
+
+	var email int
+	err := e.QueryRow(
+		`select email, bluesky from notification_settings where did = ?`,
+		did,
+	).Scan(&email, &settings.Bluesky)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+
+	settings.Email = email != 0
+	return settings, nil
+}
+
+func SetNotificationSettings(e Execer, settings NotificationSettings) error {
+	_, err := e.Exec(
+		`insert into notification_settings (did, email, bluesky)
+		values (?, ?, ?)
+		on conflict(did) do update set
+			email = excluded.email,
+			bluesky = excluded.bluesky`,
+		settings.Did,
+		settings.Email,
+		settings.Bluesky,
+	)
+	return err
+}
+
+// GetNotificationEmail returns the address to send notifications for did to,
+// or an empty string if they have not opted in or have no verified primary
+// email
+func GetNotificationEmail(e Execer, did string) (string, error) {
+	var address string
+	err := e.QueryRow(
+		`select e.email
+		from emails e
+		join notification_settings n on n.did = e.did
+		where e.did = ? and e.is_primary = true and e.verified = true and n.email = 1`,
+		did,
+	).Scan(&address)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return address, err
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Passkey is a WebAuthn credential a user registered, to confirm it is them
+// before sensitive actions and to sign back in
+type Passkey struct {
+	Id           int64
+	Did          string
+	Name         string
+	CredentialId string
+	PublicKey    []byte
+	Algorithm    int
+	SignCount    uint32
+	Created      time.Time
+	LastUsed     *time.Time
+}
+
+func AddPasskey(e Execer, passkey *Passkey) error {
+	res, err := e.Exec(
+		`insert into passkeys (did, name, credential_id, public_key, algorithm, sign_count) values (?, ?, ?, ?, ?, ?)`,
+		passkey.Did,
+		passkey.Name,
+		passkey.CredentialId,
+		passkey.PublicKey,
+		passkey.Algorithm,
+		passkey.SignCount,
+	)
+	if err != nil {
+		return err
+	}
+
+	passkey.Id, err = res.LastInsertId()
+	return err
+}
+
+func DeletePasskey(e Execer, did string, id int64) error {
+	_, err := e.Exec(`delete from passkeys where did = ? and id = ?`, did, id)
+	return err
+}
+
+// SetPasskeyUsed records a successful use of a passkey, along with the
+// signature counter its authenticator reported
+func SetPasskeyUsed(e Execer, id int64, signCount uint32) error {
+	_, err := e.Exec(
+		`update passkeys set sign_count = ?, last_used = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') where id = ?`,
+		signCount,
+		id,
+	)
+	return err
+}
+
+func GetPasskeys(e Execer, filters ...filter) ([]Passkey, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, did, name, credential_id, public_key, algorithm, sign_count, created, last_used
+		from passkeys`+whereClause+`
+		order by created`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var passkeys []Passkey
+	for rows.Next() {
+		var p Passkey
+		var created string
+		var lastUsed sql.NullString
+		if err := rows.Scan(&p.Id, &p.Did, &p.Name, &p.CredentialId, &p.PublicKey, &p.Algorithm, &p.SignCount, &created, &lastUsed); err != nil {
+			return nil, err
+		}
+
+		p.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			p.Created = time.Now()
+		}
+
+		if lastUsed.Valid {
+			if lu, err := time.Parse(time.RFC3339, lastUsed.String); err == nil {
+				p.LastUsed = &lu
+			}
+		}
+
+		passkeys = append(passkeys, p)
+	}
+
+	return passkeys, rows.Err()
+}
+
+func GetPasskey(e Execer, credentialId string) (*Passkey, error) {
+	passkeys, err := GetPasskeys(e, FilterEq("credential_id", credentialId))
+	if err != nil {
+		return nil, err
+	}
+	if len(passkeys) != 1 {
+		return nil, sql.ErrNoRows
+	}
+	return &passkeys[0], nil
+}
+
+func HasPasskeys(e Execer, did string) (bool, error) {
+	var n int
+	err := e.QueryRow(`select count(1) from passkeys where did = ?`, did).Scan(&n)
+	return n > 0, err
+}
@@ -156,15 +156,19 @@ func MakeProfileTimeline(e Execer, forDid string) (*ProfileTimeline, error) {
 		return nil, fmt.Errorf("error getting all repos by did: %w", err)
 	}
 
+	var sources []string
 	for _, repo := range repos {
-		// TODO: get this in the original query; requires COALESCE because nullable
-		var sourceRepo *Repo
 		if repo.Source != "" {
-			sourceRepo, err = GetRepoByAtUri(e, repo.Source)
-			if err != nil {
-				return nil, err
-			}
+			sources = append(sources, repo.Source)
 		}
+	}
+	sourceRepos, err := GetReposByAtUris(e, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repo := range repos {
+		sourceRepo := sourceRepos[repo.Source]
 
 		repoMonth := repo.Created.Month()
 
@@ -0,0 +1,422 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/api/tangled"
+)
+
+// Project is a board of issues and pulls of a repo, laid out in columns
+type Project struct {
+	Id          int64
+	RepoAt      syntax.ATURI
+	Name        string
+	Description string
+	Created     time.Time
+	// the day the project is due, which makes it a milestone
+	Due *time.Time
+
+	// optionally, populated by GetProjectBoard
+	Columns []ProjectColumn
+}
+
+// DueDateFormat is how due dates are stored, they are whole days
+const DueDateFormat = "2006-01-02"
+
+func (p Project) Overdue() bool {
+	return p.Due != nil && time.Now().After(p.Due.AddDate(0, 0, 1))
+}
+
+// ProjectAutomation is the kind of thread event that moves cards into a
+// column, for instance closing an issue moves it to "done"
+type ProjectAutomation string
+
+const (
+	ProjectAutomationNone     ProjectAutomation = ""
+	ProjectAutomationClosed   ProjectAutomation = ProjectAutomation(ThreadEventClosed)
+	ProjectAutomationMerged   ProjectAutomation = ProjectAutomation(ThreadEventMerged)
+	ProjectAutomationReopened ProjectAutomation = ProjectAutomation(ThreadEventReopened)
+)
+
+func (a ProjectAutomation) IsValid() bool {
+	switch a {
+	case ProjectAutomationNone, ProjectAutomationClosed, ProjectAutomationMerged, ProjectAutomationReopened:
+		return true
+	}
+	return false
+}
+
+type ProjectColumn struct {
+	Id         int64
+	ProjectId  int64
+	Name       string
+	Position   int
+	Automation ProjectAutomation
+
+	// optionally, populated by GetProjectBoard
+	Cards []ProjectCard
+}
+
+// ProjectCard is an issue or pull placed on a project
+type ProjectCard struct {
+	Id        int64
+	ProjectId int64
+	ColumnId  int64
+	SubjectAt syntax.ATURI
+	Position  int
+	Created   time.Time
+
+	// details of the issue or pull, populated by GetProjectBoard
+	Number    int
+	Title     string
+	IssueOpen bool
+	PullState PullState
+}
+
+func (c ProjectCard) IsPull() bool {
+	return c.SubjectAt.Collection().String() == tangled.RepoPullNSID
+}
+
+// DefaultProjectColumns are the columns of a new project
+var DefaultProjectColumns = []ProjectColumn{
+	{Name: "to do"},
+	{Name: "in progress", Automation: ProjectAutomationReopened},
+	{Name: "done", Automation: ProjectAutomationClosed},
+}
+
+func AddProject(e Execer, project *Project) error {
+	var due *string
+	if project.Due != nil {
+		d := project.Due.Format(DueDateFormat)
+		due = &d
+	}
+
+	res, err := e.Exec(
+		`insert into projects (repo_at, name, description, due) values (?, ?, ?, ?)`,
+		project.RepoAt,
+		project.Name,
+		project.Description,
+		due,
+	)
+	if err != nil {
+		return err
+	}
+
+	project.Id, err = res.LastInsertId()
+	return err
+}
+
+func DeleteProject(e Execer, repoAt syntax.ATURI, projectId int64) error {
+	_, err := e.Exec(`delete from projects where repo_at = ? and id = ?`, repoAt, projectId)
+	return err
+}
+
+func GetProjects(e Execer, filters ...filter) ([]Project, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, repo_at, name, description, created, due
+		from projects`+whereClause+`
+		order by name`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var p Project
+		var created string
+		var due sql.NullString
+		if err := rows.Scan(&p.Id, &p.RepoAt, &p.Name, &p.Description, &created, &due); err != nil {
+			return nil, err
+		}
+
+		if due.Valid {
+			if d, err := time.Parse(DueDateFormat, due.String); err == nil {
+				p.Due = &d
+			}
+		}
+
+		p.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			p.Created = time.Now()
+		}
+
+		projects = append(projects, p)
+	}
+
+	return projects, rows.Err()
+}
+
+// GetProjectBoard fetches a project of a repo along with its columns and
+// cards, in order
+func GetProjectBoard(e Execer, repoAt syntax.ATURI, projectId int64) (*Project, error) {
+	projects, err := GetProjects(e, FilterEq("repo_at", repoAt), FilterEq("id", projectId))
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	project := projects[0]
+
+	project.Columns, err = GetProjectColumns(e, project.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := getProjectCards(e, repoAt, project.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range project.Columns {
+		for _, c := range cards {
+			if c.ColumnId == project.Columns[i].Id {
+				project.Columns[i].Cards = append(project.Columns[i].Cards, c)
+			}
+		}
+	}
+
+	return &project, nil
+}
+
+func AddProjectColumn(e Execer, column *ProjectColumn) error {
+	res, err := e.Exec(
+		`insert into project_columns (project_id, name, position, automation)
+		values (?, ?, (select coalesce(max(position), -1) + 1 from project_columns where project_id = ?), ?)`,
+		column.ProjectId,
+		column.Name,
+		column.ProjectId,
+		column.Automation,
+	)
+	if err != nil {
+		return err
+	}
+
+	column.Id, err = res.LastInsertId()
+	return err
+}
+
+func DeleteProjectColumn(e Execer, projectId, columnId int64) error {
+	_, err := e.Exec(`delete from project_columns where project_id = ? and id = ?`, projectId, columnId)
+	return err
+}
+
+func GetProjectColumns(e Execer, projectId int64) ([]ProjectColumn, error) {
+	rows, err := e.Query(
+		`select id, project_id, name, position, automation
+		from project_columns
+		where project_id = ?
+		order by position, id`,
+		projectId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []ProjectColumn
+	for rows.Next() {
+		var c ProjectColumn
+		if err := rows.Scan(&c.Id, &c.ProjectId, &c.Name, &c.Position, &c.Automation); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, rows.Err()
+}
+
+// AddProjectCard places an issue or pull at the bottom of a column
+func AddProjectCard(e Execer, card *ProjectCard) error {
+	res, err := e.Exec(
+		`insert into project_cards (project_id, column_id, subject_at, position)
+		values (?, ?, ?, (select coalesce(max(position), -1) + 1 from project_cards where column_id = ?))`,
+		card.ProjectId,
+		card.ColumnId,
+		card.SubjectAt,
+		card.ColumnId,
+	)
+	if err != nil {
+		return err
+	}
+
+	card.Id, err = res.LastInsertId()
+	return err
+}
+
+func DeleteProjectCard(e Execer, projectId, cardId int64) error {
+	_, err := e.Exec(`delete from project_cards where project_id = ? and id = ?`, projectId, cardId)
+	return err
+}
+
+// MoveProjectCard moves a card to the given position of a column, shifting
+// the cards below it down
+func MoveProjectCard(e Execer, projectId, cardId, columnId int64, position int) error {
+	_, err := e.Exec(
+		`update project_cards set position = position + 1
+		where column_id = ? and position >= ? and id <> ?`,
+		columnId,
+		position,
+		cardId,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec(
+		`update project_cards set column_id = ?, position = ?
+		where project_id = ? and id = ?
+		and exists (select 1 from project_columns where id = ? and project_id = ?)`,
+		columnId,
+		position,
+		projectId,
+		cardId,
+		columnId,
+		projectId,
+	)
+	return err
+}
+
+// getProjectCards fetches the cards of a project, along with the number,
+// title and state of their issues and pulls
+func getProjectCards(e Execer, repoAt syntax.ATURI, projectId int64) ([]ProjectCard, error) {
+	rows, err := e.Query(
+		`select
+			c.id, c.project_id, c.column_id, c.subject_at, c.position, c.created,
+			coalesce(i.issue_id, p.pull_id, 0),
+			coalesce(i.title, p.title, ''),
+			coalesce(i.open, 0),
+			coalesce(p.state, 0)
+		from project_cards c
+		left join issues i
+			on i.repo_at = ? and i.issue_at = c.subject_at
+		left join pulls p
+			on p.repo_at = ? and c.subject_at = 'at://' || p.owner_did || '/' || ? || '/' || p.rkey
+		where c.project_id = ?
+		order by c.position, c.id`,
+		repoAt,
+		repoAt,
+		tangled.RepoPullNSID,
+		projectId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []ProjectCard
+	for rows.Next() {
+		var c ProjectCard
+		var created string
+		if err := rows.Scan(
+			&c.Id, &c.ProjectId, &c.ColumnId, &c.SubjectAt, &c.Position, &created,
+			&c.Number, &c.Title, &c.IssueOpen, &c.PullState,
+		); err != nil {
+			return nil, err
+		}
+
+		c.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			c.Created = time.Now()
+		}
+
+		cards = append(cards, c)
+	}
+
+	return cards, rows.Err()
+}
+
+// applyProjectAutomation moves the cards of an issue or pull to the column
+// that automates the given event, on every project they are on. Merging a
+// pull falls back to the column that automates closing.
+func applyProjectAutomation(e Execer, subjectAt syntax.ATURI, kind ThreadEventKind) error {
+	var automations []ProjectAutomation
+	switch kind {
+	case ThreadEventClosed:
+		automations = []ProjectAutomation{ProjectAutomationClosed}
+	case ThreadEventReopened:
+		automations = []ProjectAutomation{ProjectAutomationReopened}
+	case ThreadEventMerged:
+		automations = []ProjectAutomation{ProjectAutomationMerged, ProjectAutomationClosed}
+	default:
+		return nil
+	}
+
+	rows, err := e.Query(`select id, project_id, column_id from project_cards where subject_at = ?`, subjectAt)
+	if err != nil {
+		return err
+	}
+
+	var cards []ProjectCard
+	for rows.Next() {
+		var c ProjectCard
+		if err := rows.Scan(&c.Id, &c.ProjectId, &c.ColumnId); err != nil {
+			rows.Close()
+			return err
+		}
+		cards = append(cards, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		columns, err := GetProjectColumns(e, card.ProjectId)
+		if err != nil {
+			return err
+		}
+
+		target := automationColumn(columns, automations)
+		if target == nil || target.Id == card.ColumnId {
+			continue
+		}
+
+		var last int
+		err = e.QueryRow(
+			`select coalesce(max(position), -1) + 1 from project_cards where column_id = ?`,
+			target.Id,
+		).Scan(&last)
+		if err != nil {
+			return err
+		}
+
+		err = MoveProjectCard(e, card.ProjectId, card.Id, target.Id, last)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// automationColumn is the first column automating the first of automations
+// that any column automates
+func automationColumn(columns []ProjectColumn, automations []ProjectAutomation) *ProjectColumn {
+	for _, a := range automations {
+		for i := range columns {
+			if columns[i].Automation == a {
+				return &columns[i]
+			}
+		}
+	}
+	return nil
+}
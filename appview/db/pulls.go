@@ -157,10 +157,26 @@ type PullComment struct {
 	// content
 	Body string
 
+	// where in the patch of its round an inline review comment is, empty
+	// and zero for other comments
+	Path string
+	Line int
+
 	// meta
 	Created time.Time
 }
 
+func (c *PullComment) IsInline() bool {
+	return c.Path != ""
+}
+
+// HasSuggestion tells whether an inline comment suggests a change to its
+// line
+func (c *PullComment) HasSuggestion() bool {
+	_, ok := patchutil.ParseSuggestion(c.Body)
+	return c.IsInline() && ok
+}
+
 func (p *Pull) LatestPatch() string {
 	latestSubmission := p.Submissions[p.LastRoundNumber()]
 	return latestSubmission.Patch
@@ -676,6 +692,8 @@ func GetPull(e Execer, repoAt syntax.ATURI, pullId int) (*Pull, error) {
 			owner_did,
 			comment_at,
 			body,
+			coalesce(path, ''),
+			coalesce(line, 0),
 			created
 		from
 			pull_comments
@@ -701,6 +719,8 @@ func GetPull(e Execer, repoAt syntax.ATURI, pullId int) (*Pull, error) {
 			&comment.OwnerDid,
 			&comment.CommentAt,
 			&comment.Body,
+			&comment.Path,
+			&comment.Line,
 			&commentCreatedStr,
 		)
 		if err != nil {
@@ -820,7 +840,12 @@ func GetPullsByOwnerDid(e Execer, did, timeframe string) ([]Pull, error) {
 }
 
 func NewPullComment(e Execer, comment *PullComment) (int64, error) {
-	query := `insert into pull_comments (owner_did, repo_at, submission_id, comment_at, pull_id, body) values (?, ?, ?, ?, ?, ?)`
+	var path, line any
+	if comment.IsInline() {
+		path, line = comment.Path, comment.Line
+	}
+
+	query := `insert into pull_comments (owner_did, repo_at, submission_id, comment_at, pull_id, body, path, line) values (?, ?, ?, ?, ?, ?, ?, ?)`
 	res, err := e.Exec(
 		query,
 		comment.OwnerDid,
@@ -829,6 +854,8 @@ func NewPullComment(e Execer, comment *PullComment) (int64, error) {
 		comment.CommentAt,
 		comment.PullId,
 		comment.Body,
+		path,
+		line,
 	)
 	if err != nil {
 		return 0, err
@@ -874,6 +901,11 @@ func DeletePull(e Execer, repoAt syntax.ATURI, pullId int) error {
 	return err
 }
 
+func SetPullBody(e Execer, repoAt syntax.ATURI, pullId int, body string) error {
+	_, err := e.Exec(`update pulls set body = ? where repo_at = ? and pull_id = ?`, body, repoAt, pullId)
+	return err
+}
+
 func ResubmitPull(e Execer, pull *Pull, newPatch, sourceRev string) error {
 	newRoundNumber := len(pull.Submissions)
 	_, err := e.Exec(`
@@ -939,27 +971,11 @@ type PullCount struct {
 }
 
 func GetPullCount(e Execer, repoAt syntax.ATURI) (PullCount, error) {
-	row := e.QueryRow(`
-		select
-			count(case when state = ? then 1 end) as open_count,
-			count(case when state = ? then 1 end) as merged_count,
-			count(case when state = ? then 1 end) as closed_count,
-			count(case when state = ? then 1 end) as deleted_count
-		from pulls
-		where repo_at = ?`,
-		PullOpen,
-		PullMerged,
-		PullClosed,
-		PullDeleted,
-		repoAt,
-	)
-
-	var count PullCount
-	if err := row.Scan(&count.Open, &count.Merged, &count.Closed, &count.Deleted); err != nil {
+	stats, err := getRepoStats(e, repoAt)
+	if err != nil {
 		return PullCount{0, 0, 0, 0}, err
 	}
-
-	return count, nil
+	return stats.PullCount, nil
 }
 
 type Stack []*Pull
@@ -114,9 +114,23 @@ func GetReactionCount(e Execer, threadAt syntax.ATURI, kind ReactionKind) (int,
 func GetReactionCountMap(e Execer, threadAt syntax.ATURI) (map[ReactionKind]int, error) {
 	countMap := map[ReactionKind]int{}
 	for _, kind := range OrderedReactionKinds {
-		count, err := GetReactionCount(e, threadAt, kind)
-		if err != nil {
-			return map[ReactionKind]int{}, nil
+		countMap[kind] = 0
+	}
+
+	rows, err := e.Query(
+		`select kind, count(reacted_by_did) from reactions where thread_at = ? group by kind`,
+		threadAt,
+	)
+	if err != nil {
+		return countMap, nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind ReactionKind
+		var count int
+		if err := rows.Scan(&kind, &count); err != nil {
+			return countMap, nil
 		}
 		countMap[kind] = count
 	}
@@ -134,8 +148,23 @@ func GetReactionStatus(e Execer, userDid string, threadAt syntax.ATURI, kind Rea
 func GetReactionStatusMap(e Execer, userDid string, threadAt syntax.ATURI) map[ReactionKind]bool {
 	statusMap := map[ReactionKind]bool{}
 	for _, kind := range OrderedReactionKinds {
-		count := GetReactionStatus(e, userDid, threadAt, kind)
-		statusMap[kind] = count
+		statusMap[kind] = false
+	}
+
+	rows, err := e.Query(
+		`select kind from reactions where reacted_by_did = ? and thread_at = ?`,
+		userDid, threadAt,
+	)
+	if err != nil {
+		return statusMap
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind ReactionKind
+		if err := rows.Scan(&kind); err == nil {
+			statusMap[kind] = true
+		}
 	}
 	return statusMap
 }
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+type RecentPush struct {
+	Did    string
+	RepoAt syntax.ATURI
+	Branch string
+	Pushed time.Time
+}
+
+// AddRecentPush notes that did pushed to branch just now
+func AddRecentPush(e Execer, did string, repoAt syntax.ATURI, branch string) error {
+	_, err := e.Exec(
+		`insert into recent_pushes (did, repo_at, branch)
+		values (?, ?, ?)
+		on conflict(did, repo_at, branch) do update set pushed = excluded.pushed`,
+		did, repoAt, branch,
+	)
+	return err
+}
+
+// GetRecentPushes returns the pushes of did to a repo since a given time,
+// newest first
+func GetRecentPushes(e Execer, did string, repoAt syntax.ATURI, since time.Time) ([]RecentPush, error) {
+	rows, err := e.Query(
+		`select branch, pushed from recent_pushes
+		where did = ? and repo_at = ? and pushed >= ?
+		order by pushed desc`,
+		did, repoAt, since.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pushes []RecentPush
+	for rows.Next() {
+		p := RecentPush{Did: did, RepoAt: repoAt}
+		var pushed string
+		if err := rows.Scan(&p.Branch, &pushed); err != nil {
+			return nil, err
+		}
+		if t, err := time.Parse(time.RFC3339, pushed); err == nil {
+			p.Pushed = t
+		}
+		pushes = append(pushes, p)
+	}
+
+	return pushes, rows.Err()
+}
+
+func DeleteRecentPushes(e Execer, filters ...filter) error {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	_, err := e.Exec(fmt.Sprintf(`delete from recent_pushes %s`, whereClause), args...)
+	return err
+}
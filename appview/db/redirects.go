@@ -0,0 +1,79 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// RenameRepo gives a repo a new name under the same owner, and leaves a
+// redirect at the old one. The at-uri of the repo stays the same.
+func RenameRepo(tx *sql.Tx, repo Repo, newName string) error {
+	_, err := tx.Exec(`update repos set name = ? where at_uri = ?`, newName, repo.RepoAt())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`update pipelines set repo_name = ? where knot = ? and repo_owner = ? and repo_name = ?`,
+		newName, repo.Knot, repo.Did, repo.Name,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`insert or replace into repo_redirects (did, name, repo_at) values (?, ?, ?)`,
+		repo.Did, repo.Name, repo.RepoAt(),
+	)
+	if err != nil {
+		return err
+	}
+
+	// the repo may be going back to a name it had before
+	_, err = tx.Exec(`delete from repo_redirects where did = ? and name = ?`, repo.Did, newName)
+	return err
+}
+
+// GetRepoRedirect returns the repo that used to be at did/name
+func GetRepoRedirect(e Execer, did, name string) (*Repo, error) {
+	var repoAt string
+	err := e.QueryRow(`select repo_at from repo_redirects where did = ? and name = ?`, did, name).Scan(&repoAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetRepoByAtUri(e, repoAt)
+}
+
+// AddHandleRedirect remembers that handle used to belong to did
+func AddHandleRedirect(e Execer, handle, did string) error {
+	_, err := e.Exec(
+		`insert or replace into handle_redirects (handle, did) values (?, ?)`,
+		handle, did,
+	)
+	return err
+}
+
+// GetHandleRedirect returns the did that used to go by handle
+func GetHandleRedirect(e Execer, handle string) (string, error) {
+	var did string
+	err := e.QueryRow(`select did from handle_redirects where handle = ?`, handle).Scan(&did)
+	return did, err
+}
+
+// IsKnownDid reports whether did has done anything on the appview that its
+// handle is shown for
+func IsKnownDid(e Execer, did string) (bool, error) {
+	var known bool
+	err := e.QueryRow(
+		`select exists (select 1 from repos where did = ?1)
+			or exists (select 1 from profile where did = ?1)
+			or exists (select 1 from public_keys where did = ?1)
+			or exists (select 1 from follows where user_did = ?1 or subject_did = ?1)
+			or exists (select 1 from stars where starred_by_did = ?1)
+			or exists (select 1 from issues where owner_did = ?1)
+			or exists (select 1 from comments where owner_did = ?1)
+			or exists (select 1 from pulls where owner_did = ?1)`,
+		did,
+	).Scan(&known)
+	return known, err
+}
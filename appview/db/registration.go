@@ -16,6 +16,10 @@ type Registration struct {
 	Created    *time.Time
 	Registered *time.Time
 	ReadOnly   bool
+
+	// who may create repos, and defaults for new repos
+	RepoCreate    string
+	DefaultBranch string
 }
 
 func (r *Registration) Status() Status {
@@ -64,7 +68,7 @@ func GetRegistrations(e Execer, filters ...filter) ([]Registration, error) {
 	}
 
 	query := fmt.Sprintf(`
-		select id, domain, did, created, registered, read_only
+		select id, domain, did, created, registered, read_only, repo_create, default_branch
 		from registrations
 		%s
 		order by created
@@ -83,7 +87,7 @@ func GetRegistrations(e Execer, filters ...filter) ([]Registration, error) {
 		var readOnly int
 		var reg Registration
 
-		err = rows.Scan(&reg.Id, &reg.Domain, &reg.ByDid, &createdAt, &registeredAt, &readOnly)
+		err = rows.Scan(&reg.Id, &reg.Domain, &reg.ByDid, &createdAt, &registeredAt, &readOnly, &reg.RepoCreate, &reg.DefaultBranch)
 		if err != nil {
 			return nil, err
 		}
@@ -133,6 +137,15 @@ func AddKnot(e Execer, domain, did string) error {
 	return err
 }
 
+func SetKnotPolicy(e Execer, domain, did, repoCreate, defaultBranch string) error {
+	_, err := e.Exec(`
+		update registrations
+		set repo_create = ?, default_branch = ?
+		where domain = ? and did = ?
+	`, repoCreate, defaultBranch, domain, did)
+	return err
+}
+
 func DeleteKnot(e Execer, filters ...filter) error {
 	var conditions []string
 	var args []any
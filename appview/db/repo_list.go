@@ -0,0 +1,171 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/appview/pagination"
+)
+
+// RepoSort is the order a list of repos is in
+type RepoSort string
+
+const (
+	// most recently pushed first, repos that were never pushed to count as
+	// updated when they were created
+	RepoSortUpdated RepoSort = "updated"
+	RepoSortStars   RepoSort = "stars"
+	RepoSortName    RepoSort = "name"
+)
+
+// RepoKind narrows a list of repos down to forks or to the repos that are not
+// forks, the empty kind lists both
+type RepoKind string
+
+const (
+	RepoKindSources RepoKind = "sources"
+	RepoKindForks   RepoKind = "forks"
+)
+
+type RepoListOptions struct {
+	Sort RepoSort
+	Kind RepoKind
+	// primary language of the repo
+	Language string
+}
+
+// primaryLanguage is the language most of the default branch of repo r is
+// written in
+const primaryLanguage = `(
+	select language from repo_languages l
+	where l.repo_at = r.at_uri and l.is_default_ref = 1
+	order by bytes desc
+	limit 1
+)`
+
+// ListRepos returns a page of the repos of did
+func ListRepos(e Execer, did string, opts RepoListOptions, page pagination.Page) ([]Repo, error) {
+	conditions := []string{"r.did = ?"}
+	args := []any{did}
+
+	switch opts.Kind {
+	case RepoKindSources:
+		conditions = append(conditions, "coalesce(r.source, '') = ''")
+	case RepoKindForks:
+		conditions = append(conditions, "coalesce(r.source, '') <> ''")
+	}
+	if opts.Language != "" {
+		conditions = append(conditions, primaryLanguage+" = ?")
+		args = append(args, opts.Language)
+	}
+
+	var order string
+	switch opts.Sort {
+	case RepoSortStars:
+		order = "coalesce(c.stars, 0) desc, coalesce(r.pushed, r.created) desc"
+	case RepoSortName:
+		order = "r.name collate nocase asc"
+	default:
+		order = "coalesce(r.pushed, r.created) desc"
+	}
+
+	query := fmt.Sprintf(
+		`select r.at_uri
+		from repos r
+		left join repo_counts c on c.repo = r.at_uri
+		where %s
+		order by %s
+		limit ? offset ?`,
+		strings.Join(conditions, " and "),
+		order,
+	)
+	args = append(args, page.Limit, page.Offset)
+
+	rows, err := e.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repos: %w", err)
+	}
+	defer rows.Close()
+
+	var repoAts []syntax.ATURI
+	for rows.Next() {
+		var repoAt syntax.ATURI
+		if err := rows.Scan(&repoAt); err != nil {
+			return nil, err
+		}
+		repoAts = append(repoAts, repoAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return getReposInOrder(e, repoAts)
+}
+
+// getReposInOrder gets the given repos, in the order they are given in
+func getReposInOrder(e Execer, repoAts []syntax.ATURI) ([]Repo, error) {
+	if len(repoAts) == 0 {
+		return nil, nil
+	}
+
+	repos, err := GetRepos(e, 0, FilterIn("at_uri", repoAts))
+	if err != nil {
+		return nil, err
+	}
+
+	// GetRepos has its own order, put them back into the one asked for
+	byAt := make(map[syntax.ATURI]Repo, len(repos))
+	for _, r := range repos {
+		byAt[r.RepoAt()] = r
+	}
+	ordered := make([]Repo, 0, len(repos))
+	for _, at := range repoAts {
+		if r, ok := byAt[at]; ok {
+			ordered = append(ordered, r)
+		}
+	}
+
+	return ordered, nil
+}
+
+// GetRepoLanguagesOf returns the primary languages of the repos of did, to
+// filter them by
+func GetRepoLanguagesOf(e Execer, did string) ([]string, error) {
+	rows, err := e.Query(
+		fmt.Sprintf(
+			`select distinct language from (
+				select %s as language from repos r where r.did = ?
+			)
+			where language is not null
+			order by language`,
+			primaryLanguage,
+		),
+		did,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var languages []string
+	for rows.Next() {
+		var language string
+		if err := rows.Scan(&language); err != nil {
+			return nil, err
+		}
+		languages = append(languages, language)
+	}
+
+	return languages, rows.Err()
+}
+
+// SetRepoPushed records that a repo was pushed to at t
+func SetRepoPushed(e Execer, did, name string, t time.Time) error {
+	_, err := e.Exec(
+		`update repos set pushed = ? where did = ? and name = ?`,
+		t.UTC().Format(time.RFC3339), did, name,
+	)
+	return err
+}
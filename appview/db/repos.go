@@ -22,6 +22,8 @@ type Repo struct {
 	Created     time.Time
 	Description string
 	Spindle     string
+	// last push to the repo, nil if it was never pushed to
+	Pushed *time.Time
 
 	// optionally, populate this when querying for reverse mappings
 	RepoStats *RepoStats
@@ -68,7 +70,8 @@ func GetRepos(e Execer, limit int, filters ...filter) ([]Repo, error) {
 			created,
 			description,
 			source,
-			spindle
+			spindle,
+			pushed
 		from
 			repos r
 		%s
@@ -86,7 +89,7 @@ func GetRepos(e Execer, limit int, filters ...filter) ([]Repo, error) {
 	for rows.Next() {
 		var repo Repo
 		var createdAt string
-		var description, source, spindle sql.NullString
+		var description, source, spindle, pushed sql.NullString
 
 		err := rows.Scan(
 			&repo.Did,
@@ -97,6 +100,7 @@ func GetRepos(e Execer, limit int, filters ...filter) ([]Repo, error) {
 			&description,
 			&source,
 			&spindle,
+			&pushed,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to execute repo query: %w ", err)
@@ -114,6 +118,11 @@ func GetRepos(e Execer, limit int, filters ...filter) ([]Repo, error) {
 		if spindle.Valid {
 			repo.Spindle = spindle.String
 		}
+		if pushed.Valid {
+			if t, err := time.Parse(time.RFC3339, pushed.String); err == nil {
+				repo.Pushed = &t
+			}
+		}
 
 		repo.RepoStats = &RepoStats{}
 		repoMap[repo.RepoAt()] = &repo
@@ -170,104 +179,20 @@ func GetRepos(e Execer, limit int, filters ...filter) ([]Repo, error) {
 		return nil, fmt.Errorf("failed to execute lang query: %w ", err)
 	}
 
-	starCountQuery := fmt.Sprintf(
-		`select
-			repo_at, count(1)
-		from stars
-		where repo_at in (%s)
-		group by repo_at`,
-		inClause,
-	)
-	rows, err = e.Query(starCountQuery, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute star-count query: %w ", err)
-	}
-	for rows.Next() {
-		var repoat string
-		var count int
-		if err := rows.Scan(&repoat, &count); err != nil {
-			log.Println("err", "err", err)
-			continue
-		}
-		if r, ok := repoMap[syntax.ATURI(repoat)]; ok {
-			r.RepoStats.StarCount = count
-		}
+	repoAts := make([]syntax.ATURI, 0, len(repoMap))
+	for at := range repoMap {
+		repoAts = append(repoAts, at)
 	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to execute star-count query: %w ", err)
-	}
-
-	issueCountQuery := fmt.Sprintf(
-		`select
-			repo_at,
-			count(case when open = 1 then 1 end) as open_count,
-			count(case when open = 0 then 1 end) as closed_count
-		from issues
-		where repo_at in (%s)
-		group by repo_at`,
-		inClause,
-	)
-	rows, err = e.Query(issueCountQuery, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute issue-count query: %w ", err)
-	}
-	for rows.Next() {
-		var repoat string
-		var open, closed int
-		if err := rows.Scan(&repoat, &open, &closed); err != nil {
-			log.Println("err", "err", err)
-			continue
-		}
-		if r, ok := repoMap[syntax.ATURI(repoat)]; ok {
-			r.RepoStats.IssueCount.Open = open
-			r.RepoStats.IssueCount.Closed = closed
-		}
-	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to execute issue-count query: %w ", err)
-	}
-
-	pullCountQuery := fmt.Sprintf(
-		`select
-			repo_at,
-			count(case when state = ? then 1 end) as open_count,
-			count(case when state = ? then 1 end) as merged_count,
-			count(case when state = ? then 1 end) as closed_count,
-			count(case when state = ? then 1 end) as deleted_count
-		from pulls
-		where repo_at in (%s)
-		group by repo_at`,
-		inClause,
-	)
-	args = append([]any{
-		PullOpen,
-		PullMerged,
-		PullClosed,
-		PullDeleted,
-	}, args...)
-	rows, err = e.Query(
-		pullCountQuery,
-		args...,
-	)
+	stats, err := GetRepoStats(e, repoAts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute pulls-count query: %w ", err)
+		return nil, fmt.Errorf("failed to execute repo-counts query: %w ", err)
 	}
-	for rows.Next() {
-		var repoat string
-		var open, merged, closed, deleted int
-		if err := rows.Scan(&repoat, &open, &merged, &closed, &deleted); err != nil {
-			log.Println("err", "err", err)
-			continue
+	for at, s := range stats {
+		if r, ok := repoMap[at]; ok {
+			r.RepoStats.StarCount = s.StarCount
+			r.RepoStats.IssueCount = s.IssueCount
+			r.RepoStats.PullCount = s.PullCount
 		}
-		if r, ok := repoMap[syntax.ATURI(repoat)]; ok {
-			r.RepoStats.PullCount.Open = open
-			r.RepoStats.PullCount.Merged = merged
-			r.RepoStats.PullCount.Closed = closed
-			r.RepoStats.PullCount.Deleted = deleted
-		}
-	}
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to execute pulls-count query: %w ", err)
 	}
 
 	var repos []Repo
@@ -320,16 +245,14 @@ func GetAllReposByDid(e Execer, did string) ([]Repo, error) {
 			r.rkey,
 			r.description,
 			r.created,
-			count(s.id) as star_count,
+			coalesce(c.stars, 0) as star_count,
 			r.source
 		from
 			repos r
 		left join
-			stars s on r.at_uri = s.repo_at
+			repo_counts c on r.at_uri = c.repo
 		where
 			r.did = ?
-		group by
-			r.at_uri
 		order by r.created desc`,
 		did)
 	if err != nil {
@@ -429,6 +352,52 @@ func GetRepoByAtUri(e Execer, atUri string) (*Repo, error) {
 	return &repo, nil
 }
 
+// GetReposByAtUris is GetRepoByAtUri for many repos at once, repos that do
+// not exist are left out of the map
+func GetReposByAtUris(e Execer, atUris []string) (map[string]*Repo, error) {
+	repos := make(map[string]*Repo)
+	if len(atUris) == 0 {
+		return repos, nil
+	}
+
+	inClause := strings.TrimSuffix(strings.Repeat("?, ", len(atUris)), ", ")
+	args := make([]any, len(atUris))
+	for i, a := range atUris {
+		args[i] = a
+	}
+
+	rows, err := e.Query(
+		fmt.Sprintf(
+			`select did, name, knot, created, rkey, description, source, spindle from repos where at_uri in (%s)`,
+			inClause,
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var repo Repo
+		var createdAt string
+		var description, source, spindle sql.NullString
+		err := rows.Scan(&repo.Did, &repo.Name, &repo.Knot, &createdAt, &repo.Rkey, &description, &source, &spindle)
+		if err != nil {
+			return nil, err
+		}
+
+		repo.Created, _ = time.Parse(time.RFC3339, createdAt)
+		repo.Description = description.String
+		repo.Source = source.String
+		repo.Spindle = spindle.String
+
+		repos[repo.RepoAt().String()] = &repo
+	}
+
+	return repos, rows.Err()
+}
+
 func AddRepo(e Execer, repo *Repo) error {
 	_, err := e.Exec(
 		`insert into repos
@@ -558,6 +527,7 @@ func UpdateSpindle(e Execer, repoAt string, spindle *string) error {
 type RepoStats struct {
 	Language   string
 	StarCount  int
+	ForkCount  int
 	IssueCount IssueCount
 	PullCount  PullCount
 }
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReviewThread is the inline review comments on one line of a round of a
+// pull
+type ReviewThread struct {
+	SubmissionId int
+	Round        int
+	Path         string
+	Line         int
+	Comments     []PullComment
+
+	// empty while unresolved
+	ResolvedBy string
+	Resolved   time.Time
+}
+
+func (t ReviewThread) IsResolved() bool {
+	return t.ResolvedBy != ""
+}
+
+// Id is unique within a pull, and safe to use in html ids
+func (t ReviewThread) Id() string {
+	return fmt.Sprintf("thread-%d", t.Comments[0].ID)
+}
+
+// Participants are the people who commented in the thread
+func (t ReviewThread) Participants() []string {
+	var dids []string
+	seen := make(map[string]bool)
+	for _, c := range t.Comments {
+		if !seen[c.OwnerDid] {
+			seen[c.OwnerDid] = true
+			dids = append(dids, c.OwnerDid)
+		}
+	}
+	return dids
+}
+
+// GetReviewThreads groups the inline comments of pull into threads, in the
+// order they were started
+func GetReviewThreads(e Execer, pull *Pull) ([]ReviewThread, error) {
+	type key struct {
+		submissionId int
+		path         string
+		line         int
+	}
+
+	var threads []ReviewThread
+	index := make(map[key]int)
+	var submissionIds []int
+	for _, s := range pull.Submissions {
+		submissionIds = append(submissionIds, s.ID)
+		for _, c := range s.Comments {
+			if !c.IsInline() {
+				continue
+			}
+			k := key{s.ID, c.Path, c.Line}
+			i, ok := index[k]
+			if !ok {
+				i = len(threads)
+				index[k] = i
+				threads = append(threads, ReviewThread{
+					SubmissionId: s.ID,
+					Round:        s.RoundNumber,
+					Path:         c.Path,
+					Line:         c.Line,
+				})
+			}
+			threads[i].Comments = append(threads[i].Comments, c)
+		}
+	}
+	if len(threads) == 0 {
+		return nil, nil
+	}
+
+	filter := FilterIn("submission_id", submissionIds)
+	rows, err := e.Query(
+		`select submission_id, path, line, resolved_by, created
+		from pull_review_resolutions
+		where `+filter.Condition(),
+		filter.Arg()...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k key
+		var resolvedBy, created string
+		if err := rows.Scan(&k.submissionId, &k.path, &k.line, &resolvedBy, &created); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[k]
+		if !ok {
+			continue
+		}
+		threads[i].ResolvedBy = resolvedBy
+		threads[i].Resolved, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			threads[i].Resolved = time.Now()
+		}
+	}
+
+	return threads, rows.Err()
+}
+
+// UnresolvedThreads counts the threads nobody resolved yet
+func UnresolvedThreads(threads []ReviewThread) int {
+	var n int
+	for _, t := range threads {
+		if !t.IsResolved() {
+			n += 1
+		}
+	}
+	return n
+}
+
+func ResolveReviewThread(e Execer, submissionId int, path string, line int, did string) error {
+	_, err := e.Exec(
+		`insert into pull_review_resolutions (submission_id, path, line, resolved_by)
+		values (?, ?, ?, ?)
+		on conflict(submission_id, path, line) do nothing`,
+		submissionId, path, line, did,
+	)
+	return err
+}
+
+func UnresolveReviewThread(e Execer, submissionId int, path string, line int) error {
+	_, err := e.Exec(
+		`delete from pull_review_resolutions where submission_id = ? and path = ? and line = ?`,
+		submissionId, path, line,
+	)
+	return err
+}
+
+// ThreadsByPath groups the threads of a round by file, for the diff view
+func ThreadsByPath(threads []ReviewThread, round int) map[string][]ReviewThread {
+	byPath := make(map[string][]ReviewThread)
+	for _, t := range threads {
+		if t.Round == round {
+			byPath[t.Path] = append(byPath[t.Path], t)
+		}
+	}
+	return byPath
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/api/tangled"
+)
+
+type ReviewState string
+
+const (
+	ReviewApproved         ReviewState = "approved"
+	ReviewChangesRequested ReviewState = "changesRequested"
+	ReviewCommented        ReviewState = "commented"
+)
+
+func (s ReviewState) IsValid() bool {
+	switch s {
+	case ReviewApproved, ReviewChangesRequested, ReviewCommented:
+		return true
+	}
+	return false
+}
+
+// PullReview is a verdict on a pull, optionally on one round of it
+type PullReview struct {
+	OwnerDid string
+	Rkey     string
+	RepoAt   syntax.ATURI
+	PullId   int
+	Round    *int
+	State    ReviewState
+	Body     string
+	Created  time.Time
+}
+
+func (r *PullReview) ReviewAt() syntax.ATURI {
+	return syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", r.OwnerDid, tangled.RepoPullReviewNSID, r.Rkey))
+}
+
+func AddPullReview(e Execer, review PullReview) error {
+	_, err := e.Exec(
+		`insert into pull_reviews (owner_did, rkey, repo_at, pull_id, round, state, body, created)
+		values (?, ?, ?, ?, ?, ?, ?, ?)
+		on conflict(owner_did, rkey) do update set
+			round = excluded.round,
+			state = excluded.state,
+			body = excluded.body`,
+		review.OwnerDid,
+		review.Rkey,
+		review.RepoAt,
+		review.PullId,
+		review.Round,
+		review.State,
+		review.Body,
+		review.Created.UTC().Format(time.RFC3339),
+	)
+	return err
+}
+
+func DeletePullReviewByRkey(e Execer, ownerDid, rkey string) error {
+	_, err := e.Exec(`delete from pull_reviews where owner_did = ? and rkey = ?`, ownerDid, rkey)
+	return err
+}
+
+func GetPullReviews(e Execer, filters ...filter) ([]PullReview, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select owner_did, rkey, repo_at, pull_id, round, state, body, created
+		from pull_reviews`+whereClause+`
+		order by created`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []PullReview
+	for rows.Next() {
+		var r PullReview
+		var round sql.NullInt64
+		var created string
+		if err := rows.Scan(&r.OwnerDid, &r.Rkey, &r.RepoAt, &r.PullId, &round, &r.State, &r.Body, &created); err != nil {
+			return nil, err
+		}
+
+		if round.Valid {
+			n := int(round.Int64)
+			r.Round = &n
+		}
+
+		r.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			r.Created = time.Now()
+		}
+
+		reviews = append(reviews, r)
+	}
+
+	return reviews, rows.Err()
+}
+
+// ResolvePullFromAtUri finds the repo and id of a pull by its record
+func ResolvePullFromAtUri(e Execer, pullUri syntax.ATURI) (syntax.ATURI, int, error) {
+	var repoAt string
+	var pullId int
+
+	err := e.QueryRow(
+		`select repo_at, pull_id from pulls where owner_did = ? and rkey = ?`,
+		pullUri.Authority().String(),
+		pullUri.RecordKey().String(),
+	).Scan(&repoAt, &pullId)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return syntax.ATURI(repoAt), pullId, nil
+}
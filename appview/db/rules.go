@@ -0,0 +1,266 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// RuleEvent is what sets off a rule
+type RuleEvent string
+
+const (
+	RuleEventIssueOpened  RuleEvent = "issue.opened"
+	RuleEventIssueLabeled RuleEvent = "issue.labeled"
+	RuleEventIssueStale   RuleEvent = "issue.stale"
+	RuleEventPullOpened   RuleEvent = "pull.opened"
+	RuleEventPullLabeled  RuleEvent = "pull.labeled"
+)
+
+var RuleEvents = []RuleEvent{
+	RuleEventIssueOpened,
+	RuleEventIssueLabeled,
+	RuleEventIssueStale,
+	RuleEventPullOpened,
+	RuleEventPullLabeled,
+}
+
+func (e RuleEvent) IsValid() bool {
+	for _, ev := range RuleEvents {
+		if e == ev {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleAction is what a rule does to the issue or pull that set it off
+type RuleAction string
+
+const (
+	RuleActionLabel   RuleAction = "label"
+	RuleActionComment RuleAction = "comment"
+	RuleActionClose   RuleAction = "close"
+)
+
+var RuleActions = []RuleAction{
+	RuleActionLabel,
+	RuleActionComment,
+	RuleActionClose,
+}
+
+func (a RuleAction) IsValid() bool {
+	for _, ac := range RuleActions {
+		if a == ac {
+			return true
+		}
+	}
+	return false
+}
+
+type RuleSource string
+
+const (
+	RuleSourceSettings RuleSource = "settings"
+	RuleSourceFile     RuleSource = "file"
+)
+
+// RulesActor is who labels, comments and closes on behalf of rules. Like
+// bots, it has no PDS and no account.
+const RulesActor = BotPrefix + "rules"
+
+// Rule is an automation of a repo, such as "when an issue is labeled bug,
+// comment" or "close issues without activity for 60 days"
+type Rule struct {
+	Id     int64
+	RepoAt syntax.ATURI
+	Source RuleSource
+	Event  RuleEvent
+	// only for labeled events, the label that was applied
+	Label string
+	// only for stale events, the days without activity
+	Days   int
+	Action RuleAction
+	// the label to add, or the body of the comment to post
+	Value   string
+	Created time.Time
+}
+
+func (r Rule) Validate() error {
+	if !r.Event.IsValid() {
+		return fmt.Errorf("unknown event %q", r.Event)
+	}
+	if !r.Action.IsValid() {
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+
+	switch r.Event {
+	case RuleEventIssueLabeled, RuleEventPullLabeled:
+		if r.Label == "" {
+			return fmt.Errorf("%s rules need a label", r.Event)
+		}
+	case RuleEventIssueStale:
+		if r.Days <= 0 {
+			return fmt.Errorf("%s rules need a number of days", r.Event)
+		}
+	}
+
+	switch r.Action {
+	case RuleActionLabel:
+		if r.Value == "" {
+			return fmt.Errorf("label actions need a label to add")
+		}
+	case RuleActionComment:
+		if r.Value == "" {
+			return fmt.Errorf("comment actions need a comment to post")
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether the rule is set off by event, label is the one
+// applied for labeled events
+func (r Rule) Matches(event RuleEvent, label string) bool {
+	if r.Event != event {
+		return false
+	}
+	if event == RuleEventIssueLabeled || event == RuleEventPullLabeled {
+		return r.Label == label
+	}
+	return true
+}
+
+func AddRule(e Execer, rule *Rule) error {
+	if rule.Source == "" {
+		rule.Source = RuleSourceSettings
+	}
+
+	res, err := e.Exec(
+		`insert into repo_rules (repo_at, source, event, label, days, action, value)
+		values (?, ?, ?, ?, ?, ?, ?)`,
+		rule.RepoAt,
+		rule.Source,
+		rule.Event,
+		rule.Label,
+		rule.Days,
+		rule.Action,
+		rule.Value,
+	)
+	if err != nil {
+		return err
+	}
+
+	rule.Id, err = res.LastInsertId()
+	return err
+}
+
+// DeleteRule removes a rule added in the settings of a repo, the ones from
+// the rules file go away with the file
+func DeleteRule(e Execer, repoAt syntax.ATURI, id int64) error {
+	_, err := e.Exec(
+		`delete from repo_rules where repo_at = ? and id = ? and source = ?`,
+		repoAt,
+		id,
+		RuleSourceSettings,
+	)
+	return err
+}
+
+// SetFileRules replaces the rules read from the rules file of a repo
+func SetFileRules(e Execer, repoAt syntax.ATURI, rules []Rule) error {
+	_, err := e.Exec(`delete from repo_rules where repo_at = ? and source = ?`, repoAt, RuleSourceFile)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		rule.RepoAt = repoAt
+		rule.Source = RuleSourceFile
+		if err := AddRule(e, &rule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GetRules(e Execer, filters ...filter) ([]Rule, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, repo_at, source, event, label, days, action, value, created
+		from repo_rules`+whereClause+`
+		order by id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var r Rule
+		var created string
+		if err := rows.Scan(&r.Id, &r.RepoAt, &r.Source, &r.Event, &r.Label, &r.Days, &r.Action, &r.Value, &created); err != nil {
+			return nil, err
+		}
+
+		r.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			r.Created = time.Now()
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+// GetStaleIssueIds returns the open issues of a repo that were neither
+// commented on nor changed since before
+func GetStaleIssueIds(e Execer, repoAt syntax.ATURI, before time.Time) ([]int, error) {
+	rows, err := e.Query(
+		`select i.issue_id
+		from issues i
+		where i.repo_at = ?
+			and i.open = 1
+			and i.hidden is null
+			and max(
+				i.created,
+				coalesce((select max(c.created) from comments c where c.repo_at = i.repo_at and c.issue_id = i.issue_id), ''),
+				coalesce((select max(t.created) from thread_events t where t.subject_at = i.issue_at), '')
+			) < ?
+		order by i.issue_id`,
+		repoAt,
+		before.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// SearchOptions narrows down a search of repos or issues, every field that is
+// set has to match
+type SearchOptions struct {
+	Terms []string
+	// owners of the repos, or of the repos the issues are in
+	Dids  []string
+	Knots []string
+	// primary languages, in lower case
+	Languages []string
+	Fork      *bool
+	// only applies to issues
+	Open *bool
+}
+
+// scope filters the repos r that are searched in
+func (o SearchOptions) scope() []filter {
+	var filters []filter
+	if len(o.Dids) > 0 {
+		filters = append(filters, FilterIn("r.did", o.Dids))
+	}
+	if len(o.Knots) > 0 {
+		filters = append(filters, FilterIn("lower(r.knot)", o.Knots))
+	}
+	if len(o.Languages) > 0 {
+		filters = append(filters, FilterIn("lower("+primaryLanguage+")", o.Languages))
+	}
+	if o.Fork != nil {
+		if *o.Fork {
+			filters = append(filters, FilterNotEq("coalesce(r.source, '')", ""))
+		} else {
+			filters = append(filters, FilterEq("coalesce(r.source, '')", ""))
+		}
+	}
+	return filters
+}
+
+// SearchRepos finds repos whose name, description or topics contain every
+// term, most starred first
+func SearchRepos(e Execer, opts SearchOptions, limit int) ([]Repo, error) {
+	filters := opts.scope()
+	for _, term := range opts.Terms {
+		filters = append(filters, FilterLike("(r.name || ' ' || coalesce(r.description, '') || ' ' || r.topics)", "%"+term+"%"))
+	}
+
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		fmt.Sprintf(
+			`select r.at_uri
+			from repos r
+			left join repo_counts c on c.repo = r.at_uri
+			%s
+			order by coalesce(c.stars, 0) desc, coalesce(r.pushed, r.created) desc
+			limit %d`,
+			whereClause,
+			limit,
+		),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search repos: %w", err)
+	}
+	defer rows.Close()
+
+	var repoAts []syntax.ATURI
+	for rows.Next() {
+		var repoAt syntax.ATURI
+		if err := rows.Scan(&repoAt); err != nil {
+			return nil, err
+		}
+		repoAts = append(repoAts, repoAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return getReposInOrder(e, repoAts)
+}
+
+// SearchIssues finds issues whose title or body contain every term, newest
+// first. Hidden issues are never found.
+func SearchIssues(e Execer, opts SearchOptions, limit int) ([]Issue, error) {
+	filters := opts.scope()
+	filters = append(filters, FilterIs("i.hidden", nil))
+	if opts.Open != nil {
+		filters = append(filters, FilterEq("i.open", *opts.Open))
+	}
+
+	if len(opts.Terms) > 0 {
+		// every term is quoted, so that nothing in it is taken as query
+		// syntax
+		terms := make([]string, len(opts.Terms))
+		for i, term := range opts.Terms {
+			terms[i] = `"` + strings.ReplaceAll(term, `"`, "") + `"`
+		}
+
+		rows, err := e.Query(`select docid from issues_fts where issues_fts match ?`, strings.Join(terms, " "))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search issues: %w", err)
+		}
+		defer rows.Close()
+
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, FilterIn("i.id", ids))
+	}
+
+	return GetTriageIssues(e, limit, filters...)
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ShareSettings are a user's defaults for announcing activity on bluesky.
+// They only decide whether the checkbox starts out ticked, the user can
+// always opt in or out per action.
+type ShareSettings struct {
+	Did              string
+	AnnounceRepos    bool
+	AnnounceReleases bool
+}
+
+// GetShareSettings returns the user's settings, or the defaults (everything
+// off) if they never changed them
+func GetShareSettings(e Execer, did string) (ShareSettings, error) {
+	settings := ShareSettings{Did: did}
+
+	var repos, releases int
+	err := e.QueryRow(
+		`select announce_repos, announce_releases from share_settings where did = ?`,
+		did,
+	).Scan(&repos, &releases)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+
+	settings.AnnounceRepos = repos != 0
+	settings.AnnounceReleases = releases != 0
+	return settings, nil
+}
+
+func SetShareSettings(e Execer, settings ShareSettings) error {
+	_, err := e.Exec(
+		`insert into share_settings (did, announce_repos, announce_releases)
+		values (?, ?, ?)
+		on conflict(did) do update set
+			announce_repos = excluded.announce_repos,
+			announce_releases = excluded.announce_releases`,
+		settings.Did,
+		settings.AnnounceRepos,
+		settings.AnnounceReleases,
+	)
+	return err
+}
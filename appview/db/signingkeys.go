@@ -0,0 +1,91 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	SigningKeySSH = "ssh"
+	SigningKeyGPG = "gpg"
+)
+
+// SigningKey is a key that a user signs commits with. Keys used to push over
+// ssh are accepted for signatures too, these are only needed for keys that
+// are not also used for pushing.
+type SigningKey struct {
+	Did       string
+	Rkey      string
+	Name      string
+	Kind      string
+	Key       string
+	Published bool
+	Created   time.Time
+}
+
+func AddSigningKey(e Execer, key SigningKey) error {
+	_, err := e.Exec(
+		`insert into signing_keys (did, rkey, name, kind, key, published)
+		values (?, ?, ?, ?, ?, ?)
+		on conflict(did, rkey) do update set
+			name = excluded.name,
+			kind = excluded.kind,
+			key = excluded.key,
+			published = excluded.published`,
+		key.Did,
+		key.Rkey,
+		key.Name,
+		key.Kind,
+		key.Key,
+		key.Published,
+	)
+	return err
+}
+
+func DeleteSigningKeyByRkey(e Execer, did, rkey string) error {
+	_, err := e.Exec(`delete from signing_keys where did = ? and rkey = ?`, did, rkey)
+	return err
+}
+
+func GetSigningKeys(e Execer, filters ...filter) ([]SigningKey, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select did, rkey, name, kind, key, published, created
+		from signing_keys`+whereClause+`
+		order by created`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []SigningKey
+	for rows.Next() {
+		var k SigningKey
+		var created string
+		if err := rows.Scan(&k.Did, &k.Rkey, &k.Name, &k.Kind, &k.Key, &k.Published, &created); err != nil {
+			return nil, err
+		}
+
+		k.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			k.Created = time.Now()
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
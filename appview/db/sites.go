@@ -0,0 +1,88 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// Site is a static site published from a directory on a branch of a repo
+type Site struct {
+	RepoAt syntax.ATURI
+	Branch string
+	Dir    string
+
+	DeployedCommit string
+	DeployedAt     *time.Time
+	Created        time.Time
+}
+
+func GetSite(e Execer, repoAt syntax.ATURI) (*Site, error) {
+	var site Site
+	var deployedCommit, deployedAt sql.NullString
+	var created string
+
+	err := e.QueryRow(
+		`select repo_at, branch, dir, deployed_commit, deployed_at, created
+		from repo_sites
+		where repo_at = ?`,
+		repoAt,
+	).Scan(&site.RepoAt, &site.Branch, &site.Dir, &deployedCommit, &deployedAt, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	site.Created, err = time.Parse(time.RFC3339, created)
+	if err != nil {
+		site.Created = time.Now()
+	}
+
+	if deployedCommit.Valid {
+		site.DeployedCommit = deployedCommit.String
+	}
+
+	if deployedAt.Valid {
+		t, err := time.Parse(time.RFC3339, deployedAt.String)
+		if err == nil {
+			site.DeployedAt = &t
+		}
+	}
+
+	return &site, nil
+}
+
+// SetSite enables publishing for a repo, or changes where it is published
+// from. The deployed commit is reset along with it.
+func SetSite(e Execer, site Site) error {
+	_, err := e.Exec(
+		`insert into repo_sites (repo_at, branch, dir, deployed_commit, deployed_at)
+		values (?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		on conflict(repo_at) do update set
+			branch = excluded.branch,
+			dir = excluded.dir,
+			deployed_commit = excluded.deployed_commit,
+			deployed_at = excluded.deployed_at`,
+		site.RepoAt,
+		site.Branch,
+		site.Dir,
+		site.DeployedCommit,
+	)
+	return err
+}
+
+func DeploySite(e Execer, repoAt syntax.ATURI, commit string) error {
+	_, err := e.Exec(
+		`update repo_sites
+		set deployed_commit = ?, deployed_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		where repo_at = ?`,
+		commit,
+		repoAt,
+	)
+	return err
+}
+
+func DeleteSite(e Execer, repoAt syntax.ATURI) error {
+	_, err := e.Exec(`delete from repo_sites where repo_at = ?`, repoAt)
+	return err
+}
@@ -0,0 +1,191 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// GetFirstActivity is when the appview first saw did create something, nil
+// if it never did. Hidden issues and comments do not count.
+func GetFirstActivity(e Execer, did string) (*time.Time, error) {
+	var first sql.NullString
+	err := e.QueryRow(
+		`select min(created) from (
+			select created from issues where owner_did = ? and hidden is null
+			union all
+			select created from comments where owner_did = ? and hidden is null
+			union all
+			select created from repos where did = ?
+			union all
+			select created from pulls where owner_did = ?
+		)`,
+		did, did, did, did,
+	).Scan(&first)
+	if err != nil || !first.Valid {
+		return nil, err
+	}
+
+	t, err := time.Parse(time.RFC3339, first.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CountRecentActivity counts the issues and comments did created since
+func CountRecentActivity(e Execer, did string, since time.Time) (int, error) {
+	var count int
+	err := e.QueryRow(
+		`select
+			(select count(1) from issues where owner_did = ? and created >= ?) +
+			(select count(1) from comments where owner_did = ? and created >= ?)`,
+		did, since.UTC().Format(time.RFC3339),
+		did, since.UTC().Format(time.RFC3339),
+	).Scan(&count)
+	return count, err
+}
+
+// IsIssueIndexed tells whether the record of an issue has been indexed
+// already, records written by the appview are seen again on the firehose
+func IsIssueIndexed(e Execer, ownerDid, rkey string) (bool, error) {
+	var exists bool
+	err := e.QueryRow(
+		`select exists (select 1 from issues where owner_did = ? and rkey = ?)`,
+		ownerDid, rkey,
+	).Scan(&exists)
+	return exists, err
+}
+
+// IsCommentIndexed is IsIssueIndexed for issue comments
+func IsCommentIndexed(e Execer, ownerDid, rkey string) (bool, error) {
+	var exists bool
+	err := e.QueryRow(
+		`select exists (select 1 from comments where owner_did = ? and rkey = ?)`,
+		ownerDid, rkey,
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetHiddenIssues fetches the issues of a repo hidden by the spam filter
+func GetHiddenIssues(e Execer, repoAt syntax.ATURI) ([]Issue, error) {
+	rows, err := e.Query(
+		`select id, owner_did, rkey, issue_id, created, title, body, open, hidden
+		from issues
+		where repo_at = ? and hidden is not null
+		order by created desc`,
+		repoAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []Issue
+	for rows.Next() {
+		issue := Issue{RepoAt: repoAt}
+		var createdAt string
+		err := rows.Scan(&issue.ID, &issue.OwnerDid, &issue.Rkey, &issue.IssueId, &createdAt, &issue.Title, &issue.Body, &issue.Open, &issue.Hidden)
+		if err != nil {
+			return nil, err
+		}
+
+		issue.Created, err = time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			issue.Created = time.Now()
+		}
+
+		issues = append(issues, issue)
+	}
+
+	return issues, rows.Err()
+}
+
+// GetHiddenComments fetches the comments on issues of a repo hidden by the
+// spam filter, comments deleted since are left out
+func GetHiddenComments(e Execer, repoAt syntax.ATURI) ([]Comment, error) {
+	rows, err := e.Query(
+		`select owner_did, issue_id, comment_id, coalesce(rkey, ''), body, created, hidden
+		from comments
+		where repo_at = ? and hidden is not null and deleted is null
+		order by created desc`,
+		repoAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		comment := Comment{RepoAt: repoAt}
+		var createdAt string
+		err := rows.Scan(&comment.OwnerDid, &comment.Issue, &comment.CommentId, &comment.Rkey, &comment.Body, &createdAt, &comment.Hidden)
+		if err != nil {
+			return nil, err
+		}
+
+		created, err := time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			created = time.Now()
+		}
+		comment.Created = &created
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// ShowIssue approves an issue hidden by the spam filter
+func ShowIssue(e Execer, repoAt syntax.ATURI, issueId int) error {
+	_, err := e.Exec(`update issues set hidden = null where repo_at = ? and issue_id = ?`, repoAt, issueId)
+	return err
+}
+
+// ShowComment approves a comment hidden by the spam filter
+func ShowComment(e Execer, repoAt syntax.ATURI, issueId, commentId int) error {
+	_, err := e.Exec(
+		`update comments set hidden = null where repo_at = ? and issue_id = ? and comment_id = ?`,
+		repoAt, issueId, commentId,
+	)
+	return err
+}
+
+// DeleteHiddenIssue removes an issue hidden by the spam filter from the
+// appview, along with its comments
+func DeleteHiddenIssue(e Execer, repoAt syntax.ATURI, issueId int) error {
+	_, err := e.Exec(
+		`delete from issues where repo_at = ? and issue_id = ? and hidden is not null`,
+		repoAt, issueId,
+	)
+	return err
+}
+
+// CountRecentComments counts the issue and pull comments did made since
+func CountRecentComments(e Execer, did string, since time.Time) (int, error) {
+	var count int
+	err := e.QueryRow(
+		`select
+			(select count(1) from comments where owner_did = ? and created >= ?) +
+			(select count(1) from pull_comments where owner_did = ? and created >= ?)`,
+		did, since.UTC().Format(time.RFC3339),
+		did, since.UTC().Format(time.RFC3339),
+	).Scan(&count)
+	return count, err
+}
+
+// CountRecentRepos counts the repos did created since
+func CountRecentRepos(e Execer, did string, since time.Time) (int, error) {
+	var count int
+	err := e.QueryRow(
+		`select count(1) from repos where did = ? and created >= ?`,
+		did, since.UTC().Format(time.RFC3339),
+	).Scan(&count)
+	return count, err
+}
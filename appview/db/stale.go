@@ -0,0 +1,186 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/api/tangled"
+)
+
+// StaleSettings is how a repo has issues, and optionally pulls, triaged
+// once nobody touches them anymore: they are warned about and labeled after
+// Days, and closed Grace days later
+type StaleSettings struct {
+	RepoAt  syntax.ATURI
+	Days    int
+	Grace   int
+	Label   string
+	Comment string
+	// items with any of these labels never go stale
+	Exempt []string
+	Pulls  bool
+}
+
+func (s StaleSettings) Validate() error {
+	if s.Days <= 0 {
+		return fmt.Errorf("the days without activity have to be at least one")
+	}
+	if s.Grace <= 0 {
+		return fmt.Errorf("the grace period has to be at least one day")
+	}
+	if s.Label == "" {
+		return fmt.Errorf("stale items need a label")
+	}
+	return nil
+}
+
+// IsExempt reports whether any of labels keeps an item from going stale
+func (s StaleSettings) IsExempt(labels []Label) bool {
+	for _, l := range labels {
+		if slices.Contains(s.Exempt, l.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func SetStaleSettings(e Execer, s StaleSettings) error {
+	_, err := e.Exec(
+		`insert into repo_stale_settings (repo_at, days, grace, label, comment, exempt, pulls)
+		values (?, ?, ?, ?, ?, ?, ?)
+		on conflict(repo_at) do update set
+			days = excluded.days,
+			grace = excluded.grace,
+			label = excluded.label,
+			comment = excluded.comment,
+			exempt = excluded.exempt,
+			pulls = excluded.pulls`,
+		s.RepoAt,
+		s.Days,
+		s.Grace,
+		s.Label,
+		s.Comment,
+		strings.Join(s.Exempt, ","),
+		s.Pulls,
+	)
+	return err
+}
+
+func DeleteStaleSettings(e Execer, repoAt syntax.ATURI) error {
+	_, err := e.Exec(`delete from repo_stale_settings where repo_at = ?`, repoAt)
+	return err
+}
+
+func GetStaleSettings(e Execer, filters ...filter) ([]StaleSettings, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select repo_at, days, grace, label, comment, exempt, pulls
+		from repo_stale_settings`+whereClause,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []StaleSettings
+	for rows.Next() {
+		var s StaleSettings
+		var exempt string
+		if err := rows.Scan(&s.RepoAt, &s.Days, &s.Grace, &s.Label, &s.Comment, &exempt, &s.Pulls); err != nil {
+			return nil, err
+		}
+
+		if exempt != "" {
+			s.Exempt = strings.Split(exempt, ",")
+		}
+
+		all = append(all, s)
+	}
+
+	return all, rows.Err()
+}
+
+// Activity is when an open issue or pull was last changed or commented on.
+// What rules do is not activity.
+type Activity struct {
+	SubjectAt syntax.ATURI
+	// the issue or pull id
+	Id   int
+	Last time.Time
+}
+
+func GetOpenIssueActivity(e Execer, repoAt syntax.ATURI) ([]Activity, error) {
+	return getActivity(e, tangled.RepoIssueNSID,
+		`select i.owner_did, i.rkey, i.issue_id, max(
+			i.created,
+			coalesce((select max(c.created) from comments c
+				where c.repo_at = i.repo_at and c.issue_id = i.issue_id and c.owner_did != ?), ''),
+			coalesce((select max(t.created) from thread_events t
+				where t.subject_at = 'at://' || i.owner_did || '/' || ? || '/' || i.rkey and t.actor_did != ?), '')
+		)
+		from issues i
+		where i.repo_at = ? and i.open = 1 and i.hidden is null
+		order by i.issue_id`,
+		RulesActor, tangled.RepoIssueNSID, RulesActor, repoAt,
+	)
+}
+
+func GetOpenPullActivity(e Execer, repoAt syntax.ATURI) ([]Activity, error) {
+	return getActivity(e, tangled.RepoPullNSID,
+		`select p.owner_did, p.rkey, p.pull_id, max(
+			p.created,
+			coalesce((select max(s.created) from pull_submissions s
+				where s.repo_at = p.repo_at and s.pull_id = p.pull_id), ''),
+			coalesce((select max(c.created) from pull_comments c
+				where c.repo_at = p.repo_at and c.pull_id = p.pull_id and c.owner_did != ?), ''),
+			coalesce((select max(t.created) from thread_events t
+				where t.subject_at = 'at://' || p.owner_did || '/' || ? || '/' || p.rkey and t.actor_did != ?), '')
+		)
+		from pulls p
+		where p.repo_at = ? and p.state = ?
+		order by p.pull_id`,
+		RulesActor, tangled.RepoPullNSID, RulesActor, repoAt, PullOpen,
+	)
+}
+
+func getActivity(e Execer, collection, query string, args ...any) ([]Activity, error) {
+	rows, err := e.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Activity
+	for rows.Next() {
+		var a Activity
+		var did, rkey, last string
+		if err := rows.Scan(&did, &rkey, &a.Id, &last); err != nil {
+			return nil, err
+		}
+
+		a.SubjectAt = syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", did, collection, rkey))
+		a.Last, err = time.Parse(time.RFC3339, last)
+		if err != nil {
+			a.Last = time.Now()
+		}
+
+		all = append(all, a)
+	}
+
+	return all, rows.Err()
+}
@@ -85,13 +85,11 @@ func DeleteStarByRkey(e Execer, starredByDid string, rkey string) error {
 }
 
 func GetStarCount(e Execer, repoAt syntax.ATURI) (int, error) {
-	stars := 0
-	err := e.QueryRow(
-		`select count(starred_by_did) from stars where repo_at = ?`, repoAt).Scan(&stars)
+	stats, err := getRepoStats(e, repoAt)
 	if err != nil {
 		return 0, err
 	}
-	return stars, nil
+	return stats.StarCount, nil
 }
 
 func GetStarStatus(e Execer, userDid string, repoAt syntax.ATURI) bool {
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// MaxTopics is how many topics a repo can be tagged with
+const MaxTopics = 10
+
+var topicRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,34}$`)
+
+// ParseTopics splits a comma or space separated list of topics, and checks
+// that each is a short lowercase word
+func ParseTopics(s string) ([]string, bool) {
+	var topics []string
+	for _, t := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	}) {
+		if !topicRe.MatchString(t) {
+			return nil, false
+		}
+		if !slices.Contains(topics, t) {
+			topics = append(topics, t)
+		}
+	}
+	return topics, len(topics) <= MaxTopics
+}
+
+func SetRepoTopics(e Execer, repoAt syntax.ATURI, topics []string) error {
+	_, err := e.Exec(`update repos set topics = ? where at_uri = ?`, strings.Join(topics, " "), repoAt)
+	return err
+}
+
+// ParseWebsite checks that s is empty or a http(s) url
+func ParseWebsite(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", true
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(s) > 512 {
+		return "", false
+	}
+	return u.String(), true
+}
+
+func SetRepoWebsite(e Execer, repoAt syntax.ATURI, website string) error {
+	_, err := e.Exec(`update repos set website = ? where at_uri = ?`, website, repoAt)
+	return err
+}
+
+// SetRepoMetadata updates what the record of a repo says about it, as it was
+// edited on another appview
+func SetRepoMetadata(e Execer, repoAt syntax.ATURI, description, website string, topics []string) error {
+	_, err := e.Exec(
+		`update repos set description = ?, website = ?, topics = ? where at_uri = ?`,
+		description,
+		website,
+		strings.Join(topics, " "),
+		repoAt,
+	)
+	return err
+}
+
+// RepoActivity is an issue or pull that was opened on a repo
+type RepoActivity struct {
+	Kind     string // "issue" or "pull"
+	Id       int
+	Title    string
+	OwnerDid string
+	State    string // "open", "closed" or "merged"
+	Created  time.Time
+}
+
+// RepoSummary is what the overview of a repo shows besides its files
+type RepoSummary struct {
+	Topics  []string
+	Website string
+	// latest issues and pulls, newest first
+	Activity []RepoActivity
+}
+
+// GetRepoSummary gathers the topics and the latest activity of a repo
+func GetRepoSummary(e Execer, repoAt syntax.ATURI, activity int) (RepoSummary, error) {
+	var summary RepoSummary
+
+	// topics ride along on every row, so that they come back with the
+	// activity in a single query
+	rows, err := e.Query(
+		`select r.topics, r.website, a.kind, a.id, a.title, a.owner_did, a.state, a.created
+		from repos r
+		left join (
+			select * from (
+				select 'issue' as kind, issue_id as id, title, owner_did,
+					case open when 1 then 'open' else 'closed' end as state,
+					created
+				from issues
+				where repo_at = ?1 and hidden is null
+				union all
+				select 'pull', pull_id, title, owner_did,
+					case state when 1 then 'open' when 2 then 'merged' else 'closed' end,
+					created
+				from pulls
+				where repo_at = ?1 and state != 3
+				order by created desc
+				limit ?2
+			)
+		) a
+		where r.at_uri = ?1
+		order by a.created desc`,
+		repoAt, activity,
+	)
+	if err != nil {
+		return summary, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var topics, website string
+		var kind, title, ownerDid, state, created *string
+		var id *int
+		if err := rows.Scan(&topics, &website, &kind, &id, &title, &ownerDid, &state, &created); err != nil {
+			return summary, err
+		}
+
+		summary.Topics = strings.Fields(topics)
+		summary.Website = website
+		if kind == nil {
+			continue
+		}
+
+		a := RepoActivity{Kind: *kind, Id: *id, Title: *title, OwnerDid: *ownerDid, State: *state}
+		if t, err := time.Parse(time.RFC3339, *created); err == nil {
+			a.Created = t
+		}
+		summary.Activity = append(summary.Activity, a)
+	}
+
+	return summary, rows.Err()
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+func AddTakeout(e Execer, did, subject string) error {
+	_, err := e.Exec(`insert into takeouts (did, subject) values (?, ?)`, did, subject)
+	return err
+}
+
+// CountRecentTakeouts counts the exports did downloaded since
+func CountRecentTakeouts(e Execer, did string, since time.Time) (int, error) {
+	var count int
+	err := e.QueryRow(
+		`select count(1) from takeouts where did = ? and created >= ?`,
+		did, since.UTC().Format(time.RFC3339),
+	).Scan(&count)
+	return count, err
+}
+
+// GetAllComments fetches issue comments across repos, unlike GetComments
+// which is limited to one issue
+func GetAllComments(e Execer, filters ...filter) ([]Comment, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select owner_did, repo_at, issue_id, comment_id, coalesce(rkey, ''), body, created, edited, deleted, coalesce(hidden, '')
+		from comments`+whereClause+`
+		order by created asc`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		var comment Comment
+		var created string
+		var edited, deleted sql.NullString
+		err := rows.Scan(&comment.OwnerDid, &comment.RepoAt, &comment.Issue, &comment.CommentId, &comment.Rkey, &comment.Body, &created, &edited, &deleted, &comment.Hidden)
+		if err != nil {
+			return nil, err
+		}
+
+		if t, err := time.Parse(time.RFC3339, created); err == nil {
+			comment.Created = &t
+		}
+		if edited.Valid {
+			if t, err := time.Parse(time.RFC3339, edited.String); err == nil {
+				comment.Edited = &t
+			}
+		}
+		if deleted.Valid {
+			if t, err := time.Parse(time.RFC3339, deleted.String); err == nil {
+				comment.Deleted = &t
+			}
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// GetAllPullComments fetches pull comments across repos
+func GetAllPullComments(e Execer, filters ...filter) ([]PullComment, error) {
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		conditions = append(conditions, filter.Condition())
+		args = append(args, filter.Arg()...)
+	}
+
+	whereClause := ""
+	if conditions != nil {
+		whereClause = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := e.Query(
+		`select id, pull_id, submission_id, repo_at, owner_did, comment_at, body, created
+		from pull_comments`+whereClause+`
+		order by created asc`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []PullComment
+	for rows.Next() {
+		var comment PullComment
+		var created string
+		err := rows.Scan(&comment.ID, &comment.PullId, &comment.SubmissionId, &comment.RepoAt, &comment.OwnerDid, &comment.CommentAt, &comment.Body, &created)
+		if err != nil {
+			return nil, err
+		}
+
+		comment.Created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			comment.Created = time.Now()
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// GetPullRepos lists the repos did has opened pulls on, GetPulls only works
+// within one repo
+func GetPullRepos(e Execer, did string) ([]syntax.ATURI, error) {
+	rows, err := e.Query(`select distinct repo_at from pulls where owner_did = ?`, did)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []syntax.ATURI
+	for rows.Next() {
+		var repoAt syntax.ATURI
+		if err := rows.Scan(&repoAt); err != nil {
+			return nil, err
+		}
+		repos = append(repos, repoAt)
+	}
+
+	return repos, rows.Err()
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// TrafficDay is the layout of the days traffic is counted on
+const TrafficDay = time.DateOnly
+
+type RepoTraffic struct {
+	Day      time.Time
+	Views    int
+	Visitors int
+	Clones   int
+}
+
+// AddRepoTraffic adds to the counts of repoAt on day
+func AddRepoTraffic(e Execer, repoAt syntax.ATURI, day string, t RepoTraffic) error {
+	_, err := e.Exec(
+		`insert into repo_traffic (repo_at, day, views, visitors, clones)
+		values (?, ?, ?, ?, ?)
+		on conflict(repo_at, day) do update set
+			views = views + excluded.views,
+			visitors = visitors + excluded.visitors,
+			clones = clones + excluded.clones`,
+		repoAt, day, t.Views, t.Visitors, t.Clones,
+	)
+	return err
+}
+
+// GetRepoTraffic returns the counts of repoAt since the given day, oldest
+// first. Days without any traffic are left out.
+func GetRepoTraffic(e Execer, repoAt syntax.ATURI, since time.Time) ([]RepoTraffic, error) {
+	rows, err := e.Query(
+		`select day, views, visitors, clones
+		from repo_traffic
+		where repo_at = ? and day >= ?
+		order by day asc`,
+		repoAt, since.UTC().Format(TrafficDay),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traffic []RepoTraffic
+	for rows.Next() {
+		var t RepoTraffic
+		var day string
+		if err := rows.Scan(&day, &t.Views, &t.Visitors, &t.Clones); err != nil {
+			return nil, err
+		}
+		if t.Day, err = time.Parse(TrafficDay, day); err != nil {
+			continue
+		}
+		traffic = append(traffic, t)
+	}
+
+	return traffic, rows.Err()
+}
+
+// DeleteRepoTrafficBefore drops the counts of all repos from before the
+// given day
+func DeleteRepoTrafficBefore(e Execer, before time.Time) error {
+	_, err := e.Exec(`delete from repo_traffic where day < ?`, before.UTC().Format(TrafficDay))
+	return err
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// RepoTransfer is a repo offered by its owner to someone else, waiting for
+// them to accept
+type RepoTransfer struct {
+	RepoAt  syntax.ATURI
+	FromDid string
+	ToDid   string
+	Created time.Time
+}
+
+// SetRepoTransfer offers a repo to a new owner, replacing any earlier offer
+func SetRepoTransfer(e Execer, t RepoTransfer) error {
+	_, err := e.Exec(
+		`insert into repo_transfers (repo_at, from_did, to_did) values (?, ?, ?)
+		on conflict(repo_at) do update set
+			to_did = excluded.to_did,
+			created = excluded.created`,
+		t.RepoAt, t.FromDid, t.ToDid,
+	)
+	return err
+}
+
+func DeleteRepoTransfer(e Execer, repoAt syntax.ATURI) error {
+	_, err := e.Exec(`delete from repo_transfers where repo_at = ?`, repoAt)
+	return err
+}
+
+// GetRepoTransfer returns the pending transfer of a repo, or nil if there is
+// none
+func GetRepoTransfer(e Execer, repoAt syntax.ATURI) (*RepoTransfer, error) {
+	var t RepoTransfer
+	var created string
+	err := e.QueryRow(
+		`select repo_at, from_did, to_did, created from repo_transfers where repo_at = ?`,
+		repoAt,
+	).Scan(&t.RepoAt, &t.FromDid, &t.ToDid, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c, err := time.Parse(time.RFC3339, created); err == nil {
+		t.Created = c
+	}
+	return &t, nil
+}
+
+// TransferRepo moves a repo and everything that refers to it over to the
+// record of its new owner, and leaves a redirect at its old did/name
+func TransferRepo(tx *sql.Tx, oldRepo Repo, newRepo Repo) error {
+	oldAt := oldRepo.RepoAt()
+	newAt := newRepo.RepoAt()
+
+	// issues, pulls and the rest all point at the at-uri being changed, the
+	// checks hold again once they are all updated
+	if _, err := tx.Exec(`pragma defer_foreign_keys = on`); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(
+		`update repos set did = ?, rkey = ?, at_uri = ? where at_uri = ?`,
+		newRepo.Did, newRepo.Rkey, newAt, oldAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	// every table keyed on the repo has a repo_at column
+	rows, err := tx.Query(
+		`select m.name from sqlite_master m, pragma_table_info(m.name) p
+		where m.type = 'table' and p.name = 'repo_at'`,
+	)
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if _, err := tx.Exec(`update `+table+` set repo_at = ? where repo_at = ?`, newAt, oldAt); err != nil {
+			return err
+		}
+	}
+
+	for _, query := range []string{
+		`update repos set source = ? where source = ?`,
+		`update pulls set source_repo_at = ? where source_repo_at = ?`,
+		`update profile_pinned_repositories set at_uri = ? where at_uri = ?`,
+	} {
+		if _, err := tx.Exec(query, newAt, oldAt); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		`update pipelines set repo_owner = ? where knot = ? and repo_owner = ? and repo_name = ?`,
+		newRepo.Did, oldRepo.Knot, oldRepo.Did, oldRepo.Name,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`insert or replace into repo_redirects (did, name, repo_at) values (?, ?, ?)`,
+		oldRepo.Did, oldRepo.Name, newAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	// the new owner may be taking back a repo they once gave away
+	_, err = tx.Exec(`delete from repo_redirects where did = ? and name = ?`, newRepo.Did, newRepo.Name)
+	return err
+}
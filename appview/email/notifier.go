@@ -0,0 +1,180 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+// Notifier emails repo owners and issue or pull authors about activity, if
+// they opted in and have a verified primary email
+type Notifier struct {
+	notify.BaseNotifier
+
+	db         *db.DB
+	idResolver *idresolver.Resolver
+	config     *config.Config
+	logger     *slog.Logger
+}
+
+var _ notify.Notifier = &Notifier{}
+
+func NewNotifier(d *db.DB, idResolver *idresolver.Resolver, config *config.Config, logger *slog.Logger) *Notifier {
+	return &Notifier{
+		db:         d,
+		idResolver: idResolver,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+func (n *Notifier) NewIssue(ctx context.Context, issue *db.Issue) {
+	repo, err := db.GetRepoByAtUri(n.db, issue.RepoAt.String())
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", issue.RepoAt, "err", err)
+		return
+	}
+
+	n.notify(
+		issue.OwnerDid,
+		[]string{repo.Did},
+		repo,
+		fmt.Sprintf("issues/%d", issue.IssueId),
+		fmt.Sprintf("#%d %s", issue.IssueId, issue.Title),
+		"opened an issue",
+		issue.Body,
+	)
+}
+
+func (n *Notifier) NewIssueComment(ctx context.Context, comment *db.Comment) {
+	repo, err := db.GetRepoByAtUri(n.db, comment.RepoAt.String())
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", comment.RepoAt, "err", err)
+		return
+	}
+
+	issue, err := db.GetIssue(n.db, comment.RepoAt, comment.Issue)
+	if err != nil {
+		n.logger.Error("failed to get issue", "repo", comment.RepoAt, "issue", comment.Issue, "err", err)
+		return
+	}
+
+	n.notify(
+		comment.OwnerDid,
+		[]string{issue.OwnerDid, repo.Did},
+		repo,
+		fmt.Sprintf("issues/%d#comment-%d", comment.Issue, comment.CommentId),
+		fmt.Sprintf("#%d %s", comment.Issue, issue.Title),
+		"commented on an issue",
+		comment.Body,
+	)
+}
+
+func (n *Notifier) NewPull(ctx context.Context, pull *db.Pull) {
+	repo, err := db.GetRepoByAtUri(n.db, pull.RepoAt.String())
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", pull.RepoAt, "err", err)
+		return
+	}
+
+	n.notify(
+		pull.OwnerDid,
+		[]string{repo.Did},
+		repo,
+		fmt.Sprintf("pulls/%d", pull.PullId),
+		fmt.Sprintf("#%d %s", pull.PullId, pull.Title),
+		"opened a pull request",
+		pull.Body,
+	)
+}
+
+func (n *Notifier) NewPullComment(ctx context.Context, comment *db.PullComment) {
+	repo, err := db.GetRepoByAtUri(n.db, comment.RepoAt)
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", comment.RepoAt, "err", err)
+		return
+	}
+
+	pull, err := db.GetPull(n.db, syntax.ATURI(comment.RepoAt), comment.PullId)
+	if err != nil {
+		n.logger.Error("failed to get pull", "repo", comment.RepoAt, "pull", comment.PullId, "err", err)
+		return
+	}
+
+	n.notify(
+		comment.OwnerDid,
+		[]string{pull.OwnerDid, repo.Did},
+		repo,
+		fmt.Sprintf("pulls/%d", comment.PullId),
+		fmt.Sprintf("#%d %s", comment.PullId, pull.Title),
+		"commented on a pull request",
+		comment.Body,
+	)
+}
+
+// notify emails everyone in recipients except the actor. path is relative to
+// the repo's page.
+func (n *Notifier) notify(actor string, recipients []string, repo *db.Repo, path, title, action, body string) {
+	if n.config.Resend.ApiKey == "" {
+		return
+	}
+
+	slices.Sort(recipients)
+	recipients = slices.Compact(recipients)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		actorName := actor
+		if id, err := n.idResolver.ResolveIdent(ctx, actor); err == nil && !id.Handle.IsInvalidHandle() {
+			actorName = "@" + id.Handle.String()
+		}
+
+		ownerSlashRepo := repo.Did + "/" + repo.Name
+		if id, err := n.idResolver.ResolveIdent(ctx, repo.Did); err == nil && !id.Handle.IsInvalidHandle() {
+			ownerSlashRepo = "@" + id.Handle.String() + "/" + repo.Name
+		}
+
+		link := fmt.Sprintf("%s/%s/%s", n.config.Core.AppviewHost, ownerSlashRepo, path)
+
+		for _, did := range recipients {
+			if did == actor {
+				continue
+			}
+
+			address, err := db.GetNotificationEmail(n.db, did)
+			if err != nil {
+				n.logger.Error("failed to get notification email", "did", did, "err", err)
+				continue
+			}
+			if address == "" {
+				continue
+			}
+
+			err = SendEmail(Email{
+				APIKey:  n.config.Resend.ApiKey,
+				From:    n.config.Resend.SentFrom,
+				To:      address,
+				Subject: fmt.Sprintf("[%s] %s", ownerSlashRepo, title),
+				Text:    fmt.Sprintf("%s %s:\n\n%s\n\n%s\n", actorName, action, body, link),
+				Html: fmt.Sprintf(
+					"<p>%s %s:</p><pre style=\"white-space: pre-wrap\">%s</pre><p><a href=\"%s\">%s</a></p>",
+					html.EscapeString(actorName), action, html.EscapeString(body), link, link,
+				),
+			})
+			if err != nil {
+				n.logger.Error("failed to send notification", "did", did, "err", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,71 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid verification token")
+	ErrExpiredToken = errors.New("verification token has expired")
+)
+
+// VerificationToken is what a verification link carries. The code must still
+// match the one stored for the email, so sending a new link invalidates any
+// earlier ones.
+type VerificationToken struct {
+	Did     string `json:"did"`
+	Address string `json:"email"`
+	Code    string `json:"code"`
+	Expires int64  `json:"exp"`
+}
+
+func tokenMac(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("email-verification:"))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// SignToken encodes t as <payload>.<signature>, both base64url encoded.
+func SignToken(secret []byte, t VerificationToken) string {
+	// only strings and an int, this cannot fail
+	b, _ := json.Marshal(t)
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	sig := base64.RawURLEncoding.EncodeToString(tokenMac(secret, payload))
+	return payload + "." + sig
+}
+
+func ParseToken(secret []byte, token string, now time.Time) (VerificationToken, error) {
+	var t VerificationToken
+
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return t, ErrInvalidToken
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, tokenMac(secret, payload)) {
+		return t, ErrInvalidToken
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return t, ErrInvalidToken
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, ErrInvalidToken
+	}
+
+	if now.Unix() > t.Expires {
+		return t, ErrExpiredToken
+	}
+
+	return t, nil
+}
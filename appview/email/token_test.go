@@ -0,0 +1,39 @@
+package email
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerificationToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+
+	token := SignToken(secret, VerificationToken{
+		Did:     "did:plc:foo",
+		Address: "foo@example.com",
+		Code:    "code",
+		Expires: now.Add(time.Hour).Unix(),
+	})
+
+	got, err := ParseToken(secret, token, now)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if got.Did != "did:plc:foo" || got.Address != "foo@example.com" || got.Code != "code" {
+		t.Errorf("unexpected token: %+v", got)
+	}
+
+	if _, err := ParseToken([]byte("other"), token, now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("wrong secret: got %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := ParseToken(secret, token+"x", now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tampered signature: got %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := ParseToken(secret, token, now.Add(2*time.Hour)); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expired: got %v, want ErrExpiredToken", err)
+	}
+}
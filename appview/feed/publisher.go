@@ -0,0 +1,235 @@
+// Package feed publishes activity on the appview as sh.tangled.feed.event
+// records to an account run by the operator, so that other apps on the
+// network can follow tangled activity from a single account.
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
+	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/tid"
+	"tangled.sh/tangled.sh/core/types"
+)
+
+const (
+	KindPullMerged       = "pullMerged"
+	KindIssueClosed      = "issueClosed"
+	KindReleasePublished = "releasePublished"
+)
+
+// maxWrites is the most writes a pds accepts in one applyWrites call
+const maxWrites = 200
+
+// maxQueue bounds how many events are kept around while the pds cannot be
+// reached, the oldest are dropped beyond it
+const maxQueue = 10_000
+
+// Publisher queues events as they happen and writes them out in batches
+type Publisher struct {
+	notify.BaseNotifier
+
+	config     config.FeedConfig
+	idResolver *idresolver.Resolver
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	queue  []*tangled.FeedEvent
+	full   chan struct{}
+	client *xrpc.Client
+}
+
+var _ notify.Notifier = &Publisher{}
+
+func NewPublisher(config config.FeedConfig, idResolver *idresolver.Resolver, logger *slog.Logger) *Publisher {
+	if config.BatchSize <= 0 || config.BatchSize > maxWrites {
+		config.BatchSize = maxWrites
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 30 * time.Second
+	}
+
+	return &Publisher{
+		config:     config,
+		idResolver: idResolver,
+		logger:     logger,
+		full:       make(chan struct{}, 1),
+	}
+}
+
+// Start writes out queued events until ctx is done, whenever a batch fills up
+// or the flush interval passes
+func (p *Publisher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.config.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// one last go at what is left
+				flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				p.flush(flushCtx)
+				cancel()
+				return
+			case <-ticker.C:
+				p.flush(ctx)
+			case <-p.full:
+				p.flush(ctx)
+			}
+		}
+	}()
+}
+
+func (p *Publisher) NewPullMerged(ctx context.Context, pull *db.Pull, actorDid string) {
+	subject := pull.PullAt().String()
+	p.enqueue(&tangled.FeedEvent{
+		Repo:    pull.RepoAt.String(),
+		Kind:    KindPullMerged,
+		Actor:   actorDid,
+		Subject: &subject,
+		Title:   &pull.Title,
+	})
+}
+
+func (p *Publisher) NewIssueClosed(ctx context.Context, issue *db.Issue, actorDid string) {
+	subject := issue.AtUri().String()
+	p.enqueue(&tangled.FeedEvent{
+		Repo:    issue.RepoAt.String(),
+		Kind:    KindIssueClosed,
+		Actor:   actorDid,
+		Subject: &subject,
+		Title:   &issue.Title,
+	})
+}
+
+func (p *Publisher) NewRelease(ctx context.Context, repo *db.Repo, tag *types.TagReference) {
+	ref := tag.Name
+	p.enqueue(&tangled.FeedEvent{
+		Repo:  repo.RepoAt().String(),
+		Kind:  KindReleasePublished,
+		Actor: repo.Did,
+		Ref:   &ref,
+	})
+}
+
+func (p *Publisher) enqueue(event *tangled.FeedEvent) {
+	event.LexiconTypeID = tangled.FeedEventNSID
+	event.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	p.mu.Lock()
+	p.queue = append(p.queue, event)
+	if dropped := len(p.queue) - maxQueue; dropped > 0 {
+		p.logger.Warn("dropping feed events", "count", dropped)
+		p.queue = p.queue[dropped:]
+	}
+	full := len(p.queue) >= p.config.BatchSize
+	p.mu.Unlock()
+
+	if full {
+		select {
+		case p.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush writes out everything queued, batch by batch. Events of a batch that
+// fails are put back at the front of the queue for the next flush.
+func (p *Publisher) flush(ctx context.Context) {
+	p.mu.Lock()
+	pending := p.queue
+	p.queue = nil
+	p.mu.Unlock()
+
+	for len(pending) > 0 {
+		n := min(len(pending), p.config.BatchSize)
+		if err := p.write(ctx, pending[:n]); err != nil {
+			p.logger.Error("failed to publish feed events", "count", len(pending), "err", err)
+
+			p.mu.Lock()
+			p.queue = append(pending, p.queue...)
+			p.mu.Unlock()
+			return
+		}
+		pending = pending[n:]
+	}
+}
+
+func (p *Publisher) write(ctx context.Context, events []*tangled.FeedEvent) error {
+	client, err := p.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	var writes []*comatproto.RepoApplyWrites_Input_Writes_Elem
+	for _, event := range events {
+		rkey := tid.TID()
+		writes = append(writes, &comatproto.RepoApplyWrites_Input_Writes_Elem{
+			RepoApplyWrites_Create: &comatproto.RepoApplyWrites_Create{
+				Collection: tangled.FeedEventNSID,
+				Rkey:       &rkey,
+				Value:      &lexutil.LexiconTypeDecoder{Val: event},
+			},
+		})
+	}
+
+	_, err = comatproto.RepoApplyWrites(ctx, client, &comatproto.RepoApplyWrites_Input{
+		Repo:   p.config.Did,
+		Writes: writes,
+	})
+	if err != nil {
+		// most likely an expired token, start afresh on the next attempt
+		p.client = nil
+		return err
+	}
+	return nil
+}
+
+// session logs in to the account with its app password, the session is kept
+// until a write fails
+func (p *Publisher) session(ctx context.Context) (*xrpc.Client, error) {
+	if p.client != nil {
+		return p.client, nil
+	}
+
+	host := p.config.Host
+	if host == "" {
+		id, err := p.idResolver.ResolveIdent(ctx, p.config.Did)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", p.config.Did, err)
+		}
+		host = id.PDSEndpoint()
+		if host == "" {
+			return nil, fmt.Errorf("no pds found for %s", p.config.Did)
+		}
+	}
+
+	client := &xrpc.Client{Host: host}
+	out, err := comatproto.ServerCreateSession(ctx, client, &comatproto.ServerCreateSession_Input{
+		Identifier: p.config.Did,
+		Password:   p.config.AppPassword,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+
+	client.Auth = &xrpc.AuthInfo{
+		AccessJwt:  out.AccessJwt,
+		RefreshJwt: out.RefreshJwt,
+		Handle:     out.Handle,
+		Did:        out.Did,
+	}
+	p.client = client
+	return client, nil
+}
@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+func TestFlushBatches(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	failing := true
+
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			json.NewEncoder(w).Encode(map[string]string{
+				"accessJwt":  "access",
+				"refreshJwt": "refresh",
+				"did":        "did:plc:feed",
+				"handle":     "feed.test",
+			})
+		case "/xrpc/com.atproto.repo.applyWrites":
+			mu.Lock()
+			defer mu.Unlock()
+
+			if failing {
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, `{"error":"ExpiredToken","message":"token has expired"}`)
+				return
+			}
+
+			var input struct {
+				Writes []json.RawMessage `json:"writes"`
+			}
+			json.NewDecoder(r.Body).Decode(&input)
+			batches = append(batches, len(input.Writes))
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer pds.Close()
+
+	p := NewPublisher(config.FeedConfig{
+		Did:         "did:plc:feed",
+		AppPassword: "password",
+		Host:        pds.URL,
+		BatchSize:   2,
+	}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for range 5 {
+		p.NewIssueClosed(t.Context(), &db.Issue{Title: "bug"}, "did:plc:alice")
+	}
+
+	// nothing is lost while the pds refuses writes
+	p.flush(t.Context())
+	if len(p.queue) != 5 {
+		t.Fatalf("expected 5 queued events after a failed flush, got %d", len(p.queue))
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+
+	p.flush(t.Context())
+	if len(p.queue) != 0 {
+		t.Fatalf("expected an empty queue, got %d", len(p.queue))
+	}
+
+	want := []int{2, 2, 1}
+	if len(batches) != len(want) {
+		t.Fatalf("got batches %v, want %v", batches, want)
+	}
+	for i := range want {
+		if batches[i] != want[i] {
+			t.Fatalf("got batches %v, want %v", batches, want)
+		}
+	}
+}
@@ -0,0 +1,492 @@
+package gists
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/middleware"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/idresolver"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type Gists struct {
+	Db         *db.DB
+	OAuth      *oauth.OAuth
+	Pages      *pages.Pages
+	Config     *config.Config
+	IdResolver *idresolver.Resolver
+	Logger     *slog.Logger
+}
+
+func (g *Gists) Router(mw *middleware.Middleware) http.Handler {
+	r := chi.NewRouter()
+
+	r.
+		Get("/", g.timeline)
+
+	r.
+		With(middleware.AuthMiddleware(g.OAuth)).
+		Route("/new", func(r chi.Router) {
+			r.Get("/", g.create)
+			r.Post("/", g.create)
+		})
+
+	r.
+		With(mw.ResolveIdent()).
+		Route("/{user}", func(r chi.Router) {
+			r.Get("/", g.user)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", g.contents)
+				r.Get("/raw/{filename}", g.raw)
+				r.Get("/embed", g.embed)
+
+				r.Group(func(r chi.Router) {
+					r.Use(middleware.AuthMiddleware(g.OAuth))
+					r.Delete("/", g.delete)
+					r.Get("/edit", g.edit)
+					r.Post("/edit", g.edit)
+					r.Post("/fork", g.fork)
+				})
+			})
+		})
+
+	return r
+}
+
+func (g *Gists) timeline(w http.ResponseWriter, r *http.Request) {
+	l := g.Logger.With("handler", "timeline")
+
+	gists, err := db.GetGists(g.Db, 50, db.FilterEq("visibility", db.GistPublic))
+	if err != nil {
+		l.Error("failed to fetch gists", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	g.Pages.GistsTimeline(w, pages.GistTimelineParams{
+		LoggedInUser: g.OAuth.GetUser(r),
+		Gists:        gists,
+	})
+}
+
+func (g *Gists) user(w http.ResponseWriter, r *http.Request) {
+	l := g.Logger.With("handler", "user")
+
+	id, ok := r.Context().Value("resolvedId").(identity.Identity)
+	if !ok {
+		l.Error("malformed middleware")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// owners see all their gists, everybody else only sees listed ones
+	visible := []db.GistVisibility{db.GistPublic}
+	user := g.OAuth.GetUser(r)
+	if user != nil && user.Did == id.DID.String() {
+		visible = append(visible, db.GistUnlisted, db.GistSecret)
+	}
+
+	gists, err := db.GetGists(
+		g.Db,
+		0,
+		db.FilterEq("did", id.DID),
+		db.FilterIn("visibility", visible),
+	)
+	if err != nil {
+		l.Error("failed to fetch gists", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	g.Pages.UserGists(w, pages.UserGistsParams{
+		LoggedInUser: user,
+		Owner:        id,
+		Gists:        gists,
+	})
+}
+
+// resolveGist fetches the gist addressed by the url, writing an appropriate
+// response and returning nil if it does not exist or cannot be viewed by the
+// current user
+func (g *Gists) resolveGist(w http.ResponseWriter, r *http.Request) (*db.Gist, identity.Identity) {
+	l := g.Logger.With("handler", "resolveGist")
+
+	id, ok := r.Context().Value("resolvedId").(identity.Identity)
+	if !ok {
+		l.Error("malformed middleware")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, id
+	}
+
+	gistId := chi.URLParam(r, "id")
+	if gistId == "" {
+		l.Error("malformed url, empty gist id")
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, id
+	}
+
+	gist, err := db.GetGist(
+		g.Db,
+		db.FilterEq("did", id.DID),
+		db.FilterEq("gist_id", gistId),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		g.Pages.Error404(w)
+		return nil, id
+	} else if err != nil {
+		l.Error("failed to fetch gist", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, id
+	}
+
+	// secret gists are indistinguishable from missing ones
+	if !gist.CanView(g.OAuth.GetDid(r)) {
+		g.Pages.Error404(w)
+		return nil, id
+	}
+
+	return gist, id
+}
+
+func (g *Gists) contents(w http.ResponseWriter, r *http.Request) {
+	l := g.Logger.With("handler", "contents")
+
+	gist, id := g.resolveGist(w, r)
+	if gist == nil {
+		return
+	}
+
+	forkCount, err := db.CountGists(g.Db, db.FilterEq("forked_from", gist.GistId))
+	if err != nil {
+		l.Error("failed to count forks", "err", err)
+	}
+
+	var embedUrl string
+	if gist.Visibility != db.GistSecret {
+		embedUrl = fmt.Sprintf("%s/gists/%s/%s/embed", g.Config.Core.AppviewHost, id.DID, gist.GistId)
+	}
+
+	g.Pages.SingleGist(w, pages.SingleGistParams{
+		LoggedInUser: g.OAuth.GetUser(r),
+		Gist:         *gist,
+		Stats:        gist.Stats(),
+		Owner:        id,
+		ForkCount:    forkCount,
+		EmbedUrl:     embedUrl,
+	})
+}
+
+func (g *Gists) raw(w http.ResponseWriter, r *http.Request) {
+	l := g.Logger.With("handler", "raw")
+
+	gist, _ := g.resolveGist(w, r)
+	if gist == nil {
+		return
+	}
+
+	file, ok := gist.File(chi.URLParam(r, "filename"))
+	if !ok {
+		g.Pages.Error404(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", file.Filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Contents)))
+
+	if _, err := w.Write([]byte(file.Contents)); err != nil {
+		l.Error("failed to write raw response", "err", err)
+	}
+}
+
+// embed renders a standalone page meant to be placed in an iframe on other
+// sites
+func (g *Gists) embed(w http.ResponseWriter, r *http.Request) {
+	gist, id := g.resolveGist(w, r)
+	if gist == nil {
+		return
+	}
+
+	// the session cookie is never sent along with cross-site iframes, so a
+	// secret gist could never be viewed here anyway
+	if gist.Visibility == db.GistSecret {
+		g.Pages.Error404(w)
+		return
+	}
+
+	g.Pages.GistEmbed(w, pages.GistEmbedParams{
+		Gist:  *gist,
+		Owner: id,
+	})
+}
+
+func (g *Gists) create(w http.ResponseWriter, r *http.Request) {
+	l := g.Logger.With("handler", "create")
+	user := g.OAuth.GetUser(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		g.Pages.PutGist(w, pages.PutGistParams{
+			LoggedInUser: user,
+			Action:       "new",
+			Gist:         db.Gist{Visibility: db.GistPublic},
+		})
+
+	case http.MethodPost:
+		fail := func(msg string, err error) {
+			l.Error(msg, "err", err)
+			g.Pages.Notice(w, "error", msg)
+		}
+
+		gist, err := gistFromForm(w, r)
+		if err != nil {
+			fail(fmt.Sprintf("Gists are limited to %d KiB.", db.MaxGistSize>>10), err)
+			return
+		}
+		gist.Did = syntax.DID(user.Did)
+		gist.GistId = newGistId()
+		gist.Created = time.Now()
+
+		if err := gist.Validate(); err != nil {
+			fail(fmt.Sprintf("Invalid gist: %s", err), err)
+			return
+		}
+
+		tx, err := g.Db.Begin()
+		if err != nil {
+			fail("Failed to create gist.", err)
+			return
+		}
+		defer tx.Rollback()
+
+		if err := db.AddGist(tx, gist); err != nil {
+			fail("Failed to create gist.", err)
+			return
+		}
+
+		// the record is written while the gist is held in the transaction,
+		// for the ingester to find it once its event comes back
+		if err := g.syncRecord(r, nil, gist); err != nil {
+			fail("Failed to publish gist to your PDS.", err)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			fail("Failed to create gist.", err)
+			return
+		}
+
+		g.Pages.HxRedirect(w, "/gists/"+user.Handle+"/"+gist.GistId)
+	}
+}
+
+func (g *Gists) edit(w http.ResponseWriter, r *http.Request) {
+	l := g.Logger.With("handler", "edit")
+	user := g.OAuth.GetUser(r)
+
+	gist, id := g.resolveGist(w, r)
+	if gist == nil {
+		return
+	}
+
+	// verify that the logged in user owns this gist
+	if user.Did != id.DID.String() {
+		l.Error("unauthorized request", "expected", id.DID, "got", user.Did)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		g.Pages.PutGist(w, pages.PutGistParams{
+			LoggedInUser: user,
+			Action:       "edit",
+			Gist:         *gist,
+		})
+
+	case http.MethodPost:
+		fail := func(msg string, err error) {
+			l.Error(msg, "err", err)
+			g.Pages.Notice(w, "error", msg)
+		}
+
+		entry, err := gistFromForm(w, r)
+		if err != nil {
+			fail(fmt.Sprintf("Gists are limited to %d KiB.", db.MaxGistSize>>10), err)
+			return
+		}
+		entry.Did = gist.Did
+		entry.GistId = gist.GistId
+		entry.ForkedFrom = gist.ForkedFrom
+		entry.Created = gist.Created
+
+		if err := entry.Validate(); err != nil {
+			fail(fmt.Sprintf("Invalid gist: %s", err), err)
+			return
+		}
+
+		tx, err := g.Db.Begin()
+		if err != nil {
+			fail("Failed to update gist.", err)
+			return
+		}
+		defer tx.Rollback()
+
+		if err := db.UpdateGist(tx, entry); err != nil {
+			fail("Failed to update gist.", err)
+			return
+		}
+
+		if err := g.syncRecord(r, gist, entry); err != nil {
+			fail("Failed to update gist on your PDS.", err)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			fail("Failed to update gist.", err)
+			return
+		}
+
+		g.Pages.HxRedirect(w, "/gists/"+user.Handle+"/"+gist.GistId)
+	}
+}
+
+func (g *Gists) fork(w http.ResponseWriter, r *http.Request) {
+	l := g.Logger.With("handler", "fork")
+	user := g.OAuth.GetUser(r)
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		g.Pages.Notice(w, "error", msg)
+	}
+
+	source, _ := g.resolveGist(w, r)
+	if source == nil {
+		return
+	}
+
+	fork := db.Gist{
+		Did:         syntax.DID(user.Did),
+		GistId:      newGistId(),
+		Description: source.Description,
+		Visibility:  source.Visibility,
+		ForkedFrom:  &source.GistId,
+		Created:     time.Now(),
+		Files:       source.Files,
+	}
+
+	tx, err := g.Db.Begin()
+	if err != nil {
+		fail("Failed to fork gist.", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := db.AddGist(tx, fork); err != nil {
+		fail("Failed to fork gist.", err)
+		return
+	}
+
+	if err := g.syncRecord(r, nil, fork); err != nil {
+		fail("Failed to publish gist to your PDS.", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		fail("Failed to fork gist.", err)
+		return
+	}
+
+	g.Pages.HxRedirect(w, "/gists/"+user.Handle+"/"+fork.GistId)
+}
+
+func (g *Gists) delete(w http.ResponseWriter, r *http.Request) {
+	l := g.Logger.With("handler", "delete")
+	user := g.OAuth.GetUser(r)
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		g.Pages.Notice(w, "error", msg)
+	}
+
+	gist, id := g.resolveGist(w, r)
+	if gist == nil {
+		return
+	}
+
+	if user.Did != id.DID.String() {
+		fail("You cannot delete this gist", fmt.Errorf("unauthorized deletion, %s != %s", user.Did, id.DID.String()))
+		return
+	}
+
+	if err := g.deleteRecord(r, *gist); err != nil {
+		fail("Failed to delete gist from your PDS.", err)
+		return
+	}
+
+	if err := db.DeleteGist(
+		g.Db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("gist_id", gist.GistId),
+	); err != nil {
+		fail("Failed to delete gist.", err)
+		return
+	}
+
+	g.Pages.HxRedirect(w, "/gists/"+user.Handle)
+}
+
+// gistFromForm collects the description, visibility and files submitted by
+// the gist form; files are sent as parallel filename/content fields. Forms
+// much larger than a gist may be are cut off.
+func gistFromForm(w http.ResponseWriter, r *http.Request) (db.Gist, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+	if err := r.ParseForm(); err != nil {
+		return db.Gist{}, err
+	}
+
+	gist := db.Gist{
+		Description: strings.TrimSpace(r.FormValue("description")),
+		Visibility:  db.GistVisibility(r.FormValue("visibility")),
+	}
+
+	filenames := r.Form["filename"]
+	contents := r.Form["content"]
+	for i := range min(len(filenames), len(contents)) {
+		filename := strings.TrimSpace(filenames[i])
+
+		// ignore rows that were added but left blank
+		if filename == "" && contents[i] == "" {
+			continue
+		}
+
+		gist.Files = append(gist.Files, db.GistFile{
+			Filename: filename,
+			Contents: contents[i],
+		})
+	}
+
+	return gist, nil
+}
+
+// maxFormSize leaves room for the form encoding of a gist at its largest
+const maxFormSize = 4*db.MaxGistSize + 64<<10
+
+// gist ids double as the only secret protecting unlisted gists, so they are
+// random rather than sequential
+func newGistId() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
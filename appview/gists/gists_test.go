@@ -0,0 +1,84 @@
+package gists
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+func TestGistFromForm(t *testing.T) {
+	post := func(form url.Values) (db.Gist, error) {
+		r := httptest.NewRequest("POST", "/gists/new", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return gistFromForm(httptest.NewRecorder(), r)
+	}
+
+	gist, err := post(url.Values{
+		"description": {"  notes  "},
+		"visibility":  {"public"},
+		"filename":    {"a.go", "", "b.md"},
+		"content":     {"package a", "", "# b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gist.Description != "notes" || len(gist.Files) != 2 {
+		t.Fatalf("unexpected gist: %+v", gist)
+	}
+	if err := gist.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = post(url.Values{
+		"filename": {"big.txt"},
+		"content":  {strings.Repeat("x", maxFormSize)},
+	})
+	if err == nil {
+		t.Fatal("oversized form was accepted")
+	}
+}
+
+func TestGistLimits(t *testing.T) {
+	gist := db.Gist{Visibility: db.GistPublic}
+	for i := range db.MaxGistFiles + 1 {
+		gist.Files = append(gist.Files, db.GistFile{
+			Filename: strings.Repeat("f", i+1),
+			Contents: "x",
+		})
+	}
+	if err := gist.Validate(); err == nil {
+		t.Fatal("too many files were accepted")
+	}
+
+	gist.Files = []db.GistFile{
+		{Filename: "a", Contents: strings.Repeat("x", db.MaxGistSize/2)},
+		{Filename: "b", Contents: strings.Repeat("x", db.MaxGistSize/2+1)},
+	}
+	if err := gist.Validate(); err == nil {
+		t.Fatal("oversized gist was accepted")
+	}
+}
+
+func TestGistRecord(t *testing.T) {
+	gist := db.Gist{
+		Did:         "did:plc:alice",
+		GistId:      newGistId(),
+		Description: "notes",
+		Visibility:  db.GistPublic,
+		Files: []db.GistFile{
+			{Filename: "a.go", Contents: "package a"},
+		},
+	}
+
+	got := db.GistFromRecord(gist.Did.String(), gist.GistId, gist.AsRecord())
+	if got.Did != gist.Did || got.GistId != gist.GistId || got.Description != gist.Description {
+		t.Fatalf("gist did not survive its record: %+v", got)
+	}
+	if got.Visibility != db.GistPublic || !slices.Equal(got.Files, gist.Files) {
+		t.Fatalf("gist did not survive its record: %+v", got)
+	}
+}
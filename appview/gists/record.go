@@ -0,0 +1,67 @@
+package gists
+
+import (
+	"net/http"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// forkedFromUri is the source a public fork points its record at, nil when
+// the source is not public itself
+func (g *Gists) forkedFromUri(gist db.Gist) *string {
+	if gist.ForkedFrom == nil {
+		return nil
+	}
+	source, err := db.GetGist(g.Db, db.FilterEq("gist_id", *gist.ForkedFrom))
+	if err != nil || source.Visibility != db.GistPublic {
+		return nil
+	}
+	uri := source.AtUri().String()
+	return &uri
+}
+
+// syncRecord brings the record of a gist in line with its visibility: public
+// gists are put to the PDS, and taken off it again once they are not. old is
+// nil for new gists.
+func (g *Gists) syncRecord(r *http.Request, old *db.Gist, gist db.Gist) error {
+	wasPublic := old != nil && old.Visibility == db.GistPublic
+	if gist.Visibility != db.GistPublic && !wasPublic {
+		return nil
+	}
+
+	client, err := g.OAuth.AuthorizedClient(r)
+	if err != nil {
+		return err
+	}
+
+	if gist.Visibility != db.GistPublic {
+		_, err = client.RepoDeleteRecord(r.Context(), &comatproto.RepoDeleteRecord_Input{
+			Collection: tangled.GistNSID,
+			Repo:       gist.Did.String(),
+			Rkey:       gist.GistId,
+		})
+		return err
+	}
+
+	record := gist.AsRecord()
+	record.ForkedFrom = g.forkedFromUri(gist)
+	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.GistNSID,
+		Repo:       gist.Did.String(),
+		Rkey:       gist.GistId,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &record,
+		},
+	})
+	return err
+}
+
+// deleteRecord takes a public gist off the PDS, before it is deleted
+func (g *Gists) deleteRecord(r *http.Request, gist db.Gist) error {
+	deleted := gist
+	deleted.Visibility = db.GistSecret
+	return g.syncRecord(r, &gist, deleted)
+}
@@ -0,0 +1,271 @@
+// Package github is a small client for the parts of the GitHub REST API that
+// are used to move issues between tangled and GitHub.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const apiBase = "https://api.github.com"
+
+type Client struct {
+	// optional, unauthenticated clients are heavily rate limited
+	token string
+	http  *http.Client
+}
+
+func NewClient(token string) *Client {
+	return &Client{
+		token: token,
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type User struct {
+	Login string `json:"login"`
+	Blog  string `json:"blog"`
+	Bio   string `json:"bio"`
+}
+
+type SocialAccount struct {
+	Provider string `json:"provider"`
+	Url      string `json:"url"`
+}
+
+type Issue struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	HtmlUrl   string    `json:"html_url"`
+	User      User      `json:"user"`
+	Comments  int       `json:"comments"`
+	CreatedAt time.Time `json:"created_at"`
+	// the issues endpoint also lists pull requests, this is set for those
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+func (i Issue) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+type Comment struct {
+	Id        int64     `json:"id"`
+	Body      string    `json:"body"`
+	HtmlUrl   string    `json:"html_url"`
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IssueHtmlUrl returns the url of the issue that the comment was made on
+func (c Comment) IssueHtmlUrl() string {
+	u, _, _ := strings.Cut(c.HtmlUrl, "#")
+	return u
+}
+
+var repoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ParseRepo accepts "owner/repo" or a github.com url and returns the owner
+// and name of the repo
+func ParseRepo(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		if u.Host != "github.com" && u.Host != "www.github.com" {
+			return "", "", fmt.Errorf("not a github.com url: %s", s)
+		}
+		s = u.Path
+	}
+
+	parts := strings.Split(strings.Trim(s, "/"), "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected owner/repo, got %q", s)
+	}
+
+	owner, name := parts[0], strings.TrimSuffix(parts[1], ".git")
+	if !repoPattern.MatchString(owner) || !repoPattern.MatchString(name) {
+		return "", "", fmt.Errorf("invalid repo: %s/%s", owner, name)
+	}
+
+	return owner, name, nil
+}
+
+// Issues lists the issues of a repo, open and closed, oldest first. Only
+// issues updated after since are listed, unless since is zero. Pull requests
+// are left out.
+func (c *Client) Issues(ctx context.Context, owner, repo string, since time.Time) ([]Issue, error) {
+	next := fmt.Sprintf("%s/repos/%s/%s/issues?state=all&sort=created&direction=asc&per_page=100", apiBase, owner, repo)
+	if !since.IsZero() {
+		next += "&since=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+	}
+
+	var issues []Issue
+	for next != "" {
+		var page []Issue
+		var err error
+		next, err = c.get(ctx, next, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range page {
+			if !i.IsPullRequest() {
+				issues = append(issues, i)
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+func (c *Client) IssueComments(ctx context.Context, owner, repo string, number int) ([]Comment, error) {
+	next := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments?per_page=100", apiBase, owner, repo, number)
+
+	var comments []Comment
+	for next != "" {
+		var page []Comment
+		var err error
+		next, err = c.get(ctx, next, &page)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page...)
+	}
+
+	return comments, nil
+}
+
+// Comments lists the issue comments of a whole repo, oldest first. Only
+// comments updated after since are listed, unless since is zero.
+func (c *Client) Comments(ctx context.Context, owner, repo string, since time.Time) ([]Comment, error) {
+	next := fmt.Sprintf("%s/repos/%s/%s/issues/comments?sort=created&direction=asc&per_page=100", apiBase, owner, repo)
+	if !since.IsZero() {
+		next += "&since=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+	}
+
+	var comments []Comment
+	for next != "" {
+		var page []Comment
+		var err error
+		next, err = c.get(ctx, next, &page)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page...)
+	}
+
+	return comments, nil
+}
+
+func (c *Client) CreateIssue(ctx context.Context, owner, repo, title, body string) (*Issue, error) {
+	var issue Issue
+	err := c.post(ctx, fmt.Sprintf("%s/repos/%s/%s/issues", apiBase, owner, repo), map[string]string{
+		"title": title,
+		"body":  body,
+	}, &issue)
+	if err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+func (c *Client) CreateComment(ctx context.Context, owner, repo string, number int, body string) (*Comment, error) {
+	var comment Comment
+	err := c.post(ctx, fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", apiBase, owner, repo, number), map[string]string{
+		"body": body,
+	}, &comment)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c *Client) User(ctx context.Context, login string) (*User, error) {
+	var user User
+	if _, err := c.get(ctx, fmt.Sprintf("%s/users/%s", apiBase, url.PathEscape(login)), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) SocialAccounts(ctx context.Context, login string) ([]SocialAccount, error) {
+	var accounts []SocialAccount
+	if _, err := c.get(ctx, fmt.Sprintf("%s/users/%s/social_accounts", apiBase, url.PathEscape(login)), &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// get decodes the response into out and returns the url of the next page,
+// if there is one
+func (c *Client) get(ctx context.Context, u string, out any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.do(req, out)
+	if err != nil {
+		return "", err
+	}
+
+	return nextPage(resp.Header.Get("Link")), nil
+}
+
+func (c *Client) post(ctx context.Context, u string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.do(req, out)
+	return err
+}
+
+func (c *Client) do(req *http.Request, out any) (*http.Response, error) {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("github: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+var nextPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+func nextPage(link string) string {
+	if m := nextPattern.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
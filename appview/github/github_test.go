@@ -0,0 +1,75 @@
+package github
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRepo(t *testing.T) {
+	tests := []struct {
+		in          string
+		owner, name string
+		wantErr     bool
+	}{
+		{in: "tangled/core", owner: "tangled", name: "core"},
+		{in: "https://github.com/tangled/core", owner: "tangled", name: "core"},
+		{in: "https://github.com/tangled/core.git/", owner: "tangled", name: "core"},
+		{in: "https://gitlab.com/tangled/core", wantErr: true},
+		{in: "tangled", wantErr: true},
+		{in: "tangled/core/issues", wantErr: true},
+		{in: "tan gled/core", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		owner, name, err := ParseRepo(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRepo(%q): expected error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRepo(%q): %v", tt.in, err)
+			continue
+		}
+		if owner != tt.owner || name != tt.name {
+			t.Errorf("ParseRepo(%q) = %s/%s, want %s/%s", tt.in, owner, name, tt.owner, tt.name)
+		}
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	link := `<https://api.github.com/repositories/1/issues?page=2>; rel="next", <https://api.github.com/repositories/1/issues?page=5>; rel="last"`
+	if got := nextPage(link); got != "https://api.github.com/repositories/1/issues?page=2" {
+		t.Errorf("nextPage = %q", got)
+	}
+
+	if got := nextPage(`<https://api.github.com/repositories/1/issues?page=1>; rel="prev"`); got != "" {
+		t.Errorf("nextPage on last page = %q", got)
+	}
+}
+
+func TestSealToken(t *testing.T) {
+	sealed, err := SealToken("key", "ghp_secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || strings.Contains(sealed, "ghp_secret") {
+		t.Fatalf("token was not sealed: %q", sealed)
+	}
+
+	token, err := OpenToken("key", sealed)
+	if err != nil || token != "ghp_secret" {
+		t.Fatalf("OpenToken = %q, %v", token, err)
+	}
+
+	if _, err := OpenToken("other key", sealed); err == nil {
+		t.Error("token opened with the wrong key")
+	}
+	if _, err := OpenToken("key", "ghp_secret"); err == nil {
+		t.Error("unsealed token was opened")
+	}
+	if _, err := SealToken("", "ghp_secret"); err == nil {
+		t.Error("token sealed without a key")
+	}
+}
@@ -0,0 +1,84 @@
+package github
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// sealedPrefix marks tokens sealed by SealToken, tokens stored before they
+// were sealed have no prefix
+const sealedPrefix = "sealed:"
+
+var ErrNoTokenKey = errors.New("no key to seal tokens with")
+
+// SealToken encrypts an access token for storage, so that it cannot be
+// read without key
+func SealToken(key, token string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(token), nil)
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenToken decrypts a token sealed by SealToken with the same key
+func OpenToken(key, sealed string) (string, error) {
+	encoded, ok := strings.CutPrefix(sealed, sealedPrefix)
+	if !ok {
+		return "", errors.New("token is not sealed")
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(raw) < aead.NonceSize() {
+		return "", errors.New("sealed token is too short")
+	}
+
+	nonce, ciphertext := raw[:aead.NonceSize()], raw[aead.NonceSize():]
+	token, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(token), nil
+}
+
+// IsSealed tells whether a stored token went through SealToken
+func IsSealed(token string) bool {
+	return strings.HasPrefix(token, sealedPrefix)
+}
+
+func newAEAD(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, ErrNoTokenKey
+	}
+
+	// the key comes from config as a passphrase of any length
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,100 @@
+// Package graphql serves a read-only GraphQL API over repos, issues, pulls,
+// users and stars, for clients that want nested data in one round trip.
+//
+// Queries are posted as json to /api/graphql, or sent as the query parameter
+// of a GET, and the schema is served as SDL from /api/graphql/schema. Every
+// list takes a first argument, and queries that nest too deep or would
+// resolve too many values in all are refused before they run.
+package graphql
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+const (
+	maxDepth = 8
+	maxCost  = 5000
+
+	maxRequestSize = 64 << 10
+)
+
+type GraphQL struct {
+	db         *db.DB
+	idResolver *idresolver.Resolver
+	logger     *slog.Logger
+	schema     *Schema
+}
+
+func New(db *db.DB, idResolver *idresolver.Resolver, logger *slog.Logger) *GraphQL {
+	g := &GraphQL{
+		db:         db,
+		idResolver: idResolver,
+		logger:     logger,
+	}
+	g.schema = &Schema{
+		Query:    g.queryType(),
+		MaxDepth: maxDepth,
+		MaxCost:  maxCost,
+	}
+	return g
+}
+
+func (g *GraphQL) Router() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", g.query)
+	r.Post("/", g.query)
+	r.Get("/schema", g.sdl)
+
+	return r
+}
+
+func (g *GraphQL) query(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, Response{Errors: []Error{{Message: "invalid json body"}}})
+			return
+		}
+	} else {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeJSON(w, http.StatusBadRequest, Response{Errors: []Error{{Message: "invalid variables"}}})
+				return
+			}
+		}
+	}
+
+	resp := g.schema.Execute(r.Context(), req)
+
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	for _, e := range resp.Errors {
+		if e.Path != nil {
+			g.logger.Error("failed to resolve", "path", e.Path, "err", e.Message)
+		}
+	}
+
+	writeJSON(w, status, resp)
+}
+
+func (g *GraphQL) sdl(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(g.schema.SDL()))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
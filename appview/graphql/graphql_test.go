@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+func TestExecute(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &db.Repo{Did: "did:plc:alice", Name: "core", Knot: "knot.example", Rkey: "3abc", Description: "the core"}
+	if err := db.AddRepo(d, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, issue := range []db.Issue{
+		{RepoAt: repo.RepoAt(), OwnerDid: "did:plc:bob", Title: "flaky test", Rkey: "3def"},
+		{RepoAt: repo.RepoAt(), OwnerDid: "did:plc:bob", Title: "buy cheap watches", Rkey: "3ghi", Hidden: "spam"},
+	} {
+		tx, err := d.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.NewIssue(tx, &issue); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schema := New(d, nil, slog.Default()).schema
+
+	tests := []struct {
+		name  string
+		req   Request
+		want  string
+		error string
+	}{
+		{
+			name: "nested",
+			req: Request{Query: `query {
+				user(did: "did:plc:alice") {
+					did
+					repos(first: 5) { name, description, owner { did } }
+				}
+			}`},
+			want: `{"user":{"did":"did:plc:alice","repos":[{"name":"core","description":"the core","owner":{"did":"did:plc:alice"}}]}}`,
+		},
+		{
+			name: "fragments, aliases and variables",
+			req: Request{
+				Query: `query Issues($did: String!, $open: Boolean = true) {
+					bob: user(did: $did) { ...issues }
+				}
+				fragment issues on User {
+					__typename
+					issues(open: $open) { number, title, author { did } }
+				}`,
+				Variables: map[string]any{"did": "did:plc:bob"},
+			},
+			want: `{"bob":{"__typename":"User","issues":[{"number":1,"title":"flaky test","author":{"did":"did:plc:bob"}}]}}`,
+		},
+		{
+			name: "skip",
+			req:  Request{Query: `{ user(did: "did:plc:bob") { did @skip(if: true), repos { name } } }`},
+			want: `{"user":{"repos":[]}}`,
+		},
+		{
+			name:  "unknown field",
+			req:   Request{Query: `{ user(did: "did:plc:bob") { password } }`},
+			error: "User has no field password",
+		},
+		{
+			name:  "missing selection",
+			req:   Request{Query: `{ user(did: "did:plc:bob") }`},
+			error: "select its fields",
+		},
+		{
+			name:  "too deep",
+			req:   Request{Query: `{ repos { owner { repos { owner { repos { owner { repos { owner { did } } } } } } } } }`},
+			error: "nested deeper",
+		},
+		{
+			name:  "too costly",
+			req:   Request{Query: `{ repos(first: 100) { issues(first: 100) { title } } }`},
+			error: "more than the limit",
+		},
+		{
+			name:  "fragment cycle",
+			req:   Request{Query: `{ ...a } fragment a on Query { ...a }`},
+			error: "spreads itself",
+		},
+		{
+			name:  "mutation",
+			req:   Request{Query: `mutation { star }`},
+			error: "read only",
+		},
+		{
+			name:  "syntax",
+			req:   Request{Query: `{ user(did: "unterminated) { did } }`},
+			error: "unterminated string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := schema.Execute(context.Background(), tt.req)
+
+			if tt.error != "" {
+				if len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.error) {
+					t.Fatalf("got errors %v, want %q", resp.Errors, tt.error)
+				}
+				return
+			}
+
+			if len(resp.Errors) > 0 {
+				t.Fatalf("got errors %v", resp.Errors)
+			}
+			got, err := json.Marshal(resp.Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
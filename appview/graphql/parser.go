@@ -0,0 +1,644 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed GraphQL request, its operations and the fragments
+// they use
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	vars       []varDef
+	selections []selection
+}
+
+type varDef struct {
+	name string
+	typ  string
+	def  any
+}
+
+type fragment struct {
+	name       string
+	typeCond   string
+	selections []selection
+}
+
+// selection is one of a field, a fragment spread or an inline fragment
+type selection struct {
+	field      *field
+	spread     string
+	inline     *fragment
+	directives []directive
+}
+
+type field struct {
+	alias      string
+	name       string
+	args       map[string]any
+	selections []selection
+}
+
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type directive struct {
+	name string
+	args map[string]any
+}
+
+// variable and enum are values in a document that are not literals of a
+// json type
+type variable string
+type enum string
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+// maxQueryLength bounds the size of a query, long before the cost does
+const maxQueryLength = 16 << 10
+
+func parse(src string) (*document, error) {
+	if len(src) > maxQueryLength {
+		return nil, fmt.Errorf("query is longer than %d bytes", maxQueryLength)
+	}
+
+	p := &parser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.is(tokPunct, "{"):
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sels})
+		case p.is(tokName, "query"), p.is(tokName, "mutation"), p.is(tokName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.is(tokName, "fragment"):
+			f, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, fmt.Errorf("fragment %s is defined twice", f.name)
+			}
+			doc.fragments[f.name] = f
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("no operation found")
+	}
+	return doc, nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.value}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind == tokName {
+		op.name = p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.is(tokPunct, "(") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.is(tokPunct, ")") {
+			if err := p.expect(tokPunct, "$"); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokPunct, ":"); err != nil {
+				return nil, err
+			}
+			typ, err := p.typeRef()
+			if err != nil {
+				return nil, err
+			}
+
+			v := varDef{name: name, typ: typ}
+			if p.is(tokPunct, "=") {
+				if err := p.next(); err != nil {
+					return nil, err
+				}
+				if v.def, err = p.value(true); err != nil {
+					return nil, err
+				}
+			}
+			op.vars = append(op.vars, v)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+func (p *parser) fragmentDefinition() (*fragment, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	f := &fragment{}
+	var err error
+	if f.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if f.name == "on" {
+		return nil, fmt.Errorf("a fragment cannot be named on")
+	}
+	if err := p.expect(tokName, "on"); err != nil {
+		return nil, err
+	}
+	if f.typeCond, err = p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if f.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// typeRef reads the type of a variable, such as [String!]!
+func (p *parser) typeRef() (string, error) {
+	var t string
+	if p.is(tokPunct, "[") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect(tokPunct, "]"); err != nil {
+			return "", err
+		}
+		t = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		t = name
+	}
+
+	if p.is(tokPunct, "!") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		t += "!"
+	}
+	return t, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return nil, err
+	}
+
+	var sels []selection
+	for !p.is(tokPunct, "}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("empty selection set at %d", p.tok.pos)
+	}
+
+	return sels, p.next()
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.is(tokPunct, "...") {
+		if err := p.next(); err != nil {
+			return selection{}, err
+		}
+
+		// a named fragment, unless this is an inline one
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			name := p.tok.value
+			if err := p.next(); err != nil {
+				return selection{}, err
+			}
+			dirs, err := p.directives()
+			return selection{spread: name, directives: dirs}, err
+		}
+
+		inline := &fragment{}
+		if p.is(tokName, "on") {
+			if err := p.next(); err != nil {
+				return selection{}, err
+			}
+			var err error
+			if inline.typeCond, err = p.name(); err != nil {
+				return selection{}, err
+			}
+		}
+		dirs, err := p.directives()
+		if err != nil {
+			return selection{}, err
+		}
+		if inline.selections, err = p.selectionSet(); err != nil {
+			return selection{}, err
+		}
+		return selection{inline: inline, directives: dirs}, nil
+	}
+
+	f := &field{}
+	name, err := p.name()
+	if err != nil {
+		return selection{}, err
+	}
+	if p.is(tokPunct, ":") {
+		if err := p.next(); err != nil {
+			return selection{}, err
+		}
+		f.alias = name
+		if name, err = p.name(); err != nil {
+			return selection{}, err
+		}
+	}
+	f.name = name
+
+	if f.args, err = p.arguments(); err != nil {
+		return selection{}, err
+	}
+	dirs, err := p.directives()
+	if err != nil {
+		return selection{}, err
+	}
+	if p.is(tokPunct, "{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return selection{}, err
+		}
+	}
+
+	return selection{field: f, directives: dirs}, nil
+}
+
+func (p *parser) arguments() (map[string]any, error) {
+	args := make(map[string]any)
+	if !p.is(tokPunct, "(") {
+		return args, nil
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	for !p.is(tokPunct, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, ":"); err != nil {
+			return nil, err
+		}
+		if _, ok := args[name]; ok {
+			return nil, fmt.Errorf("argument %s is given twice", name)
+		}
+		if args[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+
+	return args, p.next()
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var dirs []directive
+	for p.is(tokPunct, "@") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, directive{name: name, args: args})
+	}
+	return dirs, nil
+}
+
+// value reads a value, constant ones may not refer to variables
+func (p *parser) value(constant bool) (any, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokInt:
+		n, err := strconv.ParseInt(tok.value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid Int", tok.value)
+		}
+		return int(n), p.next()
+	case tokFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid Float", tok.value)
+		}
+		return f, p.next()
+	case tokString:
+		return tok.value, p.next()
+	case tokName:
+		var v any
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enum(tok.value)
+		}
+		return v, p.next()
+	}
+
+	switch {
+	case p.is(tokPunct, "$"):
+		if constant {
+			return nil, fmt.Errorf("variables are not allowed here, at %d", tok.pos)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+
+	case p.is(tokPunct, "["):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for !p.is(tokPunct, "]") {
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.next()
+
+	case p.is(tokPunct, "{"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		obj := make(map[string]any)
+		for !p.is(tokPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokPunct, ":"); err != nil {
+				return nil, err
+			}
+			if obj[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return obj, p.next()
+	}
+
+	return nil, p.unexpected()
+}
+
+func (p *parser) is(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.is(kind, value) {
+		return fmt.Errorf("expected %s at %d, found %s", value, p.tok.pos, p.describe())
+	}
+	return p.next()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", fmt.Errorf("expected a name at %d, found %s", p.tok.pos, p.describe())
+	}
+	name := p.tok.value
+	return name, p.next()
+}
+
+func (p *parser) unexpected() error {
+	return fmt.Errorf("unexpected %s at %d", p.describe(), p.tok.pos)
+}
+
+func (p *parser) describe() string {
+	if p.tok.kind == tokEOF {
+		return "end of query"
+	}
+	return strconv.Quote(p.tok.value)
+}
+
+// next reads the following token, skipping whitespace, commas and comments
+func (p *parser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else if strings.HasPrefix(p.src[p.pos:], "\ufeff") {
+			p.pos += len("\ufeff")
+		} else {
+			break
+		}
+	}
+
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{tokPunct, "...", start}
+
+	case strings.IndexByte("!$():=@[]{|}", c) >= 0:
+		p.pos++
+		p.tok = token{tokPunct, string(c), start}
+
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{tokName, p.src[start:p.pos], start}
+
+	case c == '-' || isDigit(c):
+		kind := tokInt
+		p.pos++
+		for p.pos < len(p.src) {
+			d := p.src[p.pos]
+			if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+				kind = tokFloat
+			} else if !isDigit(d) {
+				break
+			}
+			p.pos++
+		}
+		p.tok = token{kind, p.src[start:p.pos], start}
+
+	case c == '"':
+		s, err := p.string()
+		if err != nil {
+			return err
+		}
+		p.tok = token{tokString, s, start}
+
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		return fmt.Errorf("unexpected character %q at %d", r, start)
+	}
+
+	return nil
+}
+
+// string reads a quoted or a block string
+func (p *parser) string() (string, error) {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string at %d", start)
+		}
+		s := p.src[p.pos+3 : p.pos+3+end]
+		p.pos += 3 + end + 3
+		return strings.TrimSpace(s), nil
+	}
+
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			return "", fmt.Errorf("unterminated string at %d", start)
+		}
+		c := p.src[p.pos]
+		if c == '"' {
+			p.pos++
+			return b.String(), nil
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		if p.pos+1 >= len(p.src) {
+			return "", fmt.Errorf("unterminated string at %d", start)
+		}
+		esc := p.src[p.pos+1]
+		p.pos += 2
+		switch esc {
+		case '"', '\\', '/':
+			b.WriteByte(esc)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.pos+4 > len(p.src) {
+				return "", fmt.Errorf("invalid unicode escape at %d", p.pos)
+			}
+			r, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape at %d", p.pos)
+			}
+			b.WriteRune(rune(r))
+			p.pos += 4
+		default:
+			return "", fmt.Errorf("invalid escape \\%c at %d", esc, p.pos-2)
+		}
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
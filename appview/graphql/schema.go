@@ -0,0 +1,573 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Type is the type of a field, a Scalar, a List or an *Object
+type Type interface {
+	String() string
+}
+
+// Scalar is a leaf type, its values are encoded to json as they are
+type Scalar string
+
+const (
+	String   Scalar = "String"
+	Int      Scalar = "Int"
+	Boolean  Scalar = "Boolean"
+	DateTime Scalar = "DateTime"
+)
+
+func (s Scalar) String() string { return string(s) }
+
+type List struct {
+	Of Type
+}
+
+func (l List) String() string { return "[" + l.Of.String() + "]" }
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      map[string]*Field
+}
+
+func (o *Object) String() string { return o.Name }
+
+// Resolver fetches the value of a field of source, one of the values that
+// the resolvers of the parent object return. Lists are returned as []any,
+// see ListOf, and nil values as nil.
+type Resolver func(ctx context.Context, source any, args map[string]any) (any, error)
+
+type Field struct {
+	Type        Type
+	Description string
+	Args        map[string]Arg
+	Resolve     Resolver
+}
+
+// Arg is an argument of a field, Int, String or Boolean. Required ones have
+// no default.
+type Arg struct {
+	Type     Scalar
+	Default  any
+	Required bool
+}
+
+// First is the argument every list field takes, limiting how many items it
+// returns, and with that the cost of a query
+const (
+	First        = "first"
+	DefaultFirst = 20
+	MaxFirst     = 100
+)
+
+// FirstArg declares the argument of a list field
+var FirstArg = Arg{Type: Int, Default: DefaultFirst}
+
+// FirstOf is the number of items a list field is asked for, within bounds
+func FirstOf(args map[string]any) int {
+	n, _ := args[First].(int)
+	if n < 1 {
+		return 1
+	}
+	return min(n, MaxFirst)
+}
+
+type Schema struct {
+	Query *Object
+	// how deep selections nest, and how many values a query may resolve at
+	// most, counting every item of every list it asks for
+	MaxDepth int
+	MaxCost  int
+}
+
+// Request is a GraphQL request, as posted
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+type Response struct {
+	Data   *orderedMap `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Execute runs a query. Requests that are malformed or too costly are
+// refused as a whole, with errors and no data.
+func (s *Schema) Execute(ctx context.Context, req Request) Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	if op.kind != "query" {
+		return Response{Errors: []Error{{Message: fmt.Sprintf("%s operations are not supported, the api is read only", op.kind)}}}
+	}
+
+	vars, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	// fragments spreading other fragments many times over take long to even
+	// look at, so the fields looked at are limited as well
+	e := &executor{schema: s, doc: doc, vars: vars, budget: 10 * s.MaxCost}
+	cost, err := e.validate(s.Query, op.selections, 1)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	if cost > s.MaxCost {
+		return Response{Errors: []Error{{Message: fmt.Sprintf("query costs %d, more than the limit of %d, ask for fewer items", cost, s.MaxCost)}}}
+	}
+
+	e.budget = 0
+	data := e.object(ctx, s.Query, nil, op.selections, nil)
+	return Response{Data: data, Errors: e.errors}
+}
+
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("the query has several operations, name the one to run")
+		}
+		return d.operations[0], nil
+	}
+
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("no operation named %s", name)
+}
+
+func coerceVariables(op *operation, given map[string]any) (map[string]any, error) {
+	vars := make(map[string]any)
+	for _, v := range op.vars {
+		value, ok := given[v.name]
+		if !ok {
+			if v.def == nil && strings.HasSuffix(v.typ, "!") {
+				return nil, fmt.Errorf("variable $%s of type %s is required", v.name, v.typ)
+			}
+			value = v.def
+		}
+
+		// json numbers decode as floats
+		if f, ok := value.(float64); ok && f == float64(int(f)) {
+			value = int(f)
+		}
+		vars[v.name] = value
+	}
+	return vars, nil
+}
+
+type executor struct {
+	schema *Schema
+	doc    *document
+	vars   map[string]any
+	errors []Error
+	// how many more fields may be collected, unlimited when 0
+	budget int
+}
+
+// collected is a response key, with the fields asked for under it
+type collected struct {
+	key    string
+	fields []*field
+}
+
+// collect flattens fragments into the fields of a selection set, grouping
+// fields under the same response key, and leaving out those skipped
+func (e *executor) collect(sels []selection, visited map[string]bool, out []collected) ([]collected, error) {
+	for _, sel := range sels {
+		include, err := e.included(sel.directives)
+		if err != nil {
+			return nil, err
+		}
+		if !include {
+			continue
+		}
+
+		switch {
+		case sel.field != nil:
+			if e.budget != 0 {
+				e.budget--
+				if e.budget == 0 {
+					return nil, fmt.Errorf("query selects too many fields")
+				}
+			}
+
+			key := sel.field.key()
+			i := slices.IndexFunc(out, func(c collected) bool { return c.key == key })
+			if i < 0 {
+				out = append(out, collected{key: key})
+				i = len(out) - 1
+			}
+			if len(out[i].fields) > 0 && out[i].fields[0].name != sel.field.name {
+				return nil, fmt.Errorf("%s is both %s and %s, alias one of them", key, out[i].fields[0].name, sel.field.name)
+			}
+			out[i].fields = append(out[i].fields, sel.field)
+
+		case sel.spread != "":
+			if visited[sel.spread] {
+				return nil, fmt.Errorf("fragment %s spreads itself", sel.spread)
+			}
+			f, ok := e.doc.fragments[sel.spread]
+			if !ok {
+				return nil, fmt.Errorf("unknown fragment %s", sel.spread)
+			}
+
+			visited[sel.spread] = true
+			out, err = e.collect(f.selections, visited, out)
+			if err != nil {
+				return nil, err
+			}
+			delete(visited, sel.spread)
+
+		case sel.inline != nil:
+			out, err = e.collect(sel.inline.selections, visited, out)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// included applies @skip and @include
+func (e *executor) included(dirs []directive) (bool, error) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", d.name)
+		}
+		cond, ok := e.resolveValue(d.args["if"]).(bool)
+		if !ok {
+			return false, fmt.Errorf("@%s needs a Boolean if argument", d.name)
+		}
+		if cond == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (e *executor) resolveValue(v any) any {
+	switch v := v.(type) {
+	case variable:
+		return e.vars[string(v)]
+	case enum:
+		return string(v)
+	}
+	return v
+}
+
+// args resolves the arguments of a field against their declarations
+func (e *executor) args(f *field, decl map[string]Arg) (map[string]any, error) {
+	args := make(map[string]any)
+	for name := range f.args {
+		if _, ok := decl[name]; !ok {
+			return nil, fmt.Errorf("%s takes no argument %s", f.name, name)
+		}
+	}
+
+	for name, a := range decl {
+		value, given := f.args[name]
+		value = e.resolveValue(value)
+		if !given || value == nil {
+			if a.Required {
+				return nil, fmt.Errorf("%s needs the argument %s", f.name, name)
+			}
+			value = a.Default
+		}
+
+		if value != nil {
+			ok := false
+			switch a.Type {
+			case Int:
+				_, ok = value.(int)
+			case String:
+				_, ok = value.(string)
+			case Boolean:
+				_, ok = value.(bool)
+			}
+			if !ok {
+				return nil, fmt.Errorf("argument %s of %s has to be a %s", name, f.name, a.Type)
+			}
+		}
+
+		args[name] = value
+	}
+	return args, nil
+}
+
+// validate checks a selection set against the type it is made on, and
+// returns its cost: one for every value resolved, lists counted as the number
+// of items they are asked for
+func (e *executor) validate(obj *Object, sels []selection, depth int) (int, error) {
+	if depth > e.schema.MaxDepth {
+		return 0, fmt.Errorf("query is nested deeper than %d levels", e.schema.MaxDepth)
+	}
+
+	fields, err := e.collect(sels, make(map[string]bool), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	cost := 0
+	for _, c := range fields {
+		name := c.fields[0].name
+		if name == "__typename" {
+			cost++
+			continue
+		}
+		if strings.HasPrefix(name, "__") {
+			return 0, fmt.Errorf("introspection is not supported, the schema is served as SDL instead")
+		}
+
+		decl, ok := obj.Fields[name]
+		if !ok {
+			return 0, fmt.Errorf("%s has no field %s", obj.Name, name)
+		}
+
+		args, err := e.args(c.fields[0], decl.Args)
+		if err != nil {
+			return 0, err
+		}
+
+		var subs []selection
+		for _, f := range c.fields {
+			subs = append(subs, f.selections...)
+		}
+
+		items, typ := 1, decl.Type
+		if l, ok := typ.(List); ok {
+			items, typ = FirstOf(args), l.Of
+		}
+
+		child, isObject := typ.(*Object)
+		switch {
+		case isObject && len(subs) == 0:
+			return 0, fmt.Errorf("%s of %s is an object, select its fields", name, obj.Name)
+		case !isObject && len(subs) > 0:
+			return 0, fmt.Errorf("%s of %s is a %s, it has no fields", name, obj.Name, typ)
+		case isObject:
+			sub, err := e.validate(child, subs, depth+1)
+			if err != nil {
+				return 0, err
+			}
+			cost += items * (1 + sub)
+		default:
+			cost += items
+		}
+
+		// no need to look further
+		if cost > e.schema.MaxCost {
+			break
+		}
+	}
+
+	return cost, nil
+}
+
+func (e *executor) object(ctx context.Context, obj *Object, source any, sels []selection, path []any) *orderedMap {
+	// validated already
+	fields, _ := e.collect(sels, make(map[string]bool), nil)
+
+	out := &orderedMap{values: make(map[string]any)}
+	for _, c := range fields {
+		fieldPath := append(slices.Clone(path), c.key)
+		out.set(c.key, e.field(ctx, obj, source, c, fieldPath))
+	}
+	return out
+}
+
+func (e *executor) field(ctx context.Context, obj *Object, source any, c collected, path []any) any {
+	name := c.fields[0].name
+	if name == "__typename" {
+		return obj.Name
+	}
+
+	decl := obj.Fields[name]
+	args, _ := e.args(c.fields[0], decl.Args)
+
+	value, err := decl.Resolve(ctx, source, args)
+	if err != nil {
+		e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+		return nil
+	}
+
+	var subs []selection
+	for _, f := range c.fields {
+		subs = append(subs, f.selections...)
+	}
+	return e.complete(ctx, decl.Type, value, subs, path)
+}
+
+// complete turns the value of a field into what is sent for it
+func (e *executor) complete(ctx context.Context, typ Type, value any, subs []selection, path []any) any {
+	if isNil(value) {
+		return nil
+	}
+
+	switch t := typ.(type) {
+	case List:
+		items, ok := value.([]any)
+		if !ok {
+			return nil
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = e.complete(ctx, t.Of, item, subs, append(slices.Clone(path), i))
+		}
+		return out
+	case *Object:
+		return e.object(ctx, t, value, subs, path)
+	}
+	return value
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// ListOf is how resolvers return lists
+func ListOf[T any](items []T) []any {
+	list := make([]any, len(items))
+	for i, item := range items {
+		list[i] = item
+	}
+	return list
+}
+
+// orderedMap keeps the fields of a response in the order they were asked for
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *orderedMap) set(key string, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// SDL describes the schema in the GraphQL schema definition language, for
+// clients to generate types from
+func (s *Schema) SDL() string {
+	var objects []*Object
+	seen := make(map[string]bool)
+	var walk func(t Type)
+	walk = func(t Type) {
+		switch t := t.(type) {
+		case List:
+			walk(t.Of)
+		case *Object:
+			if seen[t.Name] {
+				return
+			}
+			seen[t.Name] = true
+			objects = append(objects, t)
+			for _, f := range t.Fields {
+				walk(f.Type)
+			}
+		}
+	}
+	walk(s.Query)
+
+	var b strings.Builder
+	b.WriteString("scalar DateTime\n")
+	for _, o := range objects {
+		b.WriteString("\n")
+		if o.Description != "" {
+			fmt.Fprintf(&b, "%q\n", o.Description)
+		}
+		fmt.Fprintf(&b, "type %s {\n", o.Name)
+
+		names := make([]string, 0, len(o.Fields))
+		for name := range o.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			f := o.Fields[name]
+			if f.Description != "" {
+				fmt.Fprintf(&b, "  %q\n", f.Description)
+			}
+			fmt.Fprintf(&b, "  %s%s: %s\n", name, sdlArgs(f.Args), f.Type)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+func sdlArgs(args map[string]Arg) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		a := args[name]
+		switch {
+		case a.Required:
+			parts[i] = fmt.Sprintf("%s: %s!", name, a.Type)
+		case a.Default != nil:
+			def, _ := json.Marshal(a.Default)
+			parts[i] = fmt.Sprintf("%s: %s = %s", name, a.Type, def)
+		default:
+			parts[i] = fmt.Sprintf("%s: %s", name, a.Type)
+		}
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
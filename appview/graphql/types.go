@@ -0,0 +1,442 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// user is the source of User fields, anyone with a did is one
+type user struct {
+	did string
+}
+
+var pullStates = map[string]db.PullState{
+	"open":    db.PullOpen,
+	"closed":  db.PullClosed,
+	"merged":  db.PullMerged,
+	"deleted": db.PullDeleted,
+}
+
+// queryType builds the types of the schema, which refer to each other
+func (g *GraphQL) queryType() *Object {
+	userType := &Object{Name: "User", Description: "Someone with an atproto identity"}
+	repoType := &Object{Name: "Repo", Description: "A git repository, hosted on a knot"}
+	issueType := &Object{Name: "Issue"}
+	commentType := &Object{Name: "IssueComment"}
+	pullType := &Object{Name: "Pull", Description: "A pull request"}
+
+	userField := func(did func(source any) string) *Field {
+		return &Field{Type: userType, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return user{did(source)}, nil
+		}}
+	}
+	repoField := func(repoAt func(source any) syntax.ATURI) *Field {
+		return &Field{Type: repoType, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return g.repoByAt(repoAt(source))
+		}}
+	}
+
+	userType.Fields = map[string]*Field{
+		"did": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(user).did, nil
+		}},
+		"handle": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			id, err := g.idResolver.ResolveIdent(ctx, source.(user).did)
+			if err != nil || id.Handle.IsInvalidHandle() {
+				return nil, nil
+			}
+			return id.Handle.String(), nil
+		}},
+		"followers": {Type: Int, Description: "How many people follow them", Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			stats, err := db.GetFollowerFollowingCount(g.db, source.(user).did)
+			if err != nil {
+				return nil, g.failed("followers", err)
+			}
+			return stats.Followers, nil
+		}},
+		"following": {Type: Int, Description: "How many people they follow", Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			stats, err := db.GetFollowerFollowingCount(g.db, source.(user).did)
+			if err != nil {
+				return nil, g.failed("following", err)
+			}
+			return stats.Following, nil
+		}},
+		"repos": {
+			Type: List{repoType},
+			Args: map[string]Arg{First: FirstArg},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				repos, err := db.GetRepos(g.db, FirstOf(args), db.FilterEq("did", source.(user).did))
+				if err != nil {
+					return nil, g.failed("repos", err)
+				}
+				return repoList(repos), nil
+			},
+		},
+		"starred": {
+			Type:        List{repoType},
+			Description: "Repos they starred, most recent first",
+			Args:        map[string]Arg{First: FirstArg},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				stars, err := db.GetStars(g.db, FirstOf(args), db.FilterEq("starred_by_did", source.(user).did))
+				if err != nil {
+					return nil, g.failed("stars", err)
+				}
+				return g.starredRepos(stars)
+			},
+		},
+		"issues": {
+			Type:        List{issueType},
+			Description: "Issues they opened, most recent first",
+			Args:        map[string]Arg{First: FirstArg, "open": {Type: Boolean}},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				return g.issues(args, "owner_did", source.(user).did)
+			},
+		},
+		"pulls": {
+			Type:        List{pullType},
+			Description: "Pull requests they opened, most recent first",
+			Args:        map[string]Arg{First: FirstArg, "state": {Type: String}},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				return g.pulls(args, "owner_did", source.(user).did)
+			},
+		},
+	}
+
+	repoType.Fields = map[string]*Field{
+		"uri": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Repo).RepoAt().String(), nil
+		}},
+		"name": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Repo).Name, nil
+		}},
+		"knot": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Repo).Knot, nil
+		}},
+		"description": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Repo).Description, nil
+		}},
+		"created": {Type: DateTime, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Repo).Created, nil
+		}},
+		"owner": userField(func(source any) string { return source.(*db.Repo).Did }),
+		"source": {
+			Type:        repoType,
+			Description: "The repo this one is a fork of",
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				repo := source.(*db.Repo)
+				if repo.Source == "" {
+					src, err := db.GetRepoSource(g.db, repo.RepoAt())
+					if err != nil || src == "" {
+						return nil, nil
+					}
+					repo.Source = src
+				}
+				return g.repoByAt(syntax.ATURI(repo.Source))
+			},
+		},
+		"starCount": {Type: Int, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			n, err := db.GetStarCount(g.db, source.(*db.Repo).RepoAt())
+			if err != nil {
+				return nil, g.failed("stars", err)
+			}
+			return n, nil
+		}},
+		"stargazers": {
+			Type:        List{userType},
+			Description: "Who starred the repo, most recent first",
+			Args:        map[string]Arg{First: FirstArg},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				stars, err := db.GetStars(g.db, FirstOf(args), db.FilterEq("repo_at", source.(*db.Repo).RepoAt()))
+				if err != nil {
+					return nil, g.failed("stars", err)
+				}
+				users := make([]any, len(stars))
+				for i, s := range stars {
+					users[i] = user{s.StarredByDid}
+				}
+				return users, nil
+			},
+		},
+		"issues": {
+			Type: List{issueType},
+			Args: map[string]Arg{First: FirstArg, "open": {Type: Boolean}},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				return g.issues(args, "repo_at", source.(*db.Repo).RepoAt())
+			},
+		},
+		"issue": {
+			Type: issueType,
+			Args: map[string]Arg{"number": {Type: Int, Required: true}},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				issues, err := db.GetIssues(g.db,
+					db.FilterEq("repo_at", source.(*db.Repo).RepoAt()),
+					db.FilterEq("issue_id", args["number"]),
+					db.FilterIs("hidden", nil),
+				)
+				if err != nil {
+					return nil, g.failed("issue", err)
+				}
+				if len(issues) == 0 {
+					return nil, nil
+				}
+				return &issues[0], nil
+			},
+		},
+		"pulls": {
+			Type: List{pullType},
+			Args: map[string]Arg{First: FirstArg, "state": {Type: String}},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				return g.pulls(args, "repo_at", source.(*db.Repo).RepoAt())
+			},
+		},
+		"pull": {
+			Type: pullType,
+			Args: map[string]Arg{"number": {Type: Int, Required: true}},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				pulls, err := db.GetPullsWithLimit(g.db, 1,
+					db.FilterEq("repo_at", source.(*db.Repo).RepoAt()),
+					db.FilterEq("pull_id", args["number"]),
+				)
+				if err != nil {
+					return nil, g.failed("pull", err)
+				}
+				if len(pulls) == 0 {
+					return nil, nil
+				}
+				return pulls[0], nil
+			},
+		},
+	}
+
+	issueType.Fields = map[string]*Field{
+		"number": {Type: Int, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Issue).IssueId, nil
+		}},
+		"uri": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Issue).AtUri().String(), nil
+		}},
+		"title": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Issue).Title, nil
+		}},
+		"body": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Issue).Body, nil
+		}},
+		"open": {Type: Boolean, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Issue).Open, nil
+		}},
+		"created": {Type: DateTime, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Issue).Created, nil
+		}},
+		"author": userField(func(source any) string { return source.(*db.Issue).OwnerDid }),
+		"repo":   repoField(func(source any) syntax.ATURI { return source.(*db.Issue).RepoAt }),
+		"comments": {
+			Type:        List{commentType},
+			Description: "Comments on the issue, oldest first",
+			Args:        map[string]Arg{First: FirstArg},
+			Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+				issue := source.(*db.Issue)
+				all, err := db.GetComments(g.db, issue.RepoAt, issue.IssueId)
+				if err != nil {
+					return nil, g.failed("comments", err)
+				}
+
+				var comments []any
+				for _, c := range all {
+					if c.Deleted != nil || c.Hidden != "" {
+						continue
+					}
+					if len(comments) == FirstOf(args) {
+						break
+					}
+					comments = append(comments, c)
+				}
+				return comments, nil
+			},
+		},
+	}
+
+	commentType.Fields = map[string]*Field{
+		"body": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(db.Comment).Body, nil
+		}},
+		"created": {Type: DateTime, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(db.Comment).Created, nil
+		}},
+		"author": userField(func(source any) string { return source.(db.Comment).OwnerDid }),
+	}
+
+	pullType.Fields = map[string]*Field{
+		"number": {Type: Int, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Pull).PullId, nil
+		}},
+		"uri": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Pull).PullAt().String(), nil
+		}},
+		"title": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Pull).Title, nil
+		}},
+		"body": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Pull).Body, nil
+		}},
+		"state": {Type: String, Description: "open, closed, merged or deleted", Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Pull).State.String(), nil
+		}},
+		"targetBranch": {Type: String, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Pull).TargetBranch, nil
+		}},
+		"created": {Type: DateTime, Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return source.(*db.Pull).Created, nil
+		}},
+		"author": userField(func(source any) string { return source.(*db.Pull).OwnerDid }),
+		"repo":   repoField(func(source any) syntax.ATURI { return source.(*db.Pull).RepoAt }),
+	}
+
+	return &Object{
+		Name: "Query",
+		Fields: map[string]*Field{
+			"user": {
+				Type:        userType,
+				Description: "A user, by did or handle",
+				Args:        map[string]Arg{"did": {Type: String}, "handle": {Type: String}},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					if did, ok := args["did"].(string); ok {
+						if _, err := syntax.ParseDID(did); err != nil {
+							return nil, fmt.Errorf("invalid did %s", did)
+						}
+						return user{did}, nil
+					}
+					if handle, ok := args["handle"].(string); ok {
+						id, err := g.idResolver.ResolveIdent(ctx, strings.TrimPrefix(handle, "@"))
+						if err != nil {
+							return nil, nil
+						}
+						return user{id.DID.String()}, nil
+					}
+					return nil, fmt.Errorf("user needs a did or a handle")
+				},
+			},
+			"repo": {
+				Type:        repoType,
+				Description: "A repo, by the did or handle of its owner and its name",
+				Args:        map[string]Arg{"owner": {Type: String, Required: true}, "name": {Type: String, Required: true}},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					id, err := g.idResolver.ResolveIdent(ctx, strings.TrimPrefix(args["owner"].(string), "@"))
+					if err != nil {
+						return nil, nil
+					}
+					repo, err := db.GetRepo(g.db, id.DID.String(), args["name"].(string))
+					if errors.Is(err, sql.ErrNoRows) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, g.failed("repo", err)
+					}
+					return repo, nil
+				},
+			},
+			"repos": {
+				Type:        List{repoType},
+				Description: "The most recently created repos",
+				Args:        map[string]Arg{First: FirstArg},
+				Resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+					repos, err := db.GetRepos(g.db, FirstOf(args))
+					if err != nil {
+						return nil, g.failed("repos", err)
+					}
+					return repoList(repos), nil
+				},
+			},
+		},
+	}
+}
+
+// failed logs why something could not be loaded, and tells the client
+// only that it could not
+func (g *GraphQL) failed(what string, err error) error {
+	g.logger.Error("failed to load", "what", what, "err", err)
+	return fmt.Errorf("failed to load %s", what)
+}
+
+func (g *GraphQL) repoByAt(at syntax.ATURI) (any, error) {
+	repo, err := db.GetRepoByAtUri(g.db, at.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, g.failed("repo", err)
+	}
+	return repo, nil
+}
+
+func (g *GraphQL) starredRepos(stars []db.Star) (any, error) {
+	uris := make([]string, len(stars))
+	for i, s := range stars {
+		uris[i] = s.RepoAt.String()
+	}
+
+	repos, err := db.GetReposByAtUris(g.db, uris)
+	if err != nil {
+		return nil, g.failed("repos", err)
+	}
+
+	var list []any
+	for _, uri := range uris {
+		if repo, ok := repos[uri]; ok {
+			list = append(list, repo)
+		}
+	}
+	return list, nil
+}
+
+// issues lists the issues where key is value, leaving out the ones the spam
+// filter hid
+func (g *GraphQL) issues(args map[string]any, key string, value any) (any, error) {
+	var issues []db.Issue
+	var err error
+	if open, ok := args["open"].(bool); ok {
+		issues, err = db.GetIssuesWithLimit(g.db, FirstOf(args), db.FilterEq(key, value), db.FilterIs("hidden", nil), db.FilterEq("open", open))
+	} else {
+		issues, err = db.GetIssuesWithLimit(g.db, FirstOf(args), db.FilterEq(key, value), db.FilterIs("hidden", nil))
+	}
+	if err != nil {
+		return nil, g.failed("issues", err)
+	}
+
+	list := make([]any, len(issues))
+	for i := range issues {
+		list[i] = &issues[i]
+	}
+	return list, nil
+}
+
+// pulls lists the pulls where key is value, deleted ones only when asked for
+func (g *GraphQL) pulls(args map[string]any, key string, value any) (any, error) {
+	stateFilter := db.FilterNotEq("state", db.PullDeleted)
+	if s, ok := args["state"].(string); ok {
+		state, ok := pullStates[s]
+		if !ok {
+			return nil, fmt.Errorf("state has to be open, closed, merged or deleted")
+		}
+		stateFilter = db.FilterEq("state", state)
+	}
+
+	pulls, err := db.GetPullsWithLimit(g.db, FirstOf(args), db.FilterEq(key, value), stateFilter)
+	if err != nil {
+		return nil, g.failed("pulls", err)
+	}
+	return ListOf(pulls), nil
+}
+
+func repoList(repos []db.Repo) []any {
+	list := make([]any, len(repos))
+	for i := range repos {
+		list[i] = &repos[i]
+	}
+	return list
+}
@@ -0,0 +1,114 @@
+// Package hydrate batch-loads the entities a page refers to by key, so that
+// rendering a list costs one query per kind of entity rather than one per
+// item. A Hydrator lives for a single request and remembers what it loaded.
+package hydrate
+
+import (
+	"context"
+
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+type Hydrator struct {
+	e          db.Execer
+	idResolver *idresolver.Resolver
+
+	repos  map[string]*db.Repo
+	idents map[string]*identity.Identity
+}
+
+func New(e db.Execer, idResolver *idresolver.Resolver) *Hydrator {
+	return &Hydrator{
+		e:          e,
+		idResolver: idResolver,
+		repos:      make(map[string]*db.Repo),
+		idents:     make(map[string]*identity.Identity),
+	}
+}
+
+// Repos loads the repos at the given at-uris in one go. Repos that do not
+// exist are left out of the map.
+func (h *Hydrator) Repos(atUris ...string) (map[string]*db.Repo, error) {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, at := range atUris {
+		if _, ok := h.repos[at]; !ok && !seen[at] {
+			missing = append(missing, at)
+			seen[at] = true
+		}
+	}
+
+	if len(missing) > 0 {
+		loaded, err := db.GetReposByAtUris(h.e, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, at := range missing {
+			// remember misses too, so they are not looked up again
+			h.repos[at] = loaded[at]
+		}
+	}
+
+	repos := make(map[string]*db.Repo)
+	for _, at := range atUris {
+		if r := h.repos[at]; r != nil {
+			repos[at] = r
+		}
+	}
+	return repos, nil
+}
+
+// Idents resolves the given dids or handles concurrently. Ones that fail to
+// resolve are left out of the map.
+func (h *Hydrator) Idents(ctx context.Context, ids ...string) map[string]*identity.Identity {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if _, ok := h.idents[id]; !ok && !seen[id] {
+			missing = append(missing, id)
+			seen[id] = true
+		}
+	}
+
+	if len(missing) > 0 {
+		resolved := h.idResolver.ResolveIdents(ctx, missing)
+		for i, id := range missing {
+			h.idents[id] = resolved[i]
+		}
+	}
+
+	idents := make(map[string]*identity.Identity)
+	for _, id := range ids {
+		if ident := h.idents[id]; ident != nil {
+			idents[id] = ident
+		}
+	}
+	return idents
+}
+
+// PullSources fills in the source repo of pulls opened from forks
+func (h *Hydrator) PullSources(pulls []*db.Pull) error {
+	var atUris []string
+	for _, p := range pulls {
+		if p.PullSource != nil && p.PullSource.RepoAt != nil {
+			atUris = append(atUris, p.PullSource.RepoAt.String())
+		}
+	}
+	if len(atUris) == 0 {
+		return nil
+	}
+
+	repos, err := h.Repos(atUris...)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pulls {
+		if p.PullSource != nil && p.PullSource.RepoAt != nil {
+			p.PullSource.Repo = repos[p.PullSource.RepoAt.String()]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package hydrate
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+// countingExecer counts the queries that reach the database
+type countingExecer struct {
+	db.Execer
+	queries int
+}
+
+func (c *countingExecer) Query(query string, args ...any) (*sql.Rows, error) {
+	c.queries++
+	return c.Execer.Query(query, args...)
+}
+
+func (c *countingExecer) QueryRow(query string, args ...any) *sql.Row {
+	c.queries++
+	return c.Execer.QueryRow(query, args...)
+}
+
+func setup(t *testing.T, n int) (*countingExecer, []syntax.ATURI) {
+	t.Helper()
+
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+
+	var ats []syntax.ATURI
+	for i := range n {
+		repo := db.Repo{
+			Did:  "did:plc:alice",
+			Name: fmt.Sprintf("repo-%d", i),
+			Knot: "knot.example.org",
+			Rkey: fmt.Sprintf("rkey-%d", i),
+		}
+		if err := db.AddRepo(d, &repo); err != nil {
+			t.Fatal(err)
+		}
+		ats = append(ats, repo.RepoAt())
+	}
+
+	return &countingExecer{Execer: d}, ats
+}
+
+func TestPullSources(t *testing.T) {
+	e, ats := setup(t, 5)
+	h := New(e, idresolver.DefaultResolver())
+
+	var pulls []*db.Pull
+	for _, at := range ats {
+		pulls = append(pulls, &db.Pull{PullSource: &db.PullSource{RepoAt: &at}})
+	}
+	missing := syntax.ATURI("at://did:plc:bob/sh.tangled.repo/gone")
+	pulls = append(pulls, &db.Pull{PullSource: &db.PullSource{RepoAt: &missing}})
+	pulls = append(pulls, &db.Pull{PullSource: &db.PullSource{Branch: "main"}})
+
+	if err := h.PullSources(pulls); err != nil {
+		t.Fatal(err)
+	}
+	if e.queries != 1 {
+		t.Errorf("got %d queries, want 1", e.queries)
+	}
+
+	for i, at := range ats {
+		if r := pulls[i].PullSource.Repo; r == nil || r.RepoAt() != at {
+			t.Errorf("pull %d: got source %v, want %s", i, r, at)
+		}
+	}
+	if pulls[5].PullSource.Repo != nil {
+		t.Errorf("missing repo: got %v", pulls[5].PullSource.Repo)
+	}
+
+	// everything, misses included, is loaded already
+	if err := h.PullSources(pulls); err != nil {
+		t.Fatal(err)
+	}
+	if e.queries != 1 {
+		t.Errorf("got %d queries after hydrating again, want 1", e.queries)
+	}
+}
+
+func TestRepos(t *testing.T) {
+	e, ats := setup(t, 4)
+	h := New(e, idresolver.DefaultResolver())
+
+	repos, err := h.Repos(ats[0].String(), ats[1].String(), ats[1].String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 2 || e.queries != 1 {
+		t.Errorf("got %d repos in %d queries, want 2 in 1", len(repos), e.queries)
+	}
+
+	// only the repos not seen yet are queried
+	repos, err = h.Repos(ats[1].String(), ats[2].String(), ats[3].String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 3 || e.queries != 2 {
+		t.Errorf("got %d repos in %d queries, want 3 in 2", len(repos), e.queries)
+	}
+}
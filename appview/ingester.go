@@ -2,9 +2,12 @@ package appview
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,7 +20,9 @@ import (
 	"tangled.sh/tangled.sh/core/appview/db"
 	"tangled.sh/tangled.sh/core/appview/pages/markup"
 	"tangled.sh/tangled.sh/core/appview/serververify"
+	"tangled.sh/tangled.sh/core/appview/spam"
 	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/patchutil"
 	"tangled.sh/tangled.sh/core/rbac"
 )
 
@@ -26,6 +31,7 @@ type Ingester struct {
 	Enforcer   *rbac.Enforcer
 	IdResolver *idresolver.Resolver
 	Config     *config.Config
+	Spam       *spam.Filter
 	Logger     *slog.Logger
 }
 
@@ -49,7 +55,7 @@ func (i *Ingester) Ingest() processFunc {
 				err = i.IdResolver.InvalidateIdent(ctx, e.Account.Did)
 			}
 		case models.EventKindIdentity:
-			err = i.IdResolver.InvalidateIdent(ctx, e.Identity.Did)
+			err = i.ingestIdentity(ctx, e)
 		case models.EventKindCommit:
 			switch e.Commit.Collection {
 			case tangled.GraphFollowNSID:
@@ -58,8 +64,14 @@ func (i *Ingester) Ingest() processFunc {
 				err = i.ingestStar(e)
 			case tangled.PublicKeyNSID:
 				err = i.ingestPublicKey(e)
+			case tangled.SigningKeyNSID:
+				err = i.ingestSigningKey(e)
 			case tangled.RepoArtifactNSID:
 				err = i.ingestArtifact(e)
+			case tangled.RepoAttachmentNSID:
+				err = i.ingestAttachment(e)
+			case tangled.RepoNSID:
+				err = i.ingestRepo(e)
 			case tangled.ActorProfileNSID:
 				err = i.ingestProfile(e)
 			case tangled.SpindleMemberNSID:
@@ -72,10 +84,20 @@ func (i *Ingester) Ingest() processFunc {
 				err = i.ingestKnot(e)
 			case tangled.StringNSID:
 				err = i.ingestString(e)
+			case tangled.GistNSID:
+				err = i.ingestGist(e)
 			case tangled.RepoIssueNSID:
 				err = i.ingestIssue(ctx, e)
 			case tangled.RepoIssueCommentNSID:
-				err = i.ingestIssueComment(e)
+				err = i.ingestIssueComment(ctx, e)
+			case tangled.RepoIssueStateNSID:
+				err = i.ingestIssueState(e)
+			case tangled.RepoLabelNSID:
+				err = i.ingestLabel(e)
+			case tangled.RepoPullReviewNSID:
+				err = i.ingestPullReview(e)
+			case tangled.RepoPullNSID:
+				err = i.ingestPull(ctx, e)
 			}
 			l = i.Logger.With("nsid", e.Commit.Collection)
 		}
@@ -88,6 +110,50 @@ func (i *Ingester) Ingest() processFunc {
 	}
 }
 
+// ingestIdentity drops the cached identity of an account that changed, so
+// that pages show its new handle right away rather than once the cache
+// expires. The old handle is kept around so that links under it keep working.
+func (i *Ingester) ingestIdentity(ctx context.Context, e *models.Event) error {
+	did := e.Identity.Did
+
+	// identity events come in for the whole network, only accounts that did
+	// something here are worth a lookup
+	known, err := db.IsKnownDid(i.Db, did)
+	if err != nil || !known {
+		return i.IdResolver.InvalidateIdent(ctx, did)
+	}
+
+	if e.Identity.Handle != nil {
+		newHandle := *e.Identity.Handle
+
+		// still the cached identity, holding the handle until now
+		old, err := i.IdResolver.ResolveIdent(ctx, did)
+		if err == nil && !old.Handle.IsInvalidHandle() && old.Handle.String() != newHandle {
+			if err := db.AddHandleRedirect(i.Db, old.Handle.String(), did); err != nil {
+				i.Logger.Error("failed to add handle redirect", "did", did, "err", err)
+			}
+
+			// handles are cached apart from dids
+			if err := i.IdResolver.InvalidateIdent(ctx, old.Handle.String()); err != nil {
+				i.Logger.Error("failed to invalidate old handle", "handle", old.Handle, "err", err)
+			}
+		}
+
+		// the new handle may be cached as not resolving yet
+		if err := i.IdResolver.InvalidateIdent(ctx, newHandle); err != nil {
+			i.Logger.Error("failed to invalidate new handle", "handle", newHandle, "err", err)
+		}
+	}
+
+	if err := i.IdResolver.InvalidateIdent(ctx, did); err != nil {
+		return err
+	}
+
+	// warm the cache up again for the next page view
+	_, err = i.IdResolver.ResolveIdent(ctx, did)
+	return err
+}
+
 func (i *Ingester) ingestStar(e *models.Event) error {
 	var err error
 	did := e.Did
@@ -194,6 +260,101 @@ func (i *Ingester) ingestPublicKey(e *models.Event) error {
 	return nil
 }
 
+func (i *Ingester) ingestSigningKey(e *models.Event) error {
+	did := e.Did
+	var err error
+
+	l := i.Logger.With("handler", "ingestSigningKey")
+	l = l.With("nsid", e.Commit.Collection)
+
+	switch e.Commit.Operation {
+	case models.CommitOperationCreate, models.CommitOperationUpdate:
+		l.Debug("processing add of signing key")
+		raw := json.RawMessage(e.Commit.Record)
+		record := tangled.SigningKey{}
+		err = json.Unmarshal(raw, &record)
+		if err != nil {
+			l.Error("invalid record", "err", err)
+			return err
+		}
+
+		if record.Kind != db.SigningKeySSH && record.Kind != db.SigningKeyGPG {
+			return fmt.Errorf("invalid signing key kind: %s", record.Kind)
+		}
+
+		err = db.AddSigningKey(i.Db, db.SigningKey{
+			Did:       did,
+			Rkey:      e.Commit.RKey,
+			Name:      record.Name,
+			Kind:      record.Kind,
+			Key:       record.Key,
+			Published: true,
+		})
+	case models.CommitOperationDelete:
+		l.Debug("processing delete of signing key")
+		err = db.DeleteSigningKeyByRkey(i.Db, did, e.Commit.RKey)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to %s signing key record: %w", e.Commit.Operation, err)
+	}
+
+	return nil
+}
+
+func (i *Ingester) ingestAttachment(e *models.Event) error {
+	did := e.Did
+	var err error
+
+	l := i.Logger.With("handler", "ingestAttachment", "nsid", e.Commit.Collection, "did", did, "rkey", e.Commit.RKey)
+
+	switch e.Commit.Operation {
+	case models.CommitOperationCreate, models.CommitOperationUpdate:
+		raw := json.RawMessage(e.Commit.Record)
+		record := tangled.RepoAttachment{}
+		err = json.Unmarshal(raw, &record)
+		if err != nil {
+			l.Error("invalid record", "err", err)
+			return err
+		}
+
+		if record.Attachment == nil {
+			return fmt.Errorf("attachment record has no blob")
+		}
+
+		repoAt, err := syntax.ParseATURI(record.Repo)
+		if err != nil {
+			return err
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, record.CreatedAt)
+		if err != nil {
+			createdAt = time.Now()
+		}
+
+		err = db.AddAttachment(i.Db, db.Attachment{
+			Did:       did,
+			Rkey:      e.Commit.RKey,
+			RepoAt:    repoAt,
+			BlobCid:   cid.Cid(record.Attachment.Ref),
+			Name:      record.Name,
+			Size:      record.Attachment.Size,
+			MimeType:  record.Attachment.MimeType,
+			CreatedAt: createdAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to %s attachment record: %w", e.Commit.Operation, err)
+		}
+	case models.CommitOperationDelete:
+		err = db.DeleteAttachmentByRkey(i.Db, did, e.Commit.RKey)
+		if err != nil {
+			return fmt.Errorf("failed to %s attachment record: %w", e.Commit.Operation, err)
+		}
+	}
+
+	return nil
+}
+
 func (i *Ingester) ingestArtifact(e *models.Event) error {
 	did := e.Did
 	var err error
@@ -620,6 +781,147 @@ func (i *Ingester) ingestString(e *models.Event) error {
 	return nil
 }
 
+// ingestRepo picks up edits to the description, website and topics of a repo
+// made elsewhere. Repos are created and deleted through their knot, so
+// records of repos unknown here are ignored.
+func (i *Ingester) ingestRepo(e *models.Event) error {
+	did := e.Did
+	rkey := e.Commit.RKey
+
+	l := i.Logger.With("handler", "ingestRepo", "nsid", e.Commit.Collection, "did", did, "rkey", rkey)
+
+	switch e.Commit.Operation {
+	case models.CommitOperationCreate, models.CommitOperationUpdate:
+		raw := json.RawMessage(e.Commit.Record)
+		record := tangled.Repo{}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			l.Error("invalid record", "err", err)
+			return err
+		}
+
+		repoAt := syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", did, tangled.RepoNSID, rkey))
+		if _, err := db.GetRepoByAtUri(i.Db, repoAt.String()); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		description := ""
+		if record.Description != nil {
+			description = *record.Description
+		}
+
+		website := ""
+		if record.Website != nil {
+			var ok bool
+			if website, ok = db.ParseWebsite(*record.Website); !ok {
+				return fmt.Errorf("invalid website: %q", *record.Website)
+			}
+		}
+
+		topics, ok := db.ParseTopics(strings.Join(record.Topics, " "))
+		if !ok {
+			return fmt.Errorf("invalid topics: %v", record.Topics)
+		}
+
+		if err := db.SetRepoMetadata(i.Db, repoAt, description, website, topics); err != nil {
+			l.Error("failed to update repo", "err", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ingestGist keeps public gists in sync with their records. Gists that are
+// unlisted or secret here are left alone, their records are on the way out.
+func (i *Ingester) ingestGist(e *models.Event) error {
+	did := e.Did
+	rkey := e.Commit.RKey
+
+	l := i.Logger.With("handler", "ingestGist", "nsid", e.Commit.Collection, "did", did, "rkey", rkey)
+
+	ddb, ok := i.Db.Execer.(*db.DB)
+	if !ok {
+		return fmt.Errorf("failed to index gist record, invalid db cast")
+	}
+
+	existing, err := db.GetGist(ddb, db.FilterEq("gist_id", rkey))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if existing != nil && (existing.Did.String() != did || existing.Visibility != db.GistPublic) {
+		return nil
+	}
+
+	switch e.Commit.Operation {
+	case models.CommitOperationCreate, models.CommitOperationUpdate:
+		raw := json.RawMessage(e.Commit.Record)
+		record := tangled.Gist{}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			l.Error("invalid record", "err", err)
+			return err
+		}
+
+		gist := db.GistFromRecord(did, rkey, record)
+		if err := gist.Validate(); err != nil {
+			l.Error("invalid record", "err", err)
+			return err
+		}
+
+		if existing != nil {
+			// our own writes come back this way too
+			if slices.Equal(existing.Files, gist.Files) && existing.Description == gist.Description {
+				return nil
+			}
+		} else if record.ForkedFrom != nil {
+			if source, err := syntax.ParseATURI(*record.ForkedFrom); err == nil {
+				sourceId := source.RecordKey().String()
+				if _, err := db.GetGist(ddb, db.FilterEq("gist_id", sourceId)); err == nil {
+					gist.ForkedFrom = &sourceId
+				}
+			}
+		}
+
+		tx, err := ddb.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if existing != nil {
+			err = db.UpdateGist(tx, gist)
+		} else {
+			err = db.AddGist(tx, gist)
+		}
+		if err != nil {
+			l.Error("failed to add gist", "err", err)
+			return err
+		}
+
+		return tx.Commit()
+
+	case models.CommitOperationDelete:
+		if existing == nil {
+			return nil
+		}
+		// a gist made unlisted drops its record too, it is only deleted here
+		// if it is still public by the time the event comes in
+		if err := db.DeleteGist(
+			ddb,
+			db.FilterEq("did", did),
+			db.FilterEq("gist_id", rkey),
+			db.FilterEq("visibility", db.GistPublic),
+		); err != nil {
+			l.Error("failed to delete", "err", err)
+			return fmt.Errorf("failed to delete gist record: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (i *Ingester) ingestKnotMember(e *models.Event) error {
 	did := e.Did
 	var err error
@@ -713,7 +1015,23 @@ func (i *Ingester) ingestKnot(e *models.Event) error {
 			return fmt.Errorf("failed to mark verified: %w", err)
 		}
 
-		return nil
+		return i.applyKnotPolicy(ddb, domain, did, record)
+
+	case models.CommitOperationUpdate:
+		raw := json.RawMessage(e.Commit.Record)
+		record := tangled.Knot{}
+		err = json.Unmarshal(raw, &record)
+		if err != nil {
+			l.Error("invalid record", "err", err)
+			return err
+		}
+
+		ddb, ok := i.Db.Execer.(*db.DB)
+		if !ok {
+			return fmt.Errorf("failed to index knot record, invalid db cast")
+		}
+
+		return i.applyKnotPolicy(ddb, e.Commit.RKey, did, record)
 
 	case models.CommitOperationDelete:
 		domain := e.Commit.RKey
@@ -775,6 +1093,47 @@ func (i *Ingester) ingestKnot(e *models.Event) error {
 
 	return nil
 }
+
+// applyKnotPolicy stores who may create repos on a knot, and its defaults for
+// new repos, as published by its owner
+func (i *Ingester) applyKnotPolicy(ddb *db.DB, domain, did string, record tangled.Knot) error {
+	repoCreate := rbac.RepoCreateMember
+	if record.RepoCreate != nil && rbac.RepoCreatePolicy(*record.RepoCreate).IsValid() {
+		repoCreate = rbac.RepoCreatePolicy(*record.RepoCreate)
+	}
+
+	defaultBranch := ""
+	if record.DefaultBranch != nil {
+		defaultBranch = *record.DefaultBranch
+	}
+
+	err := db.SetKnotPolicy(ddb, domain, did, string(repoCreate), defaultBranch)
+	if err != nil {
+		return fmt.Errorf("failed to save knot policy: %w", err)
+	}
+
+	registrations, err := db.GetRegistrations(
+		ddb,
+		db.FilterEq("domain", domain),
+		db.FilterEq("did", did),
+		db.FilterIsNot("registered", "null"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get registration: %w", err)
+	}
+	if len(registrations) != 1 {
+		// acls are only set up for verified knots
+		return nil
+	}
+
+	err = i.Enforcer.SetRepoCreatePolicy(domain, repoCreate)
+	if err != nil {
+		return err
+	}
+
+	return i.Enforcer.E.SavePolicy()
+}
+
 func (i *Ingester) ingestIssue(ctx context.Context, e *models.Event) error {
 	did := e.Did
 	rkey := e.Commit.RKey
@@ -808,6 +1167,26 @@ func (i *Ingester) ingestIssue(ctx context.Context, e *models.Event) error {
 		if sb := strings.TrimSpace(sanitizer.SanitizeDefault(issue.Body)); sb == "" {
 			return fmt.Errorf("body is empty after HTML sanitization")
 		}
+		if err := i.Config.Limits.CheckIssueBody(issue.Body); err != nil {
+			return err
+		}
+
+		// issues opened on the appview were checked before being written
+		indexed, err := db.IsIssueIndexed(ddb, did, rkey)
+		if err != nil {
+			l.Error("failed to check for issue", "err", err)
+			return err
+		}
+		if !indexed {
+			verdict := i.Spam.Check(ctx, spam.Content{
+				Kind:      spam.KindIssue,
+				AuthorDid: did,
+				RepoAt:    issue.RepoAt,
+				Title:     issue.Title,
+				Body:      issue.Body,
+			})
+			issue.Hidden = verdict.Reason
+		}
 
 		tx, err := ddb.BeginTx(ctx, nil)
 		if err != nil {
@@ -844,13 +1223,38 @@ func (i *Ingester) ingestIssue(ctx context.Context, e *models.Event) error {
 		if sb := strings.TrimSpace(sanitizer.SanitizeDefault(body)); sb == "" {
 			return fmt.Errorf("body is empty after HTML sanitization")
 		}
+		if err := i.Config.Limits.CheckIssueBody(body); err != nil {
+			return err
+		}
 
-		err = db.UpdateIssueByRkey(ddb, did, rkey, record.Title, body)
+		existing, err := db.GetIssues(ddb, db.FilterEq("owner_did", did), db.FilterEq("rkey", rkey))
 		if err != nil {
-			l.Error("failed to update issue", "err", err)
+			l.Error("failed to get issue", "err", err)
 			return err
 		}
 
+		for _, issue := range existing {
+			err = db.EditIssue(ddb, issue.RepoAt, issue.IssueId, did, record.Title, body)
+			if err != nil {
+				l.Error("failed to update issue", "err", err)
+				return err
+			}
+
+			if issue.Title == record.Title {
+				continue
+			}
+			err = db.AddThreadEvent(ddb, db.ThreadEvent{
+				RepoAt:    issue.RepoAt,
+				SubjectAt: syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", did, tangled.RepoIssueNSID, rkey)),
+				ActorDid:  did,
+				Kind:      db.ThreadEventRenamed,
+				Value:     record.Title,
+			})
+			if err != nil {
+				l.Error("failed to add thread event", "err", err)
+			}
+		}
+
 		return nil
 
 	case models.CommitOperationDelete:
@@ -865,7 +1269,7 @@ func (i *Ingester) ingestIssue(ctx context.Context, e *models.Event) error {
 	return fmt.Errorf("unknown operation: %s", e.Commit.Operation)
 }
 
-func (i *Ingester) ingestIssueComment(e *models.Event) error {
+func (i *Ingester) ingestIssueComment(ctx context.Context, e *models.Event) error {
 	did := e.Did
 	rkey := e.Commit.RKey
 
@@ -899,6 +1303,24 @@ func (i *Ingester) ingestIssueComment(e *models.Event) error {
 		if sb := strings.TrimSpace(sanitizer.SanitizeDefault(comment.Body)); sb == "" {
 			return fmt.Errorf("body is empty after HTML sanitization")
 		}
+		if err := i.Config.Limits.CheckCommentBody(comment.Body); err != nil {
+			return err
+		}
+
+		indexed, err := db.IsCommentIndexed(ddb, did, rkey)
+		if err != nil {
+			l.Error("failed to check for issue comment", "err", err)
+			return err
+		}
+		if !indexed {
+			verdict := i.Spam.Check(ctx, spam.Content{
+				Kind:      spam.KindComment,
+				AuthorDid: did,
+				RepoAt:    comment.RepoAt,
+				Body:      comment.Body,
+			})
+			comment.Hidden = verdict.Reason
+		}
 
 		err = db.NewIssueComment(ddb, &comment)
 		if err != nil {
@@ -921,6 +1343,9 @@ func (i *Ingester) ingestIssueComment(e *models.Event) error {
 		if sb := strings.TrimSpace(sanitizer.SanitizeDefault(record.Body)); sb == "" {
 			return fmt.Errorf("body is empty after HTML sanitization")
 		}
+		if err := i.Config.Limits.CheckCommentBody(record.Body); err != nil {
+			return err
+		}
 
 		err = db.UpdateCommentByRkey(ddb, did, rkey, record.Body)
 		if err != nil {
@@ -941,3 +1366,350 @@ func (i *Ingester) ingestIssueComment(e *models.Event) error {
 
 	return fmt.Errorf("unknown operation: %s", e.Commit.Operation)
 }
+
+func (i *Ingester) ingestIssueState(e *models.Event) error {
+	did := e.Did
+
+	l := i.Logger.With("handler", "ingestIssueState", "nsid", e.Commit.Collection, "did", did, "rkey", e.Commit.RKey)
+
+	// state records are only ever added, the latest one wins
+	if e.Commit.Operation != models.CommitOperationCreate {
+		return nil
+	}
+
+	raw := json.RawMessage(e.Commit.Record)
+	record := tangled.RepoIssueState{}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		l.Error("invalid record", "err", err)
+		return err
+	}
+
+	issueAt, err := syntax.ParseATURI(record.Issue)
+	if err != nil {
+		return err
+	}
+
+	repoAt, issueId, err := db.ResolveIssueFromAtUri(i.Db, issueAt)
+	if err != nil {
+		return fmt.Errorf("failed to resolve issue: %w", err)
+	}
+
+	// issue authors can change the state of their own issues, everyone else
+	// has to be a collaborator
+	if issueAt.Authority().String() != did {
+		repo, err := db.GetRepoByAtUri(i.Db, repoAt.String())
+		if err != nil {
+			return err
+		}
+
+		ok, err := i.Enforcer.E.Enforce(did, repo.Knot, repo.DidSlashRepo(), "repo:push")
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	var kind db.ThreadEventKind
+	switch record.State {
+	case tangled.RepoIssueStateOpen:
+		err = db.ReopenIssue(i.Db, repoAt, issueId)
+		kind = db.ThreadEventReopened
+	case tangled.RepoIssueStateClosed:
+		err = db.CloseIssue(i.Db, repoAt, issueId)
+		kind = db.ThreadEventClosed
+	default:
+		return fmt.Errorf("unknown issue state: %s", record.State)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update issue state: %w", err)
+	}
+
+	sourceAt := syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", did, e.Commit.Collection, e.Commit.RKey))
+	err = db.AddThreadEvent(i.Db, db.ThreadEvent{
+		RepoAt:    repoAt,
+		SubjectAt: issueAt,
+		ActorDid:  did,
+		Kind:      kind,
+		SourceAt:  &sourceAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add thread event: %w", err)
+	}
+
+	return nil
+}
+
+func (i *Ingester) ingestLabel(e *models.Event) error {
+	did := e.Did
+	var err error
+
+	l := i.Logger.With("handler", "ingestLabel", "nsid", e.Commit.Collection, "did", did, "rkey", e.Commit.RKey)
+
+	switch e.Commit.Operation {
+	case models.CommitOperationCreate, models.CommitOperationUpdate:
+		raw := json.RawMessage(e.Commit.Record)
+		record := tangled.RepoLabel{}
+		err = json.Unmarshal(raw, &record)
+		if err != nil {
+			l.Error("invalid record", "err", err)
+			return err
+		}
+
+		name := strings.TrimSpace(record.Name)
+		if name == "" {
+			return fmt.Errorf("label name is empty")
+		}
+
+		subjectAt, err := syntax.ParseATURI(record.Subject)
+		if err != nil {
+			return err
+		}
+
+		var repoAt syntax.ATURI
+		switch subjectAt.Collection().String() {
+		case tangled.RepoIssueNSID:
+			repoAt, _, err = db.ResolveIssueFromAtUri(i.Db, subjectAt)
+		case tangled.RepoPullNSID:
+			repoAt, _, err = db.ResolvePullFromAtUri(i.Db, subjectAt)
+		default:
+			return fmt.Errorf("labels cannot be applied to %s", subjectAt.Collection())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to resolve label subject: %w", err)
+		}
+
+		repo, err := db.GetRepoByAtUri(i.Db, repoAt.String())
+		if err != nil {
+			return err
+		}
+
+		ok, err := i.Enforcer.E.Enforce(did, repo.Knot, repo.DidSlashRepo(), "repo:push")
+		if err != nil || !ok {
+			return err
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, record.CreatedAt)
+		if err != nil {
+			createdAt = time.Now()
+		}
+
+		err = db.AddLabel(i.Db, db.Label{
+			OwnerDid:  did,
+			Rkey:      e.Commit.RKey,
+			RepoAt:    repoAt,
+			SubjectAt: subjectAt,
+			Name:      name,
+			Created:   createdAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to %s label record: %w", e.Commit.Operation, err)
+		}
+
+		sourceAt := syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", did, e.Commit.Collection, e.Commit.RKey))
+		err = db.AddThreadEvent(i.Db, db.ThreadEvent{
+			RepoAt:    repoAt,
+			SubjectAt: subjectAt,
+			ActorDid:  did,
+			Kind:      db.ThreadEventLabeled,
+			Value:     name,
+			SourceAt:  &sourceAt,
+			Created:   createdAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add thread event: %w", err)
+		}
+	case models.CommitOperationDelete:
+		labels, err := db.GetLabels(i.Db, db.FilterEq("owner_did", did), db.FilterEq("rkey", e.Commit.RKey))
+		if err != nil {
+			return fmt.Errorf("failed to get label: %w", err)
+		}
+
+		err = db.DeleteLabelByRkey(i.Db, did, e.Commit.RKey)
+		if err != nil {
+			return fmt.Errorf("failed to %s label record: %w", e.Commit.Operation, err)
+		}
+
+		for _, label := range labels {
+			err = db.AddThreadEvent(i.Db, db.ThreadEvent{
+				RepoAt:    label.RepoAt,
+				SubjectAt: label.SubjectAt,
+				ActorDid:  did,
+				Kind:      db.ThreadEventUnlabeled,
+				Value:     label.Name,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to add thread event: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i *Ingester) ingestPullReview(e *models.Event) error {
+	did := e.Did
+	var err error
+
+	l := i.Logger.With("handler", "ingestPullReview", "nsid", e.Commit.Collection, "did", did, "rkey", e.Commit.RKey)
+
+	switch e.Commit.Operation {
+	case models.CommitOperationCreate, models.CommitOperationUpdate:
+		raw := json.RawMessage(e.Commit.Record)
+		record := tangled.RepoPullReview{}
+		err = json.Unmarshal(raw, &record)
+		if err != nil {
+			l.Error("invalid record", "err", err)
+			return err
+		}
+
+		state := db.ReviewState(record.State)
+		if !state.IsValid() {
+			return fmt.Errorf("unknown review state: %s", record.State)
+		}
+
+		pullAt, err := syntax.ParseATURI(record.Pull)
+		if err != nil {
+			return err
+		}
+
+		repoAt, pullId, err := db.ResolvePullFromAtUri(i.Db, pullAt)
+		if err != nil {
+			return fmt.Errorf("failed to resolve pull: %w", err)
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, record.CreatedAt)
+		if err != nil {
+			createdAt = time.Now()
+		}
+
+		review := db.PullReview{
+			OwnerDid: did,
+			Rkey:     e.Commit.RKey,
+			RepoAt:   repoAt,
+			PullId:   pullId,
+			State:    state,
+			Created:  createdAt,
+		}
+		if record.Round != nil {
+			round := int(*record.Round)
+			review.Round = &round
+		}
+		if record.Body != nil {
+			review.Body = *record.Body
+		}
+
+		err = db.AddPullReview(i.Db, review)
+	case models.CommitOperationDelete:
+		err = db.DeletePullReviewByRkey(i.Db, did, e.Commit.RKey)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to %s review record: %w", e.Commit.Operation, err)
+	}
+
+	return nil
+}
+
+// ingestPull picks up pulls that were written straight to the PDS, e.g. by the
+// tangled cli. pulls opened through the appview are already in the db.
+func (i *Ingester) ingestPull(ctx context.Context, e *models.Event) error {
+	did := e.Did
+	rkey := e.Commit.RKey
+
+	l := i.Logger.With("handler", "ingestPull", "nsid", e.Commit.Collection, "did", did, "rkey", rkey)
+
+	// resubmissions and deletions still go through the appview
+	if e.Commit.Operation != models.CommitOperationCreate {
+		return nil
+	}
+
+	raw := json.RawMessage(e.Commit.Record)
+	record := tangled.RepoPull{}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		l.Error("invalid record", "err", err)
+		return err
+	}
+
+	if record.Target == nil {
+		return fmt.Errorf("pull has no target")
+	}
+
+	repo, err := db.GetRepoByAtUri(i.Db, record.Target.Repo)
+	if err != nil {
+		return fmt.Errorf("failed to get target repo: %w", err)
+	}
+
+	if !patchutil.IsPatchValid(record.Patch) {
+		return fmt.Errorf("invalid patch")
+	}
+
+	title := record.Title
+	body := ""
+	if record.Body != nil {
+		body = *record.Body
+	}
+	if title == "" && patchutil.IsFormatPatch(record.Patch) {
+		if patches, err := patchutil.ExtractPatches(record.Patch); err == nil && len(patches) > 0 {
+			title = patches[0].Title
+			body = patches[0].Body
+		}
+	}
+
+	sanitizer := markup.NewSanitizer()
+	if st := strings.TrimSpace(sanitizer.SanitizeDescription(title)); st == "" {
+		return fmt.Errorf("title is empty after HTML sanitization")
+	}
+	if err := i.Config.Limits.CheckPullBody(body); err != nil {
+		return err
+	}
+
+	pull := &db.Pull{
+		Title:        title,
+		Body:         body,
+		TargetBranch: record.Target.Branch,
+		OwnerDid:     did,
+		RepoAt:       repo.RepoAt(),
+		Rkey:         rkey,
+		Submissions: []*db.PullSubmission{
+			{Patch: record.Patch},
+		},
+	}
+	if record.Source != nil {
+		pull.PullSource = &db.PullSource{
+			Branch: record.Source.Branch,
+		}
+		if record.Source.Repo != nil {
+			sourceAt := syntax.ATURI(*record.Source.Repo)
+			pull.PullSource.RepoAt = &sourceAt
+		}
+		pull.Submissions[0].SourceRev = record.Source.Sha
+	}
+
+	ddb, ok := i.Db.Execer.(*db.DB)
+	if !ok {
+		return fmt.Errorf("failed to index pull record, invalid db cast")
+	}
+
+	tx, err := ddb.BeginTx(ctx, nil)
+	if err != nil {
+		l.Error("failed to begin transaction", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// checked inside the transaction, so that a pull the appview is still
+	// writing is not picked up twice
+	_, _, err = db.ResolvePullFromAtUri(tx, pull.PullAt())
+	switch {
+	case err == nil:
+		return nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+
+	if err := db.NewPull(tx, pull); err != nil {
+		l.Error("failed to create pull", "err", err)
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,168 @@
+package integrations
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+const defaultIrcNick = "tangled"
+
+// maxIrcLine keeps lines well under the 512 byte limit of irc, which also
+// counts the prefix servers add when relaying the message
+const maxIrcLine = 400
+
+// sendIRC connects to the server, joins the channel, says text and leaves.
+// Announcers do not stay connected, pushes are rare enough for that to be
+// cheaper than keeping a connection per channel around.
+func (s *Sender) sendIRC(ctx context.Context, i db.Integration, text string) error {
+	u, err := url.Parse(i.Url)
+	if err != nil {
+		return err
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		port := "6697"
+		if u.Scheme == "irc" {
+			port = "6667"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	conn, err := s.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "ircs" {
+		conn = tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	conn.SetDeadline(deadline)
+
+	nick := u.User.Username()
+	if nick == "" {
+		nick = defaultIrcNick
+	}
+
+	w := bufio.NewWriter(conn)
+	send := func(format string, args ...any) error {
+		fmt.Fprintf(w, format+"\r\n", args...)
+		return w.Flush()
+	}
+
+	if i.Token != "" {
+		if err := send("PASS %s", i.Token); err != nil {
+			return err
+		}
+	}
+	if err := send("NICK %s", nick); err != nil {
+		return err
+	}
+	if err := send("USER %s 0 * :tangled", nick); err != nil {
+		return err
+	}
+
+	r := bufio.NewScanner(conn)
+	for r.Scan() {
+		command, params := parseIrcLine(r.Text())
+
+		switch command {
+		case "PING":
+			if err := send("PONG :%s", strings.Join(params, " ")); err != nil {
+				return err
+			}
+
+		// welcome
+		case "001":
+			if err := send("JOIN %s", i.Room); err != nil {
+				return err
+			}
+
+		// nick in use, try another
+		case "433":
+			nick += "_"
+			if err := send("NICK %s", nick); err != nil {
+				return err
+			}
+
+		// end of names, sent once the channel is joined
+		case "366":
+			for _, line := range ircLines(text) {
+				if err := send("PRIVMSG %s :%s", i.Room, line); err != nil {
+					return err
+				}
+			}
+			return send("QUIT :bye")
+
+		// cannot join channel
+		case "403", "405", "471", "473", "474", "475", "477":
+			return fmt.Errorf("joining %s: %s", i.Room, strings.Join(params, " "))
+
+		case "ERROR", "464", "465":
+			return fmt.Errorf("irc server: %s", strings.Join(params, " "))
+		}
+	}
+
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("irc server closed the connection")
+}
+
+// parseIrcLine splits a line into its command and parameters, with the
+// trailing parameter kept whole
+func parseIrcLine(line string) (string, []string) {
+	if strings.HasPrefix(line, ":") {
+		_, line, _ = strings.Cut(line, " ")
+	}
+
+	line, trailing, hasTrailing := strings.Cut(line, " :")
+	params := strings.Fields(line)
+	if len(params) == 0 {
+		return "", nil
+	}
+
+	command := strings.ToUpper(params[0])
+	params = params[1:]
+	if hasTrailing {
+		params = append(params, trailing)
+	}
+	return command, params
+}
+
+// ircLines breaks text into lines that fit in a message
+func ircLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		for len(line) > maxIrcLine {
+			cut := maxIrcLine
+			if idx := strings.LastIndex(line[:cut], " "); idx > 0 {
+				cut = idx
+			}
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			lines = append(lines, line[:cut])
+			line = strings.TrimLeft(line[cut:], " ")
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
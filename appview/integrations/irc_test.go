@@ -0,0 +1,89 @@
+package integrations
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/publicnet"
+)
+
+func TestSendIRC(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var got []string
+		r := bufio.NewScanner(conn)
+		for r.Scan() {
+			line := r.Text()
+			got = append(got, line)
+			switch {
+			case strings.HasPrefix(line, "USER"):
+				conn.Write([]byte("PING :irc.test\r\n:irc.test 001 tangled :welcome\r\n"))
+			case strings.HasPrefix(line, "JOIN"):
+				conn.Write([]byte(":irc.test 366 tangled #tangled :End of /NAMES list.\r\n"))
+			case strings.HasPrefix(line, "QUIT"):
+				received <- got
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = NewSender(true).Send(ctx, db.Integration{
+		Kind: db.IntegrationIRC,
+		Url:  "irc://" + ln.Addr().String(),
+		Room: "#tangled",
+	}, "first\nsecond")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"NICK tangled",
+		"USER tangled 0 * :tangled",
+		"PONG :irc.test",
+		"JOIN #tangled",
+		"PRIVMSG #tangled :first",
+		"PRIVMSG #tangled :second",
+		"QUIT :bye",
+	}
+	got := <-received
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSenderRefusesPrivateAddresses(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	err = NewSender(false).Send(context.Background(), db.Integration{
+		Kind: db.IntegrationIRC,
+		Url:  "irc://" + ln.Addr().String(),
+		Room: "#tangled",
+	}, "hello")
+	if err == nil || !strings.Contains(err.Error(), publicnet.ErrPrivateAddress.Error()) {
+		t.Errorf("got %v, want %v", err, publicnet.ErrPrivateAddress)
+	}
+}
@@ -0,0 +1,80 @@
+package integrations
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+const (
+	EventPush    = "push"
+	EventIssue   = "issue"
+	EventPull    = "pull"
+	EventRelease = "release"
+)
+
+// Events are the events an integration can be subscribed to, in the order
+// they are shown in settings
+var Events = []string{EventPush, EventIssue, EventPull, EventRelease}
+
+// DefaultTemplates are used for events that an integration has no template
+// of its own for
+var DefaultTemplates = map[string]string{
+	EventPush:    `{{ .Actor }} pushed {{ if .Commits }}{{ .Commits }} commit{{ if ne .Commits 1 }}s{{ end }} {{ end }}to {{ .Ref }} on {{ .Repo }}: {{ .Url }}`,
+	EventIssue:   `{{ .Actor }} opened issue #{{ .Number }} "{{ .Title }}" on {{ .Repo }}: {{ .Url }}`,
+	EventPull:    `{{ .Actor }} opened pull request #{{ .Number }} "{{ .Title }}" on {{ .Repo }}: {{ .Url }}`,
+	EventRelease: `{{ .Repo }} released {{ .Ref }}: {{ .Url }}`,
+}
+
+// maxMessageLength keeps messages under discord's limit, which is the
+// strictest of the services
+const maxMessageLength = 2000
+
+// Message is what templates are rendered with
+type Message struct {
+	Event string
+	// Actor is the handle of whoever caused the event
+	Actor string
+	// Repo is the repo as @handle/name
+	Repo string
+	Url  string
+
+	// Ref is the branch or tag pushed to, or the tag released
+	Ref     string
+	Commits int64
+
+	// Number, Title and Body describe an issue or pull request
+	Number int
+	Title  string
+	Body   string
+}
+
+// ParseTemplate checks that a user supplied template can be rendered
+func ParseTemplate(event, text string) (*template.Template, error) {
+	return template.New(event).Option("missingkey=zero").Parse(text)
+}
+
+// Render renders the message with the integration's template for its event,
+// falling back to the default one
+func Render(templates map[string]string, msg Message) (string, error) {
+	text, ok := templates[msg.Event]
+	if !ok || strings.TrimSpace(text) == "" {
+		text = DefaultTemplates[msg.Event]
+	}
+
+	tmpl, err := ParseTemplate(msg.Event, text)
+	if err != nil {
+		return "", fmt.Errorf("parsing template for %s: %w", msg.Event, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, msg); err != nil {
+		return "", fmt.Errorf("rendering template for %s: %w", msg.Event, err)
+	}
+
+	out := strings.TrimSpace(sb.String())
+	if len(out) > maxMessageLength {
+		out = strings.ToValidUTF8(out[:maxMessageLength-3], "") + "..."
+	}
+	return out, nil
+}
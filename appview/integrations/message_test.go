@@ -0,0 +1,76 @@
+package integrations
+
+import (
+	"strings"
+	"testing"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+func TestRender(t *testing.T) {
+	msg := Message{
+		Event:   EventPush,
+		Actor:   "@alice.tngl.sh",
+		Repo:    "@alice.tngl.sh/core",
+		Url:     "https://tangled.sh/@alice.tngl.sh/core/commit/abc",
+		Ref:     "master",
+		Commits: 1,
+	}
+
+	got, err := Render(nil, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "@alice.tngl.sh pushed 1 commit to master on @alice.tngl.sh/core: https://tangled.sh/@alice.tngl.sh/core/commit/abc"
+	if got != want {
+		t.Errorf("default template: got %q, want %q", got, want)
+	}
+
+	got, err = Render(map[string]string{EventPush: "{{ .Ref }} by {{ .Actor }}"}, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "master by @alice.tngl.sh" {
+		t.Errorf("custom template: got %q", got)
+	}
+
+	msg.Body = strings.Repeat("a", 3*maxMessageLength)
+	got, err = Render(map[string]string{EventPush: "{{ .Body }}"}, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != maxMessageLength {
+		t.Errorf("long message: got %d bytes, want %d", len(got), maxMessageLength)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		integration db.Integration
+		ok          bool
+	}{
+		{"discord", db.Integration{Kind: db.IntegrationDiscord, Url: "https://discord.com/api/webhooks/1/abc"}, true},
+		{"discord elsewhere", db.Integration{Kind: db.IntegrationDiscord, Url: "https://example.com/api/webhooks/1/abc"}, false},
+		{"slack", db.Integration{Kind: db.IntegrationSlack, Url: "https://hooks.slack.com/services/T/B/x"}, true},
+		{"slack over http", db.Integration{Kind: db.IntegrationSlack, Url: "http://hooks.slack.com/services/T/B/x"}, false},
+		{"matrix", db.Integration{Kind: db.IntegrationMatrix, Url: "https://matrix.org", Room: "!abc:matrix.org", Token: "t"}, true},
+		{"matrix alias", db.Integration{Kind: db.IntegrationMatrix, Url: "https://matrix.org", Room: "#abc:matrix.org", Token: "t"}, false},
+		{"irc", db.Integration{Kind: db.IntegrationIRC, Url: "ircs://tangled@irc.libera.chat", Room: "#tangled"}, true},
+		{"irc without channel", db.Integration{Kind: db.IntegrationIRC, Url: "ircs://irc.libera.chat", Room: "tangled"}, false},
+		{"xmpp", db.Integration{Kind: db.IntegrationXMPP, Url: "bot@example.org", Room: "dev@conference.example.org", Token: "t"}, true},
+		{"xmpp full jid", db.Integration{Kind: db.IntegrationXMPP, Url: "bot@example.org/res", Room: "dev@conference.example.org", Token: "t"}, false},
+		{"bad template", db.Integration{
+			Kind:      db.IntegrationSlack,
+			Url:       "https://hooks.slack.com/services/T/B/x",
+			Templates: map[string]string{EventIssue: "{{ .Title"},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		err := Validate(tt.integration, false)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got err %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
+	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/types"
+)
+
+// Notifier posts activity on repos to the Discord, Slack, Matrix, IRC and
+// XMPP integrations their owners have set up
+type Notifier struct {
+	notify.BaseNotifier
+
+	db         *db.DB
+	idResolver *idresolver.Resolver
+	config     *config.Config
+	logger     *slog.Logger
+	sender     *Sender
+}
+
+var _ notify.Notifier = &Notifier{}
+
+func NewNotifier(d *db.DB, idResolver *idresolver.Resolver, config *config.Config, logger *slog.Logger) *Notifier {
+	return &Notifier{
+		db:         d,
+		idResolver: idResolver,
+		config:     config,
+		logger:     logger,
+		sender:     NewSender(config.Core.Dev),
+	}
+}
+
+func (n *Notifier) NewPush(ctx context.Context, update *tangled.GitRefUpdate) {
+	// deleted refs are not worth telling anyone about
+	if update.NewSha == plumbing.ZeroHash.String() {
+		return
+	}
+
+	repo, err := db.GetRepo(n.db, update.RepoDid, update.RepoName)
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", update.RepoDid+"/"+update.RepoName, "err", err)
+		return
+	}
+
+	ref := plumbing.ReferenceName(update.Ref)
+	path := fmt.Sprintf("commit/%s", update.NewSha)
+	if ref.IsTag() {
+		path = fmt.Sprintf("tags#%s", ref.Short())
+	}
+
+	n.post(repo, update.CommitterDid, path, Message{
+		Event:   EventPush,
+		Ref:     ref.Short(),
+		Commits: commitCount(update),
+	})
+}
+
+func commitCount(update *tangled.GitRefUpdate) int64 {
+	if update.Meta == nil || update.Meta.CommitCount == nil {
+		return 0
+	}
+
+	var n int64
+	for _, c := range update.Meta.CommitCount.ByEmail {
+		if c != nil {
+			n += c.Count
+		}
+	}
+	return n
+}
+
+func (n *Notifier) NewRelease(ctx context.Context, repo *db.Repo, tag *types.TagReference) {
+	msg := Message{
+		Event: EventRelease,
+		Ref:   tag.Name,
+	}
+	if tag.Tag != nil {
+		msg.Body = tag.Tag.Message
+	}
+
+	n.post(repo, repo.Did, fmt.Sprintf("tags#%s", tag.Name), msg)
+}
+
+func (n *Notifier) NewIssue(ctx context.Context, issue *db.Issue) {
+	repo, err := db.GetRepoByAtUri(n.db, issue.RepoAt.String())
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", issue.RepoAt, "err", err)
+		return
+	}
+
+	n.post(repo, issue.OwnerDid, fmt.Sprintf("issues/%d", issue.IssueId), Message{
+		Event:  EventIssue,
+		Number: issue.IssueId,
+		Title:  issue.Title,
+		Body:   issue.Body,
+	})
+}
+
+func (n *Notifier) NewPull(ctx context.Context, pull *db.Pull) {
+	repo, err := db.GetRepoByAtUri(n.db, pull.RepoAt.String())
+	if err != nil {
+		n.logger.Error("failed to get repo", "repo", pull.RepoAt, "err", err)
+		return
+	}
+
+	n.post(repo, pull.OwnerDid, fmt.Sprintf("pulls/%d", pull.PullId), Message{
+		Event:  EventPull,
+		Number: pull.PullId,
+		Title:  pull.Title,
+		Body:   pull.Body,
+	})
+}
+
+// post sends msg to every integration of repo subscribed to its event. path
+// is relative to the repo's page.
+func (n *Notifier) post(repo *db.Repo, actor, path string, msg Message) {
+	integrations, err := db.GetIntegrations(n.db, db.FilterEq("repo_at", repo.RepoAt()))
+	if err != nil {
+		n.logger.Error("failed to get integrations", "repo", repo.RepoAt(), "err", err)
+		return
+	}
+
+	var subscribed []db.Integration
+	for _, i := range integrations {
+		if i.HasEvent(msg.Event) {
+			subscribed = append(subscribed, i)
+		}
+	}
+	if len(subscribed) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		msg.Actor = n.handle(ctx, actor)
+		msg.Repo = n.handle(ctx, repo.Did) + "/" + repo.Name
+		msg.Url = fmt.Sprintf("%s/%s/%s", n.config.Core.AppviewHost, msg.Repo, path)
+
+		for _, i := range subscribed {
+			text, err := Render(i.Templates, msg)
+			if err != nil {
+				n.logger.Error("failed to render message", "integration", i.Id, "err", err)
+				continue
+			}
+
+			if err := n.sender.Send(ctx, i, text); err != nil {
+				n.logger.Error("failed to post to integration", "integration", i.Id, "kind", i.Kind, "err", err)
+			}
+		}
+	}()
+}
+
+func (n *Notifier) handle(ctx context.Context, did string) string {
+	if id, err := n.idResolver.ResolveIdent(ctx, did); err == nil && !id.Handle.IsInvalidHandle() {
+		return "@" + id.Handle.String()
+	}
+	return did
+}
@@ -0,0 +1,171 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/publicnet"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// Validate checks an integration before it is saved. Webhooks have to point
+// at the service they claim to be, so that the appview cannot be used to
+// post to arbitrary urls.
+func Validate(i db.Integration, dev bool) error {
+	if !i.Kind.IsValid() {
+		return errors.New("Unknown integration.")
+	}
+
+	switch i.Kind {
+	case db.IntegrationIRC:
+		u, err := url.Parse(i.Url)
+		if err != nil || u.Host == "" || (u.Scheme != "ircs" && u.Scheme != "irc") {
+			return errors.New("IRC servers are given as ircs://nick@irc.example.org:6697.")
+		}
+		if !strings.HasPrefix(i.Room, "#") && !strings.HasPrefix(i.Room, "&") {
+			return errors.New("IRC channels start with #.")
+		}
+		if strings.ContainsAny(i.Room+i.Token+u.User.Username(), " \r\n") {
+			return errors.New("Channel, nick and password cannot contain spaces.")
+		}
+	case db.IntegrationXMPP:
+		if _, _, ok := parseJid(i.Url); !ok {
+			return errors.New("XMPP accounts are given by their JID, such as bot@example.org.")
+		}
+		if _, _, ok := parseJid(i.Room); !ok {
+			return errors.New("XMPP rooms are given by their JID, such as room@conference.example.org.")
+		}
+		if i.Token == "" {
+			return errors.New("XMPP integrations need the account's password.")
+		}
+	default:
+		u, err := url.Parse(i.Url)
+		if err != nil || u.Host == "" {
+			return errors.New("Invalid URL.")
+		}
+		if u.Scheme != "https" && !(dev && u.Scheme == "http") {
+			return errors.New("URL must use https.")
+		}
+		return validateHttp(i, u)
+	}
+
+	return validateTemplates(i)
+}
+
+func validateHttp(i db.Integration, u *url.URL) error {
+	switch i.Kind {
+	case db.IntegrationDiscord:
+		if (u.Host != "discord.com" && u.Host != "discordapp.com") || !strings.HasPrefix(u.Path, "/api/webhooks/") {
+			return errors.New("Not a Discord webhook URL.")
+		}
+	case db.IntegrationSlack:
+		if u.Host != "hooks.slack.com" || !strings.HasPrefix(u.Path, "/services/") {
+			return errors.New("Not a Slack webhook URL.")
+		}
+	case db.IntegrationMatrix:
+		if !strings.HasPrefix(i.Room, "!") {
+			return errors.New("Matrix rooms are given by their ID, such as !abc:example.org.")
+		}
+		if i.Token == "" {
+			return errors.New("Matrix integrations need an access token.")
+		}
+	}
+
+	return validateTemplates(i)
+}
+
+func validateTemplates(i db.Integration) error {
+	for event, text := range i.Templates {
+		if _, err := ParseTemplate(event, text); err != nil {
+			return fmt.Errorf("Invalid template for %s events: %w", event, err)
+		}
+	}
+
+	return nil
+}
+
+// Sender delivers messages to integrations. Unless in dev, it refuses to
+// connect to private addresses, as integrations can point at any host.
+type Sender struct {
+	dialer *net.Dialer
+	http   *http.Client
+}
+
+func NewSender(dev bool) *Sender {
+	dialer := publicnet.Dialer(30*time.Second, dev)
+
+	return &Sender{
+		dialer: dialer,
+		http:   publicnet.Client(dialer, 30*time.Second),
+	}
+}
+
+// Send posts text to the integration's chat
+func (s *Sender) Send(ctx context.Context, i db.Integration, text string) error {
+	var method, target string
+	var payload any
+
+	switch i.Kind {
+	case db.IntegrationIRC:
+		return s.sendIRC(ctx, i, text)
+	case db.IntegrationXMPP:
+		return s.sendXMPP(ctx, i, text)
+	case db.IntegrationDiscord:
+		method, target = http.MethodPost, i.Url
+		payload = map[string]any{
+			"content": text,
+			// mentions in commit messages or issue titles should not ping
+			"allowed_mentions": map[string]any{"parse": []string{}},
+		}
+	case db.IntegrationSlack:
+		method, target = http.MethodPost, i.Url
+		payload = map[string]any{"text": text}
+	case db.IntegrationMatrix:
+		method = http.MethodPut
+		target = fmt.Sprintf(
+			"%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+			strings.TrimSuffix(i.Url, "/"),
+			url.PathEscape(i.Room),
+			tid.TID(),
+		)
+		payload = map[string]any{"msgtype": "m.notice", "body": text}
+	default:
+		return fmt.Errorf("unknown integration kind %q", i.Kind)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if i.Kind == db.IntegrationMatrix {
+		req.Header.Set("Authorization", "Bearer "+i.Token)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
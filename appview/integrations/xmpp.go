@@ -0,0 +1,258 @@
+package integrations
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+const (
+	nsStream = "http://etherx.jabber.org/streams"
+	nsTLS    = "urn:ietf:params:xml:ns:xmpp-tls"
+	nsSASL   = "urn:ietf:params:xml:ns:xmpp-sasl"
+	nsBind   = "urn:ietf:params:xml:ns:xmpp-bind"
+	nsMUC    = "http://jabber.org/protocol/muc"
+)
+
+// parseJid splits a bare jid into its local part and domain
+func parseJid(jid string) (string, string, bool) {
+	jid = strings.TrimPrefix(jid, "xmpp:")
+	local, domain, ok := strings.Cut(jid, "@")
+	if !ok || local == "" || domain == "" || strings.ContainsAny(jid, "/ <>'\"&") {
+		return "", "", false
+	}
+	return local, domain, true
+}
+
+type xmppFeatures struct {
+	StartTLS   *struct{} `xml:"urn:ietf:params:xml:ns:xmpp-tls starttls"`
+	Mechanisms []string  `xml:"urn:ietf:params:xml:ns:xmpp-sasl mechanisms>mechanism"`
+	Bind       *struct{} `xml:"urn:ietf:params:xml:ns:xmpp-bind bind"`
+}
+
+type xmppElement struct {
+	XMLName xml.Name
+	From    string `xml:"from,attr"`
+	Type    string `xml:"type,attr"`
+	Inner   []byte `xml:",innerxml"`
+}
+
+// xmppConn is just enough of a client to log in, join a room and say
+// something there
+type xmppConn struct {
+	conn   net.Conn
+	dec    *xml.Decoder
+	domain string
+}
+
+// sendXMPP logs in as the bot, joins the room, says text and logs out
+func (s *Sender) sendXMPP(ctx context.Context, i db.Integration, text string) error {
+	local, domain, ok := parseJid(i.Url)
+	if !ok {
+		return fmt.Errorf("invalid jid %q", i.Url)
+	}
+	room := strings.TrimPrefix(i.Room, "xmpp:")
+
+	conn, err := s.dialer.DialContext(ctx, "tcp", xmppAddr(ctx, domain))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	conn.SetDeadline(deadline)
+
+	x := &xmppConn{conn: conn, domain: domain}
+
+	features, err := x.open()
+	if err != nil {
+		return err
+	}
+
+	// passwords are only ever sent over tls
+	if features.StartTLS == nil {
+		return errors.New("xmpp server does not offer starttls")
+	}
+	if err := x.write(fmt.Sprintf("<starttls xmlns='%s'/>", nsTLS)); err != nil {
+		return err
+	}
+	if el, err := x.next(); err != nil {
+		return err
+	} else if el.XMLName.Local != "proceed" {
+		return fmt.Errorf("starttls: got %s", el.XMLName.Local)
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: domain})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return err
+	}
+	x.conn = tlsConn
+
+	features, err = x.open()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(features.Mechanisms, "PLAIN") {
+		return errors.New("xmpp server does not offer plain authentication")
+	}
+
+	creds := base64.StdEncoding.EncodeToString([]byte("\x00" + local + "\x00" + i.Token))
+	if err := x.write(fmt.Sprintf("<auth xmlns='%s' mechanism='PLAIN'>%s</auth>", nsSASL, creds)); err != nil {
+		return err
+	}
+	if el, err := x.next(); err != nil {
+		return err
+	} else if el.XMLName.Local != "success" {
+		return errors.New("xmpp authentication failed")
+	}
+
+	if _, err := x.open(); err != nil {
+		return err
+	}
+
+	resource := "tangled-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	err = x.write(fmt.Sprintf(
+		"<iq type='set' id='bind'><bind xmlns='%s'><resource>%s</resource></bind></iq>",
+		nsBind, resource,
+	))
+	if err != nil {
+		return err
+	}
+	if el, err := x.next(); err != nil {
+		return err
+	} else if el.XMLName.Local != "iq" || el.Type != "result" {
+		return errors.New("xmpp resource binding failed")
+	}
+
+	occupant := room + "/" + local
+	err = x.write(fmt.Sprintf(
+		"<presence to='%s'><x xmlns='%s'><history maxchars='0'/></x></presence>",
+		escapeXml(occupant), nsMUC,
+	))
+	if err != nil {
+		return err
+	}
+
+	// the room echoes our own presence back once we are in
+	for {
+		el, err := x.next()
+		if err != nil {
+			return err
+		}
+		if el.XMLName.Local != "presence" || !strings.HasPrefix(el.From, room+"/") {
+			continue
+		}
+		if el.Type == "error" {
+			return fmt.Errorf("joining %s: %s", room, strings.TrimSpace(string(el.Inner)))
+		}
+		if el.From == occupant || strings.Contains(string(el.Inner), "code='110'") || strings.Contains(string(el.Inner), `code="110"`) {
+			break
+		}
+	}
+
+	err = x.write(fmt.Sprintf(
+		"<message to='%s' type='groupchat'><body>%s</body></message>",
+		escapeXml(room), escapeXml(text),
+	))
+	if err != nil {
+		return err
+	}
+
+	return x.write(fmt.Sprintf("<presence to='%s' type='unavailable'/></stream:stream>", escapeXml(occupant)))
+}
+
+// xmppAddr finds the client port of the domain's server through SRV
+// records, falling back to the domain itself
+func xmppAddr(ctx context.Context, domain string) string {
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "xmpp-client", "tcp", domain)
+	if err == nil && len(addrs) > 0 && addrs[0].Target != "." {
+		return net.JoinHostPort(strings.TrimSuffix(addrs[0].Target, "."), strconv.Itoa(int(addrs[0].Port)))
+	}
+	return net.JoinHostPort(domain, "5222")
+}
+
+// open starts a new stream, as is done on connecting and after tls and
+// authentication, and returns the features the server offers on it
+func (x *xmppConn) open() (*xmppFeatures, error) {
+	err := x.write(fmt.Sprintf(
+		"<?xml version='1.0'?><stream:stream to='%s' xmlns='jabber:client' xmlns:stream='%s' version='1.0'>",
+		escapeXml(x.domain), nsStream,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	x.dec = xml.NewDecoder(x.conn)
+	for {
+		tok, err := x.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Space == nsStream && start.Name.Local == "stream" {
+			continue
+		}
+		if start.Name.Space == nsStream && start.Name.Local == "features" {
+			var features xmppFeatures
+			if err := x.dec.DecodeElement(&features, &start); err != nil {
+				return nil, err
+			}
+			return &features, nil
+		}
+		return nil, fmt.Errorf("expected stream features, got %s", start.Name.Local)
+	}
+}
+
+// next reads the next top level element of the stream
+func (x *xmppConn) next() (*xmppElement, error) {
+	for {
+		tok, err := x.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var el xmppElement
+			if err := x.dec.DecodeElement(&el, &t); err != nil {
+				return nil, err
+			}
+			if t.Name.Space == nsStream && t.Name.Local == "error" {
+				return nil, fmt.Errorf("xmpp stream error: %s", strings.TrimSpace(string(el.Inner)))
+			}
+			return &el, nil
+		case xml.EndElement:
+			if t.Name.Space == nsStream && t.Name.Local == "stream" {
+				return nil, io.EOF
+			}
+		}
+	}
+}
+
+func (x *xmppConn) write(s string) error {
+	_, err := io.WriteString(x.conn, s)
+	return err
+}
+
+func escapeXml(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,350 @@
+package issues
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/github"
+	"tangled.sh/tangled.sh/core/appview/notify"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+const (
+	// left in everything the bridge posts to github, so that polling does
+	// not bring the bridge's own posts back
+	bridgeMarker = "<!-- tangled-bridge -->"
+
+	bridgeInterval = 5 * time.Minute
+)
+
+// Bridge keeps issues and comments in sync between repos and the GitHub
+// repos they are bridged to. New issues and comments are sent over as they
+// are created, and GitHub is polled for new ones in the other direction.
+type Bridge struct {
+	notify.BaseNotifier
+
+	db         *db.DB
+	idResolver *idresolver.Resolver
+	config     *config.Config
+	logger     *slog.Logger
+}
+
+var _ notify.Notifier = &Bridge{}
+
+func NewBridge(d *db.DB, idResolver *idresolver.Resolver, config *config.Config, logger *slog.Logger) *Bridge {
+	return &Bridge{
+		db:         d,
+		idResolver: idResolver,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// Start polls every bridged repo on GitHub until ctx is done
+func (b *Bridge) Start(ctx context.Context) {
+	if b.config.Github.TokenKey == "" {
+		b.logger.Warn("no token key configured, github bridges are disabled")
+		return
+	}
+
+	go func() {
+		if err := b.sealTokens(); err != nil {
+			b.logger.Error("failed to seal tokens", "err", err)
+		}
+
+		ticker := time.NewTicker(bridgeInterval)
+		defer ticker.Stop()
+
+		for {
+			bridges, err := db.GetGithubBridges(b.db)
+			if err != nil {
+				b.logger.Error("failed to get bridges", "err", err)
+			}
+
+			for _, bridge := range bridges {
+				if err := b.pull(ctx, bridge); err != nil {
+					b.logger.Error("failed to sync from github", "repo", bridge.RepoAt, "github", bridge.GithubRepo, "err", err)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sealTokens seals the tokens of bridges that were set up before tokens
+// were stored sealed
+func (b *Bridge) sealTokens() error {
+	bridges, err := db.GetGithubBridges(b.db)
+	if err != nil {
+		return err
+	}
+
+	for _, bridge := range bridges {
+		if github.IsSealed(bridge.Token) {
+			continue
+		}
+
+		sealed, err := github.SealToken(b.config.Github.TokenKey, bridge.Token)
+		if err != nil {
+			return err
+		}
+		if err := db.SetGithubBridgeToken(b.db, bridge.RepoAt, sealed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// client is a github client authenticated as the bridge
+func (b *Bridge) client(bridge db.GithubBridge) (*github.Client, error) {
+	token, err := github.OpenToken(b.config.Github.TokenKey, bridge.Token)
+	if err != nil {
+		return nil, fmt.Errorf("opening token: %w", err)
+	}
+	return github.NewClient(token), nil
+}
+
+// pull brings over issues and comments created on GitHub since the last
+// sync, and carries over the state of issues that are already linked
+func (b *Bridge) pull(ctx context.Context, bridge db.GithubBridge) error {
+	owner, name, err := github.ParseRepo(bridge.GithubRepo)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if bridge.SyncedAt != nil {
+		since = *bridge.SyncedAt
+	}
+	// anything that changes while syncing is picked up on the next run
+	started := time.Now()
+
+	gh, err := b.client(bridge)
+	if err != nil {
+		return err
+	}
+	// authors that cannot be mapped are attributed to the repo owner
+	imp := newImporter(b.db, b.idResolver, gh, bridge.RepoAt, bridge.RepoAt.Authority().String())
+
+	issues, err := gh.Issues(ctx, owner, name, since)
+	if err != nil {
+		return err
+	}
+
+	for _, gi := range issues {
+		issueId, err := db.GetIssueIdByGithubUrl(b.db, bridge.RepoAt, gi.HtmlUrl)
+		switch {
+		case err == nil:
+			if err := b.pullState(bridge.RepoAt, issueId, gi.State == "open"); err != nil {
+				return err
+			}
+		case errors.Is(err, sql.ErrNoRows):
+			if strings.Contains(gi.Body, bridgeMarker) {
+				continue
+			}
+			if err := imp.importIssue(ctx, gi, nil); err != nil {
+				return fmt.Errorf("importing #%d: %w", gi.Number, err)
+			}
+		default:
+			return err
+		}
+	}
+
+	comments, err := gh.Comments(ctx, owner, name, since)
+	if err != nil {
+		return err
+	}
+
+	for _, gc := range comments {
+		if strings.Contains(gc.Body, bridgeMarker) {
+			continue
+		}
+
+		linked, err := db.IsGithubUrlLinked(b.db, bridge.RepoAt, gc.HtmlUrl)
+		if err != nil {
+			return err
+		}
+		if linked {
+			continue
+		}
+
+		issueId, err := db.GetIssueIdByGithubUrl(b.db, bridge.RepoAt, gc.IssueHtmlUrl())
+		if errors.Is(err, sql.ErrNoRows) {
+			// a comment on a pull request
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		tx, err := b.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := imp.importComment(ctx, tx, issueId, gc); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return db.MarkGithubBridgeSynced(b.db, bridge.RepoAt, started)
+}
+
+func (b *Bridge) pullState(repoAt syntax.ATURI, issueId int, open bool) error {
+	issue, err := db.GetIssue(b.db, repoAt, issueId)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case issue.Open && !open:
+		return db.CloseIssue(b.db, repoAt, issueId)
+	case !issue.Open && open:
+		return db.ReopenIssue(b.db, repoAt, issueId)
+	}
+	return nil
+}
+
+func (b *Bridge) NewIssue(ctx context.Context, issue *db.Issue) {
+	bridge, ok := b.bridgeFor(issue.RepoAt)
+	if !ok {
+		return
+	}
+
+	// the request that created the issue should not wait on GitHub
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		owner, name, err := github.ParseRepo(bridge.GithubRepo)
+		if err != nil {
+			b.logger.Error("invalid bridge", "repo", issue.RepoAt, "err", err)
+			return
+		}
+
+		link := b.issueLink(ctx, issue.RepoAt, issue.IssueId)
+		body := b.attributed(ctx, issue.OwnerDid, fmt.Sprintf("opened [#%d](%s)", issue.IssueId, link), issue.Body)
+
+		gh, err := b.client(*bridge)
+		if err != nil {
+			b.logger.Error("failed to create github client", "repo", issue.RepoAt, "err", err)
+			return
+		}
+
+		gi, err := gh.CreateIssue(ctx, owner, name, issue.Title, body)
+		if err != nil {
+			b.logger.Error("failed to create issue on github", "repo", issue.RepoAt, "issue", issue.IssueId, "err", err)
+			return
+		}
+
+		if err := db.AddGithubBridgeItem(b.db, issue.RepoAt, issue.IssueId, 0, gi.HtmlUrl); err != nil {
+			b.logger.Error("failed to link issue", "repo", issue.RepoAt, "issue", issue.IssueId, "err", err)
+		}
+	}()
+}
+
+func (b *Bridge) NewIssueComment(ctx context.Context, comment *db.Comment) {
+	bridge, ok := b.bridgeFor(comment.RepoAt)
+	if !ok {
+		return
+	}
+
+	githubUrl, err := db.GetIssueGithubUrl(b.db, comment.RepoAt, comment.Issue)
+	if err != nil {
+		// the issue predates the bridge
+		return
+	}
+
+	number, err := strconv.Atoi(path.Base(githubUrl))
+	if err != nil {
+		b.logger.Error("invalid github issue url", "url", githubUrl)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		owner, name, err := github.ParseRepo(bridge.GithubRepo)
+		if err != nil {
+			b.logger.Error("invalid bridge", "repo", comment.RepoAt, "err", err)
+			return
+		}
+
+		link := fmt.Sprintf("%s#comment-%d", b.issueLink(ctx, comment.RepoAt, comment.Issue), comment.CommentId)
+		body := b.attributed(ctx, comment.OwnerDid, fmt.Sprintf("[commented](%s)", link), comment.Body)
+
+		gh, err := b.client(*bridge)
+		if err != nil {
+			b.logger.Error("failed to create github client", "repo", comment.RepoAt, "err", err)
+			return
+		}
+
+		gc, err := gh.CreateComment(ctx, owner, name, number, body)
+		if err != nil {
+			b.logger.Error("failed to create comment on github", "repo", comment.RepoAt, "issue", comment.Issue, "err", err)
+			return
+		}
+
+		if err := db.AddGithubBridgeItem(b.db, comment.RepoAt, comment.Issue, comment.CommentId, gc.HtmlUrl); err != nil {
+			b.logger.Error("failed to link comment", "repo", comment.RepoAt, "issue", comment.Issue, "err", err)
+		}
+	}()
+}
+
+func (b *Bridge) bridgeFor(repoAt syntax.ATURI) (*db.GithubBridge, bool) {
+	bridge, err := db.GetGithubBridge(b.db, repoAt)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			b.logger.Error("failed to get bridge", "repo", repoAt, "err", err)
+		}
+		return nil, false
+	}
+	return bridge, true
+}
+
+func (b *Bridge) issueLink(ctx context.Context, repoAt syntax.ATURI, issueId int) string {
+	base := strings.TrimSuffix(b.config.Core.AppviewHost, "/")
+
+	repo, err := db.GetRepoByAtUri(b.db, repoAt.String())
+	if err != nil {
+		return base
+	}
+
+	return fmt.Sprintf("%s/%s/%s/issues/%d", base, b.displayName(ctx, repo.Did), repo.Name, issueId)
+}
+
+// attributed credits the author of a post made on their behalf
+func (b *Bridge) attributed(ctx context.Context, did, action, body string) string {
+	author := b.displayName(ctx, did)
+	profile := fmt.Sprintf("%s/%s", strings.TrimSuffix(b.config.Core.AppviewHost, "/"), author)
+
+	return fmt.Sprintf("**[%s](%s)** %s on tangled:\n\n%s\n\n%s", author, profile, action, body, bridgeMarker)
+}
+
+func (b *Bridge) displayName(ctx context.Context, did string) string {
+	ident, err := b.idResolver.ResolveIdent(ctx, did)
+	if err != nil || ident.Handle.IsInvalidHandle() {
+		return did
+	}
+	return "@" + ident.Handle.String()
+}
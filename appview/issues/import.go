@@ -0,0 +1,265 @@
+package issues
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/github"
+	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// repos with an import in progress, keyed by repo at-uri
+var importing sync.Map
+
+// ImportIssues brings the issues and comments of a GitHub repo over into
+// this repo. It runs in the background, since larger repos take a good
+// number of API calls, and can be re-run to pick up new issues.
+func (rp *Issues) ImportIssues(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	noticeId := "import-issues"
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		rp.pages.Notice(w, noticeId, "Failed to import issues.")
+		return
+	}
+
+	owner, name, err := github.ParseRepo(r.FormValue("source"))
+	if err != nil {
+		rp.pages.Notice(w, noticeId, "Enter a GitHub repository, such as owner/repo.")
+		return
+	}
+
+	repoAt := f.RepoAt()
+	if _, busy := importing.LoadOrStore(repoAt, struct{}{}); busy {
+		rp.pages.Notice(w, noticeId, "An import is already running for this repository.")
+		return
+	}
+
+	imp := newImporter(rp.db, rp.idResolver, github.NewClient(r.FormValue("token")), repoAt, user.Did)
+
+	go func() {
+		defer importing.Delete(repoAt)
+
+		// not tied to the request, which is over long before the import is
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+
+		n, err := imp.run(ctx, owner, name)
+		if err != nil {
+			log.Printf("issue import from github.com/%s/%s into %s stopped after %d issues: %v", owner, name, repoAt, n, err)
+			return
+		}
+		log.Printf("imported %d issues from github.com/%s/%s into %s", n, owner, name, repoAt)
+	}()
+
+	rp.pages.Notice(w, noticeId, "Import started, issues will show up as they are brought over.")
+}
+
+type importer struct {
+	db         *db.DB
+	idResolver *idresolver.Resolver
+	gh         *github.Client
+	repoAt     syntax.ATURI
+	importer   string
+
+	// github login -> did, empty if the author could not be mapped
+	authors map[string]string
+}
+
+func newImporter(d *db.DB, idResolver *idresolver.Resolver, gh *github.Client, repoAt syntax.ATURI, fallbackDid string) *importer {
+	return &importer{
+		db:         d,
+		idResolver: idResolver,
+		gh:         gh,
+		repoAt:     repoAt,
+		importer:   fallbackDid,
+		authors:    make(map[string]string),
+	}
+}
+
+func (i *importer) run(ctx context.Context, owner, name string) (int, error) {
+	issues, err := i.gh.Issues(ctx, owner, name, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	imported, err := db.GetImportedIssueSources(i.db, i.repoAt)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, issue := range issues {
+		if _, ok := imported[issue.HtmlUrl]; ok {
+			continue
+		}
+
+		var comments []github.Comment
+		if issue.Comments > 0 {
+			comments, err = i.gh.IssueComments(ctx, owner, name, issue.Number)
+			if err != nil {
+				return n, err
+			}
+		}
+
+		if err := i.importIssue(ctx, issue, comments); err != nil {
+			return n, fmt.Errorf("importing #%d: %w", issue.Number, err)
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+func (i *importer) importIssue(ctx context.Context, gi github.Issue, comments []github.Comment) error {
+	ownerDid, mapped := i.author(ctx, gi.User.Login)
+
+	issue := &db.Issue{
+		RepoAt:   i.repoAt,
+		OwnerDid: ownerDid,
+		Rkey:     tid.TID(),
+		Title:    gi.Title,
+		Body:     gi.Body,
+		Open:     gi.State == "open",
+		Created:  gi.CreatedAt,
+	}
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = db.ImportIssue(tx, issue, db.IssueImport{
+		SourceUrl: gi.HtmlUrl,
+		Author:    gi.User.Login,
+		Mapped:    mapped,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, gc := range comments {
+		if err := i.importComment(ctx, tx, issue.IssueId, gc); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (i *importer) importComment(ctx context.Context, tx *sql.Tx, issueId int, gc github.Comment) error {
+	ownerDid, mapped := i.author(ctx, gc.User.Login)
+	created := gc.CreatedAt
+
+	return db.ImportIssueComment(tx, &db.Comment{
+		OwnerDid: ownerDid,
+		RepoAt:   i.repoAt,
+		Issue:    issueId,
+		Body:     gc.Body,
+		Created:  &created,
+	}, db.IssueImport{
+		SourceUrl: gc.HtmlUrl,
+		Author:    gc.User.Login,
+		Mapped:    mapped,
+	})
+}
+
+// author maps a github login to a did, falling back to the user running the
+// import if there is no verified link between the two accounts
+func (i *importer) author(ctx context.Context, login string) (string, bool) {
+	did, ok := i.authors[login]
+	if !ok {
+		var err error
+		did, err = i.verifiedDid(ctx, login)
+		if err != nil {
+			log.Printf("failed to map github user %s: %v", login, err)
+		}
+		i.authors[login] = did
+	}
+
+	if did == "" {
+		return i.importer, false
+	}
+	return did, true
+}
+
+// verifiedDid looks for a user that lists the github account on their
+// profile, and that is linked back to from the github account, either by
+// handle or by did
+func (i *importer) verifiedDid(ctx context.Context, login string) (string, error) {
+	var candidates []string
+	for _, host := range []string{"github.com", "www.github.com"} {
+		dids, err := db.GetDidsByProfileLink(i.db, fmt.Sprintf("%%://%s/%s", host, login))
+		if err != nil {
+			return "", err
+		}
+		candidates = append(candidates, dids...)
+	}
+
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	user, err := i.gh.User(ctx, login)
+	if err != nil {
+		return "", err
+	}
+
+	accounts, err := i.gh.SocialAccounts(ctx, login)
+	if err != nil {
+		return "", err
+	}
+
+	claims := []string{user.Blog, user.Bio}
+	for _, a := range accounts {
+		claims = append(claims, a.Url)
+	}
+
+	// compare whole tokens, so that "a.bsky.social" does not verify
+	// against a link to "aa.bsky.social"
+	claimed := make(map[string]struct{})
+	for _, token := range strings.FieldsFunc(strings.ToLower(strings.Join(claims, " ")), isClaimSeparator) {
+		claimed[strings.Trim(token, ".:-_")] = struct{}{}
+	}
+
+	for _, did := range candidates {
+		if _, ok := claimed[strings.ToLower(did)]; ok {
+			return did, nil
+		}
+
+		ident, err := i.idResolver.ResolveIdent(ctx, did)
+		if err != nil || ident.Handle.IsInvalidHandle() {
+			continue
+		}
+		if _, ok := claimed[strings.ToLower(ident.Handle.String())]; ok {
+			return did, nil
+		}
+	}
+
+	return "", nil
+}
+
+// handles and dids are made of letters, digits, dots, dashes and colons,
+// everything else separates them
+func isClaimSeparator(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		return false
+	case r == '.', r == '-', r == ':', r == '_':
+		return false
+	default:
+		return true
+	}
+}
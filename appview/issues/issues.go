@@ -12,6 +12,7 @@ import (
 
 	comatproto "github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/atproto/data"
+	"github.com/bluesky-social/indigo/atproto/syntax"
 	lexutil "github.com/bluesky-social/indigo/lex/util"
 	"github.com/go-chi/chi/v5"
 
@@ -24,7 +25,9 @@ import (
 	"tangled.sh/tangled.sh/core/appview/pages/markup"
 	"tangled.sh/tangled.sh/core/appview/pagination"
 	"tangled.sh/tangled.sh/core/appview/reporesolver"
+	"tangled.sh/tangled.sh/core/appview/spam"
 	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/rbac"
 	"tangled.sh/tangled.sh/core/tid"
 )
 
@@ -36,6 +39,8 @@ type Issues struct {
 	db           *db.DB
 	config       *config.Config
 	notifier     notify.Notifier
+	enforcer     *rbac.Enforcer
+	spam         *spam.Filter
 }
 
 func New(
@@ -46,6 +51,8 @@ func New(
 	db *db.DB,
 	config *config.Config,
 	notifier notify.Notifier,
+	enforcer *rbac.Enforcer,
+	spam *spam.Filter,
 ) *Issues {
 	return &Issues{
 		oauth:        oauth,
@@ -55,6 +62,8 @@ func New(
 		db:           db,
 		config:       config,
 		notifier:     notifier,
+		enforcer:     enforcer,
+		spam:         spam,
 	}
 }
 
@@ -81,6 +90,12 @@ func (rp *Issues) RepoSingleIssue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !canSeeHidden(user, f, issue.OwnerDid, issue.Hidden) {
+		rp.pages.Error404(w)
+		return
+	}
+	comments = visibleComments(user, f, comments)
+
 	reactionCountMap, err := db.GetReactionCountMap(rp.db, issue.AtUri())
 	if err != nil {
 		log.Println("failed to get issue reactions")
@@ -97,13 +112,31 @@ func (rp *Issues) RepoSingleIssue(w http.ResponseWriter, r *http.Request) {
 		log.Println("failed to resolve issue owner", err)
 	}
 
+	imports, err := db.GetIssueImports(rp.db, f.RepoAt(), issueIdInt)
+	if err != nil {
+		log.Println("failed to get issue imports", err)
+	}
+
+	events, err := db.GetThreadEvents(rp.db, db.FilterEq("subject_at", issue.AtUri()))
+	if err != nil {
+		log.Println("failed to get issue events", err)
+	}
+
+	edits, err := db.GetIssueEdits(rp.db, f.RepoAt(), issueIdInt)
+	if err != nil {
+		log.Println("failed to get issue edits", err)
+	}
+
 	rp.pages.RepoSingleIssue(w, pages.RepoSingleIssueParams{
 		LoggedInUser: user,
 		RepoInfo:     f.RepoInfo(user),
 		Issue:        issue,
 		Comments:     comments,
+		Thread:       db.IssueThread(comments, events),
+		Edits:        edits,
 
 		IssueOwnerHandle: issueOwnerIdent.Handle.String(),
+		Imports:          imports,
 
 		OrderedReactionKinds: db.OrderedReactionKinds,
 		Reactions:            reactionCountMap,
@@ -147,32 +180,7 @@ func (rp *Issues) CloseIssue(w http.ResponseWriter, r *http.Request) {
 	// TODO: make this more granular
 	if isIssueOwner || isCollaborator {
 
-		closed := tangled.RepoIssueStateClosed
-
-		client, err := rp.oauth.AuthorizedClient(r)
-		if err != nil {
-			log.Println("failed to get authorized client", err)
-			return
-		}
-		_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
-			Collection: tangled.RepoIssueStateNSID,
-			Repo:       user.Did,
-			Rkey:       tid.TID(),
-			Record: &lexutil.LexiconTypeDecoder{
-				Val: &tangled.RepoIssueState{
-					Issue: issue.AtUri().String(),
-					State: closed,
-				},
-			},
-		})
-
-		if err != nil {
-			log.Println("failed to update issue state", err)
-			rp.pages.Notice(w, "issue-action", "Failed to close issue. Try again later.")
-			return
-		}
-
-		err = db.CloseIssue(rp.db, f.RepoAt(), issueIdInt)
+		err = rp.setIssueState(r, user, f.RepoAt(), issue, false)
 		if err != nil {
 			log.Println("failed to close issue", err)
 			rp.pages.Notice(w, "issue-action", "Failed to close issue. Try again later.")
@@ -221,12 +229,13 @@ func (rp *Issues) ReopenIssue(w http.ResponseWriter, r *http.Request) {
 	isIssueOwner := user.Did == issue.OwnerDid
 
 	if isCollaborator || isIssueOwner {
-		err := db.ReopenIssue(rp.db, f.RepoAt(), issueIdInt)
+		err = rp.setIssueState(r, user, f.RepoAt(), issue, true)
 		if err != nil {
 			log.Println("failed to reopen issue", err)
 			rp.pages.Notice(w, "issue-action", "Failed to reopen issue. Try again later.")
 			return
 		}
+
 		rp.pages.HxLocation(w, fmt.Sprintf("/%s/issues/%d", f.OwnerSlashRepo(), issueIdInt))
 		return
 	} else {
@@ -236,6 +245,319 @@ func (rp *Issues) ReopenIssue(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// EditIssue lets the author of an issue or an owner of the repo change its
+// title and body. The record of the issue is only updated for its author,
+// edits by owners are local to the appview.
+func (rp *Issues) EditIssue(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issueId := chi.URLParam(r, "issue")
+	issueIdInt, err := strconv.Atoi(issueId)
+	if err != nil {
+		http.Error(w, "bad issue id", http.StatusBadRequest)
+		log.Println("failed to parse issue id", err)
+		return
+	}
+
+	issue, err := db.GetIssue(rp.db, f.RepoAt(), issueIdInt)
+	if err != nil {
+		log.Println("failed to get issue", err)
+		rp.pages.Notice(w, "issues", "Failed to load issue. Try again later.")
+		return
+	}
+
+	isIssueAuthor := user.Did == issue.OwnerDid
+	if !isIssueAuthor && !f.RolesInRepo(user).IsOwner() {
+		http.Error(w, "you are not allowed to edit this issue", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rp.pages.EditIssueFragment(w, pages.EditIssueParams{
+			LoggedInUser: user,
+			RepoInfo:     f.RepoInfo(user),
+			Issue:        issue,
+		})
+	case http.MethodPost:
+		title := strings.TrimSpace(r.FormValue("title"))
+		body := r.FormValue("body")
+
+		if title == "" || body == "" {
+			rp.pages.Notice(w, "issue-edit", "Title and body are required.")
+			return
+		}
+		if err := rp.config.Limits.CheckIssueBody(body); err != nil {
+			rp.pages.Notice(w, "issue-edit", err.Error())
+			return
+		}
+
+		sanitizer := markup.NewSanitizer()
+		if st := strings.TrimSpace(sanitizer.SanitizeDescription(title)); st == "" {
+			rp.pages.Notice(w, "issue-edit", "Title is empty after HTML sanitization.")
+			return
+		}
+		if sb := strings.TrimSpace(sanitizer.SanitizeDefault(body)); sb == "" {
+			rp.pages.Notice(w, "issue-edit", "Body is empty after HTML sanitization.")
+			return
+		}
+
+		if isIssueAuthor {
+			err = rp.putIssueRecord(r, user, issue, title, body)
+			if err != nil {
+				log.Println("failed to update issue record", err)
+				rp.pages.Notice(w, "issue-edit", "Failed to update issue, try again later.")
+				return
+			}
+		}
+
+		err = db.EditIssue(rp.db, f.RepoAt(), issueIdInt, user.Did, title, body)
+		if err != nil {
+			log.Println("failed to edit issue", err)
+			rp.pages.Notice(w, "issue-edit", "Failed to update issue, try again later.")
+			return
+		}
+
+		if title != issue.Title {
+			err = db.AddThreadEvent(rp.db, db.ThreadEvent{
+				RepoAt:    f.RepoAt(),
+				SubjectAt: issue.AtUri(),
+				ActorDid:  user.Did,
+				Kind:      db.ThreadEventRenamed,
+				Value:     title,
+			})
+			if err != nil {
+				log.Println("failed to add thread event", err)
+			}
+		}
+
+		rp.pages.HxLocation(w, fmt.Sprintf("/%s/issues/%d", f.OwnerSlashRepo(), issueIdInt))
+	}
+}
+
+// ToggleIssueTask checks or unchecks an item of a task list in the body of
+// an issue, for its author
+func (rp *Issues) ToggleIssueTask(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issueIdInt, err := strconv.Atoi(chi.URLParam(r, "issue"))
+	if err != nil {
+		http.Error(w, "bad issue id", http.StatusBadRequest)
+		return
+	}
+
+	position, err := strconv.Atoi(r.FormValue("position"))
+	if err != nil {
+		http.Error(w, "bad task position", http.StatusBadRequest)
+		return
+	}
+	checked := r.FormValue("checked") == "true"
+
+	issue, err := db.GetIssue(rp.db, f.RepoAt(), issueIdInt)
+	if err != nil {
+		log.Println("failed to get issue", err)
+		http.Error(w, "issue not found", http.StatusNotFound)
+		return
+	}
+
+	if user.Did != issue.OwnerDid {
+		http.Error(w, "only the author can update tasks", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := markup.ToggleTask(issue.Body, position, checked)
+	if err != nil {
+		http.Error(w, "the issue has changed, reload the page and try again", http.StatusConflict)
+		return
+	}
+
+	err = rp.putIssueRecord(r, user, issue, issue.Title, body)
+	if err != nil {
+		log.Println("failed to update issue record", err)
+		http.Error(w, "failed to update issue", http.StatusBadGateway)
+		return
+	}
+
+	err = db.EditIssue(rp.db, f.RepoAt(), issueIdInt, user.Did, issue.Title, body)
+	if err != nil {
+		log.Println("failed to edit issue", err)
+		http.Error(w, "failed to update issue", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// putIssueRecord updates the record of an issue on the PDS of its author
+func (rp *Issues) putIssueRecord(r *http.Request, user *oauth.User, issue *db.Issue, title, body string) error {
+	// rkey is optional, it was introduced later
+	if issue.Rkey == "" {
+		return nil
+	}
+
+	client, err := rp.oauth.AuthorizedClient(r)
+	if err != nil {
+		return fmt.Errorf("failed to get authorized client: %w", err)
+	}
+
+	ex, err := client.RepoGetRecord(r.Context(), "", tangled.RepoIssueNSID, user.Did, issue.Rkey)
+	if err != nil {
+		return fmt.Errorf("failed to get issue record: %w", err)
+	}
+
+	record, ok := ex.Value.Val.(*tangled.RepoIssue)
+	if !ok {
+		return fmt.Errorf("invalid issue record %s", issue.Rkey)
+	}
+	record.Title = title
+	record.Body = &body
+
+	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoIssueNSID,
+		Repo:       user.Did,
+		Rkey:       issue.Rkey,
+		SwapRecord: ex.Cid,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: record,
+		},
+	})
+	return err
+}
+
+// SimilarIssues suggests existing issues that may be duplicates of the one
+// being written, given its title
+func (rp *Issues) SimilarIssues(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issues, err := db.SearchSimilarIssues(rp.db, f.RepoAt(), r.URL.Query().Get("title"), 5)
+	if err != nil {
+		log.Println("failed to search issues", err)
+	}
+
+	rp.pages.SimilarIssuesFragment(w, pages.SimilarIssuesParams{
+		RepoInfo: f.RepoInfo(user),
+		Issues:   issues,
+	})
+}
+
+// IssueHistory lists the previous versions of an edited issue
+func (rp *Issues) IssueHistory(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issueId := chi.URLParam(r, "issue")
+	issueIdInt, err := strconv.Atoi(issueId)
+	if err != nil {
+		http.Error(w, "bad issue id", http.StatusBadRequest)
+		log.Println("failed to parse issue id", err)
+		return
+	}
+
+	issue, err := db.GetIssue(rp.db, f.RepoAt(), issueIdInt)
+	if err != nil || !canSeeHidden(user, f, issue.OwnerDid, issue.Hidden) {
+		log.Println("failed to get issue", err)
+		rp.pages.Error404(w)
+		return
+	}
+
+	edits, err := db.GetIssueEdits(rp.db, f.RepoAt(), issueIdInt)
+	if err != nil {
+		log.Println("failed to get issue edits", err)
+		rp.pages.Error503(w)
+		return
+	}
+
+	rp.pages.RepoIssueHistory(w, pages.RepoIssueHistoryParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Issue:        issue,
+		Edits:        edits,
+	})
+}
+
+// addStateEvent records a state change in the thread of an issue, keyed by
+// the state record so that it is not recorded again when it is ingested
+// setIssueState records the new state of an issue on the PDS of the actor,
+// and applies it locally
+func (rp *Issues) setIssueState(r *http.Request, user *oauth.User, repoAt syntax.ATURI, issue *db.Issue, open bool) error {
+	state := tangled.RepoIssueStateClosed
+	kind := db.ThreadEventClosed
+	if open {
+		state = tangled.RepoIssueStateOpen
+		kind = db.ThreadEventReopened
+	}
+
+	client, err := rp.oauth.AuthorizedClient(r)
+	if err != nil {
+		return fmt.Errorf("failed to get authorized client: %w", err)
+	}
+
+	stateRkey := tid.TID()
+	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoIssueStateNSID,
+		Repo:       user.Did,
+		Rkey:       stateRkey,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &tangled.RepoIssueState{
+				Issue: issue.AtUri().String(),
+				State: state,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update issue state: %w", err)
+	}
+
+	if open {
+		err = db.ReopenIssue(rp.db, repoAt, issue.IssueId)
+	} else {
+		err = db.CloseIssue(rp.db, repoAt, issue.IssueId)
+	}
+	if err != nil {
+		return err
+	}
+
+	rp.addStateEvent(repoAt, issue, user.Did, stateRkey, kind)
+
+	if !open {
+		rp.notifier.NewIssueClosed(r.Context(), issue, user.Did)
+	}
+	return nil
+}
+
+func (rp *Issues) addStateEvent(repoAt syntax.ATURI, issue *db.Issue, actorDid, stateRkey string, kind db.ThreadEventKind) {
+	sourceAt := syntax.ATURI(fmt.Sprintf("at://%s/%s/%s", actorDid, tangled.RepoIssueStateNSID, stateRkey))
+	err := db.AddThreadEvent(rp.db, db.ThreadEvent{
+		RepoAt:    repoAt,
+		SubjectAt: issue.AtUri(),
+		ActorDid:  actorDid,
+		Kind:      kind,
+		SourceAt:  &sourceAt,
+	})
+	if err != nil {
+		log.Println("failed to add thread event", err)
+	}
+}
+
 func (rp *Issues) NewIssueComment(w http.ResponseWriter, r *http.Request) {
 	user := rp.oauth.GetUser(r)
 	f, err := rp.repoResolver.Resolve(r)
@@ -259,26 +581,15 @@ func (rp *Issues) NewIssueComment(w http.ResponseWriter, r *http.Request) {
 			rp.pages.Notice(w, "issue", "Body is required")
 			return
 		}
+		if err := rp.config.Limits.CheckCommentBody(body); err != nil {
+			rp.pages.Notice(w, "issue-comment", err.Error())
+			return
+		}
 
 		commentId := mathrand.IntN(1000000)
 		rkey := tid.TID()
+		createdAt := time.Now()
 
-		err := db.NewIssueComment(rp.db, &db.Comment{
-			OwnerDid:  user.Did,
-			RepoAt:    f.RepoAt(),
-			Issue:     issueIdInt,
-			CommentId: commentId,
-			Body:      body,
-			Rkey:      rkey,
-		})
-		if err != nil {
-			log.Println("failed to create comment", err)
-			rp.pages.Notice(w, "issue-comment", "Failed to create comment.")
-			return
-		}
-
-		createdAt := time.Now().Format(time.RFC3339)
-		ownerDid := user.Did
 		issueAt, err := db.GetIssueAt(rp.db, f.RepoAt(), issueIdInt)
 		if err != nil {
 			log.Println("failed to get issue at", err)
@@ -286,6 +597,15 @@ func (rp *Issues) NewIssueComment(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		verdict := rp.spam.Check(r.Context(), spam.Content{
+			Kind:      spam.KindComment,
+			AuthorDid: user.Did,
+			RepoAt:    f.RepoAt(),
+			Body:      body,
+		})
+
+		// the record is written first, the appview is only an index over it
+		ownerDid := user.Did
 		atUri := f.RepoAt().String()
 		client, err := rp.oauth.AuthorizedClient(r)
 		if err != nil {
@@ -303,7 +623,7 @@ func (rp *Issues) NewIssueComment(w http.ResponseWriter, r *http.Request) {
 					Issue:     issueAt,
 					Owner:     &ownerDid,
 					Body:      body,
-					CreatedAt: createdAt,
+					CreatedAt: createdAt.Format(time.RFC3339),
 				},
 			},
 		})
@@ -313,7 +633,28 @@ func (rp *Issues) NewIssueComment(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		rp.pages.HxLocation(w, fmt.Sprintf("/%s/issues/%d#comment-%d", f.OwnerSlashRepo(), issueIdInt, commentId))
+		comment := &db.Comment{
+			OwnerDid:  user.Did,
+			RepoAt:    f.RepoAt(),
+			Issue:     issueIdInt,
+			CommentId: commentId,
+			Body:      body,
+			Rkey:      rkey,
+			Created:   &createdAt,
+			Hidden:    verdict.Reason,
+		}
+		err = db.NewIssueComment(rp.db, comment)
+		if err != nil {
+			log.Println("failed to create comment", err)
+			rp.pages.Notice(w, "issue-comment", "Failed to create comment.")
+			return
+		}
+
+		if !verdict.Spam {
+			rp.notifier.NewIssueComment(r.Context(), comment)
+		}
+
+		rp.pages.HxLocation(w, fmt.Sprintf("/%s/issues/%d#comment-%d", f.OwnerSlashRepo(), issueIdInt, comment.CommentId))
 		return
 	}
 }
@@ -416,6 +757,11 @@ func (rp *Issues) EditIssueComment(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		// extract form value
 		newBody := r.FormValue("body")
+		if err := rp.config.Limits.CheckCommentBody(newBody); err != nil {
+			rp.pages.Notice(w, fmt.Sprintf("comment-%s-status", commentId), err.Error())
+			return
+		}
+
 		client, err := rp.oauth.AuthorizedClient(r)
 		if err != nil {
 			log.Println("failed to get authorized client", err)
@@ -423,15 +769,7 @@ func (rp *Issues) EditIssueComment(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		rkey := comment.Rkey
-
-		// optimistic update
 		edited := time.Now()
-		err = db.EditComment(rp.db, comment.RepoAt, comment.Issue, comment.CommentId, newBody)
-		if err != nil {
-			log.Println("failed to perferom update-description query", err)
-			rp.pages.Notice(w, "repo-notice", "Failed to update description, try again later.")
-			return
-		}
 
 		// rkey is optional, it was introduced later
 		if comment.Rkey != "" {
@@ -466,10 +804,19 @@ func (rp *Issues) EditIssueComment(w http.ResponseWriter, r *http.Request) {
 				},
 			})
 			if err != nil {
-				log.Println(err)
+				log.Println("failed to update comment record", err)
+				rp.pages.Notice(w, fmt.Sprintf("comment-%s-status", commentId), "Failed to update comment, try again later.")
+				return
 			}
 		}
 
+		err = db.EditComment(rp.db, comment.RepoAt, comment.Issue, comment.CommentId, newBody)
+		if err != nil {
+			log.Println("failed to perferom update-description query", err)
+			rp.pages.Notice(w, "repo-notice", "Failed to update description, try again later.")
+			return
+		}
+
 		// optimistic update for htmx
 		comment.Body = newBody
 		comment.Edited = &edited
@@ -534,14 +881,7 @@ func (rp *Issues) DeleteIssueComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// optimistic deletion
 	deleted := time.Now()
-	err = db.DeleteComment(rp.db, f.RepoAt(), issueIdInt, commentIdInt)
-	if err != nil {
-		log.Println("failed to delete comment")
-		rp.pages.Notice(w, fmt.Sprintf("comment-%s-status", commentId), "failed to delete comment")
-		return
-	}
 
 	// delete from pds
 	if comment.Rkey != "" {
@@ -552,15 +892,24 @@ func (rp *Issues) DeleteIssueComment(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		_, err = client.RepoDeleteRecord(r.Context(), &comatproto.RepoDeleteRecord_Input{
-			Collection: tangled.GraphFollowNSID,
+			Collection: tangled.RepoIssueCommentNSID,
 			Repo:       user.Did,
 			Rkey:       comment.Rkey,
 		})
 		if err != nil {
-			log.Println(err)
+			log.Println("failed to delete comment record", err)
+			rp.pages.Notice(w, fmt.Sprintf("comment-%s-status", commentId), "failed to delete comment")
+			return
 		}
 	}
 
+	err = db.DeleteComment(rp.db, f.RepoAt(), issueIdInt, commentIdInt)
+	if err != nil {
+		log.Println("failed to delete comment")
+		rp.pages.Notice(w, fmt.Sprintf("comment-%s-status", commentId), "failed to delete comment")
+		return
+	}
+
 	// optimistic update for htmx
 	comment.Body = ""
 	comment.Deleted = &deleted
@@ -600,7 +949,12 @@ func (rp *Issues) RepoIssues(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	issues, err := db.GetIssuesPaginated(rp.db, f.RepoAt(), isOpen, page)
+	viewerDid := ""
+	if user != nil {
+		viewerDid = user.Did
+	}
+
+	issues, err := db.GetIssuesPaginated(rp.db, f.RepoAt(), viewerDid, isOpen, page)
 	if err != nil {
 		log.Println("failed to get issues", err)
 		rp.pages.Notice(w, "issues", "Failed to load issues. Try again later.")
@@ -639,6 +993,10 @@ func (rp *Issues) NewIssue(w http.ResponseWriter, r *http.Request) {
 			rp.pages.Notice(w, "issues", "Title and body are required")
 			return
 		}
+		if err := rp.config.Limits.CheckIssueBody(body); err != nil {
+			rp.pages.Notice(w, "issues", err.Error())
+			return
+		}
 
 		sanitizer := markup.NewSanitizer()
 		if st := strings.TrimSpace(sanitizer.SanitizeDescription(title)); st == "" {
@@ -650,11 +1008,13 @@ func (rp *Issues) NewIssue(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		tx, err := rp.db.BeginTx(r.Context(), nil)
-		if err != nil {
-			rp.pages.Notice(w, "issues", "Failed to create issue, try again later")
-			return
-		}
+		verdict := rp.spam.Check(r.Context(), spam.Content{
+			Kind:      spam.KindIssue,
+			AuthorDid: user.Did,
+			RepoAt:    f.RepoAt(),
+			Title:     title,
+			Body:      body,
+		})
 
 		issue := &db.Issue{
 			RepoAt:   f.RepoAt(),
@@ -662,14 +1022,10 @@ func (rp *Issues) NewIssue(w http.ResponseWriter, r *http.Request) {
 			Title:    title,
 			Body:     body,
 			OwnerDid: user.Did,
-		}
-		err = db.NewIssue(tx, issue)
-		if err != nil {
-			log.Println("failed to create issue", err)
-			rp.pages.Notice(w, "issues", "Failed to create issue.")
-			return
+			Hidden:   verdict.Reason,
 		}
 
+		// the record is written first, the appview is only an index over it
 		client, err := rp.oauth.AuthorizedClient(r)
 		if err != nil {
 			log.Println("failed to get authorized client", err)
@@ -683,9 +1039,10 @@ func (rp *Issues) NewIssue(w http.ResponseWriter, r *http.Request) {
 			Rkey:       issue.Rkey,
 			Record: &lexutil.LexiconTypeDecoder{
 				Val: &tangled.RepoIssue{
-					Repo:  atUri,
-					Title: title,
-					Body:  &body,
+					Repo:      atUri,
+					Title:     title,
+					Body:      &body,
+					CreatedAt: time.Now().Format(time.RFC3339),
 				},
 			},
 		})
@@ -695,7 +1052,22 @@ func (rp *Issues) NewIssue(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		rp.notifier.NewIssue(r.Context(), issue)
+		tx, err := rp.db.BeginTx(r.Context(), nil)
+		if err != nil {
+			rp.pages.Notice(w, "issues", "Failed to create issue, try again later")
+			return
+		}
+
+		err = db.NewIssue(tx, issue)
+		if err != nil {
+			log.Println("failed to create issue", err)
+			rp.pages.Notice(w, "issues", "Failed to create issue.")
+			return
+		}
+
+		if !verdict.Spam {
+			rp.notifier.NewIssue(r.Context(), issue)
+		}
 
 		rp.pages.HxLocation(w, fmt.Sprintf("/%s/issues/%d", f.OwnerSlashRepo(), issue.IssueId))
 		return
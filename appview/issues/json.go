@@ -0,0 +1,106 @@
+package issues
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pagination"
+)
+
+// issueJSON is the shape of an issue as served to the tangled cli
+type issueJSON struct {
+	Id       int       `json:"id"`
+	Uri      string    `json:"uri"`
+	Owner    string    `json:"owner"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Open     bool      `json:"open"`
+	Comments int       `json:"comments"`
+	Created  time.Time `json:"created"`
+}
+
+func toIssueJSON(issue db.Issue) issueJSON {
+	i := issueJSON{
+		Id:      issue.IssueId,
+		Uri:     issue.AtUri().String(),
+		Owner:   issue.OwnerDid,
+		Title:   issue.Title,
+		Body:    issue.Body,
+		Open:    issue.Open,
+		Created: issue.Created,
+	}
+	if issue.Metadata != nil {
+		i.Comments = issue.Metadata.CommentCount
+	}
+	return i
+}
+
+func (rp *Issues) RepoIssuesJSON(w http.ResponseWriter, r *http.Request) {
+	isOpen := r.URL.Query().Get("state") != "closed"
+
+	page, ok := r.Context().Value("page").(pagination.Page)
+	if !ok {
+		page = pagination.FirstPage()
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issues, err := db.GetIssuesPaginated(rp.db, f.RepoAt(), "", isOpen, page)
+	if err != nil {
+		log.Println("failed to get issues", err)
+		http.Error(w, "failed to load issues", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]issueJSON, 0, len(issues))
+	for _, issue := range issues {
+		resp = append(resp, toIssueJSON(issue))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (rp *Issues) RepoSingleIssueJSON(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issueId, err := strconv.Atoi(chi.URLParam(r, "issue"))
+	if err != nil {
+		http.Error(w, "bad issue id", http.StatusBadRequest)
+		return
+	}
+
+	issue, err := db.GetIssue(rp.db, f.RepoAt(), issueId)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "unknown issue", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Println("failed to get issue", err)
+		http.Error(w, "failed to load issue", http.StatusInternalServerError)
+		return
+	}
+	if issue.Hidden != "" {
+		http.Error(w, "unknown issue", http.StatusNotFound)
+		return
+	}
+	issue.IssueId = issueId
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toIssueJSON(*issue))
+}
@@ -0,0 +1,57 @@
+package issues
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/ogcard"
+)
+
+// IssueOpenGraphImage renders the social preview linked from og:image on
+// issue pages
+func (rp *Issues) IssueOpenGraphImage(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	issueIdInt, err := strconv.Atoi(chi.URLParam(r, "issue"))
+	if err != nil {
+		http.Error(w, "bad issue id", http.StatusBadRequest)
+		return
+	}
+
+	issue, comments, err := db.GetIssueWithComments(rp.db, f.RepoAt(), issueIdInt)
+	if err != nil {
+		log.Println("failed to get issue and comments", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if issue.Hidden != "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	comments = visibleComments(nil, f, comments)
+
+	state := "open"
+	if !issue.Open {
+		state = "closed"
+	}
+
+	card := ogcard.Card{
+		Eyebrow:     fmt.Sprintf("%s · issue #%d · %s", f.OwnerSlashRepo(), issue.IssueId, state),
+		Title:       issue.Title,
+		Description: issue.Body,
+		Stats: []ogcard.Stat{
+			{Label: "comments", Value: len(comments)},
+		},
+	}
+
+	card.Serve(w)
+}
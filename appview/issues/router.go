@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/challenge"
 	"tangled.sh/tangled.sh/core/appview/middleware"
 )
 
@@ -12,13 +13,21 @@ func (i *Issues) Router(mw *middleware.Middleware) http.Handler {
 
 	r.Route("/", func(r chi.Router) {
 		r.With(middleware.Paginate).Get("/", i.RepoIssues)
+		r.With(middleware.Paginate).Get("/index.json", i.RepoIssuesJSON)
+		r.Get("/similar", i.SimilarIssues)
 		r.Get("/{issue}", i.RepoSingleIssue)
+		r.Get("/{issue}.json", i.RepoSingleIssueJSON)
+		r.Get("/{issue}/opengraph", i.IssueOpenGraphImage)
+		r.Get("/{issue}/history", i.IssueHistory)
 
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.AuthMiddleware(i.oauth))
 			r.Get("/new", i.NewIssue)
 			r.Post("/new", i.NewIssue)
-			r.Post("/{issue}/comment", i.NewIssueComment)
+			r.With(mw.Challenge(challenge.ActionComment)).Post("/{issue}/comment", i.NewIssueComment)
+			r.Get("/{issue}/edit", i.EditIssue)
+			r.Post("/{issue}/edit", i.EditIssue)
+			r.Post("/{issue}/tasks", i.ToggleIssueTask)
 			r.Route("/{issue}/comment/{comment_id}/", func(r chi.Router) {
 				r.Get("/", i.IssueComment)
 				r.Delete("/", i.DeleteIssueComment)
@@ -27,6 +36,15 @@ func (i *Issues) Router(mw *middleware.Middleware) http.Handler {
 			})
 			r.Post("/{issue}/close", i.CloseIssue)
 			r.Post("/{issue}/reopen", i.ReopenIssue)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Post("/import", i.ImportIssues)
+			r.Route("/hidden", func(r chi.Router) {
+				r.Use(mw.RepoPermissionMiddleware("repo:settings"))
+				r.Get("/", i.HiddenIssues)
+				r.Post("/{issue}/approve", i.ApproveIssue)
+				r.Delete("/{issue}", i.DeleteHiddenIssue)
+				r.Post("/{issue}/comment/{comment_id}/approve", i.ApproveComment)
+				r.Delete("/{issue}/comment/{comment_id}", i.DeleteHiddenComment)
+			})
 		})
 	})
 
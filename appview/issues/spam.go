@@ -0,0 +1,204 @@
+package issues
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/reporesolver"
+)
+
+// canSeeHidden tells whether user may see an issue or comment by authorDid,
+// content hidden by the spam filter is only shown to its author and to the
+// admins of the repo
+func canSeeHidden(user *oauth.User, f *reporesolver.ResolvedRepo, authorDid, hidden string) bool {
+	if hidden == "" {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	return user.Did == authorDid || f.RolesInRepo(user).SettingsAllowed()
+}
+
+func visibleComments(user *oauth.User, f *reporesolver.ResolvedRepo, comments []db.Comment) []db.Comment {
+	var visible []db.Comment
+	for _, c := range comments {
+		if canSeeHidden(user, f, c.OwnerDid, c.Hidden) {
+			visible = append(visible, c)
+		}
+	}
+	return visible
+}
+
+// HiddenIssues lists the issues and comments hidden by the spam filter, for
+// the admins of the repo to review
+func (rp *Issues) HiddenIssues(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issues, err := db.GetHiddenIssues(rp.db, f.RepoAt())
+	if err != nil {
+		log.Println("failed to get hidden issues", err)
+		rp.pages.Error503(w)
+		return
+	}
+
+	comments, err := db.GetHiddenComments(rp.db, f.RepoAt())
+	if err != nil {
+		log.Println("failed to get hidden comments", err)
+		rp.pages.Error503(w)
+		return
+	}
+
+	rp.pages.RepoHiddenIssues(w, pages.RepoHiddenIssuesParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Issues:       issues,
+		Comments:     comments,
+	})
+}
+
+// ApproveIssue shows a hidden issue to everyone, notifying about it as if it
+// were just opened
+func (rp *Issues) ApproveIssue(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issueId, err := strconv.Atoi(chi.URLParam(r, "issue"))
+	if err != nil {
+		rp.pages.Notice(w, "hidden-issues", "Invalid issue.")
+		return
+	}
+
+	issue, err := db.GetIssue(rp.db, f.RepoAt(), issueId)
+	if err != nil || issue.Hidden == "" {
+		rp.pages.Notice(w, "hidden-issues", "Issue is not hidden.")
+		return
+	}
+
+	err = db.ShowIssue(rp.db, f.RepoAt(), issueId)
+	if err != nil {
+		log.Println("failed to show issue", err)
+		rp.pages.Notice(w, "hidden-issues", "Failed to approve issue, try again later.")
+		return
+	}
+
+	issue.Hidden = ""
+	rp.notifier.NewIssue(r.Context(), issue)
+
+	rp.pages.HxRefresh(w)
+}
+
+// DeleteHiddenIssue removes a hidden issue from the appview, the record
+// itself lives on the PDS of its author
+func (rp *Issues) DeleteHiddenIssue(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issueId, err := strconv.Atoi(chi.URLParam(r, "issue"))
+	if err != nil {
+		rp.pages.Notice(w, "hidden-issues", "Invalid issue.")
+		return
+	}
+
+	err = db.DeleteHiddenIssue(rp.db, f.RepoAt(), issueId)
+	if err != nil {
+		log.Println("failed to delete issue", err)
+		rp.pages.Notice(w, "hidden-issues", "Failed to delete issue, try again later.")
+		return
+	}
+
+	rp.pages.HxLocation(w, fmt.Sprintf("/%s/issues/hidden", f.OwnerSlashRepo()))
+}
+
+func (rp *Issues) ApproveComment(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issueId, commentId, err := commentParams(r)
+	if err != nil {
+		rp.pages.Notice(w, "hidden-issues", "Invalid comment.")
+		return
+	}
+
+	comment, err := db.GetComment(rp.db, f.RepoAt(), issueId, commentId)
+	if err != nil || comment.Hidden == "" {
+		rp.pages.Notice(w, "hidden-issues", "Comment is not hidden.")
+		return
+	}
+
+	err = db.ShowComment(rp.db, f.RepoAt(), issueId, commentId)
+	if err != nil {
+		log.Println("failed to show comment", err)
+		rp.pages.Notice(w, "hidden-issues", "Failed to approve comment, try again later.")
+		return
+	}
+
+	comment.Hidden = ""
+	rp.notifier.NewIssueComment(r.Context(), comment)
+
+	rp.pages.HxRefresh(w)
+}
+
+// DeleteHiddenComment marks a hidden comment as deleted, it stays hidden
+func (rp *Issues) DeleteHiddenComment(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	issueId, commentId, err := commentParams(r)
+	if err != nil {
+		rp.pages.Notice(w, "hidden-issues", "Invalid comment.")
+		return
+	}
+
+	comment, err := db.GetComment(rp.db, f.RepoAt(), issueId, commentId)
+	if err != nil || comment.Hidden == "" {
+		rp.pages.Notice(w, "hidden-issues", "Comment is not hidden.")
+		return
+	}
+
+	err = db.DeleteComment(rp.db, f.RepoAt(), issueId, commentId)
+	if err != nil {
+		log.Println("failed to delete comment", err)
+		rp.pages.Notice(w, "hidden-issues", "Failed to delete comment, try again later.")
+		return
+	}
+
+	rp.pages.HxRefresh(w)
+}
+
+func commentParams(r *http.Request) (int, int, error) {
+	issueId, err := strconv.Atoi(chi.URLParam(r, "issue"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid issue: %w", err)
+	}
+
+	commentId, err := strconv.Atoi(chi.URLParam(r, "comment_id"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid comment: %w", err)
+	}
+
+	return issueId, commentId, nil
+}
@@ -0,0 +1,278 @@
+package issues
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/go-chi/chi/v5"
+
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/middleware"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// triage views show this many issues at most, newest first
+const triageLimit = 200
+
+// TriageRouter serves the issues of every repo the user owns or
+// collaborates on, along with bulk actions over them
+func (i *Issues) TriageRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.AuthMiddleware(i.oauth))
+	r.Get("/", i.Triage)
+	r.Post("/bulk", i.BulkIssues)
+	return r
+}
+
+// ParseTriageFilter reads the filters of a triage view from the query of a
+// request, open issues are shown by default
+func ParseTriageFilter(r *http.Request) pages.TriageFilter {
+	return pages.TriageFilter{
+		Open:  r.URL.Query().Get("state") != "closed",
+		Repo:  r.URL.Query().Get("repo"),
+		Label: strings.TrimSpace(r.URL.Query().Get("label")),
+	}
+}
+
+// TriageIssues fetches the issues of the given repos that match filter
+func TriageIssues(e db.Execer, repos []db.Repo, filter pages.TriageFilter) ([]db.Issue, error) {
+	var repoAts []syntax.ATURI
+	for _, repo := range repos {
+		if filter.Repo == "" || filter.Repo == repo.RepoAt().String() {
+			repoAts = append(repoAts, repo.RepoAt())
+		}
+	}
+
+	if filter.Label == "" {
+		return db.GetTriageIssues(
+			e,
+			triageLimit,
+			db.FilterIn("i.repo_at", repoAts),
+			db.FilterEq("i.open", filter.Open),
+		)
+	}
+
+	labels, err := db.GetLabels(e, db.FilterIn("repo_at", repoAts), db.FilterEq("name", filter.Label))
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects []string
+	for _, l := range labels {
+		subjects = append(subjects, l.SubjectAt.String())
+	}
+
+	return db.GetTriageIssues(
+		e,
+		triageLimit,
+		db.FilterIn("i.repo_at", repoAts),
+		db.FilterEq("i.open", filter.Open),
+		db.FilterIn("i.issue_at", subjects),
+	)
+}
+
+func (rp *Issues) Triage(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+
+	repos, err := db.GetAllReposByDid(rp.db, user.Did)
+	if err != nil {
+		log.Println("failed to get repos", err)
+		rp.pages.Error503(w)
+		return
+	}
+
+	collaborating, err := db.CollaboratingIn(rp.db, user.Did)
+	if err != nil {
+		log.Println("failed to get collaborating repos", err)
+	}
+	repos = append(repos, collaborating...)
+
+	filter := ParseTriageFilter(r)
+	issues, err := TriageIssues(rp.db, repos, filter)
+	if err != nil {
+		log.Println("failed to get issues", err)
+		rp.pages.Error503(w)
+		return
+	}
+
+	var repoAts []syntax.ATURI
+	for _, repo := range repos {
+		repoAts = append(repoAts, repo.RepoAt())
+	}
+	openCounts, err := db.GetOpenIssueCounts(rp.db, repoAts)
+	if err != nil {
+		log.Println("failed to count issues", err)
+	}
+
+	rp.pages.Triage(w, pages.TriageParams{
+		LoggedInUser: user,
+		Issues:       issues,
+		Repos:        repos,
+		OpenCounts:   openCounts,
+		Filter:       filter,
+	})
+}
+
+// BulkIssues closes, reopens or labels many issues at once, possibly across
+// repos. Issues the user is not allowed to change are skipped.
+func (rp *Issues) BulkIssues(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	noticeId := "bulk-issues"
+
+	if err := r.ParseForm(); err != nil {
+		rp.pages.Notice(w, noticeId, "Invalid request.")
+		return
+	}
+
+	action := r.FormValue("action")
+	label := strings.TrimSpace(r.FormValue("label"))
+	switch action {
+	case "close", "reopen":
+	case "label":
+		if label == "" {
+			rp.pages.Notice(w, noticeId, "Enter a label to apply.")
+			return
+		}
+	default:
+		rp.pages.Notice(w, noticeId, "Unknown action.")
+		return
+	}
+
+	issueAts := r.Form["issue"]
+	if len(issueAts) == 0 {
+		rp.pages.Notice(w, noticeId, "Select some issues first.")
+		return
+	}
+
+	var failed int
+	for _, raw := range issueAts {
+		err := rp.bulkIssue(r, user, raw, action, label)
+		if err != nil {
+			log.Printf("failed to %s issue %s: %v", action, raw, err)
+			failed += 1
+		}
+	}
+
+	if failed > 0 {
+		rp.pages.Notice(w, noticeId, fmt.Sprintf("Failed to %s %d of %d issues, you may not be allowed to change them.", action, failed, len(issueAts)))
+		return
+	}
+
+	rp.pages.HxRefresh(w)
+}
+
+func (rp *Issues) bulkIssue(r *http.Request, user *oauth.User, raw, action, label string) error {
+	issueAt, err := syntax.ParseATURI(raw)
+	if err != nil {
+		return err
+	}
+
+	repoAt, issueId, err := db.ResolveIssueFromAtUri(rp.db, issueAt)
+	if err != nil {
+		return err
+	}
+
+	repo, err := db.GetRepoByAtUri(rp.db, repoAt.String())
+	if err != nil {
+		return err
+	}
+
+	issue, err := db.GetIssue(rp.db, repoAt, issueId)
+	if err != nil {
+		return err
+	}
+
+	canPush, err := rp.enforcer.E.Enforce(user.Did, repo.Knot, repo.DidSlashRepo(), "repo:push")
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "close", "reopen":
+		open := action == "reopen"
+		if !canPush && user.Did != issue.OwnerDid {
+			return fmt.Errorf("not allowed to %s issue", action)
+		}
+		if issue.Open == open {
+			return nil
+		}
+		return rp.setIssueState(r, user, repoAt, issue, open)
+
+	case "label":
+		if !canPush {
+			return fmt.Errorf("not allowed to label issue")
+		}
+		return rp.addLabel(r, user, repoAt, issue.AtUri(), label)
+	}
+
+	return nil
+}
+
+// addLabel records a label on the PDS of the user, and applies it locally
+func (rp *Issues) addLabel(r *http.Request, user *oauth.User, repoAt, subjectAt syntax.ATURI, name string) error {
+	existing, err := db.GetLabels(rp.db, db.FilterEq("subject_at", subjectAt), db.FilterEq("name", name))
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+
+	client, err := rp.oauth.AuthorizedClient(r)
+	if err != nil {
+		return fmt.Errorf("failed to get authorized client: %w", err)
+	}
+
+	repo := repoAt.String()
+	createdAt := time.Now()
+	rkey := tid.TID()
+	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoLabelNSID,
+		Repo:       user.Did,
+		Rkey:       rkey,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &tangled.RepoLabel{
+				Name:      name,
+				Repo:      &repo,
+				Subject:   subjectAt.String(),
+				CreatedAt: createdAt.Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create label record: %w", err)
+	}
+
+	l := db.Label{
+		OwnerDid:  user.Did,
+		Rkey:      rkey,
+		RepoAt:    repoAt,
+		SubjectAt: subjectAt,
+		Name:      name,
+		Created:   createdAt,
+	}
+	err = db.AddLabel(rp.db, l)
+	if err != nil {
+		return err
+	}
+
+	sourceAt := l.LabelAt()
+	return db.AddThreadEvent(rp.db, db.ThreadEvent{
+		RepoAt:    repoAt,
+		SubjectAt: subjectAt,
+		ActorDid:  user.Did,
+		Kind:      db.ThreadEventLabeled,
+		Value:     name,
+		SourceAt:  &sourceAt,
+		Created:   createdAt,
+	})
+}
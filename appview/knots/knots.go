@@ -1,12 +1,14 @@
 package knots
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -42,13 +44,22 @@ func (k *Knots) Router() http.Handler {
 
 	r.With(middleware.AuthMiddleware(k.OAuth)).Get("/", k.knots)
 	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/register", k.register)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/probe", k.probe)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Get("/setup", k.setup)
 
 	r.With(middleware.AuthMiddleware(k.OAuth)).Get("/{domain}", k.dashboard)
 	r.With(middleware.AuthMiddleware(k.OAuth)).Delete("/{domain}", k.delete)
 
 	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/{domain}/retry", k.retry)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/{domain}/selftest", k.selftest)
 	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/{domain}/add", k.addMember)
 	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/{domain}/remove", k.removeMember)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/{domain}/policy", k.policy)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Get("/{domain}/usage", k.usage)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Get("/{domain}/trash", k.trash)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/{domain}/trash/restore", k.restore)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Get("/{domain}/repos", k.repos)
+	r.With(middleware.AuthMiddleware(k.OAuth)).Post("/{domain}/repos", k.administer)
 
 	r.With(middleware.AuthMiddleware(k.OAuth)).Get("/upgradeBanner", k.banner)
 
@@ -135,6 +146,34 @@ func (k *Knots) dashboard(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// probe walks through the steps of verifying a knot before it is registered,
+// so that problems with its setup can be fixed up front
+func (k *Knots) probe(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+
+	domain := strings.TrimSpace(r.FormValue("domain"))
+	if domain == "" {
+		k.Pages.Notice(w, "register-error", "Enter the hostname of your knot first.")
+		return
+	}
+
+	k.Pages.KnotProbe(w, pages.KnotProbeParams{
+		Domain: domain,
+		Checks: serververify.DiagnoseKnot(r.Context(), domain, user.Did, k.Config.Core.Dev),
+	})
+}
+
+// diagnose explains why a knot failed to verify, in terms of the first step
+// that went wrong
+func (k *Knots) diagnose(r *http.Request, domain, owner string) string {
+	for _, c := range serververify.DiagnoseKnot(r.Context(), domain, owner, k.Config.Core.Dev) {
+		if !c.Ok {
+			return fmt.Sprintf("Failed to verify knot: %s", c.Detail)
+		}
+	}
+	return "Failed to verify knot. Try again later."
+}
+
 func (k *Knots) register(w http.ResponseWriter, r *http.Request) {
 	user := k.OAuth.GetUser(r)
 	l := k.Logger.With("handler", "register")
@@ -145,7 +184,7 @@ func (k *Knots) register(w http.ResponseWriter, r *http.Request) {
 		k.Pages.Notice(w, noticeId, defaultErr)
 	}
 
-	domain := r.FormValue("domain")
+	domain := strings.TrimSpace(r.FormValue("domain"))
 	if domain == "" {
 		k.Pages.Notice(w, noticeId, "Incomplete form.")
 		return
@@ -229,7 +268,9 @@ func (k *Knots) register(w http.ResponseWriter, r *http.Request) {
 	err = serververify.RunVerification(r.Context(), domain, user.Did, k.Config.Core.Dev)
 	if err != nil {
 		l.Error("verification failed", "err", err)
-		k.Pages.HxRefresh(w)
+		// the knot is kept around as unverified, it can be retried once the
+		// problem is fixed
+		k.Pages.Notice(w, noticeId, k.diagnose(r, domain, user.Did)+" Fix this, then reload and retry verification.")
 		return
 	}
 
@@ -240,12 +281,18 @@ func (k *Knots) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// add this knot to knotstream
+	// add this knot to knotstream, for longer than this request lasts
 	go k.Knotstream.AddSource(
-		r.Context(),
+		context.Background(),
 		eventconsumer.NewKnotSource(domain),
 	)
 
+	// the setup guide carries on with the self-test on the knot's page
+	if r.FormValue("setup") != "" {
+		k.Pages.HxLocation(w, fmt.Sprintf("/knots/%s", domain))
+		return
+	}
+
 	// ok
 	k.Pages.HxRefresh(w)
 }
@@ -400,7 +447,7 @@ func (k *Knots) retry(w http.ResponseWriter, r *http.Request) {
 		l.Error("verification failed", "err", err)
 
 		if errors.Is(err, serververify.FetchError) {
-			k.Pages.Notice(w, noticeId, "Failed to verify knot, unable to fetch owner.")
+			k.Pages.Notice(w, noticeId, k.diagnose(r, domain, user.Did))
 			return
 		}
 
@@ -444,9 +491,7 @@ func (k *Knots) retry(w http.ResponseWriter, r *http.Request) {
 			Repo:       user.Did,
 			Rkey:       domain,
 			Record: &lexutil.LexiconTypeDecoder{
-				Val: &tangled.Knot{
-					CreatedAt: time.Now().Format(time.RFC3339),
-				},
+				Val: knotRecord(registration),
 			},
 			SwapRecord: exCid,
 		})
@@ -455,9 +500,9 @@ func (k *Knots) retry(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// add this knot to knotstream
+	// add this knot to knotstream, for longer than this request lasts
 	go k.Knotstream.AddSource(
-		r.Context(),
+		context.Background(),
 		eventconsumer.NewKnotSource(domain),
 	)
 
@@ -0,0 +1,145 @@
+package knots
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/rbac"
+)
+
+// knotRecord is the record announcing a knot, along with its policy
+func knotRecord(reg db.Registration) *tangled.Knot {
+	record := &tangled.Knot{
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if reg.RepoCreate != "" {
+		record.RepoCreate = &reg.RepoCreate
+	}
+	if reg.DefaultBranch != "" {
+		record.DefaultBranch = &reg.DefaultBranch
+	}
+	return record
+}
+
+// policy updates who may create repos on a knot, and the defaults for new
+// repos
+func (k *Knots) policy(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+	l := k.Logger.With("handler", "policy")
+
+	noticeId := "policy-error"
+	defaultErr := "Failed to save policy. Try again later."
+	fail := func() {
+		k.Pages.Notice(w, noticeId, defaultErr)
+	}
+
+	domain := chi.URLParam(r, "domain")
+	if domain == "" {
+		l.Error("empty domain")
+		fail()
+		return
+	}
+	l = l.With("domain", domain)
+	l = l.With("user", user.Did)
+
+	registrations, err := db.GetRegistrations(
+		k.Db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("domain", domain),
+		db.FilterIsNot("registered", "null"),
+	)
+	if err != nil {
+		l.Error("failed to get registration", "err", err)
+		fail()
+		return
+	}
+	if len(registrations) != 1 {
+		l.Error("got incorret number of registrations", "got", len(registrations), "expected", 1)
+		fail()
+		return
+	}
+	registration := registrations[0]
+
+	repoCreate := rbac.RepoCreatePolicy(r.FormValue("repo_create"))
+	if !repoCreate.IsValid() {
+		k.Pages.Notice(w, noticeId, "Invalid repository creation policy.")
+		return
+	}
+
+	defaultBranch := strings.TrimSpace(r.FormValue("default_branch"))
+	if defaultBranch != "" {
+		if err := plumbing.NewBranchReferenceName(defaultBranch).Validate(); err != nil {
+			k.Pages.Notice(w, noticeId, "Invalid default branch name.")
+			return
+		}
+	}
+
+	registration.RepoCreate = string(repoCreate)
+	registration.DefaultBranch = defaultBranch
+
+	client, err := k.OAuth.AuthorizedClient(r)
+	if err != nil {
+		l.Error("failed to authorize client", "err", err)
+		fail()
+		return
+	}
+
+	ex, _ := client.RepoGetRecord(r.Context(), "", tangled.KnotNSID, user.Did, domain)
+	var exCid *string
+	if ex != nil {
+		exCid = ex.Cid
+	}
+
+	record := knotRecord(registration)
+	if ex != nil {
+		if existing, ok := ex.Value.Val.(*tangled.Knot); ok {
+			record.CreatedAt = existing.CreatedAt
+		}
+	}
+
+	// the knot picks the policy up from this record
+	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.KnotNSID,
+		Repo:       user.Did,
+		Rkey:       domain,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: record,
+		},
+		SwapRecord: exCid,
+	})
+	if err != nil {
+		l.Error("failed to put record", "err", err)
+		k.Pages.Notice(w, noticeId, "Failed to write record to PDS, try again later.")
+		return
+	}
+
+	err = db.SetKnotPolicy(k.Db, domain, user.Did, registration.RepoCreate, registration.DefaultBranch)
+	if err != nil {
+		l.Error("failed to save policy", "err", err)
+		fail()
+		return
+	}
+
+	err = k.Enforcer.SetRepoCreatePolicy(domain, repoCreate)
+	if err != nil {
+		l.Error("failed to update ACLs", "err", err)
+		fail()
+		return
+	}
+
+	err = k.Enforcer.E.SavePolicy()
+	if err != nil {
+		l.Error("failed to save ACLs", "err", err)
+		fail()
+		return
+	}
+
+	k.Pages.HxRefresh(w)
+}
@@ -0,0 +1,239 @@
+package knots
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+)
+
+// repos lists every repo on the knot for its owner to administer
+func (k *Knots) repos(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+	l := k.Logger.With("handler", "repos")
+
+	domain := chi.URLParam(r, "domain")
+	if domain == "" {
+		return
+	}
+	l = l.With("domain", domain)
+	l = l.With("user", user.Did)
+
+	registrations, err := db.GetRegistrations(
+		k.Db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("domain", domain),
+		db.FilterIsNot("registered", "null"),
+	)
+	if err != nil || len(registrations) != 1 {
+		l.Error("failed to get registration", "err", err)
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	params := pages.KnotReposParams{
+		LoggedInUser: user,
+		Domain:       domain,
+	}
+
+	client, err := k.OAuth.ServiceClient(
+		r,
+		oauth.WithService(domain),
+		oauth.WithLxm(tangled.KnotListReposNSID),
+		oauth.WithExp(60),
+		oauth.WithDev(k.Config.Core.Dev),
+	)
+	if err != nil {
+		l.Error("failed to create service client", "err", err)
+		params.Error = "Failed to reach the knot, try again later."
+		k.Pages.KnotRepos(w, params)
+		return
+	}
+
+	out, err := tangled.KnotListRepos(r.Context(), client)
+	if err != nil {
+		l.Error("failed to list repos", "err", err)
+		params.Error = "This knot does not list its repositories, it may need an upgrade."
+		k.Pages.KnotRepos(w, params)
+		return
+	}
+
+	for _, repo := range out.Repos {
+		listed := pages.KnotAdminRepo{
+			Did:      repo.Did,
+			Name:     repo.Name,
+			Size:     uint64(max(repo.Size, 0)),
+			Archived: repo.Archived,
+		}
+		if repo.LastActivity != nil {
+			if t, err := time.Parse(time.RFC3339, *repo.LastActivity); err == nil {
+				listed.LastActivity = &t
+			}
+		}
+		if repo.TransferTo != nil {
+			listed.TransferTo = *repo.TransferTo
+		}
+		params.Repos = append(params.Repos, listed)
+	}
+
+	k.Pages.KnotRepos(w, params)
+}
+
+// administer applies a bulk action to the repos picked on the knot's repo
+// listing, and brings the appview in line with what the knot did
+func (k *Knots) administer(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+	l := k.Logger.With("handler", "administer")
+
+	noticeId := "administer-error"
+	defaultErr := "Failed to administer repositories. Try again later."
+	fail := func() {
+		k.Pages.Notice(w, noticeId, defaultErr)
+	}
+
+	domain := chi.URLParam(r, "domain")
+	if domain == "" {
+		l.Error("empty domain")
+		fail()
+		return
+	}
+	l = l.With("domain", domain)
+	l = l.With("user", user.Did)
+
+	registrations, err := db.GetRegistrations(
+		k.Db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("domain", domain),
+		db.FilterIsNot("registered", "null"),
+	)
+	if err != nil || len(registrations) != 1 {
+		l.Error("failed to get registration", "err", err)
+		fail()
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		fail()
+		return
+	}
+
+	action := r.FormValue("action")
+	l = l.With("action", action)
+
+	input := &tangled.KnotAdministerRepos_Input{Action: action}
+	for _, repo := range r.Form["repo"] {
+		did, name, ok := strings.Cut(repo, "/")
+		if !ok {
+			continue
+		}
+		input.Repos = append(input.Repos, &tangled.KnotAdministerRepos_Repo{Did: did, Name: name})
+	}
+	if len(input.Repos) == 0 {
+		k.Pages.Notice(w, noticeId, "Select the repositories first.")
+		return
+	}
+
+	if action == "transfer" {
+		handle := strings.TrimPrefix(strings.TrimSpace(r.FormValue("handle")), "@")
+		if handle == "" {
+			k.Pages.Notice(w, noticeId, "Enter the handle of the new owner.")
+			return
+		}
+		id, err := k.IdResolver.ResolveIdent(r.Context(), handle)
+		if err != nil {
+			k.Pages.Notice(w, noticeId, fmt.Sprintf("Could not find %s.", handle))
+			return
+		}
+		newOwner := id.DID.String()
+		input.NewOwner = &newOwner
+	}
+
+	client, err := k.OAuth.ServiceClient(
+		r,
+		oauth.WithService(domain),
+		oauth.WithLxm(tangled.KnotAdministerReposNSID),
+		oauth.WithDev(k.Config.Core.Dev),
+	)
+	if err != nil {
+		l.Error("failed to create service client", "err", err)
+		fail()
+		return
+	}
+
+	out, xe := tangled.KnotAdministerRepos(r.Context(), client, input)
+	if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+		l.Error("xrpc error", "xe", xe)
+		k.Pages.Notice(w, noticeId, err.Error())
+		return
+	}
+
+	var failed []string
+	for _, result := range out.Results {
+		name := result.Did + "/" + result.Name
+		if result.Error != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Name, *result.Error))
+			continue
+		}
+
+		if err := k.followKnot(action, domain, result.Did, result.Name, input.NewOwner); err != nil {
+			l.Error("failed to update appview", "repo", name, "err", err)
+			failed = append(failed, fmt.Sprintf("%s: done on the knot, but failed to update the appview", result.Name))
+		}
+	}
+
+	if action == "delete" {
+		if err := k.Enforcer.E.SavePolicy(); err != nil {
+			l.Error("failed to save ACLs", "err", err)
+			fail()
+			return
+		}
+	}
+
+	if len(failed) > 0 {
+		k.Pages.Notice(w, noticeId, strings.Join(failed, "; "))
+		return
+	}
+
+	k.Pages.HxRefresh(w)
+}
+
+// followKnot brings the appview's copy of a repo in line with an action the
+// knot took on it. Archiving and gc leave nothing to do here.
+func (k *Knots) followKnot(action, domain, did, name string, newOwner *string) error {
+	repo, err := db.GetRepo(k.Db, did, name)
+	if err != nil || repo == nil || repo.Knot != domain {
+		// the appview never knew about it
+		return nil
+	}
+
+	switch action {
+	case "delete":
+		collaborators, err := k.Enforcer.E.GetImplicitUsersForResourceByDomain(repo.DidSlashRepo(), domain)
+		if err != nil {
+			return err
+		}
+		for _, c := range collaborators {
+			k.Enforcer.RemoveCollaborator(c[0], domain, repo.DidSlashRepo())
+		}
+		if err := k.Enforcer.RemoveRepo(did, domain, repo.DidSlashRepo()); err != nil {
+			return err
+		}
+		return db.RemoveRepo(k.Db, did, name)
+
+	case "transfer":
+		return db.SetRepoTransfer(k.Db, db.RepoTransfer{
+			RepoAt:  repo.RepoAt(),
+			FromDid: did,
+			ToDid:   *newOwner,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package knots
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/go-chi/chi/v5"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/serververify"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// setup walks through getting a knot up and running, from DNS to
+// registering it here
+func (k *Knots) setup(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+
+	k.Pages.KnotSetup(w, pages.KnotSetupParams{
+		LoggedInUser: user,
+		Domain:       strings.TrimSpace(r.URL.Query().Get("domain")),
+		AppviewHost:  k.Config.Core.AppviewHost,
+	})
+}
+
+// selftest exercises a registered knot the way its users will: a repo is
+// created on it, committed to, cloned over HTTP and deleted again. Failed
+// steps say what to look at on the knot.
+func (k *Knots) selftest(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+	l := k.Logger.With("handler", "selftest")
+
+	domain := chi.URLParam(r, "domain")
+	if domain == "" {
+		return
+	}
+	l = l.With("domain", domain)
+	l = l.With("user", user.Did)
+
+	registrations, err := db.GetRegistrations(
+		k.Db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("domain", domain),
+		db.FilterIsNot("registered", "null"),
+	)
+	if err != nil || len(registrations) != 1 {
+		l.Error("failed to get registration", "err", err)
+		k.Pages.Notice(w, "selftest-error", "Register and verify this knot before testing it.")
+		return
+	}
+
+	// the knot has a minute at most, a stuck step should not hang the page
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	checks := serververify.DiagnoseKnot(ctx, domain, user.Did, k.Config.Core.Dev)
+	for _, c := range checks {
+		if !c.Ok {
+			k.Pages.KnotProbe(w, pages.KnotProbeParams{Domain: domain, Checks: checks})
+			return
+		}
+	}
+
+	// pushes go over ssh with the user's own key, which the appview does not
+	// hold; the most it can do is see that sshd answers
+	checks = append(checks, checkSSH(ctx, domain))
+
+	checks = append(checks, k.exerciseRepo(r, l, domain, user.Did)...)
+
+	k.Pages.KnotProbe(w, pages.KnotProbeParams{Domain: domain, Checks: checks})
+}
+
+func checkSSH(ctx context.Context, domain string) serververify.Check {
+	host := domain
+	if h, _, err := net.SplitHostPort(domain); err == nil {
+		host = h
+	}
+	addr := net.JoinHostPort(host, "22")
+
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return serververify.Check{
+			Name:   "ssh",
+			Detail: fmt.Sprintf("could not connect to %s, pushes will fail; check that sshd is running and port 22 is open", addr),
+		}
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	banner, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || !strings.HasPrefix(banner, "SSH-") {
+		return serververify.Check{
+			Name:   "ssh",
+			Detail: fmt.Sprintf("%s is open but is not an SSH server", addr),
+		}
+	}
+
+	return serververify.Check{
+		Name:   "ssh",
+		Ok:     true,
+		Detail: fmt.Sprintf("sshd answers on %s; pushing also needs the AuthorizedKeysCommand from the setup guide", addr),
+	}
+}
+
+// exerciseRepo runs the steps of the self-test that need a repo, and cleans
+// up after itself however far it got
+func (k *Knots) exerciseRepo(r *http.Request, l *slog.Logger, domain, did string) []serververify.Check {
+	var checks []serververify.Check
+	pass := func(name, detail string) {
+		checks = append(checks, serververify.Check{Name: name, Ok: true, Detail: detail})
+	}
+	fail := func(name, detail string) []serververify.Check {
+		return append(checks, serververify.Check{Name: name, Detail: detail})
+	}
+	notice := func(err error) string {
+		if err := xrpcclient.HandleXrpcErr(err); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	pdsClient, err := k.OAuth.AuthorizedClient(r)
+	if err != nil {
+		l.Error("failed to authorize client", "err", err)
+		return fail("create", "failed to reach your PDS, try again later")
+	}
+
+	rkey := tid.TID()
+	name := "selftest-" + rkey
+
+	_, err = pdsClient.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoNSID,
+		Repo:       did,
+		Rkey:       rkey,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &tangled.Repo{
+				Knot:      domain,
+				Name:      name,
+				CreatedAt: time.Now().Format(time.RFC3339),
+				Owner:     did,
+			}},
+	})
+	if err != nil {
+		l.Error("failed to put record", "err", err)
+		return fail("create", "failed to write a repo record to your PDS, try again later")
+	}
+
+	created := false
+	cleanedUp := false
+	defer func() {
+		if cleanedUp {
+			return
+		}
+		// a failed step leaves a record, and perhaps a repo, behind
+		ctx := context.Background()
+		if _, err := pdsClient.RepoDeleteRecord(ctx, &comatproto.RepoDeleteRecord_Input{
+			Collection: tangled.RepoNSID,
+			Repo:       did,
+			Rkey:       rkey,
+		}); err != nil {
+			l.Error("failed to clean up record", "err", err)
+		}
+		if created {
+			if err := k.deleteRepo(r.WithContext(ctx), domain, did, name, rkey); err != nil {
+				l.Error("failed to clean up repo", "err", err)
+			}
+		}
+	}()
+
+	client, err := k.OAuth.ServiceClient(
+		r,
+		oauth.WithService(domain),
+		oauth.WithLxm(tangled.RepoCreateNSID),
+		oauth.WithDev(k.Config.Core.Dev),
+	)
+	if err != nil {
+		l.Error("failed to create service client", "err", err)
+		return fail("create", "failed to authorize with the knot, try again later")
+	}
+	if err := tangled.RepoCreate(r.Context(), client, &tangled.RepoCreate_Input{Rkey: rkey}); err != nil {
+		l.Error("failed to create repo", "err", err)
+		return fail("create", fmt.Sprintf("%s Check that KNOT_REPO_SCAN_PATH exists and is writable by the knot.", notice(err)))
+	}
+	created = true
+	pass("create", fmt.Sprintf("created %s", name))
+
+	client, err = k.OAuth.ServiceClient(
+		r,
+		oauth.WithService(domain),
+		oauth.WithLxm(tangled.RepoInitializeNSID),
+		oauth.WithDev(k.Config.Core.Dev),
+	)
+	if err != nil {
+		l.Error("failed to create service client", "err", err)
+		return fail("commit", "failed to authorize with the knot, try again later")
+	}
+	readme := true
+	if err := tangled.RepoInitialize(r.Context(), client, &tangled.RepoInitialize_Input{
+		Did:    did,
+		Name:   name,
+		Readme: &readme,
+	}); err != nil {
+		l.Error("failed to initialize repo", "err", err)
+		return fail("commit", fmt.Sprintf("%s Check that git is installed and KNOT_GIT_USER_NAME and KNOT_GIT_USER_EMAIL are set.", notice(err)))
+	}
+	pass("commit", "committed a README")
+
+	scheme := "https"
+	if k.Config.Core.Dev {
+		scheme = "http"
+	}
+	url := fmt.Sprintf("%s://%s/%s/%s", scheme, domain, did, name)
+	repo, err := gogit.CloneContext(r.Context(), memory.NewStorage(), nil, &gogit.CloneOptions{
+		URL:   url,
+		Depth: 1,
+	})
+	if err != nil {
+		return fail("clone", fmt.Sprintf("failed to clone %s: %v; check that your reverse proxy passes git requests through to the knot", url, err))
+	}
+	if err := hasReadme(repo); err != nil {
+		return fail("clone", fmt.Sprintf("cloned %s, but the commit was not in it: %v", url, err))
+	}
+	pass("clone", fmt.Sprintf("cloned %s over HTTP", url))
+
+	if _, err := pdsClient.RepoDeleteRecord(r.Context(), &comatproto.RepoDeleteRecord_Input{
+		Collection: tangled.RepoNSID,
+		Repo:       did,
+		Rkey:       rkey,
+	}); err != nil {
+		l.Error("failed to delete record", "err", err)
+		return fail("delete", "failed to delete the repo record from your PDS, try again later")
+	}
+	if err := k.deleteRepo(r, domain, did, name, rkey); err != nil {
+		l.Error("failed to delete repo", "err", err)
+		cleanedUp = true
+		return fail("delete", fmt.Sprintf("%s Delete %s by hand from the knot's repositories page.", notice(err), name))
+	}
+	cleanedUp = true
+	pass("delete", fmt.Sprintf("deleted %s, the knot keeps it in its trash until that expires", name))
+
+	return checks
+}
+
+func (k *Knots) deleteRepo(r *http.Request, domain, did, name, rkey string) error {
+	client, err := k.OAuth.ServiceClient(
+		r,
+		oauth.WithService(domain),
+		oauth.WithLxm(tangled.RepoDeleteNSID),
+		oauth.WithDev(k.Config.Core.Dev),
+	)
+	if err != nil {
+		return err
+	}
+	return tangled.RepoDelete(r.Context(), client, &tangled.RepoDelete_Input{
+		Did:  did,
+		Name: name,
+		Rkey: rkey,
+	})
+}
+
+func hasReadme(repo *gogit.Repository) error {
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	_, err = commit.File("README.md")
+	return err
+}
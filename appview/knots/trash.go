@@ -0,0 +1,221 @@
+package knots
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// trash lists deleted repos the knot still holds on to, only its owner may
+// see them
+func (k *Knots) trash(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+	l := k.Logger.With("handler", "trash")
+
+	domain := chi.URLParam(r, "domain")
+	if domain == "" {
+		return
+	}
+	l = l.With("domain", domain)
+	l = l.With("user", user.Did)
+
+	registrations, err := db.GetRegistrations(
+		k.Db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("domain", domain),
+		db.FilterIsNot("registered", "null"),
+	)
+	if err != nil || len(registrations) != 1 {
+		l.Error("failed to get registration", "err", err)
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	params := pages.KnotTrashParams{Domain: domain}
+
+	client, err := k.OAuth.ServiceClient(
+		r,
+		oauth.WithService(domain),
+		oauth.WithLxm(tangled.KnotTrashNSID),
+		oauth.WithExp(60),
+		oauth.WithDev(k.Config.Core.Dev),
+	)
+	if err != nil {
+		l.Error("failed to create service client", "err", err)
+		params.Error = "Failed to reach the knot, try again later."
+		k.Pages.KnotTrash(w, params)
+		return
+	}
+
+	out, err := tangled.KnotTrash(r.Context(), client)
+	if err != nil {
+		l.Error("failed to fetch trash", "err", err)
+		params.Error = "This knot does not keep deleted repositories, it may need an upgrade."
+		k.Pages.KnotTrash(w, params)
+		return
+	}
+
+	if out.Retention != nil {
+		params.Retention = *out.Retention
+	}
+
+	for _, repo := range out.Repos {
+		trashed := pages.KnotTrashedRepo{
+			Did:  repo.Did,
+			Name: repo.Name,
+			Size: uint64(max(repo.Size, 0)),
+		}
+		if t, err := time.Parse(time.RFC3339, repo.DeletedAt); err == nil {
+			trashed.Deleted = t
+		}
+		if t, err := time.Parse(time.RFC3339, repo.ExpiresAt); err == nil {
+			trashed.Expires = t
+		}
+		params.Repos = append(params.Repos, trashed)
+	}
+
+	k.Pages.KnotTrash(w, params)
+}
+
+// restore brings a deleted repo back on the knot. When the knot owner owns
+// the repo too, it is registered on the appview again; anyone else's repo can
+// be cloned from the knot by its owner.
+func (k *Knots) restore(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+	l := k.Logger.With("handler", "restore")
+
+	noticeId := "trash-error"
+	defaultErr := "Failed to restore repository. Try again later."
+	fail := func() {
+		k.Pages.Notice(w, noticeId, defaultErr)
+	}
+
+	domain := chi.URLParam(r, "domain")
+	if domain == "" {
+		l.Error("empty domain")
+		fail()
+		return
+	}
+	l = l.With("domain", domain)
+	l = l.With("user", user.Did)
+
+	registrations, err := db.GetRegistrations(
+		k.Db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("domain", domain),
+		db.FilterIsNot("registered", "null"),
+	)
+	if err != nil || len(registrations) != 1 {
+		l.Error("failed to get registration", "err", err)
+		fail()
+		return
+	}
+
+	did := r.FormValue("did")
+	name := r.FormValue("name")
+	if did == "" || name == "" {
+		k.Pages.Notice(w, noticeId, "Invalid repository.")
+		return
+	}
+	l = l.With("repo", did+"/"+name)
+
+	if did == user.Did {
+		if existing, err := db.GetRepo(k.Db, did, name); err == nil && existing != nil {
+			k.Pages.Notice(w, noticeId, fmt.Sprintf("You already have a repository by this name on %s.", existing.Knot))
+			return
+		}
+	}
+
+	client, err := k.OAuth.ServiceClient(
+		r,
+		oauth.WithService(domain),
+		oauth.WithLxm(tangled.RepoRestoreNSID),
+		oauth.WithDev(k.Config.Core.Dev),
+	)
+	if err != nil {
+		l.Error("failed to create service client", "err", err)
+		fail()
+		return
+	}
+
+	xe := tangled.RepoRestore(r.Context(), client, &tangled.RepoRestore_Input{
+		Did:  did,
+		Name: name,
+	})
+	if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+		l.Error("xrpc error", "xe", xe)
+		k.Pages.Notice(w, noticeId, err.Error())
+		return
+	}
+
+	if did != user.Did {
+		k.Pages.Notice(w, noticeId, fmt.Sprintf("Restored %s on the knot, its owner can clone it from https://%s/%s/%s.", name, domain, did, name))
+		return
+	}
+
+	// the record and everything on the appview went with the repo, it comes
+	// back as a new one
+	pdsClient, err := k.OAuth.AuthorizedClient(r)
+	if err != nil {
+		l.Error("failed to authorize client", "err", err)
+		fail()
+		return
+	}
+
+	repo := &db.Repo{
+		Did:  did,
+		Name: name,
+		Knot: domain,
+		Rkey: tid.TID(),
+	}
+
+	_, err = pdsClient.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoNSID,
+		Repo:       user.Did,
+		Rkey:       repo.Rkey,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &tangled.Repo{
+				Knot:      repo.Knot,
+				Name:      repo.Name,
+				CreatedAt: time.Now().Format(time.RFC3339),
+				Owner:     user.Did,
+			}},
+	})
+	if err != nil {
+		l.Error("failed to put record", "err", err)
+		k.Pages.Notice(w, noticeId, "Restored on the knot, but failed to write record to PDS.")
+		return
+	}
+
+	if err := db.AddRepo(k.Db, repo); err != nil {
+		l.Error("failed to add repo", "err", err)
+		fail()
+		return
+	}
+
+	p, _ := securejoin.SecureJoin(user.Did, name)
+	if err := k.Enforcer.AddRepo(user.Did, domain, p); err != nil {
+		l.Error("failed to update ACLs", "err", err)
+		fail()
+		return
+	}
+
+	if err := k.Enforcer.E.SavePolicy(); err != nil {
+		l.Error("failed to save ACLs", "err", err)
+		fail()
+		return
+	}
+
+	k.Pages.HxLocation(w, fmt.Sprintf("/@%s/%s", user.Handle, name))
+}
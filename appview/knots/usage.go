@@ -0,0 +1,101 @@
+package knots
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+// usage fetches disk usage and repo activity from the knot, only its owner
+// may see them
+func (k *Knots) usage(w http.ResponseWriter, r *http.Request) {
+	user := k.OAuth.GetUser(r)
+	l := k.Logger.With("handler", "usage")
+
+	domain := chi.URLParam(r, "domain")
+	if domain == "" {
+		return
+	}
+	l = l.With("domain", domain)
+	l = l.With("user", user.Did)
+
+	registrations, err := db.GetRegistrations(
+		k.Db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("domain", domain),
+		db.FilterIsNot("registered", "null"),
+	)
+	if err != nil || len(registrations) != 1 {
+		l.Error("failed to get registration", "err", err)
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	params := pages.KnotUsageParams{Domain: domain}
+
+	client, err := k.OAuth.ServiceClient(
+		r,
+		oauth.WithService(domain),
+		oauth.WithLxm(tangled.KnotUsageNSID),
+		oauth.WithExp(60),
+		oauth.WithDev(k.Config.Core.Dev),
+	)
+	if err != nil {
+		l.Error("failed to create service client", "err", err)
+		params.Error = "Failed to reach the knot, try again later."
+		k.Pages.KnotUsage(w, params)
+		return
+	}
+
+	out, err := tangled.KnotUsage(r.Context(), client)
+	if err != nil {
+		l.Error("failed to fetch usage", "err", err)
+		params.Error = "This knot does not report usage, it may need an upgrade."
+		k.Pages.KnotUsage(w, params)
+		return
+	}
+
+	params.TotalSize = uint64(max(out.TotalSize, 0))
+	if out.MaintenanceInterval != nil {
+		params.MaintenanceInterval = *out.MaintenanceInterval
+	}
+
+	for _, repo := range out.Repos {
+		usage := pages.KnotRepoUsage{
+			Did:      repo.Did,
+			Name:     repo.Name,
+			Size:     uint64(max(repo.Size, 0)),
+			Clones:   repo.Clones,
+			Pushes:   repo.Pushes,
+			Features: repo.Features,
+		}
+		if repo.LastMaintenance != nil {
+			if t, err := time.Parse(time.RFC3339, *repo.LastMaintenance); err == nil {
+				usage.LastMaintenance = &t
+			}
+		}
+		if repo.MaintenanceError != nil {
+			usage.MaintenanceError = *repo.MaintenanceError
+		}
+		params.Repos = append(params.Repos, usage)
+	}
+
+	// largest first, those are the ones worth looking at
+	slices.SortFunc(params.Repos, func(a, b pages.KnotRepoUsage) int {
+		if a.Size > b.Size {
+			return -1
+		}
+		if a.Size < b.Size {
+			return 1
+		}
+		return 0
+	})
+
+	k.Pages.KnotUsage(w, params)
+}
@@ -2,16 +2,27 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
 	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/challenge"
+	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/db"
 	"tangled.sh/tangled.sh/core/appview/oauth"
 	"tangled.sh/tangled.sh/core/appview/pages"
@@ -22,22 +33,26 @@ import (
 )
 
 type Middleware struct {
+	config       *config.Config
 	oauth        *oauth.OAuth
 	db           *db.DB
 	enforcer     *rbac.Enforcer
 	repoResolver *reporesolver.RepoResolver
 	idResolver   *idresolver.Resolver
 	pages        *pages.Pages
+	challenger   *challenge.Challenger
 }
 
-func New(oauth *oauth.OAuth, db *db.DB, enforcer *rbac.Enforcer, repoResolver *reporesolver.RepoResolver, idResolver *idresolver.Resolver, pages *pages.Pages) Middleware {
+func New(config *config.Config, oauth *oauth.OAuth, db *db.DB, enforcer *rbac.Enforcer, repoResolver *reporesolver.RepoResolver, idResolver *idresolver.Resolver, pages *pages.Pages) Middleware {
 	return Middleware{
+		config:       config,
 		oauth:        oauth,
 		db:           db,
 		enforcer:     enforcer,
 		repoResolver: repoResolver,
 		idResolver:   idResolver,
 		pages:        pages,
+		challenger:   challenge.New(config.Core.Secret("pow"), config.Challenge.Validity),
 	}
 }
 
@@ -81,6 +96,46 @@ func AuthMiddleware(a *oauth.OAuth) middlewareFunc {
 	}
 }
 
+// Sudo asks users who registered a passkey to confirm it is them with it
+// before going ahead, unless they did so in the last few minutes. Once
+// confirmed they are sent back to the page they were on, to try again.
+func Sudo(a *oauth.OAuth, d *db.DB) middlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			did := a.GetDid(r)
+			if did == "" || a.Sudo(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ok, err := db.HasPasskeys(d, did)
+			if err != nil {
+				log.Println("failed to check passkeys", "err", err)
+			} else if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			returnURL := "/"
+			current := r.Header.Get("HX-Current-URL")
+			if current == "" {
+				current = r.Header.Get("Referer")
+			}
+			if u, err := url.Parse(current); err == nil && u.Path != "" {
+				returnURL = u.RequestURI()
+			}
+			sudoURL := fmt.Sprintf("/settings/sudo?return_url=%s", url.QueryEscape(returnURL))
+
+			if r.Header.Get("HX-Request") == "true" {
+				w.Header().Set("HX-Redirect", sudoURL)
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			http.Redirect(w, r, sudoURL, http.StatusSeeOther)
+		})
+	}
+}
+
 func Paginate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		page := pagination.FirstPage()
@@ -174,6 +229,30 @@ func (mw Middleware) RepoPermissionMiddleware(requiredPerm string) middlewareFun
 	}
 }
 
+// RefreshHandle keeps the handle in the session of the logged in user in
+// step with their identity, so that a new handle shows up without logging in
+// again
+func (mw Middleware) RefreshHandle() middlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := mw.oauth.GetUser(r)
+			if user == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, err := mw.idResolver.ResolveIdent(r.Context(), user.Did)
+			if err == nil && !id.Handle.IsInvalidHandle() && id.Handle.String() != user.Handle {
+				if err := mw.oauth.UpdateHandle(w, r, id.Handle.String()); err != nil {
+					log.Println("failed to update handle in session", err)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (mw Middleware) ResolveIdent() middlewareFunc {
 	excluded := []string{"favicon.ico"}
 
@@ -189,6 +268,14 @@ func (mw Middleware) ResolveIdent() middlewareFunc {
 
 			id, err := mw.idResolver.ResolveIdent(req.Context(), didOrHandle)
 			if err != nil {
+				// the account may have gone by this handle before
+				if did, err := db.GetHandleRedirect(mw.db, strings.ToLower(didOrHandle)); err == nil {
+					if id, err := mw.idResolver.ResolveIdent(req.Context(), did); err == nil && !id.Handle.IsInvalidHandle() {
+						mw.redirectIdent(w, req, id)
+						return
+					}
+				}
+
 				// invalid did or handle
 				log.Printf("failed to resolve did/handle '%s': %s\n", didOrHandle, err)
 				mw.pages.Error404(w)
@@ -214,9 +301,20 @@ func (mw Middleware) ResolveRepo() middlewareFunc {
 			}
 
 			repo, err := db.GetRepo(mw.db, id.DID.String(), repoName)
+			if errors.Is(err, sql.ErrNoRows) {
+				// transferred repos are found under their new owner; this
+				// comes first as the old record may still be around
+				if moved, err := db.GetRepoRedirect(mw.db, id.DID.String(), repoName); err == nil {
+					mw.redirectRepo(w, req, moved)
+					return
+				}
+
+				// the repo may have been registered through another appview
+				repo, err = mw.resolveRemoteRepo(req.Context(), id, repoName)
+			}
 			if err != nil {
 				// invalid did or handle
-				log.Println("failed to resolve repo")
+				log.Println("failed to resolve repo", err)
 				mw.pages.ErrorKnot404(w)
 				return
 			}
@@ -227,6 +325,133 @@ func (mw Middleware) ResolveRepo() middlewareFunc {
 	}
 }
 
+// redirectRepo sends a request for a transferred repo on to the same page
+// under its new owner
+func (mw Middleware) redirectRepo(w http.ResponseWriter, req *http.Request, repo *db.Repo) {
+	owner := repo.Did
+	if id, err := mw.idResolver.ResolveIdent(req.Context(), repo.Did); err == nil && !id.Handle.IsInvalidHandle() {
+		owner = "@" + id.Handle.String()
+	}
+
+	prefix := fmt.Sprintf("/%s/%s", chi.URLParam(req, "user"), chi.URLParam(req, "repo"))
+	target := fmt.Sprintf("/%s/%s%s", owner, repo.Name, strings.TrimPrefix(req.URL.Path, prefix))
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+
+	// git pushes and fetches post to the repo, their method has to be kept
+	status := http.StatusMovedPermanently
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		status = http.StatusPermanentRedirect
+	}
+	http.Redirect(w, req, target, status)
+}
+
+// redirectIdent sends a request under an old handle on to the same page under
+// the current one
+func (mw Middleware) redirectIdent(w http.ResponseWriter, req *http.Request, id *identity.Identity) {
+	prefix := "/" + chi.URLParam(req, "user")
+	target := "/@" + id.Handle.String() + strings.TrimPrefix(req.URL.Path, prefix)
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+
+	// the old handle may be taken up by someone else later, so this must
+	// not be cached as permanent
+	status := http.StatusFound
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		status = http.StatusTemporaryRedirect
+	}
+	http.Redirect(w, req, target, status)
+}
+
+// resolveRemoteRepo looks for a repo record on the owner's PDS, and indexes
+// it locally if there is one. Everything else about the repo comes from its
+// knot, like it does for any other repo.
+func (mw Middleware) resolveRemoteRepo(ctx context.Context, id identity.Identity, name string) (*db.Repo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	xrpcc := xrpc.Client{
+		Host: id.PDSEndpoint(),
+	}
+
+	var record *tangled.Repo
+	var rkey string
+	cursor := ""
+	for record == nil {
+		resp, err := comatproto.RepoListRecords(ctx, &xrpcc, tangled.RepoNSID, cursor, 100, id.DID.String(), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repo records: %w", err)
+		}
+
+		for _, r := range resp.Records {
+			repo, ok := r.Value.Val.(*tangled.Repo)
+			if !ok || repo.Name != name {
+				continue
+			}
+
+			uri, err := syntax.ParseATURI(r.Uri)
+			if err != nil {
+				return nil, err
+			}
+
+			record = repo
+			rkey = uri.RecordKey().String()
+			break
+		}
+
+		if resp.Cursor == nil || *resp.Cursor == "" || len(resp.Records) == 0 {
+			break
+		}
+		cursor = *resp.Cursor
+	}
+
+	if record == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	repo := &db.Repo{
+		Did:  id.DID.String(),
+		Name: record.Name,
+		Knot: record.Knot,
+		Rkey: rkey,
+	}
+	if record.Description != nil {
+		repo.Description = *record.Description
+	}
+	if record.Source != nil {
+		repo.Source = *record.Source
+	}
+	if created, err := time.Parse(time.RFC3339, record.CreatedAt); err == nil {
+		repo.Created = created
+	}
+
+	tx, err := mw.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := db.AddRepo(tx, repo); err != nil {
+		return nil, fmt.Errorf("failed to index remote repo: %w", err)
+	}
+
+	if err := mw.enforcer.AddRepo(repo.Did, repo.Knot, repo.DidSlashRepo()); err != nil {
+		return nil, fmt.Errorf("failed to add repo policies: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := mw.enforcer.E.SavePolicy(); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
 // middleware that is tacked on top of /{user}/{repo}/pulls/{pull}
 func (mw Middleware) ResolvePull() middlewareFunc {
 	return func(next http.Handler) http.Handler {
@@ -275,11 +500,66 @@ func (mw Middleware) ResolvePull() middlewareFunc {
 	}
 }
 
+// CustomDomain serves requests arriving on a verified custom domain by
+// rewriting the path onto the profile or repo that the domain maps to, before
+// any routing takes place.
+func (mw Middleware) CustomDomain() middlewareFunc {
+	var appviewHost string
+	if u, err := url.Parse(mw.config.Core.AppviewHost); err == nil {
+		appviewHost = u.Hostname()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.Host)
+			if err != nil {
+				host = r.Host
+			}
+
+			// assets are shared across all domains
+			if host == appviewHost || strings.HasPrefix(r.URL.Path, "/static/") || strings.HasPrefix(r.URL.Path, "/favicon") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			domain, err := db.GetVerifiedDomain(mw.db, strings.ToLower(host))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			prefix := "/" + domain.Did.String()
+			if domain.RepoAt != nil {
+				repo, err := db.GetRepoByAtUri(mw.db, domain.RepoAt.String())
+				if err != nil {
+					log.Println("failed to resolve repo for custom domain", domain.Domain, err)
+					mw.pages.Error404(w)
+					return
+				}
+				prefix = prefix + "/" + repo.Name
+			}
+
+			r.URL.Path = strings.TrimSuffix(prefix+r.URL.Path, "/")
+			r.URL.RawPath = ""
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // this should serve the go-import meta tag even if the path is technically
 // a 404 like tangled.sh/oppi.li/go-git/v5
+//
+// any client passing ?go-get=1 is answered, not just the go tool; module
+// proxies and pkg.go.dev use their own user agents.
 func (mw Middleware) GoImport() middlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("go-get") != "1" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			f, err := mw.repoResolver.Resolve(r)
 			if err != nil {
 				log.Println("failed to fully resolve repo", err)
@@ -287,22 +567,93 @@ func (mw Middleware) GoImport() middlewareFunc {
 				return
 			}
 
-			fullName := f.OwnerHandle() + "/" + f.Name
+			appviewUrl, err := url.Parse(mw.config.Core.AppviewHost)
+			if err != nil {
+				log.Println("invalid appview host", err)
+				http.Error(w, "invalid appview host", http.StatusInternalServerError)
+				return
+			}
+
+			writeGoImport(w, appviewUrl, f.OwnerHandle()+"/"+f.Name)
+		})
+	}
+}
 
-			if r.Header.Get("User-Agent") == "Go-http-client/1.1" {
-				if r.URL.Query().Get("go-get") == "1" {
-					html := fmt.Sprintf(
-						`<meta name="go-import" content="tangled.sh/%s git https://tangled.sh/%s"/>`,
-						fullName,
-						fullName,
-					)
-					w.Header().Set("Content-Type", "text/html")
-					w.Write([]byte(html))
-					return
-				}
+// go-source points pkg.go.dev at our tree and blob views
+func writeGoImport(w http.ResponseWriter, host *url.URL, fullName string) {
+	prefix := fmt.Sprintf("%s/%s", host.Host, fullName)
+	home := fmt.Sprintf("%s://%s", host.Scheme, prefix)
+
+	html := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta name="go-import" content="%[1]s git %[2]s">
+<meta name="go-source" content="%[1]s %[2]s %[2]s/tree/HEAD{/dir} %[2]s/blob/HEAD{/dir}/{file}#L{line}">
+</head>
+<body>
+go get %[1]s
+</body>
+</html>
+`, prefix, home)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
+
+// Challenge requires a solved proof of work of requests for action, if this
+// instance challenges action at all. Logged in users are only challenged
+// when they are acting in a burst. Unsolved requests are answered with a
+// challenge, which fragments/challenge solves before retrying them.
+func (mw Middleware) Challenge(action challenge.Action) middlewareFunc {
+	cfg := mw.config.Challenge
+	enabled := cfg.Difficulty > 0 && slices.Contains(cfg.Actions, string(action))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !enabled {
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			next.ServeHTTP(w, r)
+			if user := mw.oauth.GetUser(r); user != nil && !mw.isBurst(user.Did, action) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			err := mw.challenger.Verify(action, r.Header.Get("X-Challenge"), r.Header.Get("X-Challenge-Solution"))
+			if err == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !errors.Is(err, challenge.ErrMissing) {
+				log.Printf("failed %s challenge: %v", action, err)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusPreconditionRequired)
+			json.NewEncoder(w).Encode(mw.challenger.Issue(action, cfg.Difficulty))
 		})
 	}
 }
+
+func (mw Middleware) isBurst(did string, action challenge.Action) bool {
+	since := time.Now().Add(-mw.config.Challenge.BurstWindow)
+
+	var count int
+	var err error
+	switch action {
+	case challenge.ActionComment:
+		count, err = db.CountRecentComments(mw.db, did, since)
+	case challenge.ActionRepo:
+		count, err = db.CountRecentRepos(mw.db, did, since)
+	default:
+		return true
+	}
+	if err != nil {
+		// challenge rather than let a burst through
+		log.Println("failed to count recent activity", err)
+		return true
+	}
+
+	return count >= mw.config.Challenge.BurstLimit
+}
@@ -3,7 +3,9 @@ package notify
 import (
 	"context"
 
+	"tangled.sh/tangled.sh/core/api/tangled"
 	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/types"
 )
 
 type mergedNotifier struct {
@@ -22,6 +24,18 @@ func (m *mergedNotifier) NewRepo(ctx context.Context, repo *db.Repo) {
 	}
 }
 
+func (m *mergedNotifier) NewPush(ctx context.Context, update *tangled.GitRefUpdate) {
+	for _, notifier := range m.notifiers {
+		notifier.NewPush(ctx, update)
+	}
+}
+
+func (m *mergedNotifier) NewRelease(ctx context.Context, repo *db.Repo, tag *types.TagReference) {
+	for _, notifier := range m.notifiers {
+		notifier.NewRelease(ctx, repo, tag)
+	}
+}
+
 func (m *mergedNotifier) NewStar(ctx context.Context, star *db.Star) {
 	for _, notifier := range m.notifiers {
 		notifier.NewStar(ctx, star)
@@ -38,6 +52,17 @@ func (m *mergedNotifier) NewIssue(ctx context.Context, issue *db.Issue) {
 		notifier.NewIssue(ctx, issue)
 	}
 }
+func (m *mergedNotifier) NewIssueComment(ctx context.Context, comment *db.Comment) {
+	for _, notifier := range m.notifiers {
+		notifier.NewIssueComment(ctx, comment)
+	}
+}
+
+func (m *mergedNotifier) NewIssueClosed(ctx context.Context, issue *db.Issue, actorDid string) {
+	for _, notifier := range m.notifiers {
+		notifier.NewIssueClosed(ctx, issue, actorDid)
+	}
+}
 
 func (m *mergedNotifier) NewFollow(ctx context.Context, follow *db.Follow) {
 	for _, notifier := range m.notifiers {
@@ -61,6 +86,12 @@ func (m *mergedNotifier) NewPullComment(ctx context.Context, comment *db.PullCom
 	}
 }
 
+func (m *mergedNotifier) NewPullMerged(ctx context.Context, pull *db.Pull, actorDid string) {
+	for _, notifier := range m.notifiers {
+		notifier.NewPullMerged(ctx, pull, actorDid)
+	}
+}
+
 func (m *mergedNotifier) UpdateProfile(ctx context.Context, profile *db.Profile) {
 	for _, notifier := range m.notifiers {
 		notifier.UpdateProfile(ctx, profile)
@@ -3,22 +3,29 @@ package notify
 import (
 	"context"
 
+	"tangled.sh/tangled.sh/core/api/tangled"
 	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/types"
 )
 
 type Notifier interface {
 	NewRepo(ctx context.Context, repo *db.Repo)
+	NewPush(ctx context.Context, update *tangled.GitRefUpdate)
+	NewRelease(ctx context.Context, repo *db.Repo, tag *types.TagReference)
 
 	NewStar(ctx context.Context, star *db.Star)
 	DeleteStar(ctx context.Context, star *db.Star)
 
 	NewIssue(ctx context.Context, issue *db.Issue)
+	NewIssueComment(ctx context.Context, comment *db.Comment)
+	NewIssueClosed(ctx context.Context, issue *db.Issue, actorDid string)
 
 	NewFollow(ctx context.Context, follow *db.Follow)
 	DeleteFollow(ctx context.Context, follow *db.Follow)
 
 	NewPull(ctx context.Context, pull *db.Pull)
 	NewPullComment(ctx context.Context, comment *db.PullComment)
+	NewPullMerged(ctx context.Context, pull *db.Pull, actorDid string)
 
 	UpdateProfile(ctx context.Context, profile *db.Profile)
 }
@@ -28,17 +35,22 @@ type BaseNotifier struct{}
 
 var _ Notifier = &BaseNotifier{}
 
-func (m *BaseNotifier) NewRepo(ctx context.Context, repo *db.Repo) {}
+func (m *BaseNotifier) NewRepo(ctx context.Context, repo *db.Repo)                             {}
+func (m *BaseNotifier) NewPush(ctx context.Context, update *tangled.GitRefUpdate)              {}
+func (m *BaseNotifier) NewRelease(ctx context.Context, repo *db.Repo, tag *types.TagReference) {}
 
 func (m *BaseNotifier) NewStar(ctx context.Context, star *db.Star)    {}
 func (m *BaseNotifier) DeleteStar(ctx context.Context, star *db.Star) {}
 
-func (m *BaseNotifier) NewIssue(ctx context.Context, issue *db.Issue) {}
+func (m *BaseNotifier) NewIssue(ctx context.Context, issue *db.Issue)                        {}
+func (m *BaseNotifier) NewIssueComment(ctx context.Context, comment *db.Comment)             {}
+func (m *BaseNotifier) NewIssueClosed(ctx context.Context, issue *db.Issue, actorDid string) {}
 
 func (m *BaseNotifier) NewFollow(ctx context.Context, follow *db.Follow)    {}
 func (m *BaseNotifier) DeleteFollow(ctx context.Context, follow *db.Follow) {}
 
-func (m *BaseNotifier) NewPull(ctx context.Context, pull *db.Pull)                  {}
-func (m *BaseNotifier) NewPullComment(ctx context.Context, comment *db.PullComment) {}
+func (m *BaseNotifier) NewPull(ctx context.Context, pull *db.Pull)                        {}
+func (m *BaseNotifier) NewPullComment(ctx context.Context, comment *db.PullComment)       {}
+func (m *BaseNotifier) NewPullMerged(ctx context.Context, pull *db.Pull, actorDid string) {}
 
 func (m *BaseNotifier) UpdateProfile(ctx context.Context, profile *db.Profile) {}
@@ -1,15 +1,31 @@
 package oauth
 
+import "time"
+
 const (
 	SessionName          = "appview-session"
 	SessionHandle        = "handle"
 	SessionDid           = "did"
+	SessionId            = "id"
 	SessionPds           = "pds"
 	SessionAccessJwt     = "accessJwt"
 	SessionRefreshJwt    = "refreshJwt"
 	SessionExpiry        = "expiry"
 	SessionAuthenticated = "authenticated"
+	SessionSudo          = "sudo"
+	SessionConsent       = "consent"
+
+	ChallengeSessionName = "appview-challenge"
+	SessionChallenge     = "challenge"
+
+	WaitlistSessionName = "appview-waitlist"
 
 	SessionDpopPrivateJwk      = "dpopPrivateJwk"
 	SessionDpopAuthServerNonce = "dpopAuthServerNonce"
 )
+
+const (
+	sudoTTL      = 10 * time.Minute
+	challengeTTL = 5 * time.Minute
+	waitlistTTL  = time.Hour
+)
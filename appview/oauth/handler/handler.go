@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"log"
 	"net/http"
 	"net/url"
@@ -21,6 +23,7 @@ import (
 	sessioncache "tangled.sh/tangled.sh/core/appview/cache/session"
 	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/email"
 	"tangled.sh/tangled.sh/core/appview/middleware"
 	"tangled.sh/tangled.sh/core/appview/oauth"
 	"tangled.sh/tangled.sh/core/appview/oauth/client"
@@ -75,12 +78,17 @@ func (o *OAuthHandler) Router() http.Handler {
 
 	r.Get("/login", o.login)
 	r.Post("/login", o.login)
+	r.Post("/login/passkey/options", o.passkeyOptions)
+	r.Post("/login/passkey", o.passkeyLogin)
 
 	r.With(middleware.AuthMiddleware(o.oauth)).Post("/logout", o.logout)
 
 	r.Get("/oauth/client-metadata.json", o.clientMetadata)
 	r.Get("/oauth/jwks.json", o.jwks)
 	r.Get("/oauth/callback", o.callback)
+
+	r.Get("/waitlist", o.waitlist)
+	r.Post("/waitlist", o.waitlist)
 	return r
 }
 
@@ -112,6 +120,7 @@ func (o *OAuthHandler) login(w http.ResponseWriter, r *http.Request) {
 		returnURL := r.URL.Query().Get("return_url")
 		o.pages.Login(w, pages.LoginParams{
 			ReturnUrl: returnURL,
+			Policy:    o.config.Registration.Policy,
 		})
 	case http.MethodPost:
 		handle := r.FormValue("handle")
@@ -140,6 +149,24 @@ func (o *OAuthHandler) login(w http.ResponseWriter, r *http.Request) {
 			o.pages.Notice(w, "login-msg", fmt.Sprintf("\"%s\" is an invalid handle.", handle))
 			return
 		}
+
+		inviteCode := strings.TrimSpace(r.FormValue("invite"))
+		notice, err := o.checkRegistration(resolved.DID.String(), resolved.PDSEndpoint(), inviteCode)
+		// those joining the waitlist sign in to prove who they are, and are
+		// only turned away for their PDS
+		if r.FormValue("waitlist") != "" && o.config.Registration.PdsAllowed(resolved.PDSEndpoint()) {
+			notice = ""
+		}
+		if err != nil {
+			log.Println("failed to check registration:", err)
+			o.pages.Notice(w, "login-msg", "Failed to authenticate. Try again later.")
+			return
+		}
+		if notice != "" {
+			o.pages.Notice(w, "login-msg", notice)
+			return
+		}
+
 		self := o.oauth.ClientMetadata()
 		oauthClient, err := client.NewClient(
 			self.ClientID,
@@ -198,6 +225,7 @@ func (o *OAuthHandler) login(w http.ResponseWriter, r *http.Request) {
 			DpopPrivateJwk:      string(dpopKeyJson),
 			State:               parResp.State,
 			ReturnUrl:           r.FormValue("return_url"),
+			InviteCode:          inviteCode,
 		})
 		if err != nil {
 			log.Println("failed to save oauth request:", err)
@@ -300,7 +328,22 @@ func (o *OAuthHandler) callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = o.oauth.SaveSession(w, r, *oauthRequest, tokenResp)
+	err = o.admit(oauthRequest.Did, oauthRequest.InviteCode)
+	if errors.Is(err, errNotAdmitted) {
+		// they have proven who they are, which is all the waitlist needs
+		if err := o.oauth.SetWaitlist(w, r, oauthRequest.Did); err != nil {
+			log.Println("failed to remember waitlist account:", err)
+		}
+		http.Redirect(w, r, "/waitlist", http.StatusFound)
+		return
+	}
+	if err != nil {
+		log.Println("failed to admit member:", err)
+		o.pages.Notice(w, "login-msg", "Failed to authenticate. Try again later.")
+		return
+	}
+
+	login, err := o.oauth.SaveSession(w, r, *oauthRequest, tokenResp)
 	if err != nil {
 		log.Println("failed to save session:", err)
 		o.pages.Notice(w, "login-msg", "Failed to authenticate. Try again later.")
@@ -308,6 +351,9 @@ func (o *OAuthHandler) callback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Println("session saved successfully")
+	if login.New {
+		o.notifyLogin(r, login)
+	}
 	go o.addToDefaultKnot(oauthRequest.Did)
 	go o.addToDefaultSpindle(oauthRequest.Did)
 
@@ -329,6 +375,47 @@ func (o *OAuthHandler) callback(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, returnUrl, http.StatusFound)
 }
 
+// notifyLogin tells a user about a login from a device or address they had not
+// signed in from before: on their other sessions, and by email if they have a
+// verified primary address
+func (o *OAuthHandler) notifyLogin(r *http.Request, login *sessioncache.Login) {
+	if err := o.oauth.SetLoginAlert(r, login); err != nil {
+		log.Println("failed to set login alert:", err)
+	}
+
+	if o.config.Resend.ApiKey == "" {
+		return
+	}
+
+	primary, err := db.GetPrimaryEmail(o.db, login.Did)
+	if err != nil || !primary.Verified {
+		return
+	}
+
+	link := o.config.Core.AppviewHost + "/settings/sessions"
+	when := login.Created.UTC().Format("Jan 2, 2006 at 15:04 UTC")
+
+	go func() {
+		err := email.SendEmail(email.Email{
+			APIKey:  o.config.Resend.ApiKey,
+			From:    o.config.Resend.SentFrom,
+			To:      primary.Address,
+			Subject: "New sign in to your Tangled account",
+			Text: fmt.Sprintf(
+				"Someone signed in to your account on %s from %s using %s.\n\nIf this wasn't you, revoke the session at %s\n",
+				when, login.IP, login.UserAgent, link,
+			),
+			Html: fmt.Sprintf(
+				"<p>Someone signed in to your account on %s from <code>%s</code> using <code>%s</code>.</p><p>If this wasn't you, revoke the session at <a href=\"%s\">%s</a>.</p>",
+				when, html.EscapeString(login.IP), html.EscapeString(login.UserAgent), link, link,
+			),
+		})
+		if err != nil {
+			log.Println("failed to send login notification:", err)
+		}
+	}()
+}
+
 func (o *OAuthHandler) logout(w http.ResponseWriter, r *http.Request) {
 	err := o.oauth.ClearSession(r, w)
 	if err != nil {
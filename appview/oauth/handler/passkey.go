@@ -0,0 +1,130 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/webauthn"
+)
+
+// passkeyOptions starts signing in with a passkey. No credentials are listed,
+// the browser offers the passkeys it holds for the appview.
+func (o *OAuthHandler) passkeyOptions(w http.ResponseWriter, r *http.Request) {
+	rp, err := webauthn.NewRelyingParty(o.config.Core.AppviewHost)
+	if err != nil {
+		log.Println("failed to set up passkeys:", err)
+		writePasskeyError(w, http.StatusInternalServerError, "Passkeys are not available on this instance.")
+		return
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err == nil {
+		err = o.oauth.SetChallenge(w, r, challenge)
+	}
+	if err != nil {
+		log.Println("failed to set challenge:", err)
+		writePasskeyError(w, http.StatusInternalServerError, "Failed to authenticate. Try again later.")
+		return
+	}
+
+	writePasskeyJSON(w, map[string]any{
+		"challenge":        challenge,
+		"rpId":             rp.ID,
+		"allowCredentials": []string{},
+		"userVerification": "required",
+		"timeout":          60000,
+	})
+}
+
+// passkeyLogin signs the owner of a passkey back in with the session the
+// appview holds for them. Once that is gone, after logging out or a week
+// away, the browser is handed their handle to go through the PDS again.
+func (o *OAuthHandler) passkeyLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ReturnUrl  string             `json:"return_url"`
+		Credential webauthn.Assertion `json:"credential"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writePasskeyError(w, http.StatusBadRequest, "Invalid passkey.")
+		return
+	}
+
+	rp, err := webauthn.NewRelyingParty(o.config.Core.AppviewHost)
+	if err != nil {
+		log.Println("failed to set up passkeys:", err)
+		writePasskeyError(w, http.StatusInternalServerError, "Passkeys are not available on this instance.")
+		return
+	}
+	rp.UserVerification = true
+
+	passkey, err := db.GetPasskey(o.db, req.Credential.Id)
+	if err != nil {
+		writePasskeyError(w, http.StatusBadRequest, "This passkey is not registered on Tangled, log in with your handle.")
+		return
+	}
+	if userHandle, err := webauthn.Decode(req.Credential.UserHandle); err == nil && len(userHandle) > 0 && string(userHandle) != passkey.Did {
+		writePasskeyError(w, http.StatusBadRequest, "Invalid passkey.")
+		return
+	}
+
+	count, err := rp.VerifyAssertion(o.oauth.TakeChallenge(w, r), req.Credential, webauthn.Credential{
+		Id:        passkey.CredentialId,
+		PublicKey: passkey.PublicKey,
+		Algorithm: passkey.Algorithm,
+		SignCount: passkey.SignCount,
+	})
+	if err != nil {
+		log.Println("failed to verify passkey:", err)
+		writePasskeyError(w, http.StatusBadRequest, "Failed to verify passkey, try again.")
+		return
+	}
+	if err := db.SetPasskeyUsed(o.db, passkey.Id, count); err != nil {
+		log.Println("failed to record passkey use:", err)
+	}
+
+	login, err := o.oauth.ResumeSession(w, r, passkey.Did)
+	if errors.Is(err, oauth.ErrNoSession) {
+		ident, err := o.idResolver.ResolveIdent(r.Context(), passkey.Did)
+		if err != nil {
+			log.Println("failed to resolve did:", err)
+			writePasskeyError(w, http.StatusInternalServerError, "Failed to authenticate. Try again later.")
+			return
+		}
+		writePasskeyJSON(w, map[string]string{"handle": ident.Handle.String()})
+		return
+	}
+	if err != nil {
+		log.Println("failed to resume session:", err)
+		writePasskeyError(w, http.StatusInternalServerError, "Failed to authenticate. Try again later.")
+		return
+	}
+
+	if err := o.oauth.SetSudo(w, r); err != nil {
+		log.Println("failed to set sudo:", err)
+	}
+	if login.New {
+		o.notifyLogin(r, login)
+	}
+
+	returnUrl := req.ReturnUrl
+	if !strings.HasPrefix(returnUrl, "/") || strings.HasPrefix(returnUrl, "//") || strings.HasPrefix(returnUrl, "/\\") {
+		returnUrl = "/"
+	}
+	writePasskeyJSON(w, map[string]string{"redirect": returnUrl})
+}
+
+func writePasskeyJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writePasskeyError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
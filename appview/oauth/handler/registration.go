@@ -0,0 +1,157 @@
+package oauth
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/email"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+var errNotAdmitted = errors.New("not admitted by registration policy")
+
+// checkRegistration is run before sending non-members off to their PDS, so
+// that they learn why they cannot sign up before authorizing, it returns the
+// notice to show them if they cannot
+func (o *OAuthHandler) checkRegistration(did, pdsUrl, inviteCode string) (string, error) {
+	isMember, err := db.IsMember(o.db, did)
+	if err != nil {
+		return "", err
+	}
+	if isMember {
+		return "", nil
+	}
+
+	reg := o.config.Registration
+	if !reg.PdsAllowed(pdsUrl) {
+		return "Accounts on your PDS cannot sign up to this instance.", nil
+	}
+
+	switch reg.Policy {
+	case config.RegistrationOpen:
+		return "", nil
+	case config.RegistrationInvite:
+		if inviteCode == "" {
+			return `Signing up requires an invite code. <a href="/waitlist" class="underline">Join the waitlist</a> to get one.`, nil
+		}
+		available, err := db.IsInviteAvailable(o.db, inviteCode, did)
+		if err != nil {
+			return "", err
+		}
+		if !available {
+			return "This invite code is invalid or has been used already.", nil
+		}
+		return "", nil
+	default:
+		return `Signups are closed. <a href="/waitlist" class="underline">Join the waitlist</a> to hear when they open.`, nil
+	}
+}
+
+// admit makes did a member once it has authorized, claiming its invite
+func (o *OAuthHandler) admit(did, inviteCode string) error {
+	isMember, err := db.IsMember(o.db, did)
+	if err != nil || isMember {
+		return err
+	}
+
+	tx, err := o.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	switch {
+	case inviteCode != "" && o.config.Registration.Policy != config.RegistrationClosed:
+		err = db.UseInvite(tx, inviteCode, did)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errNotAdmitted
+		}
+		if err != nil {
+			return err
+		}
+	case o.config.Registration.Policy == config.RegistrationOpen:
+	default:
+		return errNotAdmitted
+	}
+
+	if err := db.AddMember(tx, did, inviteCode); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// waitlist adds the account that just signed in to the waitlist. Anyone else
+// is asked to sign in first, so that nobody can put down an email address for
+// an account that is not theirs.
+func (o *OAuthHandler) waitlist(w http.ResponseWriter, r *http.Request) {
+	did := o.oauth.WaitlistDid(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		params := pages.WaitlistParams{
+			Policy: o.config.Registration.Policy,
+		}
+		if did != "" {
+			params.Handle = did
+			if resolved, err := o.idResolver.ResolveIdent(r.Context(), did); err == nil {
+				params.Handle = resolved.Handle.String()
+			}
+		}
+		o.pages.Waitlist(w, params)
+	case http.MethodPost:
+		if did == "" {
+			o.pages.Notice(w, "waitlist-msg", "Sign in with your handle to join the waitlist.")
+			return
+		}
+
+		emailAddr := strings.TrimSpace(r.FormValue("email"))
+		note := strings.TrimSpace(r.FormValue("note"))
+
+		resolved, err := o.idResolver.ResolveIdent(r.Context(), did)
+		if err != nil {
+			o.pages.Notice(w, "waitlist-msg", "Could not find your account.")
+			return
+		}
+
+		if emailAddr != "" && !email.IsValidEmail(emailAddr) {
+			o.pages.Notice(w, "waitlist-msg", "Invalid email address.")
+			return
+		}
+
+		if len(note) > 500 {
+			o.pages.Notice(w, "waitlist-msg", "Note is too long.")
+			return
+		}
+
+		isMember, err := db.IsMember(o.db, did)
+		if err != nil {
+			log.Println("failed to check membership", err)
+			o.pages.Notice(w, "waitlist-msg", "Failed to join the waitlist. Try again later.")
+			return
+		}
+		if isMember {
+			o.pages.Notice(w, "waitlist-msg", "You already have access, log in instead.")
+			return
+		}
+
+		err = db.AddToWaitlist(o.db, db.WaitlistEntry{
+			Did:    did,
+			Handle: resolved.Handle.String(),
+			Email:  emailAddr,
+			Note:   note,
+		})
+		if err != nil {
+			log.Println("failed to add to waitlist", err)
+			o.pages.Notice(w, "waitlist-msg", "Failed to join the waitlist. Try again later.")
+			return
+		}
+
+		o.pages.Notice(w, "waitlist-msg", "You are on the waitlist.")
+	}
+}
@@ -1,13 +1,17 @@
 package oauth
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	indigo_xrpc "github.com/bluesky-social/indigo/xrpc"
+	"github.com/google/uuid"
 	"github.com/gorilla/sessions"
 	oauth "tangled.sh/icyphox.sh/atproto-oauth"
 	"tangled.sh/icyphox.sh/atproto-oauth/helpers"
@@ -15,6 +19,7 @@ import (
 	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/oauth/client"
 	xrpc "tangled.sh/tangled.sh/core/appview/xrpcclient"
+	tlog "tangled.sh/tangled.sh/core/log"
 )
 
 type OAuth struct {
@@ -35,20 +40,23 @@ func (o *OAuth) Stores() *sessions.CookieStore {
 	return o.store
 }
 
-func (o *OAuth) SaveSession(w http.ResponseWriter, r *http.Request, oreq sessioncache.OAuthRequest, oresp *oauth.TokenResponse) error {
-	// first we save the did in the user session
-	userSession, err := o.store.Get(r, SessionName)
-	if err != nil {
-		return err
+// SaveSession signs the user in and records the login, which is returned so
+// that the caller can tell whether it came from a new device
+func (o *OAuth) SaveSession(w http.ResponseWriter, r *http.Request, oreq sessioncache.OAuthRequest, oresp *oauth.TokenResponse) (*sessioncache.Login, error) {
+	login := &sessioncache.Login{
+		Id:        uuid.NewString(),
+		Did:       oreq.Did,
+		IP:        ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Created:   time.Now(),
+	}
+	if err := o.sess.AddLogin(r.Context(), login); err != nil {
+		return nil, fmt.Errorf("error recording login: %w", err)
 	}
 
-	userSession.Values[SessionDid] = oreq.Did
-	userSession.Values[SessionHandle] = oreq.Handle
-	userSession.Values[SessionPds] = oreq.PdsUrl
-	userSession.Values[SessionAuthenticated] = true
-	err = userSession.Save(r, w)
-	if err != nil {
-		return fmt.Errorf("error saving user session: %w", err)
+	// first we save the did in the user session
+	if err := o.saveUserSession(w, r, login.Id, oreq.Did, oreq.Handle, oreq.PdsUrl); err != nil {
+		return nil, err
 	}
 
 	// then save the whole thing in the db
@@ -64,7 +72,200 @@ func (o *OAuth) SaveSession(w http.ResponseWriter, r *http.Request, oreq session
 		Expiry:              time.Now().Add(time.Duration(oresp.ExpiresIn) * time.Second).Format(time.RFC3339),
 	}
 
-	return o.sess.SaveSession(r.Context(), session)
+	return login, o.sess.SaveSession(r.Context(), session)
+}
+
+// ResumeSession signs did in on this device with the oauth session the appview
+// already holds for it, without a round trip to its PDS. Passkeys sign users
+// back in this way, which fails with ErrNoSession once did logged out or the
+// session expired.
+func (o *OAuth) ResumeSession(w http.ResponseWriter, r *http.Request, did string) (*sessioncache.Login, error) {
+	session, err := o.sess.GetSession(r.Context(), did)
+	if err != nil {
+		return nil, ErrNoSession
+	}
+
+	login := &sessioncache.Login{
+		Id:        uuid.NewString(),
+		Did:       did,
+		IP:        ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Created:   time.Now(),
+	}
+	if err := o.sess.AddLogin(r.Context(), login); err != nil {
+		return nil, fmt.Errorf("error recording login: %w", err)
+	}
+
+	return login, o.saveUserSession(w, r, login.Id, did, session.Handle, session.PdsUrl)
+}
+
+var ErrNoSession = errors.New("no session to resume")
+
+func (o *OAuth) saveUserSession(w http.ResponseWriter, r *http.Request, id, did, handle, pds string) error {
+	userSession, err := o.store.Get(r, SessionName)
+	if err != nil {
+		return err
+	}
+
+	userSession.Values[SessionId] = id
+	userSession.Values[SessionDid] = did
+	userSession.Values[SessionHandle] = handle
+	userSession.Values[SessionPds] = pds
+	userSession.Values[SessionAuthenticated] = true
+	delete(userSession.Values, SessionSudo)
+	if err := userSession.Save(r, w); err != nil {
+		return fmt.Errorf("error saving user session: %w", err)
+	}
+	return nil
+}
+
+// SetSudo records that the user of the session just confirmed it is them
+// with a passkey
+func (o *OAuth) SetSudo(w http.ResponseWriter, r *http.Request) error {
+	userSession, err := o.store.Get(r, SessionName)
+	if err != nil || userSession.IsNew {
+		return fmt.Errorf("error getting user session (or new session?): %w", err)
+	}
+
+	userSession.Values[SessionSudo] = time.Now().Unix()
+	return userSession.Save(r, w)
+}
+
+// Sudo reports whether the user of the session confirmed it is them recently
+// enough to go ahead with sensitive actions
+func (o *OAuth) Sudo(r *http.Request) bool {
+	userSession, err := o.store.Get(r, SessionName)
+	if err != nil || userSession.IsNew {
+		return false
+	}
+
+	at, ok := userSession.Values[SessionSudo].(int64)
+	return ok && time.Since(time.Unix(at, 0)) < sudoTTL
+}
+
+// SetConsent binds the nonce of a consent screen to the session, so that
+// only the screen shown to the user can grant an app access
+func (o *OAuth) SetConsent(w http.ResponseWriter, r *http.Request, nonce string) error {
+	userSession, err := o.store.Get(r, SessionName)
+	if err != nil || userSession.IsNew {
+		return fmt.Errorf("error getting user session (or new session?): %w", err)
+	}
+
+	userSession.Values[SessionConsent] = nonce
+	return userSession.Save(r, w)
+}
+
+// TakeConsent returns the nonce of the consent screen shown last and
+// forgets it, each screen is only answered once
+func (o *OAuth) TakeConsent(w http.ResponseWriter, r *http.Request) string {
+	userSession, err := o.store.Get(r, SessionName)
+	if err != nil || userSession.IsNew {
+		return ""
+	}
+
+	nonce, _ := userSession.Values[SessionConsent].(string)
+	delete(userSession.Values, SessionConsent)
+	userSession.Save(r, w)
+	return nonce
+}
+
+// SetWaitlist remembers an account that signed in without being let in, so
+// that it can join the waitlist as itself
+func (o *OAuth) SetWaitlist(w http.ResponseWriter, r *http.Request, did string) error {
+	session, _ := o.store.Get(r, WaitlistSessionName)
+	session.Options.MaxAge = int(waitlistTTL.Seconds())
+	session.Options.HttpOnly = true
+	session.Values[SessionDid] = did
+	return session.Save(r, w)
+}
+
+// WaitlistDid is the account remembered by SetWaitlist, if any
+func (o *OAuth) WaitlistDid(r *http.Request) string {
+	session, err := o.store.Get(r, WaitlistSessionName)
+	if err != nil || session.IsNew {
+		return ""
+	}
+	did, _ := session.Values[SessionDid].(string)
+	return did
+}
+
+// SetChallenge keeps the challenge of a passkey ceremony in a short-lived
+// cookie, until the browser answers it
+func (o *OAuth) SetChallenge(w http.ResponseWriter, r *http.Request, challenge string) error {
+	session, _ := o.store.Get(r, ChallengeSessionName)
+	session.Options.MaxAge = int(challengeTTL.Seconds())
+	session.Options.HttpOnly = true
+	session.Values[SessionChallenge] = challenge
+	return session.Save(r, w)
+}
+
+// TakeChallenge returns the challenge set last and forgets it, a challenge
+// is only ever answered once
+func (o *OAuth) TakeChallenge(w http.ResponseWriter, r *http.Request) string {
+	session, err := o.store.Get(r, ChallengeSessionName)
+	if err != nil || session.IsNew {
+		return ""
+	}
+
+	challenge, _ := session.Values[SessionChallenge].(string)
+	session.Options.MaxAge = -1
+	session.Save(r, w)
+	return challenge
+}
+
+// ClientIP is the address of the client, as forwarded by the proxy in front
+// of the appview
+func ClientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		ip, _, _ := strings.Cut(fwd, ",")
+		return strings.TrimSpace(ip)
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// SessionId returns the id of the login of the current session, sessions
+// started before logins were recorded have none
+func (o *OAuth) SessionId(r *http.Request) string {
+	userSession, err := o.store.Get(r, SessionName)
+	if err != nil || userSession.IsNew {
+		return ""
+	}
+	id, _ := userSession.Values[SessionId].(string)
+	return id
+}
+
+// revoked reports whether the login of the current session was revoked from
+// another device
+func (o *OAuth) revoked(r *http.Request, did string) bool {
+	id := o.SessionId(r)
+	if id == "" {
+		return false
+	}
+	ok, err := o.sess.HasLogin(r.Context(), did, id)
+	return err == nil && !ok
+}
+
+// Logins lists where did is signed in
+func (o *OAuth) Logins(r *http.Request, did string) ([]sessioncache.Login, error) {
+	return o.sess.GetLogins(r.Context(), did)
+}
+
+// RevokeLogin signs did out of the session with the login id
+func (o *OAuth) RevokeLogin(r *http.Request, did, id string) error {
+	return o.sess.DeleteLogin(r.Context(), did, id)
+}
+
+// ClearLoginAlert dismisses the notice about a new login of did
+func (o *OAuth) ClearLoginAlert(r *http.Request, did string) error {
+	return o.sess.ClearLoginAlert(r.Context(), did)
+}
+
+// SetLoginAlert flags login to the other sessions of its user
+func (o *OAuth) SetLoginAlert(r *http.Request, login *sessioncache.Login) error {
+	return o.sess.SetLoginAlert(r.Context(), login.Did, login.Id)
 }
 
 func (o *OAuth) ClearSession(r *http.Request, w http.ResponseWriter) error {
@@ -80,6 +281,12 @@ func (o *OAuth) ClearSession(r *http.Request, w http.ResponseWriter) error {
 		return fmt.Errorf("error deleting oauth session: %w", err)
 	}
 
+	if id, ok := userSession.Values[SessionId].(string); ok {
+		if err := o.sess.DeleteLogin(r.Context(), did, id); err != nil {
+			return fmt.Errorf("error deleting login: %w", err)
+		}
+	}
+
 	userSession.Options.MaxAge = -1
 
 	return userSession.Save(r, w)
@@ -94,6 +301,10 @@ func (o *OAuth) GetSession(r *http.Request) (*sessioncache.OAuthSession, bool, e
 	did := userSession.Values[SessionDid].(string)
 	auth := userSession.Values[SessionAuthenticated].(bool)
 
+	if o.revoked(r, did) {
+		return nil, false, fmt.Errorf("session was revoked")
+	}
+
 	session, err := o.sess.GetSession(r.Context(), did)
 	if err != nil {
 		return nil, false, fmt.Errorf("error getting oauth session: %w", err)
@@ -146,6 +357,9 @@ type User struct {
 	Handle string
 	Did    string
 	Pds    string
+	// someone signed in as this user from a new device since they last
+	// looked at their sessions
+	LoginAlert bool
 }
 
 func (a *OAuth) GetUser(r *http.Request) *User {
@@ -155,11 +369,36 @@ func (a *OAuth) GetUser(r *http.Request) *User {
 		return nil
 	}
 
-	return &User{
+	did := clientSession.Values[SessionDid].(string)
+	if a.revoked(r, did) {
+		return nil
+	}
+
+	user := &User{
 		Handle: clientSession.Values[SessionHandle].(string),
-		Did:    clientSession.Values[SessionDid].(string),
+		Did:    did,
 		Pds:    clientSession.Values[SessionPds].(string),
 	}
+
+	// a login from a new device is pointed out everywhere else did is
+	// signed in
+	if id, err := a.sess.GetLoginAlert(r.Context(), did); err == nil && id != "" {
+		user.LoginAlert = id != a.SessionId(r)
+	}
+
+	return user
+}
+
+// UpdateHandle replaces the handle kept in the session of the logged in
+// user, after they changed it
+func (a *OAuth) UpdateHandle(w http.ResponseWriter, r *http.Request, handle string) error {
+	clientSession, err := a.store.Get(r, SessionName)
+	if err != nil || clientSession.IsNew {
+		return fmt.Errorf("error getting user session (or new session?): %w", err)
+	}
+
+	clientSession.Values[SessionHandle] = handle
+	return clientSession.Save(r, w)
 }
 
 func (a *OAuth) GetDid(r *http.Request) string {
@@ -281,7 +520,7 @@ func (o *OAuth) ServiceClient(r *http.Request, os ...ServiceClientOpt) (*indigo_
 		return nil, err
 	}
 
-	return &indigo_xrpc.Client{
+	client := &indigo_xrpc.Client{
 		Auth: &indigo_xrpc.AuthInfo{
 			AccessJwt: resp.Token,
 		},
@@ -289,7 +528,14 @@ func (o *OAuth) ServiceClient(r *http.Request, os ...ServiceClientOpt) (*indigo_
 		Client: &http.Client{
 			Timeout: time.Second * 5,
 		},
-	}, nil
+	}
+
+	// lets the knot log the request under the same id
+	if id := tlog.RequestId(r.Context()); id != "" {
+		client.Headers = map[string]string{tlog.RequestIdHeader: id}
+	}
+
+	return client, nil
 }
 
 type ClientMetadata struct {
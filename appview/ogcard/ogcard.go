@@ -0,0 +1,256 @@
+// Package ogcard renders the social preview images that are linked from the
+// og:image meta tags of repo, issue and pull pages.
+package ogcard
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"tangled.sh/tangled.sh/core/types"
+)
+
+const (
+	Width  = 1200
+	Height = 630
+
+	padding   = 80
+	langBarHt = 16
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	foreground = color.RGBA{0x11, 0x18, 0x27, 0xff} // gray-900
+	muted      = color.RGBA{0x6b, 0x72, 0x80, 0xff} // gray-500
+	track      = color.RGBA{0xe5, 0xe7, 0xeb, 0xff} // gray-200
+)
+
+type Stat struct {
+	Label string
+	Value int
+}
+
+type Card struct {
+	// small line above the title, such as the repo an issue belongs to
+	Eyebrow     string
+	Title       string
+	Description string
+	Stats       []Stat
+	// drawn as a proportional bar along the bottom edge
+	Languages []types.RepoLanguageDetails
+}
+
+type faces struct {
+	eyebrow     font.Face
+	title       font.Face
+	description font.Face
+	stat        font.Face
+	footer      font.Face
+}
+
+var (
+	loadFaces = sync.OnceValues(func() (*faces, error) {
+		regular, err := opentype.Parse(goregular.TTF)
+		if err != nil {
+			return nil, err
+		}
+		bold, err := opentype.Parse(gobold.TTF)
+		if err != nil {
+			return nil, err
+		}
+
+		face := func(f *opentype.Font, size float64) (font.Face, error) {
+			return opentype.NewFace(f, &opentype.FaceOptions{
+				Size:    size,
+				DPI:     72,
+				Hinting: font.HintingFull,
+			})
+		}
+
+		var fs faces
+		if fs.eyebrow, err = face(regular, 36); err != nil {
+			return nil, err
+		}
+		if fs.title, err = face(bold, 64); err != nil {
+			return nil, err
+		}
+		if fs.description, err = face(regular, 32); err != nil {
+			return nil, err
+		}
+		if fs.stat, err = face(regular, 30); err != nil {
+			return nil, err
+		}
+		if fs.footer, err = face(bold, 30); err != nil {
+			return nil, err
+		}
+		return &fs, nil
+	})
+)
+
+// Render draws the card and encodes it as a PNG
+func (c Card) Render(w io.Writer) error {
+	fs, err := loadFaces()
+	if err != nil {
+		return fmt.Errorf("loading fonts: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	maxWidth := fixed.I(Width - 2*padding)
+	y := padding
+
+	if c.Eyebrow != "" {
+		y += fs.eyebrow.Metrics().Ascent.Ceil()
+		drawText(img, fs.eyebrow, muted, padding, y, truncate(fs.eyebrow, c.Eyebrow, maxWidth))
+		y += 24
+	}
+
+	lineHeight := fs.title.Metrics().Height.Ceil()
+	for _, line := range wrap(fs.title, c.Title, maxWidth, 3) {
+		y += lineHeight
+		drawText(img, fs.title, foreground, padding, y, line)
+	}
+	y += 24
+
+	lineHeight = fs.description.Metrics().Height.Ceil() + 8
+	for _, line := range wrap(fs.description, c.Description, maxWidth, 3) {
+		y += lineHeight
+		drawText(img, fs.description, muted, padding, y, line)
+	}
+
+	// stats and wordmark share the baseline above the language bar
+	baseline := Height - langBarHt - padding/2 - 8
+	x := padding
+	for _, s := range c.Stats {
+		text := fmt.Sprintf("%s %s", strconv.Itoa(s.Value), s.Label)
+		drawText(img, fs.stat, foreground, x, baseline, text)
+		x += font.MeasureString(fs.stat, text).Ceil() + 48
+	}
+
+	wordmark := "tangled"
+	drawText(img, fs.footer, foreground, Width-padding-font.MeasureString(fs.footer, wordmark).Ceil(), baseline, wordmark)
+
+	drawLanguageBar(img, c.Languages)
+
+	return png.Encode(w, img)
+}
+
+// Serve renders the card as the response, previews are cheap to regenerate
+// but get fetched by every unfurler, so let them be cached for a while
+func (c Card) Serve(w http.ResponseWriter) {
+	var buf bytes.Buffer
+	if err := c.Render(&buf); err != nil {
+		log.Println("failed to render opengraph card", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write(buf.Bytes())
+}
+
+func drawText(img draw.Image, face font.Face, col color.Color, x, y int, text string) {
+	d := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+func drawLanguageBar(img draw.Image, langs []types.RepoLanguageDetails) {
+	bar := image.Rect(0, Height-langBarHt, Width, Height)
+	draw.Draw(img, bar, image.NewUniform(track), image.Point{}, draw.Src)
+
+	x := 0
+	for i, l := range langs {
+		w := int(float32(Width) * l.Percentage / 100)
+		// let the last segment absorb rounding errors
+		if i == len(langs)-1 {
+			w = Width - x
+		}
+		if w <= 0 {
+			continue
+		}
+
+		segment := image.Rect(x, Height-langBarHt, x+w, Height)
+		draw.Draw(img, segment, image.NewUniform(parseHex(l.Color)), image.Point{}, draw.Src)
+		x += w
+	}
+}
+
+// wrap greedily breaks text into at most maxLines lines that fit in width,
+// ellipsizing the last line if the text does not fit
+func wrap(face font.Face, text string, width fixed.Int26_6, maxLines int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var lines []string
+	current := words[0]
+	for _, word := range words[1:] {
+		candidate := current + " " + word
+		if font.MeasureString(face, candidate) <= width {
+			current = candidate
+			continue
+		}
+
+		lines = append(lines, current)
+		current = word
+		if len(lines) == maxLines {
+			break
+		}
+	}
+
+	if len(lines) < maxLines {
+		lines = append(lines, current)
+		return lines
+	}
+
+	lines[maxLines-1] = truncate(face, lines[maxLines-1]+" "+current, width)
+	return lines
+}
+
+func truncate(face font.Face, text string, width fixed.Int26_6) string {
+	if font.MeasureString(face, text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "…"
+		if font.MeasureString(face, candidate) <= width {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+func parseHex(s string) color.Color {
+	var c color.RGBA
+	c.A = 0xff
+	if _, err := fmt.Sscanf(strings.TrimPrefix(s, "#"), "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return muted
+	}
+	return c
+}
@@ -28,6 +28,12 @@ func (c *TmplCache[K, V]) Set(key K, value V) {
 	c.data[key] = value
 }
 
+func (c *TmplCache[K, V]) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.data = make(map[K]V)
+}
+
 func (c *TmplCache[K, V]) Size() int {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
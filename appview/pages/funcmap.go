@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"html"
 	"html/template"
+	"io/fs"
 	"log"
 	"math"
 	"net/url"
@@ -19,6 +20,8 @@ import (
 
 	"github.com/dustin/go-humanize"
 	"github.com/go-enry/go-enry/v2"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
 	"tangled.sh/tangled.sh/core/appview/filetree"
 	"tangled.sh/tangled.sh/core/appview/pages/markup"
 	"tangled.sh/tangled.sh/core/crypto"
@@ -42,6 +45,14 @@ func (p *Pages) funcMap() template.FuncMap {
 
 			return "@" + identity.Handle.String()
 		},
+		// botName is the name of the bot behind an author, empty for a user
+		"botName": func(ident string) string {
+			name, _ := strings.CutPrefix(ident, db.BotPrefix)
+			if name == ident {
+				return ""
+			}
+			return name
+		},
 		"truncateAt30": func(s string) string {
 			if len(s) <= 30 {
 				return s
@@ -183,6 +194,10 @@ func (p *Pages) funcMap() template.FuncMap {
 		"nl2br": func(text string) template.HTML {
 			return template.HTML(strings.ReplaceAll(template.HTMLEscapeString(text), "\n", "<br>"))
 		},
+		// long bodies are collapsed behind a "show more" toggle
+		"isLongBody": func(text string) bool {
+			return len(text) > 3000 || strings.Count(text, "\n") > 40
+		},
 		"unwrapText": func(text string) string {
 			paragraphs := strings.Split(text, "\n\n")
 
@@ -219,6 +234,9 @@ func (p *Pages) funcMap() template.FuncMap {
 			sanitized := p.rctx.SanitizeDescription(htmlString)
 			return template.HTML(sanitized)
 		},
+		"taskProgress": func(text string) markup.TaskProgress {
+			return markup.Tasks(text)
+		},
 		"isNil": func(t any) bool {
 			// returns false for other "zero" values
 			return t == nil
@@ -248,15 +266,21 @@ func (p *Pages) funcMap() template.FuncMap {
 			return nil
 		},
 		"i": func(name string, classes ...string) template.HTML {
-			data, err := icon(name, classes)
+			data, err := icon(p.embedFS, name, classes)
 			if err != nil {
 				log.Printf("icon %s does not exist", name)
-				data, _ = icon("airplay", classes)
+				data, _ = icon(p.embedFS, "airplay", classes)
 			}
 			return template.HTML(data)
 		},
-		"cssContentHash": CssContentHash,
-		"fileTree":       filetree.FileTree,
+		"cssContentHash": func() string {
+			return CssContentHash(p.embedFS)
+		},
+		// instance is the branding of the appview, see config.InstanceConfig
+		"instance": func() config.InstanceConfig {
+			return p.instance
+		},
+		"fileTree": filetree.FileTree,
 		"pathEscape": func(s string) string {
 			return url.PathEscape(s)
 		},
@@ -290,6 +314,17 @@ func (p *Pages) funcMap() template.FuncMap {
 			}
 			return fp
 		},
+		"sshKeyInfo": func(pubKey string) crypto.SSHKey {
+			info, _ := crypto.ParseSSHKey(pubKey)
+			return info
+		},
+		"gpgFingerprint": func(pubKey string) string {
+			fp, err := crypto.PGPFingerprint(pubKey)
+			if err != nil {
+				return "error"
+			}
+			return fp
+		},
 	}
 }
 
@@ -308,14 +343,14 @@ func (p *Pages) avatarUri(handle, size string) string {
 	return fmt.Sprintf("%s/%s/%s?%s", p.avatar.Host, signature, handle, sizeArg)
 }
 
-func icon(name string, classes []string) (template.HTML, error) {
+func icon(fsys fs.FS, name string, classes []string) (template.HTML, error) {
 	iconPath := filepath.Join("static", "icons", name)
 
 	if filepath.Ext(name) == "" {
 		iconPath += ".svg"
 	}
 
-	data, err := Files.ReadFile(iconPath)
+	data, err := fs.ReadFile(fsys, iconPath)
 	if err != nil {
 		return "", fmt.Errorf("icon %s not found: %w", name, err)
 	}
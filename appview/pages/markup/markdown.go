@@ -17,6 +17,7 @@ import (
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
@@ -65,7 +66,11 @@ func (rctx *RenderContext) RenderMarkdown(source string) string {
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
 		),
-		goldmark.WithRendererOptions(html.WithUnsafe()),
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+			// lower priorities win, this replaces the checkbox renderer of GFM
+			renderer.WithNodeRenderers(util.Prioritized(&taskCheckBoxRenderer{}, 100)),
+		),
 	)
 
 	if rctx != nil {
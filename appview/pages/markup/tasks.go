@@ -0,0 +1,104 @@
+package markup
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// TaskProgress is the number of done and total items of the task lists in a
+// markdown document
+type TaskProgress struct {
+	Done  int
+	Total int
+}
+
+// Tasks counts the items of the task lists in source
+func Tasks(source string) TaskProgress {
+	var p TaskProgress
+	for _, checked := range taskPositions([]byte(source)) {
+		p.Total += 1
+		if checked {
+			p.Done += 1
+		}
+	}
+	return p
+}
+
+// ToggleTask checks or unchecks the task whose checkbox starts at byte offset
+// pos of source, as given by the data-source-position attribute of rendered
+// checkboxes.
+func ToggleTask(source string, pos int, checked bool) (string, error) {
+	if _, ok := taskPositions([]byte(source))[pos]; !ok {
+		return "", fmt.Errorf("no task at position %d", pos)
+	}
+
+	mark := " "
+	if checked {
+		mark = "x"
+	}
+
+	return source[:pos+1] + mark + source[pos+2:], nil
+}
+
+// taskPositions maps the offset of every task checkbox in source to whether
+// it is checked
+func taskPositions(source []byte) map[int]bool {
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	doc := md.Parser().Parse(text.NewReader(source))
+
+	positions := make(map[int]bool)
+	_ = gast.Walk(doc, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if cb, ok := n.(*east.TaskCheckBox); ok && entering {
+			if pos, ok := taskPosition(cb); ok {
+				positions[pos] = cb.IsChecked
+			}
+		}
+		return gast.WalkContinue, nil
+	})
+
+	return positions
+}
+
+// taskPosition is the offset of the "[ ]" of a checkbox, which is where the
+// text block it was parsed from starts
+func taskPosition(cb *east.TaskCheckBox) (int, bool) {
+	parent := cb.Parent()
+	if parent == nil || parent.Lines().Len() == 0 {
+		return 0, false
+	}
+	return parent.Lines().At(0).Start, true
+}
+
+// taskCheckBoxRenderer renders task checkboxes like goldmark does, along with
+// their position in the source so that they can be toggled
+type taskCheckBoxRenderer struct{}
+
+func (r *taskCheckBoxRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(east.KindTaskCheckBox, r.renderTaskCheckBox)
+}
+
+func (r *taskCheckBoxRenderer) renderTaskCheckBox(w util.BufWriter, source []byte, node gast.Node, entering bool) (gast.WalkStatus, error) {
+	if !entering {
+		return gast.WalkContinue, nil
+	}
+	n := node.(*east.TaskCheckBox)
+
+	_, _ = w.WriteString(`<input disabled="" type="checkbox"`)
+	if n.IsChecked {
+		_, _ = w.WriteString(` checked=""`)
+	}
+	if pos, ok := taskPosition(n); ok {
+		_, _ = w.WriteString(` data-source-position="` + strconv.Itoa(pos) + `"`)
+	}
+	_, _ = w.WriteString("> ")
+
+	return gast.WalkContinue, nil
+}
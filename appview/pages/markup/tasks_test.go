@@ -0,0 +1,55 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToggleTask(t *testing.T) {
+	source := "some text\n\n- [ ] first\n- [x] second\n  - [ ] nested\n\n```\n- [ ] not a task\n```\n"
+
+	if got := Tasks(source); got.Done != 1 || got.Total != 3 {
+		t.Fatalf("Tasks: got %+v, want 1 of 3", got)
+	}
+
+	first := strings.Index(source, "[ ] first")
+	toggled, err := ToggleTask(source, first, true)
+	if err != nil {
+		t.Fatalf("ToggleTask: %v", err)
+	}
+	if !strings.Contains(toggled, "- [x] first") {
+		t.Errorf("first task was not checked: %q", toggled)
+	}
+	if got := Tasks(toggled); got.Done != 2 || got.Total != 3 {
+		t.Errorf("Tasks after toggle: got %+v, want 2 of 3", got)
+	}
+
+	second := strings.Index(source, "[x] second")
+	toggled, err = ToggleTask(source, second, false)
+	if err != nil {
+		t.Fatalf("ToggleTask: %v", err)
+	}
+	if !strings.Contains(toggled, "- [ ] second") {
+		t.Errorf("second task was not unchecked: %q", toggled)
+	}
+
+	if _, err := ToggleTask(source, strings.Index(source, "[ ] not a task"), true); err == nil {
+		t.Error("expected an error for a checkbox in a code block")
+	}
+	if _, err := ToggleTask(source, 0, true); err == nil {
+		t.Error("expected an error for a position that is not a task")
+	}
+}
+
+func TestRenderTaskPositions(t *testing.T) {
+	source := "- [ ] first\n- [x] second\n"
+
+	rctx := &RenderContext{RendererType: RendererTypeDefault}
+	out := rctx.RenderMarkdown(source)
+
+	for _, want := range []string{`data-source-position="2"`, `data-source-position="14"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered output is missing %s: %s", want, out)
+		}
+	}
+}
@@ -11,12 +11,15 @@ import (
 	"io/fs"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"tangled.sh/tangled.sh/core/api/tangled"
+	sessioncache "tangled.sh/tangled.sh/core/appview/cache/session"
 	"tangled.sh/tangled.sh/core/appview/commitverify"
 	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/db"
@@ -24,8 +27,11 @@ import (
 	"tangled.sh/tangled.sh/core/appview/pages/markup"
 	"tangled.sh/tangled.sh/core/appview/pages/repoinfo"
 	"tangled.sh/tangled.sh/core/appview/pagination"
+	"tangled.sh/tangled.sh/core/appview/serververify"
 	"tangled.sh/tangled.sh/core/idresolver"
 	"tangled.sh/tangled.sh/core/patchutil"
+	"tangled.sh/tangled.sh/core/scaffold"
+	"tangled.sh/tangled.sh/core/trailer"
 	"tangled.sh/tangled.sh/core/types"
 
 	"github.com/alecthomas/chroma/v2"
@@ -50,6 +56,8 @@ type Pages struct {
 	dev         bool
 	embedFS     fs.FS
 	templateDir string // Path to templates on disk for dev mode
+	themeDir    string // Path to templates and static files overriding the built-in ones
+	instance    config.InstanceConfig
 	rctx        *markup.RenderContext
 	logger      *slog.Logger
 }
@@ -71,6 +79,8 @@ func NewPages(config *config.Config, res *idresolver.Resolver) *Pages {
 		rctx:        rctx,
 		resolver:    res,
 		templateDir: "appview/pages",
+		themeDir:    config.Core.ThemeDir,
+		instance:    config.Instance,
 		logger:      slog.Default().With("component", "pages"),
 	}
 
@@ -80,6 +90,15 @@ func NewPages(config *config.Config, res *idresolver.Resolver) *Pages {
 		p.embedFS = Files
 	}
 
+	if p.themeDir != "" {
+		p.embedFS = themeFS{theme: os.DirFS(p.themeDir), base: p.embedFS}
+
+		// dev mode never caches templates, there is nothing to reload
+		if !p.dev {
+			go p.watchTheme(p.themeDir, 5*time.Second)
+		}
+	}
+
 	return p
 }
 
@@ -251,14 +270,29 @@ func (p *Pages) Favicon(w io.Writer) error {
 	return p.executePlain("favicon", w, nil)
 }
 
+func (p *Pages) ServiceWorker(w io.Writer) error {
+	return p.executePlain("serviceWorker", w, nil)
+}
+
 type LoginParams struct {
 	ReturnUrl string
+	Policy    string
 }
 
 func (p *Pages) Login(w io.Writer, params LoginParams) error {
 	return p.executePlain("user/login", w, params)
 }
 
+type WaitlistParams struct {
+	Policy string
+	// Handle of the account that signed in to join, if any
+	Handle string
+}
+
+func (p *Pages) Waitlist(w io.Writer, params WaitlistParams) error {
+	return p.executePlain("user/waitlist", w, params)
+}
+
 func (p *Pages) Signup(w io.Writer) error {
 	return p.executePlain("user/signup", w, nil)
 }
@@ -313,12 +347,27 @@ type TimelineParams struct {
 	LoggedInUser *oauth.User
 	Timeline     []db.TimelineEvent
 	Repos        []db.Repo
+	// the introduction of the instance on the front page, when it has one
+	Landing template.HTML
 }
 
 func (p *Pages) Timeline(w io.Writer, params TimelineParams) error {
 	return p.execute("timeline/timeline", w, params)
 }
 
+type SearchParams struct {
+	LoggedInUser *oauth.User
+	Query        string
+	// either repos or issues
+	Type   string
+	Repos  []db.Repo
+	Issues []db.Issue
+}
+
+func (p *Pages) Search(w io.Writer, params SearchParams) error {
+	return p.execute("search/search", w, params)
+}
+
 type UserProfileSettingsParams struct {
 	LoggedInUser *oauth.User
 	Tabs         []map[string]any
@@ -330,10 +379,12 @@ func (p *Pages) UserProfileSettings(w io.Writer, params UserProfileSettingsParam
 }
 
 type UserKeysSettingsParams struct {
-	LoggedInUser *oauth.User
-	PubKeys      []db.PublicKey
-	Tabs         []map[string]any
-	Tab          string
+	LoggedInUser        *oauth.User
+	PubKeys             []db.PublicKey
+	SigningKeys         []db.SigningKey
+	CertificatesEnabled bool
+	Tabs                []map[string]any
+	Tab                 string
 }
 
 func (p *Pages) UserKeysSettings(w io.Writer, params UserKeysSettingsParams) error {
@@ -343,14 +394,172 @@ func (p *Pages) UserKeysSettings(w io.Writer, params UserKeysSettingsParams) err
 type UserEmailsSettingsParams struct {
 	LoggedInUser *oauth.User
 	Emails       []db.Email
-	Tabs         []map[string]any
-	Tab          string
+	Notify       db.NotificationSettings
+	// the account bluesky notifications come from, if they are enabled
+	BlueskyNotify string
+	Error         string
+	Tabs          []map[string]any
+	Tab           string
 }
 
 func (p *Pages) UserEmailsSettings(w io.Writer, params UserEmailsSettingsParams) error {
 	return p.execute("user/settings/emails", w, params)
 }
 
+type UserDomainsSettingsParams struct {
+	LoggedInUser *oauth.User
+	Domains      []db.Domain
+	Repos        []db.Repo
+	Tabs         []map[string]any
+	Tab          string
+}
+
+func (p *Pages) UserDomainsSettings(w io.Writer, params UserDomainsSettingsParams) error {
+	return p.execute("user/settings/domains", w, params)
+}
+
+type UserInvitesSettingsParams struct {
+	LoggedInUser *oauth.User
+	Policy       string
+	IsAdmin      bool
+	CanInvite    bool
+	Invites      []db.Invite
+	Waitlist     []db.WaitlistEntry
+	Tabs         []map[string]any
+	Tab          string
+}
+
+func (p *Pages) UserInvitesSettings(w io.Writer, params UserInvitesSettingsParams) error {
+	return p.execute("user/settings/invites", w, params)
+}
+
+type UserSessionsSettingsParams struct {
+	LoggedInUser *oauth.User
+	Logins       []sessioncache.Login
+	// id of the login of this session
+	Current string
+	Tabs    []map[string]any
+	Tab     string
+}
+
+func (p *Pages) UserSessionsSettings(w io.Writer, params UserSessionsSettingsParams) error {
+	return p.execute("user/settings/sessions", w, params)
+}
+
+type UserAppsSettingsParams struct {
+	LoggedInUser *oauth.User
+	Authorized   []db.AuthorizedApp
+	Apps         []db.App
+	Scopes       []db.AppScope
+	AppviewHost  string
+	Tabs         []map[string]any
+	Tab          string
+}
+
+func (p *Pages) UserAppsSettings(w io.Writer, params UserAppsSettingsParams) error {
+	return p.execute("user/settings/applications", w, params)
+}
+
+type UserPasskeysSettingsParams struct {
+	LoggedInUser *oauth.User
+	Passkeys     []db.Passkey
+	Tabs         []map[string]any
+	Tab          string
+}
+
+func (p *Pages) UserPasskeysSettings(w io.Writer, params UserPasskeysSettingsParams) error {
+	return p.execute("user/settings/passkeys", w, params)
+}
+
+// SudoParams asks the user to confirm it is them with a passkey, before
+// going back to ReturnUrl
+type SudoParams struct {
+	LoggedInUser *oauth.User
+	ReturnUrl    string
+}
+
+func (p *Pages) Sudo(w io.Writer, params SudoParams) error {
+	return p.execute("user/sudo", w, params)
+}
+
+// AuthorizeAppParams is the consent screen of an app, or why it can't be
+// shown
+type AuthorizeAppParams struct {
+	LoggedInUser  *oauth.User
+	Error         string
+	App           *db.App
+	Scopes        []db.AppScope
+	Scope         string
+	RedirectUri   string
+	State         string
+	CodeChallenge string
+	// Nonce ties the answer to this screen
+	Nonce string
+	// the user gave the app access before
+	Authorized bool
+}
+
+func (p *Pages) AuthorizeApp(w io.Writer, params AuthorizeAppParams) error {
+	return p.execute("user/authorizeApp", w, params)
+}
+
+type UserSharingSettingsParams struct {
+	LoggedInUser *oauth.User
+	Settings     db.ShareSettings
+	Tabs         []map[string]any
+	Tab          string
+}
+
+func (p *Pages) UserSharingSettings(w io.Writer, params UserSharingSettingsParams) error {
+	return p.execute("user/settings/sharing", w, params)
+}
+
+type UserTakeoutSettingsParams struct {
+	LoggedInUser *oauth.User
+	Remaining    int
+	Limit        int
+	Tabs         []map[string]any
+	Tab          string
+}
+
+func (p *Pages) UserTakeoutSettings(w io.Writer, params UserTakeoutSettingsParams) error {
+	return p.execute("user/settings/takeout", w, params)
+}
+
+type UserQueriesSettingsParams struct {
+	LoggedInUser *oauth.User
+	Threshold    time.Duration
+	Slow         []db.SlowQuery
+	Stats        []db.QueryStats
+	Tabs         []map[string]any
+	Tab          string
+}
+
+func (p *Pages) UserQueriesSettings(w io.Writer, params UserQueriesSettingsParams) error {
+	return p.execute("user/settings/queries", w, params)
+}
+
+// QuarantinedBlob is a blob the virus scanner has not let through, with the
+// attachments and artifacts linking to it
+type QuarantinedBlob struct {
+	db.BlobScan
+	Attachments []db.Attachment
+	Artifacts   []db.Artifact
+}
+
+type UserQuarantineSettingsParams struct {
+	LoggedInUser *oauth.User
+	Enabled      bool
+	Blobs        []QuarantinedBlob
+	Repos        map[string]*db.Repo
+	Tabs         []map[string]any
+	Tab          string
+}
+
+func (p *Pages) UserQuarantineSettings(w io.Writer, params UserQuarantineSettingsParams) error {
+	return p.execute("user/settings/quarantine", w, params)
+}
+
 type KnotBannerParams struct {
 	Registrations []db.Registration
 }
@@ -380,6 +589,68 @@ func (p *Pages) Knot(w io.Writer, params KnotParams) error {
 	return p.execute("knots/dashboard", w, params)
 }
 
+type KnotRepoUsage struct {
+	Did              string
+	Name             string
+	Size             uint64
+	Clones           int64
+	Pushes           int64
+	LastMaintenance  *time.Time
+	MaintenanceError string
+	Features         []string
+}
+
+type KnotUsageParams struct {
+	Domain              string
+	TotalSize           uint64
+	MaintenanceInterval string
+	Repos               []KnotRepoUsage
+	Error               string
+}
+
+func (p *Pages) KnotUsage(w io.Writer, params KnotUsageParams) error {
+	return p.executePlain("knots/fragments/usage", w, params)
+}
+
+type KnotTrashedRepo struct {
+	Did     string
+	Name    string
+	Size    uint64
+	Deleted time.Time
+	Expires time.Time
+}
+
+type KnotTrashParams struct {
+	Domain    string
+	Retention string
+	Repos     []KnotTrashedRepo
+	Error     string
+}
+
+func (p *Pages) KnotTrash(w io.Writer, params KnotTrashParams) error {
+	return p.executePlain("knots/fragments/trash", w, params)
+}
+
+type KnotAdminRepo struct {
+	Did          string
+	Name         string
+	Size         uint64
+	LastActivity *time.Time
+	Archived     bool
+	TransferTo   string
+}
+
+type KnotReposParams struct {
+	LoggedInUser *oauth.User
+	Domain       string
+	Repos        []KnotAdminRepo
+	Error        string
+}
+
+func (p *Pages) KnotRepos(w io.Writer, params KnotReposParams) error {
+	return p.execute("knots/repos", w, params)
+}
+
 type KnotListingParams struct {
 	*db.Registration
 }
@@ -388,6 +659,25 @@ func (p *Pages) KnotListing(w io.Writer, params KnotListingParams) error {
 	return p.executePlain("knots/fragments/knotListing", w, params)
 }
 
+type KnotSetupParams struct {
+	LoggedInUser *oauth.User
+	Domain       string
+	AppviewHost  string
+}
+
+func (p *Pages) KnotSetup(w io.Writer, params KnotSetupParams) error {
+	return p.execute("knots/setup", w, params)
+}
+
+type KnotProbeParams struct {
+	Domain string
+	Checks []serververify.Check
+}
+
+func (p *Pages) KnotProbe(w io.Writer, params KnotProbeParams) error {
+	return p.executePlain("knots/fragments/probe", w, params)
+}
+
 type SpindlesParams struct {
 	LoggedInUser *oauth.User
 	Spindles     []db.Spindle
@@ -419,6 +709,13 @@ func (p *Pages) SpindleDashboard(w io.Writer, params SpindleDashboardParams) err
 type NewRepoParams struct {
 	LoggedInUser *oauth.User
 	Knots        []string
+	// default branches of knots that have set one
+	DefaultBranches map[string]string
+	// whether "announce on bluesky" starts out checked
+	Announce bool
+	// templates that can be added in an initial commit
+	Gitignores []string
+	Licenses   []string
 }
 
 func (p *Pages) NewRepo(w io.Writer, params NewRepoParams) error {
@@ -435,6 +732,16 @@ func (p *Pages) ForkRepo(w io.Writer, params ForkRepoParams) error {
 	return p.execute("repo/fork", w, params)
 }
 
+type RepoTransferOfferParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Transfer     db.RepoTransfer
+}
+
+func (p *Pages) RepoTransferOffer(w io.Writer, params RepoTransferOfferParams) error {
+	return p.execute("repo/transfer", w, params)
+}
+
 type ProfileCard struct {
 	UserDid      string
 	UserHandle   string
@@ -457,6 +764,7 @@ func (p *ProfileCard) GetTabs() [][]any {
 	tabs := [][]any{
 		{"overview", "overview", "square-chart-gantt", nil},
 		{"repos", "repos", "book-marked", p.Stats.RepoCount},
+		{"issues", "issues", "circle-dot", nil},
 		{"starred", "starred", "star", p.Stats.StarredCount},
 		{"strings", "strings", "line-squiggle", p.Stats.StringCount},
 	}
@@ -483,6 +791,27 @@ type ProfileReposParams struct {
 	Repos        []db.Repo
 	Card         *ProfileCard
 	Active       string
+	Options      db.RepoListOptions
+	// languages to filter by
+	Languages []string
+	Page      pagination.Page
+}
+
+// PageQuery is the query string of the given page of repos, with the current
+// sorting and filters
+func (p ProfileReposParams) PageQuery(page pagination.Page) template.URL {
+	v := url.Values{}
+	v.Set("tab", "repos")
+	v.Set("sort", string(p.Options.Sort))
+	if p.Options.Kind != "" {
+		v.Set("type", string(p.Options.Kind))
+	}
+	if p.Options.Language != "" {
+		v.Set("language", p.Options.Language)
+	}
+	v.Set("offset", fmt.Sprint(page.Offset))
+	v.Set("limit", fmt.Sprint(page.Limit))
+	return template.URL(v.Encode())
 }
 
 func (p *Pages) ProfileRepos(w io.Writer, params ProfileReposParams) error {
@@ -502,6 +831,38 @@ func (p *Pages) ProfileStarred(w io.Writer, params ProfileStarredParams) error {
 	return p.executeProfile("user/starred", w, params)
 }
 
+// TriageFilter narrows down the issues of a triage view
+type TriageFilter struct {
+	Open  bool
+	Repo  string
+	Label string
+}
+
+type ProfileIssuesParams struct {
+	LoggedInUser *oauth.User
+	Issues       []db.Issue
+	Filter       TriageFilter
+	Card         *ProfileCard
+	Active       string
+}
+
+func (p *Pages) ProfileIssues(w io.Writer, params ProfileIssuesParams) error {
+	params.Active = "issues"
+	return p.executeProfile("user/issues", w, params)
+}
+
+type TriageParams struct {
+	LoggedInUser *oauth.User
+	Issues       []db.Issue
+	Repos        []db.Repo
+	OpenCounts   map[syntax.ATURI]int
+	Filter       TriageFilter
+}
+
+func (p *Pages) Triage(w io.Writer, params TriageParams) error {
+	return p.execute("user/triage", w, params)
+}
+
 type ProfileStringsParams struct {
 	LoggedInUser *oauth.User
 	Strings      []db.String
@@ -616,12 +977,25 @@ type RepoIndexParams struct {
 	VerifiedCommits    commitverify.VerifiedCommits
 	Languages          []types.RepoLanguageDetails
 	Pipelines          map[string]db.Pipeline
+	Summary            db.RepoSummary
+	// a branch the user pushed lately, offered for a pull request
+	RecentPush *db.RecentPush
+	// templates offered to start an empty repo with
+	Gitignores []string
+	Licenses   []string
 	types.RepoIndexResponse
 }
 
+// TopLanguages are the languages shown in the about sidebar
+func (p RepoIndexParams) TopLanguages() []types.RepoLanguageDetails {
+	return p.Languages[:min(3, len(p.Languages))]
+}
+
 func (p *Pages) RepoIndexPage(w io.Writer, params RepoIndexParams) error {
 	params.Active = "overview"
 	if params.IsEmpty {
+		params.Gitignores = scaffold.Gitignores()
+		params.Licenses = scaffold.Licenses()
 		return p.executeRepo("repo/empty", w, params)
 	}
 
@@ -668,6 +1042,8 @@ type RepoCommitParams struct {
 	EmailToDidOrHandle map[string]string
 	Pipeline           *db.Pipeline
 	DiffOpts           types.DiffOpts
+	Trailers           []trailer.Trailer
+	Branches           []types.Branch
 
 	// singular because it's always going to be just one
 	VerifiedCommit commitverify.VerifiedCommits
@@ -675,6 +1051,11 @@ type RepoCommitParams struct {
 	types.RepoCommitResponse
 }
 
+// People lists who the commit names in trailers of the given key
+func (p RepoCommitParams) People(key string) []trailer.Trailer {
+	return trailer.Filter(p.Trailers, key)
+}
+
 func (p *Pages) RepoCommit(w io.Writer, params RepoCommitParams) error {
 	params.Active = "overview"
 	return p.executeRepo("repo/commit", w, params)
@@ -727,6 +1108,18 @@ func (p *Pages) RepoBranches(w io.Writer, params RepoBranchesParams) error {
 	return p.executeRepo("repo/branches", w, params)
 }
 
+type RepoDependenciesParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	types.RepoDependenciesResponse
+}
+
+func (p *Pages) RepoDependencies(w io.Writer, params RepoDependenciesParams) error {
+	params.Active = "dependencies"
+	return p.executeRepo("repo/dependencies", w, params)
+}
+
 type RepoTagsParams struct {
 	LoggedInUser *oauth.User
 	RepoInfo     repoinfo.RepoInfo
@@ -734,6 +1127,9 @@ type RepoTagsParams struct {
 	types.RepoTagsResponse
 	ArtifactMap       map[plumbing.Hash][]db.Artifact
 	DanglingArtifacts []db.Artifact
+	// whether "announce on bluesky" starts out checked when uploading the
+	// first artifact of a tag
+	Announce bool
 }
 
 func (p *Pages) RepoTags(w io.Writer, params RepoTagsParams) error {
@@ -741,6 +1137,18 @@ func (p *Pages) RepoTags(w io.Writer, params RepoTagsParams) error {
 	return p.executeRepo("repo/tags", w, params)
 }
 
+type RepoForksParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Forks        []db.Repo
+}
+
+func (p *Pages) RepoForks(w io.Writer, params RepoForksParams) error {
+	params.Active = "overview"
+	return p.executeRepo("repo/forks", w, params)
+}
+
 type RepoArtifactParams struct {
 	LoggedInUser *oauth.User
 	RepoInfo     repoinfo.RepoInfo
@@ -842,6 +1250,10 @@ type RepoGeneralSettingsParams struct {
 	Tabs         []map[string]any
 	Tab          string
 	Branches     []types.Branch
+	Bridge       *db.GithubBridge
+	Transfer     *db.RepoTransfer
+	Topics       []string
+	Website      string
 }
 
 func (p *Pages) RepoGeneralSettings(w io.Writer, params RepoGeneralSettingsParams) error {
@@ -863,6 +1275,115 @@ func (p *Pages) RepoAccessSettings(w io.Writer, params RepoAccessSettingsParams)
 	return p.executeRepo("repo/settings/access", w, params)
 }
 
+type RepoSiteSettingsParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Tabs         []map[string]any
+	Tab          string
+	Branches     []types.Branch
+	Site         *db.Site
+}
+
+func (p *Pages) RepoSiteSettings(w io.Writer, params RepoSiteSettingsParams) error {
+	params.Active = "settings"
+	return p.executeRepo("repo/settings/sites", w, params)
+}
+
+type RepoIntegrationSettingsParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Tabs         []map[string]any
+	Tab          string
+	Integrations []db.Integration
+	Events       []string
+	Defaults     map[string]string
+}
+
+func (p *Pages) RepoIntegrationSettings(w io.Writer, params RepoIntegrationSettingsParams) error {
+	params.Active = "settings"
+	return p.executeRepo("repo/settings/integrations", w, params)
+}
+
+type RepoBotSettingsParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Tabs         []map[string]any
+	Tab          string
+	Bots         []db.Bot
+	Scopes       []db.BotScope
+	ApiUrl       string
+}
+
+func (p *Pages) RepoBotSettings(w io.Writer, params RepoBotSettingsParams) error {
+	params.Active = "settings"
+	return p.executeRepo("repo/settings/bots", w, params)
+}
+
+type RepoMergeSettingsParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Tabs         []map[string]any
+	Tab          string
+	Settings     db.MergeSettings
+	Strategies   []db.MergeStrategy
+}
+
+func (p *Pages) RepoMergeSettings(w io.Writer, params RepoMergeSettingsParams) error {
+	params.Active = "settings"
+	return p.executeRepo("repo/settings/merging", w, params)
+}
+
+type RepoRuleSettingsParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Tabs         []map[string]any
+	Tab          string
+	Rules        []db.Rule
+	Events       []db.RuleEvent
+	Actions      []db.RuleAction
+	FilePath     string
+	// nil if stale triage is off
+	Stale *db.StaleSettings
+}
+
+func (p *Pages) RepoRuleSettings(w io.Writer, params RepoRuleSettingsParams) error {
+	params.Active = "settings"
+	return p.executeRepo("repo/settings/rules", w, params)
+}
+
+type RepoInsightsSettingsParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Tabs         []map[string]any
+	Tab          string
+	// whether the instance counts traffic at all
+	Enabled bool
+	// one per day, oldest first
+	Days  []db.RepoTraffic
+	Total db.RepoTraffic
+	// the highest daily count, which the chart is scaled to
+	Most int
+}
+
+// Height is the height of a bar for n in the chart, in percent
+func (p RepoInsightsSettingsParams) Height(n int) int {
+	if p.Most == 0 {
+		return 0
+	}
+	return n * 100 / p.Most
+}
+
+func (p *Pages) RepoInsightsSettings(w io.Writer, params RepoInsightsSettingsParams) error {
+	params.Active = "settings"
+	return p.executeRepo("repo/settings/insights", w, params)
+}
+
 type RepoPipelineSettingsParams struct {
 	LoggedInUser   *oauth.User
 	RepoInfo       repoinfo.RepoInfo
@@ -899,7 +1420,13 @@ type RepoSingleIssueParams struct {
 	Active           string
 	Issue            *db.Issue
 	Comments         []db.Comment
+	Thread           []db.ThreadItem
 	IssueOwnerHandle string
+	// previous versions of the issue, latest first
+	Edits []db.IssueEdit
+	// attribution of imported issues and comments, keyed by comment id
+	// (0 for the issue itself)
+	Imports map[int]*db.IssueImport
 
 	OrderedReactionKinds []db.ReactionKind
 	Reactions            map[db.ReactionKind]int
@@ -940,6 +1467,56 @@ func (p *Pages) RepoNewIssue(w io.Writer, params RepoNewIssueParams) error {
 	return p.executeRepo("repo/issues/new", w, params)
 }
 
+// MarkdownPreview renders markdown the same way as comments and issue bodies
+func (p *Pages) MarkdownPreview(w io.Writer, body string) error {
+	return p.executePlain("fragments/markdownPreview", w, body)
+}
+
+type EditIssueParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Issue        *db.Issue
+}
+
+func (p *Pages) EditIssueFragment(w io.Writer, params EditIssueParams) error {
+	return p.executePlain("repo/issues/fragments/editIssue", w, params)
+}
+
+type SimilarIssuesParams struct {
+	RepoInfo repoinfo.RepoInfo
+	Issues   []db.Issue
+}
+
+func (p *Pages) SimilarIssuesFragment(w io.Writer, params SimilarIssuesParams) error {
+	return p.executePlain("repo/issues/fragments/similarIssues", w, params)
+}
+
+type RepoHiddenIssuesParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Issues       []db.Issue
+	Comments     []db.Comment
+}
+
+func (p *Pages) RepoHiddenIssues(w io.Writer, params RepoHiddenIssuesParams) error {
+	params.Active = "issues"
+	return p.executeRepo("repo/issues/hidden", w, params)
+}
+
+type RepoIssueHistoryParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Active       string
+	Issue        *db.Issue
+	Edits        []db.IssueEdit
+}
+
+func (p *Pages) RepoIssueHistory(w io.Writer, params RepoIssueHistoryParams) error {
+	params.Active = "issues"
+	return p.executeRepo("repo/issues/history", w, params)
+}
+
 type EditIssueCommentParams struct {
 	LoggedInUser *oauth.User
 	RepoInfo     repoinfo.RepoInfo
@@ -956,6 +1533,7 @@ type SingleIssueCommentParams struct {
 	RepoInfo     repoinfo.RepoInfo
 	Issue        *db.Issue
 	Comment      *db.Comment
+	Import       *db.IssueImport
 }
 
 func (p *Pages) SingleIssueCommentFragment(w io.Writer, params SingleIssueCommentParams) error {
@@ -1022,6 +1600,16 @@ type RepoSinglePullParams struct {
 	MergeCheck     types.MergeCheckResponse
 	ResubmitCheck  ResubmitResult
 	Pipelines      map[string]db.Pipeline
+	CommitStatuses map[string][]db.CommitStatus
+	// sign-offs of each round, keyed by round number, when the repo
+	// requires them
+	Signoffs map[int]db.SignoffCheck
+	// comments of each round interleaved with events, keyed by round number
+	Threads map[int][]db.ThreadItem
+	// what the repo requires before merging, and how it may be merged
+	MergeRequirements []db.MergeRequirement
+	MergeStrategies   []db.MergeStrategy
+	UnresolvedThreads int
 
 	OrderedReactionKinds []db.ReactionKind
 	Reactions            map[db.ReactionKind]int
@@ -1043,6 +1631,9 @@ type RepoPullPatchParams struct {
 	Submission           *db.PullSubmission
 	OrderedReactionKinds []db.ReactionKind
 	DiffOpts             types.DiffOpts
+	// inline review threads of this round, by file
+	Threads           map[string][]db.ReviewThread
+	UnresolvedThreads int
 }
 
 // this name is a mouthful
@@ -1058,6 +1649,7 @@ type RepoPullInterdiffParams struct {
 	Interdiff            *patchutil.InterdiffResult
 	OrderedReactionKinds []db.ReactionKind
 	DiffOpts             types.DiffOpts
+	UnresolvedThreads    int
 }
 
 // this name is a mouthful
@@ -1083,6 +1675,19 @@ func (p *Pages) PullCompareBranchesFragment(w io.Writer, params PullCompareBranc
 	return p.executePlain("repo/pulls/fragments/pullCompareBranches", w, params)
 }
 
+type PullDescriptionParams struct {
+	RepoInfo repoinfo.RepoInfo
+	Title    string
+	Body     string
+	// what was last filled in from commits, to tell if the user edited it
+	AutoTitle string
+	AutoBody  string
+}
+
+func (p *Pages) PullDescriptionFragment(w io.Writer, params PullDescriptionParams) error {
+	return p.executePlain("repo/pulls/fragments/pullDescription", w, params)
+}
+
 type PullCompareForkParams struct {
 	RepoInfo repoinfo.RepoInfo
 	Forks    []db.Repo
@@ -1122,12 +1727,25 @@ type PullActionsParams struct {
 	MergeCheck    types.MergeCheckResponse
 	ResubmitCheck ResubmitResult
 	Stack         db.Stack
+
+	MergeRequirements []db.MergeRequirement
+	MergeStrategies   []db.MergeStrategy
 }
 
 func (p *Pages) PullActionsFragment(w io.Writer, params PullActionsParams) error {
 	return p.executePlain("repo/pulls/fragments/pullActions", w, params)
 }
 
+type PullBackportParams struct {
+	RepoInfo repoinfo.RepoInfo
+	Pull     *db.Pull
+	Branches []types.Branch
+}
+
+func (p *Pages) PullBackportFragment(w io.Writer, params PullBackportParams) error {
+	return p.executePlain("repo/pulls/fragments/pullBackport", w, params)
+}
+
 type PullNewCommentParams struct {
 	LoggedInUser *oauth.User
 	RepoInfo     repoinfo.RepoInfo
@@ -1148,6 +1766,7 @@ type RepoCompareParams struct {
 	Base         string
 	Head         string
 	Diff         *types.NiceDiff
+	Commits      []*object.Commit
 	DiffOpts     types.DiffOpts
 
 	Active string
@@ -1208,6 +1827,30 @@ func (p *Pages) Pipelines(w io.Writer, params PipelinesParams) error {
 	return p.executeRepo("repo/pipelines/pipelines", w, params)
 }
 
+type RepoProjectsParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Projects     []db.Project
+	Active       string
+}
+
+func (p *Pages) RepoProjects(w io.Writer, params RepoProjectsParams) error {
+	params.Active = "projects"
+	return p.executeRepo("repo/projects/projects", w, params)
+}
+
+type RepoProjectParams struct {
+	LoggedInUser *oauth.User
+	RepoInfo     repoinfo.RepoInfo
+	Project      *db.Project
+	Active       string
+}
+
+func (p *Pages) RepoProject(w io.Writer, params RepoProjectParams) error {
+	params.Active = "projects"
+	return p.executeRepo("repo/projects/project", w, params)
+}
+
 type LogBlockParams struct {
 	Id        int
 	Name      string
@@ -1296,51 +1939,195 @@ func (p *Pages) SingleString(w io.Writer, params SingleStringParams) error {
 		}
 	}
 
-	c := params.String.Contents
+	code, err := highlight(params.String.Filename, params.String.Contents, "L", style)
+	if err != nil {
+		return err
+	}
+
+	params.String.Contents = code
+	return p.execute("strings/string", w, params)
+}
+
+// highlight renders contents as syntax highlighted html, picking a lexer
+// based on the filename. Line numbers link to #{linePrefix}{n}.
+func highlight(filename, contents, linePrefix string, style *chroma.Style) (string, error) {
 	formatter := chromahtml.New(
 		chromahtml.InlineCode(false),
 		chromahtml.WithLineNumbers(true),
-		chromahtml.WithLinkableLineNumbers(true, "L"),
+		chromahtml.WithLinkableLineNumbers(true, linePrefix),
 		chromahtml.Standalone(false),
 		chromahtml.WithClasses(true),
 	)
 
-	lexer := lexers.Get(filepath.Base(params.String.Filename))
+	lexer := lexers.Get(filepath.Base(filename))
 	if lexer == nil {
 		lexer = lexers.Fallback
 	}
 
-	iterator, err := lexer.Tokenise(nil, c)
+	iterator, err := lexer.Tokenise(nil, contents)
 	if err != nil {
-		return fmt.Errorf("chroma tokenize: %w", err)
+		return "", fmt.Errorf("chroma tokenize: %w", err)
 	}
 
 	var code bytes.Buffer
 	err = formatter.Format(&code, style, iterator)
 	if err != nil {
-		return fmt.Errorf("chroma format: %w", err)
+		return "", fmt.Errorf("chroma format: %w", err)
 	}
 
-	params.String.Contents = code.String()
-	return p.execute("strings/string", w, params)
+	return code.String(), nil
+}
+
+type GistTimelineParams struct {
+	LoggedInUser *oauth.User
+	Gists        []db.Gist
+}
+
+func (p *Pages) GistsTimeline(w io.Writer, params GistTimelineParams) error {
+	return p.execute("gists/timeline", w, params)
+}
+
+type UserGistsParams struct {
+	LoggedInUser *oauth.User
+	Owner        identity.Identity
+	Gists        []db.Gist
+}
+
+func (p *Pages) UserGists(w io.Writer, params UserGistsParams) error {
+	return p.execute("gists/user", w, params)
+}
+
+type PutGistParams struct {
+	LoggedInUser *oauth.User
+	Action       string
+
+	// this is supplied in the case of editing an existing gist
+	Gist db.Gist
+}
+
+func (p *Pages) PutGist(w io.Writer, params PutGistParams) error {
+	return p.execute("gists/put", w, params)
+}
+
+type GistFileView struct {
+	Filename string
+	// syntax highlighted contents, or rendered markdown if Rendered is set
+	Contents template.HTML
+	Rendered bool
+	Stats    db.StringStats
+}
+
+type SingleGistParams struct {
+	LoggedInUser *oauth.User
+	Gist         db.Gist
+	Files        []GistFileView
+	Stats        db.StringStats
+	Owner        identity.Identity
+	ForkCount    int64
+	EmbedUrl     string
+}
+
+func (p *Pages) SingleGist(w io.Writer, params SingleGistParams) error {
+	files, err := p.gistFileViews(params.Gist)
+	if err != nil {
+		return err
+	}
+
+	params.Files = files
+	return p.execute("gists/gist", w, params)
+}
+
+type GistEmbedParams struct {
+	Gist  db.Gist
+	Files []GistFileView
+	Owner identity.Identity
+}
+
+func (p *Pages) GistEmbed(w io.Writer, params GistEmbedParams) error {
+	files, err := p.gistFileViews(params.Gist)
+	if err != nil {
+		return err
+	}
+
+	params.Files = files
+	return p.executePlain("gists/embed", w, params)
+}
+
+func (p *Pages) gistFileViews(gist db.Gist) ([]GistFileView, error) {
+	var style *chroma.Style = styles.Get("catpuccin-latte")
+
+	var views []GistFileView
+	for i, f := range gist.Files {
+		view := GistFileView{
+			Filename: f.Filename,
+			Stats:    db.String{Contents: f.Contents}.Stats(),
+		}
+
+		switch markup.GetFormat(f.Filename) {
+		case markup.FormatMarkdown:
+			p.rctx.RendererType = markup.RendererTypeRepoMarkdown
+			htmlString := p.rctx.RenderMarkdown(f.Contents)
+			view.Contents = template.HTML(p.rctx.SanitizeDefault(htmlString))
+			view.Rendered = true
+		default:
+			// prefix line anchors with the file index to keep them unique on
+			// the page: #F0L12, #F1L3, ...
+			code, err := highlight(f.Filename, f.Contents, fmt.Sprintf("F%dL", i), style)
+			if err != nil {
+				return nil, err
+			}
+			view.Contents = template.HTML(code)
+		}
+
+		views = append(views, view)
+	}
+
+	return views, nil
 }
 
 func (p *Pages) Home(w io.Writer, params TimelineParams) error {
+	if path := p.instance.LandingPage; path != "" {
+		markdownBytes, err := os.ReadFile(path)
+		if err != nil {
+			p.logger.Error("failed to read landing page", "path", path, "err", err)
+		} else {
+			p.rctx.RendererType = markup.RendererTypeDefault
+			htmlString := p.rctx.RenderMarkdown(string(markdownBytes))
+			params.Landing = template.HTML(p.rctx.SanitizeDefault(htmlString))
+		}
+	}
+
 	return p.execute("timeline/home", w, params)
 }
 
 func (p *Pages) Static() http.Handler {
+	var static http.Handler
 	if p.dev {
-		return http.StripPrefix("/static/", http.FileServer(http.Dir("appview/pages/static")))
+		static = http.StripPrefix("/static/", http.FileServer(http.Dir("appview/pages/static")))
+	} else {
+		sub, err := fs.Sub(Files, "static")
+		if err != nil {
+			p.logger.Error("no static dir found? that's crazy", "err", err)
+			panic(err)
+		}
+		// Custom handler to apply Cache-Control headers for font files
+		static = Cache(http.StripPrefix("/static/", http.FileServer(http.FS(sub))))
 	}
 
-	sub, err := fs.Sub(Files, "static")
-	if err != nil {
-		p.logger.Error("no static dir found? that's crazy", "err", err)
-		panic(err)
+	if p.themeDir == "" {
+		return static
 	}
-	// Custom handler to apply Cache-Control headers for font files
-	return Cache(http.StripPrefix("/static/", http.FileServer(http.FS(sub))))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/static/")
+		if file, ok := themeFile(p.themeDir, name); ok {
+			// themes change in place, unlike built-in files
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+			http.ServeFile(w, r, file)
+			return
+		}
+		static.ServeHTTP(w, r)
+	})
 }
 
 func Cache(h http.Handler) http.Handler {
@@ -1357,8 +2144,8 @@ func Cache(h http.Handler) http.Handler {
 	})
 }
 
-func CssContentHash() string {
-	cssFile, err := Files.Open("static/tw.css")
+func CssContentHash(fsys fs.FS) string {
+	cssFile, err := fsys.Open("static/tw.css")
 	if err != nil {
 		slog.Debug("Error opening CSS file", "err", err)
 		return ""
@@ -1389,3 +2176,7 @@ func (p *Pages) ErrorKnot404(w io.Writer) error {
 func (p *Pages) Error503(w io.Writer) error {
 	return p.execute("errors/503", w, nil)
 }
+
+func (p *Pages) Offline(w io.Writer) error {
+	return p.execute("errors/offline", w, nil)
+}
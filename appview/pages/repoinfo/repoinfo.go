@@ -40,7 +40,9 @@ func (r RepoInfo) GetTabs() [][]string {
 		{"overview", "/", "square-chart-gantt"},
 		{"issues", "/issues", "circle-dot"},
 		{"pulls", "/pulls", "git-pull-request"},
+		{"projects", "/projects", "square-kanban"},
 		{"pipelines", "/pipelines", "layers-2"},
+		{"dependencies", "/dependencies", "package"},
 	}
 
 	if r.Roles.SettingsAllowed() {
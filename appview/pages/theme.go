@@ -0,0 +1,109 @@
+package pages
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// themeFS lays the files of a theme directory over the built-in templates
+// and static files, a file in the theme replaces the one at the same path.
+type themeFS struct {
+	theme fs.FS
+	base  fs.FS
+}
+
+func (t themeFS) Open(name string) (fs.File, error) {
+	f, err := t.theme.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	return t.base.Open(name)
+}
+
+func (t themeFS) ReadFile(name string) ([]byte, error) {
+	data, err := fs.ReadFile(t.theme, name)
+	if err == nil {
+		return data, nil
+	}
+	return fs.ReadFile(t.base, name)
+}
+
+// ReadDir merges the entries of both directories, so that templates only
+// present in the theme are picked up too
+func (t themeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	base, baseErr := fs.ReadDir(t.base, name)
+	theme, themeErr := fs.ReadDir(t.theme, name)
+	if baseErr != nil && themeErr != nil {
+		return nil, baseErr
+	}
+
+	entries := theme
+	for _, e := range base {
+		if !slices.ContainsFunc(theme, func(te fs.DirEntry) bool { return te.Name() == e.Name() }) {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		if a.Name() < b.Name() {
+			return -1
+		}
+		if a.Name() > b.Name() {
+			return 1
+		}
+		return 0
+	})
+	return entries, nil
+}
+
+// themeFile is the path of a static file in the theme directory, if the
+// theme has one
+func themeFile(dir, name string) (string, bool) {
+	if dir == "" {
+		return "", false
+	}
+	p := filepath.Join(dir, "static", filepath.FromSlash(path.Clean("/"+name)))
+	info, err := os.Stat(p)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return p, true
+}
+
+// lastModified is the most recent modification time of any file under dir
+func lastModified(dir string) time.Time {
+	var latest time.Time
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest
+}
+
+// watchTheme drops parsed templates whenever the theme directory changes,
+// so that operators can edit their theme without restarting the appview
+func (p *Pages) watchTheme(dir string, interval time.Duration) {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		p.logger.Warn("theme directory does not exist", "dir", dir)
+	}
+
+	seen := lastModified(dir)
+	for range time.Tick(interval) {
+		latest := lastModified(dir)
+		if latest.After(seen) {
+			seen = latest
+			p.cache.Clear()
+			p.logger.Info("theme changed, reloading templates", slog.String("dir", dir))
+		}
+	}
+}
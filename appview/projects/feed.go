@@ -0,0 +1,168 @@
+package projects
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+
+	"github.com/gorilla/feeds"
+)
+
+// Calendar is an iCalendar feed of the due dates of the projects of a repo,
+// for maintainers to subscribe to in their calendars
+func (p *Projects) Calendar(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "Calendar")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	projects, err := db.GetProjects(p.db, db.FilterEq("repo_at", f.RepoAt()))
+	if err != nil {
+		l.Error("failed to query db", "err", err)
+		p.pages.Error503(w)
+		return
+	}
+
+	host := "tangled.sh"
+	if u, err := url.Parse(p.config.Core.AppviewHost); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+
+	cal := calendar{}
+	cal.line("BEGIN", "VCALENDAR")
+	cal.line("VERSION", "2.0")
+	cal.line("PRODID", "-//tangled//projects//EN")
+	cal.line("CALSCALE", "GREGORIAN")
+	cal.line("X-WR-CALNAME", fmt.Sprintf("milestones of %s", f.OwnerSlashRepo()))
+
+	now := time.Now().UTC().Format("20060102T150405Z")
+	for _, project := range projects {
+		if project.Due == nil {
+			continue
+		}
+
+		link := fmt.Sprintf("%s/%s/projects/%d", p.config.Core.AppviewHost, f.OwnerSlashRepo(), project.Id)
+		cal.line("BEGIN", "VEVENT")
+		cal.line("UID", fmt.Sprintf("project-%d@%s", project.Id, host))
+		cal.line("DTSTAMP", now)
+		// an all-day event, ending the day after
+		cal.line("DTSTART;VALUE=DATE", project.Due.Format("20060102"))
+		cal.line("DTEND;VALUE=DATE", project.Due.AddDate(0, 0, 1).Format("20060102"))
+		cal.line("SUMMARY", cal.text(fmt.Sprintf("%s due: %s", f.OwnerSlashRepo(), project.Name)))
+		if project.Description != "" {
+			cal.line("DESCRIPTION", cal.text(project.Description))
+		}
+		cal.line("URL", link)
+		cal.line("END", "VEVENT")
+	}
+
+	cal.line("END", "VCALENDAR")
+
+	w.Header().Set("content-type", "text/calendar; charset=utf-8")
+	w.Write([]byte(cal.String()))
+}
+
+// Feed is an Atom feed of the issues and pulls placed on a project
+func (p *Projects) Feed(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "Feed")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	project, err := p.project(r, f.RepoAt())
+	if err != nil {
+		l.Error("failed to get project", "err", err)
+		p.pages.Error404(w)
+		return
+	}
+
+	base := fmt.Sprintf("%s/%s", p.config.Core.AppviewHost, f.OwnerSlashRepo())
+	feed := &feeds.Feed{
+		Title:   fmt.Sprintf("%s · projects · %s", project.Name, f.OwnerSlashRepo()),
+		Link:    &feeds.Link{Href: fmt.Sprintf("%s/projects/%d", base, project.Id), Type: "text/html", Rel: "alternate"},
+		Created: project.Created,
+		Updated: project.Created,
+	}
+	if project.Due != nil {
+		feed.Subtitle = fmt.Sprintf("due %s", project.Due.Format("January 2, 2006"))
+	}
+
+	for _, column := range project.Columns {
+		for _, card := range column.Cards {
+			kind, path, state := "issue", "issues", "open"
+			if card.IsPull() {
+				kind, path, state = "PR", "pulls", card.PullState.String()
+			} else if !card.IssueOpen {
+				state = "closed"
+			}
+
+			link := fmt.Sprintf("%s/%s/%d", base, path, card.Number)
+			feed.Items = append(feed.Items, &feeds.Item{
+				Title:       fmt.Sprintf("[%s #%d] %s", kind, card.Number, card.Title),
+				Link:        &feeds.Link{Href: link, Type: "text/html", Rel: "alternate"},
+				Id:          link,
+				Description: fmt.Sprintf("%s, in %s", state, column.Name),
+				Created:     card.Created,
+			})
+		}
+	}
+
+	slices.SortFunc(feed.Items, func(a, b *feeds.Item) int {
+		return b.Created.Compare(a.Created)
+	})
+	if len(feed.Items) > 0 && feed.Items[0].Created.After(feed.Updated) {
+		feed.Updated = feed.Items[0].Created
+	}
+
+	atom, err := feed.ToAtom()
+	if err != nil {
+		p.pages.Error500(w)
+		return
+	}
+
+	w.Header().Set("content-type", "application/atom+xml")
+	w.Write([]byte(atom))
+}
+
+// calendar writes the content lines of an iCalendar object, as in RFC 5545
+type calendar struct {
+	strings.Builder
+}
+
+// line writes a content line, folded to 75 octets, counting the space that
+// starts each continuation
+func (c *calendar) line(name, value string) {
+	line := name + ":" + value
+	for limit := 75; len(line) > limit; limit = 74 {
+		// don't fold in the middle of a character
+		cut := limit
+		for cut > 0 && line[cut]&0xc0 == 0x80 {
+			cut--
+		}
+		c.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	c.WriteString(line + "\r\n")
+}
+
+// text escapes a value of the TEXT type
+func (c *calendar) text(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
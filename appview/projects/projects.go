@@ -0,0 +1,423 @@
+package projects
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/reporesolver"
+	"tangled.sh/tangled.sh/core/log"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/go-chi/chi/v5"
+)
+
+type Projects struct {
+	repoResolver *reporesolver.RepoResolver
+	config       *config.Config
+	oauth        *oauth.OAuth
+	pages        *pages.Pages
+	db           *db.DB
+	logger       *slog.Logger
+}
+
+func New(
+	oauth *oauth.OAuth,
+	repoResolver *reporesolver.RepoResolver,
+	pages *pages.Pages,
+	db *db.DB,
+	config *config.Config,
+) *Projects {
+	logger := log.New("projects")
+
+	return &Projects{oauth: oauth,
+		repoResolver: repoResolver,
+		pages:        pages,
+		config:       config,
+		db:           db,
+		logger:       logger,
+	}
+}
+
+func (p *Projects) Index(w http.ResponseWriter, r *http.Request) {
+	user := p.oauth.GetUser(r)
+	l := p.logger.With("handler", "Index")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	projects, err := db.GetProjects(p.db, db.FilterEq("repo_at", f.RepoAt()))
+	if err != nil {
+		l.Error("failed to query db", "err", err)
+		p.pages.Error503(w)
+		return
+	}
+
+	p.pages.RepoProjects(w, pages.RepoProjectsParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Projects:     projects,
+	})
+}
+
+func (p *Projects) NewProject(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "NewProject")
+	noticeId := "projects"
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	description := strings.TrimSpace(r.FormValue("description"))
+	if name == "" {
+		p.pages.Notice(w, noticeId, "Project name is required.")
+		return
+	}
+
+	var due *time.Time
+	if d := strings.TrimSpace(r.FormValue("due")); d != "" {
+		parsed, err := time.Parse(db.DueDateFormat, d)
+		if err != nil {
+			p.pages.Notice(w, noticeId, "Invalid due date.")
+			return
+		}
+		due = &parsed
+	}
+
+	tx, err := p.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		l.Error("failed to start transaction", "err", err)
+		p.pages.Notice(w, noticeId, "Failed to create project, try again later.")
+		return
+	}
+	defer tx.Rollback()
+
+	project := db.Project{
+		RepoAt:      f.RepoAt(),
+		Name:        name,
+		Description: description,
+		Due:         due,
+	}
+	err = db.AddProject(tx, &project)
+	if err != nil {
+		l.Error("failed to add project", "err", err)
+		p.pages.Notice(w, noticeId, "Failed to create project, a project with this name may already exist.")
+		return
+	}
+
+	for _, c := range db.DefaultProjectColumns {
+		c.ProjectId = project.Id
+		err = db.AddProjectColumn(tx, &c)
+		if err != nil {
+			l.Error("failed to add column", "err", err)
+			p.pages.Notice(w, noticeId, "Failed to create project, try again later.")
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		l.Error("failed to commit", "err", err)
+		p.pages.Notice(w, noticeId, "Failed to create project, try again later.")
+		return
+	}
+
+	p.pages.HxLocation(w, fmt.Sprintf("/%s/projects/%d", f.OwnerSlashRepo(), project.Id))
+}
+
+func (p *Projects) Board(w http.ResponseWriter, r *http.Request) {
+	user := p.oauth.GetUser(r)
+	l := p.logger.With("handler", "Board")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	project, err := p.project(r, f.RepoAt())
+	if err != nil {
+		l.Error("failed to get project", "err", err)
+		p.pages.Error404(w)
+		return
+	}
+
+	p.pages.RepoProject(w, pages.RepoProjectParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Project:      project,
+	})
+}
+
+func (p *Projects) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "DeleteProject")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	project, err := p.project(r, f.RepoAt())
+	if err != nil {
+		p.pages.Notice(w, "project", "Project not found.")
+		return
+	}
+
+	err = db.DeleteProject(p.db, f.RepoAt(), project.Id)
+	if err != nil {
+		l.Error("failed to delete project", "err", err)
+		p.pages.Notice(w, "project", "Failed to delete project, try again later.")
+		return
+	}
+
+	p.pages.HxLocation(w, fmt.Sprintf("/%s/projects", f.OwnerSlashRepo()))
+}
+
+func (p *Projects) AddColumn(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "AddColumn")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	project, err := p.project(r, f.RepoAt())
+	if err != nil {
+		p.pages.Notice(w, "project", "Project not found.")
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		p.pages.Notice(w, "project", "Column name is required.")
+		return
+	}
+
+	automation := db.ProjectAutomation(r.FormValue("automation"))
+	if !automation.IsValid() {
+		p.pages.Notice(w, "project", "Invalid automation.")
+		return
+	}
+
+	err = db.AddProjectColumn(p.db, &db.ProjectColumn{
+		ProjectId:  project.Id,
+		Name:       name,
+		Automation: automation,
+	})
+	if err != nil {
+		l.Error("failed to add column", "err", err)
+		p.pages.Notice(w, "project", "Failed to add column, try again later.")
+		return
+	}
+
+	p.pages.HxRefresh(w)
+}
+
+func (p *Projects) DeleteColumn(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "DeleteColumn")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	project, err := p.project(r, f.RepoAt())
+	if err != nil {
+		p.pages.Notice(w, "project", "Project not found.")
+		return
+	}
+
+	columnId, err := strconv.ParseInt(chi.URLParam(r, "column"), 10, 64)
+	if err != nil {
+		p.pages.Notice(w, "project", "Invalid column.")
+		return
+	}
+
+	err = db.DeleteProjectColumn(p.db, project.Id, columnId)
+	if err != nil {
+		l.Error("failed to delete column", "err", err)
+		p.pages.Notice(w, "project", "Failed to delete column, try again later.")
+		return
+	}
+
+	p.pages.HxRefresh(w)
+}
+
+// AddCard places an issue or pull, given by its number, in a column
+func (p *Projects) AddCard(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "AddCard")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	project, err := p.project(r, f.RepoAt())
+	if err != nil {
+		p.pages.Notice(w, "project", "Project not found.")
+		return
+	}
+
+	columnId, err := strconv.ParseInt(r.FormValue("column"), 10, 64)
+	if err != nil || !hasColumn(project, columnId) {
+		p.pages.Notice(w, "project", "Invalid column.")
+		return
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(r.FormValue("number")), "#"))
+	if err != nil {
+		p.pages.Notice(w, "project", "Invalid issue or pull number.")
+		return
+	}
+
+	var subjectAt syntax.ATURI
+	switch r.FormValue("kind") {
+	case "issue":
+		issue, err := db.GetIssue(p.db, f.RepoAt(), number)
+		if err != nil || issue.Rkey == "" {
+			p.pages.Notice(w, "project", fmt.Sprintf("Issue #%d not found.", number))
+			return
+		}
+		subjectAt = issue.AtUri()
+	case "pull":
+		pull, err := db.GetPull(p.db, f.RepoAt(), number)
+		if err != nil {
+			p.pages.Notice(w, "project", fmt.Sprintf("Pull #%d not found.", number))
+			return
+		}
+		subjectAt = pull.PullAt()
+	default:
+		p.pages.Notice(w, "project", "Choose an issue or a pull.")
+		return
+	}
+
+	err = db.AddProjectCard(p.db, &db.ProjectCard{
+		ProjectId: project.Id,
+		ColumnId:  columnId,
+		SubjectAt: subjectAt,
+	})
+	if err != nil {
+		l.Error("failed to add card", "err", err)
+		p.pages.Notice(w, "project", "Failed to add card, it may already be on this project.")
+		return
+	}
+
+	p.pages.HxRefresh(w)
+}
+
+// MoveCard is called as cards are dragged around the board
+func (p *Projects) MoveCard(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "MoveCard")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	project, err := p.project(r, f.RepoAt())
+	if err != nil {
+		http.Error(w, "project not found", http.StatusNotFound)
+		return
+	}
+
+	cardId, err := strconv.ParseInt(chi.URLParam(r, "card"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid card", http.StatusBadRequest)
+		return
+	}
+
+	columnId, err := strconv.ParseInt(r.FormValue("column"), 10, 64)
+	if err != nil || !hasColumn(project, columnId) {
+		http.Error(w, "invalid column", http.StatusBadRequest)
+		return
+	}
+
+	position, err := strconv.Atoi(r.FormValue("position"))
+	if err != nil || position < 0 {
+		http.Error(w, "invalid position", http.StatusBadRequest)
+		return
+	}
+
+	err = db.MoveProjectCard(p.db, project.Id, cardId, columnId, position)
+	if err != nil {
+		l.Error("failed to move card", "err", err)
+		http.Error(w, "failed to move card", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *Projects) DeleteCard(w http.ResponseWriter, r *http.Request) {
+	l := p.logger.With("handler", "DeleteCard")
+
+	f, err := p.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to get repo and knot", "err", err)
+		return
+	}
+
+	project, err := p.project(r, f.RepoAt())
+	if err != nil {
+		p.pages.Notice(w, "project", "Project not found.")
+		return
+	}
+
+	cardId, err := strconv.ParseInt(chi.URLParam(r, "card"), 10, 64)
+	if err != nil {
+		p.pages.Notice(w, "project", "Invalid card.")
+		return
+	}
+
+	err = db.DeleteProjectCard(p.db, project.Id, cardId)
+	if err != nil {
+		l.Error("failed to delete card", "err", err)
+		p.pages.Notice(w, "project", "Failed to remove card, try again later.")
+		return
+	}
+
+	p.pages.HxRefresh(w)
+}
+
+// project fetches the board named by the url of the request
+func (p *Projects) project(r *http.Request, repoAt syntax.ATURI) (*db.Project, error) {
+	projectId, err := strconv.ParseInt(chi.URLParam(r, "project"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := db.GetProjectBoard(p.db, repoAt, projectId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no project %d", projectId)
+	}
+	return project, err
+}
+
+func hasColumn(project *db.Project, columnId int64) bool {
+	for _, c := range project.Columns {
+		if c.Id == columnId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package projects
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/middleware"
+)
+
+func (p *Projects) Router(mw *middleware.Middleware) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", p.Index)
+	r.Get("/calendar.ics", p.Calendar)
+	r.Get("/{project}", p.Board)
+	r.Get("/{project}/feed.atom", p.Feed)
+
+	// collaborators only
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(p.oauth))
+		r.Use(mw.RepoPermissionMiddleware("repo:push"))
+		r.Post("/", p.NewProject)
+		r.Delete("/{project}", p.DeleteProject)
+		r.Post("/{project}/columns", p.AddColumn)
+		r.Delete("/{project}/columns/{column}", p.DeleteColumn)
+		r.Post("/{project}/cards", p.AddCard)
+		r.Post("/{project}/cards/{card}/move", p.MoveCard)
+		r.Delete("/{project}/cards/{card}", p.DeleteCard)
+	})
+
+	return r
+}
@@ -0,0 +1,47 @@
+// Package publicnet dials out to hosts named by users, such as webhooks,
+// patch urls and fediverse servers, without reaching into the network the
+// appview runs in.
+package publicnet
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var ErrPrivateAddress = errors.New("refusing to connect to a private address")
+
+// Control refuses connections to loopback, private and link-local addresses.
+// It runs on the resolved address, so names pointing inward are caught too.
+func Control(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// Dialer only reaches public addresses, unless in dev where everything runs
+// on localhost
+func Dialer(timeout time.Duration, dev bool) *net.Dialer {
+	d := &net.Dialer{Timeout: timeout}
+	if !dev {
+		d.Control = Control
+	}
+	return d
+}
+
+// Client makes its requests through dialer
+func Client(dialer *net.Dialer, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,46 @@
+package publicnet
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestControl(t *testing.T) {
+	for address, public := range map[string]bool{
+		"1.1.1.1:443":                true,
+		"[2606:4700::1111]:443":      true,
+		"127.0.0.1:80":               false,
+		"10.0.0.1:80":                false,
+		"192.168.1.1:80":             false,
+		"169.254.169.254:80":         false,
+		"0.0.0.0:80":                 false,
+		"[::1]:80":                   false,
+		"[::ffff:127.0.0.1]:80":      false,
+		"[fd00::1]:80":               false,
+		"[fe80::1]:80":               false,
+		"not-an-ip.example.com:8080": false,
+	} {
+		if err := Control("tcp", address, nil); (err == nil) != public {
+			t.Errorf("%s: got %v, want public %v", address, err, public)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	_, err := Client(Dialer(time.Second, false), time.Second).Get(ts.URL)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("expected a private address error, got %v", err)
+	}
+
+	resp, err := Client(Dialer(time.Second, true), time.Second).Get(ts.URL)
+	if err != nil {
+		t.Fatalf("dev: %v", err)
+	}
+	resp.Body.Close()
+}
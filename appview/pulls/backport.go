@@ -0,0 +1,205 @@
+package pulls
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/tid"
+	"tangled.sh/tangled.sh/core/types"
+)
+
+const backportLabel = "backport"
+
+// Backport opens a pull that brings a merged pull onto another branch, such
+// as a maintenance branch. The new pull carries the patch that was merged,
+// once the knot has checked that it applies to that branch, and is labeled
+// and linked from the original.
+func (s *Pulls) Backport(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.GetUser(r)
+
+	errorId := "backport-error"
+
+	f, err := s.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		s.pages.Notice(w, errorId, "Failed to backport. Try again later.")
+		return
+	}
+
+	pull, ok := r.Context().Value("pull").(*db.Pull)
+	if !ok {
+		log.Println("failed to get pull")
+		s.pages.Notice(w, errorId, "Failed to backport. Try again later.")
+		return
+	}
+
+	if pull.State != db.PullMerged {
+		s.pages.Notice(w, errorId, "Only merged pull requests can be backported.")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
+		if err != nil {
+			log.Printf("failed to create unsigned client for %s", f.Knot)
+			s.pages.Error503(w)
+			return
+		}
+
+		result, err := us.Branches(f.OwnerDid(), f.Name)
+		if err != nil {
+			log.Println("failed to fetch branches", err)
+			s.pages.Error503(w)
+			return
+		}
+
+		var branches []types.Branch
+		for _, b := range result.Branches {
+			if b.Reference.Name != pull.TargetBranch {
+				branches = append(branches, b)
+			}
+		}
+
+		s.pages.PullBackportFragment(w, pages.PullBackportParams{
+			RepoInfo: f.RepoInfo(user),
+			Pull:     pull,
+			Branches: branches,
+		})
+		return
+	}
+
+	branch := r.FormValue("branch")
+	if branch == "" || branch == pull.TargetBranch {
+		s.pages.Notice(w, errorId, "Pick a branch to backport to.")
+		return
+	}
+
+	// one live backport per branch is enough
+	events, err := db.GetThreadEvents(
+		s.db,
+		db.FilterEq("subject_at", pull.PullAt()),
+		db.FilterEq("kind", db.ThreadEventBackported),
+	)
+	if err != nil {
+		log.Println("failed to get backports", err)
+		s.pages.Notice(w, errorId, "Failed to backport. Try again later.")
+		return
+	}
+	for _, ev := range events {
+		backport := ev.Backport()
+		if backport.Branch != branch {
+			continue
+		}
+		existing, err := db.GetPull(s.db, f.RepoAt(), backport.PullId)
+		if err == nil && existing.State != db.PullClosed {
+			s.pages.Notice(w, errorId, fmt.Sprintf("This was already backported to %s in #%d.", branch, backport.PullId))
+			return
+		}
+	}
+
+	patch := pull.LatestPatch()
+	check := s.mergeCheckPatch(r, f, branch, patch)
+	if check.Error != "" {
+		s.pages.Notice(w, errorId, check.Error)
+		return
+	}
+	if check.IsConflicted {
+		var files []string
+		for _, c := range check.Conflicts {
+			files = append(files, c.Filename)
+		}
+		s.pages.Notice(w, errorId, fmt.Sprintf("This pull does not apply cleanly to %s, it conflicts in %s.", branch, strings.Join(files, ", ")))
+		return
+	}
+
+	title := fmt.Sprintf("[%s] %s", branch, pull.Title)
+	body := fmt.Sprintf("Backport of #%d to `%s`.", pull.PullId, branch)
+	if pull.Body != "" {
+		body += "\n\n" + pull.Body
+	}
+	if err := s.config.Limits.CheckPullBody(body); err != nil {
+		body = fmt.Sprintf("Backport of #%d to `%s`.", pull.PullId, branch)
+	}
+
+	// createPullRequest tells the user about failures itself
+	backport := s.createPullRequest(w, r, f, user, title, body, branch, patch, "", nil, nil, false)
+	if backport == nil {
+		return
+	}
+
+	err = db.AddThreadEvent(s.db, db.ThreadEvent{
+		RepoAt:    f.RepoAt(),
+		SubjectAt: pull.PullAt(),
+		ActorDid:  user.Did,
+		Kind:      db.ThreadEventBackported,
+		Value:     fmt.Sprintf("%s %d", branch, backport.PullId),
+	})
+	if err != nil {
+		log.Println("failed to link backport", err)
+	}
+
+	if err := s.labelPull(r, user, backport, backportLabel); err != nil {
+		log.Println("failed to label backport", err)
+	}
+}
+
+// labelPull records a label on the PDS of the user, and applies it locally
+func (s *Pulls) labelPull(r *http.Request, user *oauth.User, pull *db.Pull, name string) error {
+	client, err := s.oauth.AuthorizedClient(r)
+	if err != nil {
+		return fmt.Errorf("failed to get authorized client: %w", err)
+	}
+
+	repo := pull.RepoAt.String()
+	createdAt := time.Now()
+	rkey := tid.TID()
+	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoLabelNSID,
+		Repo:       user.Did,
+		Rkey:       rkey,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &tangled.RepoLabel{
+				Name:      name,
+				Repo:      &repo,
+				Subject:   pull.PullAt().String(),
+				CreatedAt: createdAt.Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create label record: %w", err)
+	}
+
+	l := db.Label{
+		OwnerDid:  user.Did,
+		Rkey:      rkey,
+		RepoAt:    pull.RepoAt,
+		SubjectAt: pull.PullAt(),
+		Name:      name,
+		Created:   createdAt,
+	}
+	if err := db.AddLabel(s.db, l); err != nil {
+		return err
+	}
+
+	sourceAt := l.LabelAt()
+	return db.AddThreadEvent(s.db, db.ThreadEvent{
+		RepoAt:    pull.RepoAt,
+		SubjectAt: pull.PullAt(),
+		ActorDid:  user.Did,
+		Kind:      db.ThreadEventLabeled,
+		Value:     name,
+		SourceAt:  &sourceAt,
+		Created:   createdAt,
+	})
+}
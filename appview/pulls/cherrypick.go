@@ -0,0 +1,61 @@
+package pulls
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/patchutil"
+)
+
+// CherryPick opens a pull request that brings a commit of the repo onto
+// another branch, for those who may not push it there themselves
+func (s *Pulls) CherryPick(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.GetUser(r)
+	f, err := s.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	commit := r.FormValue("commit")
+	targetBranch := r.FormValue("branch")
+	if !plumbing.IsHash(commit) || targetBranch == "" {
+		s.pages.Notice(w, "pull", "Pick a branch.")
+		return
+	}
+
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
+	if err != nil {
+		log.Printf("failed to create unsigned client to %s: %v", f.Knot, err)
+		s.pages.Notice(w, "pull", "Failed to create a pull request. Try again later.")
+		return
+	}
+
+	caps, err := us.Capabilities()
+	if err != nil {
+		log.Println("error fetching knot caps", f.Knot, err)
+		s.pages.Notice(w, "pull", "Failed to create a pull request. Try again later.")
+		return
+	}
+	if !caps.PullRequests.FormatPatch || !caps.PullRequests.PatchSubmissions {
+		s.pages.Notice(w, "pull", "This knot doesn't support patch-based pull requests.")
+		return
+	}
+
+	// the commit against its parent is exactly its own change, as a
+	// format-patch that keeps its message and author
+	comparison, err := us.Compare(f.OwnerDid(), f.Name, commit+"^", commit)
+	if err != nil {
+		log.Println("failed to compare", err)
+		s.pages.Notice(w, "pull", err.Error())
+		return
+	}
+	if !patchutil.IsPatchValid(comparison.Patch) {
+		s.pages.Notice(w, "pull", "This commit has no changes to pick.")
+		return
+	}
+
+	s.createPullRequest(w, r, f, user, "", "", targetBranch, comparison.Patch, "", nil, nil, false)
+}
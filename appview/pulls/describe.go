@@ -0,0 +1,112 @@
+package pulls
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/patchutil"
+	"tangled.sh/tangled.sh/core/types"
+)
+
+// PullDescriptionFragment fills in the title and description of a new pull
+// from the commits of the chosen source branch. What the user wrote is kept;
+// only the empty fields, or the ones still holding an earlier suggestion,
+// are replaced.
+func (s *Pulls) PullDescriptionFragment(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.GetUser(r)
+	f, err := s.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	query := r.URL.Query()
+	// textareas may be sent with crlf line endings
+	crlf := strings.NewReplacer("\r\n", "\n")
+	params := pages.PullDescriptionParams{
+		RepoInfo:  f.RepoInfo(user),
+		Title:     query.Get("title"),
+		Body:      crlf.Replace(query.Get("body")),
+		AutoTitle: query.Get("autoTitle"),
+		AutoBody:  crlf.Replace(query.Get("autoBody")),
+	}
+
+	edited := (params.Title != "" || params.Body != "") &&
+		(params.Title != params.AutoTitle || params.Body != params.AutoBody)
+	targetBranch := query.Get("targetBranch")
+	sourceBranch := query.Get("sourceBranch")
+	if edited || targetBranch == "" || sourceBranch == "" {
+		s.pages.PullDescriptionFragment(w, params)
+		return
+	}
+
+	var comparison *types.RepoFormatPatchResponse
+	if fork := query.Get("fork"); fork != "" {
+		did, name, _ := strings.Cut(fork, "/")
+		var forkRepo *db.Repo
+		forkRepo, err = db.GetForkByDid(s.db, did, name)
+		if err == nil {
+			comparison, err = s.compareFork(r, forkRepo, targetBranch, sourceBranch)
+		}
+	} else {
+		var us *knotclient.UnsignedClient
+		us, err = knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
+		if err == nil {
+			comparison, err = us.Compare(f.OwnerDid(), f.Name, targetBranch, sourceBranch)
+		}
+	}
+	if err != nil {
+		log.Println("failed to compare branches for description", err)
+		s.pages.PullDescriptionFragment(w, params)
+		return
+	}
+
+	patches, err := patchutil.ExtractPatches(comparison.Patch)
+	if err != nil || len(patches) == 0 {
+		s.pages.PullDescriptionFragment(w, params)
+		return
+	}
+
+	params.Title, params.Body = describeCommits(patches, sourceBranch)
+	params.AutoTitle, params.AutoBody = params.Title, params.Body
+	s.pages.PullDescriptionFragment(w, params)
+}
+
+// describeCommits is the title and description a pull of patches gets by
+// default: the message of its commit, or for several commits, a list of
+// them titled after the branch they are from.
+func describeCommits(patches []types.FormatPatch, sourceBranch string) (string, string) {
+	if len(patches) == 1 || sourceBranch == "" {
+		return patches[0].Title, strings.TrimSpace(patches[0].Body)
+	}
+
+	var b strings.Builder
+	for _, p := range patches {
+		fmt.Fprintf(&b, "- %s\n", p.Title)
+	}
+	return branchTitle(sourceBranch), strings.TrimSuffix(b.String(), "\n")
+}
+
+// branchTitle turns a branch name like "fix/login-redirect" into
+// "Fix login redirect"
+func branchTitle(branch string) string {
+	if i := strings.LastIndex(branch, "/"); i >= 0 && i < len(branch)-1 {
+		branch = branch[i+1:]
+	}
+	title := strings.Join(strings.FieldsFunc(branch, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	}), " ")
+
+	first, size := utf8.DecodeRuneInString(title)
+	if first == utf8.RuneError {
+		return branch
+	}
+	return string(unicode.ToUpper(first)) + title[size:]
+}
@@ -0,0 +1,51 @@
+package pulls
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// pullJSON is the shape of a pull as served to the tangled cli
+type pullJSON struct {
+	Id           int       `json:"id"`
+	Uri          string    `json:"uri"`
+	Owner        string    `json:"owner"`
+	Title        string    `json:"title"`
+	Body         string    `json:"body"`
+	State        string    `json:"state"`
+	TargetBranch string    `json:"targetBranch"`
+	SourceBranch string    `json:"sourceBranch,omitempty"`
+	LatestRound  int       `json:"latestRound"`
+	Created      time.Time `json:"created"`
+}
+
+func (s *Pulls) RepoSinglePullJSON(w http.ResponseWriter, r *http.Request) {
+	pull, ok := r.Context().Value("pull").(*db.Pull)
+	if !ok {
+		log.Println("failed to get pull")
+		http.Error(w, "failed to load pull", http.StatusInternalServerError)
+		return
+	}
+
+	resp := pullJSON{
+		Id:           pull.PullId,
+		Uri:          pull.PullAt().String(),
+		Owner:        pull.OwnerDid,
+		Title:        pull.Title,
+		Body:         pull.Body,
+		State:        pull.State.String(),
+		TargetBranch: pull.TargetBranch,
+		LatestRound:  pull.LastRoundNumber(),
+		Created:      pull.Created,
+	}
+	if pull.PullSource != nil {
+		resp.SourceBranch = pull.PullSource.Branch
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,45 @@
+package pulls
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/ogcard"
+)
+
+// PullOpenGraphImage renders the social preview linked from og:image on pull
+// pages
+func (s *Pulls) PullOpenGraphImage(w http.ResponseWriter, r *http.Request) {
+	f, err := s.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	pull, ok := r.Context().Value("pull").(*db.Pull)
+	if !ok {
+		log.Println("failed to get pull")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var comments int
+	for _, submission := range pull.Submissions {
+		comments += len(submission.Comments)
+	}
+
+	card := ogcard.Card{
+		Eyebrow:     fmt.Sprintf("%s · pull #%d · %s", f.OwnerSlashRepo(), pull.PullId, pull.State.String()),
+		Title:       pull.Title,
+		Description: pull.Body,
+		Stats: []ogcard.Stat{
+			{Label: "rounds", Value: len(pull.Submissions)},
+			{Label: "comments", Value: comments},
+		},
+	}
+
+	card.Serve(w)
+}
@@ -0,0 +1,143 @@
+package pulls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/publicnet"
+	"tangled.sh/tangled.sh/core/patchutil"
+)
+
+// patches are fetched on behalf of whoever is opening the pull, so this is
+// kept well within what the appview is willing to store
+const maxFetchedPatch = 8 << 20
+
+var (
+	errInvalidPatchUrl  = errors.New("invalid patch url")
+	errInsecurePatchUrl = errors.New("patch url is not https")
+	errPatchTooLarge    = errors.New("patch is too large")
+)
+
+// patchFetchError is a failure to fetch a patch from the host it was
+// pointed at
+type patchFetchError struct {
+	host string
+	// set if the host responded, but not with the patch
+	status string
+	err    error
+}
+
+func (e *patchFetchError) Error() string {
+	if e.status != "" {
+		return fmt.Sprintf("fetching patch from %s: %s", e.host, e.status)
+	}
+	return fmt.Sprintf("fetching patch from %s: %v", e.host, e.err)
+}
+
+func (e *patchFetchError) Unwrap() error {
+	return e.err
+}
+
+// invalidPatchError is a patch that could not be normalized
+type invalidPatchError struct {
+	err error
+}
+
+func (e *invalidPatchError) Error() string {
+	return fmt.Sprintf("invalid patch: %v", e.err)
+}
+
+func (e *invalidPatchError) Unwrap() error {
+	return e.err
+}
+
+// fetchPatch downloads a patch from a url pasted in place of the patch
+// itself. Unless in dev, only public https hosts are reached.
+func (s *Pulls) fetchPatch(ctx context.Context, rawUrl string) (string, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return "", errInvalidPatchUrl
+	}
+	if u.Scheme != "https" && !(s.config.Core.Dev && u.Scheme == "http") {
+		return "", errInsecurePatchUrl
+	}
+
+	client := publicnet.Client(publicnet.Dialer(10*time.Second, s.config.Core.Dev), 30*time.Second)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", errInvalidPatchUrl
+	}
+	req.Header.Set("Accept", "text/plain, text/x-patch, text/x-diff, application/mbox")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", &patchFetchError{host: u.Host, err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &patchFetchError{host: u.Host, status: resp.Status}
+	}
+
+	patch, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchedPatch+1))
+	if err != nil {
+		return "", &patchFetchError{host: u.Host, err: err}
+	}
+	if len(patch) > maxFetchedPatch {
+		return "", errPatchTooLarge
+	}
+
+	return string(patch), nil
+}
+
+// submittedPatch is the patch pasted into a form, or fetched from the url
+// given instead, normalized to a diff or a format-patch series. See
+// patchNotice for telling the user what went wrong.
+func (s *Pulls) submittedPatch(ctx context.Context, patch, patchUrl string) (string, error) {
+	if strings.TrimSpace(patch) == "" && patchUrl != "" {
+		fetched, err := s.fetchPatch(ctx, strings.TrimSpace(patchUrl))
+		if err != nil {
+			return "", err
+		}
+		patch = fetched
+	}
+
+	normalized, err := patchutil.Normalize(patch)
+	if err != nil {
+		return "", &invalidPatchError{err: err}
+	}
+	return normalized, nil
+}
+
+// patchNotice is the message shown for an error from submittedPatch. It
+// quotes the patch and the remote host, so it is escaped to be shown as is.
+func patchNotice(err error) string {
+	var fetchErr *patchFetchError
+	var invalidErr *invalidPatchError
+
+	switch {
+	case errors.Is(err, errInvalidPatchUrl):
+		return "Invalid URL."
+	case errors.Is(err, errInsecurePatchUrl):
+		return "URL must use https."
+	case errors.Is(err, errPatchTooLarge):
+		return fmt.Sprintf("The patch is larger than %d MiB.", maxFetchedPatch>>20)
+	case errors.As(err, &fetchErr):
+		if fetchErr.status != "" {
+			return html.EscapeString(fmt.Sprintf("Failed to fetch the patch: %s.", fetchErr.status))
+		}
+		return html.EscapeString(fmt.Sprintf("Failed to fetch %s.", fetchErr.host))
+	case errors.As(err, &invalidErr):
+		return fmt.Sprintf("Invalid patch: %s.", html.EscapeString(invalidErr.err.Error()))
+	default:
+		return "Failed to read the patch."
+	}
+}
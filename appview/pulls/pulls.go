@@ -1,11 +1,13 @@
 package pulls
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,6 +16,7 @@ import (
 	"tangled.sh/tangled.sh/core/api/tangled"
 	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/hydrate"
 	"tangled.sh/tangled.sh/core/appview/notify"
 	"tangled.sh/tangled.sh/core/appview/oauth"
 	"tangled.sh/tangled.sh/core/appview/pages"
@@ -22,6 +25,7 @@ import (
 	"tangled.sh/tangled.sh/core/appview/xrpcclient"
 	"tangled.sh/tangled.sh/core/idresolver"
 	"tangled.sh/tangled.sh/core/knotclient"
+	tlog "tangled.sh/tangled.sh/core/log"
 	"tangled.sh/tangled.sh/core/patchutil"
 	"tangled.sh/tangled.sh/core/tid"
 	"tangled.sh/tangled.sh/core/types"
@@ -99,17 +103,24 @@ func (s *Pulls) PullActions(w http.ResponseWriter, r *http.Request) {
 		mergeCheckResponse := s.mergeCheck(r, f, pull, stack)
 		resubmitResult := pages.Unknown
 		if user.Did == pull.OwnerDid {
-			resubmitResult = s.resubmitCheck(f, pull, stack)
+			resubmitResult = s.resubmitCheck(r.Context(), f, pull, stack)
+		}
+
+		mergeSettings, requirements, err := s.mergeRequirements(f, pull)
+		if err != nil {
+			log.Println("failed to check merge requirements", err)
 		}
 
 		s.pages.PullActionsFragment(w, pages.PullActionsParams{
-			LoggedInUser:  user,
-			RepoInfo:      f.RepoInfo(user),
-			Pull:          pull,
-			RoundNumber:   roundNumber,
-			MergeCheck:    mergeCheckResponse,
-			ResubmitCheck: resubmitResult,
-			Stack:         stack,
+			LoggedInUser:      user,
+			RepoInfo:          f.RepoInfo(user),
+			Pull:              pull,
+			RoundNumber:       roundNumber,
+			MergeCheck:        mergeCheckResponse,
+			ResubmitCheck:     resubmitResult,
+			Stack:             stack,
+			MergeRequirements: requirements,
+			MergeStrategies:   mergeSettings.Strategies,
 		})
 		return
 	}
@@ -154,7 +165,7 @@ func (s *Pulls) RepoSinglePull(w http.ResponseWriter, r *http.Request) {
 	mergeCheckResponse := s.mergeCheck(r, f, pull, stack)
 	resubmitResult := pages.Unknown
 	if user != nil && user.Did == pull.OwnerDid {
-		resubmitResult = s.resubmitCheck(f, pull, stack)
+		resubmitResult = s.resubmitCheck(r.Context(), f, pull, stack)
 	}
 
 	repoInfo := f.RepoInfo(user)
@@ -188,6 +199,13 @@ func (s *Pulls) RepoSinglePull(w http.ResponseWriter, r *http.Request) {
 		m[p.Sha] = p
 	}
 
+	// posted by the bots of the repo, such as an external CI
+	commitStatuses, err := db.GetCommitStatuses(s.db, f.RepoAt(), shas)
+	if err != nil {
+		log.Printf("failed to fetch commit statuses: %s", err)
+		// non-fatal
+	}
+
 	reactionCountMap, err := db.GetReactionCountMap(s.db, pull.PullAt())
 	if err != nil {
 		log.Println("failed to get pull reactions")
@@ -199,6 +217,32 @@ func (s *Pulls) RepoSinglePull(w http.ResponseWriter, r *http.Request) {
 		userReactions = db.GetReactionStatusMap(s.db, user.Did, pull.PullAt())
 	}
 
+	events, err := db.GetThreadEvents(s.db, db.FilterEq("subject_at", pull.PullAt()))
+	if err != nil {
+		log.Printf("failed to fetch pull events: %s", err)
+		// non-fatal
+	}
+
+	mergeSettings, requirements, err := s.mergeRequirements(f, pull)
+	if err != nil {
+		log.Printf("failed to check merge requirements: %s", err)
+		// non-fatal
+	}
+
+	threads, err := db.GetReviewThreads(s.db, pull)
+	if err != nil {
+		log.Printf("failed to get review threads: %s", err)
+		// non-fatal
+	}
+
+	var signoffs map[int]db.SignoffCheck
+	if mergeSettings.RequireSignoff {
+		signoffs = make(map[int]db.SignoffCheck)
+		for _, submission := range pull.Submissions {
+			signoffs[submission.RoundNumber] = db.CheckSignoff(submission.Patch)
+		}
+	}
+
 	s.pages.RepoSinglePull(w, pages.RepoSinglePullParams{
 		LoggedInUser:   user,
 		RepoInfo:       repoInfo,
@@ -208,6 +252,13 @@ func (s *Pulls) RepoSinglePull(w http.ResponseWriter, r *http.Request) {
 		MergeCheck:     mergeCheckResponse,
 		ResubmitCheck:  resubmitResult,
 		Pipelines:      m,
+		CommitStatuses: commitStatuses,
+		Signoffs:       signoffs,
+		Threads:        db.PullThreads(pull, events),
+
+		MergeRequirements: requirements,
+		MergeStrategies:   mergeSettings.Strategies,
+		UnresolvedThreads: db.UnresolvedThreads(threads),
 
 		OrderedReactionKinds: db.OrderedReactionKinds,
 		Reactions:            reactionCountMap,
@@ -220,16 +271,6 @@ func (s *Pulls) mergeCheck(r *http.Request, f *reporesolver.ResolvedRepo, pull *
 		return types.MergeCheckResponse{}
 	}
 
-	scheme := "https"
-	if s.config.Core.Dev {
-		scheme = "http"
-	}
-	host := fmt.Sprintf("%s://%s", scheme, f.Knot)
-
-	xrpcc := indigoxrpc.Client{
-		Host: host,
-	}
-
 	patch := pull.LatestPatch()
 	if pull.IsStacked() {
 		// combine patches of substack
@@ -240,13 +281,29 @@ func (s *Pulls) mergeCheck(r *http.Request, f *reporesolver.ResolvedRepo, pull *
 		patch = mergeable.CombinedPatch()
 	}
 
+	return s.mergeCheckPatch(r, f, pull.TargetBranch, patch)
+}
+
+// mergeCheckPatch asks the knot whether patch applies to branch
+func (s *Pulls) mergeCheckPatch(r *http.Request, f *reporesolver.ResolvedRepo, branch, patch string) types.MergeCheckResponse {
+	scheme := "https"
+	if s.config.Core.Dev {
+		scheme = "http"
+	}
+	host := fmt.Sprintf("%s://%s", scheme, f.Knot)
+
+	xrpcc := indigoxrpc.Client{
+		Host:    host,
+		Headers: map[string]string{tlog.RequestIdHeader: tlog.RequestId(r.Context())},
+	}
+
 	resp, xe := tangled.RepoMergeCheck(
 		r.Context(),
 		&xrpcc,
 		&tangled.RepoMergeCheck_Input{
 			Did:    f.OwnerDid(),
 			Name:   f.Name,
-			Branch: pull.TargetBranch,
+			Branch: branch,
 			Patch:  patch,
 		},
 	)
@@ -282,7 +339,40 @@ func (s *Pulls) mergeCheck(r *http.Request, f *reporesolver.ResolvedRepo, pull *
 	return result
 }
 
-func (s *Pulls) resubmitCheck(f *reporesolver.ResolvedRepo, pull *db.Pull, stack db.Stack) pages.ResubmitResult {
+// mergeRequirements checks pull against the merge settings of its repo
+func (s *Pulls) mergeRequirements(f *reporesolver.ResolvedRepo, pull *db.Pull) (db.MergeSettings, []db.MergeRequirement, error) {
+	settings, err := db.GetMergeSettings(s.db, f.RepoAt())
+	if err != nil {
+		return settings, nil, err
+	}
+
+	threads, err := db.GetReviewThreads(s.db, pull)
+	if err != nil {
+		return settings, nil, err
+	}
+
+	reviews, err := db.GetPullReviews(s.db, db.FilterEq("repo_at", f.RepoAt()), db.FilterEq("pull_id", pull.PullId))
+	if err != nil {
+		return settings, nil, err
+	}
+
+	var statuses []db.CommitStatus
+	if sha := pull.LatestSha(); sha != "" {
+		bySha, err := db.GetCommitStatuses(s.db, f.RepoAt(), []string{sha})
+		if err != nil {
+			return settings, nil, err
+		}
+		statuses = bySha[sha]
+	}
+
+	canPush := func(did string) bool {
+		return f.RolesInRepo(&oauth.User{Did: did}).IsPushAllowed()
+	}
+
+	return settings, settings.Requirements(pull, reviews, threads, statuses, canPush), nil
+}
+
+func (s *Pulls) resubmitCheck(ctx context.Context, f *reporesolver.ResolvedRepo, pull *db.Pull, stack db.Stack) pages.ResubmitResult {
 	if pull.State == db.PullMerged || pull.State == db.PullDeleted || pull.PullSource == nil {
 		return pages.Unknown
 	}
@@ -307,7 +397,7 @@ func (s *Pulls) resubmitCheck(f *reporesolver.ResolvedRepo, pull *db.Pull, stack
 		repoName = f.Name
 	}
 
-	us, err := knotclient.NewUnsignedClient(knot, s.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(ctx, knot, s.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to setup client for %s; ignoring: %v", knot, err)
 		return pages.Unknown
@@ -366,15 +456,23 @@ func (s *Pulls) RepoPullPatch(w http.ResponseWriter, r *http.Request) {
 	patch := pull.Submissions[roundIdInt].Patch
 	diff := patchutil.AsNiceDiff(patch, pull.TargetBranch)
 
+	threads, err := db.GetReviewThreads(s.db, pull)
+	if err != nil {
+		log.Println("failed to get review threads", err)
+		// non-fatal
+	}
+
 	s.pages.RepoPullPatchPage(w, pages.RepoPullPatchParams{
-		LoggedInUser: user,
-		RepoInfo:     f.RepoInfo(user),
-		Pull:         pull,
-		Stack:        stack,
-		Round:        roundIdInt,
-		Submission:   pull.Submissions[roundIdInt],
-		Diff:         &diff,
-		DiffOpts:     diffOpts,
+		LoggedInUser:      user,
+		RepoInfo:          f.RepoInfo(user),
+		Pull:              pull,
+		Stack:             stack,
+		Round:             roundIdInt,
+		Submission:        pull.Submissions[roundIdInt],
+		Diff:              &diff,
+		DiffOpts:          diffOpts,
+		Threads:           db.ThreadsByPath(threads, roundIdInt),
+		UnresolvedThreads: db.UnresolvedThreads(threads),
 	})
 
 }
@@ -430,13 +528,20 @@ func (s *Pulls) RepoPullInterdiff(w http.ResponseWriter, r *http.Request) {
 
 	interdiff := patchutil.Interdiff(previousPatch, currentPatch)
 
+	threads, err := db.GetReviewThreads(s.db, pull)
+	if err != nil {
+		log.Println("failed to get review threads", err)
+		// non-fatal
+	}
+
 	s.pages.RepoPullInterdiffPage(w, pages.RepoPullInterdiffParams{
-		LoggedInUser: s.oauth.GetUser(r),
-		RepoInfo:     f.RepoInfo(user),
-		Pull:         pull,
-		Round:        roundIdInt,
-		Interdiff:    interdiff,
-		DiffOpts:     diffOpts,
+		LoggedInUser:      s.oauth.GetUser(r),
+		RepoInfo:          f.RepoInfo(user),
+		Pull:              pull,
+		Round:             roundIdInt,
+		Interdiff:         interdiff,
+		DiffOpts:          diffOpts,
+		UnresolvedThreads: db.UnresolvedThreads(threads),
 	})
 }
 
@@ -489,19 +594,8 @@ func (s *Pulls) RepoPulls(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, p := range pulls {
-		var pullSourceRepo *db.Repo
-		if p.PullSource != nil {
-			if p.PullSource.RepoAt != nil {
-				pullSourceRepo, err = db.GetRepoByAtUri(s.db, p.PullSource.RepoAt.String())
-				if err != nil {
-					log.Printf("failed to get repo by at uri: %v", err)
-					continue
-				} else {
-					p.PullSource.Repo = pullSourceRepo
-				}
-			}
-		}
+	if err := hydrate.New(s.db, s.idResolver).PullSources(pulls); err != nil {
+		log.Printf("failed to get pull source repos: %v", err)
 	}
 
 	// we want to group all stacked PRs into just one list
@@ -589,9 +683,30 @@ func (s *Pulls) PullComment(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	case http.MethodPost:
+		// inline review comments are made from the diff of a round
+		path := r.FormValue("path")
+		var line int
+		errorId := "pull-comment"
+		if path != "" {
+			errorId = "review-comment"
+			line, err = strconv.Atoi(r.FormValue("line"))
+			if err != nil || line < 1 {
+				s.pages.Notice(w, errorId, "Pick a line of the file to comment on.")
+				return
+			}
+			if !patchHasFile(pull.Submissions[roundNumber].Patch, path) {
+				s.pages.Notice(w, errorId, "This file is not part of this round.")
+				return
+			}
+		}
+
 		body := r.FormValue("body")
 		if body == "" {
-			s.pages.Notice(w, "pull", "Comment body is required")
+			s.pages.Notice(w, errorId, "Comment body is required")
+			return
+		}
+		if err := s.config.Limits.CheckCommentBody(body); err != nil {
+			s.pages.Notice(w, errorId, err.Error())
 			return
 		}
 
@@ -599,7 +714,7 @@ func (s *Pulls) PullComment(w http.ResponseWriter, r *http.Request) {
 		tx, err := s.db.BeginTx(r.Context(), nil)
 		if err != nil {
 			log.Println("failed to start transaction", err)
-			s.pages.Notice(w, "pull-comment", "Failed to create comment.")
+			s.pages.Notice(w, errorId, "Failed to create comment.")
 			return
 		}
 		defer tx.Rollback()
@@ -609,31 +724,37 @@ func (s *Pulls) PullComment(w http.ResponseWriter, r *http.Request) {
 		pullAt, err := db.GetPullAt(s.db, f.RepoAt(), pull.PullId)
 		if err != nil {
 			log.Println("failed to get pull at", err)
-			s.pages.Notice(w, "pull-comment", "Failed to create comment.")
+			s.pages.Notice(w, errorId, "Failed to create comment.")
 			return
 		}
 
 		client, err := s.oauth.AuthorizedClient(r)
 		if err != nil {
 			log.Println("failed to get authorized client", err)
-			s.pages.Notice(w, "pull-comment", "Failed to create comment.")
+			s.pages.Notice(w, errorId, "Failed to create comment.")
 			return
 		}
+		record := &tangled.RepoPullComment{
+			Pull:      string(pullAt),
+			Body:      body,
+			CreatedAt: createdAt,
+		}
+		if path != "" {
+			recordLine := int64(line)
+			record.Path = &path
+			record.Line = &recordLine
+		}
 		atResp, err := client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
 			Collection: tangled.RepoPullCommentNSID,
 			Repo:       user.Did,
 			Rkey:       tid.TID(),
 			Record: &lexutil.LexiconTypeDecoder{
-				Val: &tangled.RepoPullComment{
-					Pull:      string(pullAt),
-					Body:      body,
-					CreatedAt: createdAt,
-				},
+				Val: record,
 			},
 		})
 		if err != nil {
 			log.Println("failed to create pull comment", err)
-			s.pages.Notice(w, "pull-comment", "Failed to create comment.")
+			s.pages.Notice(w, errorId, "Failed to create comment.")
 			return
 		}
 
@@ -644,25 +765,31 @@ func (s *Pulls) PullComment(w http.ResponseWriter, r *http.Request) {
 			Body:         body,
 			CommentAt:    atResp.Uri,
 			SubmissionId: pull.Submissions[roundNumber].ID,
+			Path:         path,
+			Line:         line,
 		}
 
 		// Create the pull comment in the database with the commentAt field
 		commentId, err := db.NewPullComment(tx, comment)
 		if err != nil {
 			log.Println("failed to create pull comment", err)
-			s.pages.Notice(w, "pull-comment", "Failed to create comment.")
+			s.pages.Notice(w, errorId, "Failed to create comment.")
 			return
 		}
 
 		// Commit the transaction
 		if err = tx.Commit(); err != nil {
 			log.Println("failed to commit transaction", err)
-			s.pages.Notice(w, "pull-comment", "Failed to create comment.")
+			s.pages.Notice(w, errorId, "Failed to create comment.")
 			return
 		}
 
 		s.notifier.NewPullComment(r.Context(), comment)
 
+		if comment.IsInline() {
+			s.pages.HxLocation(w, fmt.Sprintf("/%s/pulls/%d/round/%d#comment-%d", f.OwnerSlashRepo(), pull.PullId, roundNumber, commentId))
+			return
+		}
 		s.pages.HxLocation(w, fmt.Sprintf("/%s/pulls/%d#comment-%d", f.OwnerSlashRepo(), pull.PullId, commentId))
 		return
 	}
@@ -678,7 +805,7 @@ func (s *Pulls) NewPull(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
-		us, err := knotclient.NewUnsignedClient(f.Knot, s.config.Core.Dev)
+		us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
 		if err != nil {
 			log.Printf("failed to create unsigned client for %s", f.Knot)
 			s.pages.Error503(w)
@@ -715,19 +842,33 @@ func (s *Pulls) NewPull(w http.ResponseWriter, r *http.Request) {
 		fromFork := r.FormValue("fork")
 		sourceBranch := r.FormValue("sourceBranch")
 		patch := r.FormValue("patch")
+		patchUrl := r.FormValue("patchUrl")
 
 		if targetBranch == "" {
 			s.pages.Notice(w, "pull", "Target branch is required.")
 			return
 		}
+		if err := s.config.Limits.CheckPullBody(body); err != nil {
+			s.pages.Notice(w, "pull", err.Error())
+			return
+		}
 
 		// Determine PR type based on input parameters
 		isPushAllowed := f.RepoInfo(user).Roles.IsPushAllowed()
 		isBranchBased := isPushAllowed && sourceBranch != "" && fromFork == ""
 		isForkBased := fromFork != "" && sourceBranch != ""
-		isPatchBased := patch != "" && !isBranchBased && !isForkBased
+		isPatchBased := (patch != "" || patchUrl != "") && !isBranchBased && !isForkBased
 		isStacked := r.FormValue("isStacked") == "on"
 
+		if isPatchBased {
+			patch, err = s.submittedPatch(r.Context(), patch, patchUrl)
+			if err != nil {
+				log.Println("failed to read submitted patch", err)
+				s.pages.Notice(w, "pull", patchNotice(err))
+				return
+			}
+		}
+
 		if isPatchBased && !patchutil.IsFormatPatch(patch) {
 			if title == "" {
 				s.pages.Notice(w, "pull", "Title is required for git-diff patches.")
@@ -752,7 +893,7 @@ func (s *Pulls) NewPull(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		us, err := knotclient.NewUnsignedClient(f.Knot, s.config.Core.Dev)
+		us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
 		if err != nil {
 			log.Printf("failed to create unsigned client to %s: %v", f.Knot, err)
 			s.pages.Notice(w, "pull", "Failed to create a pull request. Try again later.")
@@ -807,7 +948,7 @@ func (s *Pulls) handleBranchBasedPull(
 	isStacked bool,
 ) {
 	// Generate a patch using /compare
-	ksClient, err := knotclient.NewUnsignedClient(f.Knot, s.config.Core.Dev)
+	ksClient, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create signed client for %s: %s", f.Knot, err)
 		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
@@ -863,6 +1004,39 @@ func (s *Pulls) handleForkBasedPull(w http.ResponseWriter, r *http.Request, f *r
 		return
 	}
 
+	comparison, err := s.compareFork(r, fork, targetBranch, sourceBranch)
+	if err != nil {
+		s.pages.Notice(w, "pull", err.Error())
+		return
+	}
+
+	sourceRev := comparison.Rev2
+	patch := comparison.Patch
+
+	if !patchutil.IsPatchValid(patch) {
+		s.pages.Notice(w, "pull", "Invalid patch format. Please provide a valid diff.")
+		return
+	}
+
+	forkAtUri := fork.RepoAt()
+	forkAtUriStr := forkAtUri.String()
+
+	pullSource := &db.PullSource{
+		Branch: sourceBranch,
+		RepoAt: &forkAtUri,
+	}
+	recordPullSource := &tangled.RepoPull_Source{
+		Branch: sourceBranch,
+		Repo:   &forkAtUriStr,
+		Sha:    sourceRev,
+	}
+
+	s.createPullRequest(w, r, f, user, title, body, targetBranch, patch, sourceRev, pullSource, recordPullSource, isStacked)
+}
+
+// compareFork compares sourceBranch of fork against targetBranch of the repo
+// it was forked from. The errors returned can be shown to the user.
+func (s *Pulls) compareFork(r *http.Request, fork *db.Repo, targetBranch, sourceBranch string) (*types.RepoFormatPatchResponse, error) {
 	client, err := s.oauth.ServiceClient(
 		r,
 		oauth.WithService(fork.Knot),
@@ -871,15 +1045,13 @@ func (s *Pulls) handleForkBasedPull(w http.ResponseWriter, r *http.Request, f *r
 	)
 	if err != nil {
 		log.Printf("failed to connect to knot server: %v", err)
-		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
-		return
+		return nil, fmt.Errorf("Failed to create pull request. Try again later.")
 	}
 
-	us, err := knotclient.NewUnsignedClient(fork.Knot, s.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), fork.Knot, s.config.Core.Dev)
 	if err != nil {
 		log.Println("failed to create unsigned client:", err)
-		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
-		return
+		return nil, fmt.Errorf("Failed to create pull request. Try again later.")
 	}
 
 	resp, err := tangled.RepoHiddenRef(
@@ -892,17 +1064,14 @@ func (s *Pulls) handleForkBasedPull(w http.ResponseWriter, r *http.Request, f *r
 		},
 	)
 	if err := xrpcclient.HandleXrpcErr(err); err != nil {
-		s.pages.Notice(w, "pull", err.Error())
-		return
+		return nil, err
 	}
 
 	if !resp.Success {
-		errorMsg := "Failed to create pull request"
 		if resp.Error != nil {
-			errorMsg = fmt.Sprintf("Failed to create pull request: %s", *resp.Error)
+			return nil, fmt.Errorf("Failed to create pull request: %s", *resp.Error)
 		}
-		s.pages.Notice(w, "pull", errorMsg)
-		return
+		return nil, fmt.Errorf("Failed to create pull request")
 	}
 
 	hiddenRef := fmt.Sprintf("hidden/%s/%s", sourceBranch, targetBranch)
@@ -914,34 +1083,14 @@ func (s *Pulls) handleForkBasedPull(w http.ResponseWriter, r *http.Request, f *r
 	comparison, err := us.Compare(fork.Did, fork.Name, hiddenRef, sourceBranch)
 	if err != nil {
 		log.Println("failed to compare across branches", err)
-		s.pages.Notice(w, "pull", err.Error())
-		return
-	}
-
-	sourceRev := comparison.Rev2
-	patch := comparison.Patch
-
-	if !patchutil.IsPatchValid(patch) {
-		s.pages.Notice(w, "pull", "Invalid patch format. Please provide a valid diff.")
-		return
+		return nil, err
 	}
 
-	forkAtUri := fork.RepoAt()
-	forkAtUriStr := forkAtUri.String()
-
-	pullSource := &db.PullSource{
-		Branch: sourceBranch,
-		RepoAt: &forkAtUri,
-	}
-	recordPullSource := &tangled.RepoPull_Source{
-		Branch: sourceBranch,
-		Repo:   &forkAtUriStr,
-		Sha:    sourceRev,
-	}
-
-	s.createPullRequest(w, r, f, user, title, body, targetBranch, patch, sourceRev, pullSource, recordPullSource, isStacked)
+	return comparison, nil
 }
 
+// createPullRequest returns the pull it created, or nil when it failed and
+// told the user why. Stacks are not returned.
 func (s *Pulls) createPullRequest(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -953,7 +1102,7 @@ func (s *Pulls) createPullRequest(
 	pullSource *db.PullSource,
 	recordPullSource *tangled.RepoPull_Source,
 	isStacked bool,
-) {
+) *db.Pull {
 	if isStacked {
 		// creates a series of PRs, each linking to the previous, identified by jj's change-id
 		s.createStackedPullRequest(
@@ -966,21 +1115,21 @@ func (s *Pulls) createPullRequest(
 			sourceRev,
 			pullSource,
 		)
-		return
+		return nil
 	}
 
 	client, err := s.oauth.AuthorizedClient(r)
 	if err != nil {
 		log.Println("failed to get authorized client", err)
 		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
-		return
+		return nil
 	}
 
 	tx, err := s.db.BeginTx(r.Context(), nil)
 	if err != nil {
 		log.Println("failed to start tx")
 		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
-		return
+		return nil
 	}
 	defer tx.Rollback()
 
@@ -990,15 +1139,18 @@ func (s *Pulls) createPullRequest(
 		formatPatches, err := patchutil.ExtractPatches(patch)
 		if err != nil {
 			s.pages.Notice(w, "pull", fmt.Sprintf("Failed to extract patches: %v", err))
-			return
+			return nil
 		}
 		if len(formatPatches) == 0 {
 			s.pages.Notice(w, "pull", "No patches found in the supplied format-patch.")
-			return
+			return nil
 		}
 
-		title = formatPatches[0].Title
-		body = formatPatches[0].Body
+		var sourceBranch string
+		if pullSource != nil {
+			sourceBranch = pullSource.Branch
+		}
+		title, body = describeCommits(formatPatches, sourceBranch)
 	}
 
 	rkey := tid.TID()
@@ -1022,13 +1174,13 @@ func (s *Pulls) createPullRequest(
 	if err != nil {
 		log.Println("failed to create pull request", err)
 		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
-		return
+		return nil
 	}
 	pullId, err := db.NextPullId(tx, f.RepoAt())
 	if err != nil {
 		log.Println("failed to get pull id", err)
 		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
-		return
+		return nil
 	}
 
 	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
@@ -1050,18 +1202,44 @@ func (s *Pulls) createPullRequest(
 	if err != nil {
 		log.Println("failed to create pull request", err)
 		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
-		return
+		return nil
 	}
 
 	if err = tx.Commit(); err != nil {
 		log.Println("failed to create pull request", err)
 		s.pages.Notice(w, "pull", "Failed to create pull request. Try again later.")
-		return
+		return nil
 	}
 
 	s.notifier.NewPull(r.Context(), pull)
+	s.updatePullRefs(r, f, pull)
 
 	s.pages.HxLocation(w, fmt.Sprintf("/%s/pulls/%d", f.OwnerSlashRepo(), pullId))
+	return pull
+}
+
+// updatePullRefs asks the knot to advertise refs/pulls/{id}/head and
+// refs/pulls/{id}/merge for this pull. These are a convenience for
+// reviewers, so failures are only logged.
+func (s *Pulls) updatePullRefs(r *http.Request, f *reporesolver.ResolvedRepo, pull *db.Pull) {
+	client, err := s.oauth.ServiceClient(
+		r,
+		oauth.WithService(f.Knot),
+		oauth.WithLxm(tangled.RepoUpdatePullRefsNSID),
+		oauth.WithDev(s.config.Core.Dev),
+	)
+	if err != nil {
+		log.Printf("failed to connect to knot server: %v", err)
+		return
+	}
+
+	err = tangled.RepoUpdatePullRefs(r.Context(), client, &tangled.RepoUpdatePullRefs_Input{
+		Pull:   pull.PullAt().String(),
+		PullId: int64(pull.PullId),
+	})
+	if err := xrpcclient.HandleXrpcErr(err); err != nil {
+		log.Printf("failed to update pull refs: %v", err)
+	}
 }
 
 func (s *Pulls) createStackedPullRequest(
@@ -1172,14 +1350,15 @@ func (s *Pulls) ValidatePatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	patch := r.FormValue("patch")
-	if patch == "" {
+	patch, patchUrl := r.FormValue("patch"), r.FormValue("patchUrl")
+	if patch == "" && patchUrl == "" {
 		s.pages.Notice(w, "patch-error", "Patch is required.")
 		return
 	}
 
-	if patch == "" || !patchutil.IsPatchValid(patch) {
-		s.pages.Notice(w, "patch-error", "Invalid patch format. Please provide a valid git diff or format-patch.")
+	patch, err = s.submittedPatch(r.Context(), patch, patchUrl)
+	if err != nil {
+		s.pages.Notice(w, "patch-error", patchNotice(err))
 		return
 	}
 
@@ -1211,7 +1390,7 @@ func (s *Pulls) CompareBranchesFragment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, s.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create unsigned client for %s", f.Knot)
 		s.pages.Error503(w)
@@ -1257,6 +1436,11 @@ func (s *Pulls) CompareForksFragment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// only forks of this repo can be compared against it
+	forks = slices.DeleteFunc(forks, func(fork db.Repo) bool {
+		return fork.Source != f.RepoAt().String()
+	})
+
 	s.pages.PullCompareForkFragment(w, pages.PullCompareForkParams{
 		RepoInfo: f.RepoInfo(user),
 		Forks:    forks,
@@ -1284,7 +1468,7 @@ func (s *Pulls) CompareForksBranchesFragment(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	sourceBranchesClient, err := knotclient.NewUnsignedClient(repo.Knot, s.config.Core.Dev)
+	sourceBranchesClient, err := knotclient.NewUnsignedClient(r.Context(), repo.Knot, s.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create unsigned client for %s", repo.Knot)
 		s.pages.Error503(w)
@@ -1297,7 +1481,7 @@ func (s *Pulls) CompareForksBranchesFragment(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	targetBranchesClient, err := knotclient.NewUnsignedClient(f.Knot, s.config.Core.Dev)
+	targetBranchesClient, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create unsigned client for target knot %s", f.Knot)
 		s.pages.Error503(w)
@@ -1380,7 +1564,11 @@ func (s *Pulls) resubmitPatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	patch := r.FormValue("patch")
+	patch, err := s.submittedPatch(r.Context(), r.FormValue("patch"), "")
+	if err != nil {
+		s.pages.Notice(w, "resubmit-error", patchNotice(err))
+		return
+	}
 
 	s.resubmitPullHelper(w, r, f, user, pull, patch, "")
 }
@@ -1413,7 +1601,7 @@ func (s *Pulls) resubmitBranch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ksClient, err := knotclient.NewUnsignedClient(f.Knot, s.config.Core.Dev)
+	ksClient, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, s.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create client for %s: %s", f.Knot, err)
 		s.pages.Notice(w, "resubmit-error", "Failed to create pull request. Try again later.")
@@ -1463,7 +1651,7 @@ func (s *Pulls) resubmitFork(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// extract patch by performing compare
-	ksClient, err := knotclient.NewUnsignedClient(forkRepo.Knot, s.config.Core.Dev)
+	ksClient, err := knotclient.NewUnsignedClient(r.Context(), forkRepo.Knot, s.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create client for %s: %s", forkRepo.Knot, err)
 		s.pages.Notice(w, "resubmit-error", "Failed to create pull request. Try again later.")
@@ -1574,6 +1762,12 @@ func (s *Pulls) resubmitPullHelper(
 		s.pages.Notice(w, "resubmit-error", "Failed to create pull request. Try again later.")
 		return
 	}
+	err = addForcePushEvent(tx, f, user, pull, patch, sourceRev)
+	if err != nil {
+		log.Println("failed to add thread event", err)
+		s.pages.Notice(w, "resubmit-error", "Failed to create pull request. Try again later.")
+		return
+	}
 	client, err := s.oauth.AuthorizedClient(r)
 	if err != nil {
 		log.Println("failed to authorize client")
@@ -1633,9 +1827,49 @@ func (s *Pulls) resubmitPullHelper(
 		return
 	}
 
+	s.updatePullRefs(r, f, pull)
+
 	s.pages.HxLocation(w, fmt.Sprintf("/%s/pulls/%d", f.OwnerSlashRepo(), pull.PullId))
 }
 
+// addForcePushEvent notes on the timeline of pull that the new round dropped
+// commits of the last one, so that reviewers know to look again
+func addForcePushEvent(e db.Execer, f *reporesolver.ResolvedRepo, user *oauth.User, pull *db.Pull, patch, sourceRev string) error {
+	if len(pull.Submissions) == 0 {
+		return nil
+	}
+	last := pull.Submissions[pull.LastRoundNumber()]
+	if !patchutil.Rewrites(last.Patch, patch) {
+		return nil
+	}
+
+	// branches and forks record their heads, the head of a patch is the last
+	// commit in it
+	from, to := last.SourceRev, sourceRev
+	if from == "" || to == "" {
+		from, to = patchHead(last.Patch), patchHead(patch)
+	}
+	if from == "" || to == "" {
+		return nil
+	}
+
+	return db.AddThreadEvent(e, db.ThreadEvent{
+		RepoAt:    f.RepoAt(),
+		SubjectAt: pull.PullAt(),
+		ActorDid:  user.Did,
+		Kind:      db.ThreadEventForcePushed,
+		Value:     from + " " + to,
+	})
+}
+
+func patchHead(patch string) string {
+	patches, err := patchutil.ExtractPatches(patch)
+	if err != nil || len(patches) == 0 {
+		return ""
+	}
+	return patches[len(patches)-1].SHA
+}
+
 func (s *Pulls) resubmitStackedPullHelper(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -1791,6 +2025,13 @@ func (s *Pulls) resubmitStackedPullHelper(
 			return
 		}
 
+		err = addForcePushEvent(tx, f, user, op, submission.Patch, submission.SourceRev)
+		if err != nil {
+			log.Println("failed to add thread event", err, op.PullId)
+			s.pages.Notice(w, "pull-resubmit-error", "Failed to resubmit pull request. Try again later.")
+			return
+		}
+
 		record := op.AsRecord()
 		record.Patch = submission.Patch
 
@@ -1890,6 +2131,8 @@ func (s *Pulls) resubmitStackedPullHelper(
 }
 
 func (s *Pulls) MergePull(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.GetUser(r)
+
 	f, err := s.repoResolver.Resolve(r)
 	if err != nil {
 		log.Println("failed to resolve repo:", err)
@@ -1922,6 +2165,33 @@ func (s *Pulls) MergePull(w http.ResponseWriter, r *http.Request) {
 		pullsToMerge = append(pullsToMerge, mergeable...)
 	}
 
+	settings, requirements, err := s.mergeRequirements(f, pull)
+	if err != nil {
+		log.Println("failed to check merge requirements:", err)
+		s.pages.Notice(w, "pull-merge-error", "Failed to merge pull request. Try again later.")
+		return
+	}
+	var unmet []string
+	for _, req := range requirements {
+		if !req.Met {
+			unmet = append(unmet, req.Description)
+		}
+	}
+	if len(unmet) > 0 {
+		s.pages.Notice(w, "pull-merge-error", "This pull request does not meet the merge requirements of this repository: "+strings.Join(unmet, ", ")+".")
+		return
+	}
+
+	// the first allowed strategy is the default one of a repo
+	strategy := db.MergeStrategy(r.FormValue("strategy"))
+	if strategy == "" {
+		strategy = settings.Strategies[0]
+	}
+	if !settings.Allows(strategy) {
+		s.pages.Notice(w, "pull-merge-error", fmt.Sprintf("Merging with %q is not allowed in this repository.", strategy))
+		return
+	}
+
 	patch := pullsToMerge.CombinedPatch()
 
 	ident, err := s.idResolver.ResolveIdent(r.Context(), pull.OwnerDid)
@@ -1936,14 +2206,22 @@ func (s *Pulls) MergePull(w http.ResponseWriter, r *http.Request) {
 		log.Printf("failed to get primary email: %s", err)
 	}
 
+	// commits the knot makes reference the pull, the ones of a rebased
+	// series have to do so themselves
+	message := pull.Title
+	if settings.RequirePullRef {
+		message = fmt.Sprintf("%s (#%d)", pull.Title, pull.PullId)
+	}
+
 	authorName := ident.Handle.String()
 	mergeInput := &tangled.RepoMerge_Input{
 		Did:           f.OwnerDid(),
 		Name:          f.Name,
 		Branch:        pull.TargetBranch,
 		Patch:         patch,
-		CommitMessage: &pull.Title,
+		CommitMessage: &message,
 		AuthorName:    &authorName,
+		Strategy:      (*string)(&strategy),
 	}
 
 	if pull.Body != "" {
@@ -1987,6 +2265,18 @@ func (s *Pulls) MergePull(w http.ResponseWriter, r *http.Request) {
 			s.pages.Notice(w, "pull-merge-error", "Failed to merge pull request. Try again later.")
 			return
 		}
+
+		err = db.AddThreadEvent(tx, db.ThreadEvent{
+			RepoAt:    f.RepoAt(),
+			SubjectAt: p.PullAt(),
+			ActorDid:  user.Did,
+			Kind:      db.ThreadEventMerged,
+		})
+		if err != nil {
+			log.Printf("failed to add thread event: %s", err)
+			s.pages.Notice(w, "pull-merge-error", "Failed to merge pull request. Try again later.")
+			return
+		}
 	}
 
 	err = tx.Commit()
@@ -1997,9 +2287,96 @@ func (s *Pulls) MergePull(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, p := range pullsToMerge {
+		s.notifier.NewPullMerged(r.Context(), p, user.Did)
+	}
+
 	s.pages.HxLocation(w, fmt.Sprintf("/@%s/%s/pulls/%d", f.OwnerHandle(), f.Name, pull.PullId))
 }
 
+// TogglePullTask checks or unchecks an item of a task list in the body of a
+// pull, for its author
+func (s *Pulls) TogglePullTask(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.GetUser(r)
+
+	f, err := s.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("malformed middleware")
+		return
+	}
+
+	pull, ok := r.Context().Value("pull").(*db.Pull)
+	if !ok {
+		log.Println("failed to get pull")
+		http.Error(w, "pull not found", http.StatusNotFound)
+		return
+	}
+
+	if user.Did != pull.OwnerDid {
+		http.Error(w, "only the author can update tasks", http.StatusUnauthorized)
+		return
+	}
+
+	position, err := strconv.Atoi(r.FormValue("position"))
+	if err != nil {
+		http.Error(w, "bad task position", http.StatusBadRequest)
+		return
+	}
+	checked := r.FormValue("checked") == "true"
+
+	body, err := markup.ToggleTask(pull.Body, position, checked)
+	if err != nil {
+		http.Error(w, "the pull has changed, reload the page and try again", http.StatusConflict)
+		return
+	}
+
+	client, err := s.oauth.AuthorizedClient(r)
+	if err != nil {
+		log.Println("failed to authorize client")
+		http.Error(w, "failed to update pull", http.StatusInternalServerError)
+		return
+	}
+
+	ex, err := client.RepoGetRecord(r.Context(), "", tangled.RepoPullNSID, user.Did, pull.Rkey)
+	if err != nil {
+		log.Println("failed to get pull record", err)
+		http.Error(w, "failed to update pull, no record found on PDS", http.StatusBadGateway)
+		return
+	}
+
+	record, ok := ex.Value.Val.(*tangled.RepoPull)
+	if !ok {
+		log.Println("invalid pull record", pull.Rkey)
+		http.Error(w, "failed to update pull", http.StatusBadGateway)
+		return
+	}
+	record.Body = &body
+
+	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoPullNSID,
+		Repo:       user.Did,
+		Rkey:       pull.Rkey,
+		SwapRecord: ex.Cid,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: record,
+		},
+	})
+	if err != nil {
+		log.Println("failed to update record", err)
+		http.Error(w, "failed to update pull on the PDS", http.StatusBadGateway)
+		return
+	}
+
+	err = db.SetPullBody(s.db, f.RepoAt(), pull.PullId, body)
+	if err != nil {
+		log.Println("failed to update pull body", err)
+		http.Error(w, "failed to update pull", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (s *Pulls) ClosePull(w http.ResponseWriter, r *http.Request) {
 	user := s.oauth.GetUser(r)
 
@@ -2055,6 +2432,18 @@ func (s *Pulls) ClosePull(w http.ResponseWriter, r *http.Request) {
 			s.pages.Notice(w, "pull-close", "Failed to close pull.")
 			return
 		}
+
+		err = db.AddThreadEvent(tx, db.ThreadEvent{
+			RepoAt:    f.RepoAt(),
+			SubjectAt: p.PullAt(),
+			ActorDid:  user.Did,
+			Kind:      db.ThreadEventClosed,
+		})
+		if err != nil {
+			log.Println("failed to add thread event", err)
+			s.pages.Notice(w, "pull-close", "Failed to close pull.")
+			return
+		}
 	}
 
 	// Commit the transaction
@@ -2123,6 +2512,18 @@ func (s *Pulls) ReopenPull(w http.ResponseWriter, r *http.Request) {
 			s.pages.Notice(w, "pull-close", "Failed to close pull.")
 			return
 		}
+
+		err = db.AddThreadEvent(tx, db.ThreadEvent{
+			RepoAt:    f.RepoAt(),
+			SubjectAt: p.PullAt(),
+			ActorDid:  user.Did,
+			Kind:      db.ThreadEventReopened,
+		})
+		if err != nil {
+			log.Println("failed to add thread event", err)
+			s.pages.Notice(w, "pull-reopen", "Failed to reopen pull.")
+			return
+		}
 	}
 
 	// Commit the transaction
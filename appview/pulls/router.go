@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/challenge"
 	"tangled.sh/tangled.sh/core/appview/middleware"
 )
 
@@ -17,12 +18,17 @@ func (s *Pulls) Router(mw *middleware.Middleware) http.Handler {
 		r.Get("/compare-branches", s.CompareBranchesFragment)
 		r.Get("/compare-forks", s.CompareForksFragment)
 		r.Get("/fork-branches", s.CompareForksBranchesFragment)
+		r.Get("/description", s.PullDescriptionFragment)
 		r.Post("/", s.NewPull)
+		r.Post("/cherry-pick", s.CherryPick)
 	})
 
+	r.With(mw.ResolvePull()).Get("/{pull}.json", s.RepoSinglePullJSON)
+
 	r.Route("/{pull}", func(r chi.Router) {
 		r.Use(mw.ResolvePull())
 		r.Get("/", s.RepoSinglePull)
+		r.Get("/opengraph", s.PullOpenGraphImage)
 
 		r.Route("/round/{round}", func(r chi.Router) {
 			r.Get("/", s.RepoPullPatch)
@@ -30,8 +36,13 @@ func (s *Pulls) Router(mw *middleware.Middleware) http.Handler {
 			r.Get("/actions", s.PullActions)
 			r.With(middleware.AuthMiddleware(s.oauth)).Route("/comment", func(r chi.Router) {
 				r.Get("/", s.PullComment)
-				r.Post("/", s.PullComment)
+				r.With(mw.Challenge(challenge.ActionComment)).Post("/", s.PullComment)
+			})
+			r.With(middleware.AuthMiddleware(s.oauth)).Route("/threads", func(r chi.Router) {
+				r.Post("/", s.ResolveThread)
+				r.Delete("/", s.ResolveThread)
 			})
+			r.With(middleware.AuthMiddleware(s.oauth)).Post("/suggestions", s.ApplySuggestions)
 		})
 
 		r.Route("/round/{round}.patch", func(r chi.Router) {
@@ -48,10 +59,13 @@ func (s *Pulls) Router(mw *middleware.Middleware) http.Handler {
 			// it is handled within the route
 			r.Post("/close", s.ClosePull)
 			r.Post("/reopen", s.ReopenPull)
+			r.Post("/tasks", s.TogglePullTask)
 			// collaborators only
 			r.Group(func(r chi.Router) {
 				r.Use(mw.RepoPermissionMiddleware("repo:push"))
 				r.Post("/merge", s.MergePull)
+				r.Get("/backport", s.Backport)
+				r.Post("/backport", s.Backport)
 				// maybe lock, etc.
 			})
 		})
@@ -0,0 +1,240 @@
+package pulls
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+	"tangled.sh/tangled.sh/core/patchutil"
+)
+
+const suggestionsMessage = "Apply suggestions from code review"
+
+// ApplySuggestions makes the changes suggested in inline review comments of
+// the latest round, and submits the result as a new round. Branches and
+// forks get a commit on their head, patches are rewritten or, when they are
+// a series, appended to.
+func (s *Pulls) ApplySuggestions(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.GetUser(r)
+
+	errorId := "review-comment"
+
+	f, err := s.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to resolve repo:", err)
+		s.pages.Notice(w, errorId, "Failed to apply suggestions. Try again later.")
+		return
+	}
+
+	pull, ok := r.Context().Value("pull").(*db.Pull)
+	if !ok {
+		log.Println("failed to get pull")
+		s.pages.Notice(w, errorId, "Failed to apply suggestions. Try again later.")
+		return
+	}
+
+	if user.Did != pull.OwnerDid {
+		s.pages.Notice(w, errorId, "Only the author of a pull request can apply suggestions to it.")
+		return
+	}
+	if pull.State != db.PullOpen {
+		s.pages.Notice(w, errorId, "This pull request is not open.")
+		return
+	}
+	if pull.IsStacked() {
+		s.pages.Notice(w, errorId, "Suggestions cannot be applied to stacked pull requests yet.")
+		return
+	}
+
+	round, err := strconv.Atoi(chi.URLParam(r, "round"))
+	if err != nil || round != pull.LastRoundNumber() {
+		s.pages.Notice(w, errorId, "Only suggestions on the latest round can be applied.")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		s.pages.Notice(w, errorId, "Invalid form.")
+		return
+	}
+	var ids []int
+	for _, v := range r.Form["suggestion"] {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			s.pages.Notice(w, errorId, "Invalid suggestion.")
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		s.pages.Notice(w, errorId, "Pick the suggestions to apply.")
+		return
+	}
+
+	submission := pull.Submissions[round]
+	var suggestions []patchutil.Suggestion
+	var reviewers []string
+	for _, c := range submission.Comments {
+		if !slices.Contains(ids, c.ID) {
+			continue
+		}
+		lines, ok := patchutil.ParseSuggestion(c.Body)
+		if !c.IsInline() || !ok {
+			s.pages.Notice(w, errorId, "That comment does not suggest a change.")
+			return
+		}
+		suggestions = append(suggestions, patchutil.Suggestion{Path: c.Path, Line: int64(c.Line), Lines: lines})
+		if c.OwnerDid != user.Did && !slices.Contains(reviewers, c.OwnerDid) {
+			reviewers = append(reviewers, c.OwnerDid)
+		}
+	}
+	if len(suggestions) != len(ids) {
+		s.pages.Notice(w, errorId, "Some of these suggestions are not on the latest round.")
+		return
+	}
+
+	authorName, authorEmail := s.commitIdentity(r, user.Did)
+	body := s.coAuthoredBy(r, reviewers)
+
+	var patch string
+	switch {
+	case pull.IsPatchBased() && patchutil.IsFormatPatch(submission.Patch):
+		diff, err := patchutil.SuggestionPatch(submission.Patch, suggestions)
+		if err != nil {
+			s.pages.Notice(w, errorId, "Failed to apply suggestions: "+err.Error()+".")
+			return
+		}
+		patch = strings.TrimRight(submission.Patch, "\n") + "\n\n" + formatPatchMail(authorName, authorEmail, body, diff)
+
+	case pull.IsPatchBased():
+		patch, err = patchutil.ApplySuggestions(submission.Patch, suggestions)
+		if err != nil {
+			s.pages.Notice(w, errorId, "Failed to apply suggestions: "+err.Error()+".")
+			return
+		}
+
+	default:
+		diff, err := patchutil.SuggestionPatch(submission.Patch, suggestions)
+		if err != nil {
+			s.pages.Notice(w, errorId, "Failed to apply suggestions: "+err.Error()+".")
+			return
+		}
+
+		// commit onto the head of the pull, where it came from
+		knot, ownerDid, name := f.Knot, f.OwnerDid(), f.Name
+		if pull.IsForkBased() {
+			fork, err := db.GetRepoByAtUri(s.db, pull.PullSource.RepoAt.String())
+			if err != nil {
+				log.Println("failed to get source repo", err)
+				s.pages.Notice(w, errorId, "Failed to apply suggestions. Try again later.")
+				return
+			}
+			knot, ownerDid, name = fork.Knot, fork.Did, fork.Name
+		}
+
+		client, err := s.oauth.ServiceClient(
+			r,
+			oauth.WithService(knot),
+			oauth.WithLxm(tangled.RepoMergeNSID),
+			oauth.WithDev(s.config.Core.Dev),
+		)
+		if err != nil {
+			log.Printf("failed to connect to knot server: %v", err)
+			s.pages.Notice(w, errorId, "Failed to apply suggestions. Try again later.")
+			return
+		}
+
+		message := suggestionsMessage
+		input := &tangled.RepoMerge_Input{
+			Did:           ownerDid,
+			Name:          name,
+			Branch:        pull.PullSource.Branch,
+			Patch:         diff,
+			CommitMessage: &message,
+			AuthorName:    &authorName,
+		}
+		if authorEmail != "" {
+			input.AuthorEmail = &authorEmail
+		}
+		if body != "" {
+			input.CommitBody = &body
+		}
+		err = tangled.RepoMerge(r.Context(), client, input)
+		if err := xrpcclient.HandleXrpcErr(err); err != nil {
+			s.pages.Notice(w, errorId, "Failed to apply suggestions: "+err.Error())
+			return
+		}
+	}
+
+	// the threads of what was applied are done with
+	for _, sg := range suggestions {
+		if err := db.ResolveReviewThread(s.db, submission.ID, sg.Path, int(sg.Line), user.Did); err != nil {
+			log.Println("failed to resolve review thread", err)
+		}
+	}
+
+	switch {
+	case pull.IsBranchBased():
+		s.resubmitBranch(w, r)
+	case pull.IsForkBased():
+		s.resubmitFork(w, r)
+	default:
+		s.resubmitPullHelper(w, r, f, user, pull, patch, "")
+	}
+}
+
+// commitIdentity is who commits made for did are by
+func (s *Pulls) commitIdentity(r *http.Request, did string) (string, string) {
+	name := did
+	if ident, err := s.idResolver.ResolveIdent(r.Context(), did); err == nil {
+		name = ident.Handle.String()
+	}
+
+	email, err := db.GetPrimaryEmail(s.db, did)
+	if err != nil {
+		log.Printf("failed to get primary email: %s", err)
+	}
+
+	return name, email.Address
+}
+
+// coAuthoredBy credits the reviewers whose suggestions were applied, if
+// they have an email to credit
+func (s *Pulls) coAuthoredBy(r *http.Request, reviewers []string) string {
+	var trailers []string
+	for _, did := range reviewers {
+		name, email := s.commitIdentity(r, did)
+		if email != "" {
+			trailers = append(trailers, fmt.Sprintf("Co-authored-by: %s <%s>", name, email))
+		}
+	}
+	return strings.Join(trailers, "\n")
+}
+
+// formatPatchMail wraps diff as one more mail of a format-patch series
+func formatPatchMail(name, email, body, diff string) string {
+	if email == "" {
+		email = name
+	}
+
+	var b strings.Builder
+	b.WriteString("From " + strings.Repeat("0", 40) + " Mon Sep 17 00:00:00 2001\n")
+	fmt.Fprintf(&b, "From: %s <%s>\n", name, email)
+	fmt.Fprintf(&b, "Date: %s\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Subject: [PATCH] %s\n\n", suggestionsMessage)
+	if body != "" {
+		b.WriteString(body + "\n")
+	}
+	b.WriteString("---\n")
+	b.WriteString(diff)
+	b.WriteString("-- \n")
+	return b.String()
+}
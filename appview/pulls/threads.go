@@ -0,0 +1,99 @@
+package pulls
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/patchutil"
+)
+
+// ResolveThread resolves an inline review thread, or unresolves it. The
+// author of the pull, people who can push, and those who commented in the
+// thread can do either.
+func (s *Pulls) ResolveThread(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.GetUser(r)
+
+	errorId := "review-comment"
+
+	f, err := s.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to resolve repo:", err)
+		s.pages.Notice(w, errorId, "Failed to update thread. Try again later.")
+		return
+	}
+
+	pull, ok := r.Context().Value("pull").(*db.Pull)
+	if !ok {
+		log.Println("failed to get pull")
+		s.pages.Notice(w, errorId, "Failed to update thread. Try again later.")
+		return
+	}
+
+	round, err := strconv.Atoi(chi.URLParam(r, "round"))
+	if err != nil || round < 0 || round >= len(pull.Submissions) {
+		http.Error(w, "bad round id", http.StatusBadRequest)
+		return
+	}
+	path := r.FormValue("path")
+	line, err := strconv.Atoi(r.FormValue("line"))
+	if err != nil {
+		http.Error(w, "bad line", http.StatusBadRequest)
+		return
+	}
+
+	threads, err := db.GetReviewThreads(s.db, pull)
+	if err != nil {
+		log.Println("failed to get review threads:", err)
+		s.pages.Notice(w, errorId, "Failed to update thread. Try again later.")
+		return
+	}
+	idx := slices.IndexFunc(threads, func(t db.ReviewThread) bool {
+		return t.Round == round && t.Path == path && t.Line == line
+	})
+	if idx < 0 {
+		http.Error(w, "no such thread", http.StatusNotFound)
+		return
+	}
+	thread := threads[idx]
+
+	allowed := user.Did == pull.OwnerDid ||
+		f.RolesInRepo(user).IsPushAllowed() ||
+		slices.Contains(thread.Participants(), user.Did)
+	if !allowed {
+		s.pages.Notice(w, errorId, "Only the author, reviewers and collaborators can resolve threads.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		err = db.ResolveReviewThread(s.db, thread.SubmissionId, thread.Path, thread.Line, user.Did)
+	case http.MethodDelete:
+		err = db.UnresolveReviewThread(s.db, thread.SubmissionId, thread.Path, thread.Line)
+	}
+	if err != nil {
+		log.Println("failed to update review thread:", err)
+		s.pages.Notice(w, errorId, "Failed to update thread. Try again later.")
+		return
+	}
+
+	s.pages.HxRefresh(w)
+}
+
+// patchHasFile tells whether path is a file changed by patch, that inline
+// comments can be left on
+func patchHasFile(patch, path string) bool {
+	files, err := patchutil.AsDiff(patch)
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		if !f.IsDelete && f.NewName == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/bsky"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/ogcard"
+	"tangled.sh/tangled.sh/core/appview/reporesolver"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+	"tangled.sh/tangled.sh/core/types"
+)
+
+// announceRelease posts a link to the tags page of a repo to the user's
+// bluesky feed
+func (rp *Repo) announceRelease(ctx context.Context, client *xrpcclient.Client, did string, f *reporesolver.ResolvedRepo, tag *types.TagReference) error {
+	repoInfo := f.RepoInfo(nil)
+
+	// the first paragraph of an annotated tag is its title
+	summary, _, _ := strings.Cut(strings.TrimSpace(tag.Tag.Message), "\n\n")
+
+	langs, err := db.GetRepoLanguages(
+		rp.db,
+		db.FilterEq("repo_at", f.RepoAt()),
+		db.FilterEq("is_default_ref", 1),
+	)
+	if err != nil {
+		log.Println("failed to get repo languages", err)
+	}
+
+	link := fmt.Sprintf(
+		"%s/%s/tags#%s",
+		strings.TrimSuffix(rp.config.Core.AppviewHost, "/"),
+		repoInfo.FullName(),
+		url.PathEscape(tag.Name),
+	)
+
+	_, err = bsky.Post(ctx, client, did, bsky.Announcement{
+		Text:        fmt.Sprintf("%s %s is out!", repoInfo.Name, tag.Name),
+		Url:         link,
+		Title:       fmt.Sprintf("%s · %s", tag.Name, repoInfo.FullName()),
+		Description: summary,
+		Card: &ogcard.Card{
+			Eyebrow:     repoInfo.FullName(),
+			Title:       tag.Name,
+			Description: summary,
+			Languages:   languageDetails(langs),
+		},
+	})
+	return err
+}
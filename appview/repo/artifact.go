@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -18,6 +19,7 @@ import (
 	"tangled.sh/tangled.sh/core/appview/pages"
 	"tangled.sh/tangled.sh/core/appview/reporesolver"
 	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/objectstore"
 	"tangled.sh/tangled.sh/core/tid"
 	"tangled.sh/tangled.sh/core/types"
 )
@@ -33,7 +35,7 @@ func (rp *Repo) AttachArtifact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tag, err := rp.resolveTag(f, tagParam)
+	tag, err := rp.resolveTag(r.Context(), f, tagParam)
 	if err != nil {
 		log.Println("failed to resolve tag", err)
 		rp.pages.Notice(w, "upload", "failed to upload artifact, error in tag resolution")
@@ -64,6 +66,18 @@ func (rp *Repo) AttachArtifact(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("uploaded blob", humanize.Bytes(uint64(uploadBlobResp.Blob.Size)), uploadBlobResp.Blob.Ref.String())
 
+	// only the first artifact of a tag is treated as publishing a release
+	existing, err := db.GetArtifact(
+		rp.db,
+		db.FilterEq("repo_at", f.RepoAt()),
+		db.FilterEq("tag", tag.Tag.Hash[:]),
+	)
+	if err != nil {
+		log.Println("failed to get artifacts", err)
+	}
+	release := err == nil && len(existing) == 0
+	announce := r.FormValue("announce") == "on" && release
+
 	rkey := tid.TID()
 	createdAt := time.Now()
 
@@ -123,6 +137,16 @@ func (rp *Repo) AttachArtifact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if release {
+		rp.notifier.NewRelease(r.Context(), &f.Repo, tag)
+	}
+
+	if announce {
+		if err := rp.announceRelease(r.Context(), client, user.Did, f, tag); err != nil {
+			log.Println("failed to announce release", err)
+		}
+	}
+
 	rp.pages.RepoArtifactFragment(w, pages.RepoArtifactParams{
 		LoggedInUser: user,
 		RepoInfo:     f.RepoInfo(user),
@@ -140,7 +164,7 @@ func (rp *Repo) DownloadArtifact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tag, err := rp.resolveTag(f, tagParam)
+	tag, err := rp.resolveTag(r.Context(), f, tagParam)
 	if err != nil {
 		log.Println("failed to resolve tag", err)
 		rp.pages.Notice(w, "upload", "failed to upload artifact, error in tag resolution")
@@ -170,14 +194,16 @@ func (rp *Repo) DownloadArtifact(w http.ResponseWriter, r *http.Request) {
 
 	artifact := artifacts[0]
 
-	getBlobResp, err := client.SyncGetBlob(r.Context(), artifact.BlobCid.String(), artifact.Did)
+	opts := objectstore.URLOptions{
+		ContentDisposition: fmt.Sprintf("attachment; filename=%q", filename),
+	}
+	err = rp.serveBlob(w, r, artifact.Did, artifact.BlobCid, opts, func() ([]byte, error) {
+		return client.SyncGetBlob(r.Context(), artifact.BlobCid.String(), artifact.Did)
+	})
 	if err != nil {
 		log.Println("failed to get blob from pds", err)
 		return
 	}
-
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
-	w.Write(getBlobResp)
 }
 
 // TODO: proper statuses here on early exit
@@ -256,16 +282,18 @@ func (rp *Repo) DeleteArtifact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rp.dropBlob(r.Context(), artifact.BlobCid)
+
 	w.Write([]byte{})
 }
 
-func (rp *Repo) resolveTag(f *reporesolver.ResolvedRepo, tagParam string) (*types.TagReference, error) {
+func (rp *Repo) resolveTag(ctx context.Context, f *reporesolver.ResolvedRepo, tagParam string) (*types.TagReference, error) {
 	tagParam, err := url.QueryUnescape(tagParam)
 	if err != nil {
 		return nil, err
 	}
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, rp.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(ctx, f.Knot, rp.config.Core.Dev)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,246 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/go-chi/chi/v5"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/objectstore"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// maxAttachmentSize matches the maxSize of the attachment blob in the
+// sh.tangled.repo.attachment lexicon
+const maxAttachmentSize = 10 << 20
+
+// inlineAttachmentTypes are served as is, everything else is served as a
+// download so that uploads cannot run scripts on the appview's origin
+var inlineAttachmentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	"video/mp4":  true,
+	"video/webm": true,
+	"text/plain": true,
+}
+
+type attachmentResponse struct {
+	Url      string `json:"url"`
+	Markdown string `json:"markdown"`
+}
+
+// UploadAttachment uploads a file to the PDS of the user, for linking from
+// issues and pulls of this repo. The markdown to link it is returned.
+func (rp *Repo) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "UploadAttachment")
+
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		http.Error(w, "failed to resolve repo", http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "attachments are limited to 10MiB", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize+1))
+	if err != nil {
+		http.Error(w, "failed to read attachment", http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxAttachmentSize {
+		http.Error(w, "attachments are limited to 10MiB", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	name := path.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "attachment"
+	}
+
+	// the same file dropped into another comment links to the attachment
+	// uploaded the first time
+	mh, err := multihash.Sum(data, multihash.SHA2_256, -1)
+	if err != nil {
+		l.Error("failed to hash attachment", "err", err)
+		http.Error(w, "failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+	existing, err := db.GetAttachments(
+		rp.db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("repo_at", f.RepoAt()),
+		db.FilterEq("blob_cid", cid.NewCidV1(cid.Raw, mh).String()),
+	)
+	if err != nil {
+		l.Error("failed to look up attachments", "err", err)
+	}
+	if len(existing) > 0 {
+		rp.writeAttachment(w, f.OwnerDid(), f.Name, existing[0])
+		return
+	}
+
+	client, err := rp.oauth.AuthorizedClient(r)
+	if err != nil {
+		l.Error("failed to get authorized client", "err", err)
+		http.Error(w, "failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	uploadBlobResp, err := client.RepoUploadBlob(r.Context(), bytes.NewReader(data))
+	if err != nil {
+		l.Error("failed to upload blob", "err", err)
+		http.Error(w, "failed to upload attachment to your PDS", http.StatusBadGateway)
+		return
+	}
+
+	rkey := tid.TID()
+	createdAt := time.Now()
+
+	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoAttachmentNSID,
+		Repo:       user.Did,
+		Rkey:       rkey,
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &tangled.RepoAttachment{
+				Attachment: uploadBlobResp.Blob,
+				CreatedAt:  createdAt.Format(time.RFC3339),
+				Name:       name,
+				Repo:       f.RepoAt().String(),
+			},
+		},
+	})
+	if err != nil {
+		l.Error("failed to create record", "err", err)
+		http.Error(w, "failed to create attachment record", http.StatusBadGateway)
+		return
+	}
+
+	mimeType := uploadBlobResp.Blob.MimeType
+	if mimeType == "" || mimeType == "*/*" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	attachment := db.Attachment{
+		Did:       user.Did,
+		Rkey:      rkey,
+		RepoAt:    f.RepoAt(),
+		BlobCid:   cid.Cid(uploadBlobResp.Blob.Ref),
+		Name:      name,
+		Size:      uploadBlobResp.Blob.Size,
+		MimeType:  mimeType,
+		CreatedAt: createdAt,
+	}
+	if err := db.AddAttachment(rp.db, attachment); err != nil {
+		l.Error("failed to add attachment", "err", err)
+		http.Error(w, "failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	rp.writeAttachment(w, f.OwnerDid(), f.Name, attachment)
+}
+
+// writeAttachment responds with the url of an attachment and the markdown
+// linking it
+func (rp *Repo) writeAttachment(w http.ResponseWriter, ownerDid, repoName string, attachment db.Attachment) {
+	url := fmt.Sprintf(
+		"%s/%s/%s/attachments/%s/%s",
+		strings.TrimSuffix(rp.config.Core.AppviewHost, "/"),
+		ownerDid,
+		repoName,
+		attachment.Did,
+		attachment.Rkey,
+	)
+
+	markdown := fmt.Sprintf("[%s](%s)", attachment.Name, url)
+	if strings.HasPrefix(attachment.MimeType, "image/") {
+		markdown = "!" + markdown
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attachmentResponse{
+		Url:      url,
+		Markdown: markdown,
+	})
+}
+
+// ServeAttachment serves an attachment from the PDS of its uploader
+func (rp *Repo) ServeAttachment(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "ServeAttachment")
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		rp.pages.Error404(w)
+		return
+	}
+
+	attachments, err := db.GetAttachments(
+		rp.db,
+		db.FilterEq("did", chi.URLParam(r, "did")),
+		db.FilterEq("rkey", chi.URLParam(r, "rkey")),
+		db.FilterEq("repo_at", f.RepoAt()),
+	)
+	if err != nil || len(attachments) != 1 {
+		rp.pages.Error404(w)
+		return
+	}
+	attachment := attachments[0]
+
+	id, err := rp.idResolver.ResolveIdent(r.Context(), attachment.Did)
+	if err != nil {
+		l.Error("failed to resolve uploader", "err", err)
+		rp.pages.Error503(w)
+		return
+	}
+
+	var opts objectstore.URLOptions
+	mimeType, _, _ := strings.Cut(attachment.MimeType, ";")
+	if inlineAttachmentTypes[mimeType] {
+		opts.ContentType = attachment.MimeType
+		opts.ContentDisposition = fmt.Sprintf("inline; filename=%q", attachment.Name)
+	} else {
+		opts.ContentType = "application/octet-stream"
+		opts.ContentDisposition = fmt.Sprintf("attachment; filename=%q", attachment.Name)
+	}
+
+	err = rp.serveBlob(w, r, attachment.Did, attachment.BlobCid, opts, func() ([]byte, error) {
+		xrpcc := xrpc.Client{
+			Host: id.PDSEndpoint(),
+		}
+		blob, err := comatproto.SyncGetBlob(r.Context(), &xrpcc, attachment.BlobCid.String(), attachment.Did)
+		if err == nil {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("Content-Security-Policy", "sandbox")
+			// blobs are content addressed, they never change
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+		return blob, err
+	})
+	if err != nil {
+		l.Error("failed to get blob from pds", "err", err)
+		rp.pages.Error503(w)
+		return
+	}
+}
@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/bots"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+var botNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,38}$`)
+
+func (rp *Repo) botSettings(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "botSettings")
+
+	f, err := rp.repoResolver.Resolve(r)
+	user := rp.oauth.GetUser(r)
+
+	all, err := db.GetBots(rp.db, db.FilterEq("repo_at", f.RepoAt()))
+	if err != nil {
+		l.Error("failed to get bots", "err", err)
+	}
+
+	rp.pages.RepoBotSettings(w, pages.RepoBotSettingsParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Tabs:         settingsTabs,
+		Tab:          "bots",
+		Bots:         all,
+		Scopes:       db.BotScopes,
+		ApiUrl:       rp.config.Core.AppviewHost + "/api/bot",
+	})
+}
+
+// EditBot adds a bot to a repo and hands out its token, or removes one,
+// which revokes its token
+func (rp *Repo) EditBot(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditBot")
+
+	errorId := "bots-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, errorId, msg)
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later", err)
+		return
+	}
+	user := rp.oauth.GetUser(r)
+
+	switch r.Method {
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			fail("Invalid bot.", err)
+			return
+		}
+
+		if err := db.DeleteBot(rp.db, f.RepoAt(), id); err != nil {
+			fail("Failed to remove bot. Try again later.", err)
+			return
+		}
+
+		rp.pages.HxRefresh(w)
+
+	case http.MethodPut:
+		if err := r.ParseForm(); err != nil {
+			fail("Invalid form.", err)
+			return
+		}
+
+		bot := db.Bot{
+			RepoAt:    f.RepoAt(),
+			Name:      strings.ToLower(strings.TrimSpace(r.FormValue("name"))),
+			CreatedBy: user.Did,
+		}
+		if !botNameRegex.MatchString(bot.Name) {
+			rp.pages.Notice(w, errorId, "Bot names are up to 39 lowercase letters, digits and hyphens.")
+			return
+		}
+		if bot.Ident() == db.RulesActor {
+			rp.pages.Notice(w, errorId, "This name is taken by the rules of the repository.")
+			return
+		}
+
+		for _, scope := range db.BotScopes {
+			if r.FormValue("scope-"+string(scope)) == "on" {
+				bot.Scopes = append(bot.Scopes, scope)
+			}
+		}
+		if len(bot.Scopes) == 0 {
+			rp.pages.Notice(w, errorId, "Choose at least one thing the bot may do.")
+			return
+		}
+
+		if existing, err := db.GetBots(rp.db, db.FilterEq("repo_at", f.RepoAt()), db.FilterEq("name", bot.Name)); err == nil && len(existing) > 0 {
+			rp.pages.Notice(w, errorId, fmt.Sprintf("This repository has a bot named %s already.", bot.Name))
+			return
+		}
+
+		token, hash := bots.NewToken()
+		if err := db.AddBot(rp.db, &bot, hash); err != nil {
+			fail("Failed to add bot. Try again later.", err)
+			return
+		}
+
+		// the token is only ever shown here, the appview keeps its hash
+		rp.pages.Notice(w, "bot-token", fmt.Sprintf("Added %s. Copy its token now, it will not be shown again: %s", bot.Name, token))
+	}
+}
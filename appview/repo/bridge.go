@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/github"
+)
+
+// EditGithubBridge sets up, changes or removes the GitHub bridge of a repo,
+// see issues.Bridge for the syncing itself
+func (rp *Repo) EditGithubBridge(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditGithubBridge")
+
+	errorId := "bridge-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, errorId, msg)
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later", err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		if err := db.DeleteGithubBridge(rp.db, f.RepoAt()); err != nil {
+			fail("Failed to remove bridge. Try again later.", err)
+			return
+		}
+
+	case http.MethodPut:
+		owner, name, err := github.ParseRepo(r.FormValue("github_repo"))
+		if err != nil {
+			fail("Enter a GitHub repository, such as owner/repo.", err)
+			return
+		}
+
+		token := r.FormValue("token")
+		if token == "" {
+			// the token may only be left out when changing an existing bridge
+			if _, err := db.GetGithubBridge(rp.db, f.RepoAt()); err != nil {
+				fail("An access token is required.", err)
+				return
+			}
+		} else {
+			token, err = github.SealToken(rp.config.Github.TokenKey, token)
+			if errors.Is(err, github.ErrNoTokenKey) {
+				fail("GitHub bridges are not enabled on this instance.", err)
+				return
+			}
+			if err != nil {
+				fail("Failed to set up bridge. Try again later.", err)
+				return
+			}
+		}
+
+		err = db.SetGithubBridge(rp.db, db.GithubBridge{
+			RepoAt:     f.RepoAt(),
+			GithubRepo: fmt.Sprintf("%s/%s", owner, name),
+			Token:      token,
+		})
+		if err != nil {
+			fail("Failed to set up bridge. Try again later.", err)
+			return
+		}
+	}
+
+	rp.pages.HxRefresh(w)
+}
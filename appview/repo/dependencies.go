@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"log"
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/types"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// RepoDependencies lists the direct dependencies declared in the manifests
+// found on the default branch
+func (rp *Repo) RepoDependencies(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
+	if err != nil {
+		log.Println("failed to create unsigned client", err)
+		return
+	}
+
+	result, err := us.RepoDependencies(f.OwnerDid(), f.Name, "")
+	if xrpcerr.Is(err, xrpcerr.TagNotFound) {
+		// empty repos have nothing to depend on
+		result = &types.RepoDependenciesResponse{}
+	} else if err != nil {
+		rp.pages.Error503(w)
+		log.Println("failed to reach knotserver", err)
+		return
+	}
+
+	user := rp.oauth.GetUser(r)
+	rp.pages.RepoDependencies(w, pages.RepoDependenciesParams{
+		LoggedInUser:             user,
+		RepoInfo:                 f.RepoInfo(user),
+		RepoDependenciesResponse: *result,
+	})
+}
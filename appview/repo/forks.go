@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"log"
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+// RepoForks lists the forks of a repo, newest first
+func (rp *Repo) RepoForks(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		return
+	}
+
+	forks, err := db.GetRepos(rp.db, 0, db.FilterEq("source", f.RepoAt().String()))
+	if err != nil {
+		log.Println("failed to get forks", err)
+		rp.pages.Error500(w)
+		return
+	}
+
+	rp.pages.RepoForks(w, pages.RepoForksParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Forks:        forks,
+	})
+}
@@ -6,13 +6,17 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	"tangled.sh/tangled.sh/core/appview/commitverify"
 	"tangled.sh/tangled.sh/core/appview/db"
 	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/pages/repoinfo"
 	"tangled.sh/tangled.sh/core/appview/reporesolver"
 	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/scaffold"
 	"tangled.sh/tangled.sh/core/types"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-enry/go-enry/v2"
@@ -27,7 +31,7 @@ func (rp *Repo) RepoIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, rp.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create unsigned client for %s", f.Knot)
 		rp.pages.Error503(w)
@@ -35,7 +39,10 @@ func (rp *Repo) RepoIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := us.Index(f.OwnerDid(), f.Name, ref)
-	if err != nil {
+	if xrpcerr.Is(err, xrpcerr.TagNotFound) {
+		rp.pages.Error404(w)
+		return
+	} else if err != nil {
 		rp.pages.Error503(w)
 		log.Println("failed to reach knotserver", err)
 		return
@@ -118,6 +125,27 @@ func (rp *Repo) RepoIndex(w http.ResponseWriter, r *http.Request) {
 		// non-fatal
 	}
 
+	var recentPush *db.RecentPush
+	if user != nil {
+		recentPush = rp.recentPush(user.Did, repoInfo, result.Branches)
+	}
+
+	summary, err := db.GetRepoSummary(rp.db, f.RepoAt(), 5)
+	if err != nil {
+		log.Printf("failed to fetch repo summary: %s", err)
+		// non-fatal
+	}
+
+	// older knots do not look for a license, fall back to the file listing
+	if result.LicenseFileName == "" {
+		for _, file := range result.Files {
+			if file.IsFile && slices.Contains(scaffold.LicenseFiles, file.Name) {
+				result.LicenseFileName = file.Name
+				break
+			}
+		}
+	}
+
 	rp.pages.RepoIndexPage(w, pages.RepoIndexParams{
 		LoggedInUser:      user,
 		RepoInfo:          repoInfo,
@@ -127,13 +155,79 @@ func (rp *Repo) RepoIndex(w http.ResponseWriter, r *http.Request) {
 		TagsTrunc:         tagsTrunc,
 		// ForkInfo:           forkInfo, // TODO: reinstate this after xrpc properly lands
 		BranchesTrunc:      branchesTrunc,
-		EmailToDidOrHandle: emailToDidOrHandle(rp, emailToDidMap),
+		EmailToDidOrHandle: emailToDidOrHandle(rp, rp.withMailmap(r.Context(), f, result.Ref, signatures(commitsTrunc), emailToDidMap)),
 		VerifiedCommits:    vc,
 		Languages:          languageInfo,
 		Pipelines:          pipelines,
+		Summary:            summary,
+		RecentPush:         recentPush,
 	})
 }
 
+// recentPushWindow is how long a pushed branch is offered for a pull request
+const recentPushWindow = time.Hour
+
+// recentPush finds the latest branch did pushed to that still exists and
+// has no open pull request yet. Pulls from a fork are opened on its source.
+func (rp *Repo) recentPush(did string, repoInfo repoinfo.RepoInfo, branches []types.Branch) *db.RecentPush {
+	pushes, err := db.GetRecentPushes(rp.db, did, repoInfo.RepoAt, time.Now().Add(-recentPushWindow))
+	if err != nil {
+		log.Println("failed to get recent pushes", err)
+		return nil
+	}
+
+	for _, p := range pushes {
+		exists := slices.ContainsFunc(branches, func(b types.Branch) bool {
+			return b.Name == p.Branch && !b.IsDefault
+		})
+		if !exists {
+			continue
+		}
+
+		target, source := repoInfo.RepoAt, ""
+		if repoInfo.Source != nil {
+			target, source = repoInfo.Source.RepoAt(), repoInfo.RepoAt.String()
+		}
+		pulls, err := db.GetPulls(
+			rp.db,
+			db.FilterEq("repo_at", target),
+			db.FilterEq("coalesce(source_repo_at, '')", source),
+			db.FilterEq("source_branch", p.Branch),
+			db.FilterEq("state", db.PullOpen),
+		)
+		if err != nil || len(pulls) > 0 {
+			continue
+		}
+
+		return &p
+	}
+
+	return nil
+}
+
+// DismissRecentPush stops offering a pushed branch for a pull request
+func (rp *Repo) DismissRecentPush(w http.ResponseWriter, r *http.Request) {
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to fully resolve repo", err)
+		return
+	}
+
+	err = db.DeleteRecentPushes(
+		rp.db,
+		db.FilterEq("did", user.Did),
+		db.FilterEq("repo_at", f.RepoAt()),
+		db.FilterEq("branch", r.URL.Query().Get("branch")),
+	)
+	if err != nil {
+		log.Println("failed to dismiss recent push", err)
+	}
+
+	// the prompt is swapped out for nothing
+	w.WriteHeader(http.StatusOK)
+}
+
 func (rp *Repo) getLanguageInfo(
 	f *reporesolver.ResolvedRepo,
 	us *knotclient.UnsignedClient,
@@ -175,6 +269,12 @@ func (rp *Repo) getLanguageInfo(
 		}
 	}
 
+	return languageDetails(langs), nil
+}
+
+// languageDetails converts raw byte counts into percentages, ordered from
+// most to least used with "Other" always last
+func languageDetails(langs []db.RepoLanguage) []types.RepoLanguageDetails {
 	var total int64
 	for _, l := range langs {
 		total += l.Bytes
@@ -204,5 +304,5 @@ func (rp *Repo) getLanguageInfo(
 		return languageStats[i].Name < languageStats[j].Name
 	})
 
-	return languageStats, nil
+	return languageStats
 }
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+	"tangled.sh/tangled.sh/core/scaffold"
+)
+
+// Initialize commits a README, license or .gitignore to an empty repo from
+// its quickstart page
+func (rp *Repo) Initialize(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "Initialize")
+
+	noticeId := "initialize-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, noticeId, msg)
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later.", err)
+		return
+	}
+	l = l.With("repo", f.RepoAt())
+
+	readme := r.FormValue("readme") == "on"
+	gitignore := r.FormValue("gitignore")
+	license := r.FormValue("license")
+	if !readme && gitignore == "" && license == "" {
+		rp.pages.Notice(w, noticeId, "Pick at least one file to add.")
+		return
+	}
+	if err := (scaffold.Options{Gitignore: gitignore, License: license}).Validate(); err != nil {
+		rp.pages.Notice(w, noticeId, err.Error())
+		return
+	}
+
+	client, err := rp.oauth.ServiceClient(
+		r,
+		oauth.WithService(f.Knot),
+		oauth.WithLxm(tangled.RepoInitializeNSID),
+		oauth.WithDev(rp.config.Core.Dev),
+	)
+	if err != nil {
+		fail("Failed to reach the knot. Try again later.", err)
+		return
+	}
+
+	xe := tangled.RepoInitialize(r.Context(), client, &tangled.RepoInitialize_Input{
+		Did:         f.OwnerDid(),
+		Name:        f.Name,
+		Description: &f.Description,
+		Readme:      &readme,
+		Gitignore:   &gitignore,
+		License:     &license,
+	})
+	if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+		l.Error("xrpc error", "xe", xe)
+		rp.pages.Notice(w, noticeId, err.Error())
+		return
+	}
+
+	rp.pages.HxRefresh(w)
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"net/http"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+// trafficDays is how far back the traffic of a repo is shown
+const trafficDays = 30
+
+func (rp *Repo) insightsSettings(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "insightsSettings")
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		return
+	}
+	user := rp.oauth.GetUser(r)
+	repoInfo := f.RepoInfo(user)
+
+	// who visits a repo is for its owner to know only
+	if !repoInfo.Roles.IsOwner() {
+		rp.pages.Error404(w)
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(trafficDays - 1))
+
+	counted, err := db.GetRepoTraffic(rp.db, f.RepoAt(), since)
+	if err != nil {
+		l.Error("failed to get traffic", "err", err)
+	}
+
+	// one entry per day, including the quiet ones
+	byDay := make(map[string]db.RepoTraffic, len(counted))
+	for _, t := range counted {
+		byDay[t.Day.Format(db.TrafficDay)] = t
+	}
+
+	var days []db.RepoTraffic
+	var total db.RepoTraffic
+	var most int
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		t, ok := byDay[day.Format(db.TrafficDay)]
+		if !ok {
+			t = db.RepoTraffic{Day: day}
+		}
+		days = append(days, t)
+
+		total.Views += t.Views
+		total.Visitors += t.Visitors
+		total.Clones += t.Clones
+		most = max(most, t.Views, t.Clones)
+	}
+
+	rp.pages.RepoInsightsSettings(w, pages.RepoInsightsSettingsParams{
+		LoggedInUser: user,
+		RepoInfo:     repoInfo,
+		Tabs:         settingsTabs,
+		Tab:          "insights",
+		Enabled:      rp.config.Traffic.Enabled,
+		Days:         days,
+		Total:        total,
+		Most:         most,
+	})
+}
@@ -0,0 +1,159 @@
+package repo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/integrations"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+func (rp *Repo) integrationSettings(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "integrationSettings")
+
+	f, err := rp.repoResolver.Resolve(r)
+	user := rp.oauth.GetUser(r)
+
+	all, err := db.GetIntegrations(rp.db, db.FilterEq("repo_at", f.RepoAt()))
+	if err != nil {
+		l.Error("failed to get integrations", "err", err)
+	}
+
+	// webhook urls and tokens are secrets, collaborators get to see where
+	// messages go but not how to post there themselves
+	for i := range all {
+		if all[i].Kind != db.IntegrationXMPP {
+			if u, err := url.Parse(all[i].Url); err == nil {
+				all[i].Url = u.Host
+			}
+		}
+		all[i].Token = ""
+	}
+
+	rp.pages.RepoIntegrationSettings(w, pages.RepoIntegrationSettingsParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Tabs:         settingsTabs,
+		Tab:          "integrations",
+		Integrations: all,
+		Events:       integrations.Events,
+		Defaults:     integrations.DefaultTemplates,
+	})
+}
+
+// EditIntegration adds or removes a chat integration of a repo
+func (rp *Repo) EditIntegration(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditIntegration")
+
+	errorId := "integrations-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, errorId, msg)
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later", err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			fail("Invalid integration.", err)
+			return
+		}
+
+		if err := db.DeleteIntegration(rp.db, f.RepoAt(), id); err != nil {
+			fail("Failed to remove integration. Try again later.", err)
+			return
+		}
+
+	case http.MethodPut:
+		if err := r.ParseForm(); err != nil {
+			fail("Invalid form.", err)
+			return
+		}
+
+		integration := db.Integration{
+			RepoAt:    f.RepoAt(),
+			Kind:      db.IntegrationKind(r.FormValue("kind")),
+			Url:       strings.TrimSpace(r.FormValue("url")),
+			Room:      strings.TrimSpace(r.FormValue("room")),
+			Token:     strings.TrimSpace(r.FormValue("token")),
+			Templates: make(map[string]string),
+		}
+
+		for _, event := range integrations.Events {
+			if r.FormValue("event-"+event) == "on" {
+				integration.Events = append(integration.Events, event)
+			}
+			if text := strings.TrimSpace(r.FormValue("template-" + event)); text != "" {
+				integration.Templates[event] = text
+			}
+		}
+
+		if len(integration.Events) == 0 {
+			rp.pages.Notice(w, errorId, "Choose at least one event to post about.")
+			return
+		}
+
+		if err := integrations.Validate(integration, rp.config.Core.Dev); err != nil {
+			rp.pages.Notice(w, errorId, err.Error())
+			return
+		}
+
+		if err := db.AddIntegration(rp.db, integration); err != nil {
+			fail("Failed to add integration. Try again later.", err)
+			return
+		}
+	}
+
+	rp.pages.HxRefresh(w)
+}
+
+// TestIntegration posts a message to an integration so that its owner can
+// check that it is set up right
+func (rp *Repo) TestIntegration(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "TestIntegration")
+
+	noticeId := "integrations-error"
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to resolve repo. Try again later")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		rp.pages.Notice(w, noticeId, "Invalid integration.")
+		return
+	}
+
+	found, err := db.GetIntegrations(rp.db, db.FilterEq("repo_at", f.RepoAt()), db.FilterEq("id", id))
+	if err != nil || len(found) != 1 {
+		l.Error("failed to get integration", "id", id, "err", err)
+		rp.pages.Notice(w, noticeId, "Invalid integration.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	text := "Hello from " + f.OwnerSlashRepo() + " on " + rp.config.Core.AppviewHost
+	if err := integrations.NewSender(rp.config.Core.Dev).Send(ctx, found[0], text); err != nil {
+		l.Error("failed to send test message", "id", id, "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to post a test message: "+err.Error())
+		return
+	}
+
+	rp.pages.Notice(w, noticeId, "Test message sent.")
+}
@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+)
+
+func (rp *Repo) mergeSettings(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "mergeSettings")
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		return
+	}
+	user := rp.oauth.GetUser(r)
+
+	settings, err := db.GetMergeSettings(rp.db, f.RepoAt())
+	if err != nil {
+		l.Error("failed to get merge settings", "err", err)
+	}
+
+	rp.pages.RepoMergeSettings(w, pages.RepoMergeSettingsParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Tabs:         settingsTabs,
+		Tab:          "merging",
+		Settings:     settings,
+		Strategies:   db.MergeStrategies,
+	})
+}
+
+// EditMergeSettings sets what pulls of a repo need before they can be merged,
+// and how they may be merged
+func (rp *Repo) EditMergeSettings(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditMergeSettings")
+
+	errorId := "merging-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, errorId, msg)
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later", err)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		fail("Invalid form.", err)
+		return
+	}
+
+	settings := db.MergeSettings{
+		RepoAt:                f.RepoAt(),
+		BlockChangesRequested: r.FormValue("block_changes_requested") == "on",
+		ResolveThreads:        r.FormValue("resolve_threads") == "on",
+		RequireSignoff:        r.FormValue("require_signoff") == "on",
+		LinearHistory:         r.FormValue("linear_history") == "on",
+		NoMergeCommits:        r.FormValue("no_merge_commits") == "on",
+		RequirePullRef:        r.FormValue("require_pull_ref") == "on",
+	}
+
+	if approvals := r.FormValue("approvals"); approvals != "" {
+		settings.Approvals, err = strconv.Atoi(approvals)
+		if err != nil || settings.Approvals < 0 {
+			rp.pages.Notice(w, errorId, "Required approvals must be a number, zero or more.")
+			return
+		}
+	}
+
+	for _, context := range strings.Split(r.FormValue("contexts"), ",") {
+		if context = strings.TrimSpace(context); context != "" {
+			settings.Contexts = append(settings.Contexts, context)
+		}
+	}
+
+	// the default strategy goes first
+	def := db.MergeStrategy(r.FormValue("default"))
+	for _, s := range r.Form["strategies"] {
+		strategy := db.MergeStrategy(s)
+		if !strategy.IsValid() {
+			rp.pages.Notice(w, errorId, "Unknown merge strategy.")
+			return
+		}
+		if strategy == def {
+			settings.Strategies = append([]db.MergeStrategy{strategy}, settings.Strategies...)
+		} else {
+			settings.Strategies = append(settings.Strategies, strategy)
+		}
+	}
+	if len(settings.Strategies) == 0 {
+		rp.pages.Notice(w, errorId, "Allow at least one merge strategy.")
+		return
+	}
+	if settings.LinearHistory && slices.Contains(settings.Strategies, db.MergeStrategyMerge) {
+		rp.pages.Notice(w, errorId, "Linear history only allows rebasing and squashing.")
+		return
+	}
+
+	// pushes and merges are checked against the policy by the knot
+	client, err := rp.oauth.ServiceClient(
+		r,
+		oauth.WithService(f.Knot),
+		oauth.WithLxm(tangled.RepoSetHistoryPolicyNSID),
+		oauth.WithDev(rp.config.Core.Dev),
+	)
+	if err != nil {
+		fail("Failed to connect to knot server.", err)
+		return
+	}
+	xe := tangled.RepoSetHistoryPolicy(
+		r.Context(),
+		client,
+		&tangled.RepoSetHistoryPolicy_Input{
+			Did:            f.OwnerDid(),
+			Name:           f.Name,
+			NoMergeCommits: &settings.NoMergeCommits,
+			RequirePullRef: &settings.RequirePullRef,
+		},
+	)
+	if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+		l.Error("failed to set history policy", "err", xe)
+		rp.pages.Notice(w, errorId, err.Error())
+		return
+	}
+
+	if err := db.SetMergeSettings(rp.db, settings); err != nil {
+		fail("Failed to save merge settings. Try again later.", err)
+		return
+	}
+
+	rp.pages.HxRefresh(w)
+}
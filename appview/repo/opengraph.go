@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"log"
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/ogcard"
+)
+
+// RepoOpenGraphImage renders the social preview linked from og:image on repo
+// pages
+func (rp *Repo) RepoOpenGraphImage(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	repoInfo := f.RepoInfo(nil)
+
+	// only use what the appview already knows about, unfurlers should not
+	// cause requests to knots
+	langs, err := db.GetRepoLanguages(
+		rp.db,
+		db.FilterEq("repo_at", f.RepoAt()),
+		db.FilterEq("is_default_ref", 1),
+	)
+	if err != nil {
+		log.Println("failed to get repo languages", err)
+	}
+
+	card := ogcard.Card{
+		Eyebrow:     repoInfo.OwnerWithAt(),
+		Title:       repoInfo.Name,
+		Description: repoInfo.Description,
+		Stats: []ogcard.Stat{
+			{Label: "stars", Value: repoInfo.Stats.StarCount},
+			{Label: "issues", Value: repoInfo.Stats.IssueCount.Open},
+			{Label: "pulls", Value: repoInfo.Stats.PullCount.Open},
+		},
+		Languages: languageDetails(langs),
+	}
+
+	card.Serve(w)
+}
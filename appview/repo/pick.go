@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+)
+
+// Revert commits the inverse of a commit to a branch it is on. The revert is
+// authored by whoever asked for it.
+func (rp *Repo) Revert(w http.ResponseWriter, r *http.Request) {
+	rp.pick(w, r, tangled.RepoRevertNSID)
+}
+
+// CherryPick copies a commit onto another branch. Those who may not push
+// open a pull request with the commit instead, see pulls.CherryPick.
+func (rp *Repo) CherryPick(w http.ResponseWriter, r *http.Request) {
+	rp.pick(w, r, tangled.RepoCherryPickNSID)
+}
+
+func (rp *Repo) pick(w http.ResponseWriter, r *http.Request, nsid string) {
+	l := rp.logger.With("handler", "pick", "nsid", nsid)
+
+	noticeId := "pick-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, noticeId, msg)
+	}
+
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later.", err)
+		return
+	}
+
+	commit := chi.URLParam(r, "ref")
+	branch := r.FormValue("branch")
+	if !plumbing.IsHash(commit) || branch == "" {
+		rp.pages.Notice(w, noticeId, "Pick a branch.")
+		return
+	}
+	l = l.With("repo", f.RepoAt(), "commit", commit, "branch", branch)
+
+	client, err := rp.oauth.ServiceClient(
+		r,
+		oauth.WithService(f.Knot),
+		oauth.WithLxm(nsid),
+		oauth.WithDev(rp.config.Core.Dev),
+	)
+	if err != nil {
+		fail("Failed to reach the knot. Try again later.", err)
+		return
+	}
+
+	var newCommit string
+	if nsid == tangled.RepoRevertNSID {
+		input := &tangled.RepoRevert_Input{
+			Did:    f.OwnerDid(),
+			Name:   f.Name,
+			Commit: commit,
+			Branch: branch,
+		}
+		if ident, err := rp.idResolver.ResolveIdent(r.Context(), user.Did); err == nil && !ident.Handle.IsInvalidHandle() {
+			name := ident.Handle.String()
+			input.AuthorName = &name
+		}
+		if email, err := db.GetPrimaryEmail(rp.db, user.Did); err == nil && email.Address != "" {
+			input.AuthorEmail = &email.Address
+		}
+
+		out, xe := tangled.RepoRevert(r.Context(), client, input)
+		if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+			l.Error("xrpc error", "xe", xe)
+			rp.pages.Notice(w, noticeId, err.Error())
+			return
+		}
+		newCommit = out.Commit
+	} else {
+		out, xe := tangled.RepoCherryPick(r.Context(), client, &tangled.RepoCherryPick_Input{
+			Did:    f.OwnerDid(),
+			Name:   f.Name,
+			Commit: commit,
+			Branch: branch,
+		})
+		if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+			l.Error("xrpc error", "xe", xe)
+			rp.pages.Notice(w, noticeId, err.Error())
+			return
+		}
+		newCommit = out.Commit
+	}
+
+	rp.pages.HxLocation(w, fmt.Sprintf("/%s/commit/%s", f.OwnerSlashRepo(), newCommit))
+}
@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+)
+
+// updateRecord edits the sh.tangled.repo record on the PDS of its owner,
+// keeping the fields that update leaves alone. The record is swapped, so an
+// edit made elsewhere in the meantime is not overwritten.
+func updateRecord(ctx context.Context, client *xrpcclient.Client, did, rkey string, update func(*tangled.Repo)) error {
+	ex, err := client.RepoGetRecord(ctx, "", tangled.RepoNSID, did, rkey)
+	if err != nil {
+		return fmt.Errorf("no record found on PDS: %w", err)
+	}
+	record, ok := ex.Value.Val.(*tangled.Repo)
+	if !ok {
+		return fmt.Errorf("unexpected record type %T", ex.Value.Val)
+	}
+
+	update(record)
+
+	_, err = client.RepoPutRecord(ctx, &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoNSID,
+		Repo:       did,
+		Rkey:       rkey,
+		SwapRecord: ex.Cid,
+		Record:     &lexutil.LexiconTypeDecoder{Val: record},
+	})
+	return err
+}
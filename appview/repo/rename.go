@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/state/userutil"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+)
+
+// Rename gives the repo a new name on the knot, in its record and on the
+// appview. Links under the old name keep working through a redirect.
+func (rp *Repo) Rename(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "Rename")
+
+	noticeId := "rename-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, noticeId, msg)
+	}
+
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later.", err)
+		return
+	}
+	l = l.With("repo", f.RepoAt())
+
+	newName := strings.TrimSpace(r.FormValue("name"))
+	if newName == f.Name {
+		return
+	}
+	if err := userutil.ValidateRepoName(newName); err != nil {
+		rp.pages.Notice(w, noticeId, err.Error())
+		return
+	}
+	if existing, err := db.GetRepo(rp.db, user.Did, newName); err == nil && existing != nil {
+		rp.pages.Notice(w, noticeId, fmt.Sprintf("You have a repository named %s already.", newName))
+		return
+	}
+
+	pdsClient, err := rp.oauth.AuthorizedClient(r)
+	if err != nil {
+		fail("Failed to write record to PDS.", err)
+		return
+	}
+
+	rkey := f.Rkey
+	ex, err := pdsClient.RepoGetRecord(r.Context(), "", tangled.RepoNSID, user.Did, rkey)
+	if err != nil {
+		fail("Failed to rename repository, no record found on PDS.", err)
+		return
+	}
+	record, ok := ex.Value.Val.(*tangled.Repo)
+	if !ok {
+		fail("Failed to rename repository, no record found on PDS.", fmt.Errorf("unexpected record type %T", ex.Value.Val))
+		return
+	}
+
+	record.Name = newName
+	out, err := pdsClient.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoNSID,
+		Repo:       user.Did,
+		Rkey:       rkey,
+		SwapRecord: ex.Cid,
+		Record:     &lexutil.LexiconTypeDecoder{Val: record},
+	})
+	if err != nil {
+		fail("Failed to write record to PDS.", err)
+		return
+	}
+
+	// the old name goes back into the record if the knot refuses
+	revertRecord := func() {
+		record.Name = f.Name
+		_, err := pdsClient.RepoPutRecord(context.Background(), &comatproto.RepoPutRecord_Input{
+			Collection: tangled.RepoNSID,
+			Repo:       user.Did,
+			Rkey:       rkey,
+			SwapRecord: &out.Cid,
+			Record:     &lexutil.LexiconTypeDecoder{Val: record},
+		})
+		if err != nil {
+			l.Error("failed to revert record", "err", err)
+		}
+	}
+
+	client, err := rp.oauth.ServiceClient(
+		r,
+		oauth.WithService(f.Knot),
+		oauth.WithLxm(tangled.RepoRenameNSID),
+		oauth.WithDev(rp.config.Core.Dev),
+	)
+	if err != nil {
+		revertRecord()
+		fail("Failed to reach the knot. Try again later.", err)
+		return
+	}
+
+	xe := tangled.RepoRename(r.Context(), client, &tangled.RepoRename_Input{
+		Did:     user.Did,
+		Name:    f.Name,
+		NewName: newName,
+	})
+	if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+		l.Error("xrpc error", "xe", xe)
+		revertRecord()
+		rp.pages.Notice(w, noticeId, err.Error())
+		return
+	}
+
+	// the repo has moved on the knot, from here on failures leave the
+	// appview behind and are only logged
+	tx, err := rp.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fail("Failed to save new name.", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := db.RenameRepo(tx, f.Repo, newName); err != nil {
+		fail("Failed to save new name.", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fail("Failed to save new name.", err)
+		return
+	}
+
+	newRepo := f.Repo
+	newRepo.Name = newName
+	err = rp.enforcer.TransferRepo(user.Did, user.Did, f.Knot, f.DidSlashRepo(), newRepo.DidSlashRepo())
+	if err == nil {
+		err = rp.enforcer.E.SavePolicy()
+	}
+	if err != nil {
+		l.Error("failed to rename repo permissions", "err", err)
+	}
+
+	rp.pages.HxLocation(w, fmt.Sprintf("/@%s/%s/settings", user.Handle, newName))
+}
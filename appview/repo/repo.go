@@ -32,15 +32,19 @@ import (
 	"tangled.sh/tangled.sh/core/eventconsumer"
 	"tangled.sh/tangled.sh/core/idresolver"
 	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/objectstore"
 	"tangled.sh/tangled.sh/core/patchutil"
 	"tangled.sh/tangled.sh/core/rbac"
 	"tangled.sh/tangled.sh/core/tid"
+	"tangled.sh/tangled.sh/core/trailer"
 	"tangled.sh/tangled.sh/core/types"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 	"tangled.sh/tangled.sh/core/xrpc/serviceauth"
 
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 
 	"github.com/bluesky-social/indigo/atproto/syntax"
 )
@@ -57,6 +61,10 @@ type Repo struct {
 	notifier      notify.Notifier
 	logger        *slog.Logger
 	serviceAuth   *serviceauth.ServiceAuth
+
+	// copies of artifacts and attachments, nil when blobs are served from
+	// the PDS of their uploader every time
+	storage objectstore.Store
 }
 
 func New(
@@ -69,6 +77,7 @@ func New(
 	config *config.Config,
 	notifier notify.Notifier,
 	enforcer *rbac.Enforcer,
+	storage objectstore.Store,
 	logger *slog.Logger,
 ) *Repo {
 	return &Repo{oauth: oauth,
@@ -80,10 +89,19 @@ func New(
 		db:            db,
 		notifier:      notifier,
 		enforcer:      enforcer,
+		storage:       storage,
 		logger:        logger,
 	}
 }
 
+// archiveExts are the extensions of the archives knots serve, by the format
+// parameter of a download
+var archiveExts = map[string]string{
+	"zip": ".zip",
+	"zst": ".tar.zst",
+	"xz":  ".tar.xz",
+}
+
 func (rp *Repo) DownloadArchive(w http.ResponseWriter, r *http.Request) {
 	refParam := chi.URLParam(r, "ref")
 	f, err := rp.repoResolver.Resolve(r)
@@ -98,7 +116,14 @@ func (rp *Repo) DownloadArchive(w http.ResponseWriter, r *http.Request) {
 	} else {
 		uri = "https"
 	}
-	url := fmt.Sprintf("%s://%s/%s/%s/archive/%s.tar.gz", uri, f.Knot, f.OwnerDid(), f.Name, url.PathEscape(refParam))
+
+	// the knot picks the kind of archive by its extension
+	ext, ok := archiveExts[r.URL.Query().Get("format")]
+	if !ok {
+		ext = ".tar.gz"
+	}
+
+	url := fmt.Sprintf("%s://%s/%s/%s/archive/%s%s", uri, f.Knot, f.OwnerDid(), f.Name, url.PathEscape(refParam), ext)
 
 	http.Redirect(w, r, url, http.StatusFound)
 }
@@ -119,15 +144,20 @@ func (rp *Repo) RepoLog(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ref := chi.URLParam(r, "ref")
+	// the history of a file or directory, under /commits/{ref}/path
+	treePath := strings.Trim(chi.URLParam(r, "*"), "/")
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, rp.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
 	if err != nil {
 		log.Println("failed to create unsigned client", err)
 		return
 	}
 
-	repolog, err := us.Log(f.OwnerDid(), f.Name, ref, page)
-	if err != nil {
+	repolog, err := us.Log(f.OwnerDid(), f.Name, ref, treePath, page)
+	if xrpcerr.Is(err, xrpcerr.TagNotFound) {
+		rp.pages.Error404(w)
+		return
+	} else if err != nil {
 		rp.pages.Error503(w)
 		log.Println("failed to reach knotserver", err)
 		return
@@ -190,7 +220,7 @@ func (rp *Repo) RepoLog(w http.ResponseWriter, r *http.Request) {
 		TagMap:             tagMap,
 		RepoInfo:           repoInfo,
 		RepoLogResponse:    *repolog,
-		EmailToDidOrHandle: emailToDidOrHandle(rp, emailToDidMap),
+		EmailToDidOrHandle: emailToDidOrHandle(rp, rp.withMailmap(r.Context(), f, ref, signatures(repolog.Commits), emailToDidMap)),
 		VerifiedCommits:    vc,
 		Pipelines:          pipelines,
 	})
@@ -251,35 +281,14 @@ func (rp *Repo) RepoDescription(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// this is a bit of a pain because the golang atproto impl does not allow nil SwapRecord field
-		//
-		// SwapRecord is optional and should happen automagically, but given that it does not, we have to perform two requests
-		ex, err := client.RepoGetRecord(r.Context(), "", tangled.RepoNSID, user.Did, rkey)
-		if err != nil {
-			// failed to get record
-			rp.pages.Notice(w, "repo-notice", "Failed to update description, no record found on PDS.")
-			return
-		}
-		_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
-			Collection: tangled.RepoNSID,
-			Repo:       user.Did,
-			Rkey:       rkey,
-			SwapRecord: ex.Cid,
-			Record: &lexutil.LexiconTypeDecoder{
-				Val: &tangled.Repo{
-					Knot:        f.Knot,
-					Name:        f.Name,
-					Owner:       user.Did,
-					CreatedAt:   f.Created.Format(time.RFC3339),
-					Description: &newDescription,
-					Spindle:     &f.Spindle,
-				},
-			},
+		err = updateRecord(r.Context(), client, user.Did, rkey, func(record *tangled.Repo) {
+			record.Description = &newDescription
+			if newDescription == "" {
+				record.Description = nil
+			}
 		})
-
 		if err != nil {
-			log.Println("failed to perferom update-description query", err)
-			// failed to get record
+			log.Println("failed to update record", err)
 			rp.pages.Notice(w, "repo-notice", "Failed to update description, unable to save to PDS.")
 			return
 		}
@@ -336,7 +345,14 @@ func (rp *Repo) RepoCommit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	emailToDidMap, err := db.GetEmailToDid(rp.db, []string{result.Diff.Commit.Committer.Email, result.Diff.Commit.Author.Email}, true)
+	trailers := trailer.Parse(result.Diff.Commit.Message)
+	sigs := []object.Signature{result.Diff.Commit.Author, result.Diff.Commit.Committer}
+	for _, t := range trailers {
+		sigs = append(sigs, object.Signature{Name: t.Name, Email: t.Email})
+	}
+
+	emails := append([]string{result.Diff.Commit.Committer.Email, result.Diff.Commit.Author.Email}, trailer.Emails(trailers)...)
+	emailToDidMap, err := db.GetEmailToDid(rp.db, emails, true)
 	if err != nil {
 		log.Println("failed to get email to did mapping:", err)
 	}
@@ -358,14 +374,26 @@ func (rp *Repo) RepoCommit(w http.ResponseWriter, r *http.Request) {
 		pipeline = &p
 	}
 
+	// branches to revert on or cherry-pick onto
+	var branches []types.Branch
+	if user != nil {
+		if us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev); err == nil {
+			if bs, err := us.Branches(f.OwnerDid(), f.Name); err == nil {
+				branches = bs.Branches
+			}
+		}
+	}
+
 	rp.pages.RepoCommit(w, pages.RepoCommitParams{
 		LoggedInUser:       user,
 		RepoInfo:           f.RepoInfo(user),
 		RepoCommitResponse: result,
-		EmailToDidOrHandle: emailToDidOrHandle(rp, emailToDidMap),
+		EmailToDidOrHandle: emailToDidOrHandle(rp, rp.withMailmap(r.Context(), f, ref, sigs, emailToDidMap)),
 		VerifiedCommit:     vc,
 		Pipeline:           pipeline,
 		DiffOpts:           diffOpts,
+		Trailers:           trailers,
+		Branches:           branches,
 	})
 }
 
@@ -451,7 +479,7 @@ func (rp *Repo) RepoTags(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, rp.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
 	if err != nil {
 		log.Println("failed to create unsigned client", err)
 		return
@@ -493,12 +521,22 @@ func (rp *Repo) RepoTags(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := rp.oauth.GetUser(r)
+
+	var share db.ShareSettings
+	if user != nil {
+		share, err = db.GetShareSettings(rp.db, user.Did)
+		if err != nil {
+			log.Println("failed to get share settings", err)
+		}
+	}
+
 	rp.pages.RepoTags(w, pages.RepoTagsParams{
 		LoggedInUser:      user,
 		RepoInfo:          f.RepoInfo(user),
 		RepoTagsResponse:  *result,
 		ArtifactMap:       artifactMap,
 		DanglingArtifacts: danglingArtifacts,
+		Announce:          share.AnnounceReleases,
 	})
 }
 
@@ -509,7 +547,7 @@ func (rp *Repo) RepoBranches(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, rp.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
 	if err != nil {
 		log.Println("failed to create unsigned client", err)
 		return
@@ -644,15 +682,18 @@ func (rp *Repo) RepoBlobRaw(w http.ResponseWriter, r *http.Request) {
 
 	blobURL := fmt.Sprintf("%s://%s/%s/%s/raw/%s/%s", protocol, f.Knot, f.OwnerDid(), f.Repo.Name, ref, filePath)
 
-	req, err := http.NewRequest("GET", blobURL, nil)
+	req, err := http.NewRequestWithContext(r.Context(), "GET", blobURL, nil)
 	if err != nil {
 		log.Println("failed to create request", err)
 		return
 	}
 
-	// forward the If-None-Match header
-	if clientETag := r.Header.Get("If-None-Match"); clientETag != "" {
-		req.Header.Set("If-None-Match", clientETag)
+	// forwarded so that videos can be seeked through without downloading
+	// them whole, and unchanged files are not downloaded again
+	for _, h := range []string{"If-None-Match", "If-Range", "Range"} {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
 	}
 
 	client := &http.Client{}
@@ -664,38 +705,32 @@ func (rp *Repo) RepoBlobRaw(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 
-	// forward 304 not modified
-	if resp.StatusCode == http.StatusNotModified {
-		w.WriteHeader(http.StatusNotModified)
-		return
-	}
-
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent, http.StatusNotModified, http.StatusRequestedRangeNotSatisfiable:
+	default:
 		log.Printf("knotserver returned non-OK status for raw blob %s: %d", blobURL, resp.StatusCode)
 		w.WriteHeader(resp.StatusCode)
 		_, _ = io.Copy(w, resp.Body)
 		return
 	}
 
-	contentType := resp.Header.Get("Content-Type")
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("error reading response body from knotserver: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	if strings.Contains(contentType, "text/plain") {
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		w.Write(body)
-	} else if strings.HasPrefix(contentType, "image/") || strings.HasPrefix(contentType, "video/") {
-		w.Header().Set("Content-Type", contentType)
-		w.Write(body)
-	} else {
-		w.WriteHeader(http.StatusUnsupportedMediaType)
-		w.Write([]byte("unsupported content type"))
-		return
+	for _, h := range []string{
+		"Content-Type",
+		"Content-Length",
+		"Content-Range",
+		"Content-Disposition",
+		"Accept-Ranges",
+		"ETag",
+		"Cache-Control",
+		"X-Content-Type-Options",
+		"Content-Security-Policy",
+	} {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
 	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
 }
 
 // modify the spindle configured for this repo
@@ -758,28 +793,9 @@ func (rp *Repo) EditSpindle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ex, err := client.RepoGetRecord(r.Context(), "", tangled.RepoNSID, user.Did, rkey)
-	if err != nil {
-		fail("Failed to update spindle, no record found on PDS.", err)
-		return
-	}
-	_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
-		Collection: tangled.RepoNSID,
-		Repo:       user.Did,
-		Rkey:       rkey,
-		SwapRecord: ex.Cid,
-		Record: &lexutil.LexiconTypeDecoder{
-			Val: &tangled.Repo{
-				Knot:        f.Knot,
-				Name:        f.Name,
-				Owner:       user.Did,
-				CreatedAt:   f.Created.Format(time.RFC3339),
-				Description: &f.Description,
-				Spindle:     spindlePtr,
-			},
-		},
+	err = updateRecord(r.Context(), client, user.Did, rkey, func(record *tangled.Repo) {
+		record.Spindle = spindlePtr
 	})
-
 	if err != nil {
 		fail("Failed to update spindle, unable to save to PDS.", err)
 		return
@@ -1176,6 +1192,12 @@ var (
 		{"Name": "general", "Icon": "sliders-horizontal"},
 		{"Name": "access", "Icon": "users"},
 		{"Name": "pipelines", "Icon": "layers-2"},
+		{"Name": "merging", "Icon": "git-merge"},
+		{"Name": "sites", "Icon": "globe"},
+		{"Name": "integrations", "Icon": "webhook"},
+		{"Name": "bots", "Icon": "bot"},
+		{"Name": "rules", "Icon": "workflow"},
+		{"Name": "insights", "Icon": "chart-line"},
 	}
 )
 
@@ -1194,6 +1216,24 @@ func (rp *Repo) RepoSettings(w http.ResponseWriter, r *http.Request) {
 
 	case "pipelines":
 		rp.pipelineSettings(w, r)
+
+	case "merging":
+		rp.mergeSettings(w, r)
+
+	case "sites":
+		rp.siteSettings(w, r)
+
+	case "integrations":
+		rp.integrationSettings(w, r)
+
+	case "bots":
+		rp.botSettings(w, r)
+
+	case "rules":
+		rp.ruleSettings(w, r)
+
+	case "insights":
+		rp.insightsSettings(w, r)
 	}
 }
 
@@ -1201,7 +1241,7 @@ func (rp *Repo) generalSettings(w http.ResponseWriter, r *http.Request) {
 	f, err := rp.repoResolver.Resolve(r)
 	user := rp.oauth.GetUser(r)
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, rp.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
 	if err != nil {
 		log.Println("failed to create unsigned client", err)
 		return
@@ -1214,12 +1254,31 @@ func (rp *Repo) generalSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	bridge, err := db.GetGithubBridge(rp.db, f.RepoAt())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Println("failed to get github bridge", err)
+	}
+
+	transfer, err := db.GetRepoTransfer(rp.db, f.RepoAt())
+	if err != nil {
+		log.Println("failed to get transfer", err)
+	}
+
+	summary, err := db.GetRepoSummary(rp.db, f.RepoAt(), 0)
+	if err != nil {
+		log.Println("failed to get topics", err)
+	}
+
 	rp.pages.RepoGeneralSettings(w, pages.RepoGeneralSettingsParams{
 		LoggedInUser: user,
 		RepoInfo:     f.RepoInfo(user),
 		Branches:     result.Branches,
 		Tabs:         settingsTabs,
 		Tab:          "general",
+		Bridge:       bridge,
+		Transfer:     transfer,
+		Topics:       summary.Topics,
+		Website:      summary.Website,
 	})
 }
 
@@ -1303,7 +1362,8 @@ func (rp *Repo) pipelineSettings(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rp *Repo) SyncRepoFork(w http.ResponseWriter, r *http.Request) {
-	ref := chi.URLParam(r, "ref")
+	// empty for the default branch
+	ref := r.FormValue("branch")
 
 	user := rp.oauth.GetUser(r)
 	f, err := rp.repoResolver.Resolve(r)
@@ -1321,17 +1381,17 @@ func (rp *Repo) SyncRepoFork(w http.ResponseWriter, r *http.Request) {
 			oauth.WithDev(rp.config.Core.Dev),
 		)
 		if err != nil {
-			rp.pages.Notice(w, "repo", "Failed to connect to knot server.")
+			rp.pages.Notice(w, "fork-sync", "Failed to connect to knot server.")
 			return
 		}
 
 		repoInfo := f.RepoInfo(user)
 		if repoInfo.Source == nil {
-			rp.pages.Notice(w, "repo", "This repository is not a fork.")
+			rp.pages.Notice(w, "fork-sync", "This repository is not a fork.")
 			return
 		}
 
-		err = tangled.RepoForkSync(
+		out, err := tangled.RepoForkSync(
 			r.Context(),
 			client,
 			&tangled.RepoForkSync_Input{
@@ -1342,15 +1402,53 @@ func (rp *Repo) SyncRepoFork(w http.ResponseWriter, r *http.Request) {
 			},
 		)
 		if err := xrpcclient.HandleXrpcErr(err); err != nil {
-			rp.pages.Notice(w, "repo", err.Error())
+			rp.pages.Notice(w, "fork-sync", err.Error())
 			return
 		}
 
-		rp.pages.HxRefresh(w)
+		switch out.Status {
+		case "upToDate":
+			rp.pages.Notice(w, "fork-sync", "Already up to date with upstream.")
+		case "conflict":
+			// leave it to the owner to merge the upstream branch by hand, in
+			// a pull that says how
+			if out.Branch == nil {
+				rp.pages.Notice(w, "fork-sync", "Upstream conflicts with this fork.")
+				return
+			}
+			rp.pages.HxLocation(w, syncConflictPullUrl(f.OwnerSlashRepo(), ref, *out.Branch))
+		default:
+			rp.pages.HxRefresh(w)
+		}
 		return
 	}
 }
 
+// syncConflictPullUrl is where to open a pull that brings upstream, kept on
+// upstreamBranch, into branch of a fork, by hand
+func syncConflictPullUrl(repo, branch, upstreamBranch string) string {
+	target := branch
+	if target == "" {
+		target = strings.TrimPrefix(upstreamBranch, "upstream/")
+	}
+
+	body := fmt.Sprintf(
+		"Upstream changes to %[1]s conflict with this fork, so they could not be synced automatically. "+
+			"They are on %[2]s; merge them by hand, resolve the conflicts and push:\n\n"+
+			"```\ngit fetch origin %[2]s\ngit checkout %[1]s\ngit merge origin/%[2]s\n```\n\n"+
+			"Once pushed, this pull has nothing left to merge and can be closed.",
+		target, upstreamBranch,
+	)
+
+	q := url.Values{}
+	q.Set("strategy", "branch")
+	q.Set("sourceBranch", upstreamBranch)
+	q.Set("targetBranch", target)
+	q.Set("title", fmt.Sprintf("Sync %s with upstream", target))
+	q.Set("body", body)
+	return fmt.Sprintf("/%s/pulls/new?%s", repo, q.Encode())
+}
+
 func (rp *Repo) ForkRepo(w http.ResponseWriter, r *http.Request) {
 	user := rp.oauth.GetUser(r)
 	f, err := rp.repoResolver.Resolve(r)
@@ -1362,7 +1460,7 @@ func (rp *Repo) ForkRepo(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		user := rp.oauth.GetUser(r)
-		knots, err := rp.enforcer.GetKnotsForUser(user.Did)
+		knots, err := rp.enforcer.GetRepoCreateKnots(user.Did)
 		if err != nil {
 			rp.pages.Notice(w, "repo", "Invalid user account.")
 			return
@@ -1581,7 +1679,7 @@ func (rp *Repo) RepoCompareNew(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, rp.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create unsigned client for %s", f.Knot)
 		rp.pages.Error503(w)
@@ -1673,7 +1771,7 @@ func (rp *Repo) RepoCompare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	us, err := knotclient.NewUnsignedClient(f.Knot, rp.config.Core.Dev)
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
 	if err != nil {
 		log.Printf("failed to create unsigned client for %s", f.Knot)
 		rp.pages.Error503(w)
@@ -1700,7 +1798,12 @@ func (rp *Repo) RepoCompare(w http.ResponseWriter, r *http.Request) {
 		log.Println("failed to compare", err)
 		return
 	}
-	diff := patchutil.AsNiceDiff(formatPatch.Patch, base)
+	diff := formatPatch.Diff
+	if diff == nil {
+		// older knots only send patches
+		nd := patchutil.AsNiceDiff(formatPatch.Patch, base)
+		diff = &nd
+	}
 
 	repoinfo := f.RepoInfo(user)
 
@@ -1711,7 +1814,8 @@ func (rp *Repo) RepoCompare(w http.ResponseWriter, r *http.Request) {
 		Tags:         tags.Tags,
 		Base:         base,
 		Head:         head,
-		Diff:         &diff,
+		Diff:         diff,
+		Commits:      formatPatch.Commits,
 		DiffOpts:     diffOpts,
 	})
 
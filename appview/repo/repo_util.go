@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"log"
+	"maps"
 	"math/big"
 	"slices"
 	"sort"
@@ -11,6 +13,9 @@ import (
 
 	"tangled.sh/tangled.sh/core/appview/db"
 	"tangled.sh/tangled.sh/core/appview/pages/repoinfo"
+	"tangled.sh/tangled.sh/core/appview/reporesolver"
+	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/mailmap"
 	"tangled.sh/tangled.sh/core/types"
 
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -63,6 +68,14 @@ func uniqueEmails(commits []*object.Commit) []string {
 	return uniqueEmails
 }
 
+func signatures(commits []*object.Commit) []object.Signature {
+	var sigs []object.Signature
+	for _, commit := range commits {
+		sigs = append(sigs, commit.Author, commit.Committer)
+	}
+	return sigs
+}
+
 func balanceIndexItems(commitCount, branchCount, tagCount, fileCount int) (commitsTrunc int, branchesTrunc int, tagsTrunc int) {
 	if commitCount == 0 && tagCount == 0 && branchCount == 0 {
 		return
@@ -91,6 +104,53 @@ func balanceIndexItems(commitCount, branchCount, tagCount, fileCount int) (commi
 	return
 }
 
+// withMailmap extends an emailToDidMap from db.GetEmailToDid with emails
+// that the repo's .mailmap at ref maps onto someone's verified email. The
+// result stays keyed by the email as it appears on the commit.
+func (rp *Repo) withMailmap(ctx context.Context, f *reporesolver.ResolvedRepo, ref string, sigs []object.Signature, emailToDidMap map[string]string) map[string]string {
+	us, err := knotclient.NewUnsignedClient(ctx, f.Knot, rp.config.Core.Dev)
+	if err != nil {
+		return emailToDidMap
+	}
+
+	data, err := us.Mailmap(f.OwnerDid(), f.Name, ref)
+	if err != nil || data == "" {
+		return emailToDidMap
+	}
+	mm := mailmap.Parse(data)
+
+	canonical := make(map[string]string)
+	var lookup []string
+	for _, sig := range sigs {
+		if _, ok := emailToDidMap[sig.Email]; ok {
+			continue
+		}
+		if _, proper := mm.Resolve(sig.Name, sig.Email); proper != sig.Email {
+			canonical[sig.Email] = proper
+			lookup = append(lookup, proper)
+		}
+	}
+	if len(lookup) == 0 {
+		return emailToDidMap
+	}
+
+	properToDid, err := db.GetEmailToDid(rp.db, lookup, true)
+	if err != nil {
+		log.Println("failed to fetch mailmap email to did mapping", err)
+		return emailToDidMap
+	}
+
+	merged := make(map[string]string, len(emailToDidMap)+len(canonical))
+	maps.Copy(merged, emailToDidMap)
+	for email, proper := range canonical {
+		if did, ok := properToDid[proper]; ok {
+			merged[email] = did
+		}
+	}
+
+	return merged
+}
+
 // emailToDidOrHandle takes an emailToDidMap from db.GetEmailToDid
 // and resolves all dids to handles and returns a new map[string]string
 func emailToDidOrHandle(r *Repo, emailToDidMap map[string]string) map[string]string {
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/challenge"
 	"tangled.sh/tangled.sh/core/appview/middleware"
 )
 
@@ -11,13 +12,18 @@ func (rp *Repo) Router(mw *middleware.Middleware) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", rp.RepoIndex)
 	r.Get("/feed.atom", rp.RepoAtomFeed)
-	r.Get("/commits/{ref}", rp.RepoLog)
+	r.Get("/opengraph", rp.RepoOpenGraphImage)
+	r.Route("/commits/{ref}", func(r chi.Router) {
+		r.Get("/", rp.RepoLog)
+		r.Get("/*", rp.RepoLog)
+	})
 	r.Route("/tree/{ref}", func(r chi.Router) {
 		r.Get("/", rp.RepoIndex)
 		r.Get("/*", rp.RepoTree)
 	})
 	r.Get("/commit/{ref}", rp.RepoCommit)
 	r.Get("/branches", rp.RepoBranches)
+	r.Get("/dependencies", rp.RepoDependencies)
 	r.Route("/tags", func(r chi.Router) {
 		r.Get("/", rp.RepoTags)
 		r.Route("/{tag}", func(r chi.Router) {
@@ -39,19 +45,36 @@ func (rp *Repo) Router(mw *middleware.Middleware) http.Handler {
 	r.Get("/blob/{ref}/*", rp.RepoBlob)
 	r.Get("/raw/{ref}/*", rp.RepoBlobRaw)
 
+	// static site published from a branch, see settings?tab=sites
+	r.Get("/site", rp.ServeSite)
+	r.Get("/site/*", rp.ServeSite)
+
 	// intentionally doesn't use /* as this isn't
 	// a file path
 	r.Get("/archive/{ref}", rp.DownloadArchive)
 
+	// files linked from issues and pulls, stored on the uploader's pds
+	r.Get("/attachments/{did}/{rkey}", rp.ServeAttachment)
+	r.With(middleware.AuthMiddleware(rp.oauth)).Post("/attachments", rp.UploadAttachment)
+
+	r.Get("/forks", rp.RepoForks)
+
 	r.Route("/fork", func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware(rp.oauth))
 		r.Get("/", rp.ForkRepo)
-		r.Post("/", rp.ForkRepo)
+		r.With(mw.Challenge(challenge.ActionRepo)).Post("/", rp.ForkRepo)
 		r.With(mw.RepoPermissionMiddleware("repo:owner")).Route("/sync", func(r chi.Router) {
 			r.Post("/", rp.SyncRepoFork)
 		})
 	})
 
+	r.Route("/transfer", func(r chi.Router) {
+		r.Use(middleware.AuthMiddleware(rp.oauth))
+		r.Get("/", rp.TransferOffer)
+		r.Post("/accept", rp.AcceptTransfer)
+		r.Post("/decline", rp.DeclineTransfer)
+	})
+
 	r.Route("/compare", func(r chi.Router) {
 		r.Get("/", rp.RepoCompareNew) // start an new comparison
 
@@ -73,14 +96,39 @@ func (rp *Repo) Router(mw *middleware.Middleware) http.Handler {
 			r.Get("/", rp.RepoDescription)
 			r.Get("/edit", rp.RepoDescriptionEdit)
 		})
+		// commits a README and friends to an empty repo, see the quickstart
+		r.With(mw.RepoPermissionMiddleware("repo:push")).Post("/initialize", rp.Initialize)
+		r.With(mw.RepoPermissionMiddleware("repo:push")).Post("/commit/{ref}/revert", rp.Revert)
+		r.With(mw.RepoPermissionMiddleware("repo:push")).Post("/commit/{ref}/cherry-pick", rp.CherryPick)
+		r.Delete("/recent-push", rp.DismissRecentPush)
 		r.With(mw.RepoPermissionMiddleware("repo:settings")).Route("/settings", func(r chi.Router) {
 			r.Get("/", rp.RepoSettings)
 			r.With(mw.RepoPermissionMiddleware("repo:owner")).Post("/spindle", rp.EditSpindle)
 			r.With(mw.RepoPermissionMiddleware("repo:invite")).Put("/collaborator", rp.AddCollaborator)
-			r.With(mw.RepoPermissionMiddleware("repo:delete")).Delete("/delete", rp.DeleteRepo)
+			r.With(mw.RepoPermissionMiddleware("repo:delete"), middleware.Sudo(rp.oauth, rp.db)).Delete("/delete", rp.DeleteRepo)
 			r.Put("/branches/default", rp.SetDefaultBranch)
 			r.Put("/secrets", rp.Secrets)
 			r.Delete("/secrets", rp.Secrets)
+			r.Put("/site", rp.EditSite)
+			r.Delete("/site", rp.EditSite)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Put("/integrations", rp.EditIntegration)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Delete("/integrations", rp.EditIntegration)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Post("/integrations/test", rp.TestIntegration)
+			r.With(mw.RepoPermissionMiddleware("repo:owner"), middleware.Sudo(rp.oauth, rp.db)).Put("/bots", rp.EditBot)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Delete("/bots", rp.EditBot)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Put("/merging", rp.EditMergeSettings)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Put("/rules", rp.EditRule)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Delete("/rules", rp.EditRule)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Put("/stale", rp.EditStale)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Delete("/stale", rp.EditStale)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Put("/bridge", rp.EditGithubBridge)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Delete("/bridge", rp.EditGithubBridge)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Post("/takeout", rp.Takeout)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Post("/rename", rp.Rename)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Put("/topics", rp.EditTopics)
+			r.With(mw.RepoPermissionMiddleware("repo:owner")).Put("/website", rp.EditWebsite)
+			r.With(mw.RepoPermissionMiddleware("repo:owner"), middleware.Sudo(rp.oauth, rp.db)).Post("/transfer", rp.Transfer)
+			r.With(mw.RepoPermissionMiddleware("repo:owner"), middleware.Sudo(rp.oauth, rp.db)).Delete("/transfer", rp.Transfer)
 		})
 	})
 
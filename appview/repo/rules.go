@@ -0,0 +1,175 @@
+package repo
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/rules"
+)
+
+func (rp *Repo) ruleSettings(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "ruleSettings")
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		return
+	}
+	user := rp.oauth.GetUser(r)
+
+	all, err := db.GetRules(rp.db, db.FilterEq("repo_at", f.RepoAt()))
+	if err != nil {
+		l.Error("failed to get rules", "err", err)
+	}
+
+	var stale *db.StaleSettings
+	if settings, err := db.GetStaleSettings(rp.db, db.FilterEq("repo_at", f.RepoAt())); err != nil {
+		l.Error("failed to get stale settings", "err", err)
+	} else if len(settings) > 0 {
+		stale = &settings[0]
+	}
+
+	rp.pages.RepoRuleSettings(w, pages.RepoRuleSettingsParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Tabs:         settingsTabs,
+		Tab:          "rules",
+		Rules:        all,
+		Events:       db.RuleEvents,
+		Actions:      db.RuleActions,
+		FilePath:     rules.FilePath,
+		Stale:        stale,
+	})
+}
+
+// EditRule adds a rule to a repo, or removes one. Rules from the rules file
+// are changed by pushing to it instead.
+func (rp *Repo) EditRule(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditRule")
+
+	errorId := "rules-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, errorId, msg)
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later", err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			fail("Invalid rule.", err)
+			return
+		}
+
+		if err := db.DeleteRule(rp.db, f.RepoAt(), id); err != nil {
+			fail("Failed to remove rule. Try again later.", err)
+			return
+		}
+
+		rp.pages.HxRefresh(w)
+
+	case http.MethodPut:
+		if err := r.ParseForm(); err != nil {
+			fail("Invalid form.", err)
+			return
+		}
+
+		rule := db.Rule{
+			RepoAt: f.RepoAt(),
+			Event:  db.RuleEvent(r.FormValue("event")),
+			Action: db.RuleAction(r.FormValue("action")),
+			Value:  strings.TrimSpace(r.FormValue("value")),
+		}
+		// only what the event uses is kept
+		switch rule.Event {
+		case db.RuleEventIssueLabeled, db.RuleEventPullLabeled:
+			rule.Label = strings.TrimSpace(r.FormValue("label"))
+		case db.RuleEventIssueStale:
+			rule.Days, _ = strconv.Atoi(r.FormValue("days"))
+		}
+		if rule.Action == db.RuleActionClose {
+			rule.Value = ""
+		}
+
+		if err := rule.Validate(); err != nil {
+			rp.pages.Notice(w, errorId, "Invalid rule: "+err.Error()+".")
+			return
+		}
+
+		if err := db.AddRule(rp.db, &rule); err != nil {
+			fail("Failed to add rule. Try again later.", err)
+			return
+		}
+
+		rp.pages.HxRefresh(w)
+	}
+}
+
+// EditStale turns the stale triage of a repo on or changes it, or turns it
+// off
+func (rp *Repo) EditStale(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditStale")
+
+	errorId := "stale-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, errorId, msg)
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later", err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		if err := db.DeleteStaleSettings(rp.db, f.RepoAt()); err != nil {
+			fail("Failed to turn off stale triage. Try again later.", err)
+			return
+		}
+
+		rp.pages.HxRefresh(w)
+
+	case http.MethodPut:
+		if err := r.ParseForm(); err != nil {
+			fail("Invalid form.", err)
+			return
+		}
+
+		s := db.StaleSettings{
+			RepoAt:  f.RepoAt(),
+			Label:   strings.TrimSpace(r.FormValue("label")),
+			Comment: strings.TrimSpace(r.FormValue("comment")),
+			Pulls:   r.FormValue("pulls") == "on",
+		}
+		s.Days, _ = strconv.Atoi(r.FormValue("days"))
+		s.Grace, _ = strconv.Atoi(r.FormValue("grace"))
+		for _, label := range strings.Split(r.FormValue("exempt"), ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				s.Exempt = append(s.Exempt, label)
+			}
+		}
+
+		if err := s.Validate(); err != nil {
+			rp.pages.Notice(w, errorId, "Invalid settings: "+err.Error()+".")
+			return
+		}
+
+		if err := db.SetStaleSettings(rp.db, s); err != nil {
+			fail("Failed to save stale triage. Try again later.", err)
+			return
+		}
+
+		rp.pages.HxRefresh(w)
+	}
+}
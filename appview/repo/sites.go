@@ -0,0 +1,211 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/publicnet"
+	"tangled.sh/tangled.sh/core/knotclient"
+)
+
+func (rp *Repo) siteSettings(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	user := rp.oauth.GetUser(r)
+
+	us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
+	if err != nil {
+		log.Println("failed to create unsigned client", err)
+		return
+	}
+
+	result, err := us.Branches(f.OwnerDid(), f.Name)
+	if err != nil {
+		rp.pages.Error503(w)
+		log.Println("failed to reach knotserver", err)
+		return
+	}
+
+	site, err := db.GetSite(rp.db, f.RepoAt())
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Println("failed to get site", err)
+	}
+
+	rp.pages.RepoSiteSettings(w, pages.RepoSiteSettingsParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Tabs:         settingsTabs,
+		Tab:          "sites",
+		Branches:     result.Branches,
+		Site:         site,
+	})
+}
+
+// EditSite enables, reconfigures or disables publishing of a repo's static
+// site. Enabling immediately deploys the current head of the chosen branch,
+// subsequent pushes are picked up from the knot's event stream.
+func (rp *Repo) EditSite(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditSite")
+
+	errorId := "site-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, errorId, msg)
+	}
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later", err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		if err := db.DeleteSite(rp.db, f.RepoAt()); err != nil {
+			fail("Failed to disable site. Try again later.", err)
+			return
+		}
+
+	case http.MethodPut:
+		branch := r.FormValue("branch")
+		if branch == "" {
+			fail("Choose a branch to publish from.", nil)
+			return
+		}
+
+		dir := strings.Trim(path.Clean("/"+r.FormValue("dir")), "/")
+
+		us, err := knotclient.NewUnsignedClient(r.Context(), f.Knot, rp.config.Core.Dev)
+		if err != nil {
+			fail("Failed to connect to knot server.", err)
+			return
+		}
+
+		resp, err := us.Branch(f.OwnerDid(), f.Name, branch)
+		if err != nil {
+			fail("Failed to find branch. Try again later.", err)
+			return
+		}
+
+		err = db.SetSite(rp.db, db.Site{
+			RepoAt:         f.RepoAt(),
+			Branch:         branch,
+			Dir:            dir,
+			DeployedCommit: resp.Branch.Hash,
+		})
+		if err != nil {
+			fail("Failed to publish site. Try again later.", err)
+			return
+		}
+	}
+
+	rp.pages.HxRefresh(w)
+}
+
+// ServeSite serves files from the deployed commit of a repo's static site.
+func (rp *Repo) ServeSite(w http.ResponseWriter, r *http.Request) {
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		log.Println("failed to get repo and knot", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	site, err := db.GetSite(rp.db, f.RepoAt())
+	if err != nil || site.DeployedCommit == "" {
+		rp.pages.Error404(w)
+		return
+	}
+
+	// relative links inside the site only resolve with a trailing slash
+	if !strings.HasSuffix(r.URL.Path, "/") && chi.URLParam(r, "*") == "" {
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	filePath := path.Clean("/" + chi.URLParam(r, "*"))
+	if strings.HasSuffix(r.URL.Path, "/") {
+		filePath = path.Join(filePath, "index.html")
+	}
+
+	resp, err := rp.fetchSiteFile(r.Context(), f.Knot, f.OwnerDid(), f.Name, site, filePath)
+	if err == nil && resp.StatusCode == http.StatusNotFound && path.Ext(filePath) == "" {
+		// try /foo/index.html for /foo
+		resp.Body.Close()
+		filePath = path.Join(filePath, "index.html")
+		resp, err = rp.fetchSiteFile(r.Context(), f.Knot, f.OwnerDid(), f.Name, site, filePath)
+	}
+	if err != nil {
+		log.Println("failed to reach knotserver", err)
+		rp.pages.Error503(w)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		rp.pages.Error404(w)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("knotserver returned non-OK status for site file %s: %d", filePath, resp.StatusCode)
+		w.WriteHeader(resp.StatusCode)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(filePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// sites share an origin with the appview, sandboxing gives scripts an
+	// opaque origin so they cannot touch cookies or make credentialed requests
+	w.Header().Set("Content-Security-Policy", "sandbox allow-scripts allow-forms allow-popups")
+	w.Header().Set("ETag", fmt.Sprintf("\"%s:%s\"", site.DeployedCommit, filePath))
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// siteClient fetches site files off knots, on every page view of a site.
+// knots are named by their owners, so they are only reached on public
+// addresses, except in dev where they run on localhost.
+var (
+	siteClient    = publicnet.Client(publicnet.Dialer(10*time.Second, false), 30*time.Second)
+	devSiteClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+func (rp *Repo) fetchSiteFile(ctx context.Context, knot, did, name string, site *db.Site, filePath string) (*http.Response, error) {
+	protocol := "http"
+	if !rp.config.Core.Dev {
+		protocol = "https"
+	}
+
+	filePath = path.Join(did, name, "site", site.DeployedCommit, site.Dir, filePath)
+	fileURL := fmt.Sprintf(
+		"%s://%s/%s",
+		protocol,
+		knot,
+		(&url.URL{Path: filePath}).EscapedPath(),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rp.config.Core.Dev {
+		return devSiteClient.Do(req)
+	}
+	return siteClient.Do(req)
+}
@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/ipfs/go-cid"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/objectstore"
+)
+
+// blobKey is where the copy of a blob is kept. Blobs are content addressed,
+// so the same file uploaded by several accounts is kept once.
+func blobKey(blobCid cid.Cid) string {
+	return path.Join("blobs", blobCid.String())
+}
+
+// serveBlob redirects to a signed url of the copy of a blob in the object
+// store. Missing there, the blob is fetched from its PDS, served and copied
+// to the store for the downloads to come.
+//
+// With a virus scanner configured, blobs are only served once they were
+// scanned clean.
+func (rp *Repo) serveBlob(w http.ResponseWriter, r *http.Request, did string, blobCid cid.Cid, opts objectstore.URLOptions, fetch func() ([]byte, error)) error {
+	key := blobKey(blobCid)
+	l := rp.logger.With("handler", "serveBlob", "key", key)
+
+	if rp.config.Scan.Enabled() {
+		scan, err := db.GetBlobScan(rp.db, blobCid)
+		if errors.Is(err, sql.ErrNoRows) {
+			// uploaded before scanning was turned on
+			err = db.AddBlobScan(rp.db, did, blobCid)
+			scan = &db.BlobScan{Status: db.BlobScanPending}
+		}
+		if err != nil {
+			return err
+		}
+
+		switch scan.Status {
+		case db.BlobScanClean:
+		case db.BlobScanPending:
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "this file is still being scanned for viruses, try again in a minute", http.StatusServiceUnavailable)
+			return nil
+		default:
+			http.Error(w, "this file was quarantined by the virus scanner", http.StatusForbidden)
+			return nil
+		}
+	}
+
+	if rp.storage != nil {
+		_, err := rp.storage.Stat(r.Context(), key)
+		if err == nil {
+			u, err := rp.storage.SignedURL(r.Context(), key, opts)
+			if err == nil {
+				http.Redirect(w, r, u, http.StatusFound)
+				return nil
+			}
+			l.Error("failed to sign url", "err", err)
+		} else if !errors.Is(err, objectstore.ErrNotFound) {
+			// the PDS still has it
+			l.Error("failed to stat blob", "err", err)
+		}
+	}
+
+	data, err := fetch()
+	if err != nil {
+		return err
+	}
+
+	if rp.storage != nil {
+		err := rp.storage.Put(r.Context(), key, bytes.NewReader(data), int64(len(data)), opts.ContentType)
+		if err != nil {
+			l.Error("failed to store blob", "err", err)
+		}
+	}
+
+	if opts.ContentType != "" {
+		w.Header().Set("Content-Type", opts.ContentType)
+	}
+	if opts.ContentDisposition != "" {
+		w.Header().Set("Content-Disposition", opts.ContentDisposition)
+	}
+	w.Write(data)
+	return nil
+}
+
+// dropBlob removes the copy of a blob from the object store, once no
+// attachment or artifact links to it anymore
+func (rp *Repo) dropBlob(ctx context.Context, blobCid cid.Cid) {
+	if rp.storage == nil {
+		return
+	}
+	l := rp.logger.With("handler", "dropBlob", "cid", blobCid.String())
+
+	refs, err := db.CountBlobReferences(rp.db, blobCid)
+	if err != nil {
+		l.Error("failed to count references", "err", err)
+		return
+	}
+	if refs > 0 {
+		return
+	}
+
+	if err := rp.storage.Delete(ctx, blobKey(blobCid)); err != nil {
+		l.Error("failed to remove blob from object store", "err", err)
+	}
+}
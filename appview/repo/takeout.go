@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/takeout"
+)
+
+// Takeout streams a zip archive of the issues, pulls and stars of a repo along
+// with a git bundle of it. It counts towards the same limit as account
+// takeouts.
+func (rp *Repo) Takeout(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "Takeout")
+
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		http.Error(w, "Failed to resolve repo. Try again later.", http.StatusInternalServerError)
+		return
+	}
+	user := rp.oauth.GetUser(r)
+
+	remaining, err := takeout.Remaining(rp.db, user.Did)
+	if err != nil {
+		l.Error("failed to count takeouts", "err", err)
+		http.Error(w, "Failed to create archive. Try again later.", http.StatusInternalServerError)
+		return
+	}
+	if remaining == 0 {
+		http.Error(w, "You have downloaded too many archives recently. Try again tomorrow.", http.StatusTooManyRequests)
+		return
+	}
+
+	if err := db.AddTakeout(rp.db, user.Did, f.RepoAt().String()); err != nil {
+		l.Error("failed to record takeout", "err", err)
+		http.Error(w, "Failed to create archive. Try again later.", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.zip", f.Name, time.Now().UTC().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// from here on the response is under way, failures can only be logged
+	a := takeout.NewArchive(w)
+	defer a.Close()
+
+	if err := takeout.WriteRepo(a, rp.db, "", f.Repo); err != nil {
+		l.Error("failed to write takeout", "err", err)
+		return
+	}
+
+	collaborators, err := f.Collaborators(r.Context())
+	if err != nil {
+		l.Error("failed to get collaborators", "err", err)
+	} else if err := a.AddJSON("collaborators.json", collaborators); err != nil {
+		l.Error("failed to write takeout", "err", err)
+		return
+	}
+
+	if err := takeout.WriteBundle(r.Context(), a, f.Name+".bundle", f.Repo, rp.config.Core.Dev); err != nil {
+		l.Error("failed to fetch bundle", "err", err)
+	}
+}
@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// EditTopics sets the topics shown on the overview of the repo
+func (rp *Repo) EditTopics(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditTopics")
+
+	noticeId := "topics-error"
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to resolve repo. Try again later.")
+		return
+	}
+
+	topics, ok := db.ParseTopics(r.FormValue("topics"))
+	if !ok {
+		rp.pages.Notice(w, noticeId, fmt.Sprintf(
+			"Use up to %d topics of lowercase letters, digits and dashes.", db.MaxTopics,
+		))
+		return
+	}
+
+	client, err := rp.oauth.AuthorizedClient(r)
+	if err != nil {
+		l.Error("failed to get authorized client", "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to save topics. Try again later.")
+		return
+	}
+
+	if err := db.SetRepoTopics(rp.db, f.RepoAt(), topics); err != nil {
+		l.Error("failed to set topics", "repo", f.RepoAt(), "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to save topics. Try again later.")
+		return
+	}
+
+	// topics travel with the record, for other appviews to show them too
+	err = updateRecord(r.Context(), client, f.OwnerDid(), f.Rkey, func(record *tangled.Repo) {
+		record.Topics = topics
+	})
+	if err != nil {
+		l.Error("failed to update record", "repo", f.RepoAt(), "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to save topics to your PDS.")
+		return
+	}
+
+	rp.pages.HxRefresh(w)
+}
+
+// EditWebsite sets the homepage linked from the overview of the repo
+func (rp *Repo) EditWebsite(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "EditWebsite")
+
+	noticeId := "website-error"
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to resolve repo. Try again later.")
+		return
+	}
+
+	website, ok := db.ParseWebsite(r.FormValue("website"))
+	if !ok {
+		rp.pages.Notice(w, noticeId, "The website has to be a http or https URL.")
+		return
+	}
+
+	client, err := rp.oauth.AuthorizedClient(r)
+	if err != nil {
+		l.Error("failed to get authorized client", "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to save website. Try again later.")
+		return
+	}
+
+	if err := db.SetRepoWebsite(rp.db, f.RepoAt(), website); err != nil {
+		l.Error("failed to set website", "repo", f.RepoAt(), "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to save website. Try again later.")
+		return
+	}
+
+	err = updateRecord(r.Context(), client, f.OwnerDid(), f.Rkey, func(record *tangled.Repo) {
+		record.Website = &website
+		if website == "" {
+			record.Website = nil
+		}
+	})
+	if err != nil {
+		l.Error("failed to update record", "repo", f.RepoAt(), "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to save website to your PDS.")
+		return
+	}
+
+	rp.pages.HxRefresh(w)
+}
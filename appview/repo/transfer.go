@@ -0,0 +1,306 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/xrpcclient"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// Transfer offers the repo to a new owner, or withdraws the offer. Nothing
+// moves until they accept.
+func (rp *Repo) Transfer(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "Transfer")
+
+	noticeId := "transfer-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, noticeId, msg)
+	}
+
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later.", err)
+		return
+	}
+
+	input := &tangled.RepoTransfer_Input{
+		Did:  f.OwnerDid(),
+		Name: f.Name,
+	}
+
+	var newOwner string
+	if r.Method == http.MethodPost {
+		handle := strings.TrimPrefix(strings.TrimSpace(r.FormValue("handle")), "@")
+		if handle == "" {
+			rp.pages.Notice(w, noticeId, "Enter the handle of the new owner.")
+			return
+		}
+
+		id, err := rp.idResolver.ResolveIdent(r.Context(), handle)
+		if err != nil {
+			rp.pages.Notice(w, noticeId, fmt.Sprintf("Could not find %s.", handle))
+			return
+		}
+		newOwner = id.DID.String()
+
+		if newOwner == user.Did {
+			rp.pages.Notice(w, noticeId, "You own this repository already.")
+			return
+		}
+		if existing, err := db.GetRepo(rp.db, newOwner, f.Name); err == nil && existing != nil {
+			rp.pages.Notice(w, noticeId, fmt.Sprintf("%s has a repository by this name already.", handle))
+			return
+		}
+
+		input.NewOwner = &newOwner
+	}
+
+	client, err := rp.oauth.ServiceClient(
+		r,
+		oauth.WithService(f.Knot),
+		oauth.WithLxm(tangled.RepoTransferNSID),
+		oauth.WithDev(rp.config.Core.Dev),
+	)
+	if err != nil {
+		fail("Failed to reach the knot. Try again later.", err)
+		return
+	}
+
+	xe := tangled.RepoTransfer(r.Context(), client, input)
+	if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+		l.Error("xrpc error", "xe", xe)
+		rp.pages.Notice(w, noticeId, err.Error())
+		return
+	}
+
+	if newOwner == "" {
+		err = db.DeleteRepoTransfer(rp.db, f.RepoAt())
+	} else {
+		err = db.SetRepoTransfer(rp.db, db.RepoTransfer{
+			RepoAt:  f.RepoAt(),
+			FromDid: user.Did,
+			ToDid:   newOwner,
+		})
+	}
+	if err != nil {
+		fail("Failed to save transfer. Try again later.", err)
+		return
+	}
+
+	rp.pages.HxRefresh(w)
+}
+
+// pendingTransfer is the transfer of the repo offered to the user, if there
+// is one
+func (rp *Repo) pendingTransfer(r *http.Request) (*db.RepoTransfer, error) {
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := db.GetRepoTransfer(rp.db, f.RepoAt())
+	if err != nil || transfer == nil || transfer.ToDid != user.Did {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// TransferOffer shows the new owner a repo offered to them
+func (rp *Repo) TransferOffer(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "TransferOffer")
+
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		l.Error("failed to resolve repo", "err", err)
+		rp.pages.Error503(w)
+		return
+	}
+
+	transfer, err := rp.pendingTransfer(r)
+	if err != nil {
+		l.Error("failed to get transfer", "err", err)
+	}
+	if transfer == nil {
+		rp.pages.Error404(w)
+		return
+	}
+
+	rp.pages.RepoTransferOffer(w, pages.RepoTransferOfferParams{
+		LoggedInUser: user,
+		RepoInfo:     f.RepoInfo(user),
+		Transfer:     *transfer,
+	})
+}
+
+// DeclineTransfer turns down a repo offered to the user
+func (rp *Repo) DeclineTransfer(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "DeclineTransfer")
+
+	noticeId := "transfer-error"
+
+	transfer, err := rp.pendingTransfer(r)
+	if err != nil || transfer == nil {
+		l.Error("failed to get transfer", "err", err)
+		rp.pages.Notice(w, noticeId, "This repository is not offered to you.")
+		return
+	}
+
+	if err := db.DeleteRepoTransfer(rp.db, transfer.RepoAt); err != nil {
+		l.Error("failed to delete transfer", "err", err)
+		rp.pages.Notice(w, noticeId, "Failed to decline transfer. Try again later.")
+		return
+	}
+
+	rp.pages.HxLocation(w, "/")
+}
+
+// AcceptTransfer takes over a repo offered to the user: the knot moves it
+// under their did, a record for it is written to their PDS, and everything on
+// the appview is moved over to that record. Links under the old owner keep
+// working through a redirect.
+func (rp *Repo) AcceptTransfer(w http.ResponseWriter, r *http.Request) {
+	l := rp.logger.With("handler", "AcceptTransfer")
+
+	noticeId := "transfer-error"
+	fail := func(msg string, err error) {
+		l.Error(msg, "err", err)
+		rp.pages.Notice(w, noticeId, msg)
+	}
+
+	user := rp.oauth.GetUser(r)
+	f, err := rp.repoResolver.Resolve(r)
+	if err != nil {
+		fail("Failed to resolve repo. Try again later.", err)
+		return
+	}
+	l = l.With("repo", f.RepoAt())
+
+	transfer, err := rp.pendingTransfer(r)
+	if err != nil || transfer == nil {
+		fail("This repository is not offered to you.", err)
+		return
+	}
+
+	if existing, err := db.GetRepo(rp.db, user.Did, f.Name); err == nil && existing != nil {
+		rp.pages.Notice(w, noticeId, fmt.Sprintf("You have a repository named %s already.", f.Name))
+		return
+	}
+
+	newRepo := f.Repo
+	newRepo.Did = user.Did
+	newRepo.Rkey = tid.TID()
+
+	record := &tangled.Repo{
+		Knot:      newRepo.Knot,
+		Name:      newRepo.Name,
+		Owner:     user.Did,
+		CreatedAt: newRepo.Created.Format(time.RFC3339),
+	}
+	if newRepo.Description != "" {
+		record.Description = &newRepo.Description
+	}
+	if newRepo.Source != "" {
+		record.Source = &newRepo.Source
+	}
+	if newRepo.Spindle != "" {
+		record.Spindle = &newRepo.Spindle
+	}
+
+	pdsClient, err := rp.oauth.AuthorizedClient(r)
+	if err != nil {
+		fail("Failed to write record to PDS.", err)
+		return
+	}
+
+	_, err = pdsClient.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoNSID,
+		Repo:       user.Did,
+		Rkey:       newRepo.Rkey,
+		Record:     &lexutil.LexiconTypeDecoder{Val: record},
+	})
+	if err != nil {
+		fail("Failed to write record to PDS.", err)
+		return
+	}
+
+	// the record has to go again if the knot refuses
+	deleteRecord := func() {
+		_, err := pdsClient.RepoDeleteRecord(context.Background(), &comatproto.RepoDeleteRecord_Input{
+			Collection: tangled.RepoNSID,
+			Repo:       user.Did,
+			Rkey:       newRepo.Rkey,
+		})
+		if err != nil {
+			l.Error("failed to delete record", "err", err)
+		}
+	}
+
+	client, err := rp.oauth.ServiceClient(
+		r,
+		oauth.WithService(f.Knot),
+		oauth.WithLxm(tangled.RepoAcceptTransferNSID),
+		oauth.WithDev(rp.config.Core.Dev),
+	)
+	if err != nil {
+		deleteRecord()
+		fail("Failed to reach the knot. Try again later.", err)
+		return
+	}
+
+	xe := tangled.RepoAcceptTransfer(r.Context(), client, &tangled.RepoAcceptTransfer_Input{
+		Did:  f.OwnerDid(),
+		Name: f.Name,
+	})
+	if err := xrpcclient.HandleXrpcErr(xe); err != nil {
+		l.Error("xrpc error", "xe", xe)
+		deleteRecord()
+		rp.pages.Notice(w, noticeId, err.Error())
+		return
+	}
+
+	// the repo has moved on the knot, from here on failures leave the
+	// appview behind and are only logged
+	tx, err := rp.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		fail("Failed to save transfer.", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := db.TransferRepo(tx, f.Repo, newRepo); err != nil {
+		fail("Failed to save transfer.", err)
+		return
+	}
+	if err := db.DeleteRepoTransfer(tx, newRepo.RepoAt()); err != nil {
+		fail("Failed to save transfer.", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fail("Failed to save transfer.", err)
+		return
+	}
+
+	err = rp.enforcer.TransferRepo(transfer.FromDid, user.Did, f.Knot, f.DidSlashRepo(), newRepo.DidSlashRepo())
+	if err == nil {
+		err = rp.enforcer.E.SavePolicy()
+	}
+	if err != nil {
+		l.Error("failed to transfer repo permissions", "err", err)
+	}
+
+	rp.pages.HxLocation(w, fmt.Sprintf("/@%s/%s", user.Handle, newRepo.Name))
+}
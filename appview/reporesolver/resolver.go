@@ -155,6 +155,10 @@ func (f *ResolvedRepo) RepoInfo(user *oauth.User) repoinfo.RepoInfo {
 	if err != nil {
 		log.Println("failed to get issue count for ", repoAt)
 	}
+	forkCount, err := db.CountRepos(f.rr.execer, db.FilterEq("source", repoAt.String()))
+	if err != nil {
+		log.Println("failed to get fork count for ", repoAt)
+	}
 	source, err := db.GetRepoSource(f.rr.execer, repoAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		source = ""
@@ -192,6 +196,7 @@ func (f *ResolvedRepo) RepoInfo(user *oauth.User) repoinfo.RepoInfo {
 		Roles:       f.RolesInRepo(user),
 		Stats: db.RepoStats{
 			StarCount:  starCount,
+			ForkCount:  int(forkCount),
 			IssueCount: issueCount,
 			PullCount:  pullCount,
 		},
@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// FilePath is where the rules of a repo are read from, on its default branch
+const FilePath = ".tangled/rules.yml"
+
+// maxFileRules keeps a rules file from setting up more work than is sensible
+const maxFileRules = 50
+
+// a rules file looks like this:
+//
+//	rules:
+//	  - on: issue.labeled
+//	    label: bug
+//	    action: comment
+//	    value: Thanks for the report!
+//	  - on: pull.opened
+//	    action: label
+//	    value: needs-review
+//	  - on: issue.stale
+//	    days: 60
+//	    action: close
+type file struct {
+	Rules []struct {
+		On     string `yaml:"on"`
+		Label  string `yaml:"label"`
+		Days   int    `yaml:"days"`
+		Action string `yaml:"action"`
+		Value  string `yaml:"value"`
+	} `yaml:"rules"`
+}
+
+// Parse reads the rules out of a rules file
+func Parse(data []byte) ([]db.Rule, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if len(f.Rules) > maxFileRules {
+		return nil, fmt.Errorf("at most %d rules are allowed, found %d", maxFileRules, len(f.Rules))
+	}
+
+	var rules []db.Rule
+	for i, fr := range f.Rules {
+		rule := db.Rule{
+			Source: db.RuleSourceFile,
+			Event:  db.RuleEvent(fr.On),
+			Label:  fr.Label,
+			Days:   fr.Days,
+			Action: db.RuleAction(fr.Action),
+			Value:  fr.Value,
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,377 @@
+// Package rules runs the automations of repos: labeling, commenting on and
+// closing issues and pulls when they are opened or labeled, closing issues
+// that went stale, and the stale triage of repos that turned it on.
+//
+// Rules are added in the settings of a repo, or read from the rules file on
+// its default branch whenever that is pushed to. A single background worker
+// handles events one at a time. Whatever rules do is done as RulesActor, and
+// does not set off other rules.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
+	"tangled.sh/tangled.sh/core/knotclient"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+const (
+	// how often labels are picked up from the thread events
+	pollInterval = 10 * time.Second
+	// how often repos are swept for stale issues and pulls
+	sweepInterval = time.Hour
+)
+
+type event struct {
+	kind      db.RuleEvent
+	repoAt    syntax.ATURI
+	subjectAt syntax.ATURI
+	label     string
+
+	// set instead of the above when the rules file may have changed
+	push *tangled.GitRefUpdate
+}
+
+// Engine listens for new issues, pulls and pushes as a notifier, and for
+// labels in the thread events, which also come in from jetstream
+type Engine struct {
+	notify.BaseNotifier
+
+	db       *db.DB
+	config   *config.Config
+	logger   *slog.Logger
+	notifier notify.Notifier
+
+	events chan event
+	cursor int64
+}
+
+var _ notify.Notifier = &Engine{}
+
+func New(d *db.DB, config *config.Config, logger *slog.Logger) *Engine {
+	return &Engine{
+		db:       d,
+		config:   config,
+		logger:   logger,
+		notifier: &notify.BaseNotifier{},
+		events:   make(chan event, 256),
+	}
+}
+
+// Start runs the worker until ctx is done. Comments and closes made by rules
+// are announced to notifier.
+func (e *Engine) Start(ctx context.Context, notifier notify.Notifier) {
+	e.notifier = notifier
+
+	// labels applied before now were acted on by an earlier run, if ever
+	cursor, err := db.LastThreadEventId(e.db)
+	if err != nil {
+		e.logger.Error("failed to get last thread event", "err", err)
+	}
+	e.cursor = cursor
+
+	go func() {
+		poll := time.NewTicker(pollInterval)
+		defer poll.Stop()
+		sweep := time.NewTicker(sweepInterval)
+		defer sweep.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-e.events:
+				e.handle(ctx, ev)
+			case <-poll.C:
+				e.pollLabels(ctx)
+			case <-sweep.C:
+				e.sweep(ctx)
+				e.triage(ctx)
+			}
+		}
+	}()
+}
+
+func (e *Engine) enqueue(ev event) {
+	select {
+	case e.events <- ev:
+	default:
+		e.logger.Warn("rules queue is full, dropping event", "event", ev.kind, "subject", ev.subjectAt)
+	}
+}
+
+func (e *Engine) NewIssue(ctx context.Context, issue *db.Issue) {
+	e.enqueue(event{kind: db.RuleEventIssueOpened, repoAt: issue.RepoAt, subjectAt: issue.AtUri()})
+}
+
+func (e *Engine) NewPull(ctx context.Context, pull *db.Pull) {
+	e.enqueue(event{kind: db.RuleEventPullOpened, repoAt: pull.RepoAt, subjectAt: pull.PullAt()})
+}
+
+func (e *Engine) NewPush(ctx context.Context, update *tangled.GitRefUpdate) {
+	if update.Meta == nil || !update.Meta.IsDefaultRef {
+		return
+	}
+	e.enqueue(event{push: update})
+}
+
+func (e *Engine) handle(ctx context.Context, ev event) {
+	if ev.push != nil {
+		e.syncFile(ctx, ev.push)
+		return
+	}
+
+	rules, err := db.GetRules(e.db, db.FilterEq("repo_at", ev.repoAt), db.FilterEq("event", ev.kind))
+	if err != nil {
+		e.logger.Error("failed to get rules", "repo", ev.repoAt, "err", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if !rule.Matches(ev.kind, ev.label) {
+			continue
+		}
+		if err := e.apply(ctx, rule, ev.subjectAt); err != nil {
+			e.logger.Error("failed to apply rule", "rule", rule.Id, "subject", ev.subjectAt, "err", err)
+		}
+	}
+}
+
+// pollLabels turns the labels applied since the last poll into events
+func (e *Engine) pollLabels(ctx context.Context) {
+	labeled, err := db.GetThreadEvents(
+		e.db,
+		db.FilterGte("id", e.cursor+1),
+		db.FilterEq("kind", db.ThreadEventLabeled),
+	)
+	if err != nil {
+		e.logger.Error("failed to get thread events", "err", err)
+		return
+	}
+
+	for _, te := range labeled {
+		e.cursor = max(e.cursor, te.Id)
+		if te.ActorDid == db.RulesActor {
+			continue
+		}
+
+		kind := db.RuleEventIssueLabeled
+		if te.SubjectAt.Collection().String() == tangled.RepoPullNSID {
+			kind = db.RuleEventPullLabeled
+		}
+		e.handle(ctx, event{kind: kind, repoAt: te.RepoAt, subjectAt: te.SubjectAt, label: te.Value})
+	}
+}
+
+// sweep applies the stale rules of every repo to its stale issues
+func (e *Engine) sweep(ctx context.Context) {
+	rules, err := db.GetRules(e.db, db.FilterEq("event", db.RuleEventIssueStale))
+	if err != nil {
+		e.logger.Error("failed to get stale rules", "err", err)
+		return
+	}
+
+	for _, rule := range rules {
+		before := time.Now().AddDate(0, 0, -rule.Days)
+		ids, err := db.GetStaleIssueIds(e.db, rule.RepoAt, before)
+		if err != nil {
+			e.logger.Error("failed to get stale issues", "repo", rule.RepoAt, "err", err)
+			continue
+		}
+
+		for _, id := range ids {
+			issue, err := db.GetIssue(e.db, rule.RepoAt, id)
+			if err != nil {
+				e.logger.Error("failed to get issue", "repo", rule.RepoAt, "issue", id, "err", err)
+				continue
+			}
+			if err := e.apply(ctx, rule, issue.AtUri()); err != nil {
+				e.logger.Error("failed to apply rule", "rule", rule.Id, "subject", issue.AtUri(), "err", err)
+			}
+		}
+	}
+}
+
+// syncFile reads the rules file of a repo after its default branch moved
+func (e *Engine) syncFile(ctx context.Context, update *tangled.GitRefUpdate) {
+	l := e.logger.With("repo", update.RepoDid+"/"+update.RepoName)
+
+	repo, err := db.GetRepo(e.db, update.RepoDid, update.RepoName)
+	if err != nil {
+		l.Error("failed to get repo", "err", err)
+		return
+	}
+
+	us, err := knotclient.NewUnsignedClient(ctx, repo.Knot, e.config.Core.Dev)
+	if err != nil {
+		l.Error("failed to create knot client", "err", err)
+		return
+	}
+
+	data, err := us.RawFile(repo.Did, repo.Name, update.NewSha, FilePath)
+	if err != nil {
+		l.Error("failed to read rules file", "err", err)
+		return
+	}
+
+	// a broken file keeps the rules of the last good one
+	rules, err := Parse([]byte(data))
+	if err != nil {
+		l.Warn("invalid rules file", "err", err)
+		return
+	}
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		l.Error("failed to start transaction", "err", err)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := db.SetFileRules(tx, repo.RepoAt(), rules); err != nil {
+		l.Error("failed to set rules", "err", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		l.Error("failed to commit rules", "err", err)
+	}
+}
+
+func (e *Engine) apply(ctx context.Context, rule db.Rule, subjectAt syntax.ATURI) error {
+	if subjectAt.Collection().String() == tangled.RepoPullNSID {
+		repoAt, pullId, err := db.ResolvePullFromAtUri(e.db, subjectAt)
+		if err != nil {
+			return err
+		}
+		pull, err := db.GetPull(e.db, repoAt, pullId)
+		if err != nil {
+			return err
+		}
+		return e.applyToPull(ctx, rule, pull)
+	}
+
+	repoAt, issueId, err := db.ResolveIssueFromAtUri(e.db, subjectAt)
+	if err != nil {
+		return err
+	}
+	issue, err := db.GetIssue(e.db, repoAt, issueId)
+	if err != nil {
+		return err
+	}
+	return e.applyToIssue(ctx, rule, issue)
+}
+
+func (e *Engine) applyToIssue(ctx context.Context, rule db.Rule, issue *db.Issue) error {
+	switch rule.Action {
+	case db.RuleActionLabel:
+		return e.label(issue.RepoAt, issue.AtUri(), rule.Value)
+
+	case db.RuleActionComment:
+		createdAt := time.Now()
+		comment := &db.Comment{
+			OwnerDid:  db.RulesActor,
+			RepoAt:    issue.RepoAt,
+			Issue:     issue.IssueId,
+			CommentId: mathrand.IntN(1000000),
+			Body:      rule.Value,
+			Rkey:      tid.TID(),
+			Created:   &createdAt,
+		}
+		if err := db.NewIssueComment(e.db, comment); err != nil {
+			return err
+		}
+		e.notifier.NewIssueComment(ctx, comment)
+
+	case db.RuleActionClose:
+		if !issue.Open {
+			return nil
+		}
+		if err := db.CloseIssue(e.db, issue.RepoAt, issue.IssueId); err != nil {
+			return err
+		}
+		if err := e.event(issue.RepoAt, issue.AtUri(), db.ThreadEventClosed, ""); err != nil {
+			return err
+		}
+		e.notifier.NewIssueClosed(ctx, issue, db.RulesActor)
+	}
+
+	return nil
+}
+
+func (e *Engine) applyToPull(ctx context.Context, rule db.Rule, pull *db.Pull) error {
+	switch rule.Action {
+	case db.RuleActionLabel:
+		return e.label(pull.RepoAt, pull.PullAt(), rule.Value)
+
+	case db.RuleActionComment:
+		comment := &db.PullComment{
+			OwnerDid:     db.RulesActor,
+			RepoAt:       pull.RepoAt.String(),
+			PullId:       pull.PullId,
+			Body:         rule.Value,
+			SubmissionId: pull.Submissions[pull.LastRoundNumber()].ID,
+		}
+		id, err := db.NewPullComment(e.db, comment)
+		if err != nil {
+			return err
+		}
+		comment.ID = int(id)
+		e.notifier.NewPullComment(ctx, comment)
+
+	case db.RuleActionClose:
+		if pull.State != db.PullOpen {
+			return nil
+		}
+		if err := db.ClosePull(e.db, pull.RepoAt, pull.PullId); err != nil {
+			return err
+		}
+		return e.event(pull.RepoAt, pull.PullAt(), db.ThreadEventClosed, "")
+	}
+
+	return nil
+}
+
+// label applies a label that has no record on any PDS, like the comments of
+// bots
+func (e *Engine) label(repoAt, subjectAt syntax.ATURI, name string) error {
+	existing, err := db.GetLabels(e.db, db.FilterEq("subject_at", subjectAt), db.FilterEq("name", name))
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+
+	err = db.AddLabel(e.db, db.Label{
+		OwnerDid:  db.RulesActor,
+		Rkey:      tid.TID(),
+		RepoAt:    repoAt,
+		SubjectAt: subjectAt,
+		Name:      name,
+		Created:   time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add label: %w", err)
+	}
+
+	return e.event(repoAt, subjectAt, db.ThreadEventLabeled, name)
+}
+
+func (e *Engine) event(repoAt, subjectAt syntax.ATURI, kind db.ThreadEventKind, value string) error {
+	return db.AddThreadEvent(e.db, db.ThreadEvent{
+		RepoAt:    repoAt,
+		SubjectAt: subjectAt,
+		ActorDid:  db.RulesActor,
+		Kind:      kind,
+		Value:     value,
+	})
+}
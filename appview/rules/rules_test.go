@@ -0,0 +1,211 @@
+package rules
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+func TestParse(t *testing.T) {
+	rules, err := Parse([]byte(`
+rules:
+  - on: issue.labeled
+    label: bug
+    action: comment
+    value: Thanks for the report!
+  - on: issue.stale
+    days: 60
+    action: close
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].Label != "bug" || rules[1].Days != 60 || rules[1].Action != db.RuleActionClose {
+		t.Errorf("got %+v", rules)
+	}
+
+	for _, bad := range []string{
+		"rules:\n  - on: issue.labeled\n    action: close\n",
+		"rules:\n  - on: issue.assigned\n    action: close\n",
+		"rules:\n  - on: pull.opened\n    action: label\n",
+		"rules: [",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestEngine(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &db.Repo{Did: "did:plc:alice", Name: "core", Knot: "knot.example", Rkey: "3abc"}
+	if err := db.AddRepo(d, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	newIssue := func(rkey string) *db.Issue {
+		tx, err := d.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		issue := &db.Issue{RepoAt: repo.RepoAt(), OwnerDid: "did:plc:bob", Title: "flaky test", Rkey: rkey, Open: true}
+		if err := db.NewIssue(tx, issue); err != nil {
+			t.Fatal(err)
+		}
+		return issue
+	}
+
+	for _, rule := range []db.Rule{
+		{RepoAt: repo.RepoAt(), Event: db.RuleEventIssueOpened, Action: db.RuleActionLabel, Value: "triage"},
+		{RepoAt: repo.RepoAt(), Event: db.RuleEventIssueLabeled, Label: "triage", Action: db.RuleActionComment, Value: "looking into it"},
+		{RepoAt: repo.RepoAt(), Event: db.RuleEventIssueLabeled, Label: "wontfix", Action: db.RuleActionClose},
+		{RepoAt: repo.RepoAt(), Event: db.RuleEventIssueStale, Days: 60, Action: db.RuleActionClose},
+	} {
+		if err := db.AddRule(d, &rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := New(d, &config.Config{}, slog.Default())
+	ctx := context.Background()
+
+	issue := newIssue("3def")
+	e.NewIssue(ctx, issue)
+	e.handle(ctx, <-e.events)
+
+	labels, err := db.GetLabels(d, db.FilterEq("subject_at", issue.AtUri()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 1 || labels[0].Name != "triage" || labels[0].OwnerDid != db.RulesActor {
+		t.Fatalf("expected the triage label, got %+v", labels)
+	}
+
+	// labels applied by rules do not set off other rules
+	e.pollLabels(ctx)
+	comments, err := db.GetComments(d, repo.RepoAt(), issue.IssueId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 0 {
+		t.Errorf("expected no comments, got %d", len(comments))
+	}
+
+	// labels applied by people do
+	err = db.AddThreadEvent(d, db.ThreadEvent{
+		RepoAt:    repo.RepoAt(),
+		SubjectAt: issue.AtUri(),
+		ActorDid:  repo.Did,
+		Kind:      db.ThreadEventLabeled,
+		Value:     "wontfix",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.pollLabels(ctx)
+	if got, _ := db.GetIssue(d, repo.RepoAt(), issue.IssueId); got.Open {
+		t.Error("expected the wontfix issue to be closed")
+	}
+
+	// only issues without activity for long enough are stale
+	old, fresh := newIssue("3ghi"), newIssue("3jkl")
+	if _, err := d.Exec(`update issues set created = '2020-01-01T00:00:00Z' where rkey = ?`, old.Rkey); err != nil {
+		t.Fatal(err)
+	}
+	e.sweep(ctx)
+
+	if got, _ := db.GetIssue(d, repo.RepoAt(), old.IssueId); got.Open {
+		t.Error("expected the stale issue to be closed")
+	}
+	if got, _ := db.GetIssue(d, repo.RepoAt(), fresh.IssueId); !got.Open {
+		t.Error("expected the fresh issue to stay open")
+	}
+}
+
+func TestTriage(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &db.Repo{Did: "did:plc:alice", Name: "core", Knot: "knot.example", Rkey: "3abc"}
+	if err := db.AddRepo(d, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	// every issue is old enough to go stale
+	newIssue := func(rkey string) *db.Issue {
+		tx, err := d.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		issue := &db.Issue{RepoAt: repo.RepoAt(), OwnerDid: "did:plc:bob", Title: "flaky test", Rkey: rkey, Open: true}
+		if err := db.NewIssue(tx, issue); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.Exec(`update issues set created = '2020-01-01T00:00:00Z' where rkey = ?`, rkey); err != nil {
+			t.Fatal(err)
+		}
+		return issue
+	}
+	labels := func(issue *db.Issue) []string {
+		all, err := db.GetLabels(d, db.FilterEq("subject_at", issue.AtUri()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, l := range all {
+			names = append(names, l.Name)
+		}
+		return names
+	}
+
+	forgotten, pinned, revived := newIssue("3def"), newIssue("3ghi"), newIssue("3jkl")
+	err = db.AddLabel(d, db.Label{OwnerDid: repo.Did, Rkey: "3mno", RepoAt: repo.RepoAt(), SubjectAt: pinned.AtUri(), Name: "pinned"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := db.StaleSettings{RepoAt: repo.RepoAt(), Days: 30, Grace: 7, Label: "stale", Exempt: []string{"pinned"}}
+	e := New(d, &config.Config{}, slog.Default())
+	ctx := context.Background()
+
+	if err := e.triageRepo(ctx, s, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := labels(forgotten); len(got) != 1 || got[0] != "stale" {
+		t.Errorf("expected the forgotten issue to be stale, got %v", got)
+	}
+	if got := labels(pinned); len(got) != 1 {
+		t.Errorf("expected the pinned issue to be left alone, got %v", got)
+	}
+	if comments, _ := db.GetComments(d, repo.RepoAt(), forgotten.IssueId); len(comments) != 1 {
+		t.Errorf("expected a warning, got %d comments", len(comments))
+	}
+
+	// someone comes back to one of them
+	later := time.Now().Add(time.Hour)
+	err = db.NewIssueComment(d, &db.Comment{OwnerDid: "did:plc:bob", RepoAt: repo.RepoAt(), Issue: revived.IssueId, CommentId: 1, Body: "still happening", Created: &later})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.triageRepo(ctx, s, time.Now().AddDate(0, 0, 8)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.GetIssue(d, repo.RepoAt(), forgotten.IssueId); got.Open {
+		t.Error("expected the forgotten issue to be closed after the grace period")
+	}
+	if got, _ := db.GetIssue(d, repo.RepoAt(), revived.IssueId); !got.Open || len(labels(revived)) != 0 {
+		t.Errorf("expected the revived issue to be open and no longer stale, got labels %v", labels(revived))
+	}
+}
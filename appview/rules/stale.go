@@ -0,0 +1,115 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// triage marks the issues and pulls nobody touched for a while as stale in
+// every repo that asked for it, and closes the ones that stayed stale for
+// the grace period
+func (e *Engine) triage(ctx context.Context) {
+	all, err := db.GetStaleSettings(e.db)
+	if err != nil {
+		e.logger.Error("failed to get stale settings", "err", err)
+		return
+	}
+
+	for _, s := range all {
+		if err := e.triageRepo(ctx, s, time.Now()); err != nil {
+			e.logger.Error("failed to triage stale items", "repo", s.RepoAt, "err", err)
+		}
+	}
+}
+
+func (e *Engine) triageRepo(ctx context.Context, s db.StaleSettings, now time.Time) error {
+	items, err := db.GetOpenIssueActivity(e.db, s.RepoAt)
+	if err != nil {
+		return err
+	}
+	if s.Pulls {
+		pulls, err := db.GetOpenPullActivity(e.db, s.RepoAt)
+		if err != nil {
+			return err
+		}
+		items = append(items, pulls...)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	var subjects []string
+	for _, item := range items {
+		subjects = append(subjects, item.SubjectAt.String())
+	}
+	labels, err := db.GetLabels(e.db, db.FilterIn("subject_at", subjects))
+	if err != nil {
+		return err
+	}
+	bySubject := make(map[syntax.ATURI][]db.Label)
+	for _, l := range labels {
+		bySubject[l.SubjectAt] = append(bySubject[l.SubjectAt], l)
+	}
+
+	days := time.Duration(s.Days) * 24 * time.Hour
+	grace := time.Duration(s.Grace) * 24 * time.Hour
+
+	for _, item := range items {
+		if s.IsExempt(bySubject[item.SubjectAt]) {
+			continue
+		}
+
+		var stale *db.Label
+		for _, l := range bySubject[item.SubjectAt] {
+			if l.Name == s.Label {
+				stale = &l
+			}
+		}
+
+		switch {
+		case stale == nil:
+			if now.Sub(item.Last) >= days {
+				err = e.markStale(ctx, s, item.SubjectAt)
+			}
+		case stale.OwnerDid != db.RulesActor:
+			// marked stale by someone, which is theirs to undo
+			continue
+		case item.Last.After(stale.Created):
+			err = e.unmarkStale(stale)
+		case now.Sub(stale.Created) >= grace:
+			err = e.apply(ctx, db.Rule{Action: db.RuleActionClose}, item.SubjectAt)
+		}
+		if err != nil {
+			e.logger.Error("failed to triage", "subject", item.SubjectAt, "err", err)
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) markStale(ctx context.Context, s db.StaleSettings, subjectAt syntax.ATURI) error {
+	comment := s.Comment
+	if comment == "" {
+		comment = fmt.Sprintf(
+			"Nothing has happened here for %d days, so this is marked as %s. It will be closed in %d days unless there is some activity.",
+			s.Days, s.Label, s.Grace,
+		)
+	}
+
+	if err := e.apply(ctx, db.Rule{Action: db.RuleActionComment, Value: comment}, subjectAt); err != nil {
+		return err
+	}
+	return e.apply(ctx, db.Rule{Action: db.RuleActionLabel, Value: s.Label}, subjectAt)
+}
+
+// unmarkStale takes the stale label off again once there is activity
+func (e *Engine) unmarkStale(label *db.Label) error {
+	if err := db.DeleteLabelByRkey(e.db, label.OwnerDid, label.Rkey); err != nil {
+		return err
+	}
+	return e.event(label.RepoAt, label.SubjectAt, db.ThreadEventUnlabeled, label.Name)
+}
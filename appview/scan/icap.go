@@ -0,0 +1,111 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httputil"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ICAP scans with an ICAP service (RFC 3507), such as c-icap or a commercial
+// gateway, by handing it the file as the body of a response to modify
+type ICAP struct {
+	url     *url.URL
+	timeout time.Duration
+}
+
+// NewICAP takes the url of the service, e.g. icap://127.0.0.1:1344/avscan
+func NewICAP(rawUrl string, timeout time.Duration) (*ICAP, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "icap" {
+		return nil, fmt.Errorf("icap: unsupported scheme %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "1344")
+	}
+	return &ICAP{url: u, timeout: timeout}, nil
+}
+
+func (c *ICAP) Scan(ctx context.Context, r io.Reader) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.url.Host)
+	if err != nil {
+		return "", fmt.Errorf("connecting to icap service: %w", err)
+	}
+	defer conn.Close()
+	if c.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+
+	resHdr := "HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\n\r\n"
+
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "RESPMOD %s ICAP/1.0\r\n", c.url.String())
+	fmt.Fprintf(w, "Host: %s\r\n", c.url.Host)
+	// a clean file comes back as 204, without echoing it all back
+	fmt.Fprintf(w, "Allow: 204\r\n")
+	fmt.Fprintf(w, "Encapsulated: res-hdr=0, res-body=%d\r\n\r\n", len(resHdr))
+	w.WriteString(resHdr)
+
+	body := httputil.NewChunkedWriter(w)
+	if _, err := io.Copy(body, r); err != nil {
+		return "", err
+	}
+	body.Close()
+	w.WriteString("\r\n")
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	tp := textproto.NewReader(bufio.NewReader(conn))
+	status, err := tp.ReadLine()
+	if err != nil {
+		return "", fmt.Errorf("reading icap response: %w", err)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading icap response: %w", err)
+	}
+
+	proto, status, _ := strings.Cut(status, " ")
+	code, _, _ := strings.Cut(status, " ")
+	if !strings.HasPrefix(proto, "ICAP/") {
+		return "", fmt.Errorf("icap: unexpected response %q", proto)
+	}
+
+	switch code {
+	case "204":
+		return "", nil
+	case "200":
+		return icapThreat(header), nil
+	default:
+		return "", fmt.Errorf("icap: %s", status)
+	}
+}
+
+// icapThreat names the threat in a modified response. Services that modify
+// the response block the file, whether or not they say why.
+func icapThreat(header textproto.MIMEHeader) string {
+	// Type=0; Resolution=2; Threat=Eicar-Signature;
+	if found := header.Get("X-Infection-Found"); found != "" {
+		for field := range strings.SplitSeq(found, ";") {
+			if threat, ok := strings.CutPrefix(strings.TrimSpace(field), "Threat="); ok {
+				return threat
+			}
+		}
+		return found
+	}
+	if found := header.Get("X-Violations-Found"); found != "" {
+		return "policy violation"
+	}
+	return "blocked by the icap service"
+}
@@ -0,0 +1,146 @@
+// Package scan runs the blobs of attachments and release artifacts through a
+// virus scanner, before they can be downloaded off the appview.
+//
+// Blobs are scanned by their cid, so a file uploaded again, by anyone, is
+// not scanned again. Until it is found clean, a blob is not served; infected
+// blobs, and those that could not be scanned, stay quarantined for admins to
+// look at.
+package scan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/ipfs/go-cid"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+// MaxAttempts is how often a blob is tried before it is given up on
+const MaxAttempts = 5
+
+// Scanner scans a file, naming the threat found in it if any
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (threat string, err error)
+}
+
+// New returns the scanner configured, nil when there is none
+func New(cfg config.ScanConfig) (Scanner, error) {
+	switch {
+	case cfg.ClamAVAddr != "":
+		return NewClamAV(cfg.ClamAVAddr, cfg.Timeout), nil
+	case cfg.ICAPUrl != "":
+		return NewICAP(cfg.ICAPUrl, cfg.Timeout)
+	default:
+		return nil, nil
+	}
+}
+
+// Worker scans pending blobs every interval. A nil Worker scans nothing.
+type Worker struct {
+	db      *db.DB
+	scanner Scanner
+	config  config.ScanConfig
+	logger  *slog.Logger
+
+	// fetch gets a blob off the PDS of the account
+	fetch func(ctx context.Context, did string, blobCid cid.Cid) ([]byte, error)
+}
+
+func NewWorker(d *db.DB, cfg config.ScanConfig, res *idresolver.Resolver, logger *slog.Logger) (*Worker, error) {
+	scanner, err := New(cfg)
+	if err != nil || scanner == nil {
+		return nil, err
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+
+	return &Worker{
+		db:      d,
+		scanner: scanner,
+		config:  cfg,
+		logger:  logger,
+		fetch: func(ctx context.Context, did string, blobCid cid.Cid) ([]byte, error) {
+			id, err := res.ResolveIdent(ctx, did)
+			if err != nil {
+				return nil, fmt.Errorf("resolving %s: %w", did, err)
+			}
+			xrpcc := xrpc.Client{
+				Host: id.PDSEndpoint(),
+			}
+			return comatproto.SyncGetBlob(ctx, &xrpcc, blobCid.String(), did)
+		},
+	}, nil
+}
+
+func (w *Worker) Start(ctx context.Context) {
+	if w == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			w.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// run scans a batch of pending blobs, those failing are tried again on the
+// next tick
+func (w *Worker) run(ctx context.Context) {
+	pending, err := db.GetBlobScans(w.db, 50, db.FilterEq("status", db.BlobScanPending))
+	if err != nil {
+		w.logger.Error("failed to get pending scans", "err", err)
+		return
+	}
+
+	for _, s := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+
+		l := w.logger.With("cid", s.Cid.String(), "did", s.Did)
+		if err := w.scan(ctx, s); err != nil {
+			l.Error("failed to scan blob", "err", err, "attempts", s.Attempts+1)
+			if err := db.AddBlobScanAttempt(w.db, s.Cid, MaxAttempts); err != nil {
+				l.Error("failed to count attempt", "err", err)
+			}
+		}
+	}
+}
+
+func (w *Worker) scan(ctx context.Context, s db.BlobScan) error {
+	data, err := w.fetch(ctx, s.Did, s.Cid)
+	if err != nil {
+		return fmt.Errorf("fetching blob: %w", err)
+	}
+
+	threat, err := w.scanner.Scan(ctx, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	status := db.BlobScanClean
+	if threat != "" {
+		status = db.BlobScanInfected
+		w.logger.Warn("quarantined blob", "cid", s.Cid.String(), "did", s.Did, "threat", threat)
+	}
+	return db.SetBlobScanResult(w.db, s.Cid, status, threat)
+}
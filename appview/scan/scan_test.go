@@ -0,0 +1,199 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httputil"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+var eicar = []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+
+// serve accepts connections on a local port until the test ends
+func serve(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func fakeClamd(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil || cmd != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+
+	var data []byte
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return
+		}
+		data = append(data, chunk...)
+	}
+
+	if bytes.Contains(data, []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+		conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+	} else {
+		conn.Write([]byte("stream: OK\x00"))
+	}
+}
+
+func fakeICAP(conn net.Conn) {
+	br := bufio.NewReader(conn)
+	tp := textproto.NewReader(br)
+	line, _ := tp.ReadLine()
+	if !strings.HasPrefix(line, "RESPMOD icap://") {
+		fmt.Fprintf(conn, "ICAP/1.0 400 Bad Request\r\n\r\n")
+		return
+	}
+	if _, err := tp.ReadMIMEHeader(); err != nil {
+		return
+	}
+	// the encapsulated http response header
+	if _, err := tp.ReadLine(); err != nil {
+		return
+	}
+	if _, err := tp.ReadMIMEHeader(); err != nil {
+		return
+	}
+
+	data, err := io.ReadAll(httputil.NewChunkedReader(br))
+	if err != nil {
+		return
+	}
+
+	if bytes.Contains(data, []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+		fmt.Fprintf(conn, "ICAP/1.0 200 OK\r\nX-Infection-Found: Type=0; Resolution=2; Threat=Eicar-Test-Signature;\r\nEncapsulated: null-body=0\r\n\r\n")
+	} else {
+		fmt.Fprintf(conn, "ICAP/1.0 204 No Content\r\n\r\n")
+	}
+}
+
+func TestScanners(t *testing.T) {
+	clamd := NewClamAV("tcp://"+serve(t, fakeClamd), time.Second)
+	icap, err := NewICAP("icap://"+serve(t, fakeICAP)+"/avscan", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, s := range map[string]Scanner{"clamav": clamd, "icap": icap} {
+		t.Run(name, func(t *testing.T) {
+			clean := bytes.Repeat([]byte("not a virus "), 10000)
+			threat, err := s.Scan(context.Background(), bytes.NewReader(clean))
+			if err != nil || threat != "" {
+				t.Errorf("clean file: got %q, %v", threat, err)
+			}
+
+			threat, err = s.Scan(context.Background(), bytes.NewReader(eicar))
+			if err != nil || !strings.HasPrefix(threat, "Eicar") {
+				t.Errorf("eicar: got %q, %v", threat, err)
+			}
+		})
+	}
+
+	if _, err := NewClamAV("127.0.0.1:1", time.Second).Scan(context.Background(), bytes.NewReader(eicar)); err == nil {
+		t.Error("expected an error without clamd")
+	}
+}
+
+func TestWorker(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blobs := map[cid.Cid][]byte{}
+	add := func(data []byte) cid.Cid {
+		mh, err := multihash.Sum(data, multihash.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := cid.NewCidV1(cid.Raw, mh)
+		if data != nil {
+			blobs[c] = data
+		}
+		if err := db.AddBlobScan(d, "did:plc:alice", c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	clean := add([]byte("hello"))
+	infected := add(eicar)
+	gone := add(nil)
+
+	w, err := NewWorker(d, config.ScanConfig{ClamAVAddr: serve(t, fakeClamd)}, nil, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.fetch = func(ctx context.Context, did string, c cid.Cid) ([]byte, error) {
+		data, ok := blobs[c]
+		if !ok {
+			return nil, fmt.Errorf("blob not found")
+		}
+		return data, nil
+	}
+
+	for range MaxAttempts {
+		w.run(context.Background())
+	}
+
+	for c, want := range map[cid.Cid]string{
+		clean:    db.BlobScanClean,
+		infected: db.BlobScanInfected,
+		gone:     db.BlobScanFailed,
+	} {
+		s, err := db.GetBlobScan(d, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Status != want {
+			t.Errorf("%s: got %s, want %s", c, s.Status, want)
+		}
+	}
+
+	s, _ := db.GetBlobScan(d, infected)
+	if s.Threat != "Eicar-Signature" {
+		t.Errorf("threat: got %q", s.Threat)
+	}
+}
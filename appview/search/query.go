@@ -0,0 +1,113 @@
+// Package search parses search queries, which are free text narrowed down
+// by qualifiers:
+//
+//	user:@handle   repos of a user, or issues in them
+//	knot:domain    repos on a knot
+//	language:go    repos mostly written in a language
+//	is:fork        forks, is:source for repos that are not
+//	is:open        open issues, is:closed for closed ones
+//
+// A qualifier can be given more than once to match any of its values.
+// Anything else, including unknown qualifiers, is searched for as text.
+package search
+
+import (
+	"strings"
+)
+
+type Query struct {
+	// free text, each term has to match
+	Terms     []string
+	Users     []string
+	Knots     []string
+	Languages []string
+	Is        []string
+}
+
+// Parse splits q into terms and qualifiers. Double quotes keep a phrase
+// together as a single term.
+func Parse(q string) Query {
+	var query Query
+	for _, token := range tokenize(q) {
+		key, value, ok := strings.Cut(token, ":")
+		if !ok || value == "" {
+			query.Terms = append(query.Terms, token)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "user":
+			query.Users = append(query.Users, strings.TrimPrefix(value, "@"))
+		case "knot":
+			query.Knots = append(query.Knots, strings.ToLower(value))
+		case "language", "lang":
+			query.Languages = append(query.Languages, strings.ToLower(value))
+		case "is":
+			query.Is = append(query.Is, strings.ToLower(value))
+		default:
+			query.Terms = append(query.Terms, token)
+		}
+	}
+
+	return query
+}
+
+// Fork is whether the query asks for forks or for sources, nil if it asks
+// for neither or both
+func (q Query) Fork() *bool {
+	return q.either("fork", "source")
+}
+
+// Open is whether the query asks for open or for closed issues, nil if it
+// asks for neither or both
+func (q Query) Open() *bool {
+	return q.either("open", "closed")
+}
+
+func (q Query) either(yes, no string) *bool {
+	var isYes, isNo bool
+	for _, is := range q.Is {
+		switch is {
+		case yes:
+			isYes = true
+		case no:
+			isNo = true
+		}
+	}
+	if isYes == isNo {
+		return nil
+	}
+	return &isYes
+}
+
+// IsEmpty reports whether the query has nothing to search for
+func (q Query) IsEmpty() bool {
+	return len(q.Terms) == 0 && len(q.Users) == 0 && len(q.Knots) == 0 && len(q.Languages) == 0 && len(q.Is) == 0
+}
+
+func tokenize(q string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Query
+	}{
+		{
+			name:     "empty",
+			input:    "  ",
+			expected: Query{},
+		},
+		{
+			name:     "terms",
+			input:    `parser "merge conflict"`,
+			expected: Query{Terms: []string{"parser", "merge conflict"}},
+		},
+		{
+			name:  "qualifiers",
+			input: "user:@alice.tngl.sh knot:Knot.example.com language:Go is:fork lexer",
+			expected: Query{
+				Terms:     []string{"lexer"},
+				Users:     []string{"alice.tngl.sh"},
+				Knots:     []string{"knot.example.com"},
+				Languages: []string{"go"},
+				Is:        []string{"fork"},
+			},
+		},
+		{
+			name:     "quoted qualifier value",
+			input:    `language:"Emacs Lisp"`,
+			expected: Query{Languages: []string{"emacs lisp"}},
+		},
+		{
+			name:     "unknown qualifiers and empty values are text",
+			input:    "foo:bar user:",
+			expected: Query{Terms: []string{"foo:bar", "user:"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.input); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEither(t *testing.T) {
+	if fork := Parse("is:fork").Fork(); fork == nil || !*fork {
+		t.Error("is:fork should ask for forks")
+	}
+	if fork := Parse("is:source").Fork(); fork == nil || *fork {
+		t.Error("is:source should ask for sources")
+	}
+	if fork := Parse("is:fork is:source").Fork(); fork != nil {
+		t.Error("is:fork is:source should ask for both")
+	}
+	if open := Parse("is:closed").Open(); open == nil || *open {
+		t.Error("is:closed should ask for closed issues")
+	}
+}
@@ -0,0 +1,105 @@
+package serververify
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"tangled.sh/tangled.sh/core/types"
+)
+
+// Check is the outcome of one step of probing a server
+type Check struct {
+	Name   string
+	Ok     bool
+	Detail string
+}
+
+// DiagnoseKnot probes a knot the same way the appview talks to it, and reports
+// on each step. Probing stops at the first step that fails, since the ones
+// after it depend on it.
+func DiagnoseKnot(ctx context.Context, domain, expectedOwner string, dev bool) []Check {
+	scheme := "https"
+	if dev {
+		scheme = "http"
+	}
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+
+	var checks []Check
+	pass := func(name, detail string) {
+		checks = append(checks, Check{Name: name, Ok: true, Detail: detail})
+	}
+	fail := func(name, detail string) []Check {
+		return append(checks, Check{Name: name, Detail: detail})
+	}
+
+	host := domain
+	if h, _, err := net.SplitHostPort(domain); err == nil {
+		host = h
+	}
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fail("dns", fmt.Sprintf("%s does not resolve to an address, check your DNS records", host))
+	}
+	pass("dns", fmt.Sprintf("%s resolves to %s", host, addrs[0]))
+
+	resp, err := get(ctx, client, fmt.Sprintf("%s://%s/", scheme, domain))
+	if err != nil {
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			return fail("reachable", fmt.Sprintf("TLS handshake failed, check the certificate for %s: %v", domain, err))
+		}
+		return fail("reachable", fmt.Sprintf("could not connect to %s://%s, is the knot running and exposed? %v", scheme, domain, err))
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fail("reachable", fmt.Sprintf("%s://%s responded with %s, check your reverse proxy", scheme, domain, resp.Status))
+	}
+	pass("reachable", fmt.Sprintf("%s://%s is up", scheme, domain))
+
+	resp, err = get(ctx, client, fmt.Sprintf("%s://%s/capabilities", scheme, domain))
+	if err != nil {
+		return fail("capabilities", fmt.Sprintf("failed to fetch /capabilities: %v", err))
+	}
+	defer resp.Body.Close()
+
+	var capabilities types.Capabilities
+	if resp.StatusCode != http.StatusOK {
+		return fail("capabilities", fmt.Sprintf("/capabilities responded with %s, this does not look like a knot", resp.Status))
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&capabilities); err != nil {
+		return fail("capabilities", "/capabilities did not return valid JSON, this does not look like a knot")
+	}
+	if !capabilities.Xrpc {
+		return fail("capabilities", "this knot is running an outdated version, upgrade it and try again")
+	}
+	pass("capabilities", "knot is up to date")
+
+	observedOwner, err := fetchOwner(ctx, domain, dev)
+	if err != nil {
+		return fail("owner", "failed to fetch /owner, upgrade the knot and try again")
+	}
+	if observedOwner != expectedOwner {
+		return fail("owner", fmt.Sprintf("knot is owned by %s, but you are %s; set KNOT_SERVER_OWNER to your DID and restart the knot", observedOwner, expectedOwner))
+	}
+	pass("owner", fmt.Sprintf("knot is owned by %s", observedOwner))
+
+	return checks
+}
+
+func get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,119 @@
+package settings
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/apps"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+// how many redirect uris an app may register
+const maxRedirectUris = 10
+
+func (s *Settings) appsSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+
+	authorized, err := db.GetAuthorizedApps(s.Db, user.Did)
+	if err != nil {
+		log.Println("failed to get authorized apps", err)
+	}
+
+	own, err := db.GetApps(s.Db, db.FilterEq("owner_did", user.Did))
+	if err != nil {
+		log.Println("failed to get apps", err)
+	}
+
+	s.Pages.UserAppsSettings(w, pages.UserAppsSettingsParams{
+		LoggedInUser: user,
+		Authorized:   authorized,
+		Apps:         own,
+		Scopes:       db.AppScopes,
+		AppviewHost:  s.Config.Core.AppviewHost,
+		Tabs:         s.tabs(user.Did),
+		Tab:          "applications",
+	})
+}
+
+// apps registers an app of the user and hands out its credentials, or deletes
+// one, which revokes every token it holds
+func (s *Settings) apps(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+	errorId := "settings-apps-error"
+
+	switch r.Method {
+	case http.MethodDelete:
+		if err := db.DeleteApp(s.Db, did, r.FormValue("client_id")); err != nil {
+			log.Println("failed to delete app", err)
+			s.Pages.Notice(w, errorId, "Failed to delete application, try again later.")
+			return
+		}
+		s.Pages.HxRefresh(w)
+
+	case http.MethodPut:
+		app := db.App{
+			ClientId: apps.NewClientId(),
+			Name:     strings.TrimSpace(r.FormValue("name")),
+			Homepage: strings.TrimSpace(r.FormValue("homepage")),
+			OwnerDid: did,
+		}
+		if app.Name == "" || len(app.Name) > 64 {
+			s.Pages.Notice(w, errorId, "Application names are 1 to 64 characters long.")
+			return
+		}
+		if app.Homepage != "" {
+			if u, err := url.Parse(app.Homepage); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				s.Pages.Notice(w, errorId, "The homepage has to be a http or https URL.")
+				return
+			}
+		}
+
+		for _, uri := range strings.Fields(r.FormValue("redirect_uris")) {
+			u, err := url.Parse(uri)
+			// native apps may use custom schemes, but never scripts
+			if err != nil || u.Scheme == "" || u.Fragment != "" || strings.EqualFold(u.Scheme, "javascript") || strings.EqualFold(u.Scheme, "data") {
+				s.Pages.Notice(w, errorId, "Redirect URIs have to be absolute URLs, without a fragment.")
+				return
+			}
+			if u.Scheme == "http" && u.Hostname() != "localhost" && u.Hostname() != "127.0.0.1" {
+				s.Pages.Notice(w, errorId, "Redirect URIs have to use https, unless they point at localhost.")
+				return
+			}
+			app.RedirectUris = append(app.RedirectUris, uri)
+		}
+		if len(app.RedirectUris) == 0 || len(app.RedirectUris) > maxRedirectUris {
+			s.Pages.Notice(w, errorId, fmt.Sprintf("Register 1 to %d redirect URIs.", maxRedirectUris))
+			return
+		}
+
+		secret, hash := apps.NewSecret()
+		if err := db.AddApp(s.Db, &app, hash); err != nil {
+			log.Println("failed to add app", err)
+			s.Pages.Notice(w, errorId, "Failed to register application, try again later.")
+			return
+		}
+
+		// the secret is only ever shown here, the appview keeps its hash
+		s.Pages.Notice(w, "app-credentials", fmt.Sprintf(
+			"Registered. Client id: %s. Copy the client secret now, it will not be shown again: %s",
+			app.ClientId, secret,
+		))
+	}
+}
+
+// appsRevoke takes away the access the user gave an app
+func (s *Settings) appsRevoke(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	if err := db.RevokeApp(s.Db, did, r.FormValue("client_id")); err != nil {
+		log.Println("failed to revoke app", err)
+		s.Pages.Notice(w, "settings-authorized-error", "Failed to revoke application, try again later.")
+		return
+	}
+
+	s.Pages.HxRefresh(w)
+}
@@ -0,0 +1,251 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/google/uuid"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+func (s *Settings) domainsSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+
+	domains, err := db.GetDomains(s.Db, db.FilterEq("did", user.Did))
+	if err != nil {
+		log.Println(err)
+	}
+
+	repos, err := db.GetAllReposByDid(s.Db, user.Did)
+	if err != nil {
+		log.Println(err)
+	}
+
+	s.Pages.UserDomainsSettings(w, pages.UserDomainsSettingsParams{
+		LoggedInUser: user,
+		Domains:      domains,
+		Repos:        repos,
+		Tabs:         s.tabs(user.Did),
+		Tab:          "domains",
+	})
+}
+
+// isSubdomain reports whether domain is host or one of its subdomains
+func isSubdomain(domain, host string) bool {
+	return domain == host || strings.HasSuffix(domain, "."+host)
+}
+
+func (s *Settings) domains(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	switch r.Method {
+	case http.MethodPut:
+		noticeId := "settings-domains-add-error"
+		domain := normalizeDomain(r.FormValue("domain"))
+		if domain == "" {
+			s.Pages.Notice(w, noticeId, "That doesn't look like a valid domain.")
+			return
+		}
+
+		if appview, err := url.Parse(s.Config.Core.AppviewHost); err == nil && isSubdomain(domain, appview.Hostname()) {
+			s.Pages.Notice(w, noticeId, "This domain cannot be used.")
+			return
+		}
+
+		var repoAt *syntax.ATURI
+		if repo := r.FormValue("repo"); repo != "" {
+			repos, err := db.GetAllReposByDid(s.Db, did)
+			if err != nil {
+				log.Println("failed to get repos", err)
+				s.Pages.Notice(w, noticeId, "Unable to add domain at this moment, try again later.")
+				return
+			}
+
+			idx := slices.IndexFunc(repos, func(r db.Repo) bool { return r.Name == repo })
+			if idx < 0 {
+				s.Pages.Notice(w, noticeId, "No such repository.")
+				return
+			}
+
+			at := repos[idx].RepoAt()
+			repoAt = &at
+		}
+
+		err := db.AddDomain(s.Db, db.Domain{
+			Did:    syntax.DID(did),
+			Domain: domain,
+			RepoAt: repoAt,
+			Token:  uuid.New().String(),
+		})
+		if err != nil {
+			log.Println("failed to add domain", err)
+			s.Pages.Notice(w, noticeId, "You already added this domain.")
+			return
+		}
+
+		s.Pages.HxLocation(w, "/settings/domains")
+		return
+
+	case http.MethodDelete:
+		noticeId := "settings-domains-error"
+		domain := r.FormValue("domain")
+
+		err := db.DeleteDomain(s.Db, db.FilterEq("did", did), db.FilterEq("domain", domain))
+		if err != nil {
+			log.Println("failed to delete domain", err)
+			s.Pages.Notice(w, noticeId, "Unable to delete domain at this moment, try again later.")
+			return
+		}
+
+		s.Pages.HxLocation(w, "/settings/domains")
+		return
+	}
+}
+
+func (s *Settings) domainsVerify(w http.ResponseWriter, r *http.Request) {
+	noticeId := "settings-domains-error"
+	did := s.OAuth.GetDid(r)
+
+	domain, err := db.GetDomain(s.Db, db.FilterEq("did", did), db.FilterEq("domain", r.FormValue("domain")))
+	if err != nil {
+		log.Println("failed to get domain", err)
+		s.Pages.Notice(w, noticeId, "No such domain.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := verifyDomainOwnership(ctx, *domain); err != nil {
+		log.Println("domain verification failed", domain.Domain, err)
+		s.Pages.Notice(w, noticeId, fmt.Sprintf("Verification failed: %s", err))
+		return
+	}
+
+	tx, err := s.Db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Println("failed to start transaction", err)
+		s.Pages.Notice(w, noticeId, "Unable to verify domain at this moment, try again later.")
+		return
+	}
+	defer tx.Rollback()
+
+	if err := db.ClaimDomain(tx, domain.Id, domain.Domain); err != nil {
+		log.Println("failed to mark domain as verified", err)
+		s.Pages.Notice(w, noticeId, "Unable to verify domain at this moment, try again later.")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("failed to commit domain", err)
+		s.Pages.Notice(w, noticeId, "Unable to verify domain at this moment, try again later.")
+		return
+	}
+
+	s.Pages.HxLocation(w, "/settings/domains")
+}
+
+// verifyDomainOwnership looks for the challenge token in the TXT records
+// under the domain's challenge name
+func verifyDomainOwnership(ctx context.Context, domain db.Domain) error {
+	records, err := net.DefaultResolver.LookupTXT(ctx, domain.ChallengeName())
+	if err != nil {
+		return fmt.Errorf("no TXT record found at %s", domain.ChallengeName())
+	}
+
+	if !slices.Contains(records, domain.ChallengeValue()) {
+		return fmt.Errorf("TXT record at %s does not contain the expected value", domain.ChallengeName())
+	}
+
+	return nil
+}
+
+const (
+	// how often verified domains are checked for their TXT record
+	domainRecheckInterval = 24 * time.Hour
+	// how long a claim is kept without being verified
+	domainClaimTTL = 7 * 24 * time.Hour
+)
+
+// RecheckDomains drops expired claims on domains, and puts verified domains
+// that no longer publish their TXT record back to pending, once a day
+func RecheckDomains(ctx context.Context, d *db.DB, l *slog.Logger) {
+	ticker := time.NewTicker(domainRecheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if n, err := db.DeleteExpiredDomains(d, time.Now().Add(-domainClaimTTL)); err != nil {
+			l.Error("failed to delete expired domains", "err", err)
+		} else if n > 0 {
+			l.Info("deleted expired domains", "count", n)
+		}
+
+		domains, err := db.GetDomains(d, db.FilterIsNot("verified", nil))
+		if err != nil {
+			l.Error("failed to get domains", "err", err)
+			continue
+		}
+
+		for _, domain := range domains {
+			if ctx.Err() != nil {
+				return
+			}
+			lost, err := recheckDomain(ctx, d, domain)
+			if err != nil {
+				l.Error("failed to unverify domain", "domain", domain.Domain, "err", err)
+			} else if lost {
+				l.Info("domain lost its verification", "domain", domain.Domain, "did", domain.Did)
+			}
+		}
+	}
+}
+
+// recheckDomain unverifies the domain if its TXT record is gone. Lookups
+// that fail for reasons other than the record not being there are not held
+// against the domain.
+func recheckDomain(ctx context.Context, d *db.DB, domain db.Domain) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	records, err := net.DefaultResolver.LookupTXT(ctx, domain.ChallengeName())
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return false, nil
+	}
+	if slices.Contains(records, domain.ChallengeValue()) {
+		return false, nil
+	}
+
+	return true, db.UnverifyDomain(d, domain.Id)
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "https://")
+	domain = strings.TrimPrefix(domain, "http://")
+	domain = strings.TrimSuffix(domain, "/")
+	domain = strings.TrimSuffix(domain, ".")
+
+	if !strings.Contains(domain, ".") || strings.ContainsAny(domain, "/:@ ") {
+		return ""
+	}
+
+	return domain
+}
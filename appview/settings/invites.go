@@ -0,0 +1,164 @@
+package settings
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/email"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+func (s *Settings) invitesSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+	isAdmin := s.Config.Registration.IsAdmin(user.Did)
+
+	invites, err := db.GetInvites(s.Db, db.FilterEq("created_by", user.Did))
+	if err != nil {
+		log.Println(err)
+	}
+
+	var waitlist []db.WaitlistEntry
+	if isAdmin {
+		waitlist, err = db.GetWaitlist(s.Db)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	s.Pages.UserInvitesSettings(w, pages.UserInvitesSettingsParams{
+		LoggedInUser: user,
+		Policy:       s.Config.Registration.Policy,
+		IsAdmin:      isAdmin,
+		CanInvite:    s.canInvite(user.Did, len(invites)),
+		Invites:      invites,
+		Waitlist:     waitlist,
+		Tabs:         s.tabs(user.Did),
+		Tab:          "invites",
+	})
+}
+
+// canInvite tells whether did may create another invite, having created
+// count of them already
+func (s *Settings) canInvite(did string, count int) bool {
+	reg := s.Config.Registration
+	if reg.Policy != config.RegistrationInvite {
+		return false
+	}
+	return reg.IsAdmin(did) || count < reg.InvitesPerUser
+}
+
+func (s *Settings) invites(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	switch r.Method {
+	case http.MethodPost:
+		invites, err := db.GetInvites(s.Db, db.FilterEq("created_by", did))
+		if err != nil {
+			log.Println("failed to get invites", err)
+			s.Pages.Notice(w, "settings-invites-error", "Failed to create invite, try again later.")
+			return
+		}
+
+		if !s.canInvite(did, len(invites)) {
+			s.Pages.Notice(w, "settings-invites-error", "You cannot create any more invites.")
+			return
+		}
+
+		err = db.AddInvite(s.Db, uuid.New().String(), did)
+		if err != nil {
+			log.Println("failed to add invite", err)
+			s.Pages.Notice(w, "settings-invites-error", "Failed to create invite, try again later.")
+			return
+		}
+
+	case http.MethodDelete:
+		err := db.DeleteInvite(s.Db, r.FormValue("code"), did)
+		if err != nil {
+			log.Println("failed to delete invite", err)
+			s.Pages.Notice(w, "settings-invites-error", "Failed to revoke invite, try again later.")
+			return
+		}
+	}
+
+	s.Pages.HxRefresh(w)
+}
+
+// inviteFromWaitlist creates an invite for someone on the waitlist, and
+// emails it to them if they left an address
+func (s *Settings) inviteFromWaitlist(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+	if !s.Config.Registration.IsAdmin(did) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		s.Pages.Notice(w, "settings-invites-error", "Invalid waitlist entry.")
+		return
+	}
+
+	entries, err := db.GetWaitlist(s.Db, db.FilterEq("id", id))
+	if err != nil || len(entries) != 1 {
+		s.Pages.Notice(w, "settings-invites-error", "Invalid waitlist entry.")
+		return
+	}
+	entry := entries[0]
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		log.Println("failed to start transaction", err)
+		s.Pages.Notice(w, "settings-invites-error", "Failed to create invite, try again later.")
+		return
+	}
+	defer tx.Rollback()
+
+	code := uuid.New().String()
+	// the invite is bound to the account on the waitlist, whoever else gets
+	// to read the email
+	if err := db.AddInviteFor(tx, code, did, entry.Did); err != nil {
+		log.Println("failed to add invite", err)
+		s.Pages.Notice(w, "settings-invites-error", "Failed to create invite, try again later.")
+		return
+	}
+
+	if err := db.SetWaitlistInvite(tx, entry.Id, code); err != nil {
+		log.Println("failed to update waitlist", err)
+		s.Pages.Notice(w, "settings-invites-error", "Failed to create invite, try again later.")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("failed to commit transaction", err)
+		s.Pages.Notice(w, "settings-invites-error", "Failed to create invite, try again later.")
+		return
+	}
+
+	if entry.Email != "" {
+		loginUrl := fmt.Sprintf("%s/login", s.Config.Core.AppviewHost)
+		err := email.SendEmail(email.Email{
+			APIKey:  s.Config.Resend.ApiKey,
+			From:    s.Config.Resend.SentFrom,
+			To:      entry.Email,
+			Subject: "Your Tangled invite",
+			Text: fmt.Sprintf(`You're in! Log in at %s as %s with this invite code:
+
+%s`, loginUrl, entry.Handle, code),
+			Html: fmt.Sprintf(`<p>You're in! Log in at <a href="%s">%s</a> as <strong>%s</strong> with this invite code:</p>
+<p><code>%s</code></p>`, loginUrl, loginUrl, entry.Handle, code),
+		})
+		if err != nil {
+			log.Println("failed to send invite email", err)
+			s.Pages.Notice(w, "settings-invites-error", "Invite created, but the email could not be sent. Share the code yourself.")
+			return
+		}
+	}
+
+	s.Pages.HxRefresh(w)
+}
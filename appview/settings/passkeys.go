@@ -0,0 +1,287 @@
+package settings
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/webauthn"
+)
+
+// how many passkeys a user may register
+const maxPasskeys = 10
+
+func (s *Settings) passkeysSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+
+	passkeys, err := db.GetPasskeys(s.Db, db.FilterEq("did", user.Did))
+	if err != nil {
+		log.Println("failed to get passkeys", err)
+	}
+
+	s.Pages.UserPasskeysSettings(w, pages.UserPasskeysSettingsParams{
+		LoggedInUser: user,
+		Passkeys:     passkeys,
+		Tabs:         s.tabs(user.Did),
+		Tab:          "passkeys",
+	})
+}
+
+// passkeysOptions starts the registration of a passkey, with the options
+// navigator.credentials.create is called with
+func (s *Settings) passkeysOptions(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+
+	rp, err := webauthn.NewRelyingParty(s.Config.Core.AppviewHost)
+	if err != nil {
+		log.Println("failed to set up passkeys", err)
+		writeError(w, http.StatusInternalServerError, "Passkeys are not available on this instance.")
+		return
+	}
+
+	passkeys, err := db.GetPasskeys(s.Db, db.FilterEq("did", user.Did))
+	if err != nil {
+		log.Println("failed to get passkeys", err)
+		writeError(w, http.StatusInternalServerError, "Failed to add passkey, try again later.")
+		return
+	}
+	if len(passkeys) >= maxPasskeys {
+		writeError(w, http.StatusBadRequest, "You have too many passkeys, delete one first.")
+		return
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err == nil {
+		err = s.OAuth.SetChallenge(w, r, challenge)
+	}
+	if err != nil {
+		log.Println("failed to set challenge", err)
+		writeError(w, http.StatusInternalServerError, "Failed to add passkey, try again later.")
+		return
+	}
+
+	exclude := []map[string]string{}
+	for _, p := range passkeys {
+		exclude = append(exclude, map[string]string{"type": "public-key", "id": p.CredentialId})
+	}
+	params := []map[string]any{}
+	for _, alg := range webauthn.Algorithms {
+		params = append(params, map[string]any{"type": "public-key", "alg": alg})
+	}
+
+	writeJSON(w, map[string]any{
+		"challenge": challenge,
+		"rp":        map[string]string{"id": rp.ID, "name": "tangled"},
+		"user": map[string]string{
+			"id":          webauthn.Encode([]byte(user.Did)),
+			"name":        user.Handle,
+			"displayName": user.Handle,
+		},
+		"pubKeyCredParams":   params,
+		"excludeCredentials": exclude,
+		"authenticatorSelection": map[string]string{
+			"residentKey":      "preferred",
+			"userVerification": "preferred",
+		},
+		"attestation": "none",
+		"timeout":     60000,
+	})
+}
+
+// passkeys finishes the registration of a passkey, or deletes one
+func (s *Settings) passkeys(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	switch r.Method {
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			s.Pages.Notice(w, "settings-passkeys-error", "Invalid passkey.")
+			return
+		}
+		if err := db.DeletePasskey(s.Db, did, id); err != nil {
+			log.Println("failed to delete passkey", err)
+			s.Pages.Notice(w, "settings-passkeys-error", "Failed to delete passkey, try again later.")
+			return
+		}
+		s.Pages.HxRefresh(w)
+
+	case http.MethodPut:
+		var req struct {
+			Name       string                `json:"name"`
+			Credential webauthn.Registration `json:"credential"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid passkey.")
+			return
+		}
+
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = "passkey"
+		}
+		if len(name) > 64 {
+			writeError(w, http.StatusBadRequest, "Name the passkey with 64 characters or less.")
+			return
+		}
+
+		rp, err := webauthn.NewRelyingParty(s.Config.Core.AppviewHost)
+		if err != nil {
+			log.Println("failed to set up passkeys", err)
+			writeError(w, http.StatusInternalServerError, "Passkeys are not available on this instance.")
+			return
+		}
+
+		cred, err := rp.VerifyRegistration(s.OAuth.TakeChallenge(w, r), req.Credential)
+		if err != nil {
+			log.Println("failed to verify passkey", err)
+			writeError(w, http.StatusBadRequest, "Failed to verify passkey, try again.")
+			return
+		}
+
+		err = db.AddPasskey(s.Db, &db.Passkey{
+			Did:          did,
+			Name:         name,
+			CredentialId: cred.Id,
+			PublicKey:    cred.PublicKey,
+			Algorithm:    cred.Algorithm,
+			SignCount:    cred.SignCount,
+		})
+		if err != nil {
+			log.Println("failed to add passkey", err)
+			writeError(w, http.StatusInternalServerError, "Failed to add passkey, try again later.")
+			return
+		}
+
+		// registering it proved it is them as much as using it would
+		if err := s.OAuth.SetSudo(w, r); err != nil {
+			log.Println("failed to set sudo", err)
+		}
+
+		writeJSON(w, map[string]string{"redirect": "/settings/passkeys"})
+	}
+}
+
+func (s *Settings) sudoPage(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+
+	s.Pages.Sudo(w, pages.SudoParams{
+		LoggedInUser: user,
+		ReturnUrl:    returnURL(r.URL.Query().Get("return_url")),
+	})
+}
+
+// sudoOptions starts confirming it is the user with one of their passkeys,
+// with the options navigator.credentials.get is called with
+func (s *Settings) sudoOptions(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	rp, err := webauthn.NewRelyingParty(s.Config.Core.AppviewHost)
+	if err != nil {
+		log.Println("failed to set up passkeys", err)
+		writeError(w, http.StatusInternalServerError, "Passkeys are not available on this instance.")
+		return
+	}
+
+	passkeys, err := db.GetPasskeys(s.Db, db.FilterEq("did", did))
+	if err != nil {
+		log.Println("failed to get passkeys", err)
+		writeError(w, http.StatusInternalServerError, "Failed to confirm, try again later.")
+		return
+	}
+
+	challenge, err := webauthn.NewChallenge()
+	if err == nil {
+		err = s.OAuth.SetChallenge(w, r, challenge)
+	}
+	if err != nil {
+		log.Println("failed to set challenge", err)
+		writeError(w, http.StatusInternalServerError, "Failed to confirm, try again later.")
+		return
+	}
+
+	allow := []map[string]string{}
+	for _, p := range passkeys {
+		allow = append(allow, map[string]string{"type": "public-key", "id": p.CredentialId})
+	}
+
+	writeJSON(w, map[string]any{
+		"challenge":        challenge,
+		"rpId":             rp.ID,
+		"allowCredentials": allow,
+		"userVerification": "preferred",
+		"timeout":          60000,
+	})
+}
+
+func (s *Settings) sudo(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	var req struct {
+		ReturnUrl  string             `json:"return_url"`
+		Credential webauthn.Assertion `json:"credential"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid passkey.")
+		return
+	}
+
+	rp, err := webauthn.NewRelyingParty(s.Config.Core.AppviewHost)
+	if err != nil {
+		log.Println("failed to set up passkeys", err)
+		writeError(w, http.StatusInternalServerError, "Passkeys are not available on this instance.")
+		return
+	}
+
+	passkey, err := db.GetPasskey(s.Db, req.Credential.Id)
+	if err != nil || passkey.Did != did {
+		writeError(w, http.StatusBadRequest, "That passkey is not registered to your account.")
+		return
+	}
+
+	count, err := rp.VerifyAssertion(s.OAuth.TakeChallenge(w, r), req.Credential, webauthn.Credential{
+		Id:        passkey.CredentialId,
+		PublicKey: passkey.PublicKey,
+		Algorithm: passkey.Algorithm,
+		SignCount: passkey.SignCount,
+	})
+	if err != nil {
+		log.Println("failed to verify passkey", err)
+		writeError(w, http.StatusBadRequest, "Failed to verify passkey, try again.")
+		return
+	}
+
+	if err := db.SetPasskeyUsed(s.Db, passkey.Id, count); err != nil {
+		log.Println("failed to record passkey use", err)
+	}
+	if err := s.OAuth.SetSudo(w, r); err != nil {
+		log.Println("failed to set sudo", err)
+		writeError(w, http.StatusInternalServerError, "Failed to confirm, try again later.")
+		return
+	}
+
+	writeJSON(w, map[string]string{"redirect": returnURL(req.ReturnUrl)})
+}
+
+// returnURL only lets users be sent back to pages of the appview
+func returnURL(u string) string {
+	if !strings.HasPrefix(u, "/") || strings.HasPrefix(u, "//") || strings.HasPrefix(u, "/\\") {
+		return "/"
+	}
+	return u
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
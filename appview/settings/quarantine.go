@@ -0,0 +1,99 @@
+package settings
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ipfs/go-cid"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+// quarantineSettings shows admins the attachments and artifacts the virus
+// scanner held back, along with those still waiting to be scanned
+func (s *Settings) quarantineSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+	if !s.Config.Registration.IsAdmin(user.Did) {
+		s.Pages.Error404(w)
+		return
+	}
+
+	scans, err := db.GetBlobScans(s.Db, 200, db.FilterNotEq("status", db.BlobScanClean))
+	if err != nil {
+		log.Println("failed to get blob scans", err)
+	}
+
+	var blobs []pages.QuarantinedBlob
+	var repoAts []string
+	for _, scan := range scans {
+		attachments, err := db.GetAttachments(s.Db, db.FilterEq("blob_cid", scan.Cid.String()))
+		if err != nil {
+			log.Println("failed to get attachments", err)
+		}
+		artifacts, err := db.GetArtifact(s.Db, db.FilterEq("blob_cid", scan.Cid.String()))
+		if err != nil {
+			log.Println("failed to get artifacts", err)
+		}
+
+		for _, a := range attachments {
+			repoAts = append(repoAts, a.RepoAt.String())
+		}
+		for _, a := range artifacts {
+			repoAts = append(repoAts, a.RepoAt.String())
+		}
+
+		blobs = append(blobs, pages.QuarantinedBlob{
+			BlobScan:    scan,
+			Attachments: attachments,
+			Artifacts:   artifacts,
+		})
+	}
+
+	repos, err := db.GetReposByAtUris(s.Db, repoAts)
+	if err != nil {
+		log.Println("failed to get repos", err)
+	}
+
+	s.Pages.UserQuarantineSettings(w, pages.UserQuarantineSettingsParams{
+		LoggedInUser: user,
+		Enabled:      s.Config.Scan.Enabled(),
+		Blobs:        blobs,
+		Repos:        repos,
+		Tabs:         s.tabs(user.Did),
+		Tab:          "quarantine",
+	})
+}
+
+// quarantine scans a blob again, or lets it through when the scanner got it
+// wrong
+func (s *Settings) quarantine(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+	if !s.Config.Registration.IsAdmin(user.Did) {
+		s.Pages.Error404(w)
+		return
+	}
+
+	blobCid, err := cid.Parse(r.FormValue("cid"))
+	if err != nil {
+		s.Pages.Notice(w, "settings-quarantine-error", "Unknown blob.")
+		return
+	}
+
+	switch r.FormValue("action") {
+	case "rescan":
+		err = db.RescanBlob(s.Db, blobCid)
+	case "release":
+		log.Printf("%s released blob %s from quarantine", user.Did, blobCid)
+		err = db.SetBlobScanResult(s.Db, blobCid, db.BlobScanClean, "")
+	default:
+		s.Pages.Notice(w, "settings-quarantine-error", "Unknown action.")
+		return
+	}
+	if err != nil {
+		log.Println("failed to update blob scan", err)
+		s.Pages.Notice(w, "settings-quarantine-error", "Failed to update the blob, try again later.")
+		return
+	}
+
+	s.Pages.HxRefresh(w)
+}
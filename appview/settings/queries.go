@@ -0,0 +1,25 @@
+package settings
+
+import (
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+// queriesSettings shows admins the slowest queries the appview ran lately
+func (s *Settings) queriesSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+	if !s.Config.Registration.IsAdmin(user.Did) {
+		s.Pages.Error404(w)
+		return
+	}
+
+	s.Pages.UserQueriesSettings(w, pages.UserQueriesSettingsParams{
+		LoggedInUser: user,
+		Threshold:    s.Db.Queries.Threshold,
+		Slow:         s.Db.Queries.Slow(),
+		Stats:        s.Db.Queries.Stats(25),
+		Tabs:         s.tabs(user.Did),
+		Tab:          "queries",
+	})
+}
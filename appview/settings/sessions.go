@@ -0,0 +1,49 @@
+package settings
+
+import (
+	"log"
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+func (s *Settings) sessionsSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+
+	logins, err := s.OAuth.Logins(r, user.Did)
+	if err != nil {
+		log.Println("failed to get logins", err)
+	}
+
+	// they have seen the new login now
+	if err := s.OAuth.ClearLoginAlert(r, user.Did); err != nil {
+		log.Println("failed to clear login alert", err)
+	}
+	user.LoginAlert = false
+
+	s.Pages.UserSessionsSettings(w, pages.UserSessionsSettingsParams{
+		LoggedInUser: user,
+		Logins:       logins,
+		Current:      s.OAuth.SessionId(r),
+		Tabs:         s.tabs(user.Did),
+		Tab:          "sessions",
+	})
+}
+
+func (s *Settings) sessions(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+	id := r.FormValue("id")
+
+	if id == "" || id == s.OAuth.SessionId(r) {
+		s.Pages.Notice(w, "settings-sessions-error", "Log out to end the current session.")
+		return
+	}
+
+	if err := s.OAuth.RevokeLogin(r, did, id); err != nil {
+		log.Println("failed to revoke login", err)
+		s.Pages.Notice(w, "settings-sessions-error", "Failed to revoke session, try again later.")
+		return
+	}
+
+	s.Pages.HxRefresh(w)
+}
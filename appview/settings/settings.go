@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,12 +19,15 @@ import (
 	"tangled.sh/tangled.sh/core/appview/middleware"
 	"tangled.sh/tangled.sh/core/appview/oauth"
 	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/sshca"
+	"tangled.sh/tangled.sh/core/crypto"
 	"tangled.sh/tangled.sh/core/tid"
 
 	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/syntax"
 	lexutil "github.com/bluesky-social/indigo/lex/util"
-	"github.com/gliderlabs/ssh"
 	"github.com/google/uuid"
+	gossh "golang.org/x/crypto/ssh"
 )
 
 type Settings struct {
@@ -31,6 +35,7 @@ type Settings struct {
 	OAuth  *oauth.OAuth
 	Pages  *pages.Pages
 	Config *config.Config
+	SshCa  *sshca.Authority
 }
 
 type tab = map[string]any
@@ -39,32 +44,116 @@ var (
 	settingsTabs []tab = []tab{
 		{"Name": "profile", "Icon": "user"},
 		{"Name": "keys", "Icon": "key"},
+		{"Name": "sessions", "Icon": "monitor-smartphone"},
+		{"Name": "passkeys", "Icon": "fingerprint"},
 		{"Name": "emails", "Icon": "mail"},
+		{"Name": "domains", "Icon": "globe"},
+		{"Name": "sharing", "Icon": "share-2"},
+		{"Name": "applications", "Icon": "app-window"},
+		{"Name": "invites", "Icon": "ticket"},
+		{"Name": "takeout", "Icon": "download"},
 	}
 )
 
+// tabs are the settings tabs shown to did, admins get the ones to look after
+// the instance too
+func (s *Settings) tabs(did string) []tab {
+	if !s.Config.Registration.IsAdmin(did) {
+		return settingsTabs
+	}
+	return append(
+		slices.Clone(settingsTabs),
+		tab{"Name": "queries", "Icon": "database"},
+		tab{"Name": "quarantine", "Icon": "shield-alert"},
+	)
+}
+
 func (s *Settings) Router() http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(middleware.AuthMiddleware(s.OAuth))
 
+	// actions that could take over the account ask for a passkey, if the
+	// user has one
+	sudo := middleware.Sudo(s.OAuth, s.Db)
+
 	// settings pages
 	r.Get("/", s.profileSettings)
 	r.Get("/profile", s.profileSettings)
 
 	r.Route("/keys", func(r chi.Router) {
 		r.Get("/", s.keysSettings)
-		r.Put("/", s.keys)
-		r.Delete("/", s.keys)
+		r.With(sudo).Put("/", s.keys)
+		r.With(sudo).Delete("/", s.keys)
+		r.Post("/certificate", s.keysCertificate)
+		r.With(sudo).Put("/signing", s.signingKeys)
+		r.With(sudo).Delete("/signing", s.signingKeys)
+	})
+
+	r.Route("/sessions", func(r chi.Router) {
+		r.Get("/", s.sessionsSettings)
+		r.With(sudo).Delete("/", s.sessions)
+	})
+
+	r.Route("/passkeys", func(r chi.Router) {
+		r.Get("/", s.passkeysSettings)
+		r.Post("/options", s.passkeysOptions)
+		r.With(sudo).Put("/", s.passkeys)
+		r.With(sudo).Delete("/", s.passkeys)
+	})
+
+	r.Route("/sudo", func(r chi.Router) {
+		r.Get("/", s.sudoPage)
+		r.Post("/options", s.sudoOptions)
+		r.Post("/", s.sudo)
 	})
 
 	r.Route("/emails", func(r chi.Router) {
 		r.Get("/", s.emailsSettings)
-		r.Put("/", s.emails)
-		r.Delete("/", s.emails)
+		r.With(sudo).Put("/", s.emails)
+		r.With(sudo).Delete("/", s.emails)
 		r.Get("/verify", s.emailsVerify)
 		r.Post("/verify/resend", s.emailsVerifyResend)
-		r.Post("/primary", s.emailsPrimary)
+		r.With(sudo).Post("/primary", s.emailsPrimary)
+		r.Put("/notifications", s.emailsNotifications)
+	})
+
+	r.Route("/domains", func(r chi.Router) {
+		r.Get("/", s.domainsSettings)
+		r.With(sudo).Put("/", s.domains)
+		r.With(sudo).Delete("/", s.domains)
+		r.Post("/verify", s.domainsVerify)
+	})
+
+	r.Route("/sharing", func(r chi.Router) {
+		r.Get("/", s.sharingSettings)
+		r.Put("/", s.sharing)
+	})
+
+	r.Route("/applications", func(r chi.Router) {
+		r.Get("/", s.appsSettings)
+		r.With(sudo).Put("/", s.apps)
+		r.With(sudo).Delete("/", s.apps)
+		r.Delete("/authorized", s.appsRevoke)
+	})
+
+	r.Route("/invites", func(r chi.Router) {
+		r.Get("/", s.invitesSettings)
+		r.Post("/", s.invites)
+		r.Delete("/", s.invites)
+		r.Post("/waitlist/{id}", s.inviteFromWaitlist)
+	})
+
+	r.Get("/queries", s.queriesSettings)
+
+	r.Route("/quarantine", func(r chi.Router) {
+		r.Get("/", s.quarantineSettings)
+		r.Post("/", s.quarantine)
+	})
+
+	r.Route("/takeout", func(r chi.Router) {
+		r.Get("/", s.takeoutSettings)
+		r.With(sudo).Post("/", s.takeout)
 	})
 
 	return r
@@ -75,7 +164,7 @@ func (s *Settings) profileSettings(w http.ResponseWriter, r *http.Request) {
 
 	s.Pages.UserProfileSettings(w, pages.UserProfileSettingsParams{
 		LoggedInUser: user,
-		Tabs:         settingsTabs,
+		Tabs:         s.tabs(user.Did),
 		Tab:          "profile",
 	})
 }
@@ -87,12 +176,63 @@ func (s *Settings) keysSettings(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 	}
 
+	signingKeys, err := db.GetSigningKeys(s.Db, db.FilterEq("did", user.Did))
+	if err != nil {
+		log.Println(err)
+	}
+
 	s.Pages.UserKeysSettings(w, pages.UserKeysSettingsParams{
-		LoggedInUser: user,
-		PubKeys:      pubKeys,
-		Tabs:         settingsTabs,
-		Tab:          "keys",
+		LoggedInUser:        user,
+		PubKeys:             pubKeys,
+		SigningKeys:         signingKeys,
+		CertificatesEnabled: s.SshCa != nil,
+		Tabs:                s.tabs(user.Did),
+		Tab:                 "keys",
+	})
+}
+
+// keysCertificate issues a short-lived certificate for one of the user's keys,
+// for knots that trust this appview's certificate authority
+func (s *Settings) keysCertificate(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+	if s.SshCa == nil {
+		http.Error(w, "certificates are not issued by this appview", http.StatusNotFound)
+		return
+	}
+
+	rkey := r.FormValue("rkey")
+	pubKeys, err := db.GetPublicKeysForDid(s.Db, user.Did)
+	if err != nil {
+		log.Println("failed to get keys", err)
+		http.Error(w, "failed to get keys", http.StatusInternalServerError)
+		return
+	}
+
+	idx := slices.IndexFunc(pubKeys, func(k db.PublicKey) bool {
+		return k.Rkey == rkey
 	})
+	if rkey == "" || idx < 0 {
+		http.Error(w, "no such key", http.StatusNotFound)
+		return
+	}
+	pubKey := pubKeys[idx]
+
+	key, _, _, _, err := gossh.ParseAuthorizedKey([]byte(pubKey.Key))
+	if err != nil {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+
+	cert, err := s.SshCa.Issue(syntax.DID(user.Did), key)
+	if err != nil {
+		log.Println("failed to issue certificate", err)
+		http.Error(w, "failed to issue certificate", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-cert.pub"`, url.PathEscape(pubKey.Name)))
+	w.Write(gossh.MarshalAuthorizedKey(cert))
 }
 
 func (s *Settings) emailsSettings(w http.ResponseWriter, r *http.Request) {
@@ -102,12 +242,45 @@ func (s *Settings) emailsSettings(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 	}
 
+	notify, err := db.GetNotificationSettings(s.Db, user.Did)
+	if err != nil {
+		log.Println(err)
+	}
+
 	s.Pages.UserEmailsSettings(w, pages.UserEmailsSettingsParams{
 		LoggedInUser: user,
 		Emails:       emails,
-		Tabs:         settingsTabs,
+		Notify:       notify,
+		Tabs:         s.tabs(user.Did),
 		Tab:          "emails",
+
+		BlueskyNotify: s.Config.BskyNotify.Did,
+	})
+}
+
+func (s *Settings) emailsNotifications(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	bluesky := r.FormValue("bluesky_notifications")
+	switch bluesky {
+	case db.BlueskyOff, db.BlueskyDm, db.BlueskyMention:
+	default:
+		s.Pages.Notice(w, "settings-notifications-error", "Unknown way of notifying on Bluesky.")
+		return
+	}
+
+	err := db.SetNotificationSettings(s.Db, db.NotificationSettings{
+		Did:     did,
+		Email:   r.FormValue("email_notifications") == "on",
+		Bluesky: bluesky,
 	})
+	if err != nil {
+		log.Printf("saving notification settings: %s", err)
+		s.Pages.Notice(w, "settings-notifications-error", "Unable to save settings at this moment, try again later.")
+		return
+	}
+
+	s.Pages.HxLocation(w, "/settings/emails")
 }
 
 // buildVerificationEmail creates an email.Email struct for verification emails
@@ -214,6 +387,20 @@ func (s *Settings) emails(w http.ResponseWriter, r *http.Request) {
 		emailAddr := r.FormValue("email")
 		emailAddr = strings.TrimSpace(emailAddr)
 
+		existingEmail, err := db.GetEmail(s.Db, did, emailAddr)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("checking for existing email: %s", err)
+			}
+			s.Pages.Notice(w, "settings-emails-error", "Email not found.")
+			return
+		}
+
+		if existingEmail.Primary && existingEmail.Verified {
+			s.Pages.Notice(w, "settings-emails-error", "Make another email primary before deleting this one.")
+			return
+		}
+
 		// Begin transaction
 		tx, err := s.Db.Begin()
 		if err != nil {
@@ -229,6 +416,28 @@ func (s *Settings) emails(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// hand the primary flag over to the next verified email, if any
+		if existingEmail.Primary {
+			remaining, err := db.GetAllEmails(tx, did)
+			if err != nil {
+				log.Printf("fetching emails: %s", err)
+				s.Pages.Notice(w, "settings-emails-error", "Unable to delete email at this moment, try again later.")
+				return
+			}
+
+			for _, em := range remaining {
+				if em.Verified {
+					err = db.MakeEmailPrimary(tx, did, em.Address)
+					break
+				}
+			}
+			if err != nil {
+				log.Printf("setting primary email: %s", err)
+				s.Pages.Notice(w, "settings-emails-error", "Unable to delete email at this moment, try again later.")
+				return
+			}
+		}
+
 		// Commit transaction
 		if err := tx.Commit(); err != nil {
 			log.Printf("failed to commit transaction: %s", err)
@@ -241,7 +450,11 @@ func (s *Settings) emails(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Settings) verifyUrl(did string, email string, code string) string {
+// verification links stop working after this long, or as soon as a new one
+// is sent
+const verificationTokenTTL = 24 * time.Hour
+
+func (s *Settings) verifyUrl(did string, emailAddr string, code string) string {
 	var appUrl string
 	if s.Config.Core.Dev {
 		appUrl = "http://" + s.Config.Core.ListenAddr
@@ -249,33 +462,90 @@ func (s *Settings) verifyUrl(did string, email string, code string) string {
 		appUrl = "https://tangled.sh"
 	}
 
-	return fmt.Sprintf("%s/settings/emails/verify?did=%s&email=%s&code=%s", appUrl, url.QueryEscape(did), url.QueryEscape(email), url.QueryEscape(code))
+	token := email.SignToken(s.Config.Core.Secret("email-verify"), email.VerificationToken{
+		Did:     did,
+		Address: emailAddr,
+		Code:    code,
+		Expires: time.Now().Add(verificationTokenTTL).Unix(),
+	})
+
+	return fmt.Sprintf("%s/settings/emails/verify?token=%s", appUrl, url.QueryEscape(token))
 }
 
 func (s *Settings) emailsVerify(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query()
+	user := s.OAuth.GetUser(r)
+
+	// this is a link followed from an email, so errors are shown on the
+	// settings page rather than as htmx notices
+	fail := func(msg string) {
+		emails, err := db.GetAllEmails(s.Db, user.Did)
+		if err != nil {
+			log.Println(err)
+		}
+
+		s.Pages.UserEmailsSettings(w, pages.UserEmailsSettingsParams{
+			LoggedInUser: user,
+			Emails:       emails,
+			Error:        msg,
+			Tabs:         s.tabs(user.Did),
+			Tab:          "emails",
+		})
+	}
+
+	token, err := email.ParseToken(s.Config.Core.Secret("email-verify"), r.URL.Query().Get("token"), time.Now())
+	if errors.Is(err, email.ErrExpiredToken) {
+		fail("This verification link has expired. Please request a new verification email.")
+		return
+	}
+	if err != nil {
+		fail("Invalid verification link. Please request a new verification email.")
+		return
+	}
 
-	// Get the parameters directly from the query
-	emailAddr := q.Get("email")
-	did := q.Get("did")
-	code := q.Get("code")
+	// the link only proves access to the mailbox, make sure that the person
+	// following it is also the one who added the email
+	if token.Did != user.Did {
+		fail("This verification link was sent to a different account. Log in with that account and try again.")
+		return
+	}
 
-	valid, err := db.CheckValidVerificationCode(s.Db, did, emailAddr, code)
+	valid, err := db.CheckValidVerificationCode(s.Db, token.Did, token.Address, token.Code)
 	if err != nil {
 		log.Printf("checking email verification: %s", err)
-		s.Pages.Notice(w, "settings-emails-error", "Error verifying email. Please try again later.")
+		fail("Error verifying email. Please try again later.")
 		return
 	}
 
 	if !valid {
-		s.Pages.Notice(w, "settings-emails-error", "Invalid verification code. Please request a new verification email.")
+		fail("This verification link is no longer valid. Please use the latest verification email.")
 		return
 	}
 
+	tx, err := s.Db.Begin()
+	if err != nil {
+		log.Printf("failed to start transaction: %s", err)
+		fail("Error updating email verification status. Please try again later.")
+		return
+	}
+	defer tx.Rollback()
+
 	// Mark email as verified in the database
-	if err := db.MarkEmailVerified(s.Db, did, emailAddr); err != nil {
+	if err := db.MarkEmailVerified(tx, token.Did, token.Address); err != nil {
 		log.Printf("marking email as verified: %s", err)
-		s.Pages.Notice(w, "settings-emails-error", "Error updating email verification status. Please try again later.")
+		fail("Error updating email verification status. Please try again later.")
+		return
+	}
+
+	// the first email is made primary before it is verified
+	if err := db.EnsurePrimaryEmail(tx, token.Did, token.Address); err != nil {
+		log.Printf("setting primary email: %s", err)
+		fail("Error updating email verification status. Please try again later.")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("failed to commit transaction: %s", err)
+		fail("Error updating email verification status. Please try again later.")
 		return
 	}
 
@@ -368,6 +638,20 @@ func (s *Settings) emailsPrimary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	existingEmail, err := db.GetEmail(s.Db, did, emailAddr)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("checking for existing email: %s", err)
+		}
+		s.Pages.Notice(w, "settings-emails-error", "Email not found. Please add it first.")
+		return
+	}
+
+	if !existingEmail.Verified {
+		s.Pages.Notice(w, "settings-emails-error", "Only verified emails can be made primary.")
+		return
+	}
+
 	if err := db.MakeEmailPrimary(s.Db, did, emailAddr); err != nil {
 		log.Printf("setting primary email: %s", err)
 		s.Pages.Notice(w, "settings-emails-error", "Error setting primary email. Please try again later.")
@@ -394,12 +678,16 @@ func (s *Settings) keys(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		_, _, _, _, err = ssh.ParseAuthorizedKey([]byte(key))
+		info, err := crypto.ParseSSHKey(key)
 		if err != nil {
 			log.Printf("parsing public key: %s", err)
 			s.Pages.Notice(w, "settings-keys", "That doesn't look like a valid public key. Make sure it's a <strong>public</strong> key.")
 			return
 		}
+		if weakness := info.Weakness(); weakness != "" {
+			s.Pages.Notice(w, "settings-keys", fmt.Sprintf("This key is too weak: %s. Generate a new one with <code>ssh-keygen -t ed25519</code>.", weakness))
+			return
+		}
 
 		rkey := tid.TID()
 
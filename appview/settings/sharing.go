@@ -0,0 +1,42 @@
+package settings
+
+import (
+	"log"
+	"net/http"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+)
+
+func (s *Settings) sharingSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+
+	settings, err := db.GetShareSettings(s.Db, user.Did)
+	if err != nil {
+		log.Println(err)
+	}
+
+	s.Pages.UserSharingSettings(w, pages.UserSharingSettingsParams{
+		LoggedInUser: user,
+		Settings:     settings,
+		Tabs:         s.tabs(user.Did),
+		Tab:          "sharing",
+	})
+}
+
+func (s *Settings) sharing(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	err := db.SetShareSettings(s.Db, db.ShareSettings{
+		Did:              did,
+		AnnounceRepos:    r.FormValue("announce_repos") == "on",
+		AnnounceReleases: r.FormValue("announce_releases") == "on",
+	})
+	if err != nil {
+		log.Println("failed to save share settings", err)
+		s.Pages.Notice(w, "settings-sharing-error", "Failed to save settings, try again later.")
+		return
+	}
+
+	s.Pages.HxRefresh(w)
+}
@@ -0,0 +1,148 @@
+package settings
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	gossh "golang.org/x/crypto/ssh"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/crypto"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+func validSigningKey(kind, key string) bool {
+	switch kind {
+	case db.SigningKeySSH:
+		_, _, _, _, err := gossh.ParseAuthorizedKey([]byte(key))
+		return err == nil
+	case db.SigningKeyGPG:
+		// an armored secret key parses as a keyring too
+		if strings.Contains(key, "PRIVATE KEY") {
+			return false
+		}
+		_, err := crypto.PGPFingerprint(key)
+		return err == nil
+	}
+	return false
+}
+
+func (s *Settings) signingKeys(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	switch r.Method {
+	case http.MethodPut:
+		name := strings.TrimSpace(r.FormValue("name"))
+		kind := r.FormValue("kind")
+		key := strings.TrimSpace(r.FormValue("key"))
+		publish := r.FormValue("publish") == "on"
+
+		if name == "" {
+			s.Pages.Notice(w, "settings-signing-keys", "Key name cannot be empty.")
+			return
+		}
+
+		if !validSigningKey(kind, key) {
+			s.Pages.Notice(w, "settings-signing-keys", "That doesn't look like a valid public key. Make sure it's a <strong>public</strong> key.")
+			return
+		}
+
+		rkey := tid.TID()
+
+		tx, err := s.Db.Begin()
+		if err != nil {
+			log.Printf("failed to start tx; adding signing key: %s", err)
+			s.Pages.Notice(w, "settings-signing-keys", "Unable to add signing key at this moment, try again later.")
+			return
+		}
+		defer tx.Rollback()
+
+		err = db.AddSigningKey(tx, db.SigningKey{
+			Did:       did,
+			Rkey:      rkey,
+			Name:      name,
+			Kind:      kind,
+			Key:       key,
+			Published: publish,
+		})
+		if err != nil {
+			log.Printf("adding signing key: %s", err)
+			s.Pages.Notice(w, "settings-signing-keys", "Failed to add signing key.")
+			return
+		}
+
+		if publish {
+			client, err := s.OAuth.AuthorizedClient(r)
+			if err != nil {
+				s.Pages.Notice(w, "settings-signing-keys", "Failed to authorize. Try again later.")
+				return
+			}
+
+			_, err = client.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
+				Collection: tangled.SigningKeyNSID,
+				Repo:       did,
+				Rkey:       rkey,
+				Record: &lexutil.LexiconTypeDecoder{
+					Val: &tangled.SigningKey{
+						CreatedAt: time.Now().Format(time.RFC3339),
+						Key:       key,
+						Kind:      kind,
+						Name:      name,
+					}},
+			})
+			if err != nil {
+				log.Printf("failed to create record: %s", err)
+				s.Pages.Notice(w, "settings-signing-keys", "Failed to create record.")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("failed to commit tx; adding signing key: %s", err)
+			s.Pages.Notice(w, "settings-signing-keys", "Unable to add signing key at this moment, try again later.")
+			return
+		}
+
+		s.Pages.HxLocation(w, "/settings/keys")
+
+	case http.MethodDelete:
+		rkey := r.URL.Query().Get("rkey")
+
+		keys, err := db.GetSigningKeys(s.Db, db.FilterEq("did", did), db.FilterEq("rkey", rkey))
+		if err != nil || len(keys) == 0 {
+			s.Pages.Notice(w, "settings-signing-keys", "Signing key not found.")
+			return
+		}
+
+		if err := db.DeleteSigningKeyByRkey(s.Db, did, rkey); err != nil {
+			log.Printf("removing signing key: %s", err)
+			s.Pages.Notice(w, "settings-signing-keys", "Failed to remove signing key.")
+			return
+		}
+
+		if keys[0].Published {
+			client, err := s.OAuth.AuthorizedClient(r)
+			if err != nil {
+				s.Pages.Notice(w, "settings-signing-keys", "Failed to authorize client.")
+				return
+			}
+
+			_, err = client.RepoDeleteRecord(r.Context(), &comatproto.RepoDeleteRecord_Input{
+				Collection: tangled.SigningKeyNSID,
+				Repo:       did,
+				Rkey:       rkey,
+			})
+			if err != nil {
+				log.Printf("failed to delete record from PDS: %s", err)
+				s.Pages.Notice(w, "settings-signing-keys", "Failed to remove key from PDS.")
+				return
+			}
+		}
+
+		s.Pages.HxLocation(w, "/settings/keys")
+	}
+}
@@ -0,0 +1,84 @@
+package settings
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/takeout"
+)
+
+func (s *Settings) takeoutSettings(w http.ResponseWriter, r *http.Request) {
+	user := s.OAuth.GetUser(r)
+
+	remaining, err := takeout.Remaining(s.Db, user.Did)
+	if err != nil {
+		log.Println("failed to count takeouts", err)
+	}
+
+	s.Pages.UserTakeoutSettings(w, pages.UserTakeoutSettingsParams{
+		LoggedInUser: user,
+		Remaining:    remaining,
+		Limit:        takeout.Limit,
+		Tabs:         s.tabs(user.Did),
+		Tab:          "takeout",
+	})
+}
+
+// takeout streams a zip archive of everything the appview holds about the
+// user, along with git bundles of their repos
+func (s *Settings) takeout(w http.ResponseWriter, r *http.Request) {
+	did := s.OAuth.GetDid(r)
+
+	remaining, err := takeout.Remaining(s.Db, did)
+	if err != nil {
+		log.Println("failed to count takeouts", err)
+		http.Error(w, "Failed to create archive. Try again later.", http.StatusInternalServerError)
+		return
+	}
+	if remaining == 0 {
+		http.Error(w, "You have downloaded too many archives recently. Try again tomorrow.", http.StatusTooManyRequests)
+		return
+	}
+
+	repos, err := db.GetAllReposByDid(s.Db, did)
+	if err != nil {
+		log.Println("failed to get repos", err)
+		http.Error(w, "Failed to create archive. Try again later.", http.StatusInternalServerError)
+		return
+	}
+
+	if err := db.AddTakeout(s.Db, did, "user"); err != nil {
+		log.Println("failed to record takeout", err)
+		http.Error(w, "Failed to create archive. Try again later.", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("tangled-%s-%s.zip", did, time.Now().UTC().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// from here on the response is under way, failures can only be logged
+	a := takeout.NewArchive(w)
+	defer a.Close()
+
+	if err := takeout.WriteUser(a, s.Db, did); err != nil {
+		log.Println("failed to write takeout", did, err)
+		return
+	}
+
+	for _, repo := range repos {
+		dir := "repos/" + repo.Name + "/"
+		if err := takeout.WriteRepo(a, s.Db, dir, repo); err != nil {
+			log.Println("failed to write takeout", repo.RepoAt(), err)
+			return
+		}
+		if err := takeout.WriteBundle(r.Context(), a, dir+repo.Name+".bundle", repo, s.Config.Core.Dev); err != nil {
+			// the knot may well be down, the rest of the archive is still of use
+			log.Println("failed to fetch bundle", repo.RepoAt(), err)
+		}
+	}
+}
@@ -10,10 +10,12 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/posthog/posthog-go"
+	"tangled.sh/tangled.sh/core/appview/challenge"
 	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/db"
 	"tangled.sh/tangled.sh/core/appview/dns"
 	"tangled.sh/tangled.sh/core/appview/email"
+	"tangled.sh/tangled.sh/core/appview/middleware"
 	"tangled.sh/tangled.sh/core/appview/pages"
 	"tangled.sh/tangled.sh/core/appview/state/userutil"
 	"tangled.sh/tangled.sh/core/appview/xrpcclient"
@@ -102,10 +104,10 @@ func (s *Signup) isNicknameAllowed(nickname string) bool {
 	return !s.disallowedNicknames[strings.ToLower(nickname)]
 }
 
-func (s *Signup) Router() http.Handler {
+func (s *Signup) Router(mw *middleware.Middleware) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", s.signup)
-	r.Post("/", s.signup)
+	r.With(mw.Challenge(challenge.ActionSignup)).Post("/", s.signup)
 	r.Get("/complete", s.complete)
 	r.Post("/complete", s.complete)
 
@@ -123,6 +125,11 @@ func (s *Signup) signup(w http.ResponseWriter, r *http.Request) {
 		emailId := r.FormValue("email")
 
 		noticeId := "signup-msg"
+		if s.config.Registration.Policy == config.RegistrationClosed {
+			s.pages.Notice(w, noticeId, `Signups are closed. <a href="/waitlist" class="underline">Join the waitlist</a> to hear when they open.`)
+			return
+		}
+
 		if !email.IsValidEmail(emailId) {
 			s.pages.Notice(w, noticeId, "Invalid email address.")
 			return
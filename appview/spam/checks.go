@@ -0,0 +1,215 @@
+package spam
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// Blocklist flags content by blocked authors, or content mentioning any of
+// the blocked words and domains
+type Blocklist struct {
+	Dids  map[string]bool
+	Terms []string
+}
+
+// LoadBlocklist reads a blocklist file, one entry per line. Empty lines and
+// lines starting with # are skipped.
+func LoadBlocklist(path string, logger *slog.Logger) Blocklist {
+	b := Blocklist{Dids: make(map[string]bool)}
+
+	if path == "" {
+		return b
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Warn("failed to open spam blocklist", "file", path, "err", err)
+		return b
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "did:") {
+			b.Dids[line] = true
+		} else {
+			b.Terms = append(b.Terms, strings.ToLower(line))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		logger.Error("failed to read spam blocklist", "file", path, "err", err)
+	}
+
+	logger.Info("loaded spam blocklist", "dids", len(b.Dids), "terms", len(b.Terms), "file", path)
+	return b
+}
+
+func (b Blocklist) Check(ctx context.Context, c Content) (Verdict, error) {
+	if b.Dids[c.AuthorDid] {
+		return Verdict{Spam: true, Reason: "author is blocked"}, nil
+	}
+
+	text := strings.ToLower(c.Title + "\n" + c.Body)
+	for _, term := range b.Terms {
+		if strings.Contains(text, term) {
+			return Verdict{Spam: true, Reason: fmt.Sprintf("mentions blocked term %q", term)}, nil
+		}
+	}
+
+	return Ham, nil
+}
+
+var linkRegex = regexp.MustCompile(`(?i)\bhttps?://[^\s)>\]]+`)
+
+// links are only considered dense beyond a handful of them, a short comment
+// pointing at two docs pages is fine
+const minDenseLinks = 3
+
+// LinkDensity flags content that is mostly links
+type LinkDensity struct {
+	MaxLinks   int
+	MaxDensity float64
+}
+
+func (l LinkDensity) Check(ctx context.Context, c Content) (Verdict, error) {
+	text := c.Title + "\n" + c.Body
+
+	links := len(linkRegex.FindAllString(text, -1))
+	if links == 0 {
+		return Ham, nil
+	}
+
+	if l.MaxLinks > 0 && links > l.MaxLinks {
+		return Verdict{Spam: true, Reason: fmt.Sprintf("contains %d links", links)}, nil
+	}
+
+	words := len(strings.Fields(text))
+	if l.MaxDensity > 0 && links >= minDenseLinks && float64(links)/float64(words) > l.MaxDensity {
+		return Verdict{Spam: true, Reason: fmt.Sprintf("%d of %d words are links", links, words)}, nil
+	}
+
+	return Ham, nil
+}
+
+// RateLimit flags new accounts that open too many issues and comments in a
+// short while. Accounts are new until the appview has known them for
+// NewAccountAge.
+type RateLimit struct {
+	Db            db.Execer
+	NewAccountAge time.Duration
+	Limit         int
+	Window        time.Duration
+}
+
+func (r RateLimit) Check(ctx context.Context, c Content) (Verdict, error) {
+	if r.Limit <= 0 {
+		return Ham, nil
+	}
+
+	firstSeen, err := db.GetFirstActivity(r.Db, c.AuthorDid)
+	if err != nil {
+		return Ham, err
+	}
+	if firstSeen != nil && time.Since(*firstSeen) > r.NewAccountAge {
+		return Ham, nil
+	}
+
+	count, err := db.CountRecentActivity(r.Db, c.AuthorDid, time.Now().Add(-r.Window))
+	if err != nil {
+		return Ham, err
+	}
+
+	if count >= r.Limit {
+		return Verdict{Spam: true, Reason: fmt.Sprintf("new account posted %d times in %s", count+1, r.Window)}, nil
+	}
+
+	return Ham, nil
+}
+
+// Classifier asks an external service, content is posted as json and the
+// service responds with {"spam": bool, "reason": string}
+type Classifier struct {
+	Url    string
+	client *http.Client
+}
+
+func NewClassifier(url string, timeout time.Duration) Classifier {
+	return Classifier{
+		Url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type classifierRequest struct {
+	Kind   Kind   `json:"kind"`
+	Author string `json:"author"`
+	Repo   string `json:"repo"`
+	Title  string `json:"title,omitempty"`
+	Body   string `json:"body"`
+}
+
+type classifierResponse struct {
+	Spam   bool   `json:"spam"`
+	Reason string `json:"reason"`
+}
+
+func (cl Classifier) Check(ctx context.Context, c Content) (Verdict, error) {
+	payload, err := json.Marshal(classifierRequest{
+		Kind:   c.Kind,
+		Author: c.AuthorDid,
+		Repo:   c.RepoAt.String(),
+		Title:  c.Title,
+		Body:   c.Body,
+	})
+	if err != nil {
+		return Ham, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cl.Url, bytes.NewReader(payload))
+	if err != nil {
+		return Ham, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cl.client.Do(req)
+	if err != nil {
+		return Ham, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Ham, fmt.Errorf("classifier responded with %s", resp.Status)
+	}
+
+	var res classifierResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Ham, fmt.Errorf("invalid classifier response: %w", err)
+	}
+
+	if !res.Spam {
+		return Ham, nil
+	}
+
+	reason := res.Reason
+	if reason == "" {
+		reason = "flagged by classifier"
+	}
+	return Verdict{Spam: true, Reason: reason}, nil
+}
@@ -0,0 +1,59 @@
+package spam
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLinkDensity(t *testing.T) {
+	check := LinkDensity{MaxLinks: 10, MaxDensity: 0.3}
+
+	tests := []struct {
+		name string
+		body string
+		spam bool
+	}{
+		{"no links", "the build fails on arm64", false},
+		{"a couple of links", "see https://a.example/docs and https://b.example/faq", false},
+		{"links in prose", "this started after https://a.example/1 landed, https://a.example/2 and https://a.example/3 look related but the crash is in the parser which neither of them touched as far as i can tell", false},
+		{"mostly links", "cheap https://a.example https://b.example https://c.example", true},
+		{"too many links", strings.Repeat("release notes mention https://a.example/x here ", 11), true},
+	}
+
+	for _, tt := range tests {
+		v, err := check.Check(context.Background(), Content{Body: tt.body})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if v.Spam != tt.spam {
+			t.Errorf("%s: got spam=%v (%s), want %v", tt.name, v.Spam, v.Reason, tt.spam)
+		}
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	check := Blocklist{
+		Dids:  map[string]bool{"did:plc:spammer": true},
+		Terms: []string{"casino.example"},
+	}
+
+	tests := []struct {
+		content Content
+		spam    bool
+	}{
+		{Content{AuthorDid: "did:plc:spammer", Body: "hello"}, true},
+		{Content{AuthorDid: "did:plc:someone", Title: "Visit CASINO.example today"}, true},
+		{Content{AuthorDid: "did:plc:someone", Body: "the tests are flaky"}, false},
+	}
+
+	for _, tt := range tests {
+		v, err := check.Check(context.Background(), tt.content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Spam != tt.spam {
+			t.Errorf("%+v: got spam=%v, want %v", tt.content, v.Spam, tt.spam)
+		}
+	}
+}
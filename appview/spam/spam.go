@@ -0,0 +1,95 @@
+// Package spam decides whether new issues and comments should be
+// shadow-hidden before they are indexed. Hidden content is only shown to its
+// author and to the admins of its repo, who can approve or delete it.
+package spam
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+type Kind string
+
+const (
+	KindIssue   Kind = "issue"
+	KindComment Kind = "comment"
+)
+
+// Content is an issue or comment that is about to be indexed
+type Content struct {
+	Kind      Kind
+	AuthorDid string
+	RepoAt    syntax.ATURI
+	Title     string
+	Body      string
+}
+
+type Verdict struct {
+	Spam   bool
+	Reason string
+}
+
+var Ham = Verdict{}
+
+// Check is a single heuristic, filters run them in order
+type Check interface {
+	Check(ctx context.Context, c Content) (Verdict, error)
+}
+
+type Filter struct {
+	checks []Check
+	logger *slog.Logger
+}
+
+// New builds the checks enabled by cfg
+func New(cfg config.SpamConfig, e db.Execer, logger *slog.Logger) *Filter {
+	checks := []Check{
+		LoadBlocklist(cfg.BlocklistFile, logger),
+		LinkDensity{MaxLinks: cfg.MaxLinks, MaxDensity: cfg.MaxLinkDensity},
+		RateLimit{
+			Db:            e,
+			NewAccountAge: cfg.NewAccountAge,
+			Limit:         cfg.NewAccountLimit,
+			Window:        cfg.RateWindow,
+		},
+	}
+
+	if cfg.ClassifierUrl != "" {
+		checks = append(checks, NewClassifier(cfg.ClassifierUrl, cfg.ClassifierTimeout))
+	}
+
+	return NewWithChecks(logger, checks...)
+}
+
+func NewWithChecks(logger *slog.Logger, checks ...Check) *Filter {
+	return &Filter{checks: checks, logger: logger}
+}
+
+// Check returns the verdict of the first check that flags c. Checks that
+// fail are logged and skipped, content is never held back because a check
+// is unavailable.
+func (f *Filter) Check(ctx context.Context, c Content) Verdict {
+	if f == nil {
+		return Ham
+	}
+
+	for _, check := range f.checks {
+		v, err := check.Check(ctx, c)
+		if err != nil {
+			f.logger.Warn("spam check failed", "check", fmt.Sprintf("%T", check), "err", err)
+			continue
+		}
+
+		if v.Spam {
+			f.logger.Info("hiding content", "kind", c.Kind, "author", c.AuthorDid, "repo", c.RepoAt, "reason", v.Reason)
+			return v
+		}
+	}
+
+	return Ham
+}
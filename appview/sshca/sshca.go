@@ -0,0 +1,71 @@
+package sshca
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"golang.org/x/crypto/ssh"
+)
+
+// Authority issues short-lived ssh certificates for users, which knots that
+// trust it accept in place of the user's keys
+type Authority struct {
+	signer   ssh.Signer
+	validity time.Duration
+}
+
+// Load reads the private key of the authority, in OpenSSH format
+func Load(path string, validity time.Duration) (*Authority, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(pem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ca key: %w", err)
+	}
+
+	return &Authority{signer: signer, validity: validity}, nil
+}
+
+// PublicKey returns the key knots should trust, in authorized_keys format
+func (a *Authority) PublicKey() []byte {
+	return ssh.MarshalAuthorizedKey(a.signer.PublicKey())
+}
+
+// Issue signs a certificate for key that identifies its holder as did. The
+// did is both the key id and the only principal, knots use it to find out who
+// is pushing.
+func (a *Authority) Issue(did syntax.DID, key ssh.PublicKey) (*ssh.Certificate, error) {
+	if _, ok := key.(*ssh.Certificate); ok {
+		return nil, fmt.Errorf("cannot issue a certificate for a certificate")
+	}
+
+	var serial [8]byte
+	if _, err := rand.Read(serial[:]); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             key,
+		Serial:          binary.BigEndian.Uint64(serial[:]),
+		CertType:        ssh.UserCert,
+		KeyId:           did.String(),
+		ValidPrincipals: []string{did.String()},
+		// allow for some clock skew between the appview and knots
+		ValidAfter:  uint64(now.Add(-5 * time.Minute).Unix()),
+		ValidBefore: uint64(now.Add(a.validity).Unix()),
+	}
+
+	if err := cert.SignCert(rand.Reader, a.signer); err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+
+	return cert, nil
+}
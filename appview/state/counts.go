@@ -0,0 +1,40 @@
+package state
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// rebuildRepoCounts recounts the stars, issues and pulls of all repos once a
+// day, in case the counters kept by triggers drifted.
+func rebuildRepoCounts(ctx context.Context, d *db.DB, l *slog.Logger) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		tx, err := d.BeginTx(ctx, nil)
+		if err != nil {
+			l.Error("failed to start transaction", "err", err)
+			continue
+		}
+
+		if err := db.RebuildRepoCounts(tx); err != nil {
+			tx.Rollback()
+			l.Error("failed to rebuild repo counts", "err", err)
+			continue
+		}
+
+		if err := tx.Commit(); err != nil {
+			l.Error("failed to commit repo counts", "err", err)
+		}
+	}
+}
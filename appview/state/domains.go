@@ -0,0 +1,26 @@
+package state
+
+import (
+	"net/http"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+// DomainAsk is meant to be used as the on-demand TLS "ask" endpoint of the
+// reverse proxy in front of the appview (eg. caddy's on_demand_tls), so that
+// certificates are only ever issued for verified custom domains.
+func (s *State) DomainAsk(w http.ResponseWriter, r *http.Request) {
+	domain := strings.ToLower(r.URL.Query().Get("domain"))
+	if domain == "" {
+		http.Error(w, "missing domain", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := db.GetVerifiedDomain(s.db, domain); err != nil {
+		http.Error(w, "unknown domain", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -20,6 +20,11 @@ func (s *State) InfoRefs(w http.ResponseWriter, r *http.Request) {
 		scheme = "http"
 	}
 
+	// every clone or fetch starts by listing refs
+	if r.URL.Query().Get("service") == "git-upload-pack" {
+		s.traffic.Clone(r, repo)
+	}
+
 	targetURL := fmt.Sprintf("%s://%s/%s/%s/info/refs?%s", scheme, repo.Knot, user.DID, repo.Name, r.URL.RawQuery)
 	s.proxyRequest(w, r, targetURL)
 
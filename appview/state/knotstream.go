@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"tangled.sh/tangled.sh/core/appview/cache"
 	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/notify"
 	ec "tangled.sh/tangled.sh/core/eventconsumer"
 	"tangled.sh/tangled.sh/core/eventconsumer/cursor"
 	"tangled.sh/tangled.sh/core/log"
@@ -23,7 +25,7 @@ import (
 	"github.com/posthog/posthog-go"
 )
 
-func Knotstream(ctx context.Context, c *config.Config, d *db.DB, enforcer *rbac.Enforcer, posthog posthog.Client) (*ec.Consumer, error) {
+func Knotstream(ctx context.Context, c *config.Config, d *db.DB, enforcer *rbac.Enforcer, posthog posthog.Client, notifier notify.Notifier) (*ec.Consumer, error) {
 	knots, err := db.GetRegistrations(
 		d,
 		db.FilterIsNot("registered", "null"),
@@ -44,7 +46,7 @@ func Knotstream(ctx context.Context, c *config.Config, d *db.DB, enforcer *rbac.
 
 	cfg := ec.ConsumerConfig{
 		Sources:           srcs,
-		ProcessFunc:       knotIngester(d, enforcer, posthog, c.Core.Dev),
+		ProcessFunc:       knotIngester(d, enforcer, posthog, notifier, c.Core.Dev),
 		RetryInterval:     c.Knotstream.RetryInterval,
 		MaxRetryInterval:  c.Knotstream.MaxRetryInterval,
 		ConnectionTimeout: c.Knotstream.ConnectionTimeout,
@@ -58,11 +60,11 @@ func Knotstream(ctx context.Context, c *config.Config, d *db.DB, enforcer *rbac.
 	return ec.NewConsumer(cfg), nil
 }
 
-func knotIngester(d *db.DB, enforcer *rbac.Enforcer, posthog posthog.Client, dev bool) ec.ProcessFunc {
+func knotIngester(d *db.DB, enforcer *rbac.Enforcer, posthog posthog.Client, notifier notify.Notifier, dev bool) ec.ProcessFunc {
 	return func(ctx context.Context, source ec.Source, msg ec.Message) error {
 		switch msg.Nsid {
 		case tangled.GitRefUpdateNSID:
-			return ingestRefUpdate(d, enforcer, posthog, dev, source, msg)
+			return ingestRefUpdate(ctx, d, enforcer, posthog, notifier, dev, source, msg)
 		case tangled.PipelineNSID:
 			return ingestPipeline(d, source, msg)
 		}
@@ -71,7 +73,7 @@ func knotIngester(d *db.DB, enforcer *rbac.Enforcer, posthog posthog.Client, dev
 	}
 }
 
-func ingestRefUpdate(d *db.DB, enforcer *rbac.Enforcer, pc posthog.Client, dev bool, source ec.Source, msg ec.Message) error {
+func ingestRefUpdate(ctx context.Context, d *db.DB, enforcer *rbac.Enforcer, pc posthog.Client, notifier notify.Notifier, dev bool, source ec.Source, msg ec.Message) error {
 	var record tangled.GitRefUpdate
 	err := json.Unmarshal(msg.EventJson, &record)
 	if err != nil {
@@ -88,16 +90,22 @@ func ingestRefUpdate(d *db.DB, enforcer *rbac.Enforcer, pc posthog.Client, dev b
 
 	err1 := populatePunchcard(d, record)
 	err2 := updateRepoLanguages(d, record)
+	err3 := updateRepoSite(d, record)
 
-	var err3 error
+	var err4 error
 	if !dev {
-		err3 = pc.Enqueue(posthog.Capture{
+		err4 = pc.Enqueue(posthog.Capture{
 			DistinctId: record.CommitterDid,
 			Event:      "git_ref_update",
 		})
 	}
 
-	return errors.Join(err1, err2, err3)
+	err5 := updateRecentPushes(d, record)
+	err6 := db.SetRepoPushed(d, record.RepoDid, record.RepoName, time.Now())
+
+	notifier.NewPush(ctx, &record)
+
+	return errors.Join(err1, err2, err3, err4, err5, err6)
 }
 
 func populatePunchcard(d *db.DB, record tangled.GitRefUpdate) error {
@@ -129,7 +137,42 @@ func populatePunchcard(d *db.DB, record tangled.GitRefUpdate) error {
 		Date:  time.Now(),
 		Count: count,
 	}
-	return db.AddPunch(d, punch)
+	if err := db.AddPunch(d, punch); err != nil {
+		return err
+	}
+
+	// commits by others, such as co-authors, count for whoever verified
+	// their email
+	if record.Meta == nil || record.Meta.CommitCount == nil {
+		return nil
+	}
+	var emails []string
+	for _, ce := range record.Meta.CommitCount.ByEmail {
+		if ce != nil {
+			emails = append(emails, ce.Email)
+		}
+	}
+	emailToDid, err := db.GetEmailToDid(d, emails, true)
+	if err != nil {
+		return err
+	}
+
+	others := make(map[string]int)
+	for _, ce := range record.Meta.CommitCount.ByEmail {
+		if ce == nil {
+			continue
+		}
+		if did, ok := emailToDid[ce.Email]; ok && did != record.CommitterDid {
+			others[did] += int(ce.Count)
+		}
+	}
+	for did, count := range others {
+		if err := db.AddPunch(d, db.Punch{Did: did, Date: punch.Date, Count: count}); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func updateRepoLanguages(d *db.DB, record tangled.GitRefUpdate) error {
@@ -174,6 +217,71 @@ func updateRepoLanguages(d *db.DB, record tangled.GitRefUpdate) error {
 	return db.InsertRepoLanguages(d, langs)
 }
 
+// redeploy the repo's static site if this push was to the branch it is
+// published from
+// updateRecentPushes remembers pushes to branches other than the default one,
+// so that the pusher can be offered to open a pull request from them
+func updateRecentPushes(d *db.DB, record tangled.GitRefUpdate) error {
+	ref := plumbing.ReferenceName(record.Ref)
+	if !ref.IsBranch() || (record.Meta != nil && record.Meta.IsDefaultRef) {
+		return nil
+	}
+
+	repo, err := db.GetRepo(d, record.RepoDid, record.RepoName)
+	if err != nil {
+		return fmt.Errorf("failed to look for repo in DB (%s/%s): %w", record.RepoDid, record.RepoName, err)
+	}
+
+	// nothing to offer once the branch is gone
+	if plumbing.NewHash(record.NewSha).IsZero() {
+		return db.DeleteRecentPushes(
+			d,
+			db.FilterEq("repo_at", repo.RepoAt()),
+			db.FilterEq("branch", ref.Short()),
+		)
+	}
+
+	if err := db.AddRecentPush(d, record.CommitterDid, repo.RepoAt(), ref.Short()); err != nil {
+		return err
+	}
+
+	return db.DeleteRecentPushes(d, db.FilterLte("pushed", time.Now().Add(-24*time.Hour).UTC().Format(time.RFC3339)))
+}
+
+func updateRepoSite(d *db.DB, record tangled.GitRefUpdate) error {
+	ref := plumbing.ReferenceName(record.Ref)
+	if !ref.IsBranch() {
+		return nil
+	}
+
+	repos, err := db.GetRepos(
+		d,
+		0,
+		db.FilterEq("did", record.RepoDid),
+		db.FilterEq("name", record.RepoName),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to look for repo in DB (%s/%s): %w", record.RepoDid, record.RepoName, err)
+	}
+	if len(repos) != 1 {
+		return fmt.Errorf("incorrect number of repos returned: %d (expected 1)", len(repos))
+	}
+	repo := repos[0]
+
+	site, err := db.GetSite(d, repo.RepoAt())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if site.Branch != ref.Short() {
+		return nil
+	}
+
+	return db.DeploySite(d, repo.RepoAt(), record.NewSha)
+}
+
 func ingestPipeline(d *db.DB, source ec.Source, msg ec.Message) error {
 	var record tangled.Pipeline
 	err := json.Unmarshal(msg.EventJson, &record)
@@ -0,0 +1,114 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/ogcard"
+)
+
+// https://oembed.com/#section2.3
+type oEmbedResponse struct {
+	Version         string `json:"version"`
+	Type            string `json:"type"`
+	Title           string `json:"title,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	AuthorUrl       string `json:"author_url,omitempty"`
+	ProviderName    string `json:"provider_name"`
+	ProviderUrl     string `json:"provider_url"`
+	CacheAge        int    `json:"cache_age,omitempty"`
+	ThumbnailUrl    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+}
+
+// OEmbed describes repo, issue and pull urls for consumers that prefer oEmbed
+// over scraping opengraph tags. Only the json format is supported.
+func (s *State) OEmbed(w http.ResponseWriter, r *http.Request) {
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+		http.Error(w, "only json is supported", http.StatusNotImplemented)
+		return
+	}
+
+	target, err := url.Parse(r.URL.Query().Get("url"))
+	if err != nil || target.Path == "" {
+		http.Error(w, "invalid url", http.StatusBadRequest)
+		return
+	}
+
+	// /{owner}/{repo}[/issues/{id} | /pulls/{id}]
+	parts := strings.Split(strings.Trim(target.Path, "/"), "/")
+	if len(parts) < 2 {
+		http.Error(w, "unsupported url", http.StatusNotFound)
+		return
+	}
+
+	id, err := s.idResolver.ResolveIdent(r.Context(), strings.TrimPrefix(parts[0], "@"))
+	if err != nil {
+		http.Error(w, "unknown user", http.StatusNotFound)
+		return
+	}
+
+	repo, err := db.GetRepo(s.db, id.DID.String(), parts[1])
+	if err != nil {
+		http.Error(w, "unknown repo", http.StatusNotFound)
+		return
+	}
+
+	owner := id.DID.String()
+	if !id.Handle.IsInvalidHandle() {
+		owner = "@" + id.Handle.String()
+	}
+	base := strings.TrimSuffix(s.config.Core.AppviewHost, "/")
+	repoUrl := fmt.Sprintf("%s/%s/%s", base, owner, repo.Name)
+
+	resp := oEmbedResponse{
+		Version:         "1.0",
+		Type:            "link",
+		Title:           fmt.Sprintf("%s/%s", owner, repo.Name),
+		AuthorName:      owner,
+		AuthorUrl:       fmt.Sprintf("%s/%s", base, owner),
+		ProviderName:    "tangled",
+		ProviderUrl:     base,
+		CacheAge:        3600,
+		ThumbnailUrl:    repoUrl + "/opengraph",
+		ThumbnailWidth:  ogcard.Width,
+		ThumbnailHeight: ogcard.Height,
+	}
+
+	if len(parts) >= 4 {
+		n, err := strconv.Atoi(parts[3])
+		if err != nil {
+			http.Error(w, "unsupported url", http.StatusNotFound)
+			return
+		}
+
+		switch parts[2] {
+		case "issues":
+			issue, err := db.GetIssue(s.db, repo.RepoAt(), n)
+			if err != nil || issue.Hidden != "" {
+				http.Error(w, "unknown issue", http.StatusNotFound)
+				return
+			}
+			resp.Title = fmt.Sprintf("%s · issue #%d · %s", issue.Title, issue.IssueId, resp.Title)
+			resp.ThumbnailUrl = fmt.Sprintf("%s/issues/%d/opengraph", repoUrl, issue.IssueId)
+
+		case "pulls":
+			pull, err := db.GetPull(s.db, repo.RepoAt(), n)
+			if err != nil {
+				http.Error(w, "unknown pull", http.StatusNotFound)
+				return
+			}
+			resp.Title = fmt.Sprintf("%s · pull #%d · %s", pull.Title, pull.PullId, resp.Title)
+			resp.ThumbnailUrl = fmt.Sprintf("%s/pulls/%d/opengraph", repoUrl, pull.PullId)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -17,8 +18,11 @@ import (
 	"github.com/gorilla/feeds"
 	"tangled.sh/tangled.sh/core/api/tangled"
 	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/hydrate"
+	"tangled.sh/tangled.sh/core/appview/issues"
 	// "tangled.sh/tangled.sh/core/appview/oauth"
 	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/pagination"
 )
 
 func (s *State) Profile(w http.ResponseWriter, r *http.Request) {
@@ -26,6 +30,8 @@ func (s *State) Profile(w http.ResponseWriter, r *http.Request) {
 	switch tabVal {
 	case "repos":
 		s.reposPage(w, r)
+	case "issues":
+		s.issuesPage(w, r)
 	case "followers":
 		s.followersPage(w, r)
 	case "following":
@@ -182,6 +188,133 @@ func (s *State) reposPage(w http.ResponseWriter, r *http.Request) {
 	}
 	l = l.With("profileDid", profile.UserDid, "profileHandle", profile.UserHandle)
 
+	opts := repoListOptions(r)
+	page := repoListPage(r)
+
+	repos, err := db.ListRepos(s.db, profile.UserDid, opts, page)
+	if err != nil {
+		l.Error("failed to get repos", "err", err)
+		s.pages.Error500(w)
+		return
+	}
+
+	languages, err := db.GetRepoLanguagesOf(s.db, profile.UserDid)
+	if err != nil {
+		l.Error("failed to get repo languages", "err", err)
+	}
+
+	err = s.pages.ProfileRepos(w, pages.ProfileReposParams{
+		LoggedInUser: s.oauth.GetUser(r),
+		Repos:        repos,
+		Card:         profile,
+		Options:      opts,
+		Languages:    languages,
+		Page:         page,
+	})
+}
+
+// repoJSON is the shape of a repo as served to the tangled cli
+type repoJSON struct {
+	Name        string     `json:"name"`
+	Uri         string     `json:"uri"`
+	Owner       string     `json:"owner"`
+	Knot        string     `json:"knot"`
+	Description string     `json:"description,omitempty"`
+	Source      string     `json:"source,omitempty"`
+	Language    string     `json:"language,omitempty"`
+	Stars       int        `json:"stars"`
+	Created     time.Time  `json:"created"`
+	Pushed      *time.Time `json:"pushed,omitempty"`
+}
+
+// ReposJSON lists the repos of a user like the repos tab of their profile
+func (s *State) ReposJSON(w http.ResponseWriter, r *http.Request) {
+	ident, ok := r.Context().Value("resolvedId").(identity.Identity)
+	if !ok {
+		http.Error(w, "unknown user", http.StatusNotFound)
+		return
+	}
+
+	repos, err := db.ListRepos(s.db, ident.DID.String(), repoListOptions(r), repoListPage(r))
+	if err != nil {
+		s.logger.Error("failed to get repos", "handler", "ReposJSON", "err", err)
+		http.Error(w, "failed to load repos", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]repoJSON, 0, len(repos))
+	for _, repo := range repos {
+		rj := repoJSON{
+			Name:        repo.Name,
+			Uri:         repo.RepoAt().String(),
+			Owner:       repo.Did,
+			Knot:        repo.Knot,
+			Description: repo.Description,
+			Source:      repo.Source,
+			Created:     repo.Created,
+			Pushed:      repo.Pushed,
+		}
+		if repo.RepoStats != nil {
+			rj.Language = repo.RepoStats.Language
+			rj.Stars = repo.RepoStats.StarCount
+		}
+		resp = append(resp, rj)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func repoListOptions(r *http.Request) db.RepoListOptions {
+	opts := db.RepoListOptions{
+		Sort:     db.RepoSort(r.URL.Query().Get("sort")),
+		Kind:     db.RepoKind(r.URL.Query().Get("type")),
+		Language: r.URL.Query().Get("language"),
+	}
+
+	switch opts.Sort {
+	case db.RepoSortStars, db.RepoSortName:
+	default:
+		opts.Sort = db.RepoSortUpdated
+	}
+	switch opts.Kind {
+	case db.RepoKindSources, db.RepoKindForks:
+	default:
+		opts.Kind = ""
+	}
+
+	return opts
+}
+
+// repoListPage is the page of repos asked for, with at most maxRepoPage repos
+func repoListPage(r *http.Request) pagination.Page {
+	page, ok := r.Context().Value("page").(pagination.Page)
+	if !ok {
+		page = pagination.FirstPage()
+	}
+	if page.Limit <= 0 || page.Limit > maxRepoPage {
+		page.Limit = maxRepoPage
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	return page
+}
+
+const maxRepoPage = 100
+
+// issuesPage lists the issues across all repos of a user
+func (s *State) issuesPage(w http.ResponseWriter, r *http.Request) {
+	l := s.logger.With("handler", "issuesPage")
+
+	profile, err := s.profile(r)
+	if err != nil {
+		l.Error("failed to build profile card", "err", err)
+		s.pages.Error500(w)
+		return
+	}
+	l = l.With("profileDid", profile.UserDid, "profileHandle", profile.UserHandle)
+
 	repos, err := db.GetRepos(
 		s.db,
 		0,
@@ -193,9 +326,18 @@ func (s *State) reposPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.pages.ProfileRepos(w, pages.ProfileReposParams{
+	filter := issues.ParseTriageFilter(r)
+	issues, err := issues.TriageIssues(s.db, repos, filter)
+	if err != nil {
+		l.Error("failed to get issues", "err", err)
+		s.pages.Error500(w)
+		return
+	}
+
+	err = s.pages.ProfileIssues(w, pages.ProfileIssuesParams{
 		LoggedInUser: s.oauth.GetUser(r),
-		Repos:        repos,
+		Issues:       issues,
+		Filter:       filter,
 		Card:         profile,
 	})
 }
@@ -429,14 +571,31 @@ func (s *State) getProfileFeed(ctx context.Context, id *identity.Identity) (*fee
 		Author:  author,
 	}
 
+	// resolve the owners of every repo in the feed up front
+	var owners []string
+	for _, byMonth := range timeline.ByMonth {
+		for _, pull := range byMonth.PullEvents.Items {
+			owners = append(owners, pull.Repo.Did)
+		}
+		for _, issue := range byMonth.IssueEvents.Items {
+			owners = append(owners, issue.Metadata.Repo.Did)
+		}
+		for _, repo := range byMonth.RepoEvents {
+			if repo.Source != nil {
+				owners = append(owners, repo.Source.Did)
+			}
+		}
+	}
+	idents := hydrate.New(s.db, s.idResolver).Idents(ctx, owners...)
+
 	for _, byMonth := range timeline.ByMonth {
-		if err := s.addPullRequestItems(ctx, &feed, byMonth.PullEvents.Items, author); err != nil {
+		if err := s.addPullRequestItems(&feed, byMonth.PullEvents.Items, idents, author); err != nil {
 			return nil, err
 		}
-		if err := s.addIssueItems(ctx, &feed, byMonth.IssueEvents.Items, author); err != nil {
+		if err := s.addIssueItems(&feed, byMonth.IssueEvents.Items, idents, author); err != nil {
 			return nil, err
 		}
-		if err := s.addRepoItems(ctx, &feed, byMonth.RepoEvents, author); err != nil {
+		if err := s.addRepoItems(&feed, byMonth.RepoEvents, idents, author); err != nil {
 			return nil, err
 		}
 	}
@@ -452,11 +611,11 @@ func (s *State) getProfileFeed(ctx context.Context, id *identity.Identity) (*fee
 	return &feed, nil
 }
 
-func (s *State) addPullRequestItems(ctx context.Context, feed *feeds.Feed, pulls []*db.Pull, author *feeds.Author) error {
+func (s *State) addPullRequestItems(feed *feeds.Feed, pulls []*db.Pull, idents map[string]*identity.Identity, author *feeds.Author) error {
 	for _, pull := range pulls {
-		owner, err := s.idResolver.ResolveIdent(ctx, pull.Repo.Did)
-		if err != nil {
-			return err
+		owner, ok := idents[pull.Repo.Did]
+		if !ok {
+			return fmt.Errorf("failed to resolve %s", pull.Repo.Did)
 		}
 
 		// Add pull request creation item
@@ -465,11 +624,11 @@ func (s *State) addPullRequestItems(ctx context.Context, feed *feeds.Feed, pulls
 	return nil
 }
 
-func (s *State) addIssueItems(ctx context.Context, feed *feeds.Feed, issues []*db.Issue, author *feeds.Author) error {
+func (s *State) addIssueItems(feed *feeds.Feed, issues []*db.Issue, idents map[string]*identity.Identity, author *feeds.Author) error {
 	for _, issue := range issues {
-		owner, err := s.idResolver.ResolveIdent(ctx, issue.Metadata.Repo.Did)
-		if err != nil {
-			return err
+		owner, ok := idents[issue.Metadata.Repo.Did]
+		if !ok {
+			return fmt.Errorf("failed to resolve %s", issue.Metadata.Repo.Did)
 		}
 
 		feed.Items = append(feed.Items, s.createIssueItem(issue, owner, author))
@@ -477,9 +636,9 @@ func (s *State) addIssueItems(ctx context.Context, feed *feeds.Feed, issues []*d
 	return nil
 }
 
-func (s *State) addRepoItems(ctx context.Context, feed *feeds.Feed, repos []db.RepoEvent, author *feeds.Author) error {
+func (s *State) addRepoItems(feed *feeds.Feed, repos []db.RepoEvent, idents map[string]*identity.Identity, author *feeds.Author) error {
 	for _, repo := range repos {
-		item, err := s.createRepoItem(ctx, repo, author)
+		item, err := s.createRepoItem(repo, idents, author)
 		if err != nil {
 			return err
 		}
@@ -506,12 +665,12 @@ func (s *State) createIssueItem(issue *db.Issue, owner *identity.Identity, autho
 	}
 }
 
-func (s *State) createRepoItem(ctx context.Context, repo db.RepoEvent, author *feeds.Author) (*feeds.Item, error) {
+func (s *State) createRepoItem(repo db.RepoEvent, idents map[string]*identity.Identity, author *feeds.Author) (*feeds.Item, error) {
 	var title string
 	if repo.Source != nil {
-		sourceOwner, err := s.idResolver.ResolveIdent(ctx, repo.Source.Did)
-		if err != nil {
-			return nil, err
+		sourceOwner, ok := idents[repo.Source.Did]
+		if !ok {
+			return nil, fmt.Errorf("failed to resolve %s", repo.Source.Did)
 		}
 		title = fmt.Sprintf("%s forked repository @%s/%s to '%s'", author.Name, sourceOwner.Handle, repo.Source.Name, repo.Repo.Name)
 	} else {
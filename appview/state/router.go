@@ -6,11 +6,17 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/sessions"
+	"tangled.sh/tangled.sh/core/appview/apps"
+	"tangled.sh/tangled.sh/core/appview/bots"
+	"tangled.sh/tangled.sh/core/appview/challenge"
+	"tangled.sh/tangled.sh/core/appview/gists"
+	"tangled.sh/tangled.sh/core/appview/graphql"
 	"tangled.sh/tangled.sh/core/appview/issues"
 	"tangled.sh/tangled.sh/core/appview/knots"
 	"tangled.sh/tangled.sh/core/appview/middleware"
 	oauthhandler "tangled.sh/tangled.sh/core/appview/oauth/handler"
 	"tangled.sh/tangled.sh/core/appview/pipelines"
+	"tangled.sh/tangled.sh/core/appview/projects"
 	"tangled.sh/tangled.sh/core/appview/pulls"
 	"tangled.sh/tangled.sh/core/appview/repo"
 	"tangled.sh/tangled.sh/core/appview/settings"
@@ -24,6 +30,7 @@ import (
 func (s *State) Router() http.Handler {
 	router := chi.NewRouter()
 	middleware := middleware.New(
+		s.config,
 		s.oauth,
 		s.db,
 		s.enforcer,
@@ -32,14 +39,40 @@ func (s *State) Router() http.Handler {
 		s.pages,
 	)
 
+	router.Use(log.RequestIds(log.New("appview")))
+	router.Use(middleware.CustomDomain())
+	router.Use(middleware.RefreshHandle())
+
 	router.Get("/favicon.svg", s.Favicon)
 	router.Get("/favicon.ico", s.Favicon)
+	router.Get("/manifest.webmanifest", s.Manifest)
+	router.Get("/sw.js", s.ServiceWorker)
+	router.Get("/offline", s.Offline)
+
+	// the local object store serves its own signed urls
+	if h, ok := s.storage.(http.Handler); ok {
+		router.Handle("/storage/*", http.StripPrefix("/storage", h))
+	}
 
 	userRouter := s.UserRouter(&middleware)
 	standardRouter := s.StandardRouter(&middleware)
 
 	router.HandleFunc("/*", func(w http.ResponseWriter, r *http.Request) {
 		pat := chi.URLParam(r, "*")
+
+		// /{user}.keys and /{user}.gpg serve the keys of a user, like the
+		// equivalents on other forges
+		if !strings.Contains(pat, "/") {
+			if user, ok := strings.CutSuffix(pat, ".keys"); ok {
+				s.serveKeys(w, r, user)
+				return
+			}
+			if user, ok := strings.CutSuffix(pat, ".gpg"); ok {
+				s.serveGpgKeys(w, r, user)
+				return
+			}
+		}
+
 		if strings.HasPrefix(pat, "did:") || strings.HasPrefix(pat, "@") {
 			userRouter.ServeHTTP(w, r)
 		} else {
@@ -75,8 +108,9 @@ func (s *State) UserRouter(mw *middleware.Middleware) http.Handler {
 	r := chi.NewRouter()
 
 	r.With(mw.ResolveIdent()).Route("/{user}", func(r chi.Router) {
-		r.Get("/", s.Profile)
+		r.With(middleware.Paginate).Get("/", s.Profile)
 		r.Get("/feed.atom", s.AtomFeedPage)
+		r.With(middleware.Paginate).Get("/repos.json", s.ReposJSON)
 
 		// redirect /@handle/repo.git -> /@handle/repo
 		r.Get("/{repo}.git", func(w http.ResponseWriter, r *http.Request) {
@@ -86,10 +120,15 @@ func (s *State) UserRouter(mw *middleware.Middleware) http.Handler {
 
 		r.With(mw.ResolveRepo()).Route("/{repo}", func(r chi.Router) {
 			r.Use(mw.GoImport())
-			r.Mount("/", s.RepoRouter(mw))
-			r.Mount("/issues", s.IssuesRouter(mw))
-			r.Mount("/pulls", s.PullsRouter(mw))
-			r.Mount("/pipelines", s.PipelinesRouter(mw))
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.traffic.Views)
+				r.Mount("/", s.RepoRouter(mw))
+				r.Mount("/issues", s.IssuesRouter(mw))
+				r.Mount("/pulls", s.PullsRouter(mw))
+				r.Mount("/pipelines", s.PipelinesRouter(mw))
+				r.Mount("/projects", s.ProjectsRouter(mw))
+			})
 
 			// These routes get proxied to the knot
 			r.Get("/info/refs", s.InfoRefs)
@@ -113,12 +152,13 @@ func (s *State) StandardRouter(mw *middleware.Middleware) http.Handler {
 
 	r.Get("/", s.HomeOrTimeline)
 	r.Get("/timeline", s.Timeline)
+	r.Get("/search", s.Search)
 
 	r.Route("/repo", func(r chi.Router) {
 		r.Route("/new", func(r chi.Router) {
 			r.Use(middleware.AuthMiddleware(s.oauth))
 			r.Get("/", s.NewRepo)
-			r.Post("/", s.NewRepo)
+			r.With(mw.Challenge(challenge.ActionRepo)).Post("/", s.NewRepo)
 		})
 		// r.Post("/import", s.ImportRepo)
 	})
@@ -133,6 +173,8 @@ func (s *State) StandardRouter(mw *middleware.Middleware) http.Handler {
 		r.Delete("/", s.Star)
 	})
 
+	r.With(middleware.AuthMiddleware(s.oauth)).Post("/markdown", s.MarkdownPreview)
+
 	r.With(middleware.AuthMiddleware(s.oauth)).Route("/react", func(r chi.Router) {
 		r.Post("/", s.React)
 		r.Delete("/", s.React)
@@ -148,12 +190,23 @@ func (s *State) StandardRouter(mw *middleware.Middleware) http.Handler {
 
 	r.Mount("/settings", s.SettingsRouter())
 	r.Mount("/strings", s.StringsRouter(mw))
+	r.Mount("/issues", s.TriageRouter())
+	r.Mount("/gists", s.GistsRouter(mw))
 	r.Mount("/knots", s.KnotsRouter())
 	r.Mount("/spindles", s.SpindlesRouter())
-	r.Mount("/signup", s.SignupRouter())
+	r.Mount("/signup", s.SignupRouter(mw))
+	r.Mount("/ap", s.federation.Router())
+	r.Mount("/api/bot", s.BotsRouter())
+	r.Mount("/apps", s.AppsRouter())
+	r.Mount("/api/app", s.AppsAPIRouter())
+	r.Mount("/api/graphql", s.GraphQLRouter())
 	r.Mount("/", s.OAuthRouter())
 
 	r.Get("/keys/{user}", s.Keys)
+	r.Get("/ssh/ca.pub", s.SshCaKey)
+	r.Get("/domains/ask", s.DomainAsk)
+	r.Get("/oembed", s.OEmbed)
+	r.Get("/.well-known/webfinger", s.federation.WebFinger)
 	r.Get("/terms", s.TermsOfService)
 	r.Get("/privacy", s.PrivacyPolicy)
 
@@ -175,6 +228,7 @@ func (s *State) SettingsRouter() http.Handler {
 		OAuth:  s.oauth,
 		Pages:  s.pages,
 		Config: s.config,
+		SshCa:  s.sshCa,
 	}
 
 	return settings.Router()
@@ -230,11 +284,31 @@ func (s *State) StringsRouter(mw *middleware.Middleware) http.Handler {
 	return strs.Router(mw)
 }
 
+func (s *State) GistsRouter(mw *middleware.Middleware) http.Handler {
+	logger := log.New("gists")
+
+	gists := &gists.Gists{
+		Db:         s.db,
+		OAuth:      s.oauth,
+		Pages:      s.pages,
+		Config:     s.config,
+		IdResolver: s.idResolver,
+		Logger:     logger,
+	}
+
+	return gists.Router(mw)
+}
+
 func (s *State) IssuesRouter(mw *middleware.Middleware) http.Handler {
-	issues := issues.New(s.oauth, s.repoResolver, s.pages, s.idResolver, s.db, s.config, s.notifier)
+	issues := issues.New(s.oauth, s.repoResolver, s.pages, s.idResolver, s.db, s.config, s.notifier, s.enforcer, s.spam)
 	return issues.Router(mw)
 }
 
+func (s *State) TriageRouter() http.Handler {
+	issues := issues.New(s.oauth, s.repoResolver, s.pages, s.idResolver, s.db, s.config, s.notifier, s.enforcer, s.spam)
+	return issues.TriageRouter()
+}
+
 func (s *State) PullsRouter(mw *middleware.Middleware) http.Handler {
 	pulls := pulls.New(s.oauth, s.repoResolver, s.pages, s.idResolver, s.db, s.config, s.notifier)
 	return pulls.Router(mw)
@@ -242,7 +316,7 @@ func (s *State) PullsRouter(mw *middleware.Middleware) http.Handler {
 
 func (s *State) RepoRouter(mw *middleware.Middleware) http.Handler {
 	logger := log.New("repo")
-	repo := repo.New(s.oauth, s.repoResolver, s.pages, s.spindlestream, s.idResolver, s.db, s.config, s.notifier, s.enforcer, logger)
+	repo := repo.New(s.oauth, s.repoResolver, s.pages, s.spindlestream, s.idResolver, s.db, s.config, s.notifier, s.enforcer, s.storage, logger)
 	return repo.Router(mw)
 }
 
@@ -251,9 +325,34 @@ func (s *State) PipelinesRouter(mw *middleware.Middleware) http.Handler {
 	return pipes.Router(mw)
 }
 
-func (s *State) SignupRouter() http.Handler {
+func (s *State) ProjectsRouter(mw *middleware.Middleware) http.Handler {
+	projects := projects.New(s.oauth, s.repoResolver, s.pages, s.db, s.config)
+	return projects.Router(mw)
+}
+
+func (s *State) BotsRouter() http.Handler {
+	bots := bots.New(s.db, s.config, s.notifier, log.New("bots"))
+	return bots.Router()
+}
+
+func (s *State) AppsRouter() http.Handler {
+	apps := apps.New(s.db, s.oauth, s.pages, s.idResolver, s.config, log.New("apps"))
+	return apps.Router()
+}
+
+func (s *State) AppsAPIRouter() http.Handler {
+	apps := apps.New(s.db, s.oauth, s.pages, s.idResolver, s.config, log.New("apps"))
+	return apps.APIRouter()
+}
+
+func (s *State) GraphQLRouter() http.Handler {
+	gql := graphql.New(s.db, s.idResolver, log.New("graphql"))
+	return gql.Router()
+}
+
+func (s *State) SignupRouter(mw *middleware.Middleware) http.Handler {
 	logger := log.New("signup")
 
 	sig := signup.New(s.config, s.db, s.posthog, s.idResolver, s.pages, logger)
-	return sig.Router()
+	return sig.Router(mw)
 }
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/pages"
+	"tangled.sh/tangled.sh/core/appview/search"
+)
+
+const searchLimit = 50
+
+// Search finds repos or issues, scoped by the qualifiers in the query
+func (s *State) Search(w http.ResponseWriter, r *http.Request) {
+	user := s.oauth.GetUser(r)
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	kind := r.URL.Query().Get("type")
+	if kind != "issues" {
+		kind = "repos"
+	}
+
+	params := pages.SearchParams{
+		LoggedInUser: user,
+		Query:        q,
+		Type:         kind,
+	}
+
+	query := search.Parse(q)
+	if query.IsEmpty() {
+		s.pages.Search(w, params)
+		return
+	}
+
+	opts := db.SearchOptions{
+		Terms:     query.Terms,
+		Knots:     query.Knots,
+		Languages: query.Languages,
+		Fork:      query.Fork(),
+		Open:      query.Open(),
+	}
+	for _, u := range query.Users {
+		// an unknown user matches nothing, rather than everyone
+		id, err := s.idResolver.ResolveIdent(r.Context(), u)
+		if err != nil {
+			opts.Dids = append(opts.Dids, u)
+			continue
+		}
+		opts.Dids = append(opts.Dids, id.DID.String())
+	}
+
+	var err error
+	if kind == "issues" {
+		params.Issues, err = db.SearchIssues(s.db, opts, searchLimit)
+	} else {
+		params.Repos, err = db.SearchRepos(s.db, opts, searchLimit)
+	}
+	if err != nil {
+		log.Println("failed to search", err)
+		s.pages.Error503(w)
+		return
+	}
+
+	s.pages.Search(w, params)
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -19,21 +20,37 @@ import (
 	"github.com/posthog/posthog-go"
 	"tangled.sh/tangled.sh/core/api/tangled"
 	"tangled.sh/tangled.sh/core/appview"
+	"tangled.sh/tangled.sh/core/appview/activitypub"
+	"tangled.sh/tangled.sh/core/appview/bsky"
 	"tangled.sh/tangled.sh/core/appview/cache"
 	"tangled.sh/tangled.sh/core/appview/cache/session"
 	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/email"
+	"tangled.sh/tangled.sh/core/appview/feed"
+	"tangled.sh/tangled.sh/core/appview/integrations"
+	"tangled.sh/tangled.sh/core/appview/issues"
 	"tangled.sh/tangled.sh/core/appview/notify"
 	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/ogcard"
 	"tangled.sh/tangled.sh/core/appview/pages"
 	posthogService "tangled.sh/tangled.sh/core/appview/posthog"
 	"tangled.sh/tangled.sh/core/appview/reporesolver"
+	"tangled.sh/tangled.sh/core/appview/rules"
+	"tangled.sh/tangled.sh/core/appview/scan"
+	"tangled.sh/tangled.sh/core/appview/settings"
+	"tangled.sh/tangled.sh/core/appview/spam"
+	"tangled.sh/tangled.sh/core/appview/sshca"
+	"tangled.sh/tangled.sh/core/appview/state/userutil"
+	"tangled.sh/tangled.sh/core/appview/traffic"
 	xrpcclient "tangled.sh/tangled.sh/core/appview/xrpcclient"
 	"tangled.sh/tangled.sh/core/eventconsumer"
 	"tangled.sh/tangled.sh/core/idresolver"
 	"tangled.sh/tangled.sh/core/jetstream"
 	tlog "tangled.sh/tangled.sh/core/log"
+	"tangled.sh/tangled.sh/core/objectstore"
 	"tangled.sh/tangled.sh/core/rbac"
+	"tangled.sh/tangled.sh/core/scaffold"
 	"tangled.sh/tangled.sh/core/tid"
 	// xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 )
@@ -52,24 +69,52 @@ type State struct {
 	repoResolver  *reporesolver.RepoResolver
 	knotstream    *eventconsumer.Consumer
 	spindlestream *eventconsumer.Consumer
+	federation    *activitypub.Federation
+	sshCa         *sshca.Authority
+	spam          *spam.Filter
 	logger        *slog.Logger
+	traffic       *traffic.Tracker
+	storage       objectstore.Store
 }
 
-func Make(ctx context.Context, config *config.Config) (*State, error) {
+type makeOpts struct {
+	resolver *idresolver.Resolver
+}
+
+type MakeOpt func(*makeOpts)
+
+// WithResolver looks identities up with res instead of the network, as the
+// dev sandbox does with its own accounts
+func WithResolver(res *idresolver.Resolver) MakeOpt {
+	return func(o *makeOpts) {
+		o.resolver = res
+	}
+}
+
+func Make(ctx context.Context, config *config.Config, opts ...MakeOpt) (*State, error) {
+	o := makeOpts{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	d, err := db.Make(config.Core.DbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create db: %w", err)
 	}
+	d.Queries.Threshold = config.Core.SlowQueryThreshold
 
 	enforcer, err := rbac.NewEnforcer(config.Core.DbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create enforcer: %w", err)
 	}
 
-	res, err := idresolver.RedisResolver(config.Redis.ToURL())
-	if err != nil {
-		log.Printf("failed to create redis resolver: %v", err)
-		res = idresolver.DefaultResolver()
+	res := o.resolver
+	if res == nil {
+		res, err = idresolver.RedisResolver(config.Redis.ToURL())
+		if err != nil {
+			log.Printf("failed to create redis resolver: %v", err)
+			res = idresolver.DefaultResolver()
+		}
 	}
 
 	pgs := pages.NewPages(config, res)
@@ -86,6 +131,8 @@ func Make(ctx context.Context, config *config.Config) (*State, error) {
 
 	repoResolver := reporesolver.New(config, enforcer, res, d)
 
+	spamFilter := spam.New(config.Spam, d, tlog.New("spam"))
+
 	wrapper := db.DbWrapper{d}
 	jc, err := jetstream.NewJetstreamClient(
 		config.Jetstream.Endpoint,
@@ -94,13 +141,21 @@ func Make(ctx context.Context, config *config.Config) (*State, error) {
 			tangled.GraphFollowNSID,
 			tangled.FeedStarNSID,
 			tangled.PublicKeyNSID,
+			tangled.SigningKeyNSID,
 			tangled.RepoArtifactNSID,
+			tangled.RepoAttachmentNSID,
+			tangled.RepoNSID,
 			tangled.ActorProfileNSID,
 			tangled.SpindleMemberNSID,
 			tangled.SpindleNSID,
 			tangled.StringNSID,
+			tangled.GistNSID,
 			tangled.RepoIssueNSID,
 			tangled.RepoIssueCommentNSID,
+			tangled.RepoIssueStateNSID,
+			tangled.RepoLabelNSID,
+			tangled.RepoPullReviewNSID,
+			tangled.RepoPullNSID,
 		},
 		nil,
 		slog.Default(),
@@ -120,6 +175,7 @@ func Make(ctx context.Context, config *config.Config) (*State, error) {
 		Enforcer:   enforcer,
 		IdResolver: res,
 		Config:     config,
+		Spam:       spamFilter,
 		Logger:     tlog.New("ingester"),
 	}
 	err = jc.StartJetstream(ctx, ingester.Ingest())
@@ -127,7 +183,32 @@ func Make(ctx context.Context, config *config.Config) (*State, error) {
 		return nil, fmt.Errorf("failed to start jetstream watcher: %w", err)
 	}
 
-	knotstream, err := Knotstream(ctx, config, d, enforcer, posthog)
+	bridge := issues.NewBridge(d, res, config, tlog.New("bridge"))
+	bridge.Start(ctx)
+
+	federation := activitypub.New(d, res, config, tlog.New("activitypub"))
+
+	emailNotifier := email.NewNotifier(d, res, config, tlog.New("email"))
+	integrationsNotifier := integrations.NewNotifier(d, res, config, tlog.New("integrations"))
+
+	ruleEngine := rules.New(d, config, tlog.New("rules"))
+
+	notifiers := []notify.Notifier{bridge, federation, emailNotifier, integrationsNotifier, ruleEngine}
+	if !config.Core.Dev {
+		notifiers = append(notifiers, posthogService.NewPosthogNotifier(posthog))
+	}
+	if config.BskyNotify.Did != "" {
+		notifiers = append(notifiers, bsky.NewNotifier(d, res, config, tlog.New("bsky")))
+	}
+	if config.Feed.Enabled {
+		publisher := feed.NewPublisher(config.Feed, res, tlog.New("feed"))
+		publisher.Start(ctx)
+		notifiers = append(notifiers, publisher)
+	}
+	notifier := notify.NewMergedNotifier(notifiers...)
+	ruleEngine.Start(ctx, notifier)
+
+	knotstream, err := Knotstream(ctx, config, d, enforcer, posthog, notifier)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start knotstream consumer: %w", err)
 	}
@@ -139,11 +220,30 @@ func Make(ctx context.Context, config *config.Config) (*State, error) {
 	}
 	spindlestream.Start(ctx)
 
-	var notifiers []notify.Notifier
-	if !config.Core.Dev {
-		notifiers = append(notifiers, posthogService.NewPosthogNotifier(posthog))
+	go rebuildRepoCounts(ctx, d, tlog.New("counts"))
+	go settings.RecheckDomains(ctx, d, tlog.New("domains"))
+
+	tracker := traffic.New(d, config.Traffic, tlog.New("traffic"))
+	tracker.Start(ctx)
+
+	scanner, err := scan.NewWorker(d, config.Scan, res, tlog.New("scan"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up virus scanner: %w", err)
+	}
+	scanner.Start(ctx)
+
+	var ca *sshca.Authority
+	if config.SshCa.KeyPath != "" {
+		ca, err = sshca.Load(config.SshCa.KeyPath, config.SshCa.Validity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ssh certificate authority: %w", err)
+		}
+	}
+
+	storage, err := objectstore.Open(config.Storage, strings.TrimSuffix(config.Core.AppviewHost, "/")+"/storage")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object store: %w", err)
 	}
-	notifier := notify.NewMergedNotifier(notifiers...)
 
 	state := &State{
 		d,
@@ -159,7 +259,12 @@ func Make(ctx context.Context, config *config.Config) (*State, error) {
 		repoResolver,
 		knotstream,
 		spindlestream,
+		federation,
+		ca,
+		spamFilter,
 		slog.Default(),
+		tracker,
+		storage,
 	}
 
 	return state, nil
@@ -183,6 +288,38 @@ func (s *State) Favicon(w http.ResponseWriter, r *http.Request) {
 	s.pages.Favicon(w)
 }
 
+func (s *State) Manifest(w http.ResponseWriter, r *http.Request) {
+	manifest := map[string]any{
+		"name":             s.config.Instance.Name,
+		"short_name":       s.config.Instance.Name,
+		"description":      s.config.Instance.Tagline,
+		"start_url":        "/",
+		"scope":            "/",
+		"display":          "standalone",
+		"background_color": "#f1f5f9",
+		"theme_color":      "#f1f5f9",
+		"icons": []map[string]string{
+			{"src": "/favicon.svg", "sizes": "any", "type": "image/svg+xml", "purpose": "any"},
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	json.NewEncoder(w).Encode(manifest)
+}
+
+// ServiceWorker is served from the root rather than /static, a service
+// worker only controls the pages below the path it was loaded from
+func (s *State) ServiceWorker(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	s.pages.ServiceWorker(w)
+}
+
+func (s *State) Offline(w http.ResponseWriter, r *http.Request) {
+	s.pages.Offline(w)
+}
+
 func (s *State) TermsOfService(w http.ResponseWriter, r *http.Request) {
 	user := s.oauth.GetUser(r)
 	s.pages.TermsOfService(w, pages.TermsOfServiceParams{
@@ -251,7 +388,12 @@ func (s *State) Home(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *State) Keys(w http.ResponseWriter, r *http.Request) {
-	user := chi.URLParam(r, "user")
+	s.serveKeys(w, r, chi.URLParam(r, "user"))
+}
+
+// serveKeys writes the authentication keys of a user along with their ssh
+// signing keys, one per line, in authorized_keys format
+func (s *State) serveKeys(w http.ResponseWriter, r *http.Request, user string) {
 	user = strings.TrimPrefix(user, "@")
 
 	if user == "" {
@@ -271,7 +413,17 @@ func (s *State) Keys(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(pubKeys) == 0 {
+	signingKeys, err := db.GetSigningKeys(
+		s.db,
+		db.FilterEq("did", id.DID.String()),
+		db.FilterEq("kind", db.SigningKeySSH),
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(pubKeys) == 0 && len(signingKeys) == 0 {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -280,38 +432,71 @@ func (s *State) Keys(w http.ResponseWriter, r *http.Request) {
 		key := strings.TrimRight(k.Key, "\n")
 		w.Write([]byte(fmt.Sprintln(key)))
 	}
+	for _, k := range signingKeys {
+		key := strings.TrimRight(k.Key, "\n")
+		w.Write([]byte(fmt.Sprintln(key)))
+	}
 }
 
-func validateRepoName(name string) error {
-	// check for path traversal attempts
-	if name == "." || name == ".." ||
-		strings.Contains(name, "/") || strings.Contains(name, "\\") {
-		return fmt.Errorf("Repository name contains invalid path characters")
+// serveGpgKeys writes the armored gpg signing keys of a user
+func (s *State) serveGpgKeys(w http.ResponseWriter, r *http.Request, user string) {
+	user = strings.TrimPrefix(user, "@")
+
+	if user == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
 
-	// check for sequences that could be used for traversal when normalized
-	if strings.Contains(name, "./") || strings.Contains(name, "../") ||
-		strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
-		return fmt.Errorf("Repository name contains invalid path sequence")
+	id, err := s.idResolver.ResolveIdent(r.Context(), user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	// then continue with character validation
-	for _, char := range name {
-		if !((char >= 'a' && char <= 'z') ||
-			(char >= 'A' && char <= 'Z') ||
-			(char >= '0' && char <= '9') ||
-			char == '-' || char == '_' || char == '.') {
-			return fmt.Errorf("Repository name can only contain alphanumeric characters, periods, hyphens, and underscores")
-		}
+	signingKeys, err := db.GetSigningKeys(
+		s.db,
+		db.FilterEq("did", id.DID.String()),
+		db.FilterEq("kind", db.SigningKeyGPG),
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(signingKeys) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pgp-keys")
+	for _, k := range signingKeys {
+		key := strings.TrimRight(k.Key, "\n")
+		w.Write([]byte(fmt.Sprintln(key)))
 	}
+}
 
-	// additional check to prevent multiple sequential dots
-	if strings.Contains(name, "..") {
-		return fmt.Errorf("Repository name cannot contain sequential dots")
+// MarkdownPreview renders the body of a comment or issue for the preview tab
+// of the editor
+func (s *State) MarkdownPreview(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
 	}
 
-	// if all checks pass
-	return nil
+	s.pages.MarkdownPreview(w, r.FormValue("body"))
+}
+
+// SshCaKey serves the public key of the certificate authority, for knots to
+// put in their TrustedUserCAKeys
+func (s *State) SshCaKey(w http.ResponseWriter, r *http.Request) {
+	if s.sshCa == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(s.sshCa.PublicKey())
 }
 
 func stripGitExt(name string) string {
@@ -322,15 +507,37 @@ func (s *State) NewRepo(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		user := s.oauth.GetUser(r)
-		knots, err := s.enforcer.GetKnotsForUser(user.Did)
+		knots, err := s.enforcer.GetRepoCreateKnots(user.Did)
 		if err != nil {
 			s.pages.Notice(w, "repo", "Invalid user account.")
 			return
 		}
 
+		defaultBranches := make(map[string]string)
+		if len(knots) > 0 {
+			registrations, err := db.GetRegistrations(s.db, db.FilterIn("domain", knots))
+			if err != nil {
+				log.Println("failed to get knot registrations", err)
+			}
+			for _, reg := range registrations {
+				if reg.DefaultBranch != "" {
+					defaultBranches[reg.Domain] = reg.DefaultBranch
+				}
+			}
+		}
+
+		share, err := db.GetShareSettings(s.db, user.Did)
+		if err != nil {
+			log.Println("failed to get share settings", err)
+		}
+
 		s.pages.NewRepo(w, pages.NewRepoParams{
-			LoggedInUser: user,
-			Knots:        knots,
+			LoggedInUser:    user,
+			Knots:           knots,
+			DefaultBranches: defaultBranches,
+			Announce:        share.AnnounceRepos,
+			Gitignores:      scaffold.Gitignores(),
+			Licenses:        scaffold.Licenses(),
 		})
 
 	case http.MethodPost:
@@ -354,21 +561,35 @@ func (s *State) NewRepo(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if err := validateRepoName(repoName); err != nil {
+		if err := userutil.ValidateRepoName(repoName); err != nil {
 			s.pages.Notice(w, "repo", err.Error())
 			return
 		}
 		repoName = stripGitExt(repoName)
 		l = l.With("repoName", repoName)
 
+		// falls back to the default of the knot
 		defaultBranch := r.FormValue("branch")
 		if defaultBranch == "" {
-			defaultBranch = "main"
+			registrations, err := db.GetRegistrations(s.db, db.FilterEq("domain", domain), db.FilterIsNot("registered", "null"))
+			if err == nil && len(registrations) > 0 {
+				defaultBranch = registrations[0].DefaultBranch
+			}
 		}
 		l = l.With("defaultBranch", defaultBranch)
 
 		description := r.FormValue("description")
 
+		// files of an optional initial commit, written by the knot
+		readme := r.FormValue("readme") == "on"
+		gitignore := r.FormValue("gitignore")
+		license := r.FormValue("license")
+		err := scaffold.Options{Gitignore: gitignore, License: license}.Validate()
+		if err != nil {
+			s.pages.Notice(w, "repo", err.Error())
+			return
+		}
+
 		// ACL validation
 		ok, err := s.enforcer.E.Enforce(user.Did, domain, domain, "repo:create")
 		if err != nil || !ok {
@@ -402,18 +623,22 @@ func (s *State) NewRepo(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		createdAt := time.Now().Format(time.RFC3339)
+		record := &tangled.Repo{
+			Knot:      repo.Knot,
+			Name:      repoName,
+			CreatedAt: time.Now().Format(time.RFC3339),
+			Owner:     user.Did,
+		}
+		// the knot reads it back for the README of the initial commit
+		if description != "" {
+			record.Description = &description
+		}
+
 		atresp, err := xrpcClient.RepoPutRecord(r.Context(), &comatproto.RepoPutRecord_Input{
 			Collection: tangled.RepoNSID,
 			Repo:       user.Did,
 			Rkey:       rkey,
-			Record: &lexutil.LexiconTypeDecoder{
-				Val: &tangled.Repo{
-					Knot:      repo.Knot,
-					Name:      repoName,
-					CreatedAt: createdAt,
-					Owner:     user.Did,
-				}},
+			Record:     &lexutil.LexiconTypeDecoder{Val: record},
 		})
 		if err != nil {
 			l.Info("PDS write failed", "err", err)
@@ -469,7 +694,11 @@ func (s *State) NewRepo(w http.ResponseWriter, r *http.Request) {
 			r.Context(),
 			client,
 			&tangled.RepoCreate_Input{
-				Rkey: rkey,
+				Rkey:          rkey,
+				DefaultBranch: &defaultBranch,
+				Readme:        &readme,
+				Gitignore:     &gitignore,
+				License:       &license,
 			},
 		)
 		if err := xrpcclient.HandleXrpcErr(xe); err != nil {
@@ -511,11 +740,36 @@ func (s *State) NewRepo(w http.ResponseWriter, r *http.Request) {
 		// reset the ATURI because the transaction completed successfully
 		aturi = ""
 
+		if r.FormValue("announce") == "on" {
+			if err := s.announceRepo(r.Context(), xrpcClient, user, repo); err != nil {
+				// the repo exists at this point, a missing post is not worth failing over
+				l.Error("failed to announce repo", "err", err)
+			}
+		}
+
 		s.notifier.NewRepo(r.Context(), repo)
 		s.pages.HxLocation(w, fmt.Sprintf("/@%s/%s", user.Handle, repoName))
 	}
 }
 
+func (s *State) announceRepo(ctx context.Context, client *xrpcclient.Client, user *oauth.User, repo *db.Repo) error {
+	owner := fmt.Sprintf("@%s", user.Handle)
+	url := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.config.Core.AppviewHost, "/"), owner, repo.Name)
+
+	_, err := bsky.Post(ctx, client, user.Did, bsky.Announcement{
+		Text:        fmt.Sprintf("I just created %s on tangled!", repo.Name),
+		Url:         url,
+		Title:       fmt.Sprintf("%s/%s", owner, repo.Name),
+		Description: repo.Description,
+		Card: &ogcard.Card{
+			Eyebrow:     owner,
+			Title:       repo.Name,
+			Description: repo.Description,
+		},
+	})
+	return err
+}
+
 // this is used to rollback changes made to the PDS
 //
 // it is a no-op if the provided ATURI is empty
@@ -1,6 +1,7 @@
 package userutil
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -57,3 +58,36 @@ var subdomainRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{2,61}[a-z0-9])?$`)
 func IsValidSubdomain(name string) bool {
 	return len(name) >= 4 && len(name) <= 63 && subdomainRegex.MatchString(name)
 }
+
+// ValidateRepoName checks that name is safe to use as a directory on a knot
+func ValidateRepoName(name string) error {
+	// check for path traversal attempts
+	if name == "." || name == ".." ||
+		strings.Contains(name, "/") || strings.Contains(name, "\\") {
+		return fmt.Errorf("Repository name contains invalid path characters")
+	}
+
+	// check for sequences that could be used for traversal when normalized
+	if strings.Contains(name, "./") || strings.Contains(name, "../") ||
+		strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return fmt.Errorf("Repository name contains invalid path sequence")
+	}
+
+	// then continue with character validation
+	for _, char := range name {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '-' || char == '_' || char == '.') {
+			return fmt.Errorf("Repository name can only contain alphanumeric characters, periods, hyphens, and underscores")
+		}
+	}
+
+	// additional check to prevent multiple sequential dots
+	if strings.Contains(name, "..") {
+		return fmt.Errorf("Repository name cannot contain sequential dots")
+	}
+
+	// if all checks pass
+	return nil
+}
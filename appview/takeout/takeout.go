@@ -0,0 +1,246 @@
+// Package takeout bundles up everything the appview holds about a user or a
+// repo into a zip archive that its owner can download.
+package takeout
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/knotclient"
+)
+
+const (
+	// Limit is how many archives a user can download within Window, creating
+	// one is heavy on both the appview and the knots
+	Limit  = 3
+	Window = 24 * time.Hour
+)
+
+// Remaining reports how many more archives did can download right now
+func Remaining(e db.Execer, did string) (int, error) {
+	count, err := db.CountRecentTakeouts(e, did, time.Now().Add(-Window))
+	if err != nil {
+		return 0, err
+	}
+	return max(Limit-count, 0), nil
+}
+
+type Archive struct {
+	zw *zip.Writer
+}
+
+func NewArchive(w io.Writer) *Archive {
+	return &Archive{zw: zip.NewWriter(w)}
+}
+
+func (a *Archive) AddJSON(name string, v any) error {
+	f, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (a *Archive) AddFile(name string, r io.Reader) error {
+	f, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store, // bundles are compressed already
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func (a *Archive) Close() error {
+	return a.zw.Close()
+}
+
+// email is what is exported of a db.Email, verification codes stay behind
+type email struct {
+	Address   string
+	Verified  bool
+	Primary   bool
+	CreatedAt time.Time
+}
+
+// WriteUser adds the profile of did and everything it has created on the
+// appview to the archive
+func WriteUser(a *Archive, e db.Execer, did string) error {
+	profile, err := db.GetProfile(e, did)
+	if err != nil {
+		return fmt.Errorf("profile: %w", err)
+	}
+
+	repos, err := db.GetAllReposByDid(e, did)
+	if err != nil {
+		return fmt.Errorf("repos: %w", err)
+	}
+
+	issues, err := db.GetIssues(e, db.FilterEq("owner_did", did))
+	if err != nil {
+		return fmt.Errorf("issues: %w", err)
+	}
+
+	comments, err := db.GetAllComments(e, db.FilterEq("owner_did", did))
+	if err != nil {
+		return fmt.Errorf("issue comments: %w", err)
+	}
+
+	pullRepos, err := db.GetPullRepos(e, did)
+	if err != nil {
+		return fmt.Errorf("pulls: %w", err)
+	}
+	var pulls []*db.Pull
+	for _, repoAt := range pullRepos {
+		ps, err := db.GetPulls(e, db.FilterEq("repo_at", repoAt), db.FilterEq("owner_did", did))
+		if err != nil {
+			return fmt.Errorf("pulls: %w", err)
+		}
+		pulls = append(pulls, ps...)
+	}
+
+	pullComments, err := db.GetAllPullComments(e, db.FilterEq("owner_did", did))
+	if err != nil {
+		return fmt.Errorf("pull comments: %w", err)
+	}
+
+	stars, err := db.GetStars(e, 0, db.FilterEq("starred_by_did", did))
+	if err != nil {
+		return fmt.Errorf("stars: %w", err)
+	}
+
+	following, err := db.GetFollowing(e, did)
+	if err != nil {
+		return fmt.Errorf("follows: %w", err)
+	}
+
+	allEmails, err := db.GetAllEmails(e, did)
+	if err != nil {
+		return fmt.Errorf("emails: %w", err)
+	}
+	emails := make([]email, 0, len(allEmails))
+	for _, em := range allEmails {
+		emails = append(emails, email{
+			Address:   em.Address,
+			Verified:  em.Verified,
+			Primary:   em.Primary,
+			CreatedAt: em.CreatedAt,
+		})
+	}
+
+	keys, err := db.GetPublicKeysForDid(e, did)
+	if err != nil {
+		return fmt.Errorf("keys: %w", err)
+	}
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"profile.json", profile},
+		{"repos.json", repos},
+		{"issues.json", issues},
+		{"issue_comments.json", comments},
+		{"pulls.json", pulls},
+		{"pull_comments.json", pullComments},
+		{"stars.json", stars},
+		{"following.json", following},
+		{"emails.json", emails},
+		{"keys.json", keys},
+	}
+	for _, f := range files {
+		if err := a.AddJSON(f.name, f.v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteRepo adds a repo and all issues, pulls and stars on it to the archive
+// under dir
+func WriteRepo(a *Archive, e db.Execer, dir string, repo db.Repo) error {
+	repoAt := repo.RepoAt()
+
+	issues, err := db.GetIssues(e, db.FilterEq("repo_at", repoAt))
+	if err != nil {
+		return fmt.Errorf("issues: %w", err)
+	}
+
+	comments, err := db.GetAllComments(e, db.FilterEq("repo_at", repoAt))
+	if err != nil {
+		return fmt.Errorf("issue comments: %w", err)
+	}
+
+	pulls, err := db.GetPulls(e, db.FilterEq("repo_at", repoAt))
+	if err != nil {
+		return fmt.Errorf("pulls: %w", err)
+	}
+
+	pullComments, err := db.GetAllPullComments(e, db.FilterEq("repo_at", repoAt))
+	if err != nil {
+		return fmt.Errorf("pull comments: %w", err)
+	}
+
+	stars, err := db.GetStars(e, 0, db.FilterEq("repo_at", repoAt))
+	if err != nil {
+		return fmt.Errorf("stars: %w", err)
+	}
+
+	artifacts, err := db.GetArtifact(e, db.FilterEq("repo_at", repoAt))
+	if err != nil {
+		return fmt.Errorf("artifacts: %w", err)
+	}
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"repo.json", repo},
+		{"issues.json", issues},
+		{"issue_comments.json", comments},
+		{"pulls.json", pulls},
+		{"pull_comments.json", pullComments},
+		{"stars.json", stars},
+		{"artifacts.json", artifacts},
+	}
+	for _, f := range files {
+		if err := a.AddJSON(dir+f.name, f.v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteBundle adds a git bundle of the branches and tags of repo, fetched
+// from its knot, to the archive. Nothing is added for empty repos.
+func WriteBundle(ctx context.Context, a *Archive, name string, repo db.Repo, dev bool) error {
+	us, err := knotclient.NewUnsignedClient(ctx, repo.Knot, dev)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := us.Bundle(repo.Did, repo.Name)
+	if err != nil {
+		return err
+	}
+	if bundle == nil {
+		return nil
+	}
+	defer bundle.Close()
+
+	return a.AddFile(name, bundle)
+}
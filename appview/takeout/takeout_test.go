@@ -0,0 +1,97 @@
+package takeout
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+func TestWriteUser(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	did := "did:plc:alice"
+	err = db.AddEmail(d, db.Email{
+		Did:              did,
+		Address:          "alice@example.org",
+		VerificationCode: "secret-code",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	a := NewArchive(&buf)
+	if err := WriteUser(a, d, did); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(b)
+	}
+
+	for _, name := range []string{"profile.json", "issues.json", "stars.json", "emails.json", "keys.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+	if !strings.Contains(files["emails.json"], "alice@example.org") {
+		t.Errorf("emails.json does not list the address: %s", files["emails.json"])
+	}
+	if strings.Contains(files["emails.json"], "secret-code") {
+		t.Errorf("emails.json leaks the verification code")
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	did := "did:plc:alice"
+	for range Limit {
+		if err := db.AddTakeout(d, did, "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remaining, err := Remaining(d, did)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining != 0 {
+		t.Errorf("got %d remaining, want 0", remaining)
+	}
+
+	// takeouts older than the window no longer count
+	if _, err := d.Exec(`update takeouts set created = ?`, time.Now().Add(-2*Window).UTC().Format(time.RFC3339)); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, _ := Remaining(d, did); remaining != Limit {
+		t.Errorf("got %d remaining, want %d", remaining, Limit)
+	}
+}
@@ -0,0 +1,239 @@
+// Package traffic counts page views, unique visitors and clones of repos for
+// their owners, aggregated per day.
+//
+// Visitors are told apart by a hash of their address and user agent, salted
+// with a random key that is only kept in memory and replaced every day. Only
+// the daily totals are stored, so no visitor can be recognised afterwards or
+// followed from one day to the next.
+package traffic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+)
+
+// Retention is how long daily counts are kept around
+const Retention = 90 * 24 * time.Hour
+
+// Tracker collects counts in memory and writes them to the database every
+// flush interval. A nil Tracker counts nothing, for instances that opted out.
+type Tracker struct {
+	db     *db.DB
+	config config.TrafficConfig
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	day     string
+	salt    []byte
+	seen    map[[sha256.Size]byte]struct{}
+	pending map[syntax.ATURI]*db.RepoTraffic
+
+	registry *prometheus.Registry
+	views    *prometheus.CounterVec
+	visitors *prometheus.CounterVec
+	clones   *prometheus.CounterVec
+}
+
+func New(d *db.DB, config config.TrafficConfig, logger *slog.Logger) *Tracker {
+	if !config.Enabled {
+		return nil
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Minute
+	}
+
+	counter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "tangled",
+			Subsystem: "repo",
+			Name:      name,
+			Help:      help,
+		}, []string{"repo"})
+	}
+
+	t := &Tracker{
+		db:       d,
+		config:   config,
+		logger:   logger,
+		pending:  make(map[syntax.ATURI]*db.RepoTraffic),
+		registry: prometheus.NewRegistry(),
+		views:    counter("views_total", "Page views of a repo."),
+		visitors: counter("visitors_total", "Unique visitors of a repo, per day."),
+		clones:   counter("clones_total", "Clones and fetches of a repo over http."),
+	}
+	t.registry.MustRegister(t.views, t.visitors, t.clones)
+
+	return t
+}
+
+// Start writes out the counts every flush interval until ctx is done, and
+// serves them to prometheus if a metrics address is configured
+func (t *Tracker) Start(ctx context.Context) {
+	if t == nil {
+		return
+	}
+
+	if t.config.MetricsAddr != "" {
+		srv := &http.Server{
+			Addr:    t.config.MetricsAddr,
+			Handler: promhttp.HandlerFor(t.registry, promhttp.HandlerOpts{}),
+		}
+		go func() {
+			t.logger.Info("serving metrics", "addr", t.config.MetricsAddr)
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				t.logger.Error("metrics server failed", "err", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			srv.Close()
+		}()
+	}
+
+	go func() {
+		ticker := time.NewTicker(t.config.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				t.flush()
+				return
+			case <-ticker.C:
+				t.flush()
+			}
+		}
+	}()
+}
+
+// Views counts the page views of the repo resolved for the request, htmx
+// fragments and requests from crawlers are not page views
+func (t *Tracker) Views(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t != nil && r.Method == http.MethodGet && r.Header.Get("HX-Request") == "" && !isCrawler(r) {
+			if repo, ok := r.Context().Value("repo").(*db.Repo); ok {
+				t.count(r, repo, true)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Clone counts a clone or fetch of repo
+func (t *Tracker) Clone(r *http.Request, repo *db.Repo) {
+	if t == nil {
+		return
+	}
+	t.count(r, repo, false)
+}
+
+func (t *Tracker) count(r *http.Request, repo *db.Repo, view bool) {
+	label := repo.Did + "/" + repo.Name
+	repoAt := repo.RepoAt()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rollover()
+
+	c, ok := t.pending[repoAt]
+	if !ok {
+		c = &db.RepoTraffic{}
+		t.pending[repoAt] = c
+	}
+	if !view {
+		c.Clones++
+		t.clones.WithLabelValues(label).Inc()
+		return
+	}
+
+	c.Views++
+	t.views.WithLabelValues(label).Inc()
+
+	visitor := t.visitor(r, repoAt)
+	if _, ok := t.seen[visitor]; !ok {
+		t.seen[visitor] = struct{}{}
+		c.Visitors++
+		t.visitors.WithLabelValues(label).Inc()
+	}
+}
+
+// rollover starts counting a new day, with a new salt, once the date
+// changes. The caller holds mu.
+func (t *Tracker) rollover() {
+	today := time.Now().UTC().Format(db.TrafficDay)
+	if today == t.day {
+		return
+	}
+
+	// what was counted so far belongs to the previous day
+	if t.day != "" {
+		t.write(t.day, t.pending)
+		t.pending = make(map[syntax.ATURI]*db.RepoTraffic)
+	}
+
+	t.day = today
+	t.salt = make([]byte, 32)
+	rand.Read(t.salt)
+	t.seen = make(map[[sha256.Size]byte]struct{})
+}
+
+func (t *Tracker) visitor(r *http.Request, repoAt syntax.ATURI) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(t.salt)
+	h.Write([]byte(repoAt))
+	h.Write([]byte{0})
+	h.Write([]byte(oauth.ClientIP(r)))
+	h.Write([]byte{0})
+	h.Write([]byte(r.UserAgent()))
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func (t *Tracker) flush() {
+	t.mu.Lock()
+	day, pending := t.day, t.pending
+	t.pending = make(map[syntax.ATURI]*db.RepoTraffic)
+	t.mu.Unlock()
+
+	if day != "" {
+		t.write(day, pending)
+	}
+
+	if err := db.DeleteRepoTrafficBefore(t.db, time.Now().Add(-Retention)); err != nil {
+		t.logger.Error("failed to delete old traffic", "err", err)
+	}
+}
+
+func (t *Tracker) write(day string, pending map[syntax.ATURI]*db.RepoTraffic) {
+	for repoAt, c := range pending {
+		if err := db.AddRepoTraffic(t.db, repoAt, day, *c); err != nil {
+			t.logger.Error("failed to write traffic", "repo", repoAt, "err", err)
+		}
+	}
+}
+
+func isCrawler(r *http.Request) bool {
+	ua := strings.ToLower(r.UserAgent())
+	for _, s := range []string{"bot", "crawler", "spider"} {
+		if strings.Contains(ua, s) {
+			return true
+		}
+	}
+	return ua == ""
+}
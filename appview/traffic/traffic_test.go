@@ -0,0 +1,83 @@
+package traffic
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/db"
+)
+
+func TestTracker(t *testing.T) {
+	d, err := db.Make(filepath.Join(t.TempDir(), "appview.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := New(d, config.TrafficConfig{Enabled: true}, slog.Default())
+	repo := &db.Repo{Did: "did:plc:alice", Name: "core", Rkey: "3abc"}
+
+	visit := func(ip, ua string, hx bool) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = ip + ":1234"
+		r.Header.Set("User-Agent", ua)
+		if hx {
+			r.Header.Set("HX-Request", "true")
+		}
+		r = r.WithContext(context.WithValue(r.Context(), "repo", repo))
+		tr.Views(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	visit("10.0.0.1", "firefox", false)
+	visit("10.0.0.1", "firefox", false)
+	visit("10.0.0.2", "firefox", false)
+	visit("10.0.0.2", "firefox", true)        // a fragment
+	visit("10.0.0.3", "Googlebot/2.1", false) // a crawler
+	tr.Clone(httptest.NewRequest(http.MethodGet, "/info/refs", nil), repo)
+
+	tr.flush()
+
+	traffic, err := db.GetRepoTraffic(d, repo.RepoAt(), time.Now().AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traffic) != 1 {
+		t.Fatalf("expected one day of traffic, got %d", len(traffic))
+	}
+	if got := traffic[0]; got.Views != 3 || got.Visitors != 2 || got.Clones != 1 {
+		t.Errorf("got %d views, %d visitors and %d clones", got.Views, got.Visitors, got.Clones)
+	}
+
+	// later flushes add to the day
+	visit("10.0.0.1", "firefox", false)
+	tr.flush()
+
+	traffic, err = db.GetRepoTraffic(d, repo.RepoAt(), time.Now().AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := traffic[0]; got.Views != 4 || got.Visitors != 2 {
+		t.Errorf("got %d views and %d visitors after another visit", got.Views, got.Visitors)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	tr := New(nil, config.TrafficConfig{Enabled: false}, slog.Default())
+	if tr != nil {
+		t.Fatal("expected no tracker when traffic is disabled")
+	}
+
+	// a nil tracker still passes requests on
+	called := false
+	tr.Views(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true })).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if !called {
+		t.Error("request was not passed on")
+	}
+	tr.Clone(nil, nil)
+}
@@ -0,0 +1,308 @@
+// Package webauthn verifies the registration and authentication ceremonies
+// of passkeys.
+//
+// Browsers hand over the public key of a new credential in PKIX form, so no
+// CBOR is parsed here. Attestation statements are not checked either, the
+// appview asks for "none" and trusts the authenticator the user picked.
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// COSE identifiers of the signature algorithms accepted for credentials
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+var Algorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// RelyingParty is the site credentials are scoped to
+type RelyingParty struct {
+	// ID is the host of the appview, without scheme or port
+	ID string
+	// Origin is where the ceremonies run, e.g. https://tangled.sh
+	Origin string
+	// UserVerification requires assertions to show that the authenticator
+	// checked who the user is, with a PIN or biometrics, rather than only
+	// that someone was present
+	UserVerification bool
+}
+
+func NewRelyingParty(appviewHost string) (RelyingParty, error) {
+	u, err := url.Parse(appviewHost)
+	if err != nil || u.Host == "" {
+		return RelyingParty{}, fmt.Errorf("invalid appview host %q", appviewHost)
+	}
+	return RelyingParty{
+		ID:     u.Hostname(),
+		Origin: u.Scheme + "://" + u.Host,
+	}, nil
+}
+
+// NewChallenge returns a random challenge for a ceremony, base64url encoded
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Encode(b), nil
+}
+
+// Encode is how binary values travel to and from the browser
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// Registration is the response of navigator.credentials.create
+type Registration struct {
+	Id                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	PublicKey         string `json:"publicKey"`
+	Algorithm         int    `json:"publicKeyAlgorithm"`
+}
+
+// Assertion is the response of navigator.credentials.get
+type Assertion struct {
+	Id                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// Credential is a verified passkey
+type Credential struct {
+	Id        string
+	PublicKey []byte // PKIX
+	Algorithm int
+	SignCount uint32
+}
+
+// VerifyRegistration checks that reg answers challenge on this site, and
+// returns the credential it created
+func (rp RelyingParty) VerifyRegistration(challenge string, reg Registration) (*Credential, error) {
+	clientData, err := Decode(reg.ClientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client data: %w", err)
+	}
+	if err := rp.checkClientData(clientData, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := Decode(reg.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authenticator data: %w", err)
+	}
+	authData, err := rp.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := Decode(reg.Id)
+	if err != nil || len(id) == 0 {
+		return nil, errors.New("invalid credential id")
+	}
+	if authData.credentialId == nil {
+		return nil, errors.New("authenticator did not attest a credential")
+	}
+	if !bytes.Equal(authData.credentialId, id) {
+		return nil, errors.New("credential id does not match the authenticator data")
+	}
+
+	publicKey, err := Decode(reg.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if _, err := parsePublicKey(publicKey, reg.Algorithm); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		Id:        Encode(id),
+		PublicKey: publicKey,
+		Algorithm: reg.Algorithm,
+		SignCount: authData.signCount,
+	}, nil
+}
+
+// VerifyAssertion checks that a was signed by cred in answer to challenge on
+// this site, and returns the new signature counter of the credential
+func (rp RelyingParty) VerifyAssertion(challenge string, a Assertion, cred Credential) (uint32, error) {
+	if a.Id != cred.Id {
+		return 0, errors.New("assertion is for another credential")
+	}
+
+	clientData, err := Decode(a.ClientDataJSON)
+	if err != nil {
+		return 0, fmt.Errorf("invalid client data: %w", err)
+	}
+	if err := rp.checkClientData(clientData, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := Decode(a.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("invalid authenticator data: %w", err)
+	}
+	authData, err := rp.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if rp.UserVerification && authData.flags&flagUserVerified == 0 {
+		return 0, errors.New("user was not verified")
+	}
+
+	signature, err := Decode(a.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	clientDataHash := sha256.Sum256(clientData)
+	signed := append(rawAuthData, clientDataHash[:]...)
+	if err := verify(cred, signed, signature); err != nil {
+		return 0, err
+	}
+
+	// authenticators without a counter always report zero, otherwise it only
+	// goes up unless the credential was cloned
+	if (authData.signCount != 0 || cred.SignCount != 0) && authData.signCount <= cred.SignCount {
+		return 0, errors.New("signature counter went backwards")
+	}
+
+	return authData.signCount, nil
+}
+
+func (rp RelyingParty) checkClientData(raw []byte, typ, challenge string) error {
+	var clientData struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Origin    string `json:"origin"`
+	}
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return fmt.Errorf("invalid client data: %w", err)
+	}
+
+	if clientData.Type != typ {
+		return fmt.Errorf("expected a %s ceremony, got %q", typ, clientData.Type)
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return errors.New("challenge does not match")
+	}
+	if clientData.Origin != rp.Origin {
+		return fmt.Errorf("ceremony ran on %q", clientData.Origin)
+	}
+	return nil
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	credentialId []byte // only present when registering
+}
+
+func (rp RelyingParty) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errors.New("authenticator data is too short")
+	}
+
+	rpIdHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(raw[:32], rpIdHash[:]) != 1 {
+		return nil, errors.New("credential is for another site")
+	}
+
+	data := &authenticatorData{
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if data.flags&flagUserPresent == 0 {
+		return nil, errors.New("user was not present")
+	}
+
+	if data.flags&flagAttested != 0 {
+		// aaguid, then the length of the credential id and the id itself
+		rest := raw[37:]
+		if len(rest) < 18 {
+			return nil, errors.New("attested credential data is too short")
+		}
+		n := int(binary.BigEndian.Uint16(rest[16:18]))
+		if len(rest) < 18+n {
+			return nil, errors.New("attested credential data is too short")
+		}
+		data.credentialId = rest[18 : 18+n]
+	}
+
+	return data, nil
+}
+
+func parsePublicKey(der []byte, alg int) (any, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	ok := false
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		ok = alg == AlgES256 && k.Curve.Params().Name == "P-256"
+	case ed25519.PublicKey:
+		ok = alg == AlgEdDSA
+	case *rsa.PublicKey:
+		ok = alg == AlgRS256 && k.N.BitLen() >= 2048
+	}
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key for algorithm %d", alg)
+	}
+	return key, nil
+}
+
+func verify(cred Credential, signed, signature []byte) error {
+	key, err := parsePublicKey(cred.PublicKey, cred.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	valid := false
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		hash := sha256.Sum256(signed)
+		valid = ecdsa.VerifyASN1(k, hash[:], signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, signed, signature)
+	case *rsa.PublicKey:
+		hash := sha256.Sum256(signed)
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], signature) == nil
+	}
+	if !valid {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
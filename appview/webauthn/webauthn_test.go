@@ -0,0 +1,136 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+type authenticator struct {
+	t     *testing.T
+	key   *ecdsa.PrivateKey
+	id    []byte
+	count uint32
+}
+
+func (a *authenticator) authData(rpId string, attest bool) []byte {
+	hash := sha256.Sum256([]byte(rpId))
+	data := append([]byte{}, hash[:]...)
+
+	flags := byte(flagUserPresent)
+	if attest {
+		flags |= flagAttested
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.count)
+
+	if attest {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.id)))
+		data = append(data, a.id...)
+	}
+	return data
+}
+
+func clientData(typ, challenge, origin string) []byte {
+	b, _ := json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": origin})
+	return b
+}
+
+func (a *authenticator) register(rp RelyingParty, challenge string) Registration {
+	publicKey, err := x509.MarshalPKIXPublicKey(&a.key.PublicKey)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return Registration{
+		Id:                Encode(a.id),
+		ClientDataJSON:    Encode(clientData("webauthn.create", challenge, rp.Origin)),
+		AuthenticatorData: Encode(a.authData(rp.ID, true)),
+		PublicKey:         Encode(publicKey),
+		Algorithm:         AlgES256,
+	}
+}
+
+func (a *authenticator) assert(rpId, origin, challenge string) Assertion {
+	a.count++
+	authData := a.authData(rpId, false)
+	clientData := clientData("webauthn.get", challenge, origin)
+
+	clientDataHash := sha256.Sum256(clientData)
+	hash := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, hash[:])
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return Assertion{
+		Id:                Encode(a.id),
+		ClientDataJSON:    Encode(clientData),
+		AuthenticatorData: Encode(authData),
+		Signature:         Encode(signature),
+	}
+}
+
+func TestCeremonies(t *testing.T) {
+	rp, err := NewRelyingParty("https://tangled.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &authenticator{t: t, key: key, id: []byte("credential")}
+
+	challenge, _ := NewChallenge()
+	if _, err := rp.VerifyRegistration("another", a.register(rp, challenge)); err == nil {
+		t.Fatal("registration answering another challenge was accepted")
+	}
+	cred, err := rp.VerifyRegistration(challenge, a.register(rp, challenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, _ = NewChallenge()
+	count, err := rp.VerifyAssertion(challenge, a.assert(rp.ID, rp.Origin, challenge), *cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("got counter %d, want 1", count)
+	}
+	cred.SignCount = count
+
+	tests := []struct {
+		name      string
+		assertion func() Assertion
+	}{
+		{"other origin", func() Assertion { return a.assert(rp.ID, "https://evil.example", challenge) }},
+		{"other site", func() Assertion { return a.assert("evil.example", rp.Origin, challenge) }},
+		{"other challenge", func() Assertion { return a.assert(rp.ID, rp.Origin, "stale") }},
+		{"replayed counter", func() Assertion { a.count = 0; return a.assert(rp.ID, rp.Origin, challenge) }},
+		{"tampered", func() Assertion {
+			assertion := a.assert(rp.ID, rp.Origin, challenge)
+			assertion.AuthenticatorData = Encode(append(a.authData(rp.ID, false)[:32], 0x05, 0, 0, 0, 99))
+			return assertion
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rp.VerifyAssertion(challenge, tt.assertion(), *cred); err == nil {
+				t.Error("assertion was accepted")
+			}
+		})
+	}
+
+	rp.UserVerification = true
+	if _, err := rp.VerifyAssertion(challenge, a.assert(rp.ID, rp.Origin, challenge), *cred); err == nil {
+		t.Error("assertion without user verification was accepted")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 
@@ -12,6 +11,7 @@ import (
 	"github.com/bluesky-social/indigo/xrpc"
 	indigoxrpc "github.com/bluesky-social/indigo/xrpc"
 	oauth "tangled.sh/icyphox.sh/atproto-oauth"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 )
 
 type Client struct {
@@ -107,23 +107,63 @@ func (c *Client) ServerGetServiceAuth(ctx context.Context, aud string, exp int64
 	return &out, nil
 }
 
+// NoticeError is an error from a knot or spindle, phrased for users. The
+// original error is kept, so that callers can still match on its tag with
+// xrpcerr.Is.
+type NoticeError struct {
+	Notice string
+	Err    error
+}
+
+func (n *NoticeError) Error() string {
+	return n.Notice
+}
+
+func (n *NoticeError) Unwrap() error {
+	return n.Err
+}
+
+// notices are the user-facing counterparts of error tags
+var notices = map[string]string{
+	xrpcerr.TagRepoExists:      "A repository with this name already exists on this knot.",
+	xrpcerr.TagRecordExists:    "The repository record still exists, try again later.",
+	xrpcerr.TagAccessControl:   "You do not have permission to do this on this knot.",
+	xrpcerr.TagForbidden:       "This is not allowed on this knot.",
+	xrpcerr.TagNotFound:        "Repository not found on this knot.",
+	xrpcerr.TagRefNotFound:     "Branch or commit not found on this knot.",
+	xrpcerr.TagInvalidRequest:  "The knot rejected this request as invalid.",
+	xrpcerr.TagInvalidRepo:     "The knot rejected this request as invalid.",
+	xrpcerr.TagAuth:            "Unauthorized XRPC request.",
+	xrpcerr.TagMissingActorDid: "Unauthorized XRPC request.",
+	xrpcerr.TagGit:             "The knot failed to run git. Try again later.",
+}
+
 // produces a more manageable error
 func HandleXrpcErr(err error) error {
 	if err == nil {
 		return nil
 	}
 
-	var xrpcerr *indigoxrpc.Error
-	if ok := errors.As(err, &xrpcerr); !ok {
-		return fmt.Errorf("Recieved invalid XRPC error response.")
+	if xerr, ok := xrpcerr.FromError(err); ok {
+		if xerr.Tag == xrpcerr.TagMergeConflict {
+			return &NoticeError{Notice: xerr.Message, Err: err}
+		}
+		if notice, ok := notices[xerr.Tag]; ok {
+			return &NoticeError{Notice: notice, Err: err}
+		}
+	}
+
+	var ierr *indigoxrpc.Error
+	if ok := errors.As(err, &ierr); !ok {
+		return &NoticeError{Notice: "Recieved invalid XRPC error response.", Err: err}
 	}
 
-	switch xrpcerr.StatusCode {
+	switch ierr.StatusCode {
 	case http.StatusNotFound:
-		return fmt.Errorf("XRPC is unsupported on this knot, consider upgrading your knot.")
+		return &NoticeError{Notice: "XRPC is unsupported on this knot, consider upgrading your knot.", Err: err}
 	case http.StatusUnauthorized:
-		return fmt.Errorf("Unauthorized XRPC request.")
+		return &NoticeError{Notice: "Unauthorized XRPC request.", Err: err}
 	default:
-		return fmt.Errorf("Failed to perform operation. Try again later.")
+		return &NoticeError{Notice: "Failed to perform operation. Try again later.", Err: err}
 	}
 }
@@ -9,10 +9,11 @@ import (
 
 	"tangled.sh/tangled.sh/core/appview/config"
 	"tangled.sh/tangled.sh/core/appview/state"
+	tlog "tangled.sh/tangled.sh/core/log"
 )
 
 func main() {
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	slog.SetDefault(slog.New(tlog.NewRequestIdHandler(slog.NewTextHandler(os.Stdout, nil))))
 
 	ctx := context.Background()
 
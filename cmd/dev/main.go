@@ -0,0 +1,246 @@
+// dev runs an appview and a knot against a made up network of a few
+// accounts, so that features can be worked on without a PDS, redis or an
+// oauth client of one's own. Nothing is kept once it exits.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/cache"
+	"tangled.sh/tangled.sh/core/appview/cache/session"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/state"
+	"tangled.sh/tangled.sh/core/hook"
+	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/knotserver"
+	knotconfig "tangled.sh/tangled.sh/core/knotserver/config"
+	tlog "tangled.sh/tangled.sh/core/log"
+	"tangled.sh/tangled.sh/core/sandbox"
+)
+
+const (
+	appviewAddr = "localhost:3000"
+	knotAddr    = "localhost:6000"
+)
+
+var handles = []string{"alice.test", "bob.test"}
+
+func main() {
+	// the knot writes git hooks that call back into the binary it runs in,
+	// which is this one
+	if len(os.Args) > 1 && os.Args[1] == "hook" {
+		if err := hook.Command().Run(context.Background(), os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	data := flag.String("data", "", "directory for the databases and repositories (default: a temporary directory, removed on exit)")
+	flag.Parse()
+
+	slog.SetDefault(slog.New(tlog.NewRequestIdHandler(slog.NewTextHandler(os.Stdout, nil))))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, *data); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, data string) error {
+	if data == "" {
+		tmp, err := os.MkdirTemp("", "tangled-dev-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		data = tmp
+	} else if entries, _ := os.ReadDir(data); len(entries) > 0 {
+		// the accounts live in memory, a previous run's databases would
+		// point at records that are gone
+		return fmt.Errorf("%s is not empty", data)
+	}
+	for _, dir := range []string{"repos", "trash", "archives"} {
+		if err := os.MkdirAll(filepath.Join(data, dir), 0755); err != nil {
+			return err
+		}
+	}
+
+	redis, err := sandbox.ListenRedis("127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to start redis: %w", err)
+	}
+	defer redis.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to start pds: %w", err)
+	}
+	pds := sandbox.NewPDS("http://" + ln.Addr().String())
+	go http.Serve(ln, pds)
+
+	var accounts []*sandbox.Account
+	for _, h := range handles {
+		a, err := pds.CreateAccount(h)
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, a)
+	}
+	owner := accounts[0]
+
+	jetstream := strings.Replace(pds.URL(), "http://", "ws://", 1) + "/subscribe"
+	env := map[string]string{
+		"TANGLED_DEV":                "true",
+		"TANGLED_LISTEN_ADDR":        appviewAddr,
+		"TANGLED_APPVIEW_HOST":       "http://" + appviewAddr,
+		"TANGLED_DB_PATH":            filepath.Join(data, "appview.db"),
+		"TANGLED_REDIS_ADDR":         redis.Addr(),
+		"TANGLED_JETSTREAM_ENDPOINT": jetstream,
+		// every account is new, seeding would run into the rate limit of
+		// new accounts
+		"TANGLED_SPAM_NEW_ACCOUNT_LIMIT": "0",
+		"TANGLED_GITHUB_TOKEN_KEY":       "dev",
+
+		"KNOT_SERVER_DEV":                  "true",
+		"KNOT_SERVER_HOSTNAME":             knotAddr,
+		"KNOT_SERVER_LISTEN_ADDR":          knotAddr,
+		"KNOT_SERVER_INTERNAL_LISTEN_ADDR": "localhost:6444",
+		"KNOT_SERVER_OWNER":                owner.Did.String(),
+		"KNOT_SERVER_DB_PATH":              filepath.Join(data, "knot.db"),
+		"KNOT_SERVER_ACL_SNAPSHOT_PATH":    filepath.Join(data, "acl-snapshot.json"),
+		"KNOT_SERVER_JETSTREAM_ENDPOINT":   jetstream,
+		"KNOT_REPO_SCAN_PATH":              filepath.Join(data, "repos"),
+		"KNOT_REPO_TRASH_PATH":             filepath.Join(data, "trash"),
+		"KNOT_ARCHIVE_CACHE_PATH":          filepath.Join(data, "archives"),
+		"APPVIEW_ENDPOINT":                 "http://" + appviewAddr,
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+
+	res := idresolver.New(pds.Directory())
+
+	kc, err := knotconfig.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load knot config: %w", err)
+	}
+	knotErr := make(chan error, 1)
+	go func() {
+		knotErr <- knotserver.Serve(tlog.IntoContext(ctx, tlog.New("knot")), kc, res)
+	}()
+
+	c, err := config.LoadConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load appview config: %w", err)
+	}
+	s, err := state.Make(ctx, c, state.WithResolver(res))
+	if err != nil {
+		return fmt.Errorf("failed to start appview: %w", err)
+	}
+	defer s.Close()
+
+	sess := session.New(cache.New(c.Redis.Addr))
+	mux := http.NewServeMux()
+	mux.Handle("/", s.Router())
+	mux.Handle("GET /dev/login", sandbox.Login(pds, oauth.NewOAuth(c, sess), sess))
+	mux.Handle("POST /dev/accounts", createAccount(pds))
+
+	appviewErr := make(chan error, 1)
+	go func() {
+		appviewErr <- http.ListenAndServe(c.Core.ListenAddr, mux)
+	}()
+
+	if err := waitUntilUp(ctx, pds, knotAddr, appviewAddr); err != nil {
+		return err
+	}
+
+	appview := "http://" + appviewAddr
+	if err := seed(ctx, appview, pds, accounts); err != nil {
+		return fmt.Errorf("failed to seed: %w", err)
+	}
+
+	log.Println("sandbox is up at", appview)
+	for _, a := range accounts {
+		log.Printf("  sign in as %s: %s/dev/login?handle=%s", a.Handle, appview, a.Handle)
+	}
+	log.Println("data is in", data)
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-knotErr:
+		return fmt.Errorf("knot stopped: %v", err)
+	case err := <-appviewErr:
+		return fmt.Errorf("appview stopped: %v", err)
+	}
+}
+
+// waitUntilUp waits for the servers to listen, and for the appview and knot
+// to be following the firehose, which they connect to in the background
+func waitUntilUp(ctx context.Context, pds *sandbox.PDS, addrs ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	for _, addr := range addrs {
+		for {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				conn.Close()
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%s did not come up", addr)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}
+
+	for pds.Subscribers() < 2 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("the appview and knot did not subscribe to the firehose")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	return nil
+}
+
+// createAccount adds an account to the sandbox, or finds the one there is
+// for the handle, so that cmd/seed can add more people than the sandbox
+// starts with
+func createAccount(pds *sandbox.PDS) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handle := r.FormValue("handle")
+		a, ok := pds.Account(handle)
+		if !ok {
+			var err error
+			if a, err = pds.CreateAccount(handle); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"did":    a.Did.String(),
+			"handle": a.Handle.String(),
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"tangled.sh/tangled.sh/core/sandbox"
+)
+
+// user drives the appview as one of the sandbox accounts, through the same
+// handlers the browser uses
+type user struct {
+	*sandbox.Account
+	*sandbox.Client
+}
+
+// seed registers the knot and fills it with a few repos, going through the
+// appview so that its database, the records and the knot agree
+func seed(ctx context.Context, appview string, pds *sandbox.PDS, accounts []*sandbox.Account) error {
+	var users []*user
+	for _, a := range accounts {
+		u := &user{Account: a, Client: sandbox.NewClient(appview)}
+		if err := u.Login(a.Handle.String()); err != nil {
+			return err
+		}
+		users = append(users, u)
+	}
+	alice, bob := users[0], users[1]
+
+	if _, err := alice.Post("/knots/register", url.Values{"domain": {knotAddr}, "setup": {"1"}}); err != nil {
+		return fmt.Errorf("registering knot: %w", err)
+	}
+	if _, err := alice.Post("/knots/"+knotAddr+"/add", url.Values{"member": {bob.Did.String()}}); err != nil {
+		return fmt.Errorf("adding knot member: %w", err)
+	}
+
+	repos := []struct {
+		owner *user
+		form  url.Values
+	}{
+		{alice, url.Values{"name": {"hello-world"}, "description": {"a first repository to poke at"}, "readme": {"on"}, "license": {"MIT"}}},
+		{alice, url.Values{"name": {"website"}, "description": {"sources of alice.test"}, "readme": {"on"}, "gitignore": {"Node"}}},
+		{bob, url.Values{"name": {"scratch"}, "readme": {"on"}}},
+	}
+	for _, r := range repos {
+		r.form.Set("domain", knotAddr)
+		// the knot learns of new members off the firehose, which takes a
+		// moment
+		err := retry(ctx, func() error {
+			_, err := r.owner.Post("/repo/new", r.form)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", r.form.Get("name"), err)
+		}
+	}
+
+	if _, err := bob.Post("/follow?subject="+alice.Did.String(), nil); err != nil {
+		return fmt.Errorf("following: %w", err)
+	}
+
+	return nil
+}
+
+func retry(ctx context.Context, f func() error) error {
+	var err error
+	for range 10 {
+		if err = f(); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	return err
+}
@@ -15,8 +15,11 @@ func main() {
 		"api/tangled/cbor_gen.go",
 		"tangled",
 		tangled.ActorProfile{},
+		tangled.FeedEvent{},
 		tangled.FeedReaction{},
 		tangled.FeedStar{},
+		tangled.Gist{},
+		tangled.Gist_File{},
 		tangled.GitRefUpdate{},
 		tangled.GitRefUpdate_CommitCountBreakdown{},
 		tangled.GitRefUpdate_IndividualEmailCommitCount{},
@@ -39,15 +42,19 @@ func main() {
 		tangled.PublicKey{},
 		tangled.Repo{},
 		tangled.RepoArtifact{},
+		tangled.RepoAttachment{},
 		tangled.RepoCollaborator{},
 		tangled.RepoIssue{},
 		tangled.RepoIssueComment{},
 		tangled.RepoIssueState{},
+		tangled.RepoLabel{},
 		tangled.RepoPull{},
 		tangled.RepoPullComment{},
 		tangled.RepoPull_Source{},
 		tangled.RepoPullStatus{},
 		tangled.RepoPull_Target{},
+		tangled.RepoPullReview{},
+		tangled.SigningKey{},
 		tangled.Spindle{},
 		tangled.SpindleMember{},
 		tangled.String{},
@@ -0,0 +1,60 @@
+// git-remote-tangled lets git talk to tangled repos by their owner's did or
+// handle rather than by knot:
+//
+//	git clone tangled://did:plc:foobar/repo
+//	git clone tangled://@foo.bsky.social/repo
+//
+// the knot is looked up from the repo record on every fetch, so remotes keep
+// working if the repo moves to another knot. git is then handed over to the
+// smart-http helper for that knot.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/repolookup"
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "usage: git-remote-tangled <remote> <url>")
+		os.Exit(1)
+	}
+
+	remote, url := os.Args[1], os.Args[2]
+
+	// tangled::did:plc:foobar/repo passes the url without its scheme
+	ref := strings.TrimPrefix(url, "tangled://")
+
+	repo, err := repolookup.Resolve(context.Background(), idresolver.DefaultResolver(), ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+
+	dev := os.Getenv("TANGLED_DEV") == "true"
+	helper := "remote-https"
+	if dev {
+		helper = "remote-http"
+	}
+
+	cmd := exec.Command("git", helper, remote, repo.HttpUrl(dev))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+}
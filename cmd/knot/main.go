@@ -20,6 +20,7 @@ func main() {
 			guard.Command(),
 			knotserver.Command(),
 			keyfetch.Command(),
+			keyfetch.PrincipalsCommand(),
 			hook.Command(),
 		},
 	}
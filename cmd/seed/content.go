@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	names = []string{
+		"ada", "grace", "linus", "margaret", "ken", "barbara", "dennis",
+		"frances", "edsger", "radia", "donald", "hedy", "alan", "sophie",
+		"niklaus", "karen", "leslie", "adele", "brian", "joan",
+	}
+
+	repoNames = []string{
+		"parser", "dotfiles", "blog", "tiny-http", "notes", "raytracer",
+		"cli-tools", "garden", "ledger", "synth", "kv-store", "website",
+		"chess-engine", "lisp", "scheduler", "dns-toy",
+	}
+
+	descriptions = []string{
+		"a small %s, written to learn how they work",
+		"my %s, use at your own risk",
+		"yet another %s",
+		"%s with no dependencies",
+		"experiments with a %s",
+	}
+
+	nouns = []string{
+		"parser", "config loader", "cache", "lexer", "retry loop", "logger",
+		"error messages", "test suite", "readme", "build script", "cli flags",
+		"http client", "docs", "benchmarks", "date handling", "tokenizer",
+		"release notes", "ci config", "examples", "license headers",
+	}
+
+	subjects = []string{
+		"Add %s", "Fix %s", "Refactor %s", "Document %s", "Simplify %s",
+		"Clean up %s", "Speed up %s", "Handle empty input in %s",
+		"Remove dead code from %s", "Rework %s",
+	}
+
+	sentences = []string{
+		"This makes the behaviour match what the docs already describe.",
+		"Nothing should change for existing users.",
+		"Found this while chasing a flaky test.",
+		"The old code path is kept around for now, it can go in a later release.",
+		"I am not sure about the naming here, suggestions welcome.",
+		"Tested by hand, the test suite does not cover this yet.",
+		"This was surprisingly slow on large inputs.",
+		"Follow up to the discussion in the issue tracker.",
+	}
+
+	issueTitles = []string{
+		"%s crashes on empty input",
+		"%s is slow with large files",
+		"Support for %s on windows",
+		"Confusing error from %s",
+		"%s ignores the config file",
+		"Document how %s works",
+		"Flaky test around %s",
+		"Feature request: make %s configurable",
+	}
+
+	comments = []string{
+		"I can reproduce this on main.",
+		"Thanks for the report! Could you share the exact command you ran?",
+		"Looks good to me.",
+		"Could we add a test for this?",
+		"I had a go at this, a patch is up.",
+		"Same here, started happening after the last release.",
+		"Nice, this has been bugging me for a while.",
+		"Not sure this is the right place for it, but happy to merge.",
+		"+1",
+	}
+
+	extensions = []string{".go", ".md", ".sh", ".txt", ".toml"}
+	dirs       = []string{"", "src", "docs", "scripts", "internal/util"}
+)
+
+func pick[T any](r *rand.Rand, xs []T) T {
+	return xs[r.IntN(len(xs))]
+}
+
+func subject(r *rand.Rand) string {
+	return fmt.Sprintf(pick(r, subjects), pick(r, nouns))
+}
+
+func paragraph(r *rand.Rand) string {
+	n := 1 + r.IntN(3)
+	var s []string
+	for range n {
+		s = append(s, pick(r, sentences))
+	}
+	return strings.Join(s, " ")
+}
+
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// line makes up a line of a file, vaguely in the language of its extension
+func line(r *rand.Rand, ext string) string {
+	noun := pick(r, nouns)
+	ident := strings.ReplaceAll(noun, " ", "_")
+	switch ext {
+	case ".go":
+		return pick(r, []string{
+			fmt.Sprintf("// %s", pick(r, sentences)),
+			fmt.Sprintf("var %s = %d", ident, r.IntN(1000)),
+			fmt.Sprintf("func %s() error { return nil }", ident),
+		})
+	case ".sh":
+		return pick(r, []string{
+			fmt.Sprintf("# %s", pick(r, sentences)),
+			fmt.Sprintf("echo \"%s\"", noun),
+			fmt.Sprintf("%s=%d", strings.ToUpper(ident), r.IntN(100)),
+		})
+	case ".toml":
+		return fmt.Sprintf("%s = %d", ident, r.IntN(100))
+	default:
+		return pick(r, sentences)
+	}
+}
+
+func lines(r *rand.Rand, ext string, n int) []string {
+	var ls []string
+	for range n {
+		ls = append(ls, line(r, ext))
+	}
+	return ls
+}
+
+// tree is what the seed knows of a repository: the files it added, the ones
+// the knot scaffolded are never touched
+type tree map[string][]string
+
+// change makes up a change to t and returns its diff, applying it to t
+func (t tree) change(r *rand.Rand) string {
+	var existing []string
+	for p := range t {
+		existing = append(existing, p)
+	}
+	slices.Sort(existing)
+
+	if len(existing) == 0 || r.IntN(3) == 0 {
+		name := strings.ReplaceAll(pick(r, nouns), " ", "_") + pick(r, extensions)
+		p := path.Join(pick(r, dirs), name)
+		if _, ok := t[p]; !ok {
+			return t.create(r, p)
+		}
+	}
+
+	return t.modify(r, pick(r, existing))
+}
+
+func (t tree) create(r *rand.Rand, p string) string {
+	content := lines(r, path.Ext(p), 3+r.IntN(10))
+	t[p] = content
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", p, p)
+	fmt.Fprintf(&b, "new file mode 100644\n")
+	fmt.Fprintf(&b, "--- /dev/null\n")
+	fmt.Fprintf(&b, "+++ b/%s\n", p)
+	fmt.Fprintf(&b, "@@ -0,0 +1,%d @@\n", len(content))
+	for _, l := range content {
+		fmt.Fprintf(&b, "+%s\n", l)
+	}
+	return b.String()
+}
+
+// modify replaces a few lines of the file at p with new ones, as a single
+// hunk with up to three lines of context
+func (t tree) modify(r *rand.Rand, p string) string {
+	old := t[p]
+
+	at := r.IntN(len(old) + 1)
+	del := min(r.IntN(3), len(old)-at)
+	ins := lines(r, path.Ext(p), 1+r.IntN(4))
+
+	start, end := max(0, at-3), min(len(old), at+del+3)
+	oldCount := end - start
+	newCount := oldCount - del + len(ins)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", p, p)
+	fmt.Fprintf(&b, "--- a/%s\n", p)
+	fmt.Fprintf(&b, "+++ b/%s\n", p)
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", start+1, oldCount, start+1, newCount)
+	for _, l := range old[start:at] {
+		fmt.Fprintf(&b, " %s\n", l)
+	}
+	for _, l := range old[at : at+del] {
+		fmt.Fprintf(&b, "-%s\n", l)
+	}
+	for _, l := range ins {
+		fmt.Fprintf(&b, "+%s\n", l)
+	}
+	for _, l := range old[at+del : end] {
+		fmt.Fprintf(&b, " %s\n", l)
+	}
+
+	t[p] = slices.Concat(old[:at], ins, old[at+del:])
+	return b.String()
+}
+
+// clone copies t, for changes that are not going to be merged
+func (t tree) clone() tree {
+	c := make(tree, len(t))
+	for p, ls := range t {
+		c[p] = slices.Clone(ls)
+	}
+	return c
+}
+
+type commit struct {
+	author  *user
+	date    time.Time
+	subject string
+	body    string
+	diff    string
+}
+
+// formatPatch renders commits as git format-patch would, so that the knot
+// lands them with their authors and dates
+func formatPatch(commits []commit) string {
+	var b strings.Builder
+	for i, c := range commits {
+		fmt.Fprintf(&b, "From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001\n")
+		fmt.Fprintf(&b, "From: %s <%s>\n", c.author.name(), c.author.email())
+		fmt.Fprintf(&b, "Date: %s\n", c.date.Format(time.RFC1123Z))
+		if len(commits) > 1 {
+			fmt.Fprintf(&b, "Subject: [PATCH %d/%d] %s\n\n", i+1, len(commits), c.subject)
+		} else {
+			fmt.Fprintf(&b, "Subject: [PATCH] %s\n\n", c.subject)
+		}
+		if c.body != "" {
+			fmt.Fprintf(&b, "%s\n\n", c.body)
+		}
+		fmt.Fprintf(&b, "---\n%s-- \n2.47.0\n\n", c.diff)
+	}
+	return b.String()
+}
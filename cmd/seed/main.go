@@ -0,0 +1,334 @@
+// seed fills an instance started by cmd/dev with people, repositories with
+// a history, issues and pulls, for working on the UI against more than a
+// handful of records. The same -seed makes up the same data.
+//
+// Everything goes through the appview, as the people would: histories are
+// built by merging pulls of format-patches, since the knot of the sandbox
+// cannot be pushed to.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"time"
+
+	"tangled.sh/tangled.sh/core/sandbox"
+)
+
+type options struct {
+	appview string
+	knot    string
+	owner   string
+
+	users   int
+	repos   int
+	commits int
+	issues  int
+	pulls   int
+	seed    uint64
+}
+
+func main() {
+	var o options
+	flag.StringVar(&o.appview, "appview", "http://localhost:3000", "address of the appview of cmd/dev")
+	flag.StringVar(&o.knot, "knot", "localhost:6000", "knot to create the repositories on")
+	flag.StringVar(&o.owner, "owner", "alice.test", "owner of the knot, who lets everyone else on it")
+	flag.IntVar(&o.users, "users", 8, "number of people to make up")
+	flag.IntVar(&o.repos, "repos", 2, "repositories per person")
+	flag.IntVar(&o.commits, "commits", 25, "commits in the history of every repository")
+	flag.IntVar(&o.issues, "issues", 6, "issues per repository")
+	flag.IntVar(&o.pulls, "pulls", 4, "pulls per repository, besides the merged ones making up the history")
+	flag.Uint64Var(&o.seed, "seed", 1, "seed of the made up data")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, o); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type user struct {
+	*sandbox.Client
+	did    string
+	handle string
+}
+
+func (u *user) name() string {
+	local, _, _ := strings.Cut(u.handle, ".")
+	return capitalize(local)
+}
+
+func (u *user) email() string {
+	local, _, _ := strings.Cut(u.handle, ".")
+	return local + "@example.com"
+}
+
+type repo struct {
+	owner *user
+	name  string
+	files tree
+}
+
+func (r *repo) path() string {
+	return fmt.Sprintf("/%s/%s", r.owner.did, r.name)
+}
+
+func run(ctx context.Context, o options) error {
+	r := rand.New(rand.NewPCG(o.seed, o.seed))
+
+	owner := &user{Client: sandbox.NewClient(o.appview), handle: o.owner}
+	if err := owner.Login(o.owner); err != nil {
+		return fmt.Errorf("%w; is cmd/dev running?", err)
+	}
+
+	var users []*user
+	for i := range o.users {
+		handle := names[i%len(names)] + ".test"
+		if i >= len(names) {
+			handle = fmt.Sprintf("%s%d.test", names[i%len(names)], i/len(names))
+		}
+
+		u, err := createUser(o.appview, handle)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", handle, err)
+		}
+		if _, err := owner.Post("/knots/"+o.knot+"/add", url.Values{"member": {u.did}}); err != nil {
+			return fmt.Errorf("adding %s to the knot: %w", handle, err)
+		}
+		users = append(users, u)
+	}
+	log.Printf("made up %d people", len(users))
+
+	for _, u := range users {
+		for range 1 + r.IntN(3) {
+			other := pick(r, users)
+			if other == u {
+				continue
+			}
+			// following twice is refused, which is fine
+			u.Post("/follow?subject="+other.did, nil)
+		}
+	}
+
+	var repos []*repo
+	for _, u := range users {
+		for range o.repos {
+			rp, err := createRepo(ctx, r, o.knot, u)
+			if err != nil {
+				return err
+			}
+			if rp != nil {
+				repos = append(repos, rp)
+			}
+		}
+	}
+	log.Printf("created %d repositories", len(repos))
+
+	since := time.Now().AddDate(-1, 0, 0)
+	for _, rp := range repos {
+		if err := history(r, rp, users, o.commits, since); err != nil {
+			return fmt.Errorf("building the history of %s: %w", rp.path(), err)
+		}
+		if err := pulls(r, rp, users, o.pulls); err != nil {
+			return fmt.Errorf("opening pulls on %s: %w", rp.path(), err)
+		}
+		if err := issues(r, rp, users, o.issues); err != nil {
+			return fmt.Errorf("opening issues on %s: %w", rp.path(), err)
+		}
+		log.Printf("filled %s/%s/%s", o.appview, "@"+rp.owner.handle, rp.name)
+	}
+
+	log.Println("done, sign in as anyone at", o.appview+"/dev/login?handle="+users[0].handle)
+	return nil
+}
+
+// createUser adds an account to the sandbox and signs in as it
+func createUser(appview, handle string) (*user, error) {
+	resp, err := http.PostForm(appview+"/dev/accounts", url.Values{"handle": {handle}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	var account struct {
+		Did    string `json:"did"`
+		Handle string `json:"handle"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
+		return nil, err
+	}
+
+	u := &user{Client: sandbox.NewClient(appview), did: account.Did, handle: account.Handle}
+	if err := u.Login(handle); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// createRepo creates a repository with a name u has not used yet, nil if
+// there is none left
+func createRepo(ctx context.Context, r *rand.Rand, knot string, u *user) (*repo, error) {
+	for range len(repoNames) {
+		name := pick(r, repoNames)
+		form := url.Values{
+			"domain":      {knot},
+			"name":        {name},
+			"description": {fmt.Sprintf(pick(r, descriptions), strings.ReplaceAll(name, "-", " "))},
+			"readme":      {"on"},
+			"license":     {pick(r, []string{"", "MIT", "ISC", "BSD-3-Clause", "Unlicense"})},
+		}
+
+		// the knot learns of new members off the firehose, which takes a
+		// moment
+		var err error
+		for range 10 {
+			if _, err = u.Post("/repo/new", form); err == nil || strings.Contains(err.Error(), "already exists") {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+		if err == nil {
+			return &repo{owner: u, name: name, files: tree{}}, nil
+		}
+		if !strings.Contains(err.Error(), "already exists") {
+			return nil, fmt.Errorf("creating %s/%s: %w", u.handle, name, err)
+		}
+	}
+	return nil, nil
+}
+
+// history lands n commits on the default branch, in pulls of a few commits
+// opened by anyone and merged by the owner
+func history(r *rand.Rand, rp *repo, users []*user, n int, since time.Time) error {
+	step := time.Since(since) / time.Duration(max(n, 1))
+	date := since
+
+	for n > 0 {
+		var commits []commit
+		for range min(n, 1+r.IntN(3)) {
+			date = date.Add(step/2 + time.Duration(r.Int64N(int64(step))))
+			commits = append(commits, commit{
+				author:  pick(r, users),
+				date:    date,
+				subject: subject(r),
+				body:    paragraph(r),
+				diff:    rp.files.change(r),
+			})
+			n--
+		}
+
+		opener := commits[0].author
+		id, err := openPull(rp, opener, commits[0].subject, paragraph(r), formatPatch(commits))
+		if err != nil {
+			return err
+		}
+
+		if r.IntN(3) == 0 {
+			commenter := pick(r, users)
+			commenter.Post(fmt.Sprintf("%s/pulls/%s/round/0/comment", rp.path(), id), url.Values{"body": {pick(r, comments)}})
+		}
+
+		strategy := "rebase"
+		if r.IntN(5) == 0 {
+			strategy = "merge"
+		}
+		if _, err := rp.owner.Post(fmt.Sprintf("%s/pulls/%s/merge", rp.path(), id), url.Values{"strategy": {strategy}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pulls opens n pulls that are left open or closed
+func pulls(r *rand.Rand, rp *repo, users []*user, n int) error {
+	for range n {
+		// these are never merged, the history stays as it is
+		files := rp.files.clone()
+		c := commit{
+			author:  pick(r, users),
+			date:    time.Now().Add(-time.Duration(r.IntN(72)) * time.Hour),
+			subject: subject(r),
+			body:    paragraph(r),
+			diff:    files.change(r),
+		}
+
+		id, err := openPull(rp, c.author, c.subject, paragraph(r), formatPatch([]commit{c}))
+		if err != nil {
+			return err
+		}
+
+		for range r.IntN(4) {
+			commenter := pick(r, users)
+			commenter.Post(fmt.Sprintf("%s/pulls/%s/round/0/comment", rp.path(), id), url.Values{"body": {pick(r, comments)}})
+		}
+
+		if r.IntN(3) == 0 {
+			if _, err := rp.owner.Post(fmt.Sprintf("%s/pulls/%s/close", rp.path(), id), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func openPull(rp *repo, u *user, title, body, patch string) (string, error) {
+	resp, err := u.Post(rp.path()+"/pulls/new", url.Values{
+		"title":        {title},
+		"body":         {body},
+		"targetBranch": {"main"},
+		"patch":        {patch},
+	})
+	if err != nil {
+		return "", err
+	}
+	return path.Base(resp.Header.Get("HX-Location")), nil
+}
+
+// issues opens n issues, with a few comments, and closes some of them
+func issues(r *rand.Rand, rp *repo, users []*user, n int) error {
+	for range n {
+		author := pick(r, users)
+		resp, err := author.Post(rp.path()+"/issues/new", url.Values{
+			"title": {capitalize(fmt.Sprintf(pick(r, issueTitles), pick(r, nouns)))},
+			"body":  {paragraph(r)},
+		})
+		if err != nil {
+			return err
+		}
+		id := path.Base(resp.Header.Get("HX-Location"))
+
+		for range r.IntN(5) {
+			commenter := pick(r, users)
+			if _, err := commenter.Post(fmt.Sprintf("%s/issues/%s/comment", rp.path(), id), url.Values{"body": {pick(r, comments)}}); err != nil {
+				return err
+			}
+		}
+
+		if r.IntN(3) == 0 {
+			if _, err := rp.owner.Post(fmt.Sprintf("%s/issues/%s/close", rp.path(), id), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/urfave/cli/v3"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// issue as served by the appview at issues/index.json
+type issue struct {
+	Id       int       `json:"id"`
+	Uri      string    `json:"uri"`
+	Owner    string    `json:"owner"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	Open     bool      `json:"open"`
+	Comments int       `json:"comments"`
+	Created  time.Time `json:"created"`
+}
+
+func issueCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "issue",
+		Usage: "list, open and comment on issues",
+		Commands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "list the issues of a repo",
+				ArgsUsage: "<owner>/<repo>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "closed",
+						Usage: "list closed issues instead of open ones",
+					},
+				},
+				Action: listIssues,
+			},
+			{
+				Name:      "create",
+				Usage:     "open an issue",
+				ArgsUsage: "<owner>/<repo>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "title",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "body",
+						Required: true,
+					},
+				},
+				Action: createIssue,
+			},
+			{
+				Name:      "comment",
+				Usage:     "comment on an issue",
+				ArgsUsage: "<owner>/<repo> <issue>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "body",
+						Required: true,
+					},
+				},
+				Action: commentOnIssue,
+			},
+		},
+	}
+}
+
+func listIssues(ctx context.Context, cmd *cli.Command) error {
+	repo, err := resolveRepo(ctx, cmd.Args().First())
+	if err != nil {
+		return err
+	}
+
+	state := "open"
+	if cmd.Bool("closed") {
+		state = "closed"
+	}
+
+	body, err := getAppview(ctx, cmd, repo, "/issues/index.json?state="+state)
+	if err != nil {
+		return fmt.Errorf("listing issues: %w", err)
+	}
+
+	var issues []issue
+	if err := json.Unmarshal(body, &issues); err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("no %s issues\n", state)
+		return nil
+	}
+
+	for _, i := range issues {
+		fmt.Printf("#%-5d %s (%d comments)\n", i.Id, i.Title, i.Comments)
+	}
+	return nil
+}
+
+func createIssue(ctx context.Context, cmd *cli.Command) error {
+	repo, err := resolveRepo(ctx, cmd.Args().First())
+	if err != nil {
+		return err
+	}
+
+	s, client, err := authedClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	body := cmd.String("body")
+	resp, err := comatproto.RepoPutRecord(ctx, client, &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoIssueNSID,
+		Repo:       s.Did,
+		Rkey:       tid.TID(),
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &tangled.RepoIssue{
+				Repo:      repo.Uri.String(),
+				Title:     cmd.String("title"),
+				Body:      &body,
+				CreatedAt: time.Now().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("writing issue record: %w", err)
+	}
+
+	fmt.Println("created", resp.Uri)
+	fmt.Println("it will show up at", appviewUrl(cmd, repo, "/issues"), "shortly")
+	return nil
+}
+
+func commentOnIssue(ctx context.Context, cmd *cli.Command) error {
+	repo, err := resolveRepo(ctx, cmd.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	issueId, err := strconv.Atoi(cmd.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("usage: tangled issue comment <owner>/<repo> <issue>")
+	}
+
+	data, err := getAppview(ctx, cmd, repo, fmt.Sprintf("/issues/%d.json", issueId))
+	if err != nil {
+		return fmt.Errorf("fetching issue #%d: %w", issueId, err)
+	}
+
+	var i issue
+	if err := json.Unmarshal(data, &i); err != nil {
+		return err
+	}
+
+	s, client, err := authedClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	repoUri := repo.Uri.String()
+	_, err = comatproto.RepoPutRecord(ctx, client, &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoIssueCommentNSID,
+		Repo:       s.Did,
+		Rkey:       tid.TID(),
+		Record: &lexutil.LexiconTypeDecoder{
+			Val: &tangled.RepoIssueComment{
+				Repo:      &repoUri,
+				Issue:     i.Uri,
+				Body:      cmd.String("body"),
+				CreatedAt: time.Now().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("writing comment record: %w", err)
+	}
+
+	fmt.Printf("commented on #%d: %s\n", i.Id, i.Title)
+	return nil
+}
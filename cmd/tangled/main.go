@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+)
+
+func main() {
+	cmd := &cli.Command{
+		Name:  "tangled",
+		Usage: "work with tangled repos, issues and pulls from the command line",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "appview",
+				Usage:   "appview to read issues and pulls from",
+				Value:   "https://tangled.sh",
+				Sources: cli.EnvVars("TANGLED_APPVIEW"),
+			},
+		},
+		Commands: []*cli.Command{
+			loginCommand(),
+			logoutCommand(),
+			repoCommand(),
+			issueCommand(),
+			pullCommand(),
+		},
+	}
+
+	if err := cmd.Run(context.Background(), os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
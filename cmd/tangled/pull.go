@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/urfave/cli/v3"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/patchutil"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// pull as served by the appview at pulls/{pull}.json
+type pull struct {
+	Id           int    `json:"id"`
+	Uri          string `json:"uri"`
+	Title        string `json:"title"`
+	State        string `json:"state"`
+	TargetBranch string `json:"targetBranch"`
+	LatestRound  int    `json:"latestRound"`
+}
+
+func pullCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "pr",
+		Aliases: []string{"pull"},
+		Usage:   "open and check out pull requests",
+		Commands: []*cli.Command{
+			{
+				Name:      "create",
+				Usage:     "open a pull request with the commits on the current branch",
+				ArgsUsage: "<owner>/<repo>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "target",
+						Usage: "branch to merge into",
+						Value: "main",
+					},
+					&cli.StringFlag{
+						Name:  "base",
+						Usage: "local ref the target branch is at; defaults to origin/<target>",
+					},
+					&cli.StringFlag{
+						Name:  "title",
+						Usage: "title of the pull; defaults to the first commit's subject",
+					},
+					&cli.StringFlag{
+						Name: "body",
+					},
+				},
+				Action: createPull,
+			},
+			{
+				Name:      "checkout",
+				Usage:     "apply the latest round of a pull onto a new local branch",
+				ArgsUsage: "<owner>/<repo> <pull>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "branch",
+						Usage: "branch to create; defaults to pull/<pull>",
+					},
+					&cli.StringFlag{
+						Name:  "base",
+						Usage: "local ref the target branch is at; defaults to origin/<target>",
+					},
+				},
+				Action: checkoutPull,
+			},
+		},
+	}
+}
+
+func git(stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+func createPull(ctx context.Context, cmd *cli.Command) error {
+	repo, err := resolveRepo(ctx, cmd.Args().First())
+	if err != nil {
+		return err
+	}
+
+	target := cmd.String("target")
+	base := cmd.String("base")
+	if base == "" {
+		base = "origin/" + target
+	}
+
+	mergeBase, err := git(nil, "merge-base", "HEAD", base)
+	if err != nil {
+		return err
+	}
+
+	patch, err := git(nil, "format-patch", "--stdout", strings.TrimSpace(string(mergeBase))+"..HEAD")
+	if err != nil {
+		return err
+	}
+	if len(patch) == 0 {
+		return fmt.Errorf("no commits between %s and HEAD", base)
+	}
+
+	title, body := cmd.String("title"), cmd.String("body")
+	if title == "" {
+		patches, err := patchutil.ExtractPatches(string(patch))
+		if err != nil || len(patches) == 0 {
+			return fmt.Errorf("could not read a title from the patch, pass --title")
+		}
+		title = patches[0].Title
+		if body == "" {
+			body = patches[0].Body
+		}
+	}
+
+	s, client, err := authedClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	record := &tangled.RepoPull{
+		Title: title,
+		Target: &tangled.RepoPull_Target{
+			Repo:   repo.Uri.String(),
+			Branch: target,
+		},
+		Patch:     string(patch),
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if body != "" {
+		record.Body = &body
+	}
+
+	resp, err := comatproto.RepoPutRecord(ctx, client, &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoPullNSID,
+		Repo:       s.Did,
+		Rkey:       tid.TID(),
+		Record:     &lexutil.LexiconTypeDecoder{Val: record},
+	})
+	if err != nil {
+		return fmt.Errorf("writing pull record: %w", err)
+	}
+
+	fmt.Println("created", resp.Uri)
+	fmt.Println("it will show up at", appviewUrl(cmd, repo, "/pulls"), "shortly")
+	return nil
+}
+
+func checkoutPull(ctx context.Context, cmd *cli.Command) error {
+	repo, err := resolveRepo(ctx, cmd.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	pullId, err := strconv.Atoi(cmd.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("usage: tangled pr checkout <owner>/<repo> <pull>")
+	}
+
+	data, err := getAppview(ctx, cmd, repo, fmt.Sprintf("/pulls/%d.json", pullId))
+	if err != nil {
+		return fmt.Errorf("fetching pull #%d: %w", pullId, err)
+	}
+
+	var p pull
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	patch, err := getAppview(ctx, cmd, repo, fmt.Sprintf("/pulls/%d/round/%d.patch", pullId, p.LatestRound))
+	if err != nil {
+		return fmt.Errorf("fetching patch: %w", err)
+	}
+
+	branch := cmd.String("branch")
+	if branch == "" {
+		branch = fmt.Sprintf("pull/%d", pullId)
+	}
+	base := cmd.String("base")
+	if base == "" {
+		base = "origin/" + p.TargetBranch
+	}
+
+	if _, err := git(nil, "checkout", "-b", branch, base); err != nil {
+		return err
+	}
+
+	if patchutil.IsFormatPatch(string(patch)) {
+		if _, err := git(patch, "am", "--3way"); err != nil {
+			return err
+		}
+		fmt.Printf("checked out #%d (round %d) on %s\n", p.Id, p.LatestRound, branch)
+		return nil
+	}
+
+	// plain diffs carry no commit information, leave them staged
+	if _, err := git(patch, "apply", "--index"); err != nil {
+		return err
+	}
+	fmt.Printf("applied #%d (round %d) to %s, changes are staged\n", p.Id, p.LatestRound, branch)
+	return nil
+}
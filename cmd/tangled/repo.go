@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/urfave/cli/v3"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/repolookup"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// appviewUrl builds a url to this repo on the appview
+func appviewUrl(cmd *cli.Command, repo *repolookup.Repo, path string) string {
+	return fmt.Sprintf("%s/%s/%s%s", strings.TrimSuffix(cmd.String("appview"), "/"), repo.Owner.DID, repo.Record.Name, path)
+}
+
+func resolveRepo(ctx context.Context, ref string) (*repolookup.Repo, error) {
+	return repolookup.Resolve(ctx, idresolver.DefaultResolver(), ref)
+}
+
+// getAppview fetches a path from the appview, relative to the repo
+func getAppview(ctx context.Context, cmd *cli.Command, repo *repolookup.Repo, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, appviewUrl(cmd, repo, path), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
+
+func repoCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "repo",
+		Usage: "create repos and find their clone urls",
+		Commands: []*cli.Command{
+			{
+				Name:      "create",
+				Usage:     "create a repo on a knot",
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "knot",
+						Usage:    "knot to host the repo on",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "description",
+						Usage: "short description of the repo",
+					},
+					&cli.StringFlag{
+						Name:  "default-branch",
+						Usage: "default branch of the repo",
+					},
+					&cli.BoolFlag{
+						Name:  "dev",
+						Usage: "reach the knot over plain http",
+					},
+				},
+				Action: createRepo,
+			},
+			{
+				Name:      "clone-url",
+				Usage:     "print the clone urls of a repo",
+				ArgsUsage: "<owner>/<repo>",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					repo, err := resolveRepo(ctx, cmd.Args().First())
+					if err != nil {
+						return err
+					}
+
+					printCloneUrls(cmd, repo)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func printCloneUrls(cmd *cli.Command, repo *repolookup.Repo) {
+	fmt.Printf("https:   %s/%s/%s\n", strings.TrimSuffix(cmd.String("appview"), "/"), repo.OwnerHandle(), repo.Record.Name)
+	fmt.Printf("ssh:     %s\n", repo.SshUrl())
+	fmt.Printf("tangled: tangled://%s/%s\n", repo.Owner.DID, repo.Record.Name)
+}
+
+func createRepo(ctx context.Context, cmd *cli.Command) error {
+	name := cmd.Args().First()
+	if name == "" {
+		return fmt.Errorf("usage: tangled repo create --knot <knot> <name>")
+	}
+	knot := cmd.String("knot")
+
+	s, client, err := authedClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	record := &tangled.Repo{
+		Knot:      knot,
+		Name:      name,
+		Owner:     s.Did,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if d := cmd.String("description"); d != "" {
+		record.Description = &d
+	}
+
+	// the knot reads this record back from the PDS when creating the repo
+	rkey := tid.TID()
+	resp, err := comatproto.RepoPutRecord(ctx, client, &comatproto.RepoPutRecord_Input{
+		Collection: tangled.RepoNSID,
+		Repo:       s.Did,
+		Rkey:       rkey,
+		Record:     &lexutil.LexiconTypeDecoder{Val: record},
+	})
+	if err != nil {
+		return fmt.Errorf("writing repo record: %w", err)
+	}
+
+	knotClient, err := serviceClient(ctx, client, knot, tangled.RepoCreateNSID, cmd.Bool("dev"))
+	if err == nil {
+		input := &tangled.RepoCreate_Input{Rkey: rkey}
+		if b := cmd.String("default-branch"); b != "" {
+			input.DefaultBranch = &b
+		}
+		err = tangled.RepoCreate(ctx, knotClient, input)
+	}
+	if err != nil {
+		// don't leave a record behind for a repo that does not exist
+		_, _ = comatproto.RepoDeleteRecord(ctx, client, &comatproto.RepoDeleteRecord_Input{
+			Collection: tangled.RepoNSID,
+			Repo:       s.Did,
+			Rkey:       rkey,
+		})
+		return fmt.Errorf("creating repo on %s: %w", knot, err)
+	}
+
+	fmt.Println("created", resp.Uri)
+	printCloneUrls(cmd, &repolookup.Repo{
+		Owner: &identity.Identity{
+			DID:    syntax.DID(s.Did),
+			Handle: syntax.Handle(s.Handle),
+		},
+		Uri:    syntax.ATURI(resp.Uri),
+		Record: record,
+	})
+	return nil
+}
+
+// serviceClient returns a client for a knot, authenticated with a service
+// auth token for a single method
+func serviceClient(ctx context.Context, client *xrpc.Client, knot, lxm string, dev bool) (*xrpc.Client, error) {
+	exp := time.Now().Add(time.Minute).Unix()
+	resp, err := comatproto.ServerGetServiceAuth(ctx, client, "did:web:"+knot, exp, lxm)
+	if err != nil {
+		return nil, fmt.Errorf("getting service auth: %w", err)
+	}
+
+	scheme := "https"
+	if dev {
+		scheme = "http"
+	}
+
+	return &xrpc.Client{
+		Host: fmt.Sprintf("%s://%s", scheme, knot),
+		Auth: &xrpc.AuthInfo{AccessJwt: resp.Token},
+	}, nil
+}
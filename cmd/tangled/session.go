@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/urfave/cli/v3"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+var errNotLoggedIn = errors.New("not logged in, run `tangled login` first")
+
+// session is an app password session with the user's PDS. records are
+// written straight to the PDS, and the appview picks them up from there.
+type session struct {
+	Did        string `json:"did"`
+	Handle     string `json:"handle"`
+	Pds        string `json:"pds"`
+	AccessJwt  string `json:"accessJwt"`
+	RefreshJwt string `json:"refreshJwt"`
+}
+
+func sessionPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tangled", "session.json"), nil
+}
+
+func loadSession() (*session, error) {
+	path, err := sessionPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errNotLoggedIn
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("corrupt session file %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+func (s *session) save() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+func (s *session) client() *xrpc.Client {
+	return &xrpc.Client{
+		Host: s.Pds,
+		Auth: &xrpc.AuthInfo{
+			AccessJwt:  s.AccessJwt,
+			RefreshJwt: s.RefreshJwt,
+			Did:        s.Did,
+			Handle:     s.Handle,
+		},
+	}
+}
+
+// authedClient loads the stored session and refreshes it, so that commands
+// never run into an expired access token.
+func authedClient(ctx context.Context) (*session, *xrpc.Client, error) {
+	s, err := loadSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// refreshSession is authenticated with the refresh token
+	refresher := s.client()
+	refresher.Auth.AccessJwt = s.RefreshJwt
+
+	resp, err := comatproto.ServerRefreshSession(ctx, refresher)
+	if err != nil {
+		return nil, nil, fmt.Errorf("session expired, run `tangled login` again: %w", err)
+	}
+
+	s.AccessJwt = resp.AccessJwt
+	s.RefreshJwt = resp.RefreshJwt
+	s.Handle = resp.Handle
+	if err := s.save(); err != nil {
+		return nil, nil, err
+	}
+
+	return s, s.client(), nil
+}
+
+func loginCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "login",
+		Usage:     "log in with an app password",
+		ArgsUsage: "<handle>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "app-password",
+				Usage:   "app password to log in with; prompted for if not set",
+				Sources: cli.EnvVars("TANGLED_APP_PASSWORD"),
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			handle := cmd.Args().First()
+			if handle == "" {
+				return fmt.Errorf("usage: tangled login <handle>")
+			}
+
+			ident, err := idresolver.DefaultResolver().ResolveIdent(ctx, strings.TrimPrefix(handle, "@"))
+			if err != nil {
+				return fmt.Errorf("resolving %s: %w", handle, err)
+			}
+
+			password := cmd.String("app-password")
+			if password == "" {
+				fmt.Fprint(os.Stderr, "app password: ")
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil {
+					return err
+				}
+				password = strings.TrimSpace(line)
+			}
+
+			client := &xrpc.Client{Host: ident.PDSEndpoint()}
+			resp, err := comatproto.ServerCreateSession(ctx, client, &comatproto.ServerCreateSession_Input{
+				Identifier: ident.DID.String(),
+				Password:   password,
+			})
+			if err != nil {
+				return fmt.Errorf("logging in: %w", err)
+			}
+
+			s := session{
+				Did:        resp.Did,
+				Handle:     resp.Handle,
+				Pds:        ident.PDSEndpoint(),
+				AccessJwt:  resp.AccessJwt,
+				RefreshJwt: resp.RefreshJwt,
+			}
+			if err := s.save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("logged in as @%s (%s)\n", s.Handle, s.Did)
+			return nil
+		},
+	}
+}
+
+func logoutCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "logout",
+		Usage: "forget the stored session",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			path, err := sessionPath()
+			if err != nil {
+				return err
+			}
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package crypto
+
+import (
+	"crypto/rsa"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHKey describes the kind of an ssh public key.
+type SSHKey struct {
+	// short name of the algorithm, e.g. "ed25519", "ecdsa-sk" or "rsa"
+	Type string
+	Bits int
+	// whether the private key lives on a hardware security key (FIDO/U2F),
+	// which has to be touched for every use
+	SecurityKey bool
+}
+
+// ParseSSHKey classifies an ssh public key in authorized_keys format.
+func ParseSSHKey(pubKey string) (SSHKey, error) {
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pubKey))
+	if err != nil {
+		return SSHKey{}, err
+	}
+
+	if cert, ok := pk.(*ssh.Certificate); ok {
+		pk = cert.Key
+	}
+
+	switch pk.Type() {
+	case ssh.KeyAlgoED25519:
+		return SSHKey{Type: "ed25519", Bits: 256}, nil
+	case ssh.KeyAlgoSKED25519:
+		return SSHKey{Type: "ed25519-sk", Bits: 256, SecurityKey: true}, nil
+	case ssh.KeyAlgoECDSA256:
+		return SSHKey{Type: "ecdsa", Bits: 256}, nil
+	case ssh.KeyAlgoECDSA384:
+		return SSHKey{Type: "ecdsa", Bits: 384}, nil
+	case ssh.KeyAlgoECDSA521:
+		return SSHKey{Type: "ecdsa", Bits: 521}, nil
+	case ssh.KeyAlgoSKECDSA256:
+		return SSHKey{Type: "ecdsa-sk", Bits: 256, SecurityKey: true}, nil
+	case ssh.KeyAlgoDSA:
+		return SSHKey{Type: "dsa", Bits: 1024}, nil
+	case ssh.KeyAlgoRSA:
+		key := SSHKey{Type: "rsa"}
+		if cpk, ok := pk.(ssh.CryptoPublicKey); ok {
+			if rsaKey, ok := cpk.CryptoPublicKey().(*rsa.PublicKey); ok {
+				key.Bits = rsaKey.N.BitLen()
+			}
+		}
+		return key, nil
+	}
+
+	return SSHKey{Type: pk.Type()}, nil
+}
+
+// Weakness explains why a key should not be trusted, or is empty when the
+// key is fine.
+func (k SSHKey) Weakness() string {
+	switch k.Type {
+	case "dsa":
+		return "DSA keys are insecure and no longer supported by OpenSSH"
+	case "rsa":
+		if k.Bits < 2048 {
+			return fmt.Sprintf("RSA keys need at least 2048 bits, this one has %d", k.Bits)
+		}
+	}
+	return ""
+}
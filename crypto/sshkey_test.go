@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func authorizedKey(t *testing.T, key any) string {
+	t.Helper()
+	pk, err := ssh.NewPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(ssh.MarshalAuthorizedKey(pk))
+}
+
+func TestParseSSHKey(t *testing.T) {
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		typ      string
+		sk, weak bool
+	}{
+		{"ed25519", authorizedKey(t, edPub), "ed25519", false, false},
+		{"small rsa", authorizedKey(t, &smallRSA.PublicKey), "rsa", false, true},
+		// the format ssh-keygen -t ed25519-sk produces for a FIDO key
+		{"ed25519-sk", "sk-ssh-ed25519@openssh.com AAAAGnNrLXNzaC1lZDI1NTE5QG9wZW5zc2guY29tAAAAIHDVm2V2cm4yg3dGEl6TA9ZlzO8dhuqFH5UJKSvZzTlXAAAABHNzaDo=", "ed25519-sk", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseSSHKey(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Type != tt.typ || info.SecurityKey != tt.sk || (info.Weakness() != "") != tt.weak {
+				t.Errorf("got %+v, weakness %q", info, info.Weakness())
+			}
+		})
+	}
+
+	if _, err := ParseSSHKey("not a key"); err == nil {
+		t.Error("expected an error for garbage input")
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/hiddeco/sshsig"
 	"golang.org/x/crypto/ssh"
 	"tangled.sh/tangled.sh/core/types"
@@ -31,7 +33,7 @@ func VerifySignature(pubKey, signature, payload []byte) (error, bool) {
 	return err, err == nil
 }
 
-// VerifyCommitSignature reconstructs the payload used to sign a commit. This is
+// commitPayload reconstructs the payload used to sign a commit. This is
 // essentially the git cat-file output but without the gpgsig header.
 //
 // Caveats: signature verification will fail on commits with more than one parent,
@@ -39,9 +41,7 @@ func VerifySignature(pubKey, signature, payload []byte) (error, bool) {
 // and we are unable to reconstruct the payload correctly.
 //
 // Ideally this should directly operate on an *object.Commit.
-func VerifyCommitSignature(pubKey string, commit types.NiceDiff) (error, bool) {
-	signature := commit.Commit.PGPSignature
-
+func commitPayload(commit types.NiceDiff) string {
 	author := bytes.NewBuffer([]byte{})
 	committer := bytes.NewBuffer([]byte{})
 	commit.Commit.Author.Encode(author)
@@ -60,7 +60,35 @@ func VerifyCommitSignature(pubKey string, commit types.NiceDiff) (error, bool) {
 	}
 	fmt.Fprintf(&payload, "\n%s", commit.Commit.Message)
 
-	return VerifySignature([]byte(pubKey), []byte(signature), []byte(payload.String()))
+	return payload.String()
+}
+
+// IsPGPSignature reports whether a commit was signed with gpg rather than ssh.
+func IsPGPSignature(signature string) bool {
+	return strings.HasPrefix(strings.TrimSpace(signature), "-----BEGIN PGP SIGNATURE-----")
+}
+
+// VerifyCommitSignature verifies an ssh signed commit against an ssh public key.
+func VerifyCommitSignature(pubKey string, commit types.NiceDiff) (error, bool) {
+	signature := commit.Commit.PGPSignature
+	return VerifySignature([]byte(pubKey), []byte(signature), []byte(commitPayload(commit)))
+}
+
+// VerifyCommitPGPSignature verifies a gpg signed commit against an armored
+// gpg public key.
+func VerifyCommitPGPSignature(armoredKey string, commit types.NiceDiff) (error, bool) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKey))
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err), false
+	}
+
+	_, err = openpgp.CheckArmoredDetachedSignature(
+		keyring,
+		strings.NewReader(commitPayload(commit)),
+		strings.NewReader(commit.Commit.PGPSignature),
+		nil,
+	)
+	return err, err == nil
 }
 
 // SSHFingerprint computes the fingerprint of the supplied ssh pubkey.
@@ -73,3 +101,18 @@ func SSHFingerprint(pubKey string) (string, error) {
 	hash := sha256.Sum256(pk.Marshal())
 	return "SHA256:" + base64.StdEncoding.EncodeToString(hash[:]), nil
 }
+
+// PGPFingerprint computes the fingerprint of the primary key in an armored gpg
+// public key.
+func PGPFingerprint(armoredKey string) (string, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKey))
+	if err != nil {
+		return "", err
+	}
+
+	if len(keyring) == 0 || keyring[0].PrimaryKey == nil {
+		return "", fmt.Errorf("no public key found")
+	}
+
+	return strings.ToUpper(hex.EncodeToString(keyring[0].PrimaryKey.Fingerprint)), nil
+}
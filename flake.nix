@@ -82,10 +82,11 @@
         spindle = self.callPackage ./nix/pkgs/spindle.nix {};
         knot-unwrapped = self.callPackage ./nix/pkgs/knot-unwrapped.nix {};
         knot = self.callPackage ./nix/pkgs/knot.nix {};
+        tangled-cli = self.callPackage ./nix/pkgs/tangled-cli.nix {};
       });
   in {
     overlays.default = final: prev: {
-      inherit (mkPackageSet final) lexgen sqlite-lib genjwks spindle knot-unwrapped knot appview;
+      inherit (mkPackageSet final) lexgen sqlite-lib genjwks spindle knot-unwrapped knot appview tangled-cli;
     };
 
     packages = forAllSystems (system: let
@@ -94,7 +95,7 @@
       staticPackages = mkPackageSet pkgs.pkgsStatic;
       crossPackages = mkPackageSet pkgs.pkgsCross.gnu64.pkgsStatic;
     in {
-      inherit (packages) appview appview-static-files lexgen genjwks spindle knot knot-unwrapped sqlite-lib;
+      inherit (packages) appview appview-static-files lexgen genjwks spindle knot knot-unwrapped sqlite-lib tangled-cli;
 
       pkgsStatic-appview = staticPackages.appview;
       pkgsStatic-knot = staticPackages.knot;
@@ -10,12 +10,16 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bluesky-social/indigo/atproto/identity"
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/urfave/cli/v3"
+	"tangled.sh/tangled.sh/core/aclcache"
 	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/knotserver/git/service"
 	"tangled.sh/tangled.sh/core/log"
 )
 
@@ -45,6 +49,20 @@ func Command() *cli.Command {
 				Usage: "internal API endpoint",
 				Value: "http://localhost:5444",
 			},
+			&cli.StringFlag{
+				Name:  "push-repos",
+				Usage: "comma-separated repos the user may push to, used if the internal API is unreachable",
+			},
+			&cli.StringFlag{
+				Name:  "acl-snapshot",
+				Usage: "signed acl snapshot to fall back on if the internal API is unreachable",
+				Value: "/home/git/acl-snapshot.json",
+			},
+			&cli.DurationFlag{
+				Name:  "acl-max-age",
+				Usage: "oldest acl snapshot that will be trusted",
+				Value: 24 * time.Hour,
+			},
 			&cli.StringFlag{
 				Name:  "motd-file",
 				Usage: "path to message of the day file",
@@ -62,6 +80,11 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 	logPath := cmd.String("log-path")
 	endpoint := cmd.String("internal-api")
 	motdFile := cmd.String("motd-file")
+	offline := offlineAcl{
+		pushRepos:    parsePushRepos(cmd.String("push-repos")),
+		snapshotPath: cmd.String("acl-snapshot"),
+		maxAge:       cmd.Duration("acl-max-age"),
+	}
 
 	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -139,7 +162,7 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	if gitCommand != "git-upload-pack" {
-		if !isPushPermitted(l, incomingUser, qualifiedRepoName, endpoint) {
+		if !isPushPermitted(l, incomingUser, qualifiedRepoName, endpoint, offline) {
 			l.Error("access denied: user not allowed",
 				"did", incomingUser,
 				"reponame", qualifiedRepoName)
@@ -172,6 +195,10 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 	io.Copy(os.Stderr, motdReader)
 
 	gitCmd := exec.Command(gitCommand, fullPath)
+	if gitCommand == "git-upload-pack" {
+		// configured as over http, so that partial clones work over ssh too
+		gitCmd = exec.Command("git", append(slices.Clone(service.UploadPackConfig), "upload-pack", fullPath)...)
+	}
 	gitCmd.Stdout = os.Stdout
 	gitCmd.Stderr = os.Stderr
 	gitCmd.Stdin = os.Stdin
@@ -192,9 +219,30 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 		"repo", repoName,
 		"success", true)
 
+	if gitCommand == "git-upload-pack" {
+		countClone(l, qualifiedRepoName, endpoint)
+	}
+
 	return nil
 }
 
+// countClone tells the knot about a clone for its usage stats, failing to is
+// not worth failing the clone over
+func countClone(l *slog.Logger, qualifiedRepoName, endpoint string) {
+	u, _ := url.Parse(endpoint + "/stats/clone")
+	q := u.Query()
+	q.Add("repo", qualifiedRepoName)
+	u.RawQuery = q.Encode()
+
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Post(u.String(), "", nil)
+	if err != nil {
+		l.Warn("failed to count clone", "error", err)
+		return
+	}
+	resp.Body.Close()
+}
+
 func resolveIdentity(ctx context.Context, l *slog.Logger, didOrHandle string) *identity.Identity {
 	resolver := idresolver.DefaultResolver()
 	ident, err := resolver.ResolveIdent(ctx, didOrHandle)
@@ -211,7 +259,7 @@ func resolveIdentity(ctx context.Context, l *slog.Logger, didOrHandle string) *i
 	return ident
 }
 
-func isPushPermitted(l *slog.Logger, user, qualifiedRepoName, endpoint string) bool {
+func isPushPermitted(l *slog.Logger, user, qualifiedRepoName, endpoint string, offline offlineAcl) bool {
 	u, _ := url.Parse(endpoint + "/push-allowed")
 	q := u.Query()
 	q.Add("user", user)
@@ -221,13 +269,72 @@ func isPushPermitted(l *slog.Logger, user, qualifiedRepoName, endpoint string) b
 	req, err := http.Get(u.String())
 	if err != nil {
 		l.Error("Error verifying permissions", "error", err)
-		fmt.Fprintf(os.Stderr, "error verifying permissions: %v\n", err)
-		os.Exit(1)
+		return offline.isPushPermitted(l, user, qualifiedRepoName)
 	}
+	defer req.Body.Close()
 
 	l.Info("Checking push permission",
 		"url", u.String(),
 		"status", req.Status)
 
-	return req.StatusCode == http.StatusNoContent
+	switch req.StatusCode {
+	case http.StatusNoContent:
+		return true
+	case http.StatusForbidden, http.StatusBadRequest:
+		return false
+	default:
+		// the knot is up but not answering properly, e.g. behind a proxy
+		// while it restarts
+		return offline.isPushPermitted(l, user, qualifiedRepoName)
+	}
+}
+
+// offlineAcl is what guard falls back on when the internal API cannot be
+// reached: the signed snapshot the knot keeps on disk, and failing that, the
+// repos encoded into the authorized_keys entry for this key.
+type offlineAcl struct {
+	pushRepos    map[string]bool
+	snapshotPath string
+	maxAge       time.Duration
+}
+
+func (o offlineAcl) isPushPermitted(l *slog.Logger, user, qualifiedRepoName string) bool {
+	if snapshot, err := aclcache.Read(o.snapshotPath, o.maxAge); err == nil {
+		allowed := snapshot.CanPush(user, qualifiedRepoName)
+		l.Warn("internal API unreachable, using acl snapshot",
+			"did", user,
+			"reponame", qualifiedRepoName,
+			"generated", snapshot.Generated,
+			"allowed", allowed)
+		return allowed
+	} else {
+		l.Error("failed to read acl snapshot", "error", err)
+	}
+
+	if o.pushRepos == nil {
+		fmt.Fprintln(os.Stderr, "error verifying permissions: internal API unreachable")
+		return false
+	}
+
+	allowed := o.pushRepos[qualifiedRepoName]
+	l.Warn("internal API unreachable, using cached permissions",
+		"did", user,
+		"reponame", qualifiedRepoName,
+		"allowed", allowed)
+
+	return allowed
+}
+
+func parsePushRepos(s string) map[string]bool {
+	if s == "" {
+		return nil
+	}
+
+	repos := make(map[string]bool)
+	for _, repo := range strings.Split(s, ",") {
+		if repo = strings.TrimSpace(repo); repo != "" {
+			repos[repo] = true
+		}
+	}
+	return repos
 }
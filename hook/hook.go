@@ -2,9 +2,11 @@ package hook
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -14,6 +16,8 @@ import (
 
 type HookResponse struct {
 	Messages []string `json:"messages"`
+	// set by the pre-receive hook, to refuse the push
+	Rejected bool `json:"rejected,omitempty"`
 }
 
 // The hook command is nested like so:
@@ -52,6 +56,11 @@ func Command() *cli.Command {
 				Usage:  "sends a post-recieve hook to the knot (waits for stdin)",
 				Action: postRecieve,
 			},
+			{
+				Name:   "pre-receive",
+				Usage:  "asks the knot whether to accept a push (waits for stdin)",
+				Action: preReceive,
+			},
 		},
 	}
 }
@@ -104,3 +113,63 @@ func postRecieve(ctx context.Context, cmd *cli.Command) error {
 
 	return nil
 }
+
+// QuarantineEnv maps the variables git runs pre-receive with to the headers
+// they are passed on in. The objects of a push are held in quarantine until
+// it is accepted, and these say where.
+var QuarantineEnv = map[string]string{
+	"GIT_OBJECT_DIRECTORY":             "X-Git-Object-Directory",
+	"GIT_ALTERNATE_OBJECT_DIRECTORIES": "X-Git-Alternate-Object-Directories",
+	"GIT_QUARANTINE_PATH":              "X-Git-Quarantine-Path",
+}
+
+func preReceive(ctx context.Context, cmd *cli.Command) error {
+	gitDir := cmd.String("git-dir")
+	userDid := cmd.String("user-did")
+	endpoint := cmd.String("internal-api")
+
+	// unlike post-receive, every updated ref matters here
+	payload, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "http://"+endpoint+"/hooks/pre-receive", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("X-Git-Dir", gitDir)
+	req.Header.Set("X-Git-User-Did", userDid)
+	for env, header := range QuarantineEnv {
+		if v := os.Getenv(env); v != "" {
+			req.Header.Set(header, v)
+		}
+	}
+
+	// the policy is not worth refusing every push over while the knot is
+	// unreachable
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Println("warning: failed to check the history policy:", err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var data HookResponse
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&data) != nil {
+		fmt.Println("warning: failed to check the history policy")
+		return nil
+	}
+
+	for _, message := range data.Messages {
+		fmt.Println(message)
+	}
+
+	if data.Rejected {
+		return cli.Exit("push rejected", 1)
+	}
+
+	return nil
+}
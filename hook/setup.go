@@ -107,13 +107,13 @@ func SetupRepo(config config, path string) error {
 		return fmt.Errorf("%s: %w", path, ErrNoGitRepo)
 	}
 
-	preReceiveD := filepath.Join(path, "hooks", "post-receive.d")
-	if err := os.MkdirAll(preReceiveD, 0755); err != nil {
-		return fmt.Errorf("%s: %w", preReceiveD, ErrCreatingHookDir)
+	postReceiveD := filepath.Join(path, "hooks", "post-receive.d")
+	if err := os.MkdirAll(postReceiveD, 0755); err != nil {
+		return fmt.Errorf("%s: %w", postReceiveD, ErrCreatingHookDir)
 	}
 
-	notify := filepath.Join(preReceiveD, "40-notify.sh")
-	if err := mkHook(config, notify); err != nil {
+	notify := filepath.Join(postReceiveD, "40-notify.sh")
+	if err := mkHook(config, notify, "post-recieve"); err != nil {
 		return fmt.Errorf("%s: %w", notify, ErrCreatingHook)
 	}
 
@@ -122,10 +122,26 @@ func SetupRepo(config config, path string) error {
 		return fmt.Errorf("%s: %w", delegate, ErrCreatingDelegate)
 	}
 
+	// refuses pushes that break the history policy of the repo
+	preReceiveD := filepath.Join(path, "hooks", "pre-receive.d")
+	if err := os.MkdirAll(preReceiveD, 0755); err != nil {
+		return fmt.Errorf("%s: %w", preReceiveD, ErrCreatingHookDir)
+	}
+
+	policy := filepath.Join(preReceiveD, "40-policy.sh")
+	if err := mkHook(config, policy, "pre-receive"); err != nil {
+		return fmt.Errorf("%s: %w", policy, ErrCreatingHook)
+	}
+
+	delegate = filepath.Join(path, "hooks", "pre-receive")
+	if err := mkDelegate(delegate); err != nil {
+		return fmt.Errorf("%s: %w", delegate, ErrCreatingDelegate)
+	}
+
 	return nil
 }
 
-func mkHook(config config, hookPath string) error {
+func mkHook(config config, hookPath, hookName string) error {
 	executablePath, err := os.Executable()
 	if err != nil {
 		return err
@@ -138,8 +154,8 @@ for ((i=0; i<GIT_PUSH_OPTION_COUNT; i++)); do
     option_var="GIT_PUSH_OPTION_$i"
     push_options+=(-push-option "${!option_var}")
 done
-%s hook -git-dir "$GIT_DIR" -user-did "$GIT_USER_DID" -user-handle "$GIT_USER_HANDLE" -internal-api "%s" "${push_options[@]}" post-recieve
-	`, executablePath, config.internalApi)
+%s hook -git-dir "$GIT_DIR" -user-did "$GIT_USER_DID" -user-handle "$GIT_USER_HANDLE" -internal-api "%s" "${push_options[@]}" %s
+	`, executablePath, config.internalApi, hookName)
 
 	return os.WriteFile(hookPath, []byte(hookContent), 0755)
 }
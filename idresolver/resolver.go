@@ -49,6 +49,14 @@ func RedisDirectory(url string) (identity.Directory, error) {
 	return redisdir.NewRedisDirectory(BaseDirectory(), url, hitTTL, errTTL, invalidHandleTTL, 10000)
 }
 
+// New resolves identities with directory, for example a mock directory
+// standing in for the network
+func New(directory identity.Directory) *Resolver {
+	return &Resolver{
+		directory: directory,
+	}
+}
+
 func DefaultResolver() *Resolver {
 	return &Resolver{
 		directory: identity.DefaultDirectory(),
@@ -8,8 +8,10 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/urfave/cli/v3"
+	"tangled.sh/tangled.sh/core/aclcache"
 	"tangled.sh/tangled.sh/core/log"
 )
 
@@ -40,6 +42,16 @@ func Command() *cli.Command {
 				Usage: "path to log file",
 				Value: "/home/git/log",
 			},
+			&cli.StringFlag{
+				Name:  "acl-snapshot",
+				Usage: "signed acl snapshot to fall back on if the internal API is unreachable",
+				Value: "/home/git/acl-snapshot.json",
+			},
+			&cli.DurationFlag{
+				Name:  "acl-max-age",
+				Usage: "oldest acl snapshot that will be trusted",
+				Value: 24 * time.Hour,
+			},
 		},
 	}
 }
@@ -58,24 +70,25 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	resp, err := http.Get(internalApi + "/keys")
+	data, err := fetchKeys(internalApi)
 	if err != nil {
 		l.Error("error reaching internal API endpoint; is the knot server running?", "error", err)
-		return err
-	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		l.Error("error reading response body", "error", err)
-		return err
+		data, err = snapshotKeys(cmd.String("acl-snapshot"), cmd.Duration("acl-max-age"))
+		if err != nil {
+			l.Error("error reading acl snapshot", "error", err)
+			return err
+		}
+		l.Warn("using keys from acl snapshot")
 	}
 
-	var data []map[string]any
-	err = json.Unmarshal(body, &data)
-	if err != nil {
-		l.Error("error unmarshalling response body", "error", err)
-		return err
+	// guard needs to know where to look if the api goes away mid-session
+	var snapshotArgs string
+	if cmd.IsSet("acl-snapshot") {
+		snapshotArgs += " -acl-snapshot " + cmd.String("acl-snapshot")
+	}
+	if cmd.IsSet("acl-max-age") {
+		snapshotArgs += " -acl-max-age " + cmd.Duration("acl-max-age").String()
 	}
 
 	switch output {
@@ -91,7 +104,7 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 			return err
 		}
 	case "authorized-keys":
-		formatted := formatKeyData(executablePath, gitDir, logPath, internalApi, data)
+		formatted := formatKeyData(executablePath, gitDir, logPath, internalApi, snapshotArgs, data)
 		_, err := os.Stdout.Write([]byte(formatted))
 		if err != nil {
 			l.Error("error writing to stdout", "error", err)
@@ -110,12 +123,84 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
-func formatKeyData(executablePath, gitDir, logPath, endpoint string, data []map[string]any) string {
+func fetchKeys(internalApi string) ([]map[string]any, error) {
+	resp, err := http.Get(internalApi + "/keys")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	var data []map[string]any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("unmarshalling response body: %w", err)
+	}
+
+	return data, nil
+}
+
+// snapshotKeys reads keys from the acl snapshot in the same shape as the
+// internal API returns them.
+func snapshotKeys(path string, maxAge time.Duration) ([]map[string]any, error) {
+	snapshot, err := aclcache.Read(path, maxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []map[string]any
+	for _, k := range snapshot.Keys {
+		repos := make([]any, len(k.Repos))
+		for i, r := range k.Repos {
+			repos[i] = r
+		}
+
+		data = append(data, map[string]any{
+			"did":   k.Did,
+			"key":   k.Key,
+			"repos": repos,
+		})
+	}
+
+	return data, nil
+}
+
+func formatKeyData(executablePath, gitDir, logPath, endpoint, snapshotArgs string, data []map[string]any) string {
 	var result string
 	for _, entry := range data {
+		guardArgs := fmt.Sprintf("-git-dir %s -user %s -log-path %s -internal-api %s%s", gitDir, entry["did"], logPath, endpoint, snapshotArgs)
+		if repos := pushRepos(entry); len(repos) > 0 {
+			guardArgs += " -push-repos " + strings.Join(repos, ",")
+		}
+
 		result += fmt.Sprintf(
-			`command="%s guard -git-dir %s -user %s -log-path %s -internal-api %s",no-port-forwarding,no-X11-forwarding,no-agent-forwarding,no-pty %s`+"\n",
-			executablePath, gitDir, entry["did"], logPath, endpoint, entry["key"])
+			`command="%s guard %s",no-port-forwarding,no-X11-forwarding,no-agent-forwarding,no-pty %s`+"\n",
+			executablePath, guardArgs, entry["key"])
 	}
 	return result
 }
+
+// pushRepos reads the repos a key may push to, as reported by the internal
+// api. guard falls back to this list if it cannot reach the api later on.
+func pushRepos(entry map[string]any) []string {
+	raw, _ := entry["repos"].([]any)
+
+	var repos []string
+	for _, r := range raw {
+		repo, ok := r.(string)
+		// these end up inside a forced command, so skip anything that could
+		// break out of the argument
+		if !ok || repo == "" || strings.ContainsAny(repo, ", \t\"\\") {
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	return repos
+}
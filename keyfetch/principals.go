@@ -0,0 +1,70 @@
+package keyfetch
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/urfave/cli/v3"
+	"tangled.sh/tangled.sh/core/log"
+)
+
+func PrincipalsCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "principals",
+		Usage:  "authorize ssh certificates issued by a trusted appview",
+		Action: RunPrincipals,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "key-id",
+				Usage:    "key id of the certificate, as passed by sshd with %i",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "internal-api",
+				Usage: "internal API endpoint",
+				Value: "http://localhost:5444",
+			},
+			&cli.StringFlag{
+				Name:  "git-dir",
+				Usage: "base directory for git repos",
+				Value: "/home/git",
+			},
+			&cli.StringFlag{
+				Name:  "log-path",
+				Usage: "path to log file",
+				Value: "/home/git/log",
+			},
+		},
+	}
+}
+
+// RunPrincipals prints the principal a certificate may log in as, along with
+// the forced command that hands it over to the guard. Certificates are
+// issued with the user's did as both their key id and their only principal,
+// and sshd has checked the signature before this runs, so the key id can be
+// trusted.
+func RunPrincipals(ctx context.Context, cmd *cli.Command) error {
+	l := log.FromContext(ctx)
+
+	keyId := cmd.String("key-id")
+	did, err := syntax.ParseDID(keyId)
+	if err != nil {
+		l.Error("certificate key id is not a did", "key-id", keyId)
+		return err
+	}
+
+	executablePath, err := os.Executable()
+	if err != nil {
+		l.Error("error getting path of executable", "error", err)
+		return err
+	}
+
+	_, err = fmt.Fprintf(
+		os.Stdout,
+		`command="%s guard -git-dir %s -user %s -log-path %s -internal-api %s",no-port-forwarding,no-X11-forwarding,no-agent-forwarding,no-pty %s`+"\n",
+		executablePath, cmd.String("git-dir"), did, cmd.String("log-path"), cmd.String("internal-api"), did,
+	)
+	return err
+}
@@ -2,6 +2,7 @@ package knotclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,15 +12,20 @@ import (
 	"strconv"
 	"time"
 
+	tlog "tangled.sh/tangled.sh/core/log"
 	"tangled.sh/tangled.sh/core/types"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 )
 
 type UnsignedClient struct {
-	Url    *url.URL
-	client *http.Client
+	Url       *url.URL
+	client    *http.Client
+	requestId string
 }
 
-func NewUnsignedClient(domain string, dev bool) (*UnsignedClient, error) {
+// NewUnsignedClient creates a client for the knot at domain, requests made
+// with it carry the request id in ctx, if any
+func NewUnsignedClient(ctx context.Context, domain string, dev bool) (*UnsignedClient, error) {
 	client := &http.Client{
 		Timeout: 5 * time.Second,
 	}
@@ -34,8 +40,9 @@ func NewUnsignedClient(domain string, dev bool) (*UnsignedClient, error) {
 	}
 
 	unsignedClient := &UnsignedClient{
-		client: client,
-		Url:    url,
+		client:    client,
+		Url:       url,
+		requestId: tlog.RequestId(ctx),
 	}
 
 	return unsignedClient, nil
@@ -49,7 +56,16 @@ func (us *UnsignedClient) newRequest(method, endpoint string, query url.Values,
 		reqUrl.RawQuery = query.Encode()
 	}
 
-	return http.NewRequest(method, reqUrl.String(), bytes.NewReader(body))
+	req, err := http.NewRequest(method, reqUrl.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if us.requestId != "" {
+		req.Header.Set(tlog.RequestIdHeader, us.requestId)
+	}
+
+	return req, nil
 }
 
 func do[T any](us *UnsignedClient, req *http.Request) (*T, error) {
@@ -65,6 +81,10 @@ func do[T any](us *UnsignedClient, req *http.Request) (*T, error) {
 		return nil, err
 	}
 
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, decodeError(resp.StatusCode, body)
+	}
+
 	var result T
 	err = json.Unmarshal(body, &result)
 	if err != nil {
@@ -75,6 +95,19 @@ func do[T any](us *UnsignedClient, req *http.Request) (*T, error) {
 	return &result, nil
 }
 
+// decodeError recovers the error a knot responded with, older knots that
+// did not tag their errors are reported as generic ones
+func decodeError(status int, body []byte) error {
+	var xerr xrpcerr.XrpcError
+	if err := json.Unmarshal(body, &xerr); err != nil || xerr.Tag == "" || xerr.Message == "" {
+		if status == http.StatusNotFound {
+			return xrpcerr.NotFoundError
+		}
+		return xrpcerr.GenericError(fmt.Errorf("knot responded with status %d", status))
+	}
+	return xerr
+}
+
 func (us *UnsignedClient) Index(ownerDid, repoName, ref string) (*types.RepoIndexResponse, error) {
 	const (
 		Method = "GET"
@@ -93,12 +126,16 @@ func (us *UnsignedClient) Index(ownerDid, repoName, ref string) (*types.RepoInde
 	return do[types.RepoIndexResponse](us, req)
 }
 
-func (us *UnsignedClient) Log(ownerDid, repoName, ref string, page int) (*types.RepoLogResponse, error) {
+// Log lists the commits of ref, only those touching path unless it is empty
+func (us *UnsignedClient) Log(ownerDid, repoName, ref, path string, page int) (*types.RepoLogResponse, error) {
 	const (
 		Method = "GET"
 	)
 
 	endpoint := fmt.Sprintf("/%s/%s/log/%s", ownerDid, repoName, url.PathEscape(ref))
+	if path != "" {
+		endpoint += "/" + path
+	}
 
 	query := url.Values{}
 	query.Add("page", strconv.Itoa(page))
@@ -112,6 +149,23 @@ func (us *UnsignedClient) Log(ownerDid, repoName, ref string, page int) (*types.
 	return do[types.RepoLogResponse](us, req)
 }
 
+// Blame attributes every line of the file at path and ref to the commit that
+// last changed it
+func (us *UnsignedClient) Blame(ownerDid, repoName, ref, path string) (*types.RepoBlameResponse, error) {
+	const (
+		Method = "GET"
+	)
+
+	endpoint := fmt.Sprintf("/%s/%s/blame/%s/%s", ownerDid, repoName, url.PathEscape(ref), path)
+
+	req, err := us.newRequest(Method, endpoint, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return do[types.RepoBlameResponse](us, req)
+}
+
 func (us *UnsignedClient) Branches(ownerDid, repoName string) (*types.RepoBranchesResponse, error) {
 	const (
 		Method = "GET"
@@ -139,7 +193,12 @@ func (us *UnsignedClient) Tags(ownerDid, repoName string) (*types.RepoTagsRespon
 		return nil, err
 	}
 
-	return do[types.RepoTagsResponse](us, req)
+	// older knots cannot list the tags of empty repos
+	resp, err := do[types.RepoTagsResponse](us, req)
+	if xrpcerr.Is(err, xrpcerr.TagNotFound) {
+		return &types.RepoTagsResponse{}, nil
+	}
+	return resp, err
 }
 
 func (us *UnsignedClient) Branch(ownerDid, repoName, branch string) (*types.RepoBranchResponse, error) {
@@ -283,3 +342,94 @@ func (s *UnsignedClient) RepoLanguages(ownerDid, repoName, ref string) (*types.R
 
 	return &result, nil
 }
+
+func (us *UnsignedClient) RepoDependencies(ownerDid, repoName, ref string) (*types.RepoDependenciesResponse, error) {
+	const (
+		Method = "GET"
+	)
+
+	endpoint := fmt.Sprintf("/%s/%s/dependencies/%s", ownerDid, repoName, url.PathEscape(ref))
+	if ref == "" {
+		endpoint = fmt.Sprintf("/%s/%s/dependencies", ownerDid, repoName)
+	}
+
+	req, err := us.newRequest(Method, endpoint, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return do[types.RepoDependenciesResponse](us, req)
+}
+
+// Mailmap returns the contents of the .mailmap file at ref, or an empty
+// string if the repo does not have one.
+func (us *UnsignedClient) Mailmap(ownerDid, repoName, ref string) (string, error) {
+	return us.RawFile(ownerDid, repoName, ref, ".mailmap")
+}
+
+// RawFile returns the contents of the file at path and ref, or an empty
+// string if there is no such file. Files are cut off after a megabyte.
+func (us *UnsignedClient) RawFile(ownerDid, repoName, ref, path string) (string, error) {
+	const (
+		Method = "GET"
+	)
+
+	endpoint := fmt.Sprintf("/%s/%s/raw/%s/%s", ownerDid, repoName, url.PathEscape(ref), path)
+
+	req, err := us.newRequest(Method, endpoint, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := us.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// Bundle fetches a git bundle of the branches and tags of a repo, the caller
+// closes the returned body. Repos without any refs have no bundle, nil is
+// returned for them.
+func (us *UnsignedClient) Bundle(ownerDid, repoName string) (io.ReadCloser, error) {
+	const (
+		Method = "GET"
+	)
+
+	endpoint := fmt.Sprintf("/%s/%s/bundle", ownerDid, repoName)
+
+	req, err := us.newRequest(Method, endpoint, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// bundles of large repos take a while to create and download
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return nil, decodeError(resp.StatusCode, body)
+	}
+
+	return resp.Body, nil
+}
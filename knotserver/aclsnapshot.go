@@ -0,0 +1,84 @@
+package knotserver
+
+import (
+	"context"
+	"crypto/ed25519"
+	"log/slog"
+	"slices"
+	"time"
+
+	"tangled.sh/tangled.sh/core/aclcache"
+	"tangled.sh/tangled.sh/core/knotserver/db"
+	"tangled.sh/tangled.sh/core/rbac"
+)
+
+const aclSnapshotInterval = time.Minute
+
+// refreshAclSnapshot keeps a signed copy of every key and the repos it may
+// push to on disk, so that git over ssh keeps working if this server's
+// internal api goes down.
+func refreshAclSnapshot(ctx context.Context, path string, d *db.DB, e *rbac.Enforcer, l *slog.Logger) {
+	l = l.With("component", "aclSnapshot", "path", path)
+
+	key, err := aclcache.LoadOrCreateKey(path)
+	if err != nil {
+		l.Error("failed to load signing key, not writing acl snapshots", "error", err)
+		return
+	}
+
+	ticker := time.NewTicker(aclSnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := writeAclSnapshot(path, key, d, e); err != nil {
+			l.Error("failed to write acl snapshot", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeAclSnapshot(path string, key ed25519.PrivateKey, d *db.DB, e *rbac.Enforcer) error {
+	keys, err := d.GetAllPublicKeys()
+	if err != nil {
+		return err
+	}
+
+	archived, err := d.GetArchived()
+	if err != nil {
+		return err
+	}
+
+	snapshot := aclcache.Snapshot{
+		Generated: time.Now(),
+	}
+
+	pushable := make(map[string][]string)
+	for _, k := range keys {
+		repos, ok := pushable[k.Did]
+		if !ok {
+			repos = pushableRepos(e, archived, k.Did)
+			pushable[k.Did] = repos
+		}
+
+		snapshot.Keys = append(snapshot.Keys, aclcache.Key{
+			Did:   k.Did,
+			Key:   k.Key,
+			Repos: repos,
+		})
+	}
+
+	return aclcache.Write(path, key, snapshot)
+}
+
+// pushableRepos lists the repos did may push to, leaving out archived repos
+// since those are read-only for everyone
+func pushableRepos(e *rbac.Enforcer, archived map[string]bool, did string) []string {
+	return slices.DeleteFunc(e.GetPushableRepos(did, rbac.ThisServer), func(repo string) bool {
+		return archived[repo]
+	})
+}
@@ -0,0 +1,235 @@
+package knotserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+)
+
+// archiveFormat is a kind of archive a snapshot of a ref can be downloaded as,
+// picked by the extension of the requested file
+type archiveFormat struct {
+	ext   string
+	mime  string
+	write func(gr *git.GitRepo, w io.Writer, prefix string, maxSize int64) error
+}
+
+var archiveFormats = []archiveFormat{
+	{
+		ext:   ".tar.gz",
+		mime:  "application/gzip",
+		write: compressedTar(git.Gzip),
+	},
+	{
+		ext:   ".tar.zst",
+		mime:  "application/zstd",
+		write: compressedTar(git.Zstd),
+	},
+	{
+		ext:   ".tar.xz",
+		mime:  "application/x-xz",
+		write: compressedTar(git.Xz),
+	},
+	{
+		ext:   ".zip",
+		mime:  "application/zip",
+		write: (*git.GitRepo).WriteZip,
+	},
+}
+
+func compressedTar(compress git.Compressor) func(gr *git.GitRepo, w io.Writer, prefix string, maxSize int64) error {
+	return func(gr *git.GitRepo, w io.Writer, prefix string, maxSize int64) error {
+		return gr.WriteCompressedTar(w, compress, prefix, maxSize)
+	}
+}
+
+// archiveCache keeps generated archives on disk and removes the least
+// recently downloaded ones once they take up more than maxSize bytes.
+// Concurrent requests for the same archive share a single generation.
+type archiveCache struct {
+	dir     string
+	maxSize int64
+
+	// held while files are opened or removed, so that an archive is never
+	// evicted between being looked up and opened
+	mu    sync.Mutex
+	group singleflight.Group
+}
+
+func newArchiveCache(dir string, maxSize int64) (*archiveCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// left behind by generations that were cut short
+	tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	for _, tmp := range tmps {
+		os.Remove(tmp)
+	}
+
+	return &archiveCache{dir: dir, maxSize: maxSize}, nil
+}
+
+// archiveKey identifies an archive by everything that goes into it
+func archiveKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Open returns the archive stored under key with the extension ext,
+// generating it with write first when it is not cached yet. The caller closes
+// the file.
+func (c *archiveCache) Open(key, ext string, write func(io.Writer) error) (*os.File, error) {
+	key += ext
+	path := filepath.Join(c.dir, key)
+
+	// a fresh archive can still be evicted by a concurrent generation before
+	// it is opened, in which case it is generated once more
+	for range 2 {
+		f, err := c.open(path)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		_, err, _ = c.group.Do(key, func() (any, error) {
+			return nil, c.generate(path, write)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.open(path)
+}
+
+func (c *archiveCache) open(path string) (*os.File, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// the modification time doubles as the last time it was downloaded
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return f, nil
+}
+
+func (c *archiveCache) generate(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(c.dir, filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	return c.evict(path)
+}
+
+// evict removes the least recently used archives other than keep until the
+// cache fits in maxSize again
+func (c *archiveCache) evict(keep string) error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	type archive struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var archives []archive
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || strings.HasSuffix(e.Name(), ".tmp") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		archives = append(archives, archive{filepath.Join(c.dir, e.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	slices.SortFunc(archives, func(a, b archive) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for _, a := range archives {
+		if total <= c.maxSize {
+			break
+		}
+		if a.path == keep {
+			continue
+		}
+		if err := os.Remove(a.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= a.size
+	}
+
+	return nil
+}
+
+// storeArchive copies an archive from the cache to the object store, for it
+// to be downloaded from there from then on
+func (h *Handle) storeArchive(key, path, mime string) {
+	l := h.l.With("handler", "storeArchive", "key", key)
+
+	// the archive may be evicted from the cache in the meantime
+	f, err := os.Open(path)
+	if err != nil {
+		l.Error("opening archive", "error", err.Error())
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		l.Error("opening archive", "error", err.Error())
+		return
+	}
+
+	if err := h.storage.Put(context.Background(), key, f, info.Size(), mime); err != nil {
+		l.Error("storing archive", "error", err.Error())
+	}
+}
@@ -3,15 +3,22 @@ package config
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/sethvargo/go-envconfig"
+	"tangled.sh/tangled.sh/core/objectstore"
 )
 
 type Repo struct {
 	ScanPath   string   `env:"SCAN_PATH, default=/home/git"`
 	Readme     []string `env:"README"`
 	MainBranch string   `env:"MAIN_BRANCH, default=main"`
+
+	// deleted repos are moved to the trash and kept for the retention window
+	// so that the knot owner can restore them; 0 removes them straight away
+	TrashPath      string        `env:"TRASH_PATH, default=/home/git/.trash"`
+	TrashRetention time.Duration `env:"TRASH_RETENTION, default=720h"`
 }
 
 type Server struct {
@@ -23,10 +30,29 @@ type Server struct {
 	Owner              string `env:"OWNER, required"`
 	LogDids            bool   `env:"LOG_DIDS, default=true"`
 
+	// signed copy of keys and push permissions for guard to fall back on;
+	// set to an empty string to disable
+	AclSnapshotPath string `env:"ACL_SNAPSHOT_PATH, default=/home/git/acl-snapshot.json"`
+
+	// how often to run git gc --auto on every repo; 0 disables maintenance
+	MaintenanceInterval time.Duration `env:"MAINTENANCE_INTERVAL, default=24h"`
+
 	// This disables signature verification so use with caution.
 	Dev bool `env:"DEV, default=false"`
 }
 
+type Archive struct {
+	// generated archives are kept here, keyed by commit, and the least
+	// recently downloaded ones removed once they take up more than CacheSize
+	// bytes
+	CachePath string `env:"CACHE_PATH, default=/home/git/.archives"`
+	CacheSize int64  `env:"CACHE_SIZE, default=1073741824"`
+
+	// archives of trees larger than this many bytes, before compression, are
+	// refused; 0 allows any size
+	MaxSize int64 `env:"MAX_SIZE, default=536870912"`
+}
+
 type Git struct {
 	// user name & email used as committer
 	UserName  string `env:"USER_NAME, default=Tangled"`
@@ -37,11 +63,24 @@ func (s Server) Did() syntax.DID {
 	return syntax.DID(fmt.Sprintf("did:web:%s", s.Hostname))
 }
 
+// Url is where the knot is reached, over plain http in dev mode
+func (s Server) Url() string {
+	if s.Dev {
+		return "http://" + s.Hostname
+	}
+	return "https://" + s.Hostname
+}
+
 type Config struct {
-	Repo            Repo   `env:",prefix=KNOT_REPO_"`
-	Server          Server `env:",prefix=KNOT_SERVER_"`
-	Git             Git    `env:",prefix=KNOT_GIT_"`
-	AppViewEndpoint string `env:"APPVIEW_ENDPOINT, default=https://tangled.sh"`
+	Repo            Repo    `env:",prefix=KNOT_REPO_"`
+	Server          Server  `env:",prefix=KNOT_SERVER_"`
+	Git             Git     `env:",prefix=KNOT_GIT_"`
+	Archive         Archive `env:",prefix=KNOT_ARCHIVE_"`
+	AppViewEndpoint string  `env:"APPVIEW_ENDPOINT, default=https://tangled.sh"`
+
+	// generated archives are copied here and downloaded from it, to take
+	// the traffic off the knot
+	Storage objectstore.Config `env:",prefix=KNOT_STORAGE_"`
 }
 
 func Load(ctx context.Context) (*Config, error) {
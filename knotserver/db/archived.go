@@ -0,0 +1,47 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// SetArchived archives repo, after which pushes to it are refused, or
+// unarchives it again.
+func (d *DB) SetArchived(repo string, archived bool) error {
+	var err error
+	if archived {
+		_, err = d.db.Exec(`insert or ignore into archived (repo) values (?)`, repo)
+	} else {
+		_, err = d.db.Exec(`delete from archived where repo = ?`, repo)
+	}
+	return err
+}
+
+func (d *DB) IsArchived(repo string) (bool, error) {
+	var r string
+	err := d.db.QueryRow(`select repo from archived where repo = ?`, repo).Scan(&r)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetArchived returns every archived repository, as did/name.
+func (d *DB) GetArchived() (map[string]bool, error) {
+	rows, err := d.db.Query(`select repo from archived`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	archived := make(map[string]bool)
+	for rows.Next() {
+		var repo string
+		if err := rows.Scan(&repo); err != nil {
+			return nil, err
+		}
+		archived[repo] = true
+	}
+
+	return archived, rows.Err()
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"tangled.sh/tangled.sh/core/knotserver/git"
+)
+
+// SetHistoryPolicy sets what pushes to the default branch of repo have to
+// look like, the receive hook refuses the ones that don't.
+func (d *DB) SetHistoryPolicy(repo string, p git.HistoryPolicy) error {
+	if p == (git.HistoryPolicy{}) {
+		_, err := d.db.Exec(`delete from history_policies where repo = ?`, repo)
+		return err
+	}
+
+	_, err := d.db.Exec(
+		`insert into history_policies (repo, no_merge_commits, require_pull_ref)
+		values (?, ?, ?)
+		on conflict(repo) do update set
+			no_merge_commits = excluded.no_merge_commits,
+			require_pull_ref = excluded.require_pull_ref`,
+		repo,
+		p.NoMergeCommits,
+		p.RequirePullRef,
+	)
+	return err
+}
+
+func (d *DB) GetHistoryPolicy(repo string) (git.HistoryPolicy, error) {
+	var p git.HistoryPolicy
+	err := d.db.QueryRow(
+		`select no_merge_commits, require_pull_ref from history_policies where repo = ?`,
+		repo,
+	).Scan(&p.NoMergeCommits, &p.RequirePullRef)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, nil
+	}
+	return p, err
+}
@@ -55,6 +55,49 @@ func Setup(dbPath string) (*DB, error) {
 			created integer not null default (strftime('%s', 'now')),
 			primary key (rkey, nsid)
 		);
+
+		create table if not exists pull_refs (
+			repo text not null, -- did/name
+			pull_id integer not null,
+			pull_at text not null,
+			target_branch text not null,
+			source_sha text not null default '',
+			patch text not null,
+			updated text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+			primary key (repo, pull_id)
+		);
+
+		create table if not exists repo_stats (
+			repo text primary key, -- did/name
+			clones integer not null default 0,
+			pushes integer not null default 0,
+			last_maintenance text,
+			maintenance_error text not null default ''
+		);
+
+		create table if not exists trash (
+			id integer primary key autoincrement,
+			repo text not null, -- did/name
+			path text not null unique, -- where it sits in the trash
+			deleted text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+
+		create table if not exists transfers (
+			repo text primary key, -- did/name
+			to_did text not null,
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+
+		create table if not exists archived (
+			repo text primary key, -- did/name
+			created text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		);
+
+		create table if not exists history_policies (
+			repo text primary key, -- did/name
+			no_merge_commits integer not null default 0,
+			require_pull_ref integer not null default 0
+		);
 	`)
 	if err != nil {
 		return nil, err
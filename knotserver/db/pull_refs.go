@@ -0,0 +1,70 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// PullRef is the last known state of a pull request whose refs are
+// advertised under refs/pulls/{PullId} in the target repository.
+type PullRef struct {
+	Repo         string // did/name
+	PullId       int64
+	PullAt       string
+	TargetBranch string
+	SourceSha    string
+	Patch        string
+}
+
+func (d *DB) GetPullRef(repo string, pullId int64) (*PullRef, error) {
+	p := PullRef{Repo: repo, PullId: pullId}
+	err := d.db.QueryRow(
+		`select pull_at, target_branch, source_sha, patch from pull_refs where repo = ? and pull_id = ?`,
+		repo, pullId,
+	).Scan(&p.PullAt, &p.TargetBranch, &p.SourceSha, &p.Patch)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (d *DB) UpsertPullRef(p PullRef) error {
+	_, err := d.db.Exec(
+		`insert into pull_refs (repo, pull_id, pull_at, target_branch, source_sha, patch)
+		values (?, ?, ?, ?, ?, ?)
+		on conflict(repo, pull_id) do update set
+			pull_at = excluded.pull_at,
+			target_branch = excluded.target_branch,
+			source_sha = excluded.source_sha,
+			patch = excluded.patch,
+			updated = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')`,
+		p.Repo, p.PullId, p.PullAt, p.TargetBranch, p.SourceSha, p.Patch,
+	)
+	return err
+}
+
+// GetPullRefsByTarget returns every pull in repo that targets branch.
+func (d *DB) GetPullRefsByTarget(repo, branch string) ([]PullRef, error) {
+	rows, err := d.db.Query(
+		`select pull_id, pull_at, source_sha, patch from pull_refs where repo = ? and target_branch = ?`,
+		repo, branch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []PullRef
+	for rows.Next() {
+		p := PullRef{Repo: repo, TargetBranch: branch}
+		if err := rows.Scan(&p.PullId, &p.PullAt, &p.SourceSha, &p.Patch); err != nil {
+			return nil, err
+		}
+		refs = append(refs, p)
+	}
+
+	return refs, rows.Err()
+}
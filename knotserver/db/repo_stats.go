@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// RepoStats counts what happened to a repository since the knot started
+// keeping track.
+type RepoStats struct {
+	Repo             string // did/name
+	Clones           int64
+	Pushes           int64
+	LastMaintenance  string
+	MaintenanceError string
+}
+
+func (d *DB) AddClone(repo string) error {
+	_, err := d.db.Exec(
+		`insert into repo_stats (repo, clones) values (?, 1)
+		on conflict(repo) do update set clones = clones + 1`,
+		repo,
+	)
+	return err
+}
+
+func (d *DB) AddPush(repo string) error {
+	_, err := d.db.Exec(
+		`insert into repo_stats (repo, pushes) values (?, 1)
+		on conflict(repo) do update set pushes = pushes + 1`,
+		repo,
+	)
+	return err
+}
+
+// SetMaintained records a maintenance run of repo, maintenanceErr is empty
+// if it succeeded.
+func (d *DB) SetMaintained(repo, maintenanceErr string) error {
+	_, err := d.db.Exec(
+		`insert into repo_stats (repo, last_maintenance, maintenance_error)
+		values (?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?)
+		on conflict(repo) do update set
+			last_maintenance = excluded.last_maintenance,
+			maintenance_error = excluded.maintenance_error`,
+		repo, maintenanceErr,
+	)
+	return err
+}
+
+// GetRepoStats returns the stats of every repository, by did/name.
+func (d *DB) GetRepoStats() (map[string]RepoStats, error) {
+	rows, err := d.db.Query(
+		`select repo, clones, pushes, last_maintenance, maintenance_error from repo_stats`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]RepoStats)
+	for rows.Next() {
+		var s RepoStats
+		var lastMaintenance sql.NullString
+		if err := rows.Scan(&s.Repo, &s.Clones, &s.Pushes, &lastMaintenance, &s.MaintenanceError); err != nil {
+			return nil, err
+		}
+		s.LastMaintenance = lastMaintenance.String
+		stats[s.Repo] = s
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// SetTransfer offers repo to toDid, replacing any earlier offer.
+func (d *DB) SetTransfer(repo, toDid string) error {
+	_, err := d.db.Exec(
+		`insert into transfers (repo, to_did) values (?, ?)
+		on conflict(repo) do update set to_did = excluded.to_did, created = excluded.created`,
+		repo, toDid,
+	)
+	return err
+}
+
+func (d *DB) RemoveTransfer(repo string) error {
+	_, err := d.db.Exec(`delete from transfers where repo = ?`, repo)
+	return err
+}
+
+// GetTransfer returns who repo is offered to, or an empty string if it is
+// not offered to anyone.
+func (d *DB) GetTransfer(repo string) (string, error) {
+	var toDid string
+	err := d.db.QueryRow(`select to_did from transfers where repo = ?`, repo).Scan(&toDid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return toDid, err
+}
+
+// GetTransfers returns who every offered repo is offered to, by did/name.
+func (d *DB) GetTransfers() (map[string]string, error) {
+	rows, err := d.db.Query(`select repo, to_did from transfers`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := make(map[string]string)
+	for rows.Next() {
+		var repo, toDid string
+		if err := rows.Scan(&repo, &toDid); err != nil {
+			return nil, err
+		}
+		transfers[repo] = toDid
+	}
+
+	return transfers, rows.Err()
+}
+
+// RenameRepo moves everything the knot keeps about a repo over to its new
+// did/name.
+func (d *DB) RenameRepo(oldRepo, newRepo string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`update repo_stats set repo = ? where repo = ?`,
+		`update pull_refs set repo = ? where repo = ?`,
+		`update transfers set repo = ? where repo = ?`,
+		`update archived set repo = ? where repo = ?`,
+		`update history_policies set repo = ? where repo = ?`,
+	} {
+		if _, err := tx.Exec(query, newRepo, oldRepo); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
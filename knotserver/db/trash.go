@@ -0,0 +1,49 @@
+package db
+
+import (
+	"time"
+)
+
+// TrashedRepo is a deleted repository kept around until the retention window
+// of the knot passes.
+type TrashedRepo struct {
+	Id      int64
+	Repo    string // did/name
+	Path    string
+	Deleted time.Time
+}
+
+func (d *DB) AddTrash(repo, path string) error {
+	_, err := d.db.Exec(`insert into trash (repo, path) values (?, ?)`, repo, path)
+	return err
+}
+
+func (d *DB) RemoveTrash(id int64) error {
+	_, err := d.db.Exec(`delete from trash where id = ?`, id)
+	return err
+}
+
+// GetTrash returns everything in the trash, most recently deleted first.
+func (d *DB) GetTrash() ([]TrashedRepo, error) {
+	rows, err := d.db.Query(`select id, repo, path, deleted from trash order by deleted desc, id desc`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trash []TrashedRepo
+	for rows.Next() {
+		var t TrashedRepo
+		var deleted string
+		if err := rows.Scan(&t.Id, &t.Repo, &t.Path, &deleted); err != nil {
+			return nil, err
+		}
+		t.Deleted, err = time.Parse(time.RFC3339, deleted)
+		if err != nil {
+			return nil, err
+		}
+		trash = append(trash, t)
+	}
+
+	return trash, rows.Err()
+}
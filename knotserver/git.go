@@ -1,6 +1,8 @@
 package knotserver
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -84,6 +86,12 @@ func (d *Handle) UploadPack(w http.ResponseWriter, r *http.Request) {
 		bodyReader = gzipReader
 	}
 
+	// protocol v2 lists refs in a request of its own, only count the fetch
+	// that follows
+	br := bufio.NewReader(bodyReader)
+	head, _ := br.Peek(32)
+	isFetch := !bytes.Contains(head, []byte("command=ls-refs"))
+
 	w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
 	w.Header().Set("Connection", "Keep-Alive")
 	w.Header().Set("Cache-Control", "no-cache, max-age=0, must-revalidate")
@@ -94,7 +102,7 @@ func (d *Handle) UploadPack(w http.ResponseWriter, r *http.Request) {
 		GitProtocol: r.Header.Get("Git-Protocol"),
 		Dir:         repo,
 		Stdout:      w,
-		Stdin:       bodyReader,
+		Stdin:       br,
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -103,6 +111,12 @@ func (d *Handle) UploadPack(w http.ResponseWriter, r *http.Request) {
 		d.l.Error("git: failed to execute git-upload-pack", "handler", "UploadPack", "error", err)
 		return
 	}
+
+	if isFetch {
+		if err := d.db.AddClone(filepath.Join(did, name)); err != nil {
+			d.l.Error("git: failed to count clone", "handler", "UploadPack", "error", err)
+		}
+	}
 }
 
 func (d *Handle) ReceivePack(w http.ResponseWriter, r *http.Request) {
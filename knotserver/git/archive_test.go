@@ -0,0 +1,148 @@
+package git
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestArchives(t *testing.T) {
+	src := t.TempDir()
+	gitCmd(t, src, "init", "-q", "-b", "main")
+	if err := os.MkdirAll(filepath.Join(src, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"README.md":  "# repo\n",
+		"src/main.c": "int main() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitCmd(t, src, "add", ".")
+	gitCmd(t, src, "commit", "-q", "-m", "initial")
+
+	gr, err := Open(src, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"repo-main/README.md":  "# repo\n",
+		"repo-main/src/":       "",
+		"repo-main/src/main.c": "int main() {}\n",
+	}
+
+	t.Run("tar", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gr.WriteTar(&buf, "repo-main", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		assertEntries(t, readTar(t, &buf), want)
+	})
+
+	t.Run("compressed tar", func(t *testing.T) {
+		formats := map[string]struct {
+			compress   Compressor
+			decompress func(io.Reader) (io.Reader, error)
+		}{
+			"gzip": {Gzip, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+			"zstd": {Zstd, func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+			"xz": {Xz, func(r io.Reader) (io.Reader, error) {
+				cmd := exec.Command("xz", "--decompress", "--stdout")
+				cmd.Stdin = r
+				out, err := cmd.Output()
+				return bytes.NewReader(out), err
+			}},
+		}
+		for name, c := range formats {
+			t.Run(name, func(t *testing.T) {
+				if name == "xz" {
+					if _, err := exec.LookPath("xz"); err != nil {
+						t.Skip("xz is not installed")
+					}
+				}
+
+				var buf bytes.Buffer
+				if err := gr.WriteCompressedTar(&buf, c.compress, "repo-main", 0); err != nil {
+					t.Fatal(err)
+				}
+				r, err := c.decompress(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertEntries(t, readTar(t, r), want)
+			})
+		}
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gr.WriteZip(&buf, "repo-main", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]string{}
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			got[f.Name] = string(content)
+		}
+		assertEntries(t, got, want)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		if err := gr.WriteZip(io.Discard, "repo-main", 8); !errors.Is(err, ErrTarTooLarge) {
+			t.Errorf("got %v, want ErrTarTooLarge", err)
+		}
+	})
+}
+
+func readTar(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	got := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(tr)
+		got[header.Name] = string(content)
+	}
+	return got
+}
+
+func assertEntries(t *testing.T, got, want map[string]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("got %d entries, want %d: %v", len(got), len(want), got)
+	}
+	for name, content := range want {
+		if c, ok := got[name]; !ok || c != content {
+			t.Errorf("%s: got %q, want %q", name, c, content)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"tangled.sh/tangled.sh/core/types"
+)
+
+// Blame attributes every line of the file at path to the commit that last
+// changed it, as of the current commit
+func (g *GitRepo) Blame(path string) ([]types.BlameLine, error) {
+	c, err := g.r.CommitObject(g.h)
+	if err != nil {
+		return nil, fmt.Errorf("commit object: %w", err)
+	}
+
+	file, err := c.File(path)
+	if err != nil {
+		return nil, err
+	}
+	if isbin, _ := file.IsBinary(); isbin {
+		return nil, ErrBinaryFile
+	}
+
+	result, err := git.Blame(c, path)
+	if err != nil {
+		return nil, fmt.Errorf("blame: %w", err)
+	}
+
+	lines := make([]types.BlameLine, len(result.Lines))
+	for i, l := range result.Lines {
+		lines[i] = types.BlameLine{
+			Hash:        l.Hash.String(),
+			AuthorName:  l.AuthorName,
+			AuthorEmail: l.Author,
+			When:        l.Date,
+			Text:        l.Text,
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestBlame(t *testing.T) {
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitCmd(t, dir, "add", ".")
+	}
+	write("one\ntwo\n")
+	gitCmd(t, dir, "commit", "-q", "-m", "first")
+	first := gitCmd(t, dir, "rev-parse", "HEAD")
+
+	write("one\ntwo\nthree\n")
+	gitCmd(t, dir, "-c", "user.name=other", "commit", "-q", "-m", "second")
+	second := gitCmd(t, dir, "rev-parse", "HEAD")
+
+	gr, err := Open(dir, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines, err := gr.Blame("notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ hash, author, text string }{
+		{first, "test", "one"},
+		{first, "test", "two"},
+		{second, "other", "three"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, w := range want {
+		l := lines[i]
+		if l.Hash != w.hash || l.AuthorName != w.author || l.Text != w.text {
+			t.Errorf("line %d: got %s %s %q, want %s %s %q", i+1, l.Hash, l.AuthorName, l.Text, w.hash, w.author, w.text)
+		}
+	}
+
+	if _, err := gr.Blame("missing.txt"); !errors.Is(err, object.ErrFileNotFound) {
+		t.Errorf("got %v for a missing file", err)
+	}
+}
@@ -0,0 +1,95 @@
+package git
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// BlobReader reads a file at the commit of a repo without loading all of it,
+// as an io.ReadSeeker for serving ranges of it. Objects are stored
+// compressed, so seeking reopens the blob and skips to the offset, on the
+// next read.
+type BlobReader struct {
+	file   *object.File
+	offset int64
+	r      io.ReadCloser
+}
+
+func (g *GitRepo) OpenBlob(path string) (*BlobReader, error) {
+	c, err := g.r.CommitObject(g.h)
+	if err != nil {
+		return nil, fmt.Errorf("commit object: %w", err)
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("file tree: %w", err)
+	}
+
+	file, err := tree.File(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlobReader{file: file}, nil
+}
+
+func (b *BlobReader) Size() int64 {
+	return b.file.Size
+}
+
+// Hash is the object id of the blob, which changes with its content
+func (b *BlobReader) Hash() string {
+	return b.file.Hash.String()
+}
+
+func (b *BlobReader) Read(p []byte) (int, error) {
+	if b.r == nil {
+		r, err := b.file.Reader()
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.CopyN(io.Discard, r, b.offset); err != nil {
+			r.Close()
+			return 0, err
+		}
+		b.r = r
+	}
+
+	n, err := b.r.Read(p)
+	b.offset += int64(n)
+	return n, err
+}
+
+func (b *BlobReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.offset
+	case io.SeekEnd:
+		offset += b.file.Size
+	default:
+		return 0, fmt.Errorf("seek: invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("seek: negative offset %d", offset)
+	}
+
+	if offset != b.offset && b.r != nil {
+		b.r.Close()
+		b.r = nil
+	}
+	b.offset = offset
+	return offset, nil
+}
+
+func (b *BlobReader) Close() error {
+	if b.r == nil {
+		return nil
+	}
+	err := b.r.Close()
+	b.r = nil
+	return err
+}
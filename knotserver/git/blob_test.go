@@ -0,0 +1,62 @@
+package git
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBlobReader(t *testing.T) {
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dir, "add", ".")
+	gitCmd(t, dir, "commit", "-q", "-m", "data")
+	// packed objects are deltified and compressed, unlike loose ones
+	gitCmd(t, dir, "gc", "-q")
+
+	gr, err := Open(dir, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := gr.OpenBlob("data.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blob.Close()
+
+	if blob.Size() != int64(len(content)) {
+		t.Errorf("size: got %d, want %d", blob.Size(), len(content))
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=99995-99999,12-15")
+	http.ServeContent(rec, req, "", time.Time{}, blob)
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("got %d for a range", rec.Code)
+	}
+	body := rec.Body.Bytes()
+	if !bytes.Contains(body, []byte("56789")) || !bytes.Contains(body, []byte("2345")) {
+		t.Errorf("unexpected ranges: %q", body)
+	}
+
+	blob.Seek(0, io.SeekStart)
+	all, err := io.ReadAll(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(all, content) {
+		t.Errorf("read %d bytes, not the whole file", len(all))
+	}
+}
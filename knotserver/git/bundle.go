@@ -0,0 +1,25 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var ErrEmptyBundle = errors.New("repository has no branches or tags to bundle")
+
+// WriteBundle creates a bundle of every branch and tag of the repository at
+// path in file, hidden refs such as those of pulls are left out
+func WriteBundle(ctx context.Context, path, file string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", path, "bundle", "create", "--quiet", file, "--branches", "--tags")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(out), "empty bundle") {
+			return ErrEmptyBundle
+		}
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
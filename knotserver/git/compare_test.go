@@ -0,0 +1,69 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+
+	commit := func(name, content, msg string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitCmd(t, dir, "add", ".")
+		gitCmd(t, dir, "commit", "-q", "-m", msg)
+	}
+	commit("README.md", "hello\n", "readme")
+	gitCmd(t, dir, "checkout", "-q", "-b", "feature")
+	commit("feature.txt", "one\ntwo\n", "add feature")
+	commit("README.md", "hello\nfeature\n", "mention feature")
+	gitCmd(t, dir, "checkout", "-q", "main")
+	// changes on main since the branch point stay out of the diff
+	commit("main.txt", "main\n", "main moves on")
+
+	gr, err := PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := gr.ResolveRevision("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := gr.ResolveRevision("feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, commits, err := gr.Compare(base, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	for _, d := range diff.Diff {
+		files = append(files, d.Name.New)
+	}
+	if len(files) != 2 || files[0] != "README.md" || files[1] != "feature.txt" {
+		t.Errorf("files: got %q", files)
+	}
+	if diff.Stat.Insertions != 3 || diff.Stat.Deletions != 0 {
+		t.Errorf("stat: got +%d -%d", diff.Stat.Insertions, diff.Stat.Deletions)
+	}
+	if diff.Commit.This != head.Hash.String() {
+		t.Errorf("this: got %s", diff.Commit.This)
+	}
+
+	if len(commits) != 2 || commits[0].Message != "mention feature\n" || commits[1].Message != "add feature\n" {
+		t.Errorf("commits: got %d", len(commits))
+	}
+
+	// nothing is missing the other way round but main's own commit
+	_, commits, err = gr.Compare(head, base)
+	if err != nil || len(commits) != 1 {
+		t.Errorf("reverse: got %d commits, %v", len(commits), err)
+	}
+}
@@ -0,0 +1,64 @@
+package git
+
+import (
+	"compress/gzip"
+	"io"
+	"os/exec"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor wraps w in a compressed stream, which is complete once closed
+type Compressor func(w io.Writer) (io.WriteCloser, error)
+
+var (
+	Gzip Compressor = func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	}
+
+	Zstd Compressor = func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w)
+	}
+
+	// Xz runs the xz binary, there is no xz encoder among our dependencies
+	Xz Compressor = func(w io.Writer) (io.WriteCloser, error) {
+		cmd := exec.Command("xz", "--compress", "--stdout", "--quiet")
+		cmd.Stdout = w
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		return &xzWriter{WriteCloser: stdin, cmd: cmd}, nil
+	}
+)
+
+type xzWriter struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+// Close ends the input of xz and waits for it to write out the rest
+func (x *xzWriter) Close() error {
+	err := x.WriteCloser.Close()
+	if waitErr := x.cmd.Wait(); err == nil {
+		err = waitErr
+	}
+	return err
+}
+
+// WriteCompressedTar is WriteTar through compress
+func (g *GitRepo) WriteCompressedTar(w io.Writer, compress Compressor, prefix string, maxSize int64) error {
+	cw, err := compress(w)
+	if err != nil {
+		return err
+	}
+
+	if err := g.WriteTar(cw, prefix, maxSize); err != nil {
+		cw.Close()
+		return err
+	}
+	return cw.Close()
+}
@@ -246,3 +246,60 @@ func (g *GitRepo) FormatPatch(base, commit2 *object.Commit) (string, []types.For
 
 	return allPatchesContent.String(), allPatches, nil
 }
+
+// maxCompareCommits caps the commits listed in a comparison
+const maxCompareCommits = 250
+
+// Compare diffs head against its merge base with base, which is what merging
+// head into base would change, and lists the commits on head that base is
+// missing, the most recent first.
+func (g *GitRepo) Compare(base, head *object.Commit) (*types.NiceDiff, []*object.Commit, error) {
+	mergeBases, err := head.MergeBase(base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("merge base: %w", err)
+	}
+
+	// unrelated histories are compared against nothing
+	from := &object.Tree{}
+	parent := ""
+	if len(mergeBases) > 0 {
+		from, err = mergeBases[0].Tree()
+		if err != nil {
+			return nil, nil, fmt.Errorf("merge base tree: %w", err)
+		}
+		parent = mergeBases[0].Hash.String()
+	}
+
+	to, err := head.Tree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("head tree: %w", err)
+	}
+
+	patch, err := from.Patch(to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("patch: %w", err)
+	}
+
+	diff := patchutil.AsNiceDiff(patch.String(), parent)
+	diff.Commit.This = head.Hash.String()
+	diff.Commit.Tree = head.TreeHash.String()
+
+	output, err := g.revList(
+		fmt.Sprintf("--max-count=%d", maxCompareCommits),
+		fmt.Sprintf("%s..%s", base.Hash.String(), head.Hash.String()),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("revlist: %w", err)
+	}
+
+	var commits []*object.Commit
+	for item := range strings.FieldsSeq(string(output)) {
+		obj, err := g.r.CommitObject(plumbing.NewHash(item))
+		if err != nil {
+			continue
+		}
+		commits = append(commits, obj)
+	}
+
+	return &diff, commits, nil
+}
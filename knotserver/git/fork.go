@@ -1,12 +1,16 @@
 package git
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 )
 
 func Fork(repoPath, source string) error {
@@ -23,22 +27,161 @@ func Fork(repoPath, source string) error {
 	return nil
 }
 
-func (g *GitRepo) Sync() error {
-	branch := g.h.String()
+// SyncStatus is what syncing a branch of a fork did
+type SyncStatus string
 
-	fetchOpts := &git.FetchOptions{
-		RefSpecs: []config.RefSpec{
-			config.RefSpec("+" + branch + ":" + branch), // +refs/heads/master:refs/heads/master
-		},
+const (
+	// the branch already has everything upstream has
+	SyncUpToDate SyncStatus = "upToDate"
+	// the branch only moved forward to the upstream branch
+	SyncFastForwarded SyncStatus = "fastForwarded"
+	// the branch and upstream both moved on and were merged
+	SyncMerged SyncStatus = "merged"
+	// the branch and upstream changed the same lines, the upstream branch is
+	// left on a branch of its own to be merged by hand
+	SyncConflict SyncStatus = "conflict"
+)
+
+type SyncResult struct {
+	Status SyncStatus
+	// head of the branch after syncing
+	Commit plumbing.Hash
+	// branch holding the upstream changes if they conflict
+	Branch string
+}
+
+// SyncOptions specifies the configuration for syncing a fork
+type SyncOptions struct {
+	CommitterName  string
+	CommitterEmail string
+}
+
+// SyncBranchPrefix is where the upstream branch is kept when it does not
+// merge cleanly, for example upstream/main
+const SyncBranchPrefix = "upstream/"
+
+// Sync brings branch of the fork up to date with the same branch of the repo
+// it was forked from. It fast-forwards when the fork has nothing of its own,
+// and merges otherwise. When the merge conflicts the branch is left alone.
+func (g *GitRepo) Sync(branch string, opts SyncOptions) (SyncResult, error) {
+	ref, err := g.Branch(branch)
+	if err != nil {
+		return SyncResult{}, err
 	}
+	local := ref.Hash()
 
-	err := g.r.Fetch(fetchOpts)
-	if errors.Is(git.NoErrAlreadyUpToDate, err) {
-		return nil
-	} else if err != nil {
-		return fmt.Errorf("failed to fetch origin branch: %s: %w", branch, err)
+	syncRef := "refs/hidden/sync/" + branch
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", g.path, "fetch", "--quiet", "--no-tags", "origin", "+refs/heads/"+branch+":"+syncRef)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return SyncResult{}, fmt.Errorf("failed to fetch upstream branch %s: %s", branch, stderr.String())
 	}
-	return nil
+
+	upstreamRef, err := g.r.Reference(plumbing.ReferenceName(syncRef), true)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("failed to resolve upstream branch %s: %w", branch, err)
+	}
+	upstream := upstreamRef.Hash()
+
+	isAncestor := func(a, b plumbing.Hash) bool {
+		return exec.Command("git", "-C", g.path, "merge-base", "--is-ancestor", a.String(), b.String()).Run() == nil
+	}
+
+	// the fork is ahead, or exactly where upstream is
+	if isAncestor(upstream, local) {
+		return SyncResult{Status: SyncUpToDate, Commit: local}, nil
+	}
+
+	if isAncestor(local, upstream) {
+		// fails if someone pushed in the meantime
+		err := g.r.Storer.CheckAndSetReference(
+			plumbing.NewHashReference(ref.Name(), upstream),
+			ref,
+		)
+		if err != nil {
+			return SyncResult{}, fmt.Errorf("failed to fast-forward %s: %w", branch, err)
+		}
+		return SyncResult{Status: SyncFastForwarded, Commit: upstream}, nil
+	}
+
+	head, err := g.mergeUpstream(branch, upstream, opts)
+	var mergeErr *ErrMerge
+	if errors.As(err, &mergeErr) && mergeErr.HasConflict {
+		conflictBranch := SyncBranchPrefix + branch
+		err := g.r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(conflictBranch), upstream))
+		if err != nil {
+			return SyncResult{}, fmt.Errorf("failed to keep upstream branch: %w", err)
+		}
+		return SyncResult{Status: SyncConflict, Commit: local, Branch: conflictBranch}, nil
+	}
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	return SyncResult{Status: SyncMerged, Commit: head}, nil
+}
+
+// mergeUpstream merges upstream into branch in a shared clone, like a pick
+func (g *GitRepo) mergeUpstream(branch string, upstream plumbing.Hash, opts SyncOptions) (plumbing.Hash, error) {
+	tmpDir, err := os.MkdirTemp("", "git-sync-")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "clone", "--quiet", "--shared", "--branch", branch, g.path, tmpDir)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to clone branch %s: %s", branch, stderr.String())
+	}
+
+	run := func(args ...string) ([]byte, error) {
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", append([]string{"-C", tmpDir}, args...)...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, stderr.String())
+		}
+		return out, nil
+	}
+
+	run("config", "user.name", opts.CommitterName)
+	run("config", "user.email", opts.CommitterEmail)
+
+	message := fmt.Sprintf("Merge branch '%s' of upstream", branch)
+	if _, err := run("merge", "--no-ff", "-m", message, upstream.String()); err != nil {
+		out, _ := run("diff", "--name-only", "--diff-filter=U")
+
+		var conflicts []ConflictInfo
+		for _, file := range strings.Fields(string(out)) {
+			conflicts = append(conflicts, ConflictInfo{Filename: file, Reason: "conflict"})
+		}
+		return plumbing.ZeroHash, &ErrMerge{
+			Message:     fmt.Sprintf("upstream does not merge cleanly into %s", branch),
+			Conflicts:   conflicts,
+			HasConflict: len(conflicts) > 0,
+			OtherError:  err,
+		}
+	}
+
+	out, err := run("rev-parse", "HEAD")
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	head := plumbing.NewHash(strings.TrimSpace(string(out)))
+
+	// not forced, so that this fails if the branch moved in the meantime
+	if _, err := run("push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		return plumbing.ZeroHash, &ErrMerge{
+			Message:    "failed to push changes to bare repository",
+			OtherError: err,
+		}
+	}
+
+	return head, nil
 }
 
 // TrackHiddenRemoteRef tracks a hidden remote in the repository. For example,
@@ -2,6 +2,7 @@ package git
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"fmt"
 	"io"
@@ -19,6 +20,7 @@ import (
 var (
 	ErrBinaryFile    = fmt.Errorf("binary file")
 	ErrNotBinaryFile = fmt.Errorf("not binary file")
+	ErrTarTooLarge   = fmt.Errorf("archive too large")
 )
 
 type GitRepo struct {
@@ -122,14 +124,21 @@ func PlainOpen(path string) (*GitRepo, error) {
 	return &g, nil
 }
 
-func (g *GitRepo) Commits(offset, limit int) ([]*object.Commit, error) {
+// Commits lists the history of the ref, of the commits touching paths when
+// any are given
+func (g *GitRepo) Commits(offset, limit int, paths ...string) ([]*object.Commit, error) {
 	commits := []*object.Commit{}
 
-	output, err := g.revList(
+	args := []string{
 		g.h.String(),
 		fmt.Sprintf("--skip=%d", offset),
 		fmt.Sprintf("--max-count=%d", limit),
-	)
+	}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+
+	output, err := g.revList(args...)
 	if err != nil {
 		return nil, fmt.Errorf("commits from ref: %w", err)
 	}
@@ -150,11 +159,16 @@ func (g *GitRepo) Commits(offset, limit int) ([]*object.Commit, error) {
 	return commits, nil
 }
 
-func (g *GitRepo) TotalCommits() (int, error) {
-	output, err := g.revList(
+func (g *GitRepo) TotalCommits(paths ...string) (int, error) {
+	args := []string{
 		g.h.String(),
 		fmt.Sprintf("--count"),
-	)
+	}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+
+	output, err := g.revList(args...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to run rev-list: %w", err)
 	}
@@ -281,6 +295,16 @@ func (g *GitRepo) SetDefaultBranch(branch string) error {
 	return g.r.Storer.SetReference(ref)
 }
 
+// HeadBranch is the branch HEAD points at, which works for empty
+// repositories too, unlike FindMainBranch
+func (g *GitRepo) HeadBranch() (string, error) {
+	head, err := g.r.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", err
+	}
+	return head.Target().Short(), nil
+}
+
 func (g *GitRepo) FindMainBranch() (string, error) {
 	output, err := g.revParse("--abbrev-ref", "HEAD")
 	if err != nil {
@@ -290,12 +314,66 @@ func (g *GitRepo) FindMainBranch() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-// WriteTar writes itself from a tree into a binary tar file format.
-// prefix is root folder to be appended.
-func (g *GitRepo) WriteTar(w io.Writer, prefix string) error {
+// WriteTar writes the tree of the current commit to w as a tar archive with
+// every path under prefix. It stops with ErrTarTooLarge as soon as the files
+// add up to more than maxSize bytes, unless maxSize is 0.
+func (g *GitRepo) WriteTar(w io.Writer, prefix string, maxSize int64) error {
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
+	return g.walkArchive(prefix, maxSize, func(info *infoWrapper, file *object.File) error {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if file == nil {
+			return nil
+		}
+		return copyBlob(tw, file)
+	})
+}
+
+// WriteZip is WriteTar for zip archives
+func (g *GitRepo) WriteZip(w io.Writer, prefix string, maxSize int64) error {
+	zw := zip.NewWriter(w)
+
+	err := g.walkArchive(prefix, maxSize, func(info *infoWrapper, file *object.File) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = info.name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if file == nil {
+			return nil
+		}
+		return copyBlob(fw, file)
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// walkArchive calls add for every entry in the tree of the current commit,
+// with the file to write for everything but directories
+func (g *GitRepo) walkArchive(prefix string, maxSize int64, add func(*infoWrapper, *object.File) error) error {
 	c, err := g.r.CommitObject(g.h)
 	if err != nil {
 		return fmt.Errorf("commit object: %w", err)
@@ -309,6 +387,7 @@ func (g *GitRepo) WriteTar(w io.Writer, prefix string) error {
 	walker := object.NewTreeWalker(tree, true, nil)
 	defer walker.Close()
 
+	var size int64
 	name, entry, err := walker.Next()
 	for ; err == nil; name, entry, err = walker.Next() {
 		info, err := newInfoWrapper(name, prefix, &entry, tree)
@@ -316,39 +395,38 @@ func (g *GitRepo) WriteTar(w io.Writer, prefix string) error {
 			return err
 		}
 
-		header, err := tar.FileInfoHeader(info, "")
-		if err != nil {
-			return err
-		}
-
-		err = tw.WriteHeader(header)
-		if err != nil {
-			return err
+		size += info.Size()
+		if maxSize > 0 && size > maxSize {
+			return ErrTarTooLarge
 		}
 
+		var file *object.File
 		if !info.IsDir() {
-			file, err := tree.File(name)
-			if err != nil {
-				return err
-			}
-
-			reader, err := file.Blob.Reader()
+			file, err = tree.File(name)
 			if err != nil {
 				return err
 			}
+		}
 
-			_, err = io.Copy(tw, reader)
-			if err != nil {
-				reader.Close()
-				return err
-			}
-			reader.Close()
+		if err := add(info, file); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+func copyBlob(w io.Writer, file *object.File) error {
+	reader, err := file.Blob.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(w, reader)
+	return err
+}
+
 func newInfoWrapper(
 	name string,
 	prefix string,
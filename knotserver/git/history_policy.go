@@ -0,0 +1,75 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// HistoryPolicy is what the commits landing on the default branch of a repo
+// have to look like, whether pushed or merged from a pull
+type HistoryPolicy struct {
+	// commits with more than one parent are refused
+	NoMergeCommits bool
+	// every commit message has to reference a pull request, as #123 or a
+	// link to one
+	RequirePullRef bool
+}
+
+var pullRef = regexp.MustCompile(`(^|[^&\w])#\d+\b|/pulls/\d+\b`)
+
+// how many commits breaking a policy are listed, pushes of many commits
+// would bury the reason otherwise
+const maxViolations = 10
+
+// CheckHistory returns why the commits of revs in the repo at dir break
+// policy, one line each, or nothing when they don't. env is added to the
+// environment of git, a receive hook passes on the variables that point at
+// the objects it holds in quarantine.
+func CheckHistory(dir string, env []string, policy HistoryPolicy, revs ...string) ([]string, error) {
+	if policy == (HistoryPolicy{}) {
+		return nil, nil
+	}
+
+	args := append([]string{"-C", dir, "log", "--format=%H" + fieldSeparator + "%P" + fieldSeparator + "%B" + recordSeparator}, revs...)
+	args = append(args, "--")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w, stderr: %s", err, stderr.String())
+	}
+
+	var violations []string
+	for _, record := range strings.Split(string(out), recordSeparator) {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), fieldSeparator, 3)
+		if len(fields) != 3 {
+			continue
+		}
+		sha, parents, message := fields[0], strings.Fields(fields[1]), fields[2]
+		title, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		if len(sha) > 8 {
+			sha = sha[:8]
+		}
+
+		if policy.NoMergeCommits && len(parents) > 1 {
+			violations = append(violations, fmt.Sprintf("%s %q is a merge commit, history has to be linear", sha, title))
+		}
+		if policy.RequirePullRef && !pullRef.MatchString(message) {
+			violations = append(violations, fmt.Sprintf("%s %q does not reference a pull request, such as #1", sha, title))
+		}
+	}
+
+	if len(violations) > maxViolations {
+		more := len(violations) - maxViolations
+		violations = append(violations[:maxViolations], fmt.Sprintf("and %d more", more))
+	}
+
+	return violations, nil
+}
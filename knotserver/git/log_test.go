@@ -0,0 +1,68 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitsTouchingPath(t *testing.T) {
+	dir := t.TempDir()
+	gitCmd(t, dir, "init", "-q", "-b", "main")
+
+	commit := func(name, content, msg string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitCmd(t, dir, "add", ".")
+		gitCmd(t, dir, "commit", "-q", "-m", msg)
+	}
+	commit("README.md", "hello", "readme")
+	commit("docs/a.md", "a", "docs a")
+	commit("README.md", "hello again", "readme again")
+	commit("docs/b.md", "b", "docs b")
+
+	gr, err := Open(dir, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string][]string{
+		"README.md": {"readme again", "readme"},
+		"docs":      {"docs b", "docs a"},
+		"docs/a.md": {"docs a"},
+		"gone.txt":  {},
+	} {
+		commits, err := gr.Commits(0, 10, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range commits {
+			got = append(got, c.Message[:len(c.Message)-1])
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got %q, want %q", path, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s: got %q, want %q", path, got, want)
+			}
+		}
+
+		total, err := gr.TotalCommits(path)
+		if err != nil || total != len(want) {
+			t.Errorf("%s: total %d, %v", path, total, err)
+		}
+	}
+
+	page, err := gr.Commits(1, 1, "README.md")
+	if err != nil || len(page) != 1 || page[0].Message != "readme\n" {
+		t.Errorf("second page: %v, %v", page, err)
+	}
+}
@@ -0,0 +1,151 @@
+package git
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ListRepos lists the repositories under scanPath, as did/name
+func ListRepos(scanPath string) ([]string, error) {
+	owners, err := os.ReadDir(scanPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []string
+	for _, owner := range owners {
+		if !owner.IsDir() || !strings.HasPrefix(owner.Name(), "did:") {
+			continue
+		}
+
+		entries, err := os.ReadDir(filepath.Join(scanPath, owner.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				repos = append(repos, filepath.Join(owner.Name(), entry.Name()))
+			}
+		}
+	}
+
+	return repos, nil
+}
+
+// DiskUsage is the size of every file in the repository at path, in bytes
+func DiskUsage(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// LastActivity is when the most recent commit on any branch of the
+// repository at path was made, zero for an empty repository
+func LastActivity(path string) (time.Time, error) {
+	cmd := exec.Command("git", "-C", path, "for-each-ref",
+		"--sort=-committerdate", "--count=1",
+		"--format=%(committerdate:iso-strict)", "refs/heads")
+	out, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date := strings.TrimSpace(string(out))
+	if date == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, date)
+}
+
+// GC repacks and prunes the repository at path right away, unlike Maintain
+// which waits for enough loose objects to pile up
+func GC(path string) error {
+	cmd := exec.Command("git", "-C", path,
+		"-c", "repack.writeBitmaps=true",
+		"-c", "gc.writeCommitGraph=true",
+		"gc", "--quiet")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Maintain runs git's housekeeping on the repository at path, which only
+// repacks and prunes once enough loose objects have piled up. Repacks write
+// reachability bitmaps, and a commit-graph is written if there is none yet,
+// both of which speed up clones and history walks on large repositories.
+func Maintain(path string) error {
+	cmd := exec.Command("git", "-C", path,
+		"-c", "repack.writeBitmaps=true",
+		"-c", "gc.writeCommitGraph=true",
+		"gc", "--auto", "--quiet")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	if !RepoFeatures(path).CommitGraph {
+		cmd := exec.Command("git", "-C", path, "commit-graph", "write", "--reachable")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("writing commit-graph: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	return nil
+}
+
+// Features are the on-disk indexes that make serving a repository cheaper
+type Features struct {
+	CommitGraph    bool
+	Bitmap         bool
+	MultiPackIndex bool
+}
+
+// Names lists the features present, as reported to the knot owner
+func (f Features) Names() []string {
+	var names []string
+	if f.CommitGraph {
+		names = append(names, "commit-graph")
+	}
+	if f.Bitmap {
+		names = append(names, "bitmap")
+	}
+	if f.MultiPackIndex {
+		names = append(names, "multi-pack-index")
+	}
+	return names
+}
+
+// RepoFeatures looks for the indexes of the bare repository at path
+func RepoFeatures(path string) Features {
+	exists := func(pattern string) bool {
+		matches, _ := filepath.Glob(filepath.Join(path, "objects", pattern))
+		return len(matches) > 0
+	}
+
+	return Features{
+		CommitGraph:    exists("info/commit-graph") || exists("info/commit-graphs/commit-graph-chain"),
+		Bitmap:         exists("pack/*.bitmap"),
+		MultiPackIndex: exists("pack/multi-pack-index"),
+	}
+}
@@ -0,0 +1,283 @@
+package git
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"tangled.sh/tangled.sh/core/types"
+)
+
+var (
+	manifestCache *ristretto.Cache
+)
+
+func init() {
+	cache, _ := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 1e5,
+		MaxCost:     1 << 26,
+		BufferItems: 64,
+	})
+	manifestCache = cache
+}
+
+type manifestParser func(content string) ([]types.Dependency, error)
+
+// manifests that we know how to read, looked up at the root of the tree
+var manifestParsers = []struct {
+	path      string
+	ecosystem string
+	parse     manifestParser
+}{
+	{"go.mod", "go", parseGoMod},
+	{"package.json", "npm", parsePackageJson},
+	{"Cargo.toml", "cargo", parseCargoToml},
+	{"requirements.txt", "pypi", parseRequirementsTxt},
+}
+
+// Manifests extracts the direct dependencies declared by any known manifest
+// at the root of the tree. Results are cached per commit, since the contents
+// of a commit never change.
+func (g *GitRepo) Manifests() ([]types.Manifest, error) {
+	key := fmt.Sprintf("%s:%s", g.path, g.h.String())
+	if cached, ok := manifestCache.Get(key); ok {
+		return cached.([]types.Manifest), nil
+	}
+
+	manifests := []types.Manifest{}
+	for _, p := range manifestParsers {
+		content, err := g.FileContentN(p.path, 1024*1024) // 1MB
+		if errors.Is(err, object.ErrFileNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", p.path, err)
+		}
+
+		deps, err := p.parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", p.path, err)
+		}
+
+		manifests = append(manifests, types.Manifest{
+			Path:         p.path,
+			Ecosystem:    p.ecosystem,
+			Dependencies: deps,
+		})
+	}
+
+	manifestCache.Set(key, manifests, int64(len(manifests)+1))
+	manifestCache.Wait()
+
+	return manifests, nil
+}
+
+func parseGoMod(content string) ([]types.Dependency, error) {
+	var deps []types.Dependency
+
+	inRequire := false
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// indirect dependencies are not interesting here
+		if strings.HasSuffix(line, "// indirect") {
+			continue
+		}
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		switch {
+		case line == "":
+			continue
+		case inRequire && line == ")":
+			inRequire = false
+			continue
+		case line == "require (":
+			inRequire = true
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inRequire:
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed require: %q", line)
+		}
+		deps = append(deps, types.Dependency{
+			Name:    fields[0],
+			Version: fields[1],
+		})
+	}
+
+	return deps, scanner.Err()
+}
+
+func parsePackageJson(content string) ([]types.Dependency, error) {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return nil, err
+	}
+
+	var deps []types.Dependency
+	for name, version := range pkg.Dependencies {
+		deps = append(deps, types.Dependency{Name: name, Version: version})
+	}
+	for name, version := range pkg.DevDependencies {
+		deps = append(deps, types.Dependency{Name: name, Version: version, Dev: true})
+	}
+	sortDependencies(deps)
+
+	return deps, nil
+}
+
+// parseCargoToml understands just enough toml to pull dependencies out of a
+// Cargo manifest; inline tables and dotted dependency tables are supported,
+// workspace inheritance is reported without a version.
+func parseCargoToml(content string) ([]types.Dependency, error) {
+	var deps []types.Dependency
+
+	section := ""
+	// index into deps for [dependencies.foo] style tables
+	current := -1
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			current = -1
+
+			for _, s := range []string{"dependencies", "dev-dependencies"} {
+				if name, ok := strings.CutPrefix(section, s+"."); ok {
+					deps = append(deps, types.Dependency{
+						Name: strings.Trim(name, `"`),
+						Dev:  s == "dev-dependencies",
+					})
+					current = len(deps) - 1
+				}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case current >= 0:
+			if key == "version" {
+				deps[current].Version = unquote(value)
+			}
+		case section == "dependencies" || section == "dev-dependencies":
+			deps = append(deps, types.Dependency{
+				Name:    key,
+				Version: cargoVersion(value),
+				Dev:     section == "dev-dependencies",
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sortDependencies(deps)
+	return deps, nil
+}
+
+// cargoVersion extracts the version from either `"1.0"` or
+// `{ version = "1.0", features = [...] }`
+func cargoVersion(value string) string {
+	if !strings.HasPrefix(value, "{") {
+		return unquote(value)
+	}
+
+	inner := strings.Trim(value, "{} ")
+	for _, kv := range strings.Split(inner, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if ok && strings.TrimSpace(k) == "version" {
+			return unquote(strings.TrimSpace(v))
+		}
+	}
+
+	return ""
+}
+
+func parseRequirementsTxt(content string) ([]types.Dependency, error) {
+	var deps []types.Dependency
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		// skip blanks and pip options such as -r, -e or --index-url
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		// drop environment markers
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		i := strings.IndexAny(line, "=<>!~[@ ")
+		if i < 0 {
+			deps = append(deps, types.Dependency{Name: line})
+			continue
+		}
+
+		name := line[:i]
+		rest := line[i:]
+		// strip extras, eg. requests[security]>=2.0
+		if strings.HasPrefix(rest, "[") {
+			if j := strings.Index(rest, "]"); j >= 0 {
+				rest = rest[j+1:]
+			}
+		}
+
+		deps = append(deps, types.Dependency{
+			Name:    name,
+			Version: strings.TrimSpace(rest),
+		})
+	}
+
+	return deps, scanner.Err()
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"'`)
+}
+
+func sortDependencies(deps []types.Dependency) {
+	slices.SortFunc(deps, func(a, b types.Dependency) int {
+		if a.Dev != b.Dev {
+			if a.Dev {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
@@ -0,0 +1,105 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	"tangled.sh/tangled.sh/core/types"
+)
+
+func TestManifestParsers(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    manifestParser
+		content  string
+		expected []types.Dependency
+	}{
+		{
+			name:  `go.mod`,
+			parse: parseGoMod,
+			content: `module example.com/foo
+
+go 1.24
+
+require github.com/single/dep v1.0.0
+
+require (
+	github.com/go-chi/chi/v5 v5.2.0 // router
+	golang.org/x/sync v0.16.0 // indirect
+)
+`,
+			expected: []types.Dependency{
+				{Name: "github.com/single/dep", Version: "v1.0.0"},
+				{Name: "github.com/go-chi/chi/v5", Version: "v5.2.0"},
+			},
+		},
+		{
+			name:  `package.json`,
+			parse: parsePackageJson,
+			content: `{
+  "name": "foo",
+  "dependencies": { "react": "^18.0.0", "htmx.org": "2.0.0" },
+  "devDependencies": { "tailwindcss": "^3" }
+}`,
+			expected: []types.Dependency{
+				{Name: "htmx.org", Version: "2.0.0"},
+				{Name: "react", Version: "^18.0.0"},
+				{Name: "tailwindcss", Version: "^3", Dev: true},
+			},
+		},
+		{
+			name:  `Cargo.toml`,
+			parse: parseCargoToml,
+			content: `[package]
+name = "foo"
+version = "0.1.0"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+anyhow = "1"
+local = { path = "../local" }
+
+[dependencies.tokio]
+version = "1.38"
+features = ["full"]
+
+[dev-dependencies]
+insta = "1.39"
+`,
+			expected: []types.Dependency{
+				{Name: "anyhow", Version: "1"},
+				{Name: "local"},
+				{Name: "serde", Version: "1.0"},
+				{Name: "tokio", Version: "1.38"},
+				{Name: "insta", Version: "1.39", Dev: true},
+			},
+		},
+		{
+			name:  `requirements.txt`,
+			parse: parseRequirementsTxt,
+			content: `# comment
+-r base.txt
+flask==3.0.0
+requests[security] >= 2.0 ; python_version > "3.8"
+numpy
+`,
+			expected: []types.Dependency{
+				{Name: "flask", Version: "==3.0.0"},
+				{Name: "requests", Version: ">= 2.0"},
+				{Name: "numpy"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(tt.content)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -92,6 +92,31 @@ type MergeOptions struct {
 	CommitterName  string
 	CommitterEmail string
 	FormatPatch    bool
+	Strategy       MergeStrategy
+	// checked against what lands, when merging into the default branch
+	Policy HistoryPolicy
+}
+
+// MergeStrategy is how a patch lands on the target branch
+type MergeStrategy string
+
+const (
+	// MergeDefault rebases format-patches and commits plain diffs
+	MergeDefault MergeStrategy = ""
+	// MergeCommit merges the patch with a merge commit
+	MergeCommit MergeStrategy = "merge"
+	// MergeSquash lands the patch as a single commit
+	MergeSquash MergeStrategy = "squash"
+	// MergeRebase lands the commits of the patch on top of the branch
+	MergeRebase MergeStrategy = "rebase"
+)
+
+func (s MergeStrategy) IsValid() bool {
+	switch s {
+	case MergeDefault, MergeCommit, MergeSquash, MergeRebase:
+		return true
+	}
+	return false
 }
 
 func (e ErrMerge) Error() string {
@@ -171,6 +196,23 @@ func (g *GitRepo) applyPatch(tmpDir, patchFile string, opts MergeOptions) error
 	exec.Command("git", "-C", tmpDir, "config", "user.email", opts.CommitterEmail).Run()
 	exec.Command("git", "-C", tmpDir, "config", "advice.mergeConflict", "false").Run()
 
+	// a merge commit merges a side branch the patch is applied to first
+	if opts.Strategy == MergeCommit {
+		if err := exec.Command("git", "-C", tmpDir, "checkout", "-q", "-b", mergeBranch).Run(); err != nil {
+			return fmt.Errorf("failed to create merge branch: %w", err)
+		}
+	}
+
+	// a squash folds the commits of a format-patch into one afterwards
+	var base string
+	if opts.Strategy == MergeSquash && opts.FormatPatch {
+		out, err := exec.Command("git", "-C", tmpDir, "rev-parse", "HEAD").Output()
+		if err != nil {
+			return fmt.Errorf("failed to resolve HEAD: %w", err)
+		}
+		base = strings.TrimSpace(string(out))
+	}
+
 	// if patch is a format-patch, apply using 'git am'
 	if opts.FormatPatch {
 		cmd = exec.Command("git", "-C", tmpDir, "am", patchFile)
@@ -187,33 +229,75 @@ func (g *GitRepo) applyPatch(tmpDir, patchFile string, opts MergeOptions) error
 			return fmt.Errorf("failed to stage changes: %w", err)
 		}
 
-		commitArgs := []string{"-C", tmpDir, "commit"}
+		cmd = exec.Command("git", commitArgs(tmpDir, opts)...)
+	}
+
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("patch application failed: %s", stderr.String())
+	}
 
-		// Set author if provided
-		authorName := opts.AuthorName
-		authorEmail := opts.AuthorEmail
+	switch {
+	case base != "":
+		stderr.Reset()
+		resetCmd := exec.Command("git", "-C", tmpDir, "reset", "--soft", base)
+		resetCmd.Stderr = &stderr
+		if err := resetCmd.Run(); err != nil {
+			return fmt.Errorf("failed to squash commits: %s", stderr.String())
+		}
 
-		if authorName != "" && authorEmail != "" {
-			commitArgs = append(commitArgs, "--author", fmt.Sprintf("%s <%s>", authorName, authorEmail))
+		stderr.Reset()
+		cmd = exec.Command("git", commitArgs(tmpDir, opts)...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to squash commits: %s", stderr.String())
 		}
-		// else, will default to knot's global user.name & user.email configured via `KNOT_GIT_USER_*` env variables
 
-		commitArgs = append(commitArgs, "-m", opts.CommitMessage)
+	case opts.Strategy == MergeCommit:
+		if err := exec.Command("git", "-C", tmpDir, "checkout", "-q", "-").Run(); err != nil {
+			return fmt.Errorf("failed to check out target branch: %w", err)
+		}
 
+		args := []string{"-C", tmpDir, "merge", "--no-ff", "-q", "-m", opts.CommitMessage}
 		if opts.CommitBody != "" {
-			commitArgs = append(commitArgs, "-m", opts.CommitBody)
+			args = append(args, "-m", opts.CommitBody)
 		}
+		args = append(args, mergeBranch)
 
-		cmd = exec.Command("git", commitArgs...)
+		stderr.Reset()
+		cmd = exec.Command("git", args...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to create merge commit: %s", stderr.String())
+		}
 	}
 
-	cmd.Stderr = &stderr
+	return nil
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("patch application failed: %s", stderr.String())
+// the side branch of a merge commit, it is never pushed
+const mergeBranch = "tangled-merge"
+
+func commitArgs(tmpDir string, opts MergeOptions) []string {
+	args := []string{"-C", tmpDir, "commit"}
+
+	// Set author if provided
+	authorName := opts.AuthorName
+	authorEmail := opts.AuthorEmail
+
+	if authorName != "" && authorEmail != "" {
+		args = append(args, "--author", fmt.Sprintf("%s <%s>", authorName, authorEmail))
 	}
+	// else, will default to knot's global user.name & user.email configured via `KNOT_GIT_USER_*` env variables
 
-	return nil
+	args = append(args, "-m", opts.CommitMessage)
+
+	if opts.CommitBody != "" {
+		args = append(args, "-m", opts.CommitBody)
+	}
+
+	return args
 }
 
 func (g *GitRepo) MergeCheck(patchData []byte, targetBranch string) error {
@@ -263,10 +347,32 @@ func (g *GitRepo) MergeWithOptions(patchData []byte, targetBranch string, opts M
 	}
 	defer os.RemoveAll(tmpDir)
 
+	base, err := exec.Command("git", "-C", tmpDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return &ErrMerge{
+			Message:    "failed to resolve target branch",
+			OtherError: err,
+		}
+	}
+
 	if err := g.applyPatch(tmpDir, patchFile, opts); err != nil {
 		return err
 	}
 
+	// the receive hook refuses these too, but only this can say why
+	violations, err := CheckHistory(tmpDir, nil, opts.Policy, strings.TrimSpace(string(base))+"..HEAD")
+	if err != nil {
+		return &ErrMerge{
+			Message:    "failed to check history policy",
+			OtherError: err,
+		}
+	}
+	if len(violations) > 0 {
+		return &ErrMerge{
+			Message: "refused by the history policy of this repository: " + strings.Join(violations, "; "),
+		}
+	}
+
 	pushCmd := exec.Command("git", "-C", tmpDir, "push")
 	if err := pushCmd.Run(); err != nil {
 		return &ErrMerge{
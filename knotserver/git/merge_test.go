@@ -0,0 +1,144 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestMergeStrategies(t *testing.T) {
+	tests := []struct {
+		strategy MergeStrategy
+		// the commits on the branch afterwards, newest first
+		subjects []string
+		// the parents of the newest one
+		parents int
+	}{
+		{MergeRebase, []string{"add b", "add a", "initial"}, 1},
+		{MergeSquash, []string{"the pull", "initial"}, 1},
+		{MergeCommit, []string{"the pull", "add b", "add a", "initial"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			// a repo with one commit on main, and a patch of two more
+			src := t.TempDir()
+			gitCmd(t, src, "init", "-q", "-b", "main")
+			gitCmd(t, src, "commit", "-q", "--allow-empty", "-m", "initial")
+
+			bare := filepath.Join(t.TempDir(), "repo.git")
+			gitCmd(t, src, "clone", "-q", "--bare", src, bare)
+
+			gitCmd(t, src, "checkout", "-q", "-b", "changes")
+			for _, name := range []string{"a", "b"} {
+				if err := os.WriteFile(filepath.Join(src, name), []byte(name+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				gitCmd(t, src, "add", name)
+				gitCmd(t, src, "commit", "-q", "-m", "add "+name)
+			}
+			patch := gitCmd(t, src, "format-patch", "--stdout", "main..changes") + "\n"
+
+			gr, err := Open(bare, "main")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = gr.MergeWithOptions([]byte(patch), "main", MergeOptions{
+				CommitMessage:  "the pull",
+				AuthorName:     "author",
+				AuthorEmail:    "author@example.com",
+				CommitterName:  "knot",
+				CommitterEmail: "knot@example.com",
+				FormatPatch:    true,
+				Strategy:       tt.strategy,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			subjects := strings.Split(gitCmd(t, bare, "log", "--topo-order", "--format=%s", "main"), "\n")
+			if strings.Join(subjects, ", ") != strings.Join(tt.subjects, ", ") {
+				t.Errorf("got commits %v, expected %v", subjects, tt.subjects)
+			}
+			parents := strings.Fields(gitCmd(t, bare, "log", "-1", "--format=%P", "main"))
+			if len(parents) != tt.parents {
+				t.Errorf("got %d parents, expected %d", len(parents), tt.parents)
+			}
+			if files := gitCmd(t, bare, "ls-tree", "--name-only", "main"); files != "a\nb" {
+				t.Errorf("got files %q", files)
+			}
+		})
+	}
+}
+
+func TestMergeHistoryPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		message  string
+		policy   HistoryPolicy
+		refused  bool
+	}{
+		{"unreferenced commits", MergeRebase, "", HistoryPolicy{RequirePullRef: true}, true},
+		{"referencing squash", MergeSquash, "the pull (#2)", HistoryPolicy{RequirePullRef: true}, false},
+		{"unreferenced squash", MergeSquash, "the pull", HistoryPolicy{RequirePullRef: true}, true},
+		{"merge commit", MergeCommit, "the pull (#2)", HistoryPolicy{NoMergeCommits: true}, true},
+		{"linear", MergeRebase, "", HistoryPolicy{NoMergeCommits: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := t.TempDir()
+			gitCmd(t, src, "init", "-q", "-b", "main")
+			gitCmd(t, src, "commit", "-q", "--allow-empty", "-m", "initial")
+
+			bare := filepath.Join(t.TempDir(), "repo.git")
+			gitCmd(t, src, "clone", "-q", "--bare", src, bare)
+
+			gitCmd(t, src, "checkout", "-q", "-b", "changes")
+			for _, name := range []string{"a", "b"} {
+				if err := os.WriteFile(filepath.Join(src, name), []byte(name+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				gitCmd(t, src, "add", name)
+				gitCmd(t, src, "commit", "-q", "-m", "add "+name)
+			}
+			patch := gitCmd(t, src, "format-patch", "--stdout", "main..changes") + "\n"
+
+			gr, err := Open(bare, "main")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = gr.MergeWithOptions([]byte(patch), "main", MergeOptions{
+				CommitMessage:  tt.message,
+				AuthorName:     "author",
+				AuthorEmail:    "author@example.com",
+				CommitterName:  "knot",
+				CommitterEmail: "knot@example.com",
+				FormatPatch:    true,
+				Strategy:       tt.strategy,
+				Policy:         tt.policy,
+			})
+			if refused := err != nil; refused != tt.refused {
+				t.Fatalf("expected refused to be %v, got %v", tt.refused, err)
+			}
+
+			subjects := gitCmd(t, bare, "log", "--format=%s", "main")
+			if tt.refused && subjects != "initial" {
+				t.Errorf("expected main to be left alone, got %q", subjects)
+			}
+		})
+	}
+}
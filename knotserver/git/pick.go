@@ -0,0 +1,130 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// PickOptions specifies the configuration for a revert or cherry-pick
+type PickOptions struct {
+	// author of a revert, a cherry-pick keeps the author of the original
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
+}
+
+// Revert commits the inverse of commit, which has to be on branch, on top of
+// branch and returns the new commit
+func (g *GitRepo) Revert(commit plumbing.Hash, branch string, opts PickOptions) (plumbing.Hash, error) {
+	return g.pick("revert", commit, branch, opts)
+}
+
+// CherryPick commits a copy of commit on top of branch, noting where it was
+// picked from, and returns the new commit
+func (g *GitRepo) CherryPick(commit plumbing.Hash, branch string, opts PickOptions) (plumbing.Hash, error) {
+	return g.pick("cherry-pick", commit, branch, opts)
+}
+
+func (g *GitRepo) pick(op string, commit plumbing.Hash, branch string, opts PickOptions) (plumbing.Hash, error) {
+	c, err := g.r.CommitObject(commit)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("commit %s not found: %w", commit, err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "git-pick-")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// a shared clone borrows the objects of the bare repository, so every
+	// commit is at hand without copying history around
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "clone", "--quiet", "--shared", "--branch", branch, g.path, tmpDir)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to clone branch %s: %s", branch, stderr.String())
+	}
+
+	run := func(args ...string) ([]byte, error) {
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", append([]string{"-C", tmpDir}, args...)...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, stderr.String())
+		}
+		return out, nil
+	}
+
+	run("config", "user.name", opts.CommitterName)
+	run("config", "user.email", opts.CommitterEmail)
+
+	// these are reported like conflicts, as something the user can act on
+	_, err = run("merge-base", "--is-ancestor", commit.String(), "HEAD")
+	onBranch := err == nil
+	if op == "revert" && !onBranch {
+		return plumbing.ZeroHash, &ErrMerge{Message: fmt.Sprintf("commit %s is not on %s", commit.String()[:8], branch)}
+	}
+	if op == "cherry-pick" && onBranch {
+		return plumbing.ZeroHash, &ErrMerge{Message: fmt.Sprintf("commit %s is already on %s", commit.String()[:8], branch)}
+	}
+
+	args := []string{op}
+	if op == "revert" {
+		args = append(args, "--no-edit")
+	} else {
+		args = append(args, "-x")
+	}
+	// the changes of a merge are the ones it brought onto its first parent
+	if c.NumParents() > 1 {
+		args = append(args, "--mainline", "1")
+	}
+	args = append(args, commit.String())
+
+	cmd = exec.Command("git", append([]string{"-C", tmpDir}, args...)...)
+	if op == "revert" && opts.AuthorName != "" && opts.AuthorEmail != "" {
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME="+opts.AuthorName, "GIT_AUTHOR_EMAIL="+opts.AuthorEmail)
+	}
+	stderr.Reset()
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		out, _ := run("diff", "--name-only", "--diff-filter=U")
+		if status, _ := run("status", "--porcelain"); len(out) == 0 && len(status) == 0 {
+			return plumbing.ZeroHash, &ErrMerge{Message: fmt.Sprintf("%s has these changes already", branch)}
+		}
+
+		var conflicts []ConflictInfo
+		for _, file := range strings.Fields(string(out)) {
+			conflicts = append(conflicts, ConflictInfo{Filename: file, Reason: "conflict"})
+		}
+		return plumbing.ZeroHash, &ErrMerge{
+			Message:     fmt.Sprintf("%s does not apply cleanly to %s", op, branch),
+			Conflicts:   conflicts,
+			HasConflict: len(conflicts) > 0,
+			OtherError:  fmt.Errorf("%w: %s", err, stderr.String()),
+		}
+	}
+
+	out, err := run("rev-parse", "HEAD")
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	head := plumbing.NewHash(strings.TrimSpace(string(out)))
+
+	// not forced, so that this fails if the branch moved in the meantime
+	if _, err := run("push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		return plumbing.ZeroHash, &ErrMerge{
+			Message:    "failed to push changes to bare repository",
+			OtherError: err,
+		}
+	}
+
+	return head, nil
+}
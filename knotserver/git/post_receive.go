@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/trailer"
 
 	"github.com/go-git/go-git/v5/plumbing"
 )
@@ -125,6 +126,14 @@ func (g *GitRepo) newCommitCount(line PostReceiveLine) (CommitCount, error) {
 			continue
 		}
 		commitCount.ByEmail[obj.Author.Email] += 1
+
+		// co-authors are credited with the commit too
+		coAuthors := trailer.Filter(trailer.Parse(obj.Message), trailer.CoAuthoredBy)
+		for _, email := range trailer.Emails(coAuthors) {
+			if email != obj.Author.Email {
+				commitCount.ByEmail[email] += 1
+			}
+		}
 	}
 
 	return commitCount, nil
@@ -0,0 +1,99 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const pullRefsPrefix = "refs/pulls"
+
+func PullHeadRef(pullId int64) plumbing.ReferenceName {
+	return plumbing.ReferenceName(fmt.Sprintf("%s/%d/head", pullRefsPrefix, pullId))
+}
+
+func PullMergeRef(pullId int64) plumbing.ReferenceName {
+	return plumbing.ReferenceName(fmt.Sprintf("%s/%d/merge", pullRefsPrefix, pullId))
+}
+
+// SetPullRefs points refs/pulls/{pullId}/merge at the result of applying
+// patch on top of targetBranch, and refs/pulls/{pullId}/head at sourceSha.
+//
+// The source of fork-based and patch-based pulls does not live in this
+// repository, so head falls back to the merge commit in those cases. The
+// refs are written with a fetch rather than a push, so that they do not
+// trigger the post-receive hook.
+func (g *GitRepo) SetPullRefs(pullId int64, patch []byte, targetBranch, sourceSha string, opts MergeOptions) error {
+	if err := g.hidePullRefs(); err != nil {
+		return err
+	}
+
+	headRef := PullHeadRef(pullId)
+	mergeRef := PullMergeRef(pullId)
+
+	hasSource := false
+	if sourceSha != "" {
+		if _, err := g.r.CommitObject(plumbing.NewHash(sourceSha)); err == nil {
+			hasSource = true
+		}
+	}
+
+	if hasSource {
+		ref := plumbing.NewHashReference(headRef, plumbing.NewHash(sourceSha))
+		if err := g.r.Storer.SetReference(ref); err != nil {
+			return fmt.Errorf("failed to set %s: %w", headRef, err)
+		}
+	}
+
+	patchFile, err := g.createTempFileWithPatch(patch)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(patchFile)
+
+	tmpDir, err := g.cloneRepository(targetBranch)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := g.applyPatch(tmpDir, patchFile, opts); err != nil {
+		// a stale merge ref is worse than none at all
+		g.r.Storer.RemoveReference(mergeRef)
+		return err
+	}
+
+	refspecs := []string{fmt.Sprintf("+HEAD:%s", mergeRef)}
+	if !hasSource {
+		refspecs = append(refspecs, fmt.Sprintf("+HEAD:%s", headRef))
+	}
+
+	var stderr bytes.Buffer
+	fetchCmd := exec.Command("git", append([]string{"-C", g.path, "fetch", "--quiet", tmpDir}, refspecs...)...)
+	fetchCmd.Stderr = &stderr
+	if err := fetchCmd.Run(); err != nil {
+		return fmt.Errorf("failed to fetch pull refs: %s", stderr.String())
+	}
+
+	return nil
+}
+
+// hidePullRefs makes sure that refs/pulls can be fetched, but not pushed to.
+func (g *GitRepo) hidePullRefs() error {
+	out, _ := exec.Command("git", "-C", g.path, "config", "--get-all", "receive.hideRefs").Output()
+	if slices.Contains(strings.Fields(string(out)), pullRefsPrefix) {
+		return nil
+	}
+
+	configureCmd := exec.Command("git", "-C", g.path, "config", "--add", "receive.hideRefs", pullRefsPrefix)
+	if err := configureCmd.Run(); err != nil {
+		return fmt.Errorf("failed to configure hidden refs: %w", err)
+	}
+
+	return nil
+}
@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage"
 )
 
 func InitBare(path, defaultBranch string) error {
@@ -40,3 +45,90 @@ func InitBare(path, defaultBranch string) error {
 
 	return nil
 }
+
+// InitialCommit commits files, keyed by their path at the root of the tree,
+// to branch of the empty bare repository at path, or to the branch HEAD
+// points at when branch is empty
+func InitialCommit(path, branch string, files map[string][]byte, author object.Signature) error {
+	repository, err := gogit.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+
+	if branch == "" {
+		head, err := repository.Storer.Reference(plumbing.HEAD)
+		if err != nil {
+			return fmt.Errorf("reading HEAD: %w", err)
+		}
+		branch = head.Target().Short()
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	tree := &object.Tree{}
+	for _, name := range names {
+		hash, err := storeObject(repository.Storer, &blob{files[name]})
+		if err != nil {
+			return fmt.Errorf("storing %s: %w", name, err)
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{
+			Name: name,
+			Mode: filemode.Regular,
+			Hash: hash,
+		})
+	}
+
+	treeHash, err := storeObject(repository.Storer, tree)
+	if err != nil {
+		return fmt.Errorf("storing tree: %w", err)
+	}
+
+	if author.When.IsZero() {
+		author.When = time.Now()
+	}
+	commitHash, err := storeObject(repository.Storer, &object.Commit{
+		Author:    author,
+		Committer: author,
+		Message:   "Initial commit\n",
+		TreeHash:  treeHash,
+	})
+	if err != nil {
+		return fmt.Errorf("storing commit: %w", err)
+	}
+
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), commitHash)
+	return repository.Storer.SetReference(ref)
+}
+
+type encodable interface {
+	Encode(plumbing.EncodedObject) error
+}
+
+func storeObject(s storage.Storer, o encodable) (plumbing.Hash, error) {
+	obj := s.NewEncodedObject()
+	if err := o.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.SetEncodedObject(obj)
+}
+
+// blob encodes raw contents as a blob object, object.Blob can only be read
+type blob struct {
+	contents []byte
+}
+
+func (b *blob) Encode(o plumbing.EncodedObject) error {
+	o.SetType(plumbing.BlobObject)
+	w, err := o.Writer()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write(b.contents)
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os/exec"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
@@ -72,13 +73,21 @@ func (c *ServiceCommand) RunService(cmd *exec.Cmd) error {
 	return nil
 }
 
+// UploadPackConfig lets clients ask for partial clones, with filters such as
+// blob:none, and fetch the objects left out later on. It has to be passed to
+// every upload-pack, including the one advertising capabilities.
+var UploadPackConfig = []string{
+	"-c", "uploadpack.allowFilter=true",
+	"-c", "uploadpack.allowReachableSHA1InWant=true",
+}
+
 func (c *ServiceCommand) InfoRefs() error {
-	cmd := exec.Command("git", []string{
+	cmd := exec.Command("git", append(slices.Clone(UploadPackConfig),
 		"upload-pack",
 		"--stateless-rpc",
 		"--http-backend-info-refs",
 		".",
-	}...)
+	)...)
 
 	if !strings.Contains(c.GitProtocol, "version=2") {
 		if err := packLine(c.Stdout, "# service=git-upload-pack\n"); err != nil {
@@ -96,12 +105,11 @@ func (c *ServiceCommand) InfoRefs() error {
 }
 
 func (c *ServiceCommand) UploadPack() error {
-	cmd := exec.Command("git", []string{
-		"-c", "uploadpack.allowFilter=true",
+	cmd := exec.Command("git", append(slices.Clone(UploadPackConfig),
 		"upload-pack",
 		"--stateless-rpc",
 		".",
-	}...)
+	)...)
 
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_PROTOCOL=%s", c.GitProtocol))
@@ -1,15 +1,15 @@
 package knotserver
 
 import (
-	"compress/gzip"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -17,13 +17,16 @@ import (
 	"time"
 
 	securejoin "github.com/cyphar/filepath-securejoin"
-	"github.com/gliderlabs/ssh"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"tangled.sh/tangled.sh/core/knotserver/db"
+	"tangled.sh/tangled.sh/core/crypto"
 	"tangled.sh/tangled.sh/core/knotserver/git"
+	"tangled.sh/tangled.sh/core/objectstore"
+	"tangled.sh/tangled.sh/core/scaffold"
 	"tangled.sh/tangled.sh/core/types"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+	"tangled.sh/tangled.sh/core/xrpc/serviceauth"
 )
 
 func (h *Handle) Index(w http.ResponseWriter, r *http.Request) {
@@ -41,6 +44,11 @@ func (h *Handle) Capabilities(w http.ResponseWriter, r *http.Request) {
 			"fork_submissions":   true,
 		},
 		"xrpc": true,
+		"service_auth": map[string]any{
+			"lxm":                    true,
+			"replay":                 true,
+			"max_nonceless_lifetime": int(serviceauth.MaxNoncelessLifetime.Seconds()),
+		},
 	}
 
 	jsonData, err := json.Marshal(capabilities)
@@ -67,12 +75,14 @@ func (h *Handle) RepoIndex(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		branches, _ := plain.Branches()
+		head, _ := plain.HeadBranch()
 
 		log.Println(err)
 
 		if errors.Is(err, plumbing.ErrReferenceNotFound) {
 			resp := types.RepoIndexResponse{
 				IsEmpty:  true,
+				Ref:      head,
 				Branches: branches,
 			}
 			writeJSON(w, resp)
@@ -156,7 +166,7 @@ func (h *Handle) RepoIndex(w http.ResponseWriter, r *http.Request) {
 	// show any errors
 	for err := range errorsCh {
 		l.Error("loading repo", "error", err.Error())
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		return
 	}
 
@@ -192,10 +202,20 @@ func (h *Handle) RepoIndex(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var license, licenseFile string
+	for _, name := range scaffold.LicenseFiles {
+		content, _ := gr.FileContent(name)
+		if len(content) > 0 {
+			license = scaffold.DetectLicense(content)
+			licenseFile = name
+			break
+		}
+	}
+
 	if ref == "" {
 		mainBranch, err := gr.FindMainBranch()
 		if err != nil {
-			writeError(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 			l.Error("finding main branch", "error", err.Error())
 			return
 		}
@@ -203,16 +223,18 @@ func (h *Handle) RepoIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := types.RepoIndexResponse{
-		IsEmpty:        false,
-		Ref:            ref,
-		Commits:        commits,
-		Description:    getDescription(path),
-		Readme:         readmeContent,
-		ReadmeFileName: readmeFile,
-		Files:          files,
-		Branches:       branches,
-		Tags:           rtags,
-		TotalCommits:   total,
+		IsEmpty:         false,
+		Ref:             ref,
+		Commits:         commits,
+		Description:     getDescription(path),
+		Readme:          readmeContent,
+		ReadmeFileName:  readmeFile,
+		License:         license,
+		LicenseFileName: licenseFile,
+		Files:           files,
+		Branches:        branches,
+		Tags:            rtags,
+		TotalCommits:    total,
 	}
 
 	writeJSON(w, resp)
@@ -234,7 +256,7 @@ func (h *Handle) RepoTree(w http.ResponseWriter, r *http.Request) {
 
 	files, err := gr.FileTree(r.Context(), treePath)
 	if err != nil {
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		l.Error("file tree", "error", err.Error())
 		return
 	}
@@ -250,6 +272,9 @@ func (h *Handle) RepoTree(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, resp)
 }
 
+// BlobRaw streams the file at ref, answering range requests, so that large
+// files are never loaded whole. Images, audio, video and text are served as
+// they are, anything else as a download.
 func (h *Handle) BlobRaw(w http.ResponseWriter, r *http.Request) {
 	treePath := chi.URLParam(r, "*")
 	ref := chi.URLParam(r, "ref")
@@ -264,42 +289,91 @@ func (h *Handle) BlobRaw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	contents, err := gr.RawContent(treePath)
-	if err != nil {
-		writeError(w, err.Error(), http.StatusBadRequest)
+	blob, err := gr.OpenBlob(treePath)
+	if errors.Is(err, object.ErrFileNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		notFound(w)
+		return
+	} else if err != nil {
+		writeError(w, xrpcerr.GitError(err), http.StatusBadRequest)
 		l.Error("file content", "error", err.Error())
 		return
 	}
+	defer blob.Close()
 
-	mimeType := http.DetectContentType(contents)
+	// sniffed from the start of the file, like browsers do
+	head := make([]byte, 512)
+	n, err := io.ReadFull(blob, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		l.Error("file content", "error", err.Error())
+		return
+	}
+	blob.Seek(0, io.SeekStart)
+
+	mimeType := http.DetectContentType(head[:n])
 
 	// exception for svg
 	if filepath.Ext(treePath) == ".svg" {
 		mimeType = "image/svg+xml"
 	}
 
-	contentHash := sha256.Sum256(contents)
-	eTag := fmt.Sprintf("\"%x\"", contentHash)
-
-	// allow image, video, and text/plain files to be served directly
 	switch {
-	case strings.HasPrefix(mimeType, "image/"), strings.HasPrefix(mimeType, "video/"):
-		if clientETag := r.Header.Get("If-None-Match"); clientETag == eTag {
-			w.WriteHeader(http.StatusNotModified)
-			return
-		}
-		w.Header().Set("ETag", eTag)
+	case strings.HasPrefix(mimeType, "image/"), strings.HasPrefix(mimeType, "video/"), strings.HasPrefix(mimeType, "audio/"):
+	case strings.HasPrefix(mimeType, "text/"):
+		// html and the like are shown as their source
+		mimeType = "text/plain; charset=utf-8"
+	default:
+		mimeType = "application/octet-stream"
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(treePath)))
+	}
 
-	case strings.HasPrefix(mimeType, "text/plain"):
-		w.Header().Set("Cache-Control", "public, no-cache")
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	// the ref may be a branch, revalidated by the hash of the blob
+	w.Header().Set("Cache-Control", "public, no-cache")
+	w.Header().Set("ETag", fmt.Sprintf("%q", blob.Hash()))
+
+	// seeking back in a blob inflates it again from the start, so serving
+	// several ranges would cost the whole blob for every one of them.
+	// requests for more than one range get the whole blob instead.
+	if strings.Contains(r.Header.Get("Range"), ",") {
+		r.Header.Del("Range")
+	}
 
-	default:
-		l.Error("attempted to serve disallowed file type", "mimetype", mimeType)
-		writeError(w, "only image, video, and text files can be accessed directly", http.StatusForbidden)
+	http.ServeContent(w, r, "", time.Time{}, blob)
+}
+
+// SiteFile serves the raw bytes of any file for static site publishing. The
+// appview decides how to present it, so nothing here is allowed to render on
+// the knot's own origin.
+func (h *Handle) SiteFile(w http.ResponseWriter, r *http.Request) {
+	treePath := chi.URLParam(r, "*")
+	ref := chi.URLParam(r, "ref")
+	ref, _ = url.PathUnescape(ref)
+
+	l := h.l.With("handler", "SiteFile", "ref", ref, "treePath", treePath)
+
+	path, _ := securejoin.SecureJoin(h.c.Repo.ScanPath, didPath(r))
+	gr, err := git.Open(path, ref)
+	if err != nil {
+		notFound(w)
 		return
 	}
 
-	w.Header().Set("Content-Type", mimeType)
+	contents, err := gr.RawContent(treePath)
+	if errors.Is(err, object.ErrFileNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		notFound(w)
+		return
+	} else if err != nil {
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		l.Error("file content", "error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
 	w.Write(contents)
 }
 
@@ -325,7 +399,7 @@ func (h *Handle) Blob(w http.ResponseWriter, r *http.Request) {
 		notFound(w)
 		return
 	} else if err != nil {
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		return
 	}
 
@@ -344,19 +418,60 @@ func (h *Handle) Blob(w http.ResponseWriter, r *http.Request) {
 	h.showFile(resp, w, l)
 }
 
+// Blame attributes every line of a file to the commit that last changed it
+func (h *Handle) Blame(w http.ResponseWriter, r *http.Request) {
+	treePath := chi.URLParam(r, "*")
+	ref := chi.URLParam(r, "ref")
+	ref, _ = url.PathUnescape(ref)
+
+	l := h.l.With("handler", "Blame", "ref", ref, "treePath", treePath)
+
+	path, _ := securejoin.SecureJoin(h.c.Repo.ScanPath, didPath(r))
+	gr, err := git.Open(path, ref)
+	if err != nil {
+		notFound(w)
+		return
+	}
+
+	lines, err := gr.Blame(treePath)
+	if errors.Is(err, git.ErrBinaryFile) {
+		writeError(w, xrpcerr.InvalidRequestError(fmt.Errorf("%s is a binary file", treePath)), http.StatusUnprocessableEntity)
+		return
+	} else if errors.Is(err, object.ErrFileNotFound) {
+		notFound(w)
+		return
+	} else if err != nil {
+		l.Error("blaming file", "error", err.Error())
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, types.RepoBlameResponse{
+		Ref:   ref,
+		Path:  treePath,
+		Lines: lines,
+	})
+}
+
 func (h *Handle) Archive(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 	file := chi.URLParam(r, "file")
 
 	l := h.l.With("handler", "Archive", "name", name, "file", file)
 
-	// TODO: extend this to add more files compression (e.g.: xz)
-	if !strings.HasSuffix(file, ".tar.gz") {
+	var format *archiveFormat
+	for _, f := range archiveFormats {
+		if strings.HasSuffix(file, f.ext) {
+			format = &f
+			break
+		}
+	}
+	if format == nil {
 		notFound(w)
 		return
 	}
 
-	ref := strings.TrimSuffix(file, ".tar.gz")
+	ref := strings.TrimSuffix(file, format.ext)
 
 	unescapedRef, err := url.PathUnescape(ref)
 	if err != nil {
@@ -366,47 +481,119 @@ func (h *Handle) Archive(w http.ResponseWriter, r *http.Request) {
 
 	safeRefFilename := strings.ReplaceAll(plumbing.ReferenceName(unescapedRef).Short(), "/", "-")
 
+	path, _ := securejoin.SecureJoin(h.c.Repo.ScanPath, didPath(r))
+	gr, err := git.Open(path, unescapedRef)
+	if err != nil {
+		notFound(w)
+		return
+	}
+
+	commit, err := gr.LastCommit()
+	if err != nil {
+		notFound(w)
+		return
+	}
+
+	prefix := fmt.Sprintf("%s-%s", name, safeRefFilename)
+	key := archiveKey(path, commit.Hash.String(), prefix)
+	filename := prefix + format.ext
+
+	storeKey := "archives/" + key + format.ext
+	storeOpts := objectstore.URLOptions{
+		ContentType:        format.mime,
+		ContentDisposition: fmt.Sprintf("attachment; filename=%q", filename),
+	}
+	if h.storage != nil {
+		if _, err := h.storage.Stat(r.Context(), storeKey); err == nil {
+			if u, err := h.storage.SignedURL(r.Context(), storeKey, storeOpts); err == nil {
+				http.Redirect(w, r, u, http.StatusFound)
+				return
+			}
+		}
+	}
+
+	f, err := h.archives.Open(key, format.ext, func(w io.Writer) error {
+		return format.write(gr, w, prefix, h.c.Archive.MaxSize)
+	})
+	if errors.Is(err, git.ErrTarTooLarge) {
+		err = fmt.Errorf("repository is larger than the %d bytes archives are limited to", h.c.Archive.MaxSize)
+		writeError(w, xrpcerr.GenericError(err), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		l.Error("writing archive", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	if h.storage != nil {
+		go h.storeArchive(storeKey, f.Name(), format.mime)
+	}
+
 	// This allows the browser to use a proper name for the file when
 	// downloading
-	filename := fmt.Sprintf("%s-%s.tar.gz", name, safeRefFilename)
 	setContentDisposition(w, filename)
-	setGZipMIME(w)
+	setMIME(w, format.mime)
+
+	http.ServeContent(w, r, filename, commit.Committer.When, f)
+}
+
+// Bundle serves a git bundle of the branches and tags of a repo, so that it
+// can be exported along with the rest of its data
+func (h *Handle) Bundle(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	l := h.l.With("handler", "Bundle", "name", name)
 
 	path, _ := securejoin.SecureJoin(h.c.Repo.ScanPath, didPath(r))
-	gr, err := git.Open(path, unescapedRef)
-	if err != nil {
+	if _, err := git.PlainOpen(path); err != nil {
 		notFound(w)
 		return
 	}
 
-	gw := gzip.NewWriter(w)
-	defer gw.Close()
+	tmp, err := os.MkdirTemp("", "bundle-*")
+	if err != nil {
+		l.Error("creating temp dir", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(tmp)
 
-	prefix := fmt.Sprintf("%s-%s", name, safeRefFilename)
-	err = gr.WriteTar(gw, prefix)
+	file := filepath.Join(tmp, name+".bundle")
+	err = git.WriteBundle(r.Context(), path, file)
+	if errors.Is(err, git.ErrEmptyBundle) {
+		writeError(w, xrpcerr.NotFoundError, http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		// once we start writing to the body we can't report error anymore
-		// so we are only left with printing the error.
-		l.Error("writing tar file", "error", err.Error())
+		l.Error("writing bundle", "error", err.Error())
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		return
 	}
 
-	err = gw.Flush()
+	f, err := os.Open(file)
 	if err != nil {
-		// once we start writing to the body we can't report error anymore
-		// so we are only left with printing the error.
-		l.Error("flushing?", "error", err.Error())
+		l.Error("opening bundle", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
 		return
 	}
+	defer f.Close()
+
+	setContentDisposition(w, name+".bundle")
+	setMIME(w, "application/x-git-bundle")
+	http.ServeContent(w, r, name+".bundle", time.Time{}, f)
 }
 
+// Log lists the commits of a ref, or those touching a file or directory
+// when the route has a path
 func (h *Handle) Log(w http.ResponseWriter, r *http.Request) {
 	ref := chi.URLParam(r, "ref")
 	ref, _ = url.PathUnescape(ref)
+	treePath := strings.Trim(chi.URLParam(r, "*"), "/")
 
 	path, _ := securejoin.SecureJoin(h.c.Repo.ScanPath, didPath(r))
 
-	l := h.l.With("handler", "Log", "ref", ref, "path", path)
+	l := h.l.With("handler", "Log", "ref", ref, "path", path, "treePath", treePath)
 
 	gr, err := git.Open(path, ref)
 	if err != nil {
@@ -434,18 +621,33 @@ func (h *Handle) Log(w http.ResponseWriter, r *http.Request) {
 	offset := (page - 1) * pageSize
 	limit := pageSize
 
-	commits, err := gr.Commits(offset, limit)
+	var paths []string
+	if treePath != "" {
+		paths = append(paths, treePath)
+	}
+
+	commits, err := gr.Commits(offset, limit, paths...)
 	if err != nil {
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		l.Error("fetching commits", "error", err.Error())
 		return
 	}
 
 	total := len(commits)
+	if treePath != "" {
+		// the history of a path is paged through by its total
+		total, err = gr.TotalCommits(paths...)
+		if err != nil {
+			writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+			l.Error("counting commits", "error", err.Error())
+			return
+		}
+	}
 
 	resp := types.RepoLogResponse{
 		Commits:     commits,
 		Ref:         ref,
+		Path:        treePath,
 		Description: getDescription(path),
 		Log:         true,
 		Total:       total,
@@ -471,7 +673,7 @@ func (h *Handle) Diff(w http.ResponseWriter, r *http.Request) {
 
 	diff, err := gr.Diff()
 	if err != nil {
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		l.Error("getting diff", "error", err.Error())
 		return
 	}
@@ -488,7 +690,7 @@ func (h *Handle) Tags(w http.ResponseWriter, r *http.Request) {
 	path, _ := securejoin.SecureJoin(h.c.Repo.ScanPath, didPath(r))
 	l := h.l.With("handler", "Refs")
 
-	gr, err := git.Open(path, "")
+	gr, err := git.PlainOpen(path)
 	if err != nil {
 		notFound(w)
 		return
@@ -563,14 +765,14 @@ func (h *Handle) Branch(w http.ResponseWriter, r *http.Request) {
 	ref, err := gr.Branch(branchName)
 	if err != nil {
 		l.Error("getting branch", "error", err.Error())
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		return
 	}
 
 	commit, err := gr.Commit(ref.Hash())
 	if err != nil {
 		l.Error("getting commit object", "error", err.Error())
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		return
 	}
 
@@ -600,44 +802,23 @@ func (h *Handle) Branch(w http.ResponseWriter, r *http.Request) {
 func (h *Handle) Keys(w http.ResponseWriter, r *http.Request) {
 	l := h.l.With("handler", "Keys")
 
-	switch r.Method {
-	case http.MethodGet:
-		keys, err := h.db.GetAllPublicKeys()
-		if err != nil {
-			writeError(w, err.Error(), http.StatusInternalServerError)
-			l.Error("getting public keys", "error", err.Error())
-			return
-		}
-
-		data := make([]map[string]any, 0)
-		for _, key := range keys {
-			j := key.JSON()
-			data = append(data, j)
-		}
-		writeJSON(w, data)
+	keys, err := h.db.GetAllPublicKeys()
+	if err != nil {
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		l.Error("getting public keys", "error", err.Error())
 		return
+	}
 
-	case http.MethodPut:
-		pk := db.PublicKey{}
-		if err := json.NewDecoder(r.Body).Decode(&pk); err != nil {
-			writeError(w, "invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		_, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pk.Key))
-		if err != nil {
-			writeError(w, "invalid pubkey", http.StatusBadRequest)
-		}
-
-		if err := h.db.AddPublicKey(pk); err != nil {
-			writeError(w, err.Error(), http.StatusInternalServerError)
-			l.Error("adding public key", "error", err.Error())
-			return
+	data := make([]map[string]any, 0)
+	for _, key := range keys {
+		j := key.JSON()
+		if info, err := crypto.ParseSSHKey(key.Key); err == nil {
+			j["type"] = info.Type
+			j["securityKey"] = info.SecurityKey
 		}
-
-		w.WriteHeader(http.StatusNoContent)
-		return
+		data = append(data, j)
 	}
+	writeJSON(w, data)
 }
 
 // func (h *Handle) RepoForkAheadBehind(w http.ResponseWriter, r *http.Request) {
@@ -737,7 +918,7 @@ func (h *Handle) RepoLanguages(w http.ResponseWriter, r *http.Request) {
 	sizes, err := gr.AnalyzeLanguages(ctx)
 	if err != nil {
 		l.Error("failed to analyze languages", "error", err.Error())
-		writeError(w, err.Error(), http.StatusNoContent)
+		writeError(w, xrpcerr.GitError(err), http.StatusNoContent)
 		return
 	}
 
@@ -746,6 +927,43 @@ func (h *Handle) RepoLanguages(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, resp)
 }
 
+func (h *Handle) RepoDependencies(w http.ResponseWriter, r *http.Request) {
+	repoPath, _ := securejoin.SecureJoin(h.c.Repo.ScanPath, didPath(r))
+	ref := chi.URLParam(r, "ref")
+	ref, _ = url.PathUnescape(ref)
+
+	l := h.l.With("handler", "RepoDependencies")
+
+	gr, err := git.Open(repoPath, ref)
+	if err != nil {
+		l.Error("opening repo", "error", err.Error())
+		notFound(w)
+		return
+	}
+
+	manifests, err := gr.Manifests()
+	if err != nil {
+		l.Error("failed to read manifests", "error", err.Error())
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		return
+	}
+
+	lastCommit, err := gr.LastCommit()
+	if err != nil {
+		l.Error("fetching last commit", "error", err.Error())
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		return
+	}
+
+	resp := types.RepoDependenciesResponse{
+		Ref:       ref,
+		Hash:      lastCommit.Hash.String(),
+		Manifests: manifests,
+	}
+
+	writeJSON(w, resp)
+}
+
 // func (h *Handle) RepoForkSync(w http.ResponseWriter, r *http.Request) {
 // 	l := h.l.With("handler", "RepoForkSync")
 //
@@ -1020,21 +1238,28 @@ func (h *Handle) Compare(w http.ResponseWriter, r *http.Request) {
 	commit1, err := gr.ResolveRevision(rev1)
 	if err != nil {
 		l.Error("error resolving revision 1", "msg", err.Error())
-		writeError(w, fmt.Sprintf("error resolving revision %s", rev1), http.StatusBadRequest)
+		writeError(w, xrpcerr.RefNotFoundError(rev1), http.StatusBadRequest)
 		return
 	}
 
 	commit2, err := gr.ResolveRevision(rev2)
 	if err != nil {
 		l.Error("error resolving revision 2", "msg", err.Error())
-		writeError(w, fmt.Sprintf("error resolving revision %s", rev2), http.StatusBadRequest)
+		writeError(w, xrpcerr.RefNotFoundError(rev2), http.StatusBadRequest)
 		return
 	}
 
 	rawPatch, formatPatch, err := gr.FormatPatch(commit1, commit2)
 	if err != nil {
 		l.Error("error comparing revisions", "msg", err.Error())
-		writeError(w, "error comparing revisions", http.StatusBadRequest)
+		writeError(w, xrpcerr.GitError(fmt.Errorf("error comparing revisions")), http.StatusBadRequest)
+		return
+	}
+
+	diff, commits, err := gr.Compare(commit1, commit2)
+	if err != nil {
+		l.Error("error diffing revisions", "msg", err.Error())
+		writeError(w, xrpcerr.GitError(fmt.Errorf("error comparing revisions")), http.StatusBadRequest)
 		return
 	}
 
@@ -1043,6 +1268,8 @@ func (h *Handle) Compare(w http.ResponseWriter, r *http.Request) {
 		Rev2:        commit2.Hash.String(),
 		FormatPatch: formatPatch,
 		Patch:       rawPatch,
+		Diff:        diff,
+		Commits:     commits,
 	})
 }
 
@@ -1058,7 +1285,7 @@ func (h *Handle) DefaultBranch(w http.ResponseWriter, r *http.Request) {
 
 	branch, err := gr.FindMainBranch()
 	if err != nil {
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
 		l.Error("getting default branch", "error", err.Error())
 		return
 	}
@@ -3,6 +3,8 @@ package knotserver
 import (
 	"encoding/json"
 	"net/http"
+
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 )
 
 func writeJSON(w http.ResponseWriter, data interface{}) {
@@ -11,12 +13,14 @@ func writeJSON(w http.ResponseWriter, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func writeError(w http.ResponseWriter, msg string, status int) {
+// writeError responds with the same envelope as the xrpc endpoints, so that
+// clients can tell errors apart by their tag
+func writeError(w http.ResponseWriter, e xrpcerr.XrpcError, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	json.NewEncoder(w).Encode(e)
 }
 
 func notFound(w http.ResponseWriter) {
-	writeError(w, "not found", http.StatusNotFound)
+	writeError(w, xrpcerr.NotFoundError, http.StatusNotFound)
 }
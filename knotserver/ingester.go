@@ -4,19 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"path/filepath"
-	"strings"
 
 	comatproto "github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/bluesky-social/indigo/xrpc"
 	"github.com/bluesky-social/jetstream/pkg/models"
 	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/gliderlabs/ssh"
 	"tangled.sh/tangled.sh/core/api/tangled"
-	"tangled.sh/tangled.sh/core/idresolver"
 	"tangled.sh/tangled.sh/core/knotserver/db"
 	"tangled.sh/tangled.sh/core/knotserver/git"
 	"tangled.sh/tangled.sh/core/log"
@@ -34,6 +30,10 @@ func (h *Handle) processPublicKey(ctx context.Context, event *models.Event) erro
 		return fmt.Errorf("failed to unmarshal record: %w", err)
 	}
 
+	if err := validatePublicKey(record.Key); err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+
 	pk := db.PublicKey{
 		Did:       did,
 		PublicKey: record,
@@ -86,6 +86,38 @@ func (h *Handle) processKnotMember(ctx context.Context, event *models.Event) err
 	return nil
 }
 
+// processKnot applies the repo creation policy the owner has published for
+// this knot
+func (h *Handle) processKnot(ctx context.Context, event *models.Event) error {
+	l := log.FromContext(ctx)
+
+	if event.Commit.Operation == models.CommitOperationDelete {
+		return nil
+	}
+
+	if event.Did != h.c.Server.Owner || event.Commit.RKey != h.c.Server.Hostname {
+		return nil
+	}
+
+	var record tangled.Knot
+	if err := json.Unmarshal(json.RawMessage(event.Commit.Record), &record); err != nil {
+		return fmt.Errorf("failed to unmarshal record: %w", err)
+	}
+
+	policy := rbac.RepoCreateMember
+	if record.RepoCreate != nil && rbac.RepoCreatePolicy(*record.RepoCreate).IsValid() {
+		policy = rbac.RepoCreatePolicy(*record.RepoCreate)
+	}
+
+	if err := h.e.SetRepoCreatePolicy(rbac.ThisServer, policy); err != nil {
+		l.Error("failed to set repo creation policy", "error", err)
+		return fmt.Errorf("failed to set repo creation policy: %w", err)
+	}
+	l.Info("set repo creation policy from firehose", "policy", policy)
+
+	return nil
+}
+
 func (h *Handle) processPull(ctx context.Context, event *models.Event) error {
 	raw := json.RawMessage(event.Commit.Record)
 	did := event.Did
@@ -115,8 +147,7 @@ func (h *Handle) processPull(ctx context.Context, event *models.Event) error {
 	}
 
 	// resolve this aturi to extract the repo record
-	resolver := idresolver.DefaultResolver()
-	ident, err := resolver.ResolveIdent(ctx, repoAt.Authority().String())
+	ident, err := h.resolver.ResolveIdent(ctx, repoAt.Authority().String())
 	if err != nil || ident.Handle.IsInvalidHandle() {
 		return fmt.Errorf("failed to resolve handle: %w", err)
 	}
@@ -228,16 +259,14 @@ func (h *Handle) processCollaborator(ctx context.Context, event *models.Event) e
 		return err
 	}
 
-	resolver := idresolver.DefaultResolver()
-
-	subjectId, err := resolver.ResolveIdent(ctx, record.Subject)
+	subjectId, err := h.resolver.ResolveIdent(ctx, record.Subject)
 	if err != nil || subjectId.Handle.IsInvalidHandle() {
 		return err
 	}
 
 	// TODO: fix this for good, we need to fetch the record here unfortunately
 	// resolve this aturi to extract the repo record
-	owner, err := resolver.ResolveIdent(ctx, repoAt.Authority().String())
+	owner, err := h.resolver.ResolveIdent(ctx, repoAt.Authority().String())
 	if err != nil || owner.Handle.IsInvalidHandle() {
 		return fmt.Errorf("failed to resolve handle: %w", err)
 	}
@@ -275,47 +304,61 @@ func (h *Handle) processCollaborator(ctx context.Context, event *models.Event) e
 	return h.fetchAndAddKeys(ctx, subjectId.DID.String())
 }
 
+// fetchAndAddKeys adds the keys a user has published on their PDS. Keys are
+// only ever taken from records in the user's own repo, so that nobody else can
+// associate a key with them.
 func (h *Handle) fetchAndAddKeys(ctx context.Context, did string) error {
 	l := log.FromContext(ctx)
 
-	keysEndpoint, err := url.JoinPath(h.c.AppViewEndpoint, "keys", did)
-	if err != nil {
-		l.Error("error building endpoint url", "did", did, "error", err.Error())
-		return fmt.Errorf("error building endpoint url: %w", err)
-	}
-
-	resp, err := http.Get(keysEndpoint)
+	ident, err := h.resolver.ResolveIdent(ctx, did)
 	if err != nil {
-		l.Error("error getting keys", "did", did, "error", err)
-		return fmt.Errorf("error getting keys: %w", err)
+		l.Error("error resolving did", "did", did, "error", err)
+		return fmt.Errorf("error resolving did: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		l.Info("no keys found for did", "did", did)
-		return nil
-	}
-
-	plaintext, err := io.ReadAll(resp.Body)
-	if err != nil {
-		l.Error("error reading response body", "error", err)
-		return fmt.Errorf("error reading response body: %w", err)
+	xrpcc := xrpc.Client{
+		Host: ident.PDSEndpoint(),
 	}
 
-	for key := range strings.SplitSeq(string(plaintext), "\n") {
-		if key == "" {
-			continue
+	cursor := ""
+	for {
+		resp, err := comatproto.RepoListRecords(ctx, &xrpcc, tangled.PublicKeyNSID, cursor, 100, did, false)
+		if err != nil {
+			l.Error("error listing keys", "did", did, "error", err)
+			return fmt.Errorf("error listing keys: %w", err)
 		}
-		pk := db.PublicKey{
-			Did: did,
+
+		for _, r := range resp.Records {
+			record, ok := r.Value.Val.(*tangled.PublicKey)
+			if !ok {
+				continue
+			}
+
+			if err := validatePublicKey(record.Key); err != nil {
+				l.Warn("skipping invalid public key", "did", did, "uri", r.Uri, "error", err)
+				continue
+			}
+
+			pk := db.PublicKey{
+				Did:       did,
+				PublicKey: *record,
+			}
+			if err := h.db.AddPublicKey(pk); err != nil {
+				l.Error("failed to add public key", "error", err)
+				return fmt.Errorf("failed to add public key: %w", err)
+			}
 		}
-		pk.Key = key
-		if err := h.db.AddPublicKey(pk); err != nil {
-			l.Error("failed to add public key", "error", err)
-			return fmt.Errorf("failed to add public key: %w", err)
+
+		if resp.Cursor == nil || *resp.Cursor == "" || len(resp.Records) == 0 {
+			return nil
 		}
+		cursor = *resp.Cursor
 	}
-	return nil
+}
+
+func validatePublicKey(key string) error {
+	_, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	return err
 }
 
 func (h *Handle) processMessages(ctx context.Context, event *models.Event) error {
@@ -337,6 +380,8 @@ func (h *Handle) processMessages(ctx context.Context, event *models.Event) error
 		err = h.processPublicKey(ctx, event)
 	case tangled.KnotMemberNSID:
 		err = h.processKnotMember(ctx, event)
+	case tangled.KnotNSID:
+		err = h.processKnot(ctx, event)
 	case tangled.RepoPullNSID:
 		err = h.processPull(ctx, event)
 	case tangled.RepoCollaboratorNSID:
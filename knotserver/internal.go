@@ -13,14 +13,17 @@ import (
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-git/go-git/v5/plumbing"
 	"tangled.sh/tangled.sh/core/api/tangled"
 	"tangled.sh/tangled.sh/core/hook"
 	"tangled.sh/tangled.sh/core/knotserver/config"
 	"tangled.sh/tangled.sh/core/knotserver/db"
 	"tangled.sh/tangled.sh/core/knotserver/git"
 	"tangled.sh/tangled.sh/core/notifier"
+	"tangled.sh/tangled.sh/core/patchutil"
 	"tangled.sh/tangled.sh/core/rbac"
 	"tangled.sh/tangled.sh/core/workflow"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 )
 
 type InternalHandle struct {
@@ -46,19 +49,59 @@ func (h *InternalHandle) PushAllowed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// archived repos are read-only, for everyone
+	if archived, err := h.db.IsArchived(repo); err != nil || archived {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Cloned counts a clone or fetch over ssh, guard reports these once
+// git-upload-pack is done
+func (h *InternalHandle) Cloned(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.AddClone(repo); err != nil {
+		h.l.Error("failed to count clone", "repo", repo, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *InternalHandle) InternalKeys(w http.ResponseWriter, r *http.Request) {
 	keys, err := h.db.GetAllPublicKeys()
 	if err != nil {
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	archived, err := h.db.GetArchived()
+	if err != nil {
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
 		return
 	}
 
+	// repos each did may push to, so that guard can still make a decision
+	// when this api is briefly unreachable
+	pushable := make(map[string][]string)
+
 	data := make([]map[string]interface{}, 0)
 	for _, key := range keys {
 		j := key.JSON()
+		repos, ok := pushable[key.Did]
+		if !ok {
+			repos = pushableRepos(h.e, archived, key.Did)
+			pushable[key.Did] = repos
+		}
+		j["repos"] = repos
 		data = append(data, j)
 	}
 	writeJSON(w, data)
@@ -125,6 +168,98 @@ func (h *InternalHandle) PostReceiveHook(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// merge refs of open pulls go stale as their target branches move
+	go h.refreshPullRefs(lines, repoDid, repoName)
+
+	if err := h.db.AddPush(gitRelativeDir); err != nil {
+		l.Error("failed to count push", "err", err, "repo", gitRelativeDir)
+		// non-fatal
+	}
+
+	writeJSON(w, resp)
+}
+
+// PreReceiveHook refuses pushes to the default branch of a repo that break
+// its history policy
+func (h *InternalHandle) PreReceiveHook(w http.ResponseWriter, r *http.Request) {
+	l := h.l.With("handler", "PreReceiveHook")
+
+	resp := hook.HookResponse{
+		Messages: make([]string, 0),
+	}
+
+	gitAbsoluteDir := r.Header.Get("X-Git-Dir")
+	gitRelativeDir, err := filepath.Rel(h.c.Repo.ScanPath, gitAbsoluteDir)
+	if err != nil || len(strings.SplitN(gitRelativeDir, "/", 2)) != 2 {
+		l.Error("invalid git dir", "scanPath", h.c.Repo.ScanPath, "gitAbsoluteDir", gitAbsoluteDir)
+		writeJSON(w, resp)
+		return
+	}
+
+	policy, err := h.db.GetHistoryPolicy(gitRelativeDir)
+	if err != nil {
+		l.Error("failed to get history policy", "err", err, "repo", gitRelativeDir)
+		writeJSON(w, resp)
+		return
+	}
+	if policy == (git.HistoryPolicy{}) {
+		writeJSON(w, resp)
+		return
+	}
+
+	gr, err := git.PlainOpen(gitAbsoluteDir)
+	if err != nil {
+		l.Error("failed to open repo", "err", err, "repo", gitRelativeDir)
+		writeJSON(w, resp)
+		return
+	}
+	defaultBranch, err := gr.HeadBranch()
+	if err != nil {
+		l.Error("failed to get default branch", "err", err, "repo", gitRelativeDir)
+		writeJSON(w, resp)
+		return
+	}
+
+	// the objects of the push are not in the repo until it is accepted
+	var env []string
+	for name, header := range hook.QuarantineEnv {
+		if v := r.Header.Get(header); v != "" {
+			env = append(env, name+"="+v)
+		}
+	}
+
+	lines, err := git.ParsePostReceive(r.Body)
+	if err != nil {
+		l.Error("failed to parse pre-receive payload", "err", err)
+		writeJSON(w, resp)
+		return
+	}
+
+	for _, line := range lines {
+		if line.Ref != plumbing.NewBranchReferenceName(defaultBranch).String() || line.NewSha.IsZero() {
+			continue
+		}
+
+		// what the push adds to the branch, all of it for a new branch
+		revs := []string{line.OldSha.String() + ".." + line.NewSha.String()}
+		if line.OldSha.IsZero() {
+			revs = []string{line.NewSha.String(), "--not", "--all"}
+		}
+
+		violations, err := git.CheckHistory(gitAbsoluteDir, env, policy, revs...)
+		if err != nil {
+			l.Error("failed to check history", "err", err, "repo", gitRelativeDir)
+			continue
+		}
+		if len(violations) > 0 {
+			resp.Rejected = true
+			resp.Messages = append(resp.Messages, fmt.Sprintf("%s is protected by the history policy of this repository:", defaultBranch))
+			for _, v := range violations {
+				resp.Messages = append(resp.Messages, "  "+v)
+			}
+		}
+	}
+
 	writeJSON(w, resp)
 }
 
@@ -173,6 +308,55 @@ func (h *InternalHandle) insertRefUpdate(line git.PostReceiveLine, gitUserDid, r
 	return errors.Join(errs, h.db.InsertEvent(event, h.n))
 }
 
+func (h *InternalHandle) refreshPullRefs(lines []git.PostReceiveLine, repoDid, repoName string) {
+	l := h.l.With("handler", "refreshPullRefs", "did", repoDid, "repo", repoName)
+
+	didSlashRepo, err := securejoin.SecureJoin(repoDid, repoName)
+	if err != nil {
+		return
+	}
+
+	repoPath, err := securejoin.SecureJoin(h.c.Repo.ScanPath, didSlashRepo)
+	if err != nil {
+		return
+	}
+
+	for _, line := range lines {
+		branch, ok := strings.CutPrefix(line.Ref, "refs/heads/")
+		if !ok || line.NewSha.IsZero() {
+			continue
+		}
+
+		pulls, err := h.db.GetPullRefsByTarget(didSlashRepo, branch)
+		if err != nil {
+			l.Error("failed to get pull refs", "branch", branch, "err", err)
+			continue
+		}
+
+		for _, p := range pulls {
+			gr, err := git.PlainOpen(repoPath)
+			if err != nil {
+				l.Error("failed to open repo", "err", err)
+				return
+			}
+
+			mo := git.MergeOptions{
+				CommitMessage:  fmt.Sprintf("pull #%d", p.PullId),
+				AuthorName:     h.c.Git.UserName,
+				AuthorEmail:    h.c.Git.UserEmail,
+				CommitterName:  h.c.Git.UserName,
+				CommitterEmail: h.c.Git.UserEmail,
+				FormatPatch:    patchutil.IsFormatPatch(p.Patch),
+			}
+
+			err = gr.SetPullRefs(p.PullId, []byte(p.Patch), branch, p.SourceSha, mo)
+			if err != nil {
+				l.Warn("failed to refresh pull refs", "pullId", p.PullId, "err", err)
+			}
+		}
+	}
+}
+
 func (h *InternalHandle) triggerPipeline(clientMsgs *[]string, line git.PostReceiveLine, gitUserDid, repoDid, repoName string, pushOptions PushOptions) error {
 	if pushOptions.skipCi {
 		return nil
@@ -281,7 +465,9 @@ func Internal(ctx context.Context, c *config.Config, db *db.DB, e *rbac.Enforcer
 
 	r.Get("/push-allowed", h.PushAllowed)
 	r.Get("/keys", h.InternalKeys)
+	r.Post("/stats/clone", h.Cloned)
 	r.Post("/hooks/post-receive", h.PostReceiveHook)
+	r.Post("/hooks/pre-receive", h.PreReceiveHook)
 	r.Mount("/debug", middleware.Profiler())
 
 	return r
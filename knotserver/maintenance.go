@@ -0,0 +1,46 @@
+package knotserver
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"tangled.sh/tangled.sh/core/knotserver/db"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+)
+
+// maintainRepos runs git's housekeeping on every repository once per
+// interval, and records how it went for the knot's usage dashboard.
+func maintainRepos(ctx context.Context, scanPath string, interval time.Duration, d *db.DB, l *slog.Logger) {
+	l = l.With("component", "maintenance")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		repos, err := git.ListRepos(scanPath)
+		if err != nil {
+			l.Error("failed to list repos", "error", err)
+			continue
+		}
+
+		for _, repo := range repos {
+			maintenanceErr := ""
+			if err := git.Maintain(filepath.Join(scanPath, repo)); err != nil {
+				l.Error("failed to maintain repo", "repo", repo, "error", err)
+				maintenanceErr = err.Error()
+			}
+
+			if err := d.SetMaintained(repo, maintenanceErr); err != nil {
+				l.Error("failed to record maintenance", "repo", repo, "error", err)
+			}
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"tangled.sh/tangled.sh/core/knotserver/xrpc"
 	tlog "tangled.sh/tangled.sh/core/log"
 	"tangled.sh/tangled.sh/core/notifier"
+	"tangled.sh/tangled.sh/core/objectstore"
 	"tangled.sh/tangled.sh/core/rbac"
 	"tangled.sh/tangled.sh/core/xrpc/serviceauth"
 )
@@ -27,10 +28,13 @@ type Handle struct {
 	l        *slog.Logger
 	n        *notifier.Notifier
 	resolver *idresolver.Resolver
+	archives *archiveCache
+	storage  objectstore.Store
 }
 
-func Setup(ctx context.Context, c *config.Config, db *db.DB, e *rbac.Enforcer, jc *jetstream.JetstreamClient, l *slog.Logger, n *notifier.Notifier) (http.Handler, error) {
+func Setup(ctx context.Context, c *config.Config, db *db.DB, e *rbac.Enforcer, jc *jetstream.JetstreamClient, res *idresolver.Resolver, l *slog.Logger, n *notifier.Notifier) (http.Handler, error) {
 	r := chi.NewRouter()
+	r.Use(tlog.RequestIds(l))
 
 	h := Handle{
 		c:        c,
@@ -39,10 +43,22 @@ func Setup(ctx context.Context, c *config.Config, db *db.DB, e *rbac.Enforcer, j
 		l:        l,
 		jc:       jc,
 		n:        n,
-		resolver: idresolver.DefaultResolver(),
+		resolver: res,
 	}
 
-	err := e.AddKnot(rbac.ThisServer)
+	archives, err := newArchiveCache(c.Archive.CachePath, c.Archive.CacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup archive cache: %w", err)
+	}
+	h.archives = archives
+
+	storage, err := objectstore.Open(c.Storage, c.Server.Url()+"/storage")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object store: %w", err)
+	}
+	h.storage = storage
+
+	err = e.AddKnot(rbac.ThisServer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup enforcer: %w", err)
 	}
@@ -71,6 +87,9 @@ func Setup(ctx context.Context, c *config.Config, db *db.DB, e *rbac.Enforcer, j
 	r.Get("/", h.Index)
 	r.Get("/capabilities", h.Capabilities)
 	r.Get("/version", h.Version)
+	if s, ok := h.storage.(http.Handler); ok {
+		r.Handle("/storage/*", http.StripPrefix("/storage", s))
+	}
 	r.Get("/owner", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(h.c.Server.Owner))
 	})
@@ -83,11 +102,16 @@ func Setup(ctx context.Context, c *config.Config, db *db.DB, e *rbac.Enforcer, j
 				r.Get("/{ref}", h.RepoLanguages)
 			})
 
+			r.Route("/dependencies", func(r chi.Router) {
+				r.Get("/", h.RepoDependencies)
+				r.Get("/{ref}", h.RepoDependencies)
+			})
+
 			r.Get("/", h.RepoIndex)
 			r.Get("/info/refs", h.InfoRefs)
 			r.Post("/git-upload-pack", h.UploadPack)
 			r.Post("/git-receive-pack", h.ReceivePack)
-			r.Get("/compare/{rev1}/{rev2}", h.Compare) // git diff-tree compare of two objects
+			r.Get("/compare/{rev1}/{rev2}", h.Compare) // patches, diff and commits of rev2 missing from rev1
 
 			r.Route("/tree/{ref}", func(r chi.Router) {
 				r.Get("/", h.RepoIndex)
@@ -98,12 +122,24 @@ func Setup(ctx context.Context, c *config.Config, db *db.DB, e *rbac.Enforcer, j
 				r.Get("/*", h.Blob)
 			})
 
+			r.Route("/blame/{ref}", func(r chi.Router) {
+				r.Get("/*", h.Blame)
+			})
+
 			r.Route("/raw/{ref}", func(r chi.Router) {
 				r.Get("/*", h.BlobRaw)
 			})
 
-			r.Get("/log/{ref}", h.Log)
+			r.Route("/site/{ref}", func(r chi.Router) {
+				r.Get("/*", h.SiteFile)
+			})
+
+			r.Route("/log/{ref}", func(r chi.Router) {
+				r.Get("/", h.Log)
+				r.Get("/*", h.Log)
+			})
 			r.Get("/archive/{file}", h.Archive)
+			r.Get("/bundle", h.Bundle)
 			r.Get("/commit/{ref}", h.Diff)
 			r.Get("/tags", h.Tags)
 			r.Route("/branches", func(r chi.Router) {
@@ -8,6 +8,7 @@ import (
 	"github.com/urfave/cli/v3"
 	"tangled.sh/tangled.sh/core/api/tangled"
 	"tangled.sh/tangled.sh/core/hook"
+	"tangled.sh/tangled.sh/core/idresolver"
 	"tangled.sh/tangled.sh/core/jetstream"
 	"tangled.sh/tangled.sh/core/knotserver/config"
 	"tangled.sh/tangled.sh/core/knotserver/db"
@@ -30,6 +31,8 @@ func Command() *cli.Command {
 		KNOT_SERVER_JETSTREAM_ENDPOINT   (default: wss://jetstream1.us-west.bsky.network/subscribe)
 		KNOT_SERVER_OWNER                (required)
 		KNOT_SERVER_LOG_DIDS             (default: true)
+		KNOT_SERVER_ACL_SNAPSHOT_PATH    (default: /home/git/acl-snapshot.json)
+		KNOT_SERVER_MAINTENANCE_INTERVAL (default: 24h)
 		KNOT_SERVER_DEV                  (default: false)
 		KNOT_REPO_SCAN_PATH              (default: /home/git)
 		KNOT_REPO_README                 (comma-separated list)
@@ -42,15 +45,21 @@ func Command() *cli.Command {
 }
 
 func Run(ctx context.Context, cmd *cli.Command) error {
-	logger := log.FromContext(ctx)
-	iLogger := log.New("knotserver/internal")
-
 	c, err := config.Load(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	err = hook.Setup(hook.Config(
+	return Serve(ctx, c, idresolver.DefaultResolver())
+}
+
+// Serve runs the knot described by c until its server fails. Identities are
+// looked up with res.
+func Serve(ctx context.Context, c *config.Config, res *idresolver.Resolver) error {
+	logger := log.FromContext(ctx)
+	iLogger := log.New("knotserver/internal")
+
+	err := hook.Setup(hook.Config(
 		hook.WithScanPath(c.Repo.ScanPath),
 		hook.WithInternalApi(c.Server.InternalListenAddr),
 	))
@@ -78,6 +87,7 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 	jc, err := jetstream.NewJetstreamClient(c.Server.JetstreamEndpoint, "knotserver", []string{
 		tangled.PublicKeyNSID,
 		tangled.KnotMemberNSID,
+		tangled.KnotNSID,
 		tangled.RepoPullNSID,
 		tangled.RepoCollaboratorNSID,
 	}, nil, logger, db, true, c.Server.LogDids)
@@ -87,11 +97,23 @@ func Run(ctx context.Context, cmd *cli.Command) error {
 
 	notifier := notifier.New()
 
-	mux, err := Setup(ctx, c, db, e, jc, logger, &notifier)
+	mux, err := Setup(ctx, c, db, e, jc, res, logger, &notifier)
 	if err != nil {
 		return fmt.Errorf("failed to setup server: %w", err)
 	}
 
+	if c.Server.AclSnapshotPath != "" {
+		go refreshAclSnapshot(ctx, c.Server.AclSnapshotPath, db, e, logger)
+	}
+
+	if c.Server.MaintenanceInterval > 0 {
+		go maintainRepos(ctx, c.Repo.ScanPath, c.Server.MaintenanceInterval, db, logger)
+	}
+
+	if c.Repo.TrashRetention > 0 {
+		go purgeTrash(ctx, c.Repo.TrashRetention, db, logger)
+	}
+
 	imux := Internal(ctx, c, db, e, iLogger, &notifier)
 
 	logger.Info("starting internal server", "address", c.Server.InternalListenAddr)
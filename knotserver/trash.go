@@ -0,0 +1,51 @@
+package knotserver
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"tangled.sh/tangled.sh/core/knotserver/db"
+)
+
+// purgeTrash removes deleted repositories for good once they have been in the
+// trash for longer than retention.
+func purgeTrash(ctx context.Context, retention time.Duration, d *db.DB, l *slog.Logger) {
+	l = l.With("component", "trash")
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		trash, err := d.GetTrash()
+		if err != nil {
+			l.Error("failed to list trash", "error", err)
+			continue
+		}
+
+		for _, t := range trash {
+			if time.Since(t.Deleted) < retention {
+				continue
+			}
+
+			if err := os.RemoveAll(t.Path); err != nil {
+				l.Error("failed to purge repo", "repo", t.Repo, "path", t.Path, "error", err)
+				continue
+			}
+
+			if err := d.RemoveTrash(t.Id); err != nil {
+				l.Error("failed to remove repo from trash", "repo", t.Repo, "error", err)
+				continue
+			}
+
+			l.Info("purged repo", "repo", t.Repo, "deleted", t.Deleted)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package xrpc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -13,15 +15,19 @@ import (
 	"github.com/bluesky-social/indigo/xrpc"
 	securejoin "github.com/cyphar/filepath-securejoin"
 	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gossh "golang.org/x/crypto/ssh"
 	"tangled.sh/tangled.sh/core/api/tangled"
 	"tangled.sh/tangled.sh/core/hook"
+	"tangled.sh/tangled.sh/core/knotserver/db"
 	"tangled.sh/tangled.sh/core/knotserver/git"
 	"tangled.sh/tangled.sh/core/rbac"
+	"tangled.sh/tangled.sh/core/scaffold"
 	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 )
 
 func (h *Xrpc) CreateRepo(w http.ResponseWriter, r *http.Request) {
-	l := h.Logger.With("handler", "NewRepo")
+	l := h.logger(r, "NewRepo")
 	fail := func(e xrpcerr.XrpcError) {
 		l.Error("failed", "kind", e.Tag, "error", e.Message)
 		writeError(w, e, http.StatusBadRequest)
@@ -45,7 +51,7 @@ func (h *Xrpc) CreateRepo(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoCreate_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
@@ -83,6 +89,27 @@ func (h *Xrpc) CreateRepo(w http.ResponseWriter, r *http.Request) {
 	relativeRepoPath := filepath.Join(actorDid.String(), repo.Name)
 	repoPath, _ := securejoin.SecureJoin(h.Config.Repo.ScanPath, relativeRepoPath)
 
+	opts := scaffold.Options{
+		Name:   repo.Name,
+		Holder: ident.Handle.String(),
+		Readme: data.Readme != nil && *data.Readme,
+	}
+	if repo.Description != nil {
+		opts.Description = *repo.Description
+	}
+	if data.Gitignore != nil {
+		opts.Gitignore = *data.Gitignore
+	}
+	if data.License != nil {
+		opts.License = *data.License
+	}
+
+	files, err := scaffold.Files(opts)
+	if err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
 	if data.Source != nil && *data.Source != "" {
 		err = git.Fork(repoPath, *data.Source)
 		if err != nil {
@@ -95,13 +122,26 @@ func (h *Xrpc) CreateRepo(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			l.Error("initializing bare repo", "error", err.Error())
 			if errors.Is(err, gogit.ErrRepositoryAlreadyExists) {
-				fail(xrpcerr.RepoExistsError("repository already exists"))
+				writeError(w, xrpcerr.RepoExistsError(relativeRepoPath), http.StatusConflict)
 				return
 			} else {
 				writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
 				return
 			}
 		}
+
+		if len(files) > 0 {
+			err = git.InitialCommit(repoPath, defaultBranch, files, object.Signature{
+				Name:  h.Config.Git.UserName,
+				Email: h.Config.Git.UserEmail,
+			})
+			if err != nil {
+				l.Error("committing scaffold", "error", err.Error())
+				os.RemoveAll(repoPath)
+				writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+				return
+			}
+		}
 	}
 
 	// add perms for this user to access the repo
@@ -120,9 +160,61 @@ func (h *Xrpc) CreateRepo(w http.ResponseWriter, r *http.Request) {
 		repoPath,
 	)
 
+	// knots that let anyone create repos may not know the owner yet, their
+	// keys are needed for them to push
+	isMember, err = h.Enforcer.IsKnotMember(actorDid.String(), rbac.ThisServer)
+	if err == nil && !isMember {
+		if err := h.addActor(r.Context(), actorDid.String(), ident.PDSEndpoint()); err != nil {
+			l.Error("failed to add keys of repo owner", "error", err)
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
+// addActor starts following the keys of did
+func (h *Xrpc) addActor(ctx context.Context, did, pds string) error {
+	if err := h.Db.AddDid(did); err != nil {
+		return err
+	}
+	h.Ingester.AddDid(did)
+
+	xrpcc := xrpc.Client{
+		Host: pds,
+	}
+
+	cursor := ""
+	for {
+		resp, err := comatproto.RepoListRecords(ctx, &xrpcc, tangled.PublicKeyNSID, cursor, 100, did, false)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resp.Records {
+			record, ok := r.Value.Val.(*tangled.PublicKey)
+			if !ok {
+				continue
+			}
+			if _, _, _, _, err := gossh.ParseAuthorizedKey([]byte(record.Key)); err != nil {
+				continue
+			}
+
+			err := h.Db.AddPublicKey(db.PublicKey{
+				Did:       did,
+				PublicKey: *record,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if resp.Cursor == nil || *resp.Cursor == "" || len(resp.Records) == 0 {
+			return nil
+		}
+		cursor = *resp.Cursor
+	}
+}
+
 func validateRepoName(name string) error {
 	// check for path traversal attempts
 	if name == "." || name == ".." ||
@@ -2,10 +2,12 @@ package xrpc
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	comatproto "github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/atproto/syntax"
@@ -17,7 +19,7 @@ import (
 )
 
 func (x *Xrpc) DeleteRepo(w http.ResponseWriter, r *http.Request) {
-	l := x.Logger.With("handler", "DeleteRepo")
+	l := x.logger(r, "DeleteRepo")
 	fail := func(e xrpcerr.XrpcError) {
 		l.Error("failed", "kind", e.Tag, "error", e.Message)
 		writeError(w, e, http.StatusBadRequest)
@@ -31,7 +33,7 @@ func (x *Xrpc) DeleteRepo(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoDelete_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
@@ -40,7 +42,7 @@ func (x *Xrpc) DeleteRepo(w http.ResponseWriter, r *http.Request) {
 	rkey := data.Rkey
 
 	if did == "" || name == "" {
-		fail(xrpcerr.GenericError(fmt.Errorf("did and name are required")))
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did and name are required")))
 		return
 	}
 
@@ -78,7 +80,11 @@ func (x *Xrpc) DeleteRepo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = os.RemoveAll(repoPath)
+	if x.Config.Repo.TrashRetention > 0 {
+		err = x.trashRepo(relativeRepoPath, repoPath)
+	} else {
+		err = os.RemoveAll(repoPath)
+	}
 	if err != nil {
 		l.Error("deleting repo", "error", err.Error())
 		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
@@ -94,3 +100,31 @@ func (x *Xrpc) DeleteRepo(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// trashRepo moves a repo out of the scan path and into the trash, where it is
+// kept until the retention window passes
+func (x *Xrpc) trashRepo(relativeRepoPath, repoPath string) error {
+	// the same name may well be deleted more than once
+	trashPath, err := securejoin.SecureJoin(
+		x.Config.Repo.TrashPath,
+		fmt.Sprintf("%s.%d", relativeRepoPath, time.Now().UnixNano()),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(trashPath), 0755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(repoPath, trashPath); err != nil {
+		return err
+	}
+
+	if err := x.Db.AddTrash(relativeRepoPath, trashPath); err != nil {
+		// an untracked repo in the trash would never be purged
+		return errors.Join(err, os.Rename(trashPath, repoPath))
+	}
+
+	return nil
+}
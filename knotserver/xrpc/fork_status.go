@@ -16,7 +16,7 @@ import (
 )
 
 func (x *Xrpc) ForkStatus(w http.ResponseWriter, r *http.Request) {
-	l := x.Logger.With("handler", "ForkStatus")
+	l := x.logger(r, "ForkStatus")
 	fail := func(e xrpcerr.XrpcError) {
 		l.Error("failed", "kind", e.Tag, "error", e.Message)
 		writeError(w, e, http.StatusBadRequest)
@@ -30,7 +30,7 @@ func (x *Xrpc) ForkStatus(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoForkStatus_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
@@ -40,7 +40,7 @@ func (x *Xrpc) ForkStatus(w http.ResponseWriter, r *http.Request) {
 	hiddenRef := data.HiddenRef
 
 	if did == "" || source == "" || branch == "" || hiddenRef == "" {
-		fail(xrpcerr.GenericError(fmt.Errorf("did, source, branch, and hiddenRef are required")))
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did, source, branch, and hiddenRef are required")))
 		return
 	}
 
@@ -74,14 +74,14 @@ func (x *Xrpc) ForkStatus(w http.ResponseWriter, r *http.Request) {
 	forkCommit, err := gr.ResolveRevision(branch)
 	if err != nil {
 		l.Error("error resolving ref revision", "msg", err.Error())
-		fail(xrpcerr.GenericError(fmt.Errorf("error resolving revision %s: %w", branch, err)))
+		fail(xrpcerr.RefNotFoundError(branch))
 		return
 	}
 
 	sourceCommit, err := gr.ResolveRevision(hiddenRef)
 	if err != nil {
 		l.Error("error resolving hidden ref revision", "msg", err.Error())
-		fail(xrpcerr.GenericError(fmt.Errorf("error resolving revision %s: %w", hiddenRef, err)))
+		fail(xrpcerr.RefNotFoundError(hiddenRef))
 		return
 	}
 
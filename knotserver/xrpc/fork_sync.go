@@ -15,7 +15,7 @@ import (
 )
 
 func (x *Xrpc) ForkSync(w http.ResponseWriter, r *http.Request) {
-	l := x.Logger.With("handler", "ForkSync")
+	l := x.logger(r, "ForkSync")
 	fail := func(e xrpcerr.XrpcError) {
 		l.Error("failed", "kind", e.Tag, "error", e.Message)
 		writeError(w, e, http.StatusBadRequest)
@@ -29,7 +29,7 @@ func (x *Xrpc) ForkSync(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoForkSync_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
@@ -38,7 +38,7 @@ func (x *Xrpc) ForkSync(w http.ResponseWriter, r *http.Request) {
 	branch := data.Branch
 
 	if did == "" || name == "" {
-		fail(xrpcerr.GenericError(fmt.Errorf("did, name are required")))
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did, name are required")))
 		return
 	}
 
@@ -56,18 +56,44 @@ func (x *Xrpc) ForkSync(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gr, err := git.Open(repoPath, branch)
+	gr, err := git.PlainOpen(repoPath)
 	if err != nil {
 		fail(xrpcerr.GenericError(fmt.Errorf("failed to open repository: %w", err)))
 		return
 	}
 
-	err = gr.Sync()
+	if branch == "" {
+		branch, err = gr.FindMainBranch()
+		if err != nil {
+			fail(xrpcerr.GenericError(err))
+			return
+		}
+	}
+	if _, err := gr.Branch(branch); err != nil {
+		writeError(w, xrpcerr.RefNotFoundError(branch), http.StatusNotFound)
+		return
+	}
+
+	result, err := gr.Sync(branch, git.SyncOptions{
+		CommitterName:  x.Config.Git.UserName,
+		CommitterEmail: x.Config.Git.UserEmail,
+	})
 	if err != nil {
 		l.Error("error syncing repo fork", "error", err.Error())
 		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
 		return
 	}
+	l.Info("synced fork", "repo", relativeRepoPath, "branch", branch, "status", result.Status)
+
+	response := tangled.RepoForkSync_Output{
+		Status: string(result.Status),
+		Commit: result.Commit.String(),
+	}
+	if result.Branch != "" {
+		response.Branch = &result.Branch
+	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
 }
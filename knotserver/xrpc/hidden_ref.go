@@ -16,7 +16,7 @@ import (
 )
 
 func (x *Xrpc) HiddenRef(w http.ResponseWriter, r *http.Request) {
-	l := x.Logger.With("handler", "HiddenRef")
+	l := x.logger(r, "HiddenRef")
 	fail := func(e xrpcerr.XrpcError) {
 		l.Error("failed", "kind", e.Tag, "error", e.Message)
 		writeError(w, e, http.StatusBadRequest)
@@ -30,7 +30,7 @@ func (x *Xrpc) HiddenRef(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoHiddenRef_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
@@ -39,7 +39,7 @@ func (x *Xrpc) HiddenRef(w http.ResponseWriter, r *http.Request) {
 	repoAtUri := data.Repo
 
 	if forkRef == "" || remoteRef == "" || repoAtUri == "" {
-		fail(xrpcerr.GenericError(fmt.Errorf("forkRef, remoteRef, and repo are required")))
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("forkRef, remoteRef, and repo are required")))
 		return
 	}
 
@@ -0,0 +1,121 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+	"tangled.sh/tangled.sh/core/rbac"
+	"tangled.sh/tangled.sh/core/scaffold"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// InitializeRepo commits a README, license or .gitignore to a repo that has
+// no branches yet, so that it can be started from the web
+func (x *Xrpc) InitializeRepo(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "InitializeRepo")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	var data tangled.RepoInitialize_Input
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
+	if data.Did == "" || data.Name == "" {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did and name are required")))
+		return
+	}
+
+	relativeRepoPath := filepath.Join(data.Did, data.Name)
+	l = l.With("repo", relativeRepoPath)
+
+	if ok, err := x.Enforcer.IsPushAllowed(actorDid.String(), rbac.ThisServer, relativeRepoPath); !ok || err != nil {
+		l.Error("insufficent permissions", "did", actorDid.String())
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	repoPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, relativeRepoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+	if _, err := os.Stat(repoPath); err != nil {
+		writeError(w, xrpcerr.NotFoundError, http.StatusNotFound)
+		return
+	}
+
+	gr, err := git.PlainOpen(repoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+	branches, err := gr.Branches()
+	if err != nil {
+		fail(xrpcerr.GitError(err))
+		return
+	}
+	if len(branches) > 0 {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("repository is not empty")))
+		return
+	}
+
+	opts := scaffold.Options{
+		Name:   data.Name,
+		Readme: data.Readme != nil && *data.Readme,
+	}
+	if data.Description != nil {
+		opts.Description = *data.Description
+	}
+	if data.Gitignore != nil {
+		opts.Gitignore = *data.Gitignore
+	}
+	if data.License != nil {
+		opts.License = *data.License
+	}
+
+	// the license names whoever owns the repo
+	opts.Holder = data.Did
+	if ident, err := x.Resolver.ResolveIdent(r.Context(), data.Did); err == nil && !ident.Handle.IsInvalidHandle() {
+		opts.Holder = ident.Handle.String()
+	}
+
+	files, err := scaffold.Files(opts)
+	if err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+	if len(files) == 0 {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("nothing to commit")))
+		return
+	}
+
+	err = git.InitialCommit(repoPath, "", files, object.Signature{
+		Name:  x.Config.Git.UserName,
+		Email: x.Config.Git.UserEmail,
+	})
+	if err != nil {
+		l.Error("committing", "error", err.Error())
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,227 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+	"tangled.sh/tangled.sh/core/rbac"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// KnotListRepos lists every repository on the knot, for the knot owner only
+func (x *Xrpc) KnotListRepos(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "KnotListRepos")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	if ok, err := x.Enforcer.IsKnotOwner(actorDid.String(), rbac.ThisServer); !ok || err != nil {
+		l.Error("insufficent permissions", "did", actorDid.String())
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	repos, err := git.ListRepos(x.Config.Repo.ScanPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	archived, err := x.Db.GetArchived()
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	transfers, err := x.Db.GetTransfers()
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	response := tangled.KnotListRepos_Output{
+		Repos: []*tangled.KnotListRepos_Repo{},
+	}
+
+	for _, repo := range repos {
+		path := filepath.Join(x.Config.Repo.ScanPath, repo)
+		size, err := git.DiskUsage(path)
+		if err != nil {
+			l.Error("failed to measure repo", "repo", repo, "error", err)
+		}
+
+		did, name, _ := strings.Cut(repo, "/")
+		listed := &tangled.KnotListRepos_Repo{
+			Did:      did,
+			Name:     name,
+			Size:     size,
+			Archived: archived[repo],
+		}
+
+		if t, err := git.LastActivity(path); err != nil {
+			l.Error("failed to read last activity", "repo", repo, "error", err)
+		} else if !t.IsZero() {
+			s := t.UTC().Format(time.RFC3339)
+			listed.LastActivity = &s
+		}
+
+		if toDid, ok := transfers[repo]; ok {
+			listed.TransferTo = &toDid
+		}
+
+		response.Repos = append(response.Repos, listed)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// KnotAdministerRepos applies one action to several repositories, for the
+// knot owner only. A repository the action fails on is reported in the
+// results and does not stop the others.
+func (x *Xrpc) KnotAdministerRepos(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "KnotAdministerRepos")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	if ok, err := x.Enforcer.IsKnotOwner(actorDid.String(), rbac.ThisServer); !ok || err != nil {
+		l.Error("insufficent permissions", "did", actorDid.String())
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	var data tangled.KnotAdministerRepos_Input
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+	l = l.With("action", data.Action)
+
+	var op func(relativeRepoPath, repoPath string) error
+	switch data.Action {
+	case "archive", "unarchive":
+		op = func(relativeRepoPath, _ string) error {
+			return x.Db.SetArchived(relativeRepoPath, data.Action == "archive")
+		}
+	case "delete":
+		op = x.administerDelete
+	case "gc":
+		op = func(relativeRepoPath, repoPath string) error {
+			gcErr := git.GC(repoPath)
+			maintenanceErr := ""
+			if gcErr != nil {
+				maintenanceErr = gcErr.Error()
+			}
+			return errors.Join(gcErr, x.Db.SetMaintained(relativeRepoPath, maintenanceErr))
+		}
+	case "transfer":
+		if data.NewOwner == nil {
+			fail(xrpcerr.InvalidRequestError(fmt.Errorf("newOwner is required to transfer")))
+			return
+		}
+		newOwner, err := syntax.ParseDID(*data.NewOwner)
+		if err != nil {
+			fail(xrpcerr.InvalidRequestError(fmt.Errorf("invalid new owner: %s", *data.NewOwner)))
+			return
+		}
+		op = func(relativeRepoPath, _ string) error {
+			if strings.HasPrefix(relativeRepoPath, newOwner.String()+"/") {
+				return fmt.Errorf("already owned by %s", newOwner)
+			}
+			return x.Db.SetTransfer(relativeRepoPath, newOwner.String())
+		}
+	default:
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("unknown action: %s", data.Action)))
+		return
+	}
+
+	response := tangled.KnotAdministerRepos_Output{
+		Results: []*tangled.KnotAdministerRepos_Result{},
+	}
+
+	for _, repo := range data.Repos {
+		result := &tangled.KnotAdministerRepos_Result{Did: repo.Did, Name: repo.Name}
+		response.Results = append(response.Results, result)
+
+		err := func() error {
+			// SecureJoin would happily turn these into the owner's whole
+			// directory, or the scan path itself
+			if _, err := syntax.ParseDID(repo.Did); err != nil {
+				return fmt.Errorf("invalid did: %s", repo.Did)
+			}
+			if repo.Name == "" || repo.Name == "." || repo.Name == ".." || strings.ContainsAny(repo.Name, `/\`) {
+				return fmt.Errorf("invalid repository name: %q", repo.Name)
+			}
+
+			relativeRepoPath, err := securejoin.SecureJoin(repo.Did, repo.Name)
+			if err != nil {
+				return err
+			}
+			repoPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, relativeRepoPath)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(repoPath); err != nil {
+				return fmt.Errorf("no such repository")
+			}
+			return op(relativeRepoPath, repoPath)
+		}()
+		if err != nil {
+			l.Error("failed to administer repo", "repo", repo.Did+"/"+repo.Name, "error", err)
+			e := err.Error()
+			result.Error = &e
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// administerDelete deletes a repo on behalf of the knot owner, into the trash
+// if the knot keeps one. Unlike DeleteRepo, the owner's record may well still
+// exist.
+func (x *Xrpc) administerDelete(relativeRepoPath, repoPath string) error {
+	var err error
+	if x.Config.Repo.TrashRetention > 0 {
+		err = x.trashRepo(relativeRepoPath, repoPath)
+	} else {
+		err = os.RemoveAll(repoPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	did, _, _ := strings.Cut(relativeRepoPath, "/")
+	return errors.Join(
+		x.Enforcer.RemoveRepo(did, rbac.ThisServer, relativeRepoPath),
+		x.Db.RemoveTransfer(relativeRepoPath),
+		x.Db.SetArchived(relativeRepoPath, false),
+	)
+}
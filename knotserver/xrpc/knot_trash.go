@@ -0,0 +1,73 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+	"tangled.sh/tangled.sh/core/rbac"
+
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// KnotTrash lists deleted repositories that can still be restored, for the
+// knot owner only
+func (x *Xrpc) KnotTrash(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "KnotTrash")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	if ok, err := x.Enforcer.IsKnotOwner(actorDid.String(), rbac.ThisServer); !ok || err != nil {
+		l.Error("insufficent permissions", "did", actorDid.String())
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	trash, err := x.Db.GetTrash()
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	retention := x.Config.Repo.TrashRetention
+	response := tangled.KnotTrash_Output{
+		Repos: []*tangled.KnotTrash_Repo{},
+	}
+	if retention > 0 {
+		s := retention.String()
+		response.Retention = &s
+	}
+
+	for _, t := range trash {
+		did, name, _ := strings.Cut(t.Repo, "/")
+
+		size, err := git.DiskUsage(t.Path)
+		if err != nil {
+			l.Error("failed to measure repo", "repo", t.Repo, "error", err)
+		}
+
+		response.Repos = append(response.Repos, &tangled.KnotTrash_Repo{
+			Did:       did,
+			Name:      name,
+			Size:      size,
+			DeletedAt: t.Deleted.Format(time.RFC3339),
+			ExpiresAt: t.Deleted.Add(retention).Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
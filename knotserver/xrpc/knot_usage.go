@@ -0,0 +1,91 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+	"tangled.sh/tangled.sh/core/rbac"
+
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// KnotUsage reports disk usage and activity of every repository, for the
+// knot owner only
+func (x *Xrpc) KnotUsage(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "KnotUsage")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	if ok, err := x.Enforcer.IsKnotOwner(actorDid.String(), rbac.ThisServer); !ok || err != nil {
+		l.Error("insufficent permissions", "did", actorDid.String())
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	repos, err := git.ListRepos(x.Config.Repo.ScanPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	stats, err := x.Db.GetRepoStats()
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	response := tangled.KnotUsage_Output{
+		Repos: []*tangled.KnotUsage_Repo{},
+	}
+	if interval := x.Config.Server.MaintenanceInterval; interval > 0 {
+		s := interval.String()
+		response.MaintenanceInterval = &s
+	}
+
+	for _, repo := range repos {
+		path := filepath.Join(x.Config.Repo.ScanPath, repo)
+		size, err := git.DiskUsage(path)
+		if err != nil {
+			l.Error("failed to measure repo", "repo", repo, "error", err)
+		}
+
+		did, name, _ := strings.Cut(repo, "/")
+		usage := &tangled.KnotUsage_Repo{
+			Did:      did,
+			Name:     name,
+			Size:     size,
+			Features: git.RepoFeatures(path).Names(),
+		}
+
+		if s, ok := stats[repo]; ok {
+			usage.Clones = s.Clones
+			usage.Pushes = s.Pushes
+			if s.LastMaintenance != "" {
+				usage.LastMaintenance = &s.LastMaintenance
+			}
+			if s.MaintenanceError != "" {
+				usage.MaintenanceError = &s.MaintenanceError
+			}
+		}
+
+		response.TotalSize += size
+		response.Repos = append(response.Repos, usage)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
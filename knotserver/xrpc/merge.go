@@ -12,12 +12,11 @@ import (
 	"tangled.sh/tangled.sh/core/knotserver/git"
 	"tangled.sh/tangled.sh/core/patchutil"
 	"tangled.sh/tangled.sh/core/rbac"
-	"tangled.sh/tangled.sh/core/types"
 	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 )
 
 func (x *Xrpc) Merge(w http.ResponseWriter, r *http.Request) {
-	l := x.Logger.With("handler", "Merge")
+	l := x.logger(r, "Merge")
 	fail := func(e xrpcerr.XrpcError) {
 		l.Error("failed", "kind", e.Tag, "error", e.Message)
 		writeError(w, e, http.StatusBadRequest)
@@ -31,7 +30,7 @@ func (x *Xrpc) Merge(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoMerge_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
@@ -39,7 +38,7 @@ func (x *Xrpc) Merge(w http.ResponseWriter, r *http.Request) {
 	name := data.Name
 
 	if did == "" || name == "" {
-		fail(xrpcerr.GenericError(fmt.Errorf("did and name are required")))
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did and name are required")))
 		return
 	}
 
@@ -81,27 +80,38 @@ func (x *Xrpc) Merge(w http.ResponseWriter, r *http.Request) {
 		mo.CommitMessage = *data.CommitMessage
 	}
 
+	if data.Strategy != nil {
+		mo.Strategy = git.MergeStrategy(*data.Strategy)
+		if !mo.Strategy.IsValid() {
+			fail(xrpcerr.InvalidRequestError(fmt.Errorf("unknown merge strategy %q", *data.Strategy)))
+			return
+		}
+	}
+
 	mo.CommitterName = x.Config.Git.UserName
 	mo.CommitterEmail = x.Config.Git.UserEmail
 	mo.FormatPatch = patchutil.IsFormatPatch(data.Patch)
 
+	// the history policy of a repo is about its default branch
+	if head, err := gr.HeadBranch(); err == nil && head == data.Branch {
+		mo.Policy, err = x.Db.GetHistoryPolicy(relativeRepoPath)
+		if err != nil {
+			l.Error("failed to get history policy", "error", err.Error())
+			writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	err = gr.MergeWithOptions([]byte(data.Patch), data.Branch, mo)
 	if err != nil {
 		var mergeErr *git.ErrMerge
 		if errors.As(err, &mergeErr) {
-			conflicts := make([]types.ConflictInfo, len(mergeErr.Conflicts))
+			files := make([]string, len(mergeErr.Conflicts))
 			for i, conflict := range mergeErr.Conflicts {
-				conflicts[i] = types.ConflictInfo{
-					Filename: conflict.Filename,
-					Reason:   conflict.Reason,
-				}
+				files[i] = conflict.Filename
 			}
 
-			conflictErr := xrpcerr.NewXrpcError(
-				xrpcerr.WithTag("MergeConflict"),
-				xrpcerr.WithMessage(fmt.Sprintf("Merge failed due to conflicts: %s", mergeErr.Message)),
-			)
-			writeError(w, conflictErr, http.StatusConflict)
+			writeError(w, xrpcerr.MergeConflictError(mergeErr.Message, files...), http.StatusConflict)
 			return
 		} else {
 			l.Error("failed to merge", "error", err.Error())
@@ -13,7 +13,7 @@ import (
 )
 
 func (x *Xrpc) MergeCheck(w http.ResponseWriter, r *http.Request) {
-	l := x.Logger.With("handler", "MergeCheck")
+	l := x.logger(r, "MergeCheck")
 	fail := func(e xrpcerr.XrpcError) {
 		l.Error("failed", "kind", e.Tag, "error", e.Message)
 		writeError(w, e, http.StatusBadRequest)
@@ -21,7 +21,7 @@ func (x *Xrpc) MergeCheck(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoMergeCheck_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
@@ -29,7 +29,7 @@ func (x *Xrpc) MergeCheck(w http.ResponseWriter, r *http.Request) {
 	name := data.Name
 
 	if did == "" || name == "" {
-		fail(xrpcerr.GenericError(fmt.Errorf("did and name are required")))
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did and name are required")))
 		return
 	}
 
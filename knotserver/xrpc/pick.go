@@ -0,0 +1,134 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/go-git/go-git/v5/plumbing"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+	"tangled.sh/tangled.sh/core/rbac"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// Revert commits the inverse of a commit to the branch it is on
+func (x *Xrpc) Revert(w http.ResponseWriter, r *http.Request) {
+	var data tangled.RepoRevert_Input
+	x.pick(w, r, "Revert", &data, func() (string, string, string, string) {
+		return data.Did, data.Name, data.Commit, data.Branch
+	}, func(gr *git.GitRepo, commit plumbing.Hash, opts git.PickOptions) (any, error) {
+		if data.AuthorName != nil {
+			opts.AuthorName = *data.AuthorName
+		}
+		if data.AuthorEmail != nil {
+			opts.AuthorEmail = *data.AuthorEmail
+		}
+		h, err := gr.Revert(commit, data.Branch, opts)
+		return tangled.RepoRevert_Output{Commit: h.String()}, err
+	})
+}
+
+// CherryPick copies a commit onto a branch
+func (x *Xrpc) CherryPick(w http.ResponseWriter, r *http.Request) {
+	var data tangled.RepoCherryPick_Input
+	x.pick(w, r, "CherryPick", &data, func() (string, string, string, string) {
+		return data.Did, data.Name, data.Commit, data.Branch
+	}, func(gr *git.GitRepo, commit plumbing.Hash, opts git.PickOptions) (any, error) {
+		h, err := gr.CherryPick(commit, data.Branch, opts)
+		return tangled.RepoCherryPick_Output{Commit: h.String()}, err
+	})
+}
+
+// pick decodes the input into data, checks that the actor may push to the
+// repo and runs op on it
+func (x *Xrpc) pick(
+	w http.ResponseWriter,
+	r *http.Request,
+	handler string,
+	data any,
+	fields func() (did, name, commit, branch string),
+	op func(gr *git.GitRepo, commit plumbing.Hash, opts git.PickOptions) (any, error),
+) {
+	l := x.logger(r, handler)
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
+	did, name, commit, branch := fields()
+	if did == "" || name == "" || branch == "" {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did, name and branch are required")))
+		return
+	}
+	if !plumbing.IsHash(commit) {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("commit must be a full hash")))
+		return
+	}
+
+	relativeRepoPath, err := securejoin.SecureJoin(did, name)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+	l = l.With("repo", relativeRepoPath, "commit", commit, "branch", branch)
+
+	if ok, err := x.Enforcer.IsPushAllowed(actorDid.String(), rbac.ThisServer, relativeRepoPath); !ok || err != nil {
+		l.Error("insufficient permissions", "did", actorDid.String())
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	repoPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, relativeRepoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	gr, err := git.PlainOpen(repoPath)
+	if err != nil {
+		writeError(w, xrpcerr.NotFoundError, http.StatusNotFound)
+		return
+	}
+	if _, err := gr.Branch(branch); err != nil {
+		writeError(w, xrpcerr.RefNotFoundError(branch), http.StatusNotFound)
+		return
+	}
+
+	out, err := op(gr, plumbing.NewHash(commit), git.PickOptions{
+		CommitterName:  x.Config.Git.UserName,
+		CommitterEmail: x.Config.Git.UserEmail,
+	})
+	if err != nil {
+		var mergeErr *git.ErrMerge
+		if errors.As(err, &mergeErr) {
+			l.Error("failed to apply", "error", mergeErr.Error())
+			files := make([]string, len(mergeErr.Conflicts))
+			for i, conflict := range mergeErr.Conflicts {
+				files[i] = conflict.Filename
+			}
+			writeError(w, xrpcerr.MergeConflictError(mergeErr.Message, files...), http.StatusConflict)
+			return
+		}
+		fail(xrpcerr.GitError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(out)
+}
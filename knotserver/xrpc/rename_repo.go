@@ -0,0 +1,109 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/hook"
+	"tangled.sh/tangled.sh/core/rbac"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// RenameRepo moves a repo to a new name under the same owner
+func (x *Xrpc) RenameRepo(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "RenameRepo")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	var data tangled.RepoRename_Input
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
+	if data.Did == "" || data.Name == "" || data.NewName == "" {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did, name and newName are required")))
+		return
+	}
+	if strings.ContainsAny(data.NewName, `/\`) || strings.HasPrefix(data.NewName, ".") {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("invalid name: %s", data.NewName)))
+		return
+	}
+
+	oldRepoPath := filepath.Join(data.Did, data.Name)
+	newRepoPath := filepath.Join(data.Did, data.NewName)
+	l = l.With("repo", oldRepoPath, "newName", data.NewName)
+
+	// same as a transfer, only the owner decides where the repo lives
+	if data.Did != actorDid.String() {
+		fail(xrpcerr.AccessControlError(actorDid.String()))
+		return
+	}
+	if ok, err := x.Enforcer.IsRepoDeleteAllowed(actorDid.String(), rbac.ThisServer, oldRepoPath); !ok || err != nil {
+		fail(xrpcerr.AccessControlError(actorDid.String()))
+		return
+	}
+
+	oldPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, oldRepoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+	newPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, newRepoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	if _, err := os.Stat(oldPath); err != nil {
+		writeError(w, xrpcerr.NotFoundError, http.StatusNotFound)
+		return
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		writeError(w, xrpcerr.RepoExistsError(newRepoPath), http.StatusConflict)
+		return
+	}
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		l.Error("moving repo", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := x.Db.RenameRepo(oldRepoPath, newRepoPath); err != nil {
+		l.Error("failed to rename repo stats", "error", err.Error())
+	}
+
+	err = x.Enforcer.TransferRepo(data.Did, data.Did, rbac.ThisServer, oldRepoPath, newRepoPath)
+	if err != nil {
+		l.Error("failed to rename repo in enforcer", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	// hooks carry the repo path
+	hook.SetupRepo(
+		hook.Config(
+			hook.WithScanPath(x.Config.Repo.ScanPath),
+			hook.WithInternalApi(x.Config.Server.InternalListenAddr),
+		),
+		newPath,
+	)
+
+	w.WriteHeader(http.StatusOK)
+}
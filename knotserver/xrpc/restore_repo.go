@@ -0,0 +1,107 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/rbac"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// RestoreRepo moves the most recently deleted repo by a name back out of the
+// trash, for the knot owner only
+func (x *Xrpc) RestoreRepo(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "RestoreRepo")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	if ok, err := x.Enforcer.IsKnotOwner(actorDid.String(), rbac.ThisServer); !ok || err != nil {
+		l.Error("insufficent permissions", "did", actorDid.String())
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	var data tangled.RepoRestore_Input
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
+	if data.Did == "" || data.Name == "" {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did and name are required")))
+		return
+	}
+
+	relativeRepoPath := filepath.Join(data.Did, data.Name)
+	l = l.With("repo", relativeRepoPath)
+
+	trash, err := x.Db.GetTrash()
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	// trash is sorted most recent first
+	var found bool
+	var trashed int
+	for i, t := range trash {
+		if t.Repo == relativeRepoPath {
+			found = true
+			trashed = i
+			break
+		}
+	}
+	if !found {
+		writeError(w, xrpcerr.NotFoundError, http.StatusNotFound)
+		return
+	}
+
+	repoPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, relativeRepoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	if _, err := os.Stat(repoPath); err == nil {
+		fail(xrpcerr.RepoExistsError(relativeRepoPath))
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(repoPath), 0755); err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	if err := os.Rename(trash[trashed].Path, repoPath); err != nil {
+		l.Error("restoring repo", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := x.Db.RemoveTrash(trash[trashed].Id); err != nil {
+		l.Error("failed to remove repo from trash", "error", err.Error())
+	}
+
+	// collaborators were dropped along with the repo, only its owner is back
+	if err := x.Enforcer.AddRepo(data.Did, rbac.ThisServer, relativeRepoPath); err != nil {
+		l.Error("failed to add repo to enforcer", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
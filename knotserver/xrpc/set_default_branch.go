@@ -19,7 +19,7 @@ import (
 const ActorDid string = "ActorDid"
 
 func (x *Xrpc) SetDefaultBranch(w http.ResponseWriter, r *http.Request) {
-	l := x.Logger
+	l := x.logger(r, "SetDefaultBranch")
 	fail := func(e xrpcerr.XrpcError) {
 		l.Error("failed", "kind", e.Tag, "error", e.Message)
 		writeError(w, e, http.StatusBadRequest)
@@ -33,7 +33,7 @@ func (x *Xrpc) SetDefaultBranch(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoSetDefaultBranch_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
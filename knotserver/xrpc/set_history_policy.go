@@ -0,0 +1,65 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+	"tangled.sh/tangled.sh/core/rbac"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// SetHistoryPolicy sets what commits landing on the default branch of a
+// repo have to look like. The receive hook and merges enforce it.
+func (x *Xrpc) SetHistoryPolicy(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "SetHistoryPolicy")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	var data tangled.RepoSetHistoryPolicy_Input
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
+	if data.Did == "" || data.Name == "" {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did and name are required")))
+		return
+	}
+
+	relativeRepoPath, err := securejoin.SecureJoin(data.Did, data.Name)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	if ok, err := x.Enforcer.IsSettingsAllowed(actorDid.String(), rbac.ThisServer, relativeRepoPath); !ok || err != nil {
+		l.Error("insufficient permissions", "did", actorDid.String(), "repo", relativeRepoPath)
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	policy := git.HistoryPolicy{
+		NoMergeCommits: data.NoMergeCommits != nil && *data.NoMergeCommits,
+		RequirePullRef: data.RequirePullRef != nil && *data.RequirePullRef,
+	}
+	if err := x.Db.SetHistoryPolicy(relativeRepoPath, policy); err != nil {
+		l.Error("failed to set history policy", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,199 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/hook"
+	"tangled.sh/tangled.sh/core/rbac"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+// TransferRepo offers a repo to a new owner, only its current owner may do
+// so. The repo stays where it is until the new owner accepts.
+func (x *Xrpc) TransferRepo(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "TransferRepo")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	var data tangled.RepoTransfer_Input
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
+	if data.Did == "" || data.Name == "" {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did and name are required")))
+		return
+	}
+
+	relativeRepoPath := filepath.Join(data.Did, data.Name)
+	l = l.With("repo", relativeRepoPath)
+
+	// collaborators may not give the repo away, nor may the knot owner
+	if data.Did != actorDid.String() {
+		fail(xrpcerr.AccessControlError(actorDid.String()))
+		return
+	}
+	if ok, err := x.Enforcer.IsRepoDeleteAllowed(actorDid.String(), rbac.ThisServer, relativeRepoPath); !ok || err != nil {
+		fail(xrpcerr.AccessControlError(actorDid.String()))
+		return
+	}
+
+	if data.NewOwner == nil || *data.NewOwner == "" {
+		if err := x.Db.RemoveTransfer(relativeRepoPath); err != nil {
+			fail(xrpcerr.GenericError(err))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	newOwner, err := syntax.ParseDID(*data.NewOwner)
+	if err != nil || newOwner.String() == data.Did {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("invalid new owner: %s", *data.NewOwner)))
+		return
+	}
+
+	if err := x.Db.SetTransfer(relativeRepoPath, newOwner.String()); err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// AcceptTransfer moves a repo offered to the caller under their did
+func (x *Xrpc) AcceptTransfer(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "AcceptTransfer")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	var data tangled.RepoAcceptTransfer_Input
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
+	if data.Did == "" || data.Name == "" {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("did and name are required")))
+		return
+	}
+
+	oldRepoPath := filepath.Join(data.Did, data.Name)
+	newRepoPath := filepath.Join(actorDid.String(), data.Name)
+	l = l.With("repo", oldRepoPath)
+
+	toDid, err := x.Db.GetTransfer(oldRepoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+	if toDid != actorDid.String() {
+		fail(xrpcerr.AccessControlError(actorDid.String()))
+		return
+	}
+
+	oldPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, oldRepoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+	newPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, newRepoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	if _, err := os.Stat(newPath); err == nil {
+		fail(xrpcerr.RepoExistsError(newRepoPath))
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		l.Error("moving repo", "error", err.Error())
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	// until the acls follow the repo, a failure puts it back where it was, and
+	// the transfer stays on offer to be accepted again
+	rollback := func() {
+		if err := os.Rename(newPath, oldPath); err != nil {
+			l.Error("failed to move repo back", "error", err.Error())
+		}
+	}
+
+	if err := x.Db.RenameRepo(oldRepoPath, newRepoPath); err != nil {
+		l.Error("failed to rename repo stats", "error", err.Error())
+		rollback()
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	err = x.Enforcer.TransferRepo(data.Did, actorDid.String(), rbac.ThisServer, oldRepoPath, newRepoPath)
+	if err != nil {
+		l.Error("failed to transfer repo in enforcer", "error", err.Error())
+		if err := x.Db.RenameRepo(newRepoPath, oldRepoPath); err != nil {
+			l.Error("failed to rename repo stats back", "error", err.Error())
+		}
+		rollback()
+		writeError(w, xrpcerr.GenericError(err), http.StatusInternalServerError)
+		return
+	}
+
+	// RenameRepo moved the transfer along with the rest
+	if err := x.Db.RemoveTransfer(newRepoPath); err != nil {
+		l.Error("failed to remove transfer", "error", err.Error())
+	}
+
+	hook.SetupRepo(
+		hook.Config(
+			hook.WithScanPath(x.Config.Repo.ScanPath),
+			hook.WithInternalApi(x.Config.Server.InternalListenAddr),
+		),
+		newPath,
+	)
+
+	// like on creation, the keys of a new owner from outside the knot are
+	// needed for them to push
+	isMember, err := x.Enforcer.IsKnotMember(actorDid.String(), rbac.ThisServer)
+	if err == nil && !isMember {
+		ident, err := x.Resolver.ResolveIdent(r.Context(), actorDid.String())
+		if err == nil {
+			err = x.addActor(r.Context(), actorDid.String(), ident.PDSEndpoint())
+		}
+		if err != nil {
+			l.Error("failed to add keys of new owner", "error", err)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
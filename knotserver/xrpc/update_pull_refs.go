@@ -0,0 +1,169 @@
+package xrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/knotserver/db"
+	"tangled.sh/tangled.sh/core/knotserver/git"
+	"tangled.sh/tangled.sh/core/patchutil"
+	"tangled.sh/tangled.sh/core/rbac"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+)
+
+func (x *Xrpc) UpdatePullRefs(w http.ResponseWriter, r *http.Request) {
+	l := x.logger(r, "UpdatePullRefs")
+	fail := func(e xrpcerr.XrpcError) {
+		l.Error("failed", "kind", e.Tag, "error", e.Message)
+		writeError(w, e, http.StatusBadRequest)
+	}
+
+	actorDid, ok := r.Context().Value(ActorDid).(syntax.DID)
+	if !ok {
+		fail(xrpcerr.MissingActorDidError)
+		return
+	}
+
+	var data tangled.RepoUpdatePullRefs_Input
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		fail(xrpcerr.InvalidRequestError(err))
+		return
+	}
+
+	if data.Pull == "" || data.PullId < 1 {
+		fail(xrpcerr.InvalidRequestError(fmt.Errorf("pull and pullId are required")))
+		return
+	}
+
+	pullAt, err := syntax.ParseATURI(data.Pull)
+	if err != nil || pullAt.Collection() != tangled.RepoPullNSID {
+		fail(xrpcerr.GenericError(fmt.Errorf("invalid pull uri: %s", data.Pull)))
+		return
+	}
+
+	// the pull record is the source of truth, so fetch it from the author's pds
+	author, err := x.Resolver.ResolveIdent(r.Context(), pullAt.Authority().String())
+	if err != nil || author.Handle.IsInvalidHandle() {
+		fail(xrpcerr.GenericError(fmt.Errorf("failed to resolve handle: %w", err)))
+		return
+	}
+
+	xrpcc := xrpc.Client{Host: author.PDSEndpoint()}
+	resp, err := comatproto.RepoGetRecord(r.Context(), &xrpcc, "", tangled.RepoPullNSID, author.DID.String(), pullAt.RecordKey().String())
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	pull, ok := resp.Value.Val.(*tangled.RepoPull)
+	if !ok || pull.Target == nil {
+		fail(xrpcerr.GenericError(fmt.Errorf("invalid pull record")))
+		return
+	}
+
+	repoAt, err := syntax.ParseATURI(pull.Target.Repo)
+	if err != nil {
+		fail(xrpcerr.InvalidRepoError(pull.Target.Repo))
+		return
+	}
+
+	owner, err := x.Resolver.ResolveIdent(r.Context(), repoAt.Authority().String())
+	if err != nil || owner.Handle.IsInvalidHandle() {
+		fail(xrpcerr.GenericError(fmt.Errorf("failed to resolve handle: %w", err)))
+		return
+	}
+
+	xrpcc = xrpc.Client{Host: owner.PDSEndpoint()}
+	resp, err = comatproto.RepoGetRecord(r.Context(), &xrpcc, "", tangled.RepoNSID, owner.DID.String(), repoAt.RecordKey().String())
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	repo, ok := resp.Value.Val.(*tangled.Repo)
+	if !ok || repo.Knot != x.Config.Server.Hostname {
+		fail(xrpcerr.InvalidRepoError(pull.Target.Repo))
+		return
+	}
+
+	didPath, err := securejoin.SecureJoin(owner.DID.String(), repo.Name)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	// pull authors may update their own pulls, collaborators may update any
+	canPush, _ := x.Enforcer.IsPushAllowed(actorDid.String(), rbac.ThisServer, didPath)
+	if actorDid != author.DID && !canPush {
+		l.Error("insufficient permissions", "did", actorDid.String(), "repo", didPath)
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	// pull ids are handed out by the appview, make sure that nobody but
+	// a collaborator can point an existing id at a different pull
+	existing, err := x.Db.GetPullRef(didPath, data.PullId)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+	if existing != nil && existing.PullAt != pullAt.String() && !canPush {
+		l.Error("pull id already taken", "did", actorDid.String(), "repo", didPath, "pullId", data.PullId)
+		writeError(w, xrpcerr.AccessControlError(actorDid.String()), http.StatusUnauthorized)
+		return
+	}
+
+	var sourceSha string
+	if pull.Source != nil {
+		sourceSha = pull.Source.Sha
+	}
+
+	err = x.Db.UpsertPullRef(db.PullRef{
+		Repo:         didPath,
+		PullId:       data.PullId,
+		PullAt:       pullAt.String(),
+		TargetBranch: pull.Target.Branch,
+		SourceSha:    sourceSha,
+		Patch:        pull.Patch,
+	})
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	repoPath, err := securejoin.SecureJoin(x.Config.Repo.ScanPath, didPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(err))
+		return
+	}
+
+	gr, err := git.PlainOpen(repoPath)
+	if err != nil {
+		fail(xrpcerr.GenericError(fmt.Errorf("failed to open repository: %w", err)))
+		return
+	}
+
+	mo := git.MergeOptions{
+		CommitMessage:  fmt.Sprintf("pull #%d", data.PullId),
+		AuthorName:     x.Config.Git.UserName,
+		AuthorEmail:    x.Config.Git.UserEmail,
+		CommitterName:  x.Config.Git.UserName,
+		CommitterEmail: x.Config.Git.UserEmail,
+		FormatPatch:    patchutil.IsFormatPatch(pull.Patch),
+	}
+
+	err = gr.SetPullRefs(data.PullId, []byte(pull.Patch), pull.Target.Branch, sourceSha, mo)
+	if err != nil {
+		l.Error("failed to set pull refs", "error", err.Error())
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
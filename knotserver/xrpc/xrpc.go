@@ -10,6 +10,7 @@ import (
 	"tangled.sh/tangled.sh/core/jetstream"
 	"tangled.sh/tangled.sh/core/knotserver/config"
 	"tangled.sh/tangled.sh/core/knotserver/db"
+	tlog "tangled.sh/tangled.sh/core/log"
 	"tangled.sh/tangled.sh/core/notifier"
 	"tangled.sh/tangled.sh/core/rbac"
 	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
@@ -38,10 +39,23 @@ func (x *Xrpc) Router() http.Handler {
 		r.Post("/"+tangled.RepoSetDefaultBranchNSID, x.SetDefaultBranch)
 		r.Post("/"+tangled.RepoCreateNSID, x.CreateRepo)
 		r.Post("/"+tangled.RepoDeleteNSID, x.DeleteRepo)
+		r.Post("/"+tangled.RepoRestoreNSID, x.RestoreRepo)
+		r.Post("/"+tangled.RepoTransferNSID, x.TransferRepo)
+		r.Post("/"+tangled.RepoAcceptTransferNSID, x.AcceptTransfer)
+		r.Post("/"+tangled.RepoRenameNSID, x.RenameRepo)
+		r.Post("/"+tangled.RepoInitializeNSID, x.InitializeRepo)
 		r.Post("/"+tangled.RepoForkStatusNSID, x.ForkStatus)
 		r.Post("/"+tangled.RepoForkSyncNSID, x.ForkSync)
 		r.Post("/"+tangled.RepoHiddenRefNSID, x.HiddenRef)
 		r.Post("/"+tangled.RepoMergeNSID, x.Merge)
+		r.Post("/"+tangled.RepoRevertNSID, x.Revert)
+		r.Post("/"+tangled.RepoCherryPickNSID, x.CherryPick)
+		r.Post("/"+tangled.RepoUpdatePullRefsNSID, x.UpdatePullRefs)
+		r.Post("/"+tangled.RepoSetHistoryPolicyNSID, x.SetHistoryPolicy)
+		r.Get("/"+tangled.KnotUsageNSID, x.KnotUsage)
+		r.Get("/"+tangled.KnotTrashNSID, x.KnotTrash)
+		r.Get("/"+tangled.KnotListReposNSID, x.KnotListRepos)
+		r.Post("/"+tangled.KnotAdministerReposNSID, x.KnotAdministerRepos)
 	})
 
 	// merge check is an open endpoint
@@ -53,6 +67,12 @@ func (x *Xrpc) Router() http.Handler {
 	return r
 }
 
+// logger is the logger of a handler, tagged with the id the appview gave the
+// request
+func (x *Xrpc) logger(r *http.Request, handler string) *slog.Logger {
+	return x.Logger.With("handler", handler, "request_id", tlog.RequestId(r.Context()))
+}
+
 func writeError(w http.ResponseWriter, e xrpcerr.XrpcError, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
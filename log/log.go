@@ -15,8 +15,7 @@ func NewHandler(name string) slog.Handler {
 
 	var attrs []slog.Attr
 	attrs = append(attrs, slog.Attr{Key: "service", Value: slog.StringValue(name)})
-	handler.WithAttrs(attrs)
-	return handler
+	return NewRequestIdHandler(handler.WithAttrs(attrs))
 }
 
 func New(name string) *slog.Logger {
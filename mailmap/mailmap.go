@@ -0,0 +1,125 @@
+// Package mailmap parses git's .mailmap files, see gitmailmap(5).
+package mailmap
+
+import (
+	"bufio"
+	"strings"
+)
+
+type Entry struct {
+	ProperName  string
+	ProperEmail string
+	CommitName  string
+	CommitEmail string
+}
+
+type Mailmap struct {
+	entries []Entry
+}
+
+// Parse reads a .mailmap file. Lines that cannot be parsed are skipped, as
+// git does.
+func Parse(data string) *Mailmap {
+	m := &Mailmap{}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		if e, ok := parseLine(line); ok {
+			m.entries = append(m.entries, e)
+		}
+	}
+
+	return m
+}
+
+// parseLine handles each of the forms:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func parseLine(line string) (Entry, bool) {
+	var names, emails []string
+
+	rest := line
+	for {
+		open := strings.IndexByte(rest, '<')
+		if open < 0 {
+			break
+		}
+		close := strings.IndexByte(rest[open:], '>')
+		if close < 0 {
+			return Entry{}, false
+		}
+		close += open
+
+		names = append(names, strings.TrimSpace(rest[:open]))
+		emails = append(emails, strings.TrimSpace(rest[open+1:close]))
+		rest = rest[close+1:]
+	}
+
+	var e Entry
+	switch len(emails) {
+	case 1:
+		e.ProperName = names[0]
+		e.CommitEmail = emails[0]
+	case 2:
+		e.ProperName = names[0]
+		e.ProperEmail = emails[0]
+		e.CommitName = names[1]
+		e.CommitEmail = emails[1]
+	default:
+		return Entry{}, false
+	}
+
+	if e.CommitEmail == "" || (e.ProperName == "" && e.ProperEmail == "") {
+		return Entry{}, false
+	}
+
+	return e, true
+}
+
+// Resolve returns the canonical name and email for a commit identity. Emails
+// are compared case-insensitively and names case-sensitively. Entries that
+// also match on the commit name take precedence, otherwise later entries
+// override earlier ones.
+func (m *Mailmap) Resolve(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+
+	var byEmail, byName *Entry
+	for i := range m.entries {
+		e := &m.entries[i]
+		if !strings.EqualFold(e.CommitEmail, email) {
+			continue
+		}
+		if e.CommitName == "" {
+			byEmail = e
+		} else if e.CommitName == name {
+			byName = e
+		}
+	}
+
+	match := byName
+	if match == nil {
+		match = byEmail
+	}
+	if match == nil {
+		return name, email
+	}
+
+	if match.ProperName != "" {
+		name = match.ProperName
+	}
+	if match.ProperEmail != "" {
+		email = match.ProperEmail
+	}
+
+	return name, email
+}
@@ -0,0 +1,34 @@
+package mailmap
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	m := Parse(`
+# comments and blank lines are ignored
+
+Jane Doe <jane@old.example>
+<jane@example.com> <jane@laptop.local>
+Joe Bloggs <joe@example.com> <JOE@work.example>
+Joe Bloggs <joe@example.com> bot <ci@example.com>
+not an entry
+`)
+
+	tests := []struct {
+		name, email         string
+		wantName, wantEmail string
+	}{
+		{"jd", "jane@old.example", "Jane Doe", "jane@old.example"},
+		{"Jane", "jane@laptop.local", "Jane", "jane@example.com"},
+		{"joe", "joe@work.example", "Joe Bloggs", "joe@example.com"},
+		{"bot", "ci@example.com", "Joe Bloggs", "joe@example.com"},
+		{"someone", "ci@example.com", "someone", "ci@example.com"},
+		{"x", "x@example.com", "x", "x@example.com"},
+	}
+
+	for _, tt := range tests {
+		name, email := m.Resolve(tt.name, tt.email)
+		if name != tt.wantName || email != tt.wantEmail {
+			t.Errorf("Resolve(%q, %q) = %q, %q; want %q, %q", tt.name, tt.email, name, email, tt.wantName, tt.wantEmail)
+		}
+	}
+}
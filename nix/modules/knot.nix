@@ -123,6 +123,7 @@ in
     config = mkIf cfg.enable {
       environment.systemPackages = [
         pkgs.git
+        pkgs.xz
         cfg.package
       ];
 
@@ -153,6 +154,7 @@ in
             -output authorized-keys \
             -internal-api "http://${cfg.server.internalListenAddr}" \
             -git-dir "${cfg.repo.scanPath}" \
+            -acl-snapshot "${cfg.stateDir}/acl-snapshot.json" \
             -log-path /tmp/knotguard.log
         '';
       };
@@ -194,12 +196,14 @@ in
           Environment = [
             "KNOT_REPO_SCAN_PATH=${cfg.repo.scanPath}"
             "KNOT_REPO_MAIN_BRANCH=${cfg.repo.mainBranch}"
+            "KNOT_REPO_TRASH_PATH=${cfg.stateDir}/.trash"
             "APPVIEW_ENDPOINT=${cfg.appviewEndpoint}"
             "KNOT_SERVER_INTERNAL_LISTEN_ADDR=${cfg.server.internalListenAddr}"
             "KNOT_SERVER_LISTEN_ADDR=${cfg.server.listenAddr}"
             "KNOT_SERVER_DB_PATH=${cfg.server.dbPath}"
             "KNOT_SERVER_HOSTNAME=${cfg.server.hostname}"
             "KNOT_SERVER_OWNER=${cfg.server.owner}"
+            "KNOT_SERVER_ACL_SNAPSHOT_PATH=${cfg.stateDir}/acl-snapshot.json"
           ];
           ExecStart = "${cfg.package}/bin/knot server";
           Restart = "always";
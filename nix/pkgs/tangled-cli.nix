@@ -0,0 +1,15 @@
+{
+  buildGoApplication,
+  modules,
+  src,
+}:
+buildGoApplication {
+  pname = "tangled";
+  version = "0.1.0";
+  inherit src modules;
+
+  doCheck = false;
+
+  subPackages = ["cmd/tangled" "cmd/git-remote-tangled"];
+  CGO_ENABLED = 0;
+}
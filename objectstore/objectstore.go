@@ -0,0 +1,89 @@
+// Package objectstore keeps blobs like release artifacts, attachments and
+// archives out of the databases and repositories, on local disk or in an
+// S3-compatible bucket, and hands out signed urls to download them from
+// directly.
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+type Object struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// URLOptions are the headers a signed url is served with, so that a
+// download keeps its name and cannot pass for a page of the origin
+type URLOptions struct {
+	ContentType        string
+	ContentDisposition string
+}
+
+type Store interface {
+	// Put stores size bytes of r under key, replacing what was there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (Object, error)
+	Delete(ctx context.Context, key string) error
+
+	// SignedURL is a url the object can be downloaded from without further
+	// authentication, until it expires
+	SignedURL(ctx context.Context, key string, opts URLOptions) (string, error)
+}
+
+// Config picks and sets up a backend, embedded in the configs of the
+// appview and the knot under a prefix of their own
+type Config struct {
+	// local or s3, blobs are not kept anywhere when empty
+	Backend string `env:"BACKEND"`
+
+	// signed urls are valid for this long
+	UrlExpiry time.Duration `env:"URL_EXPIRY, default=1h"`
+
+	// directory of the local backend, and the key its urls are signed with
+	Path   string `env:"PATH"`
+	Secret string `env:"SECRET"`
+
+	// the s3 backend; buckets are addressed as a subdomain of the endpoint
+	// unless PathStyle is set, as most self-hosted stores need
+	Endpoint  string `env:"ENDPOINT, default=https://s3.amazonaws.com"`
+	Region    string `env:"REGION, default=us-east-1"`
+	Bucket    string `env:"BUCKET"`
+	AccessKey string `env:"ACCESS_KEY"`
+	SecretKey string `env:"SECRET_KEY"`
+	PathStyle bool   `env:"PATH_STYLE, default=false"`
+}
+
+// Open sets up the configured backend, nil if there is none. Signed urls of
+// the local backend point to baseUrl, where the caller serves the store.
+func Open(cfg Config, baseUrl string) (Store, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case BackendLocal:
+		if cfg.Path == "" || cfg.Secret == "" {
+			return nil, fmt.Errorf("objectstore: the local backend needs a path and a secret")
+		}
+		return NewLocal(cfg.Path, baseUrl, []byte(cfg.Secret), cfg.UrlExpiry)
+	case BackendS3:
+		if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+			return nil, fmt.Errorf("objectstore: the s3 backend needs a bucket and credentials")
+		}
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("objectstore: unknown backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,115 @@
+package patchutil
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+var (
+	mailHeaderLine = regexp.MustCompile(`^[A-Za-z0-9-]+: `)
+	escapedFrom    = regexp.MustCompile(`(?m)^>(>*From )`)
+	coverLetter    = regexp.MustCompile(`\b0+/\d+\]`)
+	commitSha      = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// Normalize takes a patch in any of the forms people paste, a unified diff,
+// the output of git format-patch, or an mbox of patch mails such as a
+// mailing list archive, and returns it as a plain diff or a format-patch
+// series. The errors returned describe what is wrong with the patch.
+func Normalize(patch string) (string, error) {
+	patch = strings.ReplaceAll(patch, "\r\n", "\n")
+	patch = strings.TrimPrefix(patch, "\ufeff")
+	patch = strings.TrimLeft(patch, " \t\n")
+	if patch == "" {
+		return "", errors.New("the patch is empty")
+	}
+	if !strings.HasSuffix(patch, "\n") {
+		patch += "\n"
+	}
+
+	if strings.HasPrefix(patch, "From ") || strings.HasPrefix(patch, "From: ") {
+		return normalizeMbox(patch)
+	}
+	return normalizeDiff(patch)
+}
+
+func normalizeDiff(patch string) (string, error) {
+	files, _, err := gitdiff.Parse(strings.NewReader(patch))
+	if err != nil {
+		return "", fmt.Errorf("not a valid diff: %w", err)
+	}
+	if len(files) == 0 {
+		return "", errors.New("no changes found, paste a git diff, a git format-patch series or an mbox")
+	}
+	return patch, nil
+}
+
+// normalizeMbox splits an mbox into its mails, drops cover letters, and
+// writes each mail out the way git format-patch would
+func normalizeMbox(mbox string) (string, error) {
+	var mails []string
+	lines := strings.SplitAfter(mbox, "\n")
+	start := 0
+	for i := 1; i < len(lines)-1; i++ {
+		if strings.HasPrefix(lines[i], "From ") && mailHeaderLine.MatchString(lines[i+1]) {
+			mails = append(mails, strings.Join(lines[start:i], ""))
+			start = i
+		}
+	}
+	mails = append(mails, strings.Join(lines[start:], ""))
+
+	var b strings.Builder
+	for i, mail := range mails {
+		// the first line separates mails, unless the mail starts right away
+		var separator string
+		if !strings.HasPrefix(mail, "From: ") {
+			separator, mail, _ = strings.Cut(mail, "\n")
+		}
+		mail = escapedFrom.ReplaceAllString(mail, "$1")
+
+		files, preamble, err := gitdiff.Parse(strings.NewReader(mail))
+		if err != nil {
+			return "", fmt.Errorf("patch %d is not valid: %w", i+1, err)
+		}
+		header, err := gitdiff.ParsePatchHeader(preamble)
+		if err != nil {
+			return "", fmt.Errorf("patch %d has an invalid header: %w", i+1, err)
+		}
+		if len(files) == 0 {
+			if coverLetter.MatchString(header.SubjectPrefix) {
+				continue
+			}
+			return "", fmt.Errorf("patch %d, %q, has no changes", i+1, header.Title)
+		}
+
+		// mails from an archive are not separated by their commit, but
+		// something has to tell them apart
+		sha, _, _ := strings.Cut(strings.TrimPrefix(separator, "From "), " ")
+		if !commitSha.MatchString(sha) {
+			sum := sha1.Sum([]byte(mail))
+			sha = hex.EncodeToString(sum[:])
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "From %s Mon Sep 17 00:00:00 2001\n", sha)
+		b.WriteString(strings.TrimRight(mail, "\n") + "\n")
+	}
+
+	if b.Len() == 0 {
+		return "", errors.New("no patches found")
+	}
+
+	normalized := b.String()
+	if _, err := ExtractPatches(normalized); err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
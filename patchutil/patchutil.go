@@ -78,6 +78,39 @@ func IsPatchValid(patch string) bool {
 	return false
 }
 
+// Rewrites reports whether newPatch rewrote the history of oldPatch, that is
+// whether a commit of oldPatch is missing from newPatch. Plain diffs carry no
+// history, so they never rewrite it.
+func Rewrites(oldPatch, newPatch string) bool {
+	if !IsFormatPatch(oldPatch) || !IsFormatPatch(newPatch) {
+		return false
+	}
+
+	oldPatches, err := ExtractPatches(oldPatch)
+	if err != nil {
+		return false
+	}
+	newPatches, err := ExtractPatches(newPatch)
+	if err != nil {
+		return false
+	}
+
+	commits := make(map[string]struct{})
+	for _, p := range newPatches {
+		commits[p.SHA] = struct{}{}
+	}
+	for _, p := range oldPatches {
+		if p.SHA == "" {
+			continue
+		}
+		if _, ok := commits[p.SHA]; !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 func IsFormatPatch(patch string) bool {
 	lines := strings.Split(patch, "\n")
 	if len(lines) < 2 {
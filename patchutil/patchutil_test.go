@@ -2,6 +2,7 @@ package patchutil
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -322,3 +323,251 @@ content
 		})
 	}
 }
+
+func TestRewrites(t *testing.T) {
+	commit := func(sha, subject string) string {
+		return `From ` + sha + ` Mon Sep 17 00:00:00 2001
+From: Author <author@example.com>
+Date: Wed, 16 Apr 2025 11:01:00 +0300
+Subject: [PATCH] ` + subject + `
+
+diff --git a/file.txt b/file.txt
+index 123456..789012 100644
+--- a/file.txt
++++ b/file.txt
+@@ -1 +1 @@
+-old content
++` + subject + `
+--
+2.48.1
+`
+	}
+	first := commit("3c5035488318164b81f60fe3adcd6c9199d76331", "first")
+	second := commit("a9529f3b3a653329a5268f0f4067225480207e3c", "second")
+	amended := commit("5e1b3a6c0f1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f", "first, amended")
+	diff := "diff --git a/file.txt b/file.txt\n--- a/file.txt\n+++ b/file.txt\n@@ -1 +1 @@\n-old\n+new\n"
+
+	tests := []struct {
+		name     string
+		old, new string
+		expected bool
+	}{
+		{"unchanged", first, first, false},
+		{"new commit on top", first, first + second, false},
+		{"amended commit", first, amended, true},
+		{"dropped commit", first + second, second, true},
+		{"plain diffs", diff, diff, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Rewrites(tt.old, tt.new); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	patch := `diff --git a/a.txt b/a.txt
+index 0000000..1111111 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,4 @@
+ one
++two
+ three
+ four
+@@ -8,3 +9,3 @@
+ eight
+-nine
++NINE
+ ten
+\ No newline at end of file
+`
+	suggestions := []Suggestion{
+		{Path: "a.txt", Line: 2, Lines: []string{"2", "2b"}},
+		{Path: "a.txt", Line: 3},
+		{Path: "a.txt", Line: 11, Lines: []string{"TEN"}},
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		lines, ok := ParseSuggestion("looks off\n```suggestion\nfoo\n  bar\n```\nthanks")
+		if !ok || !reflect.DeepEqual(lines, []string{"foo", "  bar"}) {
+			t.Errorf("unexpected suggestion %q", lines)
+		}
+		if lines, ok := ParseSuggestion("```suggestion\n```"); !ok || len(lines) != 0 {
+			t.Errorf("expected an empty suggestion, got %q", lines)
+		}
+		if _, ok := ParseSuggestion("```go\nfoo\n```"); ok {
+			t.Error("expected no suggestion")
+		}
+	})
+
+	t.Run("on top", func(t *testing.T) {
+		got, err := SuggestionPatch(patch, suggestions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,4 +1,4 @@
+ one
+-two
++2
++2b
+-three
+ four
+@@ -9,3 +9,3 @@
+ eight
+ NINE
+-ten
+\ No newline at end of file
++TEN
+\ No newline at end of file
+`
+		if got != expected {
+			t.Errorf("unexpected patch:\n%s", got)
+		}
+	})
+
+	t.Run("rewritten", func(t *testing.T) {
+		got, err := ApplySuggestions(patch, suggestions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `diff --git a/a.txt b/a.txt
+index 0000000..1111111 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,4 @@
+ one
++2
++2b
+-three
+ four
+@@ -8,3 +9,3 @@
+ eight
+-nine
++NINE
+-ten
+\ No newline at end of file
++TEN
+\ No newline at end of file
+`
+		if got != expected {
+			t.Errorf("unexpected patch:\n%s", got)
+		}
+	})
+
+	t.Run("outside the patch", func(t *testing.T) {
+		outside := []Suggestion{{Path: "a.txt", Line: 6, Lines: []string{"six"}}}
+		if _, err := SuggestionPatch(patch, outside); err == nil {
+			t.Error("expected an error")
+		}
+		other := []Suggestion{{Path: "b.txt", Line: 1}}
+		if _, err := ApplySuggestions(patch, other); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	diff := "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n"
+	mail := func(subject, body, diff string) string {
+		return "From alice@example.org Mon Jan  1 00:00:00 2024\n" +
+			"From: Alice <alice@example.org>\n" +
+			"Date: Mon, 1 Jan 2024 10:00:00 +0000\n" +
+			"Subject: " + subject + "\n" +
+			"Message-Id: <" + subject + "@example.org>\n\n" +
+			body + "\n---\n" + diff
+	}
+	archive := mail("[PATCH 0/1] fix a", "cover letter", "") + "\n" +
+		mail("[PATCH 1/1] fix a", ">From the top", diff)
+
+	t.Run("diffs", func(t *testing.T) {
+		got, err := Normalize("\r\n  " + strings.ReplaceAll(strings.TrimSuffix(diff, "\n"), "\n", "\r\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != diff {
+			t.Errorf("expected %q, got %q", diff, got)
+		}
+	})
+
+	t.Run("mbox", func(t *testing.T) {
+		got, err := Normalize(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsFormatPatch(got) {
+			t.Fatalf("expected a format-patch, got:\n%s", got)
+		}
+
+		patches, err := ExtractPatches(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(patches) != 1 {
+			t.Fatalf("expected the cover letter to be dropped, got %d patches", len(patches))
+		}
+		if patches[0].Title != "fix a" || patches[0].Body != "From the top" || len(patches[0].SHA) != 40 {
+			t.Errorf("unexpected patch %q %q %q", patches[0].SHA, patches[0].Title, patches[0].Body)
+		}
+
+		again, err := Normalize(got)
+		if err != nil || again != got {
+			t.Errorf("expected normalizing to be idempotent, got %v:\n%s", err, again)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, patch := range []string{
+			"",
+			"hello\nworld\n",
+			mail("[PATCH] nothing", "no changes here", ""),
+		} {
+			if _, err := Normalize(patch); err == nil {
+				t.Errorf("expected an error for %q", patch)
+			}
+		}
+	})
+}
+
+func TestUnsignedCommits(t *testing.T) {
+	commit := func(sha, from, subject, body string) string {
+		return "From " + sha + " Mon Sep 17 00:00:00 2001\n" +
+			"From: " + from + "\n" +
+			"Date: Mon, 1 Jan 2024 10:00:00 +0000\n" +
+			"Subject: [PATCH] " + subject + "\n\n" +
+			body + "\n---\n" +
+			"diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n"
+	}
+	shaA := strings.Repeat("a", 40)
+	shaB := strings.Repeat("b", 40)
+	shaC := strings.Repeat("c", 40)
+
+	series := commit(shaA, "Alice <alice@example.org>", "signed", "Fix a.\n\nSigned-off-by: Alice <Alice@example.org>") + "\n" +
+		commit(shaB, "Alice <alice@example.org>", "signed by someone else", "Signed-off-by: Bob <bob@example.org>") + "\n" +
+		commit(shaC, "Alice <alice@example.org>", "not signed", "Fix a again.")
+
+	unsigned, err := UnsignedCommits(series)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shas []string
+	for _, u := range unsigned {
+		shas = append(shas, u.Sha)
+	}
+	if !reflect.DeepEqual(shas, []string{shaB, shaC}) {
+		t.Errorf("expected %v to be unsigned, got %v", []string{shaB, shaC}, shas)
+	}
+	if unsigned[0].AuthorEmail != "alice@example.org" {
+		t.Errorf("unexpected author %q", unsigned[0].AuthorEmail)
+	}
+
+	if _, err := UnsignedCommits("diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-a\n+A\n"); err != ErrNoCommits {
+		t.Errorf("expected ErrNoCommits for a plain diff, got %v", err)
+	}
+}
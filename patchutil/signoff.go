@@ -0,0 +1,60 @@
+package patchutil
+
+import (
+	"errors"
+	"strings"
+
+	"tangled.sh/tangled.sh/core/trailer"
+)
+
+// ErrNoCommits is returned when checking a plain diff, which has no commit
+// messages to carry a sign-off
+var ErrNoCommits = errors.New("a plain diff has no commits to sign off")
+
+// UnsignedCommit is a commit without a sign-off from its author
+type UnsignedCommit struct {
+	Sha         string
+	Title       string
+	AuthorName  string
+	AuthorEmail string
+}
+
+// UnsignedCommits returns the commits of a format-patch series that carry no
+// Signed-off-by trailer with the email of their author, the way git commit
+// --signoff writes it, certifying the Developer Certificate of Origin.
+func UnsignedCommits(patch string) ([]UnsignedCommit, error) {
+	if !IsFormatPatch(patch) {
+		return nil, ErrNoCommits
+	}
+
+	patches, err := ExtractPatches(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var unsigned []UnsignedCommit
+	for _, p := range patches {
+		var name, email string
+		if p.Author != nil {
+			name, email = p.Author.Name, p.Author.Email
+		}
+
+		signed := false
+		for _, t := range trailer.Filter(trailer.Parse(p.Title+"\n\n"+p.Body), trailer.SignedOffBy) {
+			if email != "" && strings.EqualFold(t.Email, email) {
+				signed = true
+			}
+		}
+
+		if !signed {
+			unsigned = append(unsigned, UnsignedCommit{
+				Sha:         p.SHA,
+				Title:       p.Title,
+				AuthorName:  name,
+				AuthorEmail: email,
+			})
+		}
+	}
+
+	return unsigned, nil
+}
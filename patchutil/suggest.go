@@ -0,0 +1,239 @@
+package patchutil
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+// Suggestion replaces a line of a file, as it is after a patch, with Lines.
+// No lines removes it.
+type Suggestion struct {
+	Path  string
+	Line  int64
+	Lines []string
+}
+
+var suggestionBlock = regexp.MustCompile("(?ms)^```suggestion[ \t]*\r?\n(.*?)^```[ \t]*$")
+
+// ParseSuggestion finds the first suggestion block in a comment, the lines
+// it suggests are returned without their newlines
+func ParseSuggestion(body string) ([]string, bool) {
+	m := suggestionBlock.FindStringSubmatch(body)
+	if m == nil {
+		return nil, false
+	}
+
+	content := strings.ReplaceAll(m[1], "\r\n", "\n")
+	if content == "" {
+		return []string{}, true
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n"), true
+}
+
+// SuggestionPatch turns suggestions into a diff that applies on top of
+// patch. Each suggestion has to be on a line that patch shows, added or as
+// context, as the surrounding lines of the hunk are used as its context.
+func SuggestionPatch(patch string, suggestions []Suggestion) (string, error) {
+	files, err := AsDiff(patch)
+	if err != nil {
+		return "", err
+	}
+
+	byPath, err := groupSuggestions(suggestions)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, file := range files {
+		fileSuggestions, ok := byPath[file.NewName]
+		if !ok || file.IsDelete {
+			continue
+		}
+		delete(byPath, file.NewName)
+
+		out := &gitdiff.File{OldName: file.NewName, NewName: file.NewName}
+		var shift int64
+		for _, frag := range file.TextFragments {
+			// the hunk as it is after patch, all context
+			var lines []gitdiff.Line
+			for _, l := range frag.Lines {
+				if l.Op != gitdiff.OpDelete {
+					lines = append(lines, gitdiff.Line{Op: gitdiff.OpContext, Line: l.Line})
+				}
+			}
+
+			hunk := &gitdiff.TextFragment{
+				OldPosition: frag.NewPosition,
+				NewPosition: frag.NewPosition + shift,
+				Lines:       lines,
+			}
+			applied, err := replaceLines(hunk, fileSuggestions)
+			if err != nil {
+				return "", err
+			}
+			if applied == 0 {
+				continue
+			}
+			shift += hunk.NewLines - hunk.OldLines
+			out.TextFragments = append(out.TextFragments, hunk)
+		}
+
+		if err := unappliedSuggestion(fileSuggestions); err != nil {
+			return "", err
+		}
+		b.WriteString(out.String())
+	}
+
+	for path := range byPath {
+		return "", fmt.Errorf("%s is not changed by this patch", path)
+	}
+
+	return b.String(), nil
+}
+
+// ApplySuggestions rewrites a plain diff so that it makes the suggested
+// changes as well
+func ApplySuggestions(patch string, suggestions []Suggestion) (string, error) {
+	if IsFormatPatch(patch) {
+		return "", fmt.Errorf("format-patches are appended to instead")
+	}
+
+	files, _, err := gitdiff.Parse(strings.NewReader(patch))
+	if err != nil {
+		return "", err
+	}
+
+	byPath, err := groupSuggestions(suggestions)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, file := range files {
+		if fileSuggestions, ok := byPath[file.NewName]; ok && !file.IsDelete {
+			delete(byPath, file.NewName)
+
+			var shift int64
+			for _, frag := range file.TextFragments {
+				frag.NewPosition += shift
+				before := frag.NewLines
+				if _, err := replaceLines(frag, fileSuggestions); err != nil {
+					return "", err
+				}
+				shift += frag.NewLines - before
+			}
+
+			if err := unappliedSuggestion(fileSuggestions); err != nil {
+				return "", err
+			}
+		}
+		b.WriteString(file.String())
+	}
+
+	for path := range byPath {
+		return "", fmt.Errorf("%s is not changed by this patch", path)
+	}
+
+	return b.String(), nil
+}
+
+type pendingSuggestion struct {
+	Suggestion
+	applied bool
+}
+
+func groupSuggestions(suggestions []Suggestion) (map[string][]*pendingSuggestion, error) {
+	byPath := make(map[string][]*pendingSuggestion)
+	for _, s := range suggestions {
+		for _, other := range byPath[s.Path] {
+			if other.Line == s.Line {
+				return nil, fmt.Errorf("more than one suggestion for line %d of %s", s.Line, s.Path)
+			}
+		}
+		byPath[s.Path] = append(byPath[s.Path], &pendingSuggestion{Suggestion: s})
+	}
+	return byPath, nil
+}
+
+func unappliedSuggestion(suggestions []*pendingSuggestion) error {
+	for _, s := range suggestions {
+		if !s.applied {
+			return fmt.Errorf("line %d of %s is not part of this patch", s.Line, s.Path)
+		}
+	}
+	return nil
+}
+
+// replaceLines makes the suggestions that fall on the new side of frag, and
+// recounts it. A context line that is replaced becomes a deletion.
+func replaceLines(frag *gitdiff.TextFragment, suggestions []*pendingSuggestion) (int, error) {
+	var applied int
+	var lines []gitdiff.Line
+	position := frag.NewPosition
+	for i, l := range frag.Lines {
+		if l.Op == gitdiff.OpDelete {
+			lines = append(lines, l)
+			continue
+		}
+
+		idx := slices.IndexFunc(suggestions, func(s *pendingSuggestion) bool {
+			return !s.applied && s.Line == position
+		})
+		position += 1
+		if idx < 0 {
+			lines = append(lines, l)
+			continue
+		}
+		s := suggestions[idx]
+		s.applied = true
+		applied += 1
+
+		if l.Op == gitdiff.OpContext {
+			lines = append(lines, gitdiff.Line{Op: gitdiff.OpDelete, Line: l.Line})
+		}
+		// the last line of a file may have no newline, keep it that way
+		noNewline := i == len(frag.Lines)-1 && !strings.HasSuffix(l.Line, "\n")
+		for j, content := range s.Lines {
+			if !(noNewline && j == len(s.Lines)-1) {
+				content += "\n"
+			}
+			lines = append(lines, gitdiff.Line{Op: gitdiff.OpAdd, Line: content})
+		}
+	}
+
+	frag.Lines = lines
+	frag.OldLines, frag.NewLines = 0, 0
+	frag.LinesAdded, frag.LinesDeleted = 0, 0
+	for _, l := range lines {
+		switch l.Op {
+		case gitdiff.OpContext:
+			frag.OldLines += 1
+			frag.NewLines += 1
+		case gitdiff.OpAdd:
+			frag.NewLines += 1
+			frag.LinesAdded += 1
+		case gitdiff.OpDelete:
+			frag.OldLines += 1
+			frag.LinesDeleted += 1
+		}
+	}
+
+	// leading and trailing context are only used when applying
+	frag.LeadingContext, frag.TrailingContext = 0, 0
+	for _, l := range lines {
+		if l.Op != gitdiff.OpContext {
+			break
+		}
+		frag.LeadingContext += 1
+	}
+	for i := len(lines) - 1; i >= 0 && lines[i].Op == gitdiff.OpContext; i-- {
+		frag.TrailingContext += 1
+	}
+
+	return applied, nil
+}
@@ -12,8 +12,37 @@ import (
 
 const (
 	ThisServer = "thisserver" // resource identifier for local rbac enforcement
+	Anyone     = "*"          // policy subject that matches every user
 )
 
+// RepoCreatePolicy is who may create repos on a knot
+type RepoCreatePolicy string
+
+const (
+	RepoCreateOwner  RepoCreatePolicy = "owner"
+	RepoCreateMember RepoCreatePolicy = "member"
+	RepoCreateAnyone RepoCreatePolicy = "anyone"
+)
+
+func (p RepoCreatePolicy) IsValid() bool {
+	switch p {
+	case RepoCreateOwner, RepoCreateMember, RepoCreateAnyone:
+		return true
+	}
+	return false
+}
+
+func (p RepoCreatePolicy) subject() string {
+	switch p {
+	case RepoCreateOwner:
+		return "server:owner"
+	case RepoCreateAnyone:
+		return Anyone
+	default:
+		return "server:member"
+	}
+}
+
 const (
 	Model = `
 [request_definition]
@@ -29,7 +58,7 @@ g = _, _, _
 e = some(where (p.eft == allow))
 
 [matchers]
-m = r.act == p.act && r.dom == p.dom && r.obj == p.obj && g(r.sub, p.sub, r.dom)
+m = r.act == p.act && r.dom == p.dom && r.obj == p.obj && (p.sub == "*" || g(r.sub, p.sub, r.dom))
 `
 )
 
@@ -67,17 +96,102 @@ func (e *Enforcer) AddKnot(knot string) error {
 	// Add policies with patterns
 	_, err := e.E.AddPolicies([][]string{
 		{"server:owner", knot, knot, "server:invite"},
-		{"server:member", knot, knot, "repo:create"},
 	})
 	if err != nil {
 		return err
 	}
 
+	// members may create repos, unless the knot has set a policy already
+	existing, err := e.E.GetFilteredPolicy(1, knot, knot, "repo:create")
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		_, err = e.E.AddPolicy(RepoCreateMember.subject(), knot, knot, "repo:create")
+		if err != nil {
+			return err
+		}
+	}
+
 	// all owners are also members
 	_, err = e.E.AddGroupingPolicy("server:owner", "server:member", knot)
 	return err
 }
 
+// SetRepoCreatePolicy replaces who may create repos on a knot
+func (e *Enforcer) SetRepoCreatePolicy(knot string, policy RepoCreatePolicy) error {
+	_, err := e.E.RemoveFilteredPolicy(1, knot, knot, "repo:create")
+	if err != nil {
+		return err
+	}
+
+	_, err = e.E.AddPolicy(policy.subject(), knot, knot, "repo:create")
+	return err
+}
+
+// GetRepoCreatePolicy tells who may create repos on a knot
+func (e *Enforcer) GetRepoCreatePolicy(knot string) (RepoCreatePolicy, error) {
+	existing, err := e.E.GetFilteredPolicy(1, knot, knot, "repo:create")
+	if err != nil {
+		return "", err
+	}
+
+	policy := RepoCreateOwner
+	for _, p := range existing {
+		switch p[0] {
+		case Anyone:
+			return RepoCreateAnyone, nil
+		case "server:member":
+			policy = RepoCreateMember
+		}
+	}
+	return policy, nil
+}
+
+// GetOpenKnots lists the knots where anyone may create repos
+func (e *Enforcer) GetOpenKnots() ([]string, error) {
+	policies, err := e.E.GetFilteredPolicy(0, Anyone, "", "", "repo:create")
+	if err != nil {
+		return nil, err
+	}
+
+	var knots []string
+	for _, p := range policies {
+		if !isSpindle(p[1]) {
+			knots = append(knots, p[1])
+		}
+	}
+	return knots, nil
+}
+
+// GetRepoCreateKnots lists the knots where the user may create repos
+func (e *Enforcer) GetRepoCreateKnots(did string) ([]string, error) {
+	knots, err := e.GetKnotsForUser(did)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := e.GetOpenKnots()
+	if err != nil {
+		return nil, err
+	}
+	knots = append(knots, open...)
+	slices.Sort(knots)
+	knots = slices.Compact(knots)
+
+	var allowed []string
+	for _, knot := range knots {
+		ok, err := e.IsRepoCreateAllowed(did, knot)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			allowed = append(allowed, knot)
+		}
+	}
+	return allowed, nil
+}
+
 func (e *Enforcer) AddSpindle(spindle string) error {
 	// the internal repr for spindles is spindle:foo.com
 	spindle = intoSpindle(spindle)
@@ -208,6 +322,55 @@ func (e *Enforcer) RemoveCollaborator(collaborator, domain, repo string) error {
 	return err
 }
 
+// TransferRepo hands a repo over to a new owner under its new path.
+// Collaborators keep their access, the previous owner keeps none.
+func (e *Enforcer) TransferRepo(from, to, domain, oldRepo, newRepo string) error {
+	if err := checkRepoFormat(oldRepo); err != nil {
+		return err
+	}
+	if err := checkRepoFormat(newRepo); err != nil {
+		return err
+	}
+
+	policies, err := e.E.GetFilteredPolicy(1, domain, oldRepo)
+	if err != nil {
+		return err
+	}
+
+	var collaborators []string
+	for _, p := range policies {
+		if p[3] == "repo:collaborator" && p[0] != from && p[0] != to {
+			collaborators = append(collaborators, p[0])
+		}
+	}
+
+	if _, err := e.E.RemoveFilteredPolicy(1, domain, oldRepo); err != nil {
+		return err
+	}
+
+	err = e.transferRepo(to, domain, newRepo, collaborators)
+	if err != nil {
+		// put the old policies back, leaving the repo as it was
+		e.E.RemoveFilteredPolicy(1, domain, newRepo)
+		e.E.AddPolicies(policies)
+	}
+	return err
+}
+
+func (e *Enforcer) transferRepo(to, domain, newRepo string, collaborators []string) error {
+	if err := e.AddRepo(to, domain, newRepo); err != nil {
+		return err
+	}
+
+	for _, c := range collaborators {
+		if err := e.AddCollaborator(c, domain, newRepo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (e *Enforcer) GetUserByRole(role, domain string) ([]string, error) {
 	var membersWithoutRoles []string
 
@@ -310,3 +473,17 @@ func (e *Enforcer) GetPermissionsInRepo(user, domain, repo string) []string {
 
 	return permissions
 }
+
+// GetPushableRepos lists every repo in the domain that the user may push to
+func (e *Enforcer) GetPushableRepos(user, domain string) []string {
+	var repos []string
+	res := e.E.GetPermissionsForUserInDomain(user, domain)
+	for _, p := range res {
+		if p[3] == "repo:push" {
+			repos = append(repos, p[2])
+		}
+	}
+
+	slices.Sort(repos)
+	return slices.Compact(repos)
+}
@@ -151,6 +151,37 @@ func TestCollaboratorPermissions(t *testing.T) {
 	assert.ElementsMatch(t, []string{}, perms)
 }
 
+func TestTransferRepo(t *testing.T) {
+	e := setup(t)
+
+	knot := "example.com"
+	from := "did:plc:foo"
+	to := "did:plc:baz"
+	collaborator := "did:plc:bar"
+	oldRepo := "did:plc:foo/my-repo"
+	newRepo := "did:plc:baz/my-repo"
+
+	_ = e.AddKnot(knot)
+	_ = e.AddRepo(from, knot, oldRepo)
+	_ = e.AddCollaborator(collaborator, knot, oldRepo)
+
+	err := e.TransferRepo(from, to, knot, oldRepo, newRepo)
+	assert.NoError(t, err)
+
+	// nobody has access under the old path
+	assert.ElementsMatch(t, []string{}, e.GetPermissionsInRepo(from, knot, oldRepo))
+	assert.ElementsMatch(t, []string{}, e.GetPermissionsInRepo(collaborator, knot, oldRepo))
+
+	// the previous owner is gone, the collaborator stays
+	assert.ElementsMatch(t, []string{}, e.GetPermissionsInRepo(from, knot, newRepo))
+	assert.ElementsMatch(t, []string{
+		"repo:settings", "repo:push", "repo:owner", "repo:invite", "repo:delete",
+	}, e.GetPermissionsInRepo(to, knot, newRepo))
+	assert.ElementsMatch(t, []string{
+		"repo:settings", "repo:push", "repo:collaborator",
+	}, e.GetPermissionsInRepo(collaborator, knot, newRepo))
+}
+
 func TestGetByRole(t *testing.T) {
 	e := setup(t)
 
@@ -447,3 +478,102 @@ func TestRemoveSpindle(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, spindles)
 }
+
+func TestGetPushableRepos(t *testing.T) {
+	e := setup(t)
+
+	knot := "example.com"
+	owner := "did:plc:foo"
+	collaborator := "did:plc:bar"
+
+	_ = e.AddKnot(knot)
+	_ = e.AddRepo(owner, knot, "did:plc:foo/b-repo")
+	_ = e.AddRepo(owner, knot, "did:plc:foo/a-repo")
+	_ = e.AddRepo(collaborator, knot, "did:plc:bar/own-repo")
+
+	err := e.AddCollaborator(collaborator, knot, "did:plc:foo/a-repo")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"did:plc:foo/a-repo",
+		"did:plc:foo/b-repo",
+	}, e.GetPushableRepos(owner, knot))
+
+	assert.Equal(t, []string{
+		"did:plc:bar/own-repo",
+		"did:plc:foo/a-repo",
+	}, e.GetPushableRepos(collaborator, knot))
+
+	assert.Empty(t, e.GetPushableRepos("did:plc:baz", knot))
+}
+
+func TestRepoCreatePolicy(t *testing.T) {
+	e := setup(t)
+
+	err := e.AddKnot("example.com")
+	assert.NoError(t, err)
+
+	err = e.AddKnotOwner("example.com", "did:plc:foo")
+	assert.NoError(t, err)
+
+	err = e.AddKnotMember("example.com", "did:plc:bar")
+	assert.NoError(t, err)
+
+	policy, err := e.GetRepoCreatePolicy("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, rbac.RepoCreateMember, policy)
+
+	allowed := func(user string) bool {
+		ok, err := e.IsRepoCreateAllowed(user, "example.com")
+		assert.NoError(t, err)
+		return ok
+	}
+
+	assert.True(t, allowed("did:plc:foo"))
+	assert.True(t, allowed("did:plc:bar"))
+	assert.False(t, allowed("did:plc:baz"))
+
+	err = e.SetRepoCreatePolicy("example.com", rbac.RepoCreateOwner)
+	assert.NoError(t, err)
+	assert.True(t, allowed("did:plc:foo"))
+	assert.False(t, allowed("did:plc:bar"))
+	assert.False(t, allowed("did:plc:baz"))
+
+	// re-adding the knot keeps its policy
+	err = e.AddKnot("example.com")
+	assert.NoError(t, err)
+	assert.False(t, allowed("did:plc:bar"))
+
+	err = e.SetRepoCreatePolicy("example.com", rbac.RepoCreateAnyone)
+	assert.NoError(t, err)
+	assert.True(t, allowed("did:plc:foo"))
+	assert.True(t, allowed("did:plc:bar"))
+	assert.True(t, allowed("did:plc:baz"))
+
+	policy, err = e.GetRepoCreatePolicy("example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, rbac.RepoCreateAnyone, policy)
+
+	knots, err := e.GetOpenKnots()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"example.com"}, knots)
+
+	knots, err = e.GetRepoCreateKnots("did:plc:baz")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"example.com"}, knots)
+
+	err = e.SetRepoCreatePolicy("example.com", rbac.RepoCreateOwner)
+	assert.NoError(t, err)
+
+	knots, err = e.GetRepoCreateKnots("did:plc:bar")
+	assert.NoError(t, err)
+	assert.Empty(t, knots)
+
+	err = e.SetRepoCreatePolicy("example.com", rbac.RepoCreateAnyone)
+	assert.NoError(t, err)
+
+	// other permissions are unaffected
+	ok, err := e.IsKnotInviteAllowed("did:plc:baz", "example.com")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,95 @@
+// Package repolookup finds repos by their owner and name, straight from the
+// owner's PDS, without going through an appview.
+package repolookup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/idresolver"
+)
+
+type Repo struct {
+	Owner  *identity.Identity
+	Uri    syntax.ATURI
+	Record *tangled.Repo
+}
+
+// OwnerHandle is the owner's handle with a leading @, or their did if the
+// handle does not resolve
+func (r *Repo) OwnerHandle() string {
+	if r.Owner.Handle.IsInvalidHandle() {
+		return r.Owner.DID.String()
+	}
+	return "@" + r.Owner.Handle.String()
+}
+
+// HttpUrl is the smart-http endpoint of the repo on its knot
+func (r *Repo) HttpUrl(dev bool) string {
+	scheme := "https"
+	if dev {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", scheme, r.Record.Knot, r.Owner.DID, r.Record.Name)
+}
+
+// SshUrl is the ssh remote of the repo on its knot
+func (r *Repo) SshUrl() string {
+	return fmt.Sprintf("git@%s:%s/%s", r.Record.Knot, strings.TrimPrefix(r.OwnerHandle(), "@"), r.Record.Name)
+}
+
+// Parse splits an <owner>/<repo> reference, where the owner is a handle or
+// did, optionally prefixed with @
+func Parse(ref string) (owner, name string, err error) {
+	owner, name, ok := strings.Cut(strings.TrimPrefix(ref, "@"), "/")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "/"), ".git")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("expected <owner>/<repo>, got %q", ref)
+	}
+	return owner, name, nil
+}
+
+// Resolve finds the repo record for an <owner>/<repo> reference
+func Resolve(ctx context.Context, resolver *idresolver.Resolver, ref string) (*Repo, error) {
+	owner, name, err := Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	ident, err := resolver.ResolveIdent(ctx, owner)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", owner, err)
+	}
+
+	client := &xrpc.Client{Host: ident.PDSEndpoint()}
+
+	cursor := ""
+	for {
+		resp, err := comatproto.RepoListRecords(ctx, client, tangled.RepoNSID, cursor, 100, ident.DID.String(), false)
+		if err != nil {
+			return nil, fmt.Errorf("listing repos of %s: %w", owner, err)
+		}
+
+		for _, rec := range resp.Records {
+			repo, ok := rec.Value.Val.(*tangled.Repo)
+			if ok && repo.Name == name {
+				return &Repo{
+					Owner:  ident,
+					Uri:    syntax.ATURI(rec.Uri),
+					Record: repo,
+				}, nil
+			}
+		}
+
+		if resp.Cursor == nil || *resp.Cursor == "" {
+			return nil, fmt.Errorf("no repo named %s/%s", owner, name)
+		}
+		cursor = *resp.Cursor
+	}
+}
@@ -0,0 +1,97 @@
+package sandbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+// Client drives an appview through the same handlers the browser uses,
+// keeping the cookies it is handed
+type Client struct {
+	appview string
+	http    *http.Client
+}
+
+func NewClient(appview string) *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{
+		appview: appview,
+		http:    &http.Client{Jar: jar},
+	}
+}
+
+// Login signs in as the account of handle, through Login mounted at
+// /dev/login
+func (c *Client) Login(handle string) error {
+	// the timeline it redirects to is of no interest
+	client := *c.http
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Get(c.appview + "/dev/login?handle=" + url.QueryEscape(handle))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("signing in as %s: %s: %s", handle, resp.Status, body)
+	}
+	return nil
+}
+
+func (c *Client) Get(path string) (*http.Response, error) {
+	return c.Do(http.MethodGet, path, nil)
+}
+
+func (c *Client) Post(path string, form url.Values) (*http.Response, error) {
+	return c.Do(http.MethodPost, path, form)
+}
+
+func (c *Client) Put(path string, form url.Values) (*http.Response, error) {
+	return c.Do(http.MethodPut, path, form)
+}
+
+func (c *Client) Delete(path string, form url.Values) (*http.Response, error) {
+	return c.Do(http.MethodDelete, path, form)
+}
+
+// Do submits a form the way htmx does. Handlers answer failures with a
+// notice, which is turned into an error, as are error statuses. The body of
+// the response is read in full, and can be read again.
+func (c *Client) Do(method, path string, form url.Values) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.appview+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if resp.StatusCode >= 400 {
+		return resp, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if bytes.HasPrefix(body, []byte("<span id=")) && bytes.Contains(body, []byte(`hx-swap-oob="innerHTML"`)) {
+		return resp, fmt.Errorf("%s %s: %s", method, path, body)
+	}
+	return resp, nil
+}
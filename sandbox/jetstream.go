@@ -0,0 +1,171 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/jetstream/pkg/models"
+	"github.com/gorilla/websocket"
+	"github.com/klauspost/compress/zstd"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+// firehose keeps every event since the sandbox started, so that subscribers
+// can pick up from any cursor
+type firehose struct {
+	mu          sync.Mutex
+	events      []models.Event
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	collections []string
+	events      chan models.Event
+	dropped     bool
+}
+
+func newFirehose() firehose {
+	return firehose{
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+func (f *firehose) publish(did syntax.DID, op, collection, rkey, cid string, value json.RawMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// cursors are times, events must not share one
+	now := time.Now().UnixMicro()
+	if n := len(f.events); n > 0 && now <= f.events[n-1].TimeUS {
+		now = f.events[n-1].TimeUS + 1
+	}
+
+	event := models.Event{
+		Did:    did.String(),
+		TimeUS: now,
+		Kind:   models.EventKindCommit,
+		Commit: &models.Commit{
+			Rev:        tid.TID(),
+			Operation:  op,
+			Collection: collection,
+			RKey:       rkey,
+			Record:     value,
+			CID:        cid,
+		},
+	}
+	f.events = append(f.events, event)
+
+	for s := range f.subscribers {
+		s.send(event)
+	}
+}
+
+// Subscribers counts the open connections to the firehose
+func (f *firehose) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subscribers)
+}
+
+func (s *subscriber) send(event models.Event) {
+	if s.dropped {
+		return
+	}
+	if len(s.collections) > 0 && !slices.Contains(s.collections, event.Commit.Collection) {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		// a subscriber this far behind is dropped, as jetstream would
+		close(s.events)
+		s.dropped = true
+	}
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// subscribe speaks the jetstream protocol. Unlike jetstream, a subscriber
+// without a cursor is sent every event from the start.
+func (f *firehose) subscribe(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cursor, _ := strconv.ParseInt(q.Get("cursor"), 10, 64)
+
+	var encoder *zstd.Encoder
+	if r.Header.Get("Socket-Encoding") == "zstd" {
+		var err error
+		encoder, err = zstd.NewWriter(nil, zstd.WithEncoderDict(models.ZSTDDictionary))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	f.mu.Lock()
+	s := &subscriber{
+		collections: q["wantedCollections"],
+		events:      make(chan models.Event, len(f.events)+1024),
+	}
+	for _, event := range f.events {
+		if event.TimeUS > cursor {
+			s.send(event)
+		}
+	}
+	f.subscribers[s] = struct{}{}
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.subscribers, s)
+		f.mu.Unlock()
+	}()
+
+	// nothing is expected from the subscriber, reading notices it leaving
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case event, ok := <-s.events:
+			if !ok {
+				return
+			}
+			msg, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			kind := websocket.TextMessage
+			if encoder != nil {
+				msg = encoder.EncodeAll(msg, nil)
+				kind = websocket.BinaryMessage
+			}
+			if err := conn.WriteMessage(kind, msg); err != nil {
+				return
+			}
+		}
+	}
+}
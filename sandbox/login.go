@@ -0,0 +1,56 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"tangled.sh/icyphox.sh/atproto-oauth/helpers"
+	"tangled.sh/tangled.sh/core/appview/cache/session"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+)
+
+// Login signs the browser in as the account named by the handle parameter,
+// in place of the oauth flow with a PDS, and sends it to the timeline
+func Login(pds *PDS, o *oauth.OAuth, sess *session.SessionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a, ok := pds.Account(r.URL.Query().Get("handle"))
+		if !ok {
+			http.Error(w, "no such account", http.StatusNotFound)
+			return
+		}
+
+		dpopKey, err := helpers.GenerateKey(nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		dpopKeyJson, err := json.Marshal(dpopKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = sess.SaveSession(r.Context(), session.OAuthSession{
+			Did:            a.Did.String(),
+			Handle:         a.Handle.String(),
+			PdsUrl:         pds.URL(),
+			AuthServerIss:  pds.URL(),
+			AccessJwt:      a.AccessToken,
+			DpopPrivateJwk: string(dpopKeyJson),
+			// never refreshed, the sandbox does not outlive it
+			Expiry: time.Now().AddDate(1, 0, 0).Format(time.RFC3339),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := o.ResumeSession(w, r, a.Did.String()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}
+}
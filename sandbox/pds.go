@@ -0,0 +1,566 @@
+// Package sandbox stands in for the parts of the network a local appview and
+// knot talk to: a PDS holding the records of a few made up accounts, the
+// jetstream firehose of those records, an identity directory resolving the
+// accounts, and a redis server. Everything is kept in memory.
+package sandbox
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/atproto/auth"
+	"github.com/bluesky-social/indigo/atproto/crypto"
+	"github.com/bluesky-social/indigo/atproto/identity"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"tangled.sh/tangled.sh/core/tid"
+)
+
+type Account struct {
+	Did    syntax.DID
+	Handle syntax.Handle
+
+	// AccessToken authorizes requests to the PDS on behalf of the account,
+	// as a Bearer or DPoP token
+	AccessToken string
+
+	key *crypto.PrivateKeyP256
+}
+
+type record struct {
+	cid   string
+	value json.RawMessage
+}
+
+type blob struct {
+	mimeType string
+	data     []byte
+}
+
+// PDS serves the XRPC methods of a PDS for all of its accounts, and the
+// jetstream firehose of their records at /subscribe
+type PDS struct {
+	url string
+	dir identity.MockDirectory
+
+	mu       sync.Mutex
+	accounts map[syntax.DID]*Account
+	tokens   map[string]*Account
+	// did -> collection -> rkey
+	records map[syntax.DID]map[string]map[string]record
+	blobs   map[string]blob
+
+	firehose
+}
+
+// NewPDS creates a PDS that will be served at url
+func NewPDS(url string) *PDS {
+	return &PDS{
+		url:      strings.TrimSuffix(url, "/"),
+		dir:      identity.NewMockDirectory(),
+		accounts: make(map[syntax.DID]*Account),
+		tokens:   make(map[string]*Account),
+		records:  make(map[syntax.DID]map[string]map[string]record),
+		blobs:    make(map[string]blob),
+		firehose: newFirehose(),
+	}
+}
+
+func (p *PDS) URL() string {
+	return p.url
+}
+
+// Directory resolves the accounts of the PDS, and nothing else
+func (p *PDS) Directory() identity.Directory {
+	return &p.dir
+}
+
+// CreateAccount adds an account for handle. Its DID is derived from the
+// handle, so that it stays the same from one run to the next.
+func (p *PDS) CreateAccount(handle string) (*Account, error) {
+	h, err := syntax.ParseHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+	h = h.Normalize()
+
+	sum := sha256.Sum256([]byte(h))
+	did := syntax.DID("did:plc:" + strings.ToLower(base32.StdEncoding.EncodeToString(sum[:15])))
+
+	key, err := crypto.GeneratePrivateKeyP256()
+	if err != nil {
+		return nil, err
+	}
+	pub, err := key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	token := make([]byte, 16)
+	rand.Read(token)
+
+	a := &Account{
+		Did:         did,
+		Handle:      h,
+		AccessToken: hex.EncodeToString(token),
+		key:         key,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.accounts[did]; ok {
+		return nil, fmt.Errorf("account %s already exists", h)
+	}
+	p.accounts[did] = a
+	p.tokens[a.AccessToken] = a
+	p.records[did] = make(map[string]map[string]record)
+
+	p.dir.Insert(identity.Identity{
+		DID:         did,
+		Handle:      h,
+		AlsoKnownAs: []string{"at://" + h.String()},
+		Services: map[string]identity.ServiceEndpoint{
+			"atproto_pds": {Type: "AtprotoPersonalDataServer", URL: p.url},
+		},
+		Keys: map[string]identity.VerificationMethod{
+			"atproto": {Type: "Multikey", PublicKeyMultibase: pub.Multibase()},
+		},
+	})
+
+	return a, nil
+}
+
+// Account looks an account up by handle or DID
+func (p *PDS) Account(id string) (*Account, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, a := range p.accounts {
+		if a.Did.String() == id || a.Handle.String() == id {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// ServiceAuth mints a service auth token for a, the way the PDS would when
+// asked by the appview
+func (a *Account) ServiceAuth(aud string, lxm syntax.NSID, ttl time.Duration) (string, error) {
+	return auth.SignServiceAuth(a.Did, aud, ttl, &lxm, a.key)
+}
+
+// PutRecord writes a record to the repo of did, creating or replacing it
+func (p *PDS) PutRecord(did syntax.DID, collection, rkey string, value json.RawMessage) (string, error) {
+	if _, err := syntax.ParseNSID(collection); err != nil {
+		return "", err
+	}
+	if rkey == "" {
+		rkey = tid.TID()
+	}
+	if _, err := syntax.ParseRecordKey(rkey); err != nil {
+		return "", err
+	}
+
+	c, err := cidOf(cid.DagCBOR, value)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	repo, ok := p.records[did]
+	if !ok {
+		return "", errNoRepo
+	}
+	if repo[collection] == nil {
+		repo[collection] = make(map[string]record)
+	}
+
+	op := "create"
+	if _, ok := repo[collection][rkey]; ok {
+		op = "update"
+	}
+	repo[collection][rkey] = record{cid: c, value: value}
+
+	p.publish(did, op, collection, rkey, c, value)
+
+	return rkey, nil
+}
+
+// DeleteRecord removes a record from the repo of did, if it is there
+func (p *PDS) DeleteRecord(did syntax.DID, collection, rkey string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	repo, ok := p.records[did]
+	if !ok {
+		return errNoRepo
+	}
+	if _, ok := repo[collection][rkey]; !ok {
+		return nil
+	}
+	delete(repo[collection], rkey)
+
+	p.publish(did, "delete", collection, rkey, "", nil)
+
+	return nil
+}
+
+var errNoRepo = errors.New("repo not found")
+
+func cidOf(codec uint64, data []byte) (string, error) {
+	c, err := cid.NewPrefixV1(codec, multihash.SHA2_256).Sum(data)
+	if err != nil {
+		return "", err
+	}
+	return c.String(), nil
+}
+
+func (p *PDS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := strings.CutPrefix(r.URL.Path, "/xrpc/")
+	if !ok {
+		if r.URL.Path == "/subscribe" {
+			p.subscribe(w, r)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	switch method {
+	case "com.atproto.repo.getRecord":
+		p.getRecord(w, r)
+	case "com.atproto.repo.listRecords":
+		p.listRecords(w, r)
+	case "com.atproto.sync.getBlob":
+		p.getBlob(w, r)
+	case "com.atproto.repo.putRecord":
+		p.authed(w, r, p.putRecord)
+	case "com.atproto.repo.createRecord":
+		p.authed(w, r, p.putRecord)
+	case "com.atproto.repo.deleteRecord":
+		p.authed(w, r, p.deleteRecord)
+	case "com.atproto.repo.applyWrites":
+		p.authed(w, r, p.applyWrites)
+	case "com.atproto.repo.uploadBlob":
+		p.authed(w, r, p.uploadBlob)
+	case "com.atproto.server.getServiceAuth":
+		p.authed(w, r, p.getServiceAuth)
+	default:
+		writeError(w, http.StatusNotImplemented, "MethodNotImplemented", method+" is not implemented by the sandbox")
+	}
+}
+
+// authed calls next with the account whose access token came with the
+// request. DPoP proofs are not checked.
+func (p *PDS) authed(w http.ResponseWriter, r *http.Request, next func(http.ResponseWriter, *http.Request, *Account)) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		token, ok = strings.CutPrefix(header, "DPoP ")
+	}
+
+	p.mu.Lock()
+	a, found := p.tokens[token]
+	p.mu.Unlock()
+
+	if !ok || !found {
+		writeError(w, http.StatusUnauthorized, "AuthenticationRequired", "invalid access token")
+		return
+	}
+	next(w, r, a)
+}
+
+func (p *PDS) getRecord(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	a, ok := p.Account(q.Get("repo"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "RepoNotFound", "repo not found")
+		return
+	}
+	collection, rkey := q.Get("collection"), q.Get("rkey")
+
+	p.mu.Lock()
+	rec, ok := p.records[a.Did][collection][rkey]
+	p.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusBadRequest, "RecordNotFound", "record not found")
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"uri":   recordUri(a.Did, collection, rkey),
+		"cid":   rec.cid,
+		"value": rec.value,
+	})
+}
+
+func (p *PDS) listRecords(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	a, ok := p.Account(q.Get("repo"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "RepoNotFound", "repo not found")
+		return
+	}
+	collection := q.Get("collection")
+
+	limit := 50
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	reverse := q.Get("reverse") == "true"
+	cursor := q.Get("cursor")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	records := p.records[a.Did][collection]
+	rkeys := make([]string, 0, len(records))
+	for rkey := range records {
+		rkeys = append(rkeys, rkey)
+	}
+
+	// newest first, unless asked otherwise; rkeys are usually TIDs
+	slices.Sort(rkeys)
+	if !reverse {
+		slices.Reverse(rkeys)
+	}
+
+	type entry struct {
+		Uri   string          `json:"uri"`
+		Cid   string          `json:"cid"`
+		Value json.RawMessage `json:"value"`
+	}
+	out := struct {
+		Records []entry `json:"records"`
+		Cursor  string  `json:"cursor,omitempty"`
+	}{Records: []entry{}}
+
+	last := ""
+	for _, rkey := range rkeys {
+		if cursor != "" && (!reverse && rkey >= cursor || reverse && rkey <= cursor) {
+			continue
+		}
+		if len(out.Records) == limit {
+			out.Cursor = last
+			break
+		}
+		rec := records[rkey]
+		out.Records = append(out.Records, entry{recordUri(a.Did, collection, rkey), rec.cid, rec.value})
+		last = rkey
+	}
+
+	writeJSON(w, out)
+}
+
+func (p *PDS) putRecord(w http.ResponseWriter, r *http.Request, a *Account) {
+	var in struct {
+		Repo       string          `json:"repo"`
+		Collection string          `json:"collection"`
+		Rkey       string          `json:"rkey"`
+		Record     json.RawMessage `json:"record"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if !a.owns(in.Repo) {
+		writeError(w, http.StatusForbidden, "InvalidToken", "cannot write to the repo of another account")
+		return
+	}
+
+	rkey, err := p.PutRecord(a.Did, in.Collection, in.Rkey, in.Record)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+
+	p.mu.Lock()
+	c := p.records[a.Did][in.Collection][rkey].cid
+	p.mu.Unlock()
+
+	writeJSON(w, map[string]string{
+		"uri": recordUri(a.Did, in.Collection, rkey),
+		"cid": c,
+	})
+}
+
+func (p *PDS) deleteRecord(w http.ResponseWriter, r *http.Request, a *Account) {
+	var in struct {
+		Repo       string `json:"repo"`
+		Collection string `json:"collection"`
+		Rkey       string `json:"rkey"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if !a.owns(in.Repo) {
+		writeError(w, http.StatusForbidden, "InvalidToken", "cannot write to the repo of another account")
+		return
+	}
+
+	if err := p.DeleteRecord(a.Did, in.Collection, in.Rkey); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	writeJSON(w, map[string]any{})
+}
+
+func (p *PDS) applyWrites(w http.ResponseWriter, r *http.Request, a *Account) {
+	var in struct {
+		Repo   string `json:"repo"`
+		Writes []struct {
+			Type       string          `json:"$type"`
+			Collection string          `json:"collection"`
+			Rkey       string          `json:"rkey"`
+			Value      json.RawMessage `json:"value"`
+		} `json:"writes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if !a.owns(in.Repo) {
+		writeError(w, http.StatusForbidden, "InvalidToken", "cannot write to the repo of another account")
+		return
+	}
+
+	// writes are not atomic here, unlike on a real PDS
+	results := []map[string]string{}
+	for _, write := range in.Writes {
+		op := strings.TrimPrefix(write.Type, "com.atproto.repo.applyWrites#")
+		switch op {
+		case "create", "update":
+			rkey, err := p.PutRecord(a.Did, write.Collection, write.Rkey, write.Value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+				return
+			}
+			p.mu.Lock()
+			c := p.records[a.Did][write.Collection][rkey].cid
+			p.mu.Unlock()
+			results = append(results, map[string]string{
+				"$type": fmt.Sprintf("com.atproto.repo.applyWrites#%sResult", op),
+				"uri":   recordUri(a.Did, write.Collection, rkey),
+				"cid":   c,
+			})
+		case "delete":
+			if err := p.DeleteRecord(a.Did, write.Collection, write.Rkey); err != nil {
+				writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+				return
+			}
+			results = append(results, map[string]string{
+				"$type": "com.atproto.repo.applyWrites#deleteResult",
+			})
+		default:
+			writeError(w, http.StatusBadRequest, "InvalidRequest", "unknown write "+write.Type)
+			return
+		}
+	}
+
+	writeJSON(w, map[string]any{"results": results})
+}
+
+func (p *PDS) uploadBlob(w http.ResponseWriter, r *http.Request, a *Account) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 50<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BlobTooLarge", err.Error())
+		return
+	}
+
+	c, err := cidOf(cid.Raw, data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "InternalServerError", err.Error())
+		return
+	}
+
+	mimeType := r.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	p.mu.Lock()
+	p.blobs[c] = blob{mimeType: mimeType, data: data}
+	p.mu.Unlock()
+
+	writeJSON(w, map[string]any{
+		"blob": map[string]any{
+			"$type":    "blob",
+			"ref":      map[string]string{"$link": c},
+			"mimeType": mimeType,
+			"size":     len(data),
+		},
+	})
+}
+
+func (p *PDS) getBlob(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	b, ok := p.blobs[r.URL.Query().Get("cid")]
+	p.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusBadRequest, "BlobNotFound", "blob not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", b.mimeType)
+	w.Write(b.data)
+}
+
+func (p *PDS) getServiceAuth(w http.ResponseWriter, r *http.Request, a *Account) {
+	q := r.URL.Query()
+
+	lxm, err := syntax.ParseNSID(q.Get("lxm"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "lxm is required")
+		return
+	}
+
+	ttl := time.Minute
+	if exp, err := strconv.ParseInt(q.Get("exp"), 10, 64); err == nil {
+		ttl = time.Until(time.Unix(exp, 0))
+	}
+
+	token, err := a.ServiceAuth(q.Get("aud"), lxm, ttl)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	writeJSON(w, map[string]string{"token": token})
+}
+
+func (a *Account) owns(repo string) bool {
+	return repo == a.Did.String() || repo == a.Handle.String()
+}
+
+func recordUri(did syntax.DID, collection, rkey string) string {
+	return fmt.Sprintf("at://%s/%s/%s", did, collection, rkey)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, name, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": name, "message": message})
+}
@@ -0,0 +1,260 @@
+package sandbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redis speaks enough of the redis protocol for the sessions and cursors of
+// the appview: strings with expiry, and hashes
+type Redis struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	strings map[string]value
+	hashes  map[string]map[string]string
+}
+
+type value struct {
+	s       string
+	expires time.Time
+}
+
+// ListenRedis serves an empty redis at addr, until closed
+func ListenRedis(addr string) (*Redis, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Redis{
+		ln:      ln,
+		strings: make(map[string]value),
+		hashes:  make(map[string]map[string]string),
+	}
+	go r.serve()
+
+	return r, nil
+}
+
+func (r *Redis) Addr() string {
+	return r.ln.Addr().String()
+}
+
+func (r *Redis) Close() error {
+	return r.ln.Close()
+}
+
+func (r *Redis) serve() {
+	for {
+		conn, err := r.ln.Accept()
+		if err != nil {
+			return
+		}
+		go r.handle(conn)
+	}
+}
+
+func (r *Redis) handle(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		r.exec(w, args)
+
+		// pipelined commands are answered together
+		if rd.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := readLine(rd)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := readLine(rd)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, errors.New("expected a bulk string")
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(rd *bufio.Reader) (string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (r *Redis) exec(w *bufio.Writer, args []string) {
+	if len(args) == 0 {
+		writeRedisError(w, "empty command")
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cmd, args := strings.ToUpper(args[0]), args[1:]
+	switch {
+	case cmd == "PING":
+		fmt.Fprint(w, "+PONG\r\n")
+	case cmd == "CLIENT" || cmd == "SELECT":
+		fmt.Fprint(w, "+OK\r\n")
+	case cmd == "GET" && len(args) == 1:
+		if v, ok := r.get(args[0]); ok {
+			writeBulk(w, v.s)
+		} else {
+			fmt.Fprint(w, "$-1\r\n")
+		}
+	case cmd == "SET" && len(args) >= 2:
+		r.set(w, args)
+	case cmd == "DEL":
+		n := 0
+		for _, key := range args {
+			if _, ok := r.get(key); ok {
+				n++
+			}
+			if _, ok := r.hashes[key]; ok {
+				n++
+			}
+			delete(r.strings, key)
+			delete(r.hashes, key)
+		}
+		writeInt(w, n)
+	case cmd == "EXPIRE" && len(args) == 2:
+		seconds, err := strconv.Atoi(args[1])
+		v, ok := r.get(args[0])
+		if err != nil || !ok {
+			writeInt(w, 0)
+			return
+		}
+		v.expires = time.Now().Add(time.Duration(seconds) * time.Second)
+		r.strings[args[0]] = v
+		writeInt(w, 1)
+	case cmd == "HSET" && len(args) >= 3 && len(args)%2 == 1:
+		h, ok := r.hashes[args[0]]
+		if !ok {
+			h = make(map[string]string)
+			r.hashes[args[0]] = h
+		}
+		n := 0
+		for i := 1; i < len(args); i += 2 {
+			if _, ok := h[args[i]]; !ok {
+				n++
+			}
+			h[args[i]] = args[i+1]
+		}
+		writeInt(w, n)
+	case cmd == "HDEL" && len(args) >= 2:
+		n := 0
+		for _, field := range args[1:] {
+			if _, ok := r.hashes[args[0]][field]; ok {
+				delete(r.hashes[args[0]], field)
+				n++
+			}
+		}
+		writeInt(w, n)
+	case cmd == "HEXISTS" && len(args) == 2:
+		if _, ok := r.hashes[args[0]][args[1]]; ok {
+			writeInt(w, 1)
+		} else {
+			writeInt(w, 0)
+		}
+	case cmd == "HGETALL" && len(args) == 1:
+		h := r.hashes[args[0]]
+		fmt.Fprintf(w, "*%d\r\n", len(h)*2)
+		for field, v := range h {
+			writeBulk(w, field)
+			writeBulk(w, v)
+		}
+	default:
+		// HELLO is refused too, which keeps clients on RESP2
+		writeRedisError(w, fmt.Sprintf("unknown command '%s'", cmd))
+	}
+}
+
+func (r *Redis) get(key string) (value, bool) {
+	v, ok := r.strings[key]
+	if ok && !v.expires.IsZero() && time.Now().After(v.expires) {
+		delete(r.strings, key)
+		return value{}, false
+	}
+	return v, ok
+}
+
+func (r *Redis) set(w *bufio.Writer, args []string) {
+	v := value{s: args[1]}
+	for i := 2; i < len(args); i++ {
+		option := strings.ToUpper(args[i])
+		if option != "EX" && option != "PX" || i+1 == len(args) {
+			writeRedisError(w, "syntax error")
+			return
+		}
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			writeRedisError(w, "value is not an integer or out of range")
+			return
+		}
+		unit := time.Second
+		if option == "PX" {
+			unit = time.Millisecond
+		}
+		v.expires = time.Now().Add(time.Duration(n) * unit)
+		i++
+	}
+	r.strings[args[0]] = v
+	fmt.Fprint(w, "+OK\r\n")
+}
+
+func writeBulk(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func writeInt(w *bufio.Writer, n int) {
+	fmt.Fprintf(w, ":%d\r\n", n)
+}
+
+func writeRedisError(w *bufio.Writer, message string) {
+	fmt.Fprintf(w, "-ERR %s\r\n", message)
+}
@@ -0,0 +1,172 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/auth"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/bluesky-social/jetstream/pkg/models"
+	"github.com/gorilla/websocket"
+	"github.com/klauspost/compress/zstd"
+	"github.com/redis/go-redis/v9"
+	"tangled.sh/tangled.sh/core/api/tangled"
+)
+
+func TestPDS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(nil)
+	pds := NewPDS("http://" + srv.Listener.Addr().String())
+	srv.Config.Handler = pds
+	srv.Start()
+	defer srv.Close()
+
+	alice, err := pds.CreateAccount("alice.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := NewPDS(pds.URL())
+	if a, _ := again.CreateAccount("alice.test"); a.Did != alice.Did {
+		t.Errorf("got did %s on the second run, want %s", a.Did, alice.Did)
+	}
+
+	ctx := context.Background()
+	client := &xrpc.Client{
+		Host: pds.URL(),
+		Auth: &xrpc.AuthInfo{AccessJwt: alice.AccessToken},
+	}
+
+	// subscribe before writing, to see the write come through
+	header := http.Header{"Socket-Encoding": {"zstd"}}
+	url := strings.Replace(pds.URL(), "http", "ws", 1) + "/subscribe?wantedCollections=" + tangled.GraphFollowNSID
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	out, err := comatproto.RepoPutRecord(ctx, client, &comatproto.RepoPutRecord_Input{
+		Repo:       alice.Did.String(),
+		Collection: tangled.GraphFollowNSID,
+		Rkey:       "3abc",
+		Record: &lexutil.LexiconTypeDecoder{Val: &tangled.GraphFollow{
+			Subject:   "did:plc:bob",
+			CreatedAt: time.Now().Format(time.RFC3339),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := comatproto.RepoGetRecord(ctx, &xrpc.Client{Host: pds.URL()}, "", tangled.GraphFollowNSID, alice.Handle.String(), "3abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Uri != out.Uri || *rec.Cid != out.Cid {
+		t.Errorf("got %s %s, want %s %s", rec.Uri, *rec.Cid, out.Uri, out.Cid)
+	}
+	if follow, ok := rec.Value.Val.(*tangled.GraphFollow); !ok || follow.Subject != "did:plc:bob" {
+		t.Errorf("got record %#v", rec.Value.Val)
+	}
+
+	list, err := comatproto.RepoListRecords(ctx, client, tangled.GraphFollowNSID, "", 10, alice.Did.String(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Records) != 1 {
+		t.Errorf("got %d records, want 1", len(list.Records))
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, _ := zstd.NewReader(nil, zstd.WithDecoderDicts(models.ZSTDDictionary))
+	msg, err = dec.DecodeAll(msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event models.Event
+	if err := json.Unmarshal(msg, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Did != alice.Did.String() || event.Commit.RKey != "3abc" || event.Commit.Operation != "create" {
+		t.Errorf("got event %+v", event)
+	}
+
+	if _, err := comatproto.RepoPutRecord(ctx, &xrpc.Client{Host: pds.URL()}, &comatproto.RepoPutRecord_Input{
+		Repo:       alice.Did.String(),
+		Collection: tangled.GraphFollowNSID,
+		Rkey:       "3abd",
+		Record:     &lexutil.LexiconTypeDecoder{Val: &tangled.GraphFollow{}},
+	}); err == nil {
+		t.Error("write without a token was accepted")
+	}
+
+	token, err := comatproto.ServerGetServiceAuth(ctx, client, "did:web:knot.test", time.Now().Add(time.Minute).Unix(), tangled.RepoCreateNSID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator := auth.ServiceAuthValidator{Audience: "did:web:knot.test", Dir: pds.Directory()}
+	lxm := syntax.NSID(tangled.RepoCreateNSID)
+	did, err := validator.Validate(ctx, token.Token, &lxm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if did != alice.Did {
+		t.Errorf("got token for %s, want %s", did, alice.Did)
+	}
+}
+
+func TestRedis(t *testing.T) {
+	r, err := ListenRedis("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ctx := context.Background()
+	rdb := redis.NewClient(&redis.Options{Addr: r.Addr()})
+	defer rdb.Close()
+
+	if err := rdb.Set(ctx, "session", "alice", time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := rdb.Get(ctx, "session").Result(); err != nil || v != "alice" {
+		t.Errorf("got %q, %v", v, err)
+	}
+
+	if err := rdb.Set(ctx, "short", "lived", 10*time.Millisecond).Err(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := rdb.Get(ctx, "short").Result(); err != redis.Nil {
+		t.Errorf("got %v after expiry, want redis.Nil", err)
+	}
+
+	if err := rdb.HSet(ctx, "logins", "a", "1", "b", "2").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.HDel(ctx, "logins", "a").Err(); err != nil {
+		t.Fatal(err)
+	}
+	logins, err := rdb.HGetAll(ctx, "logins").Result()
+	if err != nil || len(logins) != 1 || logins["b"] != "2" {
+		t.Errorf("got %v, %v", logins, err)
+	}
+	if ok, _ := rdb.HExists(ctx, "logins", "b").Result(); !ok {
+		t.Error("b is missing")
+	}
+
+	if n, err := rdb.Del(ctx, "session", "logins").Result(); err != nil || n != 2 {
+		t.Errorf("deleted %d, %v", n, err)
+	}
+}
@@ -0,0 +1,158 @@
+package sandboxtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"tangled.sh/tangled.sh/core/appview/cache"
+	"tangled.sh/tangled.sh/core/appview/cache/session"
+	"tangled.sh/tangled.sh/core/appview/config"
+	"tangled.sh/tangled.sh/core/appview/oauth"
+	"tangled.sh/tangled.sh/core/appview/state"
+	"tangled.sh/tangled.sh/core/idresolver"
+	"tangled.sh/tangled.sh/core/sandbox"
+)
+
+// Harness is an appview served over httptest, with its own PDS, redis and
+// knot. Everything is torn down when the test ends.
+type Harness struct {
+	PDS   *sandbox.PDS
+	Knot  *Knot
+	State *state.State
+
+	// URL is the address of the appview
+	URL string
+
+	t testing.TB
+}
+
+// New starts a harness with an account for each handle, the first of which
+// owns the knot. The knot is not registered with the appview, that is left
+// to the test.
+//
+// The appview runs in dev mode, which reads its templates from the tree:
+// the working directory is changed to the root of this module for the
+// duration of the test, so that it cannot run in parallel with others.
+func New(t testing.TB, handles ...string) *Harness {
+	t.Helper()
+
+	if len(handles) == 0 {
+		t.Fatal("sandboxtest: the knot needs an owner, pass at least one handle")
+	}
+
+	_, file, _, _ := runtime.Caller(0)
+	t.Chdir(filepath.Join(filepath.Dir(file), "..", ".."))
+
+	ctx := context.Background()
+
+	redis, err := sandbox.ListenRedis("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redis.Close() })
+
+	pdsSrv := httptest.NewUnstartedServer(nil)
+	pds := sandbox.NewPDS("http://" + pdsSrv.Listener.Addr().String())
+	pdsSrv.Config.Handler = pds
+	pdsSrv.Start()
+	t.Cleanup(pdsSrv.Close)
+
+	var owner *sandbox.Account
+	for _, h := range handles {
+		a, err := pds.CreateAccount(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if owner == nil {
+			owner = a
+		}
+	}
+
+	res := idresolver.New(pds.Directory())
+
+	knot := NewKnot(owner.Did, res)
+	t.Cleanup(knot.Close)
+
+	srv := httptest.NewUnstartedServer(nil)
+	appview := "http://" + srv.Listener.Addr().String()
+
+	env := map[string]string{
+		"TANGLED_DEV":                "true",
+		"TANGLED_LISTEN_ADDR":        srv.Listener.Addr().String(),
+		"TANGLED_APPVIEW_HOST":       appview,
+		"TANGLED_DB_PATH":            filepath.Join(t.TempDir(), "appview.db"),
+		"TANGLED_REDIS_ADDR":         redis.Addr(),
+		"TANGLED_JETSTREAM_ENDPOINT": strings.Replace(pds.URL(), "http://", "ws://", 1) + "/subscribe",
+		// every account is new, tests would run into the rate limit of new
+		// accounts
+		"TANGLED_SPAM_NEW_ACCOUNT_LIMIT": "0",
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+
+	c, err := config.LoadConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := state.Make(ctx, c, state.WithResolver(res))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	sess := session.New(cache.New(c.Redis.Addr))
+	mux := http.NewServeMux()
+	mux.Handle("/", s.Router())
+	mux.Handle("GET /dev/login", sandbox.Login(pds, oauth.NewOAuth(c, sess), sess))
+
+	srv.Config.Handler = mux
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	// the appview follows the firehose from the background
+	deadline := time.Now().Add(10 * time.Second)
+	for pds.Subscribers() < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("sandboxtest: the appview did not subscribe to the firehose")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return &Harness{
+		PDS:   pds,
+		Knot:  knot,
+		State: s,
+		URL:   appview,
+		t:     t,
+	}
+}
+
+// Client is a browser signed in to the appview as one of the accounts
+type Client struct {
+	*sandbox.Account
+	*sandbox.Client
+}
+
+// Login signs in as the account of handle
+func (h *Harness) Login(handle string) *Client {
+	h.t.Helper()
+
+	a, ok := h.PDS.Account(handle)
+	if !ok {
+		h.t.Fatalf("sandboxtest: no account %s", handle)
+	}
+
+	c := sandbox.NewClient(h.URL)
+	if err := c.Login(handle); err != nil {
+		h.t.Fatalf("sandboxtest: %v", err)
+	}
+
+	return &Client{Account: a, Client: c}
+}
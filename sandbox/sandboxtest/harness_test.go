@@ -0,0 +1,75 @@
+package sandboxtest
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+const contributing = `diff --git a/CONTRIBUTING.md b/CONTRIBUTING.md
+new file mode 100644
+index 0000000..2a3fa9b
+--- /dev/null
++++ b/CONTRIBUTING.md
+@@ -0,0 +1 @@
++be nice
+`
+
+func TestHarness(t *testing.T) {
+	h := New(t, "alice.test", "bob.test")
+	alice, bob := h.Login("alice.test"), h.Login("bob.test")
+
+	if _, err := alice.Post("/knots/register", url.Values{"domain": {h.Knot.Host()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := alice.Post("/repo/new", url.Values{
+		"domain":      {h.Knot.Host()},
+		"name":        {"hello"},
+		"description": {"says hello"},
+		"readme":      {"on"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("HX-Location"); got != "/@alice.test/hello" {
+		t.Errorf("got location %q", got)
+	}
+	if _, err := h.Knot.File(alice.Did.String(), "hello", "main", "README.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := fmt.Sprintf("/%s/hello", alice.Did)
+
+	// bob may open pulls but not merge them, until he is a collaborator
+	resp, err = bob.Post(repo+"/pulls/new", url.Values{
+		"title":        {"Add contributing guidelines"},
+		"targetBranch": {"main"},
+		"patch":        {contributing},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("HX-Location"); got != "/@alice.test/hello/pulls/1" {
+		t.Errorf("got location %q", got)
+	}
+
+	if _, err := bob.Post(repo+"/pulls/1/merge", nil); err == nil {
+		t.Fatal("merged without being a collaborator")
+	}
+
+	if _, err := alice.Put(repo+"/settings/collaborator", url.Values{"collaborator": {bob.Handle.String()}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bob.Post(repo+"/pulls/1/merge", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := h.Knot.File(alice.Did.String(), "hello", "main", "CONTRIBUTING.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "be nice\n" {
+		t.Errorf("got CONTRIBUTING.md %q", got)
+	}
+}
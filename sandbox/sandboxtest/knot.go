@@ -0,0 +1,526 @@
+// Package sandboxtest runs an appview against the sandbox network and a fake
+// knot, so that integrators can exercise its handlers end to end from
+// ordinary go tests.
+package sandboxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/gorilla/websocket"
+	"tangled.sh/tangled.sh/core/api/tangled"
+	"tangled.sh/tangled.sh/core/idresolver"
+	tlog "tangled.sh/tangled.sh/core/log"
+	"tangled.sh/tangled.sh/core/patchutil"
+	"tangled.sh/tangled.sh/core/scaffold"
+	"tangled.sh/tangled.sh/core/types"
+	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
+	"tangled.sh/tangled.sh/core/xrpc/serviceauth"
+)
+
+// Knot is a knot keeping its repositories in memory. It answers what the
+// appview asks of a knot to register it, create and delete repos, and check
+// and merge patches; everything else is answered with 501.
+//
+// Unlike a real knot it does not follow the firehose: any account with a
+// valid service auth token may create repos and merge into them, access
+// control is left to the appview.
+type Knot struct {
+	owner    syntax.DID
+	resolver *idresolver.Resolver
+	auth     *serviceauth.ServiceAuth
+	srv      *httptest.Server
+
+	mu    sync.Mutex
+	repos map[string]*git.Repository
+}
+
+// NewKnot starts a knot owned by owner, resolving the callers of its
+// authenticated methods with res. It is stopped with Close.
+func NewKnot(owner syntax.DID, res *idresolver.Resolver) *Knot {
+	k := &Knot{
+		owner:    owner,
+		resolver: res,
+		repos:    make(map[string]*git.Repository),
+	}
+
+	k.srv = httptest.NewUnstartedServer(nil)
+	k.auth = serviceauth.NewServiceAuth(tlog.New("knot"), res, "did:web:"+k.Host())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /owner", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(k.owner.String()))
+	})
+	mux.HandleFunc("GET /capabilities", k.capabilities)
+	mux.HandleFunc("GET /events", k.events)
+	mux.Handle("POST /xrpc/"+tangled.RepoCreateNSID, k.auth.VerifyServiceAuth(http.HandlerFunc(k.createRepo)))
+	mux.Handle("POST /xrpc/"+tangled.RepoDeleteNSID, k.auth.VerifyServiceAuth(http.HandlerFunc(k.deleteRepo)))
+	mux.Handle("POST /xrpc/"+tangled.RepoMergeNSID, k.auth.VerifyServiceAuth(http.HandlerFunc(k.merge)))
+	mux.Handle("POST /xrpc/"+tangled.RepoUpdatePullRefsNSID, k.auth.VerifyServiceAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	mux.HandleFunc("POST /xrpc/"+tangled.RepoMergeCheckNSID, k.mergeCheck)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not implemented by the fake knot", http.StatusNotImplemented)
+	})
+
+	k.srv.Config.Handler = mux
+	k.srv.Start()
+
+	return k
+}
+
+// Host is the domain the knot is registered under
+func (k *Knot) Host() string {
+	return k.srv.Listener.Addr().String()
+}
+
+func (k *Knot) Close() {
+	k.srv.Close()
+}
+
+// Repo is the repository of did called name, if the knot has it
+func (k *Knot) Repo(did, name string) (*git.Repository, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	r, ok := k.repos[path.Join(did, name)]
+	return r, ok
+}
+
+// File reads a file of a repository at the tip of branch
+func (k *Knot) File(did, name, branch, file string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	r, ok := k.repos[path.Join(did, name)]
+	if !ok {
+		return "", fmt.Errorf("no repo %s/%s", did, name)
+	}
+	files, _, err := readBranch(r, branch)
+	if err != nil {
+		return "", err
+	}
+	content, ok := files[file]
+	if !ok {
+		return "", fmt.Errorf("no file %s on %s", file, branch)
+	}
+	return string(content), nil
+}
+
+func (k *Knot) capabilities(w http.ResponseWriter, r *http.Request) {
+	var caps types.Capabilities
+	caps.PullRequests.FormatPatch = true
+	caps.PullRequests.PatchSubmissions = true
+	caps.Xrpc = true
+	caps.ServiceAuth.Lxm = true
+	caps.ServiceAuth.Replay = true
+	caps.ServiceAuth.MaxNoncelessLifetime = int(serviceauth.MaxNoncelessLifetime.Seconds())
+	writeJSON(w, caps)
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// events keeps the appview's knotstream connected, there is nothing to send
+func (k *Knot) events(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (k *Knot) createRepo(w http.ResponseWriter, r *http.Request) {
+	actor := r.Context().Value(serviceauth.ActorDid).(syntax.DID)
+
+	var input tangled.RepoCreate_Input
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, xrpcerr.InvalidRequestError(err), http.StatusBadRequest)
+		return
+	}
+
+	// the name and description are in the record, as the real knot finds
+	ident, err := k.resolver.ResolveIdent(r.Context(), actor.String())
+	if err != nil {
+		writeError(w, xrpcerr.GenericError(err), http.StatusBadRequest)
+		return
+	}
+	out, err := comatproto.RepoGetRecord(r.Context(), &xrpc.Client{Host: ident.PDSEndpoint()}, "", tangled.RepoNSID, actor.String(), input.Rkey)
+	if err != nil {
+		writeError(w, xrpcerr.GenericError(err), http.StatusBadRequest)
+		return
+	}
+	record, ok := out.Value.Val.(*tangled.Repo)
+	if !ok {
+		writeError(w, xrpcerr.InvalidRepoError(out.Uri), http.StatusBadRequest)
+		return
+	}
+
+	opts := scaffold.Options{
+		Name:   record.Name,
+		Holder: ident.Handle.String(),
+		Readme: input.Readme != nil && *input.Readme,
+	}
+	if record.Description != nil {
+		opts.Description = *record.Description
+	}
+	if input.Gitignore != nil {
+		opts.Gitignore = *input.Gitignore
+	}
+	if input.License != nil {
+		opts.License = *input.License
+	}
+	files, err := scaffold.Files(opts)
+	if err != nil {
+		writeError(w, xrpcerr.InvalidRequestError(err), http.StatusBadRequest)
+		return
+	}
+
+	branch := "main"
+	if input.DefaultBranch != nil && *input.DefaultBranch != "" {
+		branch = *input.DefaultBranch
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key := path.Join(actor.String(), record.Name)
+	if _, ok := k.repos[key]; ok {
+		writeError(w, xrpcerr.RepoExistsError(key), http.StatusConflict)
+		return
+	}
+
+	repo, err := git.Init(memory.NewStorage(), nil)
+	if err != nil {
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		return
+	}
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))
+	if err := repo.Storer.SetReference(head); err != nil {
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		return
+	}
+	if len(files) > 0 {
+		if err := commit(repo, branch, files, k.signature(), "Initial commit"); err != nil {
+			writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	k.repos[key] = repo
+}
+
+func (k *Knot) deleteRepo(w http.ResponseWriter, r *http.Request) {
+	actor := r.Context().Value(serviceauth.ActorDid).(syntax.DID)
+
+	var input tangled.RepoDelete_Input
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, xrpcerr.InvalidRequestError(err), http.StatusBadRequest)
+		return
+	}
+	if input.Did != actor.String() {
+		writeError(w, xrpcerr.AccessControlError(actor.String()), http.StatusUnauthorized)
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.repos, path.Join(input.Did, input.Name))
+}
+
+func (k *Knot) mergeCheck(w http.ResponseWriter, r *http.Request) {
+	var input tangled.RepoMergeCheck_Input
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, xrpcerr.InvalidRequestError(err), http.StatusBadRequest)
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	repo, ok := k.repos[path.Join(input.Did, input.Name)]
+	if !ok {
+		writeError(w, xrpcerr.NotFoundError, http.StatusNotFound)
+		return
+	}
+	files, _, err := readBranch(repo, input.Branch)
+	if err != nil {
+		writeError(w, xrpcerr.RefNotFoundError(input.Branch), http.StatusNotFound)
+		return
+	}
+
+	var out tangled.RepoMergeCheck_Output
+	for _, c := range apply(files, input.Patch) {
+		out.Is_conflicted = true
+		out.Conflicts = append(out.Conflicts, &tangled.RepoMergeCheck_ConflictInfo{
+			Filename: c.file,
+			Reason:   c.err.Error(),
+		})
+	}
+	writeJSON(w, out)
+}
+
+// merge lands the patch as a single commit, whatever the strategy asked for
+func (k *Knot) merge(w http.ResponseWriter, r *http.Request) {
+	var input tangled.RepoMerge_Input
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, xrpcerr.InvalidRequestError(err), http.StatusBadRequest)
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	repo, ok := k.repos[path.Join(input.Did, input.Name)]
+	if !ok {
+		writeError(w, xrpcerr.NotFoundError, http.StatusNotFound)
+		return
+	}
+	files, _, err := readBranch(repo, input.Branch)
+	if err != nil {
+		writeError(w, xrpcerr.RefNotFoundError(input.Branch), http.StatusNotFound)
+		return
+	}
+
+	if conflicts := apply(files, input.Patch); len(conflicts) > 0 {
+		var names []string
+		for _, c := range conflicts {
+			names = append(names, c.file)
+		}
+		writeError(w, xrpcerr.MergeConflictError(conflicts[0].err.Error(), names...), http.StatusConflict)
+		return
+	}
+
+	message := "Merge patch"
+	if input.CommitMessage != nil {
+		message = *input.CommitMessage
+	}
+	if input.CommitBody != nil {
+		message += "\n\n" + *input.CommitBody
+	}
+	author := k.signature()
+	if input.AuthorName != nil {
+		author.Name = *input.AuthorName
+	}
+	if input.AuthorEmail != nil {
+		author.Email = *input.AuthorEmail
+	}
+
+	if err := commit(repo, input.Branch, files, author, message); err != nil {
+		writeError(w, xrpcerr.GitError(err), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (k *Knot) signature() object.Signature {
+	return object.Signature{
+		Name:  "Tangled",
+		Email: "noreply@" + k.Host(),
+		When:  time.Now(),
+	}
+}
+
+type conflict struct {
+	file string
+	err  error
+}
+
+// apply applies patch to files in place, and reports the files it did not
+// apply to
+func apply(files map[string][]byte, patch string) []conflict {
+	diffs, err := patchutil.AsDiff(patch)
+	if err != nil {
+		return []conflict{{err: err}}
+	}
+
+	var conflicts []conflict
+	for _, d := range diffs {
+		if d.IsDelete {
+			delete(files, d.OldName)
+			continue
+		}
+
+		var src []byte
+		if !d.IsNew {
+			var ok bool
+			if src, ok = files[d.OldName]; !ok {
+				conflicts = append(conflicts, conflict{d.OldName, errors.New("file does not exist")})
+				continue
+			}
+		}
+
+		var dst bytes.Buffer
+		if err := gitdiff.Apply(&dst, bytes.NewReader(src), d); err != nil {
+			conflicts = append(conflicts, conflict{d.NewName, err})
+			continue
+		}
+		if d.IsRename {
+			delete(files, d.OldName)
+		}
+		files[d.NewName] = dst.Bytes()
+	}
+	return conflicts
+}
+
+// readBranch reads every file at the tip of branch, an unborn branch has
+// none
+func readBranch(repo *git.Repository, branch string) (map[string][]byte, *plumbing.Hash, error) {
+	files := make(map[string][]byte)
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return files, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, nil, err
+	}
+	iter, err := c.Files()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = iter.ForEach(func(f *object.File) error {
+		r, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		content, err := io.ReadAll(r)
+		files[f.Name] = content
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hash := c.Hash
+	return files, &hash, nil
+}
+
+// commit makes files the tree of a new commit on branch
+func commit(repo *git.Repository, branch string, files map[string][]byte, author object.Signature, message string) error {
+	_, parent, err := readBranch(repo, branch)
+	if err != nil {
+		return err
+	}
+
+	tree, err := writeTree(repo, files)
+	if err != nil {
+		return err
+	}
+
+	c := &object.Commit{
+		Author:    author,
+		Committer: author,
+		Message:   message,
+		TreeHash:  tree,
+	}
+	if parent != nil {
+		c.ParentHashes = []plumbing.Hash{*parent}
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := c.Encode(obj); err != nil {
+		return err
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return err
+	}
+
+	return repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), hash))
+}
+
+func writeTree(repo *git.Repository, files map[string][]byte) (plumbing.Hash, error) {
+	var tree object.Tree
+	dirs := make(map[string]map[string][]byte)
+
+	for name, content := range files {
+		if dir, rest, ok := strings.Cut(name, "/"); ok {
+			if dirs[dir] == nil {
+				dirs[dir] = make(map[string][]byte)
+			}
+			dirs[dir][rest] = content
+			continue
+		}
+
+		obj := repo.Storer.NewEncodedObject()
+		obj.SetType(plumbing.BlobObject)
+		w, err := obj.Writer()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		w.Write(content)
+		w.Close()
+		hash, err := repo.Storer.SetEncodedObject(obj)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: hash})
+	}
+
+	for dir, files := range dirs {
+		hash, err := writeTree(repo, files)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: hash})
+	}
+
+	// git orders directories as if their names ended in a slash
+	sortName := func(e object.TreeEntry) string {
+		if e.Mode == filemode.Dir {
+			return e.Name + "/"
+		}
+		return e.Name
+	}
+	sort.Slice(tree.Entries, func(i, j int) bool {
+		return sortName(tree.Entries[i]) < sortName(tree.Entries[j])
+	})
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, e xrpcerr.XrpcError, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(e)
+}
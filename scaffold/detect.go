@@ -0,0 +1,57 @@
+package scaffold
+
+import "strings"
+
+// LicenseFiles are the names a license is looked for under, at the root of
+// a repository
+var LicenseFiles = []string{
+	"LICENSE", "LICENSE.md", "LICENSE.txt",
+	"LICENCE", "LICENCE.md", "LICENCE.txt",
+	"COPYING", "COPYING.md", "COPYING.txt",
+	"UNLICENSE",
+}
+
+// phrases that tell common licenses apart, checked in order so that the
+// more specific ones come first
+var licensePhrases = []struct {
+	spdx    string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"BSL-1.0", []string{"boost software license"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+	{"Unlicense", []string{"free and unencumbered software released into the public domain"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software", "provided that the above copyright notice"}},
+	{"0BSD", []string{"permission to use, copy, modify, and/or distribute this software"}},
+}
+
+// DetectLicense guesses the SPDX identifier of the license in text, or
+// returns an empty string if it is not one it knows
+func DetectLicense(text string) string {
+	// line breaks and indentation vary between copies
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+
+	for _, l := range licensePhrases {
+		matches := true
+		for _, p := range l.phrases {
+			if !strings.Contains(normalized, p) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return l.spdx
+		}
+	}
+
+	return ""
+}
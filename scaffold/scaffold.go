@@ -0,0 +1,109 @@
+// Package scaffold generates the files of the initial commit of a new
+// repository: a README stub, a LICENSE and a .gitignore. It also recognises
+// the license of existing repositories.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed gitignore/*.gitignore licenses/*.txt
+var templates embed.FS
+
+type Options struct {
+	// name of the repository, used as the title of the README
+	Name        string
+	Description string
+	// copyright holder named in the license
+	Holder string
+
+	// names as listed by Gitignores and Licenses, empty to leave them out
+	Gitignore string
+	License   string
+	Readme    bool
+}
+
+// Gitignores lists the .gitignore templates, by language
+func Gitignores() []string {
+	return names("gitignore", ".gitignore")
+}
+
+// Licenses lists the licenses, by SPDX identifier
+func Licenses() []string {
+	return names("licenses", ".txt")
+}
+
+func names(dir, ext string) []string {
+	entries, _ := fs.ReadDir(templates, dir)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ext))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Validate checks that the chosen templates exist
+func (o Options) Validate() error {
+	if o.Gitignore != "" && !slices.Contains(Gitignores(), o.Gitignore) {
+		return fmt.Errorf("unknown .gitignore template %q", o.Gitignore)
+	}
+	if o.License != "" && !slices.Contains(Licenses(), o.License) {
+		return fmt.Errorf("unknown license %q", o.License)
+	}
+	return nil
+}
+
+// Files renders the chosen files, keyed by their path in the repository
+func Files(o Options) (map[string][]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+
+	if o.Readme {
+		var readme strings.Builder
+		fmt.Fprintf(&readme, "# %s\n", o.Name)
+		if o.Description != "" {
+			fmt.Fprintf(&readme, "\n%s\n", o.Description)
+		}
+		files["README.md"] = []byte(readme.String())
+	}
+
+	if o.Gitignore != "" {
+		contents, err := templates.ReadFile(path.Join("gitignore", o.Gitignore+".gitignore"))
+		if err != nil {
+			return nil, err
+		}
+		files[".gitignore"] = contents
+	}
+
+	if o.License != "" {
+		tmpl, err := template.ParseFS(templates, path.Join("licenses", o.License+".txt"))
+		if err != nil {
+			return nil, err
+		}
+
+		var license bytes.Buffer
+		err = tmpl.Execute(&license, map[string]any{
+			"Year":   time.Now().Year(),
+			"Holder": o.Holder,
+		})
+		if err != nil {
+			return nil, err
+		}
+		files["LICENSE"] = license.Bytes()
+	}
+
+	return files, nil
+}
@@ -0,0 +1,60 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	files, err := Files(Options{
+		Name:        "core",
+		Description: "the tangled monorepo",
+		Holder:      "alice.tngl.sh",
+		Gitignore:   "Go",
+		License:     "MIT",
+		Readme:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(files["README.md"]); got != "# core\n\nthe tangled monorepo\n" {
+		t.Errorf("README.md: got %q", got)
+	}
+	if !strings.Contains(string(files[".gitignore"]), "go.work") {
+		t.Errorf(".gitignore: got %q", files[".gitignore"])
+	}
+	if !strings.Contains(string(files["LICENSE"]), "alice.tngl.sh") {
+		t.Errorf("LICENSE does not name the holder: %q", files["LICENSE"])
+	}
+
+	if files, _ := Files(Options{}); len(files) != 0 {
+		t.Errorf("no options: got %d files", len(files))
+	}
+
+	if _, err := Files(Options{License: "../licenses/MIT"}); err == nil {
+		t.Error("unknown license: expected an error")
+	}
+}
+
+func TestDetectLicense(t *testing.T) {
+	// every license offered for new repos is recognised again
+	for _, name := range Licenses() {
+		files, err := Files(Options{License: name, Holder: "alice.tngl.sh"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := DetectLicense(string(files["LICENSE"])); got != name {
+			t.Errorf("%s: detected %q", name, got)
+		}
+	}
+
+	apache := "                                 Apache License\n                           Version 2.0, January 2004\n"
+	if got := DetectLicense(apache); got != "Apache-2.0" {
+		t.Errorf("apache: detected %q", got)
+	}
+
+	if got := DetectLicense("all rights reserved"); got != "" {
+		t.Errorf("proprietary: detected %q", got)
+	}
+}
@@ -31,7 +31,7 @@ func (x *Xrpc) AddSecret(w http.ResponseWriter, r *http.Request) {
 
 	var data tangled.RepoAddSecret_Input
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		fail(xrpcerr.GenericError(err))
+		fail(xrpcerr.InvalidRequestError(err))
 		return
 	}
 
@@ -0,0 +1,114 @@
+// Package trailer parses the trailers at the end of commit messages, see
+// git-interpret-trailers(1).
+package trailer
+
+import (
+	"strings"
+)
+
+// Trailers that name people, as written by git commit --signoff and the
+// usual tools
+const (
+	CoAuthoredBy = "Co-authored-by"
+	ReviewedBy   = "Reviewed-by"
+	SignedOffBy  = "Signed-off-by"
+)
+
+var known = []string{CoAuthoredBy, ReviewedBy, SignedOffBy}
+
+type Trailer struct {
+	// one of the keys above, with the usual capitalisation
+	Key   string
+	Name  string
+	Email string
+}
+
+// Parse returns the known trailers in the last paragraph of message, in
+// the order they appear. Like git, the last paragraph only counts as
+// trailers if every line in it looks like one, and the subject never does.
+func Parse(message string) []Trailer {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+
+	i := strings.LastIndex(message, "\n\n")
+	if i < 0 {
+		return nil
+	}
+	block := strings.TrimSpace(message[i:])
+
+	// folded values continue on indented lines
+	var lines []string
+	for _, line := range strings.Split(block, "\n") {
+		if len(lines) > 0 && line != "" && (line[0] == ' ' || line[0] == '\t') {
+			lines[len(lines)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var trailers []Trailer
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !isToken(key) {
+			return nil
+		}
+
+		for _, k := range known {
+			if strings.EqualFold(k, key) {
+				name, email := parseIdent(value)
+				if email != "" {
+					trailers = append(trailers, Trailer{Key: k, Name: name, Email: email})
+				}
+			}
+		}
+	}
+
+	return trailers
+}
+
+// Filter returns the trailers with the given key
+func Filter(trailers []Trailer, key string) []Trailer {
+	var out []Trailer
+	for _, t := range trailers {
+		if t.Key == key {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Emails returns the email of every trailer once, for looking up who they
+// belong to
+func Emails(trailers []Trailer) []string {
+	var emails []string
+	seen := make(map[string]bool)
+	for _, t := range trailers {
+		if !seen[t.Email] {
+			seen[t.Email] = true
+			emails = append(emails, t.Email)
+		}
+	}
+	return emails
+}
+
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIdent splits "Name <email>"
+func parseIdent(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	open := strings.LastIndexByte(s, '<')
+	end := strings.LastIndexByte(s, '>')
+	if open < 0 || end < open {
+		return "", ""
+	}
+	return strings.TrimSpace(s[:open]), strings.TrimSpace(s[open+1 : end])
+}
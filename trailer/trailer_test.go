@@ -0,0 +1,43 @@
+package trailer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []Trailer
+	}{
+		{
+			"trailers",
+			"fix the thing\n\nit was broken\n\nCo-authored-by: Jane Doe <jane@example.com>\nreviewed-by: Joe <joe@example.com>\nChange-Id: I1234\nSigned-off-by: Jane Doe <jane@example.com>\n",
+			[]Trailer{
+				{CoAuthoredBy, "Jane Doe", "jane@example.com"},
+				{ReviewedBy, "Joe", "joe@example.com"},
+				{SignedOffBy, "Jane Doe", "jane@example.com"},
+			},
+		},
+		{
+			"folded value",
+			"subject\n\nCo-authored-by: Jane Doe\n <jane@example.com>\nSigned-off-by: Joe <joe@example.com>",
+			[]Trailer{
+				{CoAuthoredBy, "Jane Doe", "jane@example.com"},
+				{SignedOffBy, "Joe", "joe@example.com"},
+			},
+		},
+		{"subject only", "Co-authored-by: Jane <jane@example.com>", nil},
+		{"prose in the last paragraph", "subject\n\nthanks to\nCo-authored-by: Jane <jane@example.com>", nil},
+		{"no email", "subject\n\nCo-authored-by: Jane", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
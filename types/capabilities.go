@@ -7,4 +7,15 @@ type Capabilities struct {
 		BranchSubmissions bool `json:"branch_submissions"`
 		ForkSubmissions   bool `json:"fork_submissions"`
 	} `json:"pull_requests"`
+	Xrpc bool `json:"xrpc"`
+	// how service auth tokens are checked on xrpc calls, see
+	// xrpc/serviceauth. Knots from before these checks leave it out.
+	ServiceAuth struct {
+		// tokens must name the method they are used on
+		Lxm bool `json:"lxm"`
+		// nonces are only accepted once
+		Replay bool `json:"replay"`
+		// longest lifetime in seconds of a token without a nonce
+		MaxNoncelessLifetime int `json:"max_nonceless_lifetime"`
+	} `json:"service_auth"`
 }
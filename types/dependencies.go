@@ -0,0 +1,39 @@
+package types
+
+type Dependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Dev is set for dependencies that are only needed during development,
+	// such as devDependencies in package.json
+	Dev bool `json:"dev,omitempty"`
+}
+
+type Manifest struct {
+	// Path of the manifest relative to the repository root
+	Path         string       `json:"path"`
+	Ecosystem    string       `json:"ecosystem"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+type RepoDependenciesResponse struct {
+	Ref       string     `json:"ref,omitempty"`
+	Hash      string     `json:"hash,omitempty"`
+	Manifests []Manifest `json:"manifests,omitempty"`
+}
+
+// PackageUrl links to the registry page of a dependency, if the ecosystem
+// has a well-known registry
+func (m Manifest) PackageUrl(name string) string {
+	switch m.Ecosystem {
+	case "go":
+		return "https://pkg.go.dev/" + name
+	case "npm":
+		return "https://www.npmjs.com/package/" + name
+	case "cargo":
+		return "https://crates.io/crates/" + name
+	case "pypi":
+		return "https://pypi.org/project/" + name
+	default:
+		return ""
+	}
+}
@@ -1,25 +1,32 @@
 package types
 
 import (
+	"time"
+
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 type RepoIndexResponse struct {
-	IsEmpty        bool             `json:"is_empty"`
-	Ref            string           `json:"ref,omitempty"`
-	Readme         string           `json:"readme,omitempty"`
-	ReadmeFileName string           `json:"readme_file_name,omitempty"`
-	Commits        []*object.Commit `json:"commits,omitempty"`
-	Description    string           `json:"description,omitempty"`
-	Files          []NiceTree       `json:"files,omitempty"`
-	Branches       []Branch         `json:"branches,omitempty"`
-	Tags           []*TagReference  `json:"tags,omitempty"`
-	TotalCommits   int              `json:"total_commits,omitempty"`
+	IsEmpty        bool   `json:"is_empty"`
+	Ref            string `json:"ref,omitempty"`
+	Readme         string `json:"readme,omitempty"`
+	ReadmeFileName string `json:"readme_file_name,omitempty"`
+	// SPDX identifier of the license, empty if there is none or it is not
+	// a well known one
+	License         string           `json:"license,omitempty"`
+	LicenseFileName string           `json:"license_file_name,omitempty"`
+	Commits         []*object.Commit `json:"commits,omitempty"`
+	Description     string           `json:"description,omitempty"`
+	Files           []NiceTree       `json:"files,omitempty"`
+	Branches        []Branch         `json:"branches,omitempty"`
+	Tags            []*TagReference  `json:"tags,omitempty"`
+	TotalCommits    int              `json:"total_commits,omitempty"`
 }
 
 type RepoLogResponse struct {
 	Commits     []*object.Commit `json:"commits,omitempty"`
 	Ref         string           `json:"ref,omitempty"`
+	Path        string           `json:"path,omitempty"`
 	Description string           `json:"description,omitempty"`
 	Log         bool             `json:"log,omitempty"`
 	Total       int              `json:"total,omitempty"`
@@ -38,6 +45,11 @@ type RepoFormatPatchResponse struct {
 	FormatPatch []FormatPatch `json:"format_patch,omitempty"`
 	MergeBase   string        `json:"merge_base,omitempty"` // deprecated
 	Patch       string        `json:"patch,omitempty"`
+
+	// the changes of rev2 since its merge base with rev1, and its commits
+	// missing from rev1; unset by older knots
+	Diff    *NiceDiff        `json:"diff,omitempty"`
+	Commits []*object.Commit `json:"commits,omitempty"`
 }
 
 type RepoTreeResponse struct {
@@ -91,6 +103,22 @@ type RepoBlobResponse struct {
 	SizeHint uint64 `json:"size_hint,omitempty"`
 }
 
+// RepoBlameResponse attributes every line of a file to the commit that last
+// changed it
+type RepoBlameResponse struct {
+	Ref   string      `json:"ref,omitempty"`
+	Path  string      `json:"path,omitempty"`
+	Lines []BlameLine `json:"lines,omitempty"`
+}
+
+type BlameLine struct {
+	Hash        string    `json:"hash"`
+	AuthorName  string    `json:"author_name"`
+	AuthorEmail string    `json:"author_email"`
+	When        time.Time `json:"when"`
+	Text        string    `json:"text"`
+}
+
 type ForkStatus int
 
 const (
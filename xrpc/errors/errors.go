@@ -2,12 +2,37 @@ package errors
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+
+	indigoxrpc "github.com/bluesky-social/indigo/xrpc"
+)
+
+// tags of the errors returned by knots and spindles, clients should match on
+// these rather than on messages
+const (
+	TagGeneric         = "Generic"
+	TagMissingActorDid = "MissingActorDid"
+	TagAuth            = "Auth"
+	TagInvalidRequest  = "InvalidRequest"
+	TagInvalidRepo     = "InvalidRepo"
+	TagNotFound        = "NotFound"
+	TagRefNotFound     = "RefNotFound"
+	TagGit             = "Git"
+	TagAccessControl   = "AccessControl"
+	TagForbidden       = "Forbidden"
+	TagRepoExists      = "RepoExists"
+	TagRecordExists    = "RecordExists"
+	TagMergeConflict   = "MergeConflict"
 )
 
+// XrpcError is the envelope of every error response, details carries
+// machine readable context such as the ref that was not found
 type XrpcError struct {
-	Tag     string `json:"error"`
-	Message string `json:"message"`
+	Tag     string            `json:"error"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
 }
 
 func (x XrpcError) Error() string {
@@ -46,60 +71,134 @@ func WithError(e error) ErrOpt {
 	}
 }
 
+func WithDetail(key, value string) ErrOpt {
+	return func(xerr *XrpcError) {
+		if xerr.Details == nil {
+			xerr.Details = make(map[string]string)
+		}
+		xerr.Details[key] = value
+	}
+}
+
 var MissingActorDidError = NewXrpcError(
-	WithTag("MissingActorDid"),
+	WithTag(TagMissingActorDid),
 	WithMessage("actor DID not supplied"),
 )
 
 var AuthError = func(err error) XrpcError {
 	return NewXrpcError(
-		WithTag("Auth"),
+		WithTag(TagAuth),
 		WithError(fmt.Errorf("signature verification failed: %w", err)),
 	)
 }
 
 var InvalidRepoError = func(r string) XrpcError {
 	return NewXrpcError(
-		WithTag("InvalidRepo"),
+		WithTag(TagInvalidRepo),
 		WithError(fmt.Errorf("supplied at-uri is not a repo: %s", r)),
+		WithDetail("repo", r),
+	)
+}
+
+var InvalidRequestError = func(e error) XrpcError {
+	return NewXrpcError(
+		WithTag(TagInvalidRequest),
+		WithError(e),
+	)
+}
+
+var NotFoundError = NewXrpcError(
+	WithTag(TagNotFound),
+	WithMessage("not found"),
+)
+
+var RefNotFoundError = func(ref string) XrpcError {
+	return NewXrpcError(
+		WithTag(TagRefNotFound),
+		WithError(fmt.Errorf("error resolving revision %s", ref)),
+		WithDetail("ref", ref),
 	)
 }
 
 var GitError = func(e error) XrpcError {
 	return NewXrpcError(
-		WithTag("Git"),
+		WithTag(TagGit),
 		WithError(fmt.Errorf("git error: %w", e)),
 	)
 }
 
 var AccessControlError = func(d string) XrpcError {
 	return NewXrpcError(
-		WithTag("AccessControl"),
+		WithTag(TagAccessControl),
 		WithError(fmt.Errorf("DID does not have sufficent access permissions for this operation: %s", d)),
+		WithDetail("did", d),
+	)
+}
+
+var ForbiddenError = func(e error) XrpcError {
+	return NewXrpcError(
+		WithTag(TagForbidden),
+		WithError(e),
 	)
 }
 
 var RepoExistsError = func(r string) XrpcError {
 	return NewXrpcError(
-		WithTag("RepoExists"),
+		WithTag(TagRepoExists),
 		WithError(fmt.Errorf("repo already exists: %s", r)),
+		WithDetail("repo", r),
 	)
 }
 
 var RecordExistsError = func(r string) XrpcError {
 	return NewXrpcError(
-		WithTag("RecordExists"),
-		WithError(fmt.Errorf("repo already exists: %s", r)),
+		WithTag(TagRecordExists),
+		WithError(fmt.Errorf("record already exists: %s", r)),
+		WithDetail("rkey", r),
 	)
 }
 
+var MergeConflictError = func(msg string, files ...string) XrpcError {
+	opts := []ErrOpt{
+		WithTag(TagMergeConflict),
+		WithMessage(fmt.Sprintf("Merge failed due to conflicts: %s", msg)),
+	}
+	if len(files) > 0 {
+		opts = append(opts, WithDetail("files", strings.Join(files, ",")))
+	}
+	return NewXrpcError(opts...)
+}
+
 func GenericError(err error) XrpcError {
 	return NewXrpcError(
-		WithTag("Generic"),
+		WithTag(TagGeneric),
 		WithError(err),
 	)
 }
 
+// FromError recovers the XrpcError returned by a knot or spindle, whether it
+// was decoded by an indigo client or by hand. Details do not survive the
+// indigo client.
+func FromError(err error) (XrpcError, bool) {
+	var xerr XrpcError
+	if errors.As(err, &xerr) {
+		return xerr, true
+	}
+
+	var ierr *indigoxrpc.XRPCError
+	if errors.As(err, &ierr) && ierr.ErrStr != "" {
+		return XrpcError{Tag: ierr.ErrStr, Message: ierr.Message}, true
+	}
+
+	return XrpcError{}, false
+}
+
+// Is tells whether err is an XrpcError tagged with tag
+func Is(err error, tag string) bool {
+	xerr, ok := FromError(err)
+	return ok && xerr.Tag == tag
+}
+
 func Unmarshal(errStr string) (XrpcError, error) {
 	var xerr XrpcError
 	err := json.Unmarshal([]byte(errStr), &xerr)
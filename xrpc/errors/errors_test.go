@@ -0,0 +1,55 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	indigoxrpc "github.com/bluesky-social/indigo/xrpc"
+)
+
+func TestFromError(t *testing.T) {
+	sent := RefNotFoundError("main")
+
+	body, err := json.Marshal(sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// what an indigo client makes of the response
+	var ierr indigoxrpc.XRPCError
+	if err := json.Unmarshal(body, &ierr); err != nil {
+		t.Fatal(err)
+	}
+	received := fmt.Errorf("calling knot: %w", &indigoxrpc.Error{StatusCode: 400, Wrapped: &ierr})
+
+	got, ok := FromError(received)
+	if !ok {
+		t.Fatal("expected an XrpcError")
+	}
+	if got.Tag != TagRefNotFound || got.Message != sent.Message {
+		t.Errorf("got %+v, want %+v", got, sent)
+	}
+
+	if !Is(received, TagRefNotFound) {
+		t.Error("expected error to be tagged RefNotFound")
+	}
+	if Is(received, TagNotFound) {
+		t.Error("expected error not to be tagged NotFound")
+	}
+
+	var decoded XrpcError
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Details["ref"] != "main" {
+		t.Errorf("expected ref detail, got %v", decoded.Details)
+	}
+	if !Is(fmt.Errorf("wrapped: %w", decoded), TagRefNotFound) {
+		t.Error("expected wrapped XrpcError to be tagged RefNotFound")
+	}
+
+	if _, ok := FromError(fmt.Errorf("plain")); ok {
+		t.Error("expected plain errors not to be XrpcErrors")
+	}
+}
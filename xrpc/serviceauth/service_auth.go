@@ -2,30 +2,59 @@ package serviceauth
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bluesky-social/indigo/atproto/auth"
+	"github.com/bluesky-social/indigo/atproto/syntax"
 	"tangled.sh/tangled.sh/core/idresolver"
 	xrpcerr "tangled.sh/tangled.sh/core/xrpc/errors"
 )
 
 const ActorDid string = "ActorDid"
 
+const (
+	// tokens without a nonce cannot be told apart when replayed, so they
+	// are only accepted if they were issued to live this long at most
+	MaxNoncelessLifetime = time.Minute
+
+	pruneInterval = time.Minute
+)
+
 type ServiceAuth struct {
 	logger      *slog.Logger
 	resolver    *idresolver.Resolver
 	audienceDid string
+
+	// nonces of tokens that have been used, until they expire
+	mu   sync.Mutex
+	seen map[string]time.Time
 }
 
 func NewServiceAuth(logger *slog.Logger, resolver *idresolver.Resolver, audienceDid string) *ServiceAuth {
-	return &ServiceAuth{
+	sa := &ServiceAuth{
 		logger:      logger,
 		resolver:    resolver,
 		audienceDid: audienceDid,
+		seen:        make(map[string]time.Time),
 	}
+
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sa.prune(time.Now())
+		}
+	}()
+
+	return sa
 }
 
 func (sa *ServiceAuth) VerifyServiceAuth(next http.Handler) http.Handler {
@@ -40,13 +69,27 @@ func (sa *ServiceAuth) VerifyServiceAuth(next http.Handler) http.Handler {
 			Dir:      sa.resolver.Directory(),
 		}
 
-		did, err := s.Validate(r.Context(), token, nil)
+		// tokens are bound to the method they were issued for, so that a
+		// token for one call cannot be used to make another
+		lxm, err := methodFromPath(r.URL.Path)
+		if err != nil {
+			writeError(w, xrpcerr.AuthError(err), http.StatusForbidden)
+			return
+		}
+
+		did, err := s.Validate(r.Context(), token, &lxm)
 		if err != nil {
 			l.Error("signature verification failed", "err", err)
 			writeError(w, xrpcerr.AuthError(err), http.StatusForbidden)
 			return
 		}
 
+		if err := sa.checkReplay(token); err != nil {
+			l.Error("rejected replayed token", "did", did, "err", err)
+			writeError(w, xrpcerr.AuthError(err), http.StatusForbidden)
+			return
+		}
+
 		r = r.WithContext(
 			context.WithValue(r.Context(), ActorDid, did),
 		)
@@ -55,6 +98,82 @@ func (sa *ServiceAuth) VerifyServiceAuth(next http.Handler) http.Handler {
 	})
 }
 
+// methodFromPath extracts the NSID of the method being called from a path like
+// /xrpc/sh.tangled.repo.create
+func methodFromPath(path string) (syntax.NSID, error) {
+	_, method, ok := strings.Cut(path, "/xrpc/")
+	if !ok {
+		return "", fmt.Errorf("not an xrpc endpoint: %s", path)
+	}
+	method, _, _ = strings.Cut(method, "/")
+	return syntax.ParseNSID(method)
+}
+
+// checkReplay rejects tokens that have been used before. Tokens are minted by
+// the caller's PDS with a random nonce, tokens without one are only let
+// through if they are short-lived.
+//
+// this must only be called on tokens that have been validated.
+func (sa *ServiceAuth) checkReplay(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+
+	var claims struct {
+		Iss string `json:"iss"`
+		Jti string `json:"jti"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+
+	if claims.Jti == "" {
+		if claims.Iat == 0 {
+			return fmt.Errorf("token has neither a nonce nor an issue time")
+		}
+		if time.Duration(claims.Exp-claims.Iat)*time.Second > MaxNoncelessLifetime {
+			return fmt.Errorf("token without a nonce must not be valid for more than %s", MaxNoncelessLifetime)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	key := fmt.Sprintf("%s:%s", claims.Iss, claims.Jti)
+
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	if exp, ok := sa.seen[key]; ok && now.Before(exp) {
+		return fmt.Errorf("token has already been used")
+	}
+
+	// keep the nonce around a little longer than the token is valid, to
+	// account for clock skew
+	sa.seen[key] = time.Unix(claims.Exp, 0).Add(time.Minute)
+
+	return nil
+}
+
+// prune forgets nonces of tokens that have expired
+func (sa *ServiceAuth) prune(now time.Time) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	for k, exp := range sa.seen {
+		if now.After(exp) {
+			delete(sa.seen, k)
+		}
+	}
+}
+
 // this is slightly different from http_util::write_error to follow the spec:
 //
 // the json object returned must include an "error" and a "message"
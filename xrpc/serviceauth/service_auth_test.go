@@ -0,0 +1,51 @@
+package serviceauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// unsigned builds a token with the given claims, checkReplay does not look
+// at signatures
+func unsigned(t *testing.T, claims map[string]any) string {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func TestCheckReplay(t *testing.T) {
+	sa := &ServiceAuth{seen: make(map[string]time.Time)}
+	now := time.Now().Unix()
+
+	token := unsigned(t, map[string]any{"iss": "did:plc:alice", "jti": "abc", "iat": now, "exp": now + 60})
+	if err := sa.checkReplay(token); err != nil {
+		t.Fatal(err)
+	}
+	if err := sa.checkReplay(token); err == nil {
+		t.Error("replayed token was accepted")
+	}
+
+	short := unsigned(t, map[string]any{"iss": "did:plc:alice", "iat": now, "exp": now + 30})
+	if err := sa.checkReplay(short); err != nil {
+		t.Errorf("short-lived token without a nonce was rejected: %v", err)
+	}
+
+	long := unsigned(t, map[string]any{"iss": "did:plc:alice", "iat": now, "exp": now + 3600})
+	if err := sa.checkReplay(long); err == nil {
+		t.Error("long-lived token without a nonce was accepted")
+	}
+
+	bare := unsigned(t, map[string]any{"iss": "did:plc:alice", "exp": now + 30})
+	if err := sa.checkReplay(bare); err == nil {
+		t.Error("token without a nonce or issue time was accepted")
+	}
+
+	sa.prune(time.Now().Add(time.Hour))
+	if len(sa.seen) != 0 {
+		t.Errorf("expired nonces were kept: %v", sa.seen)
+	}
+}